@@ -6,10 +6,12 @@
 package azureblob
 
 import (
+	"bytes"
 	"context"
 	"encoding/base64"
 	"encoding/hex"
 	"encoding/json"
+	"encoding/xml"
 	"errors"
 	"fmt"
 	"io"
@@ -26,12 +28,14 @@ import (
 	"github.com/Azure/azure-storage-blob-go/azblob"
 	"github.com/Azure/go-autorest/autorest/adal"
 	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fs/accounting"
 	"github.com/rclone/rclone/fs/config"
 	"github.com/rclone/rclone/fs/config/configmap"
 	"github.com/rclone/rclone/fs/config/configstruct"
 	"github.com/rclone/rclone/fs/fserrors"
 	"github.com/rclone/rclone/fs/fshttp"
 	"github.com/rclone/rclone/fs/hash"
+	"github.com/rclone/rclone/fs/operations"
 	"github.com/rclone/rclone/fs/walk"
 	"github.com/rclone/rclone/lib/bucket"
 	"github.com/rclone/rclone/lib/encoder"
@@ -59,10 +63,17 @@ const (
 	emulatorBlobEndpoint = "http://127.0.0.1:10000/devstoreaccount1"
 	memoryPoolFlushTime  = fs.Duration(time.Minute) // flush the cached buffers after this long
 	memoryPoolUseMmap    = false
+	// Largest blob which can be copied with the synchronous Copy Blob
+	// From URL, bigger blobs are copied asynchronously
+	maxCopyFromURLSize = 256 * fs.Mebi
+	// How long the SAS made for copying a blob from another account
+	// is valid for. Asynchronous copies need it until they finish.
+	copySASExpiry = 24 * time.Hour
 )
 
 var (
 	errCantUpdateArchiveTierBlobs = fserrors.NoRetryError(errors.New("can't update archive tier blob without --azureblob-archive-tier-delete"))
+	errNotWithVersionAt           = fserrors.NoRetryError(errors.New("can't modify or delete files in --azureblob-version-at mode"))
 )
 
 // Register with Fs
@@ -71,6 +82,7 @@ func init() {
 		Name:        "azureblob",
 		Description: "Microsoft Azure Blob Storage",
 		NewFs:       NewFs,
+		CommandHelp: commandHelp,
 		Options: []fs.Option{{
 			Name: "account",
 			Help: "Storage Account Name.\n\nLeave blank to use SAS URL or Emulator.",
@@ -260,6 +272,56 @@ This option controls how often unused buffers will be removed from the pool.`,
 			Help:     `If set, do not do HEAD before GET when getting objects.`,
 			Default:  false,
 			Advanced: true,
+		}, {
+			Name: "blob_tags",
+			Help: `Blob index tags to set on uploaded blobs.
+
+This is a comma separated list of key=value pairs, e.g.
+
+    --azureblob-blob-tags project=alpha,owner=finance
+
+Blob index tags can be used to find blobs with the find-tags backend
+command. Azure allows at most 10 tags on a blob.`,
+			Default:  fs.CommaSepList{},
+			Advanced: true,
+		}, {
+			Name: "use_copy_blob_from_url",
+			Help: `Use Copy Blob From URL for server-side copies from other accounts.
+
+Normally copies between remotes with different configs, for example
+between two storage accounts, are streamed through rclone. If this is
+set on the destination then rclone copies them server-side instead
+by giving Azure the URL of the source blob with a read only SAS.
+
+The source must be able to make the SAS, so it must be configured
+with an account and key, or with a SAS URL which allows reading.
+Copies from other sources are streamed as usual.
+
+Blobs up to 256 MiB are copied synchronously with Copy Blob From URL.
+Bigger blobs are copied with an asynchronous Copy Blob and rclone
+waits for them to finish.`,
+			Default:  false,
+			Advanced: true,
+		}, {
+			Name: "version_at",
+			Help: `Show blob versions as they were at the specified time.
+
+This needs blob versioning to be enabled on the storage account. All
+the versions of the blobs are listed and the one which was current at
+the time is shown, so a whole container can be restored to how it
+was with rclone copy.
+
+The parameter should be a date, "2006-01-02", datetime "2006-01-02
+15:04:05" or a duration for that long ago, eg "100d" or "1h".
+
+Azure doesn't record when a blob is deleted, so deleted blobs are
+shown as they were before they were deleted, including when the time
+is between a blob being deleted and uploaded again.
+
+Note that when using this no file write operations are permitted,
+so you can't upload files or delete them.`,
+			Default:  fs.Time{},
+			Advanced: true,
 		}},
 	})
 }
@@ -287,6 +349,9 @@ type Options struct {
 	Enc                  encoder.MultiEncoder `config:"encoding"`
 	PublicAccess         string               `config:"public_access"`
 	NoHeadObject         bool                 `config:"no_head_object"`
+	BlobTags             fs.CommaSepList      `config:"blob_tags"`
+	UseCopyBlobFromURL   bool                 `config:"use_copy_blob_from_url"`
+	VersionAt            fs.Time              `config:"version_at"`
 }
 
 // Fs represents a remote azure server
@@ -298,6 +363,7 @@ type Fs struct {
 	features      *fs.Features                    // optional features
 	client        *http.Client                    // http client we are using
 	svcURL        *azblob.ServiceURL              // reference to serviceURL
+	pipe          pipeline.Pipeline               // pipeline used by svcURL
 	cntURLcacheMu sync.Mutex                      // mutex to protect cntURLcache
 	cntURLcache   map[string]*azblob.ContainerURL // reference to containerURL per container
 	rootContainer string                          // container part of root (if any)
@@ -309,6 +375,8 @@ type Fs struct {
 	uploadToken   *pacer.TokenDispenser           // control concurrency
 	pool          *pool.Pool                      // memory pool
 	publicAccess  azblob.PublicAccessType         // Container Public Access Level
+	blobTags      azblob.BlobTagsMap              // index tags to set on uploaded blobs
+	sharedKey     *azblob.SharedKeyCredential     // account key credential used to sign SAS if set
 }
 
 // Object describes an azure object
@@ -321,6 +389,7 @@ type Object struct {
 	mimeType   string                // Content-Type of the object
 	accessTier azblob.AccessTierType // Blob Access Tier
 	meta       map[string]string     // blob metadata
+	versionID  string                // version of the blob shown with --azureblob-version-at
 }
 
 // ------------------------------------------------------------
@@ -395,6 +464,43 @@ func validatePublicAccess(publicAccess string) bool {
 	}
 }
 
+// Limits on blob index tags
+const (
+	maxBlobTags        = 10
+	maxBlobTagKeyLen   = 128
+	maxBlobTagValueLen = 256
+)
+
+// parseBlobTags parses the key=value pairs in tags into a
+// BlobTagsMap checking they are within the limits Azure imposes
+func parseBlobTags(tags fs.CommaSepList) (azblob.BlobTagsMap, error) {
+	if len(tags) == 0 {
+		return nil, nil
+	}
+	if len(tags) > maxBlobTags {
+		return nil, fmt.Errorf("too many blob tags: %d given but Azure allows at most %d", len(tags), maxBlobTags)
+	}
+	blobTags := make(azblob.BlobTagsMap, len(tags))
+	for _, tag := range tags {
+		equals := strings.IndexRune(tag, '=')
+		if equals < 0 {
+			return nil, fmt.Errorf("blob tag %q must be in the form key=value", tag)
+		}
+		key, value := tag[:equals], tag[equals+1:]
+		if key == "" || len(key) > maxBlobTagKeyLen {
+			return nil, fmt.Errorf("blob tag key %q must be 1 to %d characters long", key, maxBlobTagKeyLen)
+		}
+		if len(value) > maxBlobTagValueLen {
+			return nil, fmt.Errorf("blob tag value for %q must be at most %d characters long", key, maxBlobTagValueLen)
+		}
+		if _, found := blobTags[key]; found {
+			return nil, fmt.Errorf("blob tag key %q given more than once", key)
+		}
+		blobTags[key] = value
+	}
+	return blobTags, nil
+}
+
 // retryErrorCodes is a slice of error codes that we will retry
 var retryErrorCodes = []int{
 	401, // Unauthorized (e.g. "Token has expired")
@@ -523,7 +629,8 @@ func (f *Fs) newPipeline(c azblob.Credential, o azblob.PipelineOptions) pipeline
 		pipeline.MethodFactoryMarker(), // indicates at what stage in the pipeline the method factory is invoked
 		azblob.NewRequestLogPolicyFactory(o.RequestLog),
 	}
-	return pipeline.NewPipeline(factories, pipeline.Options{HTTPSender: httpClientFactory(f.client), Log: o.Log})
+	f.pipe = pipeline.NewPipeline(factories, pipeline.Options{HTTPSender: httpClientFactory(f.client), Log: o.Log})
+	return f.pipe
 }
 
 // setRoot changes the root of the Fs
@@ -564,6 +671,11 @@ func NewFs(ctx context.Context, name, root string, m configmap.Mapper) (fs.Fs, e
 			string(azblob.PublicAccessBlob), string(azblob.PublicAccessContainer))
 	}
 
+	blobTags, err := parseBlobTags(opt.BlobTags)
+	if err != nil {
+		return nil, fmt.Errorf("Azure Blob: %w", err)
+	}
+
 	ci := fs.GetConfig(ctx)
 	f := &Fs{
 		name:        name,
@@ -583,15 +695,19 @@ func NewFs(ctx context.Context, name, root string, m configmap.Mapper) (fs.Fs, e
 		),
 	}
 	f.publicAccess = azblob.PublicAccessType(opt.PublicAccess)
+	f.blobTags = blobTags
 	f.imdsPacer.SetRetries(5) // per IMDS documentation
 	f.setRoot(root)
 	f.features = (&fs.Features{
 		ReadMimeType:      true,
 		WriteMimeType:     true,
+		WriteMetadata:     true,
 		BucketBased:       true,
 		BucketBasedRootOK: true,
 		SetTier:           true,
 		GetTier:           true,
+
+		ServerSideAcrossConfigs: opt.UseCopyBlobFromURL,
 	}).Fill(ctx, f)
 
 	var (
@@ -608,6 +724,7 @@ func NewFs(ctx context.Context, name, root string, m configmap.Mapper) (fs.Fs, e
 		if err != nil {
 			return nil, fmt.Errorf("failed to make azure storage url from account and endpoint: %w", err)
 		}
+		f.sharedKey = credential
 		pipeline := f.newPipeline(credential, azblob.PipelineOptions{Retry: azblob.RetryOptions{TryTimeout: maxTryTimeout}})
 		serviceURL = azblob.NewServiceURL(*u, pipeline)
 	case opt.UseMSI:
@@ -690,6 +807,7 @@ func NewFs(ctx context.Context, name, root string, m configmap.Mapper) (fs.Fs, e
 		if err != nil {
 			return nil, fmt.Errorf("failed to make azure storage url from account and endpoint: %w", err)
 		}
+		f.sharedKey = credential
 		pipeline := f.newPipeline(credential, azblob.PipelineOptions{Retry: azblob.RetryOptions{TryTimeout: maxTryTimeout}})
 		serviceURL = azblob.NewServiceURL(*u, pipeline)
 	case opt.SASURL != "":
@@ -864,6 +982,29 @@ func (f *Fs) list(ctx context.Context, container, directory, prefix string, addC
 		Prefix:     directory,
 		MaxResults: int32(maxResults),
 	}
+	// With --azureblob-version-at list all the versions and send
+	// the one of each blob which was current at the time
+	var picker *versionPicker
+	if f.opt.VersionAt.IsSet() {
+		options.Details.Versions = true
+		picker = &versionPicker{at: time.Time(f.opt.VersionAt)}
+	}
+	sendBlob := func(file *azblob.BlobItemInternal) error {
+		remote := f.opt.Enc.ToStandardPath(file.Name)
+		if !strings.HasPrefix(remote, prefix) {
+			fs.Debugf(f, "Odd name received %q", remote)
+			return nil
+		}
+		remote = remote[len(prefix):]
+		if isDirectoryMarker(*file.Properties.ContentLength, file.Metadata, remote) {
+			return nil // skip directory marker
+		}
+		if addContainer {
+			remote = path.Join(container, remote)
+		}
+		// Send object
+		return fn(remote, file, false)
+	}
 	for marker := (azblob.Marker{}); marker.NotDone(); {
 		var response *azblob.ListBlobsHierarchySegmentResponse
 		err := f.pacer.Call(func() (bool, error) {
@@ -887,20 +1028,13 @@ func (f *Fs) list(ctx context.Context, container, directory, prefix string, addC
 			// if prefix != "" && !strings.HasPrefix(file.Name, prefix) {
 			// 	return nil
 			// }
-			remote := f.opt.Enc.ToStandardPath(file.Name)
-			if !strings.HasPrefix(remote, prefix) {
-				fs.Debugf(f, "Odd name received %q", remote)
-				continue
-			}
-			remote = remote[len(prefix):]
-			if isDirectoryMarker(*file.Properties.ContentLength, file.Metadata, remote) {
-				continue // skip directory marker
-			}
-			if addContainer {
-				remote = path.Join(container, remote)
+			if picker != nil {
+				file = picker.add(file)
+				if file == nil {
+					continue
+				}
 			}
-			// Send object
-			err = fn(remote, file, false)
+			err = sendBlob(file)
 			if err != nil {
 				return err
 			}
@@ -924,9 +1058,65 @@ func (f *Fs) list(ctx context.Context, container, directory, prefix string, addC
 			}
 		}
 	}
+	// Send the version of the last blob
+	if picker != nil {
+		if file := picker.flush(); file != nil {
+			return sendBlob(file)
+		}
+	}
 	return nil
 }
 
+// versionTime returns when the version of a blob in a listing became
+// the current one
+//
+// This is the version ID, or the last modified time for blobs with no
+// version ID which were written before versioning was enabled.
+func versionTime(info *azblob.BlobItemInternal) time.Time {
+	if info.VersionID != nil {
+		t, err := time.Parse(time.RFC3339Nano, *info.VersionID)
+		if err == nil {
+			return t
+		}
+		fs.Debugf(nil, "Bad version ID %q for %q: %v", *info.VersionID, info.Name, err)
+	}
+	return info.Properties.LastModified
+}
+
+// versionPicker picks the version of each blob which was current at
+// --azureblob-version-at from a listing of all the versions, where the
+// versions of each blob are listed together.
+type versionPicker struct {
+	at     time.Time                // the time to pick the versions at
+	name   string                   // name of the blob being picked
+	best   *azblob.BlobItemInternal // version of it current at the time so far
+	bestAt time.Time                // when best became current
+}
+
+// add the version info of a blob to the picker
+//
+// If info is for a different blob this returns the version picked for
+// the previous one, or nil if none of its versions existed at the time.
+func (p *versionPicker) add(info *azblob.BlobItemInternal) (picked *azblob.BlobItemInternal) {
+	if info.Name != p.name {
+		picked = p.flush()
+		p.name = info.Name
+	}
+	t := versionTime(info)
+	if !t.After(p.at) && (p.best == nil || t.After(p.bestAt)) {
+		p.best, p.bestAt = info, t
+	}
+	return picked
+}
+
+// flush returns the version picked for the last blob added, or nil if
+// there wasn't one, and resets the picker
+func (p *versionPicker) flush() (picked *azblob.BlobItemInternal) {
+	picked = p.best
+	p.name, p.best, p.bestAt = "", nil, time.Time{}
+	return picked
+}
+
 // Convert a list item into a DirEntry
 func (f *Fs) itemToDirEntry(remote string, object *azblob.BlobItemInternal, isDirectory bool) (fs.DirEntry, error) {
 	if isDirectory {
@@ -1133,9 +1323,59 @@ func (f *Fs) PutStream(ctx context.Context, in io.Reader, src fs.ObjectInfo, opt
 }
 
 // Mkdir creates the container if it doesn't exist
+//
+// If --create-dir-markers is set it creates a directory marker for
+// dir too
 func (f *Fs) Mkdir(ctx context.Context, dir string) error {
-	container, _ := f.split(dir)
-	return f.makeContainer(ctx, container)
+	container, directory := f.split(dir)
+	err := f.makeContainer(ctx, container)
+	if err != nil || directory == "" || !fs.GetConfig(ctx).CreateDirMarkers {
+		return err
+	}
+	return f.putDirMarker(ctx, container, directory)
+}
+
+// putDirMarker creates a zero length blob called directory + "/"
+// which keeps the directory in existence when it is empty
+func (f *Fs) putDirMarker(ctx context.Context, container, directory string) error {
+	markerPath := directory + "/"
+	blob := f.getBlobReference(container, markerPath).ToBlockBlobURL()
+	metadata := azblob.Metadata{"hdi_isfolder": "true"}
+	err := f.pacer.Call(func() (bool, error) {
+		_, err := blob.Upload(ctx, bytes.NewReader(nil), azblob.BlobHTTPHeaders{}, metadata, azblob.BlobAccessConditions{}, azblob.AccessTierNone, nil, azblob.ClientProvidedKeyOptions{})
+		return f.shouldRetry(ctx, err)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create directory marker: %w", err)
+	}
+	fs.Debugf(f, "Created directory marker %q", markerPath)
+	return nil
+}
+
+// removeDirMarker removes the directory marker for directory
+//
+// Returns an error if the directory isn't empty
+func (f *Fs) removeDirMarker(ctx context.Context, container, directory string) error {
+	empty := true
+	err := f.list(ctx, container, directory, f.rootDirectory, f.rootContainer == "", false, 1, func(remote string, object *azblob.BlobItemInternal, isDirectory bool) error {
+		empty = false
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if !empty {
+		return fs.ErrorDirectoryNotEmpty
+	}
+	blob := f.getBlobReference(container, directory+"/")
+	return f.pacer.Call(func() (bool, error) {
+		_, err := blob.Delete(ctx, azblob.DeleteSnapshotsOptionNone, azblob.BlobAccessConditions{})
+		if storageErr, ok := err.(azblob.StorageError); ok && storageErr.ServiceCode() == azblob.ServiceCodeBlobNotFound {
+			// No marker so nothing to remove
+			return false, nil
+		}
+		return f.shouldRetry(ctx, err)
+	})
 }
 
 // makeContainer creates the container if it doesn't exist
@@ -1212,9 +1452,15 @@ func (f *Fs) deleteContainer(ctx context.Context, container string) error {
 
 // Rmdir deletes the container if the fs is at the root
 //
+// If --create-dir-markers is set it removes the directory marker for
+// dir if it isn't the root.
+//
 // Returns an error if it isn't empty
 func (f *Fs) Rmdir(ctx context.Context, dir string) error {
 	container, directory := f.split(dir)
+	if container != "" && directory != "" && fs.GetConfig(ctx).CreateDirMarkers {
+		return f.removeDirMarker(ctx, container, directory)
+	}
 	if container == "" || directory != "" {
 		return nil
 	}
@@ -1251,15 +1497,15 @@ func (f *Fs) Purge(ctx context.Context, dir string) error {
 //
 // It returns the destination Object and a possible error
 //
-// Will only be called if src.Fs().Name() == f.Name()
+// Will only be called if src.Fs().Name() == f.Name() unless
+// --azureblob-use-copy-blob-from-url is set
 //
 // If it isn't possible then return fs.ErrorCantCopy
 func (f *Fs) Copy(ctx context.Context, src fs.Object, remote string) (fs.Object, error) {
-	dstContainer, dstPath := f.split(remote)
-	err := f.makeContainer(ctx, dstContainer)
-	if err != nil {
-		return nil, err
+	if f.opt.VersionAt.IsSet() {
+		return nil, errNotWithVersionAt
 	}
+	dstContainer, dstPath := f.split(remote)
 	srcObj, ok := src.(*Object)
 	if !ok {
 		fs.Debugf(src, "Can't copy - not same remote type")
@@ -1268,16 +1514,43 @@ func (f *Fs) Copy(ctx context.Context, src fs.Object, remote string) (fs.Object,
 	dstBlobURL := f.getBlobReference(dstContainer, dstPath)
 	srcBlobURL := srcObj.getBlobReference()
 
-	source, err := url.Parse(srcBlobURL.String())
+	source := srcBlobURL.URL()
+	withSAS := false
+	if f.opt.UseCopyBlobFromURL {
+		var err error
+		source, withSAS, err = srcObj.fs.sasURL(srcBlobURL)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if !withSAS && srcObj.fs.name != f.name {
+		// the other account can't read the source without a SAS
+		fs.Debugf(src, "Can't copy - can't make a SAS URL for source")
+		return nil, fs.ErrorCantCopy
+	}
+	err := f.makeContainer(ctx, dstContainer)
 	if err != nil {
 		return nil, err
 	}
 
 	options := azblob.BlobAccessConditions{}
+	tier := azblob.AccessTierType(f.opt.AccessTier)
+	if withSAS && srcObj.size <= int64(maxCopyFromURLSize) {
+		// small enough to copy synchronously
+		err = f.pacer.Call(func() (bool, error) {
+			_, err = dstBlobURL.ToBlockBlobURL().CopyFromURL(ctx, source, nil, azblob.ModifiedAccessConditions{}, options, nil, tier, nil)
+			return f.shouldRetry(ctx, err)
+		})
+		if err != nil {
+			return nil, err
+		}
+		return f.NewObject(ctx, remote)
+	}
+
 	var startCopy *azblob.BlobStartCopyFromURLResponse
 
 	err = f.pacer.Call(func() (bool, error) {
-		startCopy, err = dstBlobURL.StartCopyFromURL(ctx, *source, nil, azblob.ModifiedAccessConditions{}, options, azblob.AccessTierType(f.opt.AccessTier), nil)
+		startCopy, err = dstBlobURL.StartCopyFromURL(ctx, source, nil, azblob.ModifiedAccessConditions{}, options, tier, nil)
 		return f.shouldRetry(ctx, err)
 	})
 	if err != nil {
@@ -1297,6 +1570,32 @@ func (f *Fs) Copy(ctx context.Context, src fs.Object, remote string) (fs.Object,
 	return f.NewObject(ctx, remote)
 }
 
+// sasURL returns the URL of blob, which must be in f, with a read only
+// SAS so it can be copied by another account.
+//
+// It returns false if f can't make a SAS as it isn't using an account
+// key or a SAS URL.
+func (f *Fs) sasURL(blob azblob.BlobURL) (u url.URL, ok bool, err error) {
+	if f.opt.SASURL != "" {
+		// the blob URL already has the SAS of the container
+		return blob.URL(), true, nil
+	}
+	if f.sharedKey == nil {
+		return u, false, nil
+	}
+	parts := azblob.NewBlobURLParts(blob.URL())
+	parts.SAS, err = azblob.BlobSASSignatureValues{
+		ExpiryTime:    time.Now().UTC().Add(copySASExpiry),
+		ContainerName: parts.ContainerName,
+		BlobName:      parts.BlobName,
+		Permissions:   azblob.BlobSASPermissions{Read: true}.String(),
+	}.NewSASQueryParameters(f.sharedKey)
+	if err != nil {
+		return u, false, fmt.Errorf("failed to make SAS for copy: %w", err)
+	}
+	return parts.URL(), true, nil
+}
+
 func (f *Fs) getMemoryPool(size int64) *pool.Pool {
 	if size == int64(f.opt.ChunkSize) {
 		return f.pool
@@ -1439,13 +1738,23 @@ func (o *Object) decodeMetaDataFromBlob(info *azblob.BlobItemInternal) (err erro
 	o.modTime = info.Properties.LastModified
 	o.accessTier = info.Properties.AccessTier
 	o.setMetadata(metadata)
+	if o.fs.opt.VersionAt.IsSet() && info.VersionID != nil {
+		o.versionID = *info.VersionID
+	}
 	return nil
 }
 
 // getBlobReference creates an empty blob reference with no metadata
+//
+// This refers to the version of the blob shown if using
+// --azureblob-version-at
 func (o *Object) getBlobReference() azblob.BlobURL {
 	container, directory := o.split()
-	return o.fs.getBlobReference(container, directory)
+	blob := o.fs.getBlobReference(container, directory)
+	if o.versionID != "" {
+		blob = blob.WithVersionID(o.versionID)
+	}
+	return blob
 }
 
 // clearMetaData clears enough metadata so readMetaData will re-read it
@@ -1468,11 +1777,14 @@ func (o *Object) readMetaData() (err error) {
 	if !o.modTime.IsZero() {
 		return nil
 	}
+	ctx := context.Background()
+	if o.fs.opt.VersionAt.IsSet() {
+		return o.readMetaDataVersionAt(ctx)
+	}
 	blob := o.getBlobReference()
 
 	// Read metadata (this includes metadata)
 	options := azblob.BlobAccessConditions{}
-	ctx := context.Background()
 	var blobProperties *azblob.BlobGetPropertiesResponse
 	err = o.fs.pacer.Call(func() (bool, error) {
 		blobProperties, err = blob.GetProperties(ctx, options, azblob.ClientProvidedKeyOptions{})
@@ -1489,6 +1801,49 @@ func (o *Object) readMetaData() (err error) {
 	return o.decodeMetaDataFromPropertiesResponse(blobProperties)
 }
 
+// readMetaDataVersionAt reads the metadata of the version of the blob
+// which was current at --azureblob-version-at by listing its versions
+func (o *Object) readMetaDataVersionAt(ctx context.Context) (err error) {
+	container, containerPath := o.split()
+	options := azblob.ListBlobsSegmentOptions{
+		Details: azblob.BlobListingDetails{
+			Metadata: true,
+			Versions: true,
+		},
+		Prefix: containerPath,
+	}
+	picker := versionPicker{at: time.Time(o.fs.opt.VersionAt)}
+listing:
+	for marker := (azblob.Marker{}); marker.NotDone(); {
+		var response *azblob.ListBlobsFlatSegmentResponse
+		err = o.fs.pacer.Call(func() (bool, error) {
+			response, err = o.fs.cntURL(container).ListBlobsFlatSegment(ctx, marker, options)
+			return o.fs.shouldRetry(ctx, err)
+		})
+		if err != nil {
+			if storageErr, ok := err.(azblob.StorageError); ok && (storageErr.ServiceCode() == azblob.ServiceCodeContainerNotFound || storageErr.Response().StatusCode == http.StatusNotFound) {
+				return fs.ErrorObjectNotFound
+			}
+			return err
+		}
+		marker = response.NextMarker
+		for i := range response.Segment.BlobItems {
+			file := &response.Segment.BlobItems[i]
+			// The versions of the blob are listed before any
+			// other blobs with it as a prefix
+			if file.Name != containerPath {
+				break listing
+			}
+			picker.add(file)
+		}
+	}
+	info := picker.flush()
+	if info == nil {
+		return fs.ErrorObjectNotFound
+	}
+	return o.decodeMetaDataFromBlob(info)
+}
+
 // ModTime returns the modification time of the object
 //
 // It attempts to read the objects mtime and if that isn't present the
@@ -1501,6 +1856,9 @@ func (o *Object) ModTime(ctx context.Context) (result time.Time) {
 
 // SetModTime sets the modification time of the local fs object
 func (o *Object) SetModTime(ctx context.Context, modTime time.Time) error {
+	if o.fs.opt.VersionAt.IsSet() {
+		return errNotWithVersionAt
+	}
 	// Make sure o.meta is not nil
 	if o.meta == nil {
 		o.meta = make(map[string]string, 1)
@@ -1646,6 +2004,9 @@ func (pw *poolWrapper) Close() {
 //
 // The new object may have been created if an error is returned
 func (o *Object) Update(ctx context.Context, in io.Reader, src fs.ObjectInfo, options ...fs.OpenOption) (err error) {
+	if o.fs.opt.VersionAt.IsSet() {
+		return errNotWithVersionAt
+	}
 	if o.accessTier == azblob.AccessTierArchive {
 		if o.fs.opt.ArchiveTierDelete {
 			fs.Debugf(o, "deleting archive tier blob before updating")
@@ -1672,6 +2033,18 @@ func (o *Object) Update(ctx context.Context, in io.Reader, src fs.ObjectInfo, op
 		return err
 	}
 
+	// Add any user metadata
+	for _, option := range options {
+		if m, ok := option.(*fs.MetadataOption); ok {
+			key := strings.ToLower(m.Key)
+			if key == modTimeKey {
+				fs.Errorf(o, "Not overriding metadata key %q used by rclone", key)
+				continue
+			}
+			o.meta[key] = m.Value
+		}
+	}
+
 	blob := o.getBlobReference()
 	httpHeaders := azblob.BlobHTTPHeaders{}
 	httpHeaders.ContentType = fs.MimeType(ctx, src)
@@ -1694,6 +2067,7 @@ func (o *Object) Update(ctx context.Context, in io.Reader, src fs.ObjectInfo, op
 		MaxBuffers:      o.fs.opt.UploadConcurrency,
 		Metadata:        o.meta,
 		BlobHTTPHeaders: httpHeaders,
+		BlobTagsMap:     o.fs.blobTags,
 		TransferManager: o.fs.newPoolWrapper(o.fs.opt.UploadConcurrency),
 	}
 
@@ -1725,6 +2099,9 @@ func (o *Object) Update(ctx context.Context, in io.Reader, src fs.ObjectInfo, op
 
 // Remove an object
 func (o *Object) Remove(ctx context.Context) error {
+	if o.fs.opt.VersionAt.IsSet() {
+		return errNotWithVersionAt
+	}
 	blob := o.getBlobReference()
 	snapShotOptions := azblob.DeleteSnapshotsOptionNone
 	ac := azblob.BlobAccessConditions{}
@@ -1739,6 +2116,20 @@ func (o *Object) MimeType(ctx context.Context) string {
 	return o.mimeType
 }
 
+// Metadata returns the user metadata of the object with the keys in
+// lower case
+func (o *Object) Metadata(ctx context.Context) (map[string]string, error) {
+	err := o.readMetaData()
+	if err != nil {
+		return nil, err
+	}
+	metadata := make(map[string]string, len(o.meta))
+	for key, value := range o.meta {
+		metadata[strings.ToLower(key)] = value
+	}
+	return metadata, nil
+}
+
 // AccessTier of an object, default is of type none
 func (o *Object) AccessTier() azblob.AccessTierType {
 	return o.accessTier
@@ -1746,6 +2137,9 @@ func (o *Object) AccessTier() azblob.AccessTierType {
 
 // SetTier performs changing object tier
 func (o *Object) SetTier(tier string) error {
+	if o.fs.opt.VersionAt.IsSet() {
+		return errNotWithVersionAt
+	}
 	if !validateAccessTier(tier) {
 		return fmt.Errorf("Tier %s not supported by Azure Blob Storage", tier)
 	}
@@ -1779,9 +2173,346 @@ func (o *Object) GetTier() string {
 	return string(o.accessTier)
 }
 
+var commandHelp = []fs.CommandHelp{{
+	Name:  "tier-on-idle",
+	Short: "Change the access tier of objects which haven't been modified for a while",
+	Long: `This command sets the access tier of objects older than min-age to
+tier. It can be run regularly (e.g. from cron) as a client side
+alternative to a lifecycle management policy.
+
+Usage Examples:
+
+    rclone backend tier-on-idle azureblob:container -o tier=Cool -o min-age=30d
+    rclone backend tier-on-idle azureblob:container/path -o tier=Archive -o min-age=1y
+
+The age of an object is worked out from its modification time as
+shown by rclone lsl. This is the Last-Modified time of the blob unless
+rclone stored the original modification time in the blob metadata when
+it uploaded it.
+
+Objects are only moved to a cooler tier (Hot to Cool to Archive), so
+objects already in tier or a cooler one are left alone, unless tier is
+Hot in which case all the objects which aren't Hot are moved to it.
+
+This command obeys the filters and the objects are changed --checkers
+at a time. Test first with -i/--interactive or --dry-run flags
+
+    rclone --dry-run backend tier-on-idle azureblob:container -o tier=Cool -o min-age=30d
+
+It returns a list of status dictionaries with Remote and Status
+keys. The Status will be OK if the tier was changed, Skipped if the
+object didn't need changing or an error message if it failed.
+
+    [
+        {
+            "Status": "OK",
+            "Remote": "test.txt"
+        },
+        {
+            "Status": "Skipped",
+            "Remote": "test/file4.txt"
+        }
+    ]
+
+`,
+	Opts: map[string]string{
+		"tier":    "Access tier to move the objects to: Cool|Archive",
+		"min-age": "Change objects with a modification time older than this, e.g. 30d",
+	},
+}, {
+	Name:  "tags",
+	Short: "Show the blob index tags of objects",
+	Long: `This command shows the blob index tags of the objects given as
+arguments, relative to the remote.
+
+Usage Example:
+
+    rclone backend tags azureblob:container/path file1.txt dir/file2.txt
+
+It returns a dictionary of the tags of each object
+
+    {
+        "file1.txt": {
+            "project": "alpha"
+        },
+        "dir/file2.txt": {}
+    }
+
+Tags can be set on upload with --azureblob-blob-tags.
+`,
+}, {
+	Name:  "find-tags",
+	Short: "Find blobs by their blob index tags",
+	Long: `This command finds the blobs whose index tags match the
+expression given as the argument. This is done on the server so is
+quick even for large containers.
+
+Usage Examples:
+
+    rclone backend find-tags azureblob:container "project = 'alpha'"
+    rclone backend find-tags azureblob: "project = 'alpha' AND owner = 'finance'"
+
+See the [Azure documentation](https://docs.microsoft.com/en-us/rest/api/storageservices/find-blobs-by-tags)
+for the syntax of the expression. If the remote has a container then
+only blobs in that container (and under the path if any) are returned.
+
+This searches the whole storage account so it needs account-level
+credentials - it can't be used with a container level SAS URL.
+
+It returns a list of dictionaries with Remote and Tags keys
+
+    [
+        {
+            "Remote": "file1.txt",
+            "Tags": {
+                "project": "alpha"
+            }
+        }
+    ]
+`,
+}}
+
+// tierOrder is the order of the tiers from hottest to coolest
+var tierOrder = map[string]int{
+	string(azblob.AccessTierHot):     0,
+	string(azblob.AccessTierCool):    1,
+	string(azblob.AccessTierArchive): 2,
+}
+
+// skipTier returns true if tier-on-idle should leave an object in the
+// tier current rather than move it to tier
+//
+// Objects are only moved to a cooler tier unless tier is Hot, as then
+// they are being asked to be warmed up.
+func skipTier(current, tier string) bool {
+	currentOrder, known := tierOrder[current]
+	if !known {
+		return false
+	}
+	if tier == string(azblob.AccessTierHot) {
+		return currentOrder == tierOrder[tier]
+	}
+	return currentOrder >= tierOrder[tier]
+}
+
+// Command the backend to run a named command
+//
+// The command run is name
+// args may be used to read arguments from
+// opts may be used to read optional arguments from
+//
+// The result should be capable of being JSON encoded
+// If it is a string or a []string it will be shown to the user
+// otherwise it will be JSON encoded and shown to the user like that
+func (f *Fs) Command(ctx context.Context, name string, arg []string, opt map[string]string) (out interface{}, err error) {
+	switch name {
+	case "tier-on-idle":
+		return f.tierOnIdle(ctx, opt)
+	case "tags":
+		return f.tagsCommand(ctx, arg)
+	case "find-tags":
+		if len(arg) != 1 {
+			return nil, errors.New("need exactly one argument: the tag expression")
+		}
+		return f.findTags(ctx, arg[0])
+	default:
+		return nil, fs.ErrorCommandNotFound
+	}
+}
+
+// tierOnIdle sets the access tier of objects older than min-age
+func (f *Fs) tierOnIdle(ctx context.Context, opt map[string]string) (out interface{}, err error) {
+	tier := opt["tier"]
+	if tier == "" {
+		return nil, errors.New("need -o tier=TIER")
+	}
+	if !validateAccessTier(tier) {
+		return nil, fmt.Errorf("tier %q not supported - must be one of %q, %q or %q", tier,
+			azblob.AccessTierHot, azblob.AccessTierCool, azblob.AccessTierArchive)
+	}
+	if opt["min-age"] == "" {
+		return nil, errors.New("need -o min-age=DURATION")
+	}
+	minAge, err := fs.ParseDuration(opt["min-age"])
+	if err != nil {
+		return nil, fmt.Errorf("bad min-age: %w", err)
+	}
+	cutoff := time.Now().Add(-minAge)
+	type status struct {
+		Status string
+		Remote string
+	}
+	var (
+		outMu    sync.Mutex
+		statuses = []status{}
+	)
+	err = operations.ListFn(ctx, f, func(obj fs.Object) {
+		// Remember this is run --checkers times concurrently
+		st := status{Status: "OK", Remote: obj.Remote()}
+		defer func() {
+			outMu.Lock()
+			statuses = append(statuses, st)
+			outMu.Unlock()
+		}()
+		o, ok := obj.(*Object)
+		if !ok {
+			st.Status = "Not an azureblob object"
+			return
+		}
+		if !obj.ModTime(ctx).Before(cutoff) || skipTier(o.GetTier(), tier) {
+			st.Status = "Skipped"
+			return
+		}
+		if operations.SkipDestructive(ctx, obj, "set tier to "+tier) {
+			return
+		}
+		tr := accounting.Stats(ctx).NewCheckingTransfer(obj)
+		err := o.SetTier(tier)
+		tr.Done(ctx, err)
+		if err != nil {
+			fs.Errorf(o, "Failed to set tier: %v", err)
+			st.Status = err.Error()
+		}
+	})
+	if err != nil {
+		return statuses, err
+	}
+	return statuses, nil
+}
+
+// tagsToMap converts the tags returned by Azure into a map
+func tagsToMap(tags *azblob.BlobTags) map[string]string {
+	out := map[string]string{}
+	if tags != nil {
+		for _, tag := range tags.BlobTagSet {
+			out[tag.Key] = tag.Value
+		}
+	}
+	return out
+}
+
+// GetTags reads the blob index tags of the object
+func (o *Object) GetTags(ctx context.Context) (tags map[string]string, err error) {
+	blob := o.getBlobReference()
+	var blobTags *azblob.BlobTags
+	err = o.fs.pacer.Call(func() (bool, error) {
+		blobTags, err = blob.GetTags(ctx, nil)
+		return o.fs.shouldRetry(ctx, err)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read blob tags: %w", err)
+	}
+	return tagsToMap(blobTags), nil
+}
+
+// tagsCommand returns the blob index tags of the objects in remotes
+func (f *Fs) tagsCommand(ctx context.Context, remotes []string) (out interface{}, err error) {
+	if len(remotes) == 0 {
+		return nil, errors.New("need at least one object to read the tags of")
+	}
+	tags := make(map[string]map[string]string, len(remotes))
+	for _, remote := range remotes {
+		obj, err := f.NewObject(ctx, remote)
+		if err != nil {
+			return nil, err
+		}
+		tags[remote], err = obj.(*Object).GetTags(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", remote, err)
+		}
+	}
+	return tags, nil
+}
+
+// findTags finds the blobs whose index tags match expression using
+// the Find Blobs by Tags API
+func (f *Fs) findTags(ctx context.Context, expression string) (out interface{}, err error) {
+	if f.isLimited {
+		return nil, errors.New("find-tags needs account-level credentials - it can't be used with a container level SAS URL")
+	}
+	where := expression
+	if f.rootContainer != "" {
+		container := f.opt.Enc.FromStandardName(f.rootContainer)
+		where = fmt.Sprintf("@container = '%s' AND (%s)", container, expression)
+	}
+	type result struct {
+		Remote string
+		Tags   map[string]string
+	}
+	results := []result{}
+	marker := ""
+	for {
+		var segment *azblob.FilterBlobSegment
+		err = f.pacer.Call(func() (bool, error) {
+			segment, err = f.filterBlobs(ctx, where, marker)
+			return f.shouldRetry(ctx, err)
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to find blobs by tags: %w", err)
+		}
+		for _, blob := range segment.Blobs {
+			remote := f.opt.Enc.ToStandardPath(blob.Name)
+			if f.rootContainer == "" {
+				remote = path.Join(f.opt.Enc.ToStandardName(blob.ContainerName), remote)
+			} else if f.rootDirectory != "" {
+				if !strings.HasPrefix(remote, f.rootDirectory+"/") {
+					continue
+				}
+				remote = remote[len(f.rootDirectory)+1:]
+			}
+			results = append(results, result{Remote: remote, Tags: tagsToMap(blob.Tags)})
+		}
+		if segment.NextMarker == nil || *segment.NextMarker == "" {
+			break
+		}
+		marker = *segment.NextMarker
+	}
+	return results, nil
+}
+
+// filterBlobs does a single call of the Find Blobs by Tags API
+//
+// The SDK doesn't export this call so make the request directly using
+// the pipeline of the service URL.
+func (f *Fs) filterBlobs(ctx context.Context, where, marker string) (*azblob.FilterBlobSegment, error) {
+	u := f.svcURL.URL()
+	params := u.Query()
+	params.Set("comp", "blobs")
+	params.Set("where", where)
+	if marker != "" {
+		params.Set("marker", marker)
+	}
+	u.RawQuery = params.Encode()
+	req, err := pipeline.NewRequest("GET", u, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("x-ms-version", azblob.ServiceVersion)
+	resp, err := f.pipe.Do(ctx, nil, req)
+	if err != nil {
+		return nil, err
+	}
+	httpResp := resp.Response()
+	defer fs.CheckClose(httpResp.Body, &err)
+	body, err := ioutil.ReadAll(httpResp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if httpResp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("HTTP error %d (%s): %s", httpResp.StatusCode, httpResp.Status, strings.TrimSpace(string(body)))
+	}
+	var segment azblob.FilterBlobSegment
+	err = xml.Unmarshal(body, &segment)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+	return &segment, nil
+}
+
 // Check the interfaces are satisfied
 var (
 	_ fs.Fs          = &Fs{}
+	_ fs.Commander   = &Fs{}
 	_ fs.Copier      = &Fs{}
 	_ fs.PutStreamer = &Fs{}
 	_ fs.Purger      = &Fs{}
@@ -1790,4 +2521,5 @@ var (
 	_ fs.MimeTyper   = &Object{}
 	_ fs.GetTierer   = &Object{}
 	_ fs.SetTierer   = &Object{}
+	_ fs.Metadataer  = &Object{}
 )
@@ -4,9 +4,17 @@
 package azureblob
 
 import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/Azure/azure-storage-blob-go/azblob"
+	"github.com/rclone/rclone/fs"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func (f *Fs) InternalTest(t *testing.T) {
@@ -34,3 +42,156 @@ func TestIncrement(t *testing.T) {
 		assert.Equal(t, test.want, test.in)
 	}
 }
+
+func TestTierOnIdleArgs(t *testing.T) {
+	ctx := context.Background()
+	f := &Fs{}
+	for _, test := range []struct {
+		opt  map[string]string
+		want string
+	}{
+		{map[string]string{"min-age": "30d"}, "need -o tier"},
+		{map[string]string{"tier": "Frozen", "min-age": "30d"}, "not supported"},
+		{map[string]string{"tier": "Cool"}, "need -o min-age"},
+		{map[string]string{"tier": "Cool", "min-age": "potato"}, "bad min-age"},
+	} {
+		_, err := f.Command(ctx, "tier-on-idle", nil, test.opt)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), test.want)
+	}
+}
+
+func TestSkipTier(t *testing.T) {
+	for _, test := range []struct {
+		current string
+		tier    string
+		want    bool
+	}{
+		{"Hot", "Cool", false},
+		{"Hot", "Archive", false},
+		{"Cool", "Archive", false},
+		{"Cool", "Cool", true},
+		{"Archive", "Cool", true},
+		{"Hot", "Hot", true},
+		{"Cool", "Hot", false},
+		{"Archive", "Hot", false},
+		{"", "Cool", false},
+		{"", "Hot", false},
+	} {
+		got := skipTier(test.current, test.tier)
+		assert.Equal(t, test.want, got, fmt.Sprintf("current=%q, tier=%q", test.current, test.tier))
+	}
+}
+
+func TestParseBlobTags(t *testing.T) {
+	tags, err := parseBlobTags(nil)
+	require.NoError(t, err)
+	assert.Nil(t, tags)
+
+	tags, err = parseBlobTags(fs.CommaSepList{"project=alpha", "owner=", "expr=a=b"})
+	require.NoError(t, err)
+	assert.Equal(t, azblob.BlobTagsMap{"project": "alpha", "owner": "", "expr": "a=b"}, tags)
+
+	for _, bad := range []fs.CommaSepList{
+		{"project"},
+		{"=alpha"},
+		{"project=alpha", "project=beta"},
+		{strings.Repeat("k", maxBlobTagKeyLen+1) + "=v"},
+		{"k=" + strings.Repeat("v", maxBlobTagValueLen+1)},
+		{"1=1", "2=2", "3=3", "4=4", "5=5", "6=6", "7=7", "8=8", "9=9", "10=10", "11=11"},
+	} {
+		_, err = parseBlobTags(bad)
+		assert.Error(t, err, bad)
+	}
+}
+
+func TestFindTagsContainerSAS(t *testing.T) {
+	f := &Fs{isLimited: true}
+	_, err := f.findTags(context.Background(), "project = 'alpha'")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "needs account-level credentials")
+}
+
+func TestSASURL(t *testing.T) {
+	u, err := url.Parse("https://account.blob.core.windows.net/container/dir/file.txt")
+	require.NoError(t, err)
+	blob := azblob.NewBlobURL(*u, azblob.NewPipeline(azblob.NewAnonymousCredential(), azblob.PipelineOptions{}))
+
+	// no account key or SAS URL
+	f := &Fs{}
+	_, ok, err := f.sasURL(blob)
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	// signed with the account key
+	f.sharedKey, err = azblob.NewSharedKeyCredential(emulatorAccount, emulatorAccountKey)
+	require.NoError(t, err)
+	signed, ok, err := f.sasURL(blob)
+	require.NoError(t, err)
+	assert.True(t, ok)
+	parts := azblob.NewBlobURLParts(signed)
+	assert.Equal(t, "container", parts.ContainerName)
+	assert.Equal(t, "dir/file.txt", parts.BlobName)
+	assert.Equal(t, "r", parts.SAS.Permissions())
+	assert.Equal(t, "b", parts.SAS.Resource())
+	assert.NotEqual(t, "", parts.SAS.Signature())
+	assert.WithinDuration(t, time.Now().Add(copySASExpiry), parts.SAS.ExpiryTime(), time.Minute)
+
+	// the blob URL already has the SAS from the SAS URL
+	f = &Fs{opt: Options{SASURL: "https://account.blob.core.windows.net/container?sv=x&sig=y"}}
+	u.RawQuery = "sv=x&sig=y"
+	blob = azblob.NewBlobURL(*u, azblob.NewPipeline(azblob.NewAnonymousCredential(), azblob.PipelineOptions{}))
+	signed, ok, err = f.sasURL(blob)
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, u.String(), signed.String())
+}
+
+func TestVersionPicker(t *testing.T) {
+	version := func(name, versionID string) *azblob.BlobItemInternal {
+		info := &azblob.BlobItemInternal{Name: name}
+		if versionID != "" {
+			info.VersionID = &versionID
+		} else {
+			info.Properties.LastModified = time.Date(2001, 1, 1, 0, 0, 0, 0, time.UTC)
+		}
+		return info
+	}
+	// The versions as they are listed - by name then oldest first.
+	// "a" was overwritten twice, "b" has no versions from before the
+	// time and "c" was written before versioning was enabled.
+	versions := []*azblob.BlobItemInternal{
+		version("a", "2001-01-01T00:00:00.0000000Z"),
+		version("a", "2001-01-02T00:00:00.0000000Z"),
+		version("a", "2001-01-03T00:00:00.0000000Z"),
+		version("b", "2001-01-04T00:00:00.0000000Z"),
+		version("c", ""),
+	}
+	for _, test := range []struct {
+		at   time.Time
+		want []string
+	}{
+		{time.Date(2000, 12, 31, 0, 0, 0, 0, time.UTC), nil},
+		{time.Date(2001, 1, 1, 0, 0, 0, 0, time.UTC), []string{"a@2001-01-01T00:00:00.0000000Z", "c"}},
+		{time.Date(2001, 1, 2, 12, 0, 0, 0, time.UTC), []string{"a@2001-01-02T00:00:00.0000000Z", "c"}},
+		{time.Date(2001, 1, 5, 0, 0, 0, 0, time.UTC), []string{"a@2001-01-03T00:00:00.0000000Z", "b@2001-01-04T00:00:00.0000000Z", "c"}},
+	} {
+		p := versionPicker{at: test.at}
+		var got []string
+		add := func(info *azblob.BlobItemInternal) {
+			if info == nil {
+				return
+			}
+			name := info.Name
+			if info.VersionID != nil {
+				name += "@" + *info.VersionID
+			}
+			got = append(got, name)
+		}
+		for _, info := range versions {
+			add(p.add(info))
+		}
+		add(p.flush())
+		assert.Equal(t, test.want, got, test.at)
+	}
+}
@@ -19,6 +19,7 @@ func TestIntegration(t *testing.T) {
 	fstests.Run(t, &fstests.Opt{
 		RemoteName:    "TestAzureBlob:",
 		NilObject:     (*Object)(nil),
+		DirMarkers:    true,
 		TiersToTest:   []string{"Hot", "Cool"},
 		ChunkedUpload: fstests.ChunkedUploadConfig{},
 	})
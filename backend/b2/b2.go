@@ -64,7 +64,8 @@ const (
 
 // Globals
 var (
-	errNotWithVersions = errors.New("can't modify or delete files in --b2-versions mode")
+	errNotWithVersions  = errors.New("can't modify or delete files in --b2-versions mode")
+	errNotWithVersionAt = errors.New("can't modify or delete files in --b2-version-at mode")
 )
 
 // Register with Fs
@@ -106,6 +107,20 @@ in the [b2 integrations checklist](https://www.backblaze.com/b2/docs/integration
 			Help:     "Include old versions in directory listings.\n\nNote that when using this no file write operations are permitted,\nso you can't upload files or delete them.",
 			Default:  false,
 			Advanced: true,
+		}, {
+			Name: "version_at",
+			Help: `Show file versions as they were at the specified time.
+
+The parameter should be a date, "2006-01-02", datetime "2006-01-02
+15:04:05" or a duration for that long ago, eg "100d" or "1h".
+
+Files which had been deleted (hidden) at that time aren't shown, even
+if they were uploaded again later.
+
+Note that when using this no file write operations are permitted,
+so you can't upload files or delete them.`,
+			Default:  fs.Time{},
+			Advanced: true,
 		}, {
 			Name:    "hard_delete",
 			Help:    "Permanently delete files on remote removal, otherwise hide files.",
@@ -211,6 +226,7 @@ type Options struct {
 	Endpoint                      string               `config:"endpoint"`
 	TestMode                      string               `config:"test_mode"`
 	Versions                      bool                 `config:"versions"`
+	VersionAt                     fs.Time              `config:"version_at"`
 	HardDelete                    bool                 `config:"hard_delete"`
 	UploadCutoff                  fs.SizeSuffix        `config:"upload_cutoff"`
 	CopyCutoff                    fs.SizeSuffix        `config:"copy_cutoff"`
@@ -437,6 +453,9 @@ func NewFs(ctx context.Context, name, root string, m configmap.Mapper) (fs.Fs, e
 	if opt.Endpoint == "" {
 		opt.Endpoint = defaultEndpoint
 	}
+	if opt.Versions && opt.VersionAt.IsSet() {
+		return nil, errors.New("can't use --b2-versions and --b2-version-at at the same time")
+	}
 	ci := fs.GetConfig(ctx)
 	f := &Fs{
 		name:        name,
@@ -725,6 +744,10 @@ func (f *Fs) list(ctx context.Context, bucket, directory, prefix string, addBuck
 			if isDirectory && len(remote) > 1 {
 				remote = remote[:len(remote)-1]
 			}
+			// is this the directory marker of the directory?
+			if remote == "" && directory != "" && !findFile {
+				continue // skip directory marker
+			}
 			if addBucket {
 				remote = path.Join(bucket, remote)
 			}
@@ -749,12 +772,30 @@ func (f *Fs) list(ctx context.Context, bucket, directory, prefix string, addBuck
 	return nil
 }
 
+// listVersions returns whether the old versions of the files need
+// listing as well as the current ones
+func (f *Fs) listVersions() bool {
+	return f.opt.Versions || f.opt.VersionAt.IsSet()
+}
+
+// isVersionAt returns whether object could be the version of its
+// file which was current at the time t
+//
+// Versions uploaded after t didn't exist then and unfinished large
+// file uploads were never current.
+func isVersionAt(object *api.File, t fs.Time) bool {
+	return object.Action != "start" && !time.Time(object.UploadTimestamp).After(time.Time(t))
+}
+
 // Convert a list item into a DirEntry
 func (f *Fs) itemToDirEntry(ctx context.Context, remote string, object *api.File, isDirectory bool, last *string) (fs.DirEntry, error) {
 	if isDirectory {
 		d := fs.NewDir(remote, time.Time{})
 		return d, nil
 	}
+	if f.opt.VersionAt.IsSet() {
+		return f.versionAtToDirEntry(ctx, remote, object, last)
+	}
 	if remote == *last {
 		remote = object.UploadTimestamp.AddVersion(remote)
 	} else {
@@ -771,10 +812,32 @@ func (f *Fs) itemToDirEntry(ctx context.Context, remote string, object *api.File
 	return o, nil
 }
 
+// versionAtToDirEntry converts a list item into a DirEntry for
+// --b2-version-at
+//
+// The versions of each file are listed newest first so the first one
+// which was current at the time is shown, unless it is a hide marker
+// meaning the file had been deleted by then. last is set to the file
+// once this is decided so its older versions are skipped.
+func (f *Fs) versionAtToDirEntry(ctx context.Context, remote string, object *api.File, last *string) (fs.DirEntry, error) {
+	if remote == *last || !isVersionAt(object, f.opt.VersionAt) {
+		return nil, nil
+	}
+	*last = remote
+	if object.Action == "hide" {
+		return nil, nil
+	}
+	o, err := f.newObjectWithInfo(ctx, remote, object)
+	if err != nil {
+		return nil, err
+	}
+	return o, nil
+}
+
 // listDir lists a single directory
 func (f *Fs) listDir(ctx context.Context, bucket, directory, prefix string, addBucket bool) (entries fs.DirEntries, err error) {
 	last := ""
-	err = f.list(ctx, bucket, directory, prefix, f.rootBucket == "", false, 0, f.opt.Versions, false, func(remote string, object *api.File, isDirectory bool) error {
+	err = f.list(ctx, bucket, directory, prefix, f.rootBucket == "", false, 0, f.listVersions(), false, func(remote string, object *api.File, isDirectory bool) error {
 		entry, err := f.itemToDirEntry(ctx, remote, object, isDirectory, &last)
 		if err != nil {
 			return err
@@ -846,7 +909,7 @@ func (f *Fs) ListR(ctx context.Context, dir string, callback fs.ListRCallback) (
 	list := walk.NewListRHelper(callback)
 	listR := func(bucket, directory, prefix string, addBucket bool) error {
 		last := ""
-		return f.list(ctx, bucket, directory, prefix, addBucket, true, 0, f.opt.Versions, false, func(remote string, object *api.File, isDirectory bool) error {
+		return f.list(ctx, bucket, directory, prefix, addBucket, true, 0, f.listVersions(), false, func(remote string, object *api.File, isDirectory bool) error {
 			entry, err := f.itemToDirEntry(ctx, remote, object, isDirectory, &last)
 			if err != nil {
 				return err
@@ -1019,9 +1082,89 @@ func (f *Fs) PutStream(ctx context.Context, in io.Reader, src fs.ObjectInfo, opt
 }
 
 // Mkdir creates the bucket if it doesn't exist
+//
+// If --create-dir-markers is set it creates a directory marker for
+// dir too
 func (f *Fs) Mkdir(ctx context.Context, dir string) error {
-	bucket, _ := f.split(dir)
-	return f.makeBucket(ctx, bucket)
+	bucket, directory := f.split(dir)
+	err := f.makeBucket(ctx, bucket)
+	if err != nil || directory == "" || !fs.GetConfig(ctx).CreateDirMarkers {
+		return err
+	}
+	return f.putDirMarker(ctx, bucket, directory)
+}
+
+// putDirMarker uploads a zero length file called directory + "/"
+// which keeps the directory in existence when it is empty
+func (f *Fs) putDirMarker(ctx context.Context, bucket, directory string) (err error) {
+	markerPath := directory + "/"
+	upload, err := f.getUploadURL(ctx, bucket)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		// return it like this because we might nil it out
+		f.returnUploadURL(upload)
+	}()
+	size := int64(0)
+	opts := rest.Opts{
+		Method:  "POST",
+		RootURL: upload.UploadURL,
+		Body:    bytes.NewReader(nil),
+		ExtraHeaders: map[string]string{
+			"Authorization":  upload.AuthorizationToken,
+			"X-Bz-File-Name": urlEncode(f.opt.Enc.FromStandardPath(markerPath)),
+			"Content-Type":   "application/x-directory",
+			sha1Header:       fmt.Sprintf("%x", sha1.Sum(nil)),
+		},
+		ContentLength: &size,
+	}
+	var response api.FileInfo
+	err = f.pacer.Call(func() (bool, error) {
+		resp, err := f.srv.CallJSON(ctx, &opts, nil, &response)
+		retry, err := f.shouldRetry(ctx, resp, err)
+		// On retryable error clear UploadURL
+		if retry {
+			fs.Debugf(f, "Clearing upload URL because of error: %v", err)
+			upload = nil
+		}
+		return retry, err
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create directory marker: %w", err)
+	}
+	fs.Debugf(f, "Created directory marker %q", markerPath)
+	return nil
+}
+
+// removeDirMarker removes the directory marker for directory
+//
+// Returns an error if the directory isn't empty
+func (f *Fs) removeDirMarker(ctx context.Context, bucket, directory string) error {
+	empty := true
+	err := f.list(ctx, bucket, directory, f.rootDirectory, f.rootBucket == "", false, 1, false, false, func(remote string, object *api.File, isDirectory bool) error {
+		empty = false
+		return errEndList
+	})
+	if err != nil {
+		return err
+	}
+	if !empty {
+		return fs.ErrorDirectoryNotEmpty
+	}
+	// Find the ID of the marker so it can be deleted
+	markerPath := directory + "/"
+	var markerID string
+	err = f.list(ctx, bucket, markerPath, "", false, true, 1, false, true, func(remote string, object *api.File, isDirectory bool) error {
+		if object.Name == markerPath {
+			markerID = object.ID
+		}
+		return errEndList
+	})
+	if err != nil || markerID == "" {
+		return err
+	}
+	return f.deleteByID(ctx, markerID, markerPath)
 }
 
 // makeBucket creates the bucket if it doesn't exist
@@ -1067,8 +1210,14 @@ func (f *Fs) makeBucket(ctx context.Context, bucket string) error {
 // Rmdir deletes the bucket if the fs is at the root
 //
 // Returns an error if it isn't empty
+//
+// If --create-dir-markers is set it removes the directory marker for
+// dir if it isn't the root.
 func (f *Fs) Rmdir(ctx context.Context, dir string) error {
 	bucket, directory := f.split(dir)
+	if bucket != "" && directory != "" && fs.GetConfig(ctx).CreateDirMarkers {
+		return f.removeDirMarker(ctx, bucket, directory)
+	}
 	if bucket == "" || directory != "" {
 		return nil
 	}
@@ -1246,11 +1395,17 @@ func (f *Fs) purge(ctx context.Context, dir string, oldOnly bool) error {
 
 // Purge deletes all the files and directories including the old versions.
 func (f *Fs) Purge(ctx context.Context, dir string) error {
+	if f.opt.VersionAt.IsSet() {
+		return errNotWithVersionAt
+	}
 	return f.purge(ctx, dir, false)
 }
 
 // CleanUp deletes all the hidden files.
 func (f *Fs) CleanUp(ctx context.Context) error {
+	if f.opt.VersionAt.IsSet() {
+		return errNotWithVersionAt
+	}
 	return f.purge(ctx, "", true)
 }
 
@@ -1321,6 +1476,9 @@ func (f *Fs) copy(ctx context.Context, dstObj *Object, srcObj *Object, newInfo *
 //
 // If it isn't possible then return fs.ErrorCantCopy
 func (f *Fs) Copy(ctx context.Context, src fs.Object, remote string) (fs.Object, error) {
+	if f.opt.VersionAt.IsSet() {
+		return nil, errNotWithVersionAt
+	}
 	srcObj, ok := src.(*Object)
 	if !ok {
 		fs.Debugf(src, "Can't copy - not same remote type")
@@ -1388,7 +1546,7 @@ func (f *Fs) PublicLink(ctx context.Context, remote string, expire fs.Duration,
 	}
 	_, err = f.NewObject(ctx, remote)
 	if err == fs.ErrorObjectNotFound || err == fs.ErrorNotAFile {
-		err2 := f.list(ctx, bucket, bucketPath, f.rootDirectory, f.rootBucket == "", false, 1, f.opt.Versions, false, func(remote string, object *api.File, isDirectory bool) error {
+		err2 := f.list(ctx, bucket, bucketPath, f.rootDirectory, f.rootBucket == "", false, 1, f.listVersions(), false, func(remote string, object *api.File, isDirectory bool) error {
 			err = nil
 			return nil
 		})
@@ -1522,17 +1680,27 @@ func (o *Object) getMetaDataListing(ctx context.Context) (info *api.File, err er
 	bucket, bucketPath := o.split()
 	maxSearched := 1
 	var timestamp api.Timestamp
+	versionAt := o.fs.opt.VersionAt.IsSet()
 	if o.fs.opt.Versions {
 		timestamp, bucketPath = api.RemoveVersion(bucketPath)
 		maxSearched = maxVersions
+	} else if versionAt {
+		maxSearched = maxVersions
 	}
 
-	err = o.fs.list(ctx, bucket, bucketPath, "", false, true, maxSearched, o.fs.opt.Versions, true, func(remote string, object *api.File, isDirectory bool) error {
+	err = o.fs.list(ctx, bucket, bucketPath, "", false, true, maxSearched, o.fs.listVersions(), true, func(remote string, object *api.File, isDirectory bool) error {
 		if isDirectory {
 			return nil
 		}
 		if remote == bucketPath {
-			if !timestamp.IsZero() && !timestamp.Equal(object.UploadTimestamp) {
+			if versionAt {
+				if !isVersionAt(object, o.fs.opt.VersionAt) {
+					return nil // look at the older versions
+				}
+				if object.Action == "hide" {
+					return errEndList // file was deleted at that time
+				}
+			} else if !timestamp.IsZero() && !timestamp.Equal(object.UploadTimestamp) {
 				return nil
 			}
 			info = object
@@ -1553,6 +1721,10 @@ func (o *Object) getMetaDataListing(ctx context.Context) (info *api.File, err er
 
 // getMetaData gets the metadata from the object unconditionally
 func (o *Object) getMetaData(ctx context.Context) (info *api.File, err error) {
+	// With --b2-version-at need to list the versions to find the one current at the time
+	if o.fs.opt.VersionAt.IsSet() {
+		return o.getMetaDataListing(ctx)
+	}
 	// If using versions and have a version suffix, need to list the directory to find the correct versions
 	if o.fs.opt.Versions {
 		timestamp, _ := api.RemoveVersion(o.remote)
@@ -1618,6 +1790,9 @@ func (o *Object) ModTime(ctx context.Context) (result time.Time) {
 
 // SetModTime sets the modification time of the Object
 func (o *Object) SetModTime(ctx context.Context, modTime time.Time) error {
+	if o.fs.opt.VersionAt.IsSet() {
+		return errNotWithVersionAt
+	}
 	info, err := o.getMetaData(ctx)
 	if err != nil {
 		return err
@@ -1700,16 +1875,20 @@ func (o *Object) getOrHead(ctx context.Context, method string, options []fs.Open
 		NoResponse: method == "HEAD",
 	}
 
-	// Use downloadUrl from backblaze if downloadUrl is not set
-	// otherwise use the custom downloadUrl
-	if o.fs.opt.DownloadURL == "" {
+	// Download by id if set and not using DownloadURL otherwise by
+	// name. With --b2-version-at always download by id as the name
+	// gets the current version.
+	byID := o.id != "" && (o.fs.opt.DownloadURL == "" || o.fs.opt.VersionAt.IsSet())
+
+	// Use downloadUrl from backblaze if downloadUrl is not set or
+	// downloading by id otherwise use the custom downloadUrl
+	if o.fs.opt.DownloadURL == "" || byID {
 		opts.RootURL = o.fs.info.DownloadURL
 	} else {
 		opts.RootURL = o.fs.opt.DownloadURL
 	}
 
-	// Download by id if set and not using DownloadURL otherwise by name
-	if o.id != "" && o.fs.opt.DownloadURL == "" {
+	if byID {
 		opts.Path += "/b2api/v1/b2_download_file_by_id?fileId=" + urlEncode(o.id)
 	} else {
 		bucket, bucketPath := o.split()
@@ -1828,6 +2007,9 @@ func (o *Object) Update(ctx context.Context, in io.Reader, src fs.ObjectInfo, op
 	if o.fs.opt.Versions {
 		return errNotWithVersions
 	}
+	if o.fs.opt.VersionAt.IsSet() {
+		return errNotWithVersionAt
+	}
 	size := src.Size()
 
 	bucket, bucketPath := o.split()
@@ -1983,6 +2165,9 @@ func (o *Object) Remove(ctx context.Context) error {
 	if o.fs.opt.Versions {
 		return errNotWithVersions
 	}
+	if o.fs.opt.VersionAt.IsSet() {
+		return errNotWithVersionAt
+	}
 	if o.fs.opt.HardDelete {
 		return o.fs.deleteByID(ctx, o.id, bucketPath)
 	}
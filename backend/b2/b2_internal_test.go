@@ -1,10 +1,15 @@
 package b2
 
 import (
+	"context"
 	"testing"
 	"time"
 
+	"github.com/rclone/rclone/backend/b2/api"
+	"github.com/rclone/rclone/fs"
 	"github.com/rclone/rclone/fstest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 // Test b2 string encoding
@@ -168,3 +173,69 @@ func TestParseTimeString(t *testing.T) {
 	}
 
 }
+
+func TestVersionAtToDirEntry(t *testing.T) {
+	ctx := context.Background()
+	at := func(s string) api.Timestamp {
+		return api.Timestamp(fstest.Time(s))
+	}
+	// The versions as b2_list_file_versions returns them - by name then
+	// newest first. "a" was deleted then uploaded again and the newest
+	// version of "b" is an unfinished large file.
+	versions := []api.File{
+		{ID: "a4", Name: "a", Action: "upload", UploadTimestamp: at("2001-01-04T00:00:00Z")},
+		{ID: "a3", Name: "a", Action: "hide", UploadTimestamp: at("2001-01-03T00:00:00Z")},
+		{ID: "a2", Name: "a", Action: "upload", UploadTimestamp: at("2001-01-02T00:00:00Z")},
+		{ID: "a1", Name: "a", Action: "upload", UploadTimestamp: at("2001-01-01T00:00:00Z")},
+		{ID: "b5", Name: "b", Action: "start", UploadTimestamp: at("2001-01-05T00:00:00Z")},
+		{ID: "b2", Name: "b", Action: "upload", UploadTimestamp: at("2001-01-02T00:00:00Z")},
+	}
+	for _, test := range []struct {
+		versionAt string
+		want      []string
+	}{
+		{"2000-12-31T00:00:00Z", nil},
+		{"2001-01-01T00:00:00Z", []string{"a1"}},
+		{"2001-01-01T12:00:00Z", []string{"a1"}},
+		{"2001-01-02T00:00:00Z", []string{"a2", "b2"}},
+		{"2001-01-03T00:00:00Z", []string{"b2"}},
+		{"2001-01-03T12:00:00Z", []string{"b2"}},
+		{"2001-01-04T00:00:00Z", []string{"a4", "b2"}},
+		{"2001-01-06T00:00:00Z", []string{"a4", "b2"}},
+	} {
+		f := &Fs{}
+		require.NoError(t, f.opt.VersionAt.Set(test.versionAt))
+		var got []string
+		last := ""
+		for i := range versions {
+			object := &versions[i]
+			entry, err := f.itemToDirEntry(ctx, object.Name, object, false, &last)
+			require.NoError(t, err)
+			if entry != nil {
+				o := entry.(*Object)
+				assert.Equal(t, object.Name, o.Remote())
+				got = append(got, o.id)
+			}
+		}
+		assert.Equal(t, test.want, got, test.versionAt)
+	}
+}
+
+func TestListVersions(t *testing.T) {
+	assert.False(t, (&Fs{}).listVersions())
+	assert.True(t, (&Fs{opt: Options{Versions: true}}).listVersions())
+	assert.True(t, (&Fs{opt: Options{VersionAt: fs.Time(time.Now())}}).listVersions())
+}
+
+func TestVersionAtReadOnly(t *testing.T) {
+	ctx := context.Background()
+	f := &Fs{opt: Options{VersionAt: fs.Time(time.Now())}}
+	o := &Object{fs: f, remote: "file"}
+	assert.Equal(t, errNotWithVersionAt, f.Purge(ctx, ""))
+	assert.Equal(t, errNotWithVersionAt, f.CleanUp(ctx))
+	_, err := f.Copy(ctx, o, "copy")
+	assert.Equal(t, errNotWithVersionAt, err)
+	assert.Equal(t, errNotWithVersionAt, o.SetModTime(ctx, time.Now()))
+	assert.Equal(t, errNotWithVersionAt, o.Update(ctx, nil, o))
+	assert.Equal(t, errNotWithVersionAt, o.Remove(ctx))
+}
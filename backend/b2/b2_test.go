@@ -13,6 +13,7 @@ func TestIntegration(t *testing.T) {
 	fstests.Run(t, &fstests.Opt{
 		RemoteName: "TestB2:",
 		NilObject:  (*Object)(nil),
+		DirMarkers: true,
 		ChunkedUpload: fstests.ChunkedUploadConfig{
 			MinChunkSize:       minChunkSize,
 			NeedMultipleChunks: true,
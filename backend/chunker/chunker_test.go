@@ -39,6 +39,7 @@ func TestIntegration(t *testing.T) {
 		UnimplementableFsMethods: []string{
 			"PublicLink",
 			"OpenWriterAt",
+			"OpenWriterAtResume",
 			"MergeDirs",
 			"DirCacheFlush",
 			"UserInfo",
@@ -21,6 +21,7 @@ func TestIntegration(t *testing.T) {
 		NilObject:  (*Object)(nil),
 		UnimplementableFsMethods: []string{
 			"OpenWriterAt",
+			"OpenWriterAtResume",
 			"MergeDirs",
 			"DirCacheFlush",
 			"PutUnchecked",
@@ -45,6 +46,7 @@ func TestRemoteGzip(t *testing.T) {
 		NilObject:  (*Object)(nil),
 		UnimplementableFsMethods: []string{
 			"OpenWriterAt",
+			"OpenWriterAtResume",
 			"MergeDirs",
 			"DirCacheFlush",
 			"PutUnchecked",
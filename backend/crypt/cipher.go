@@ -15,6 +15,7 @@ import (
 	"strings"
 	"sync"
 	"time"
+	"unicode/utf16"
 	"unicode/utf8"
 
 	"github.com/Max-Sum/base32768"
@@ -178,6 +179,12 @@ type Cipher struct {
 	buffers        sync.Pool // encrypt/decrypt buffers
 	cryptoRand     io.Reader // read crypto random numbers from here
 	dirNameEncrypt bool
+	passBadBlocks  bool // if set pass bad blocks as zeroed blocks
+	maxNameLength  int  // warn about encrypted names longer than this if set
+	countUTF16     bool // if set name lengths are counted in UTF-16 code units not bytes
+
+	warnMu sync.Mutex          // protects warned
+	warned map[string]struct{} // names checkNameLength has warned about
 }
 
 // newCipher initialises the cipher.  If salt is "" then it uses a built in salt val
@@ -198,6 +205,57 @@ func newCipher(mode NameEncryptionMode, password, salt string, dirNameEncrypt bo
 	return c, nil
 }
 
+// setPassBadBlocks sets whether blocks which fail authentication are
+// passed through as zeros rather than returning an error
+func (c *Cipher) setPassBadBlocks(passBadBlocks bool) {
+	c.passBadBlocks = passBadBlocks
+}
+
+// setMaxNameLength sets the length of encrypted name segments above
+// which a warning is logged, counted in UTF-16 code units if
+// countUTF16 is set or in bytes otherwise. 0 turns the check off.
+func (c *Cipher) setMaxNameLength(maxNameLength int, countUTF16 bool) {
+	c.maxNameLength = maxNameLength
+	c.countUTF16 = countUTF16
+}
+
+// nameLength returns the length of the encrypted name segment s as
+// counted for maxNameLength
+func (c *Cipher) nameLength(s string) int {
+	if c.countUTF16 {
+		return len(utf16.Encode([]rune(s)))
+	}
+	return len(s)
+}
+
+// checkNameLength warns if the encrypted name segment ciphertext of
+// plaintext is too long for the remote
+//
+// Names are encrypted each time they are used so the warning is only
+// given the first time for each name.
+func (c *Cipher) checkNameLength(plaintext, ciphertext string) {
+	if c.maxNameLength <= 0 {
+		return
+	}
+	length := c.nameLength(ciphertext)
+	if length <= c.maxNameLength {
+		return
+	}
+	c.warnMu.Lock()
+	_, warned := c.warned[plaintext]
+	if !warned {
+		if c.warned == nil {
+			c.warned = map[string]struct{}{}
+		}
+		c.warned[plaintext] = struct{}{}
+	}
+	c.warnMu.Unlock()
+	if warned {
+		return
+	}
+	fs.Logf(nil, "Encrypted name of %q is %d long which is more than --crypt-max-name-length %d so may be too long for the remote - consider a shorter --crypt-filename-encoding", plaintext, length, c.maxNameLength)
+}
+
 // Key creates all the internal keys from the password passed in using
 // scrypt.
 //
@@ -262,7 +320,9 @@ func (c *Cipher) encryptSegment(plaintext string) string {
 	}
 	paddedPlaintext := pkcs7.Pad(nameCipherBlockSize, []byte(plaintext))
 	ciphertext := eme.Transform(c.block, c.nameTweak[:], paddedPlaintext, eme.DirectionEncrypt)
-	return c.fileNameEnc.EncodeToString(ciphertext)
+	encoded := c.fileNameEnc.EncodeToString(ciphertext)
+	c.checkNameLength(plaintext, encoded)
+	return encoded
 }
 
 // decryptSegment decrypts a path segment
@@ -762,6 +822,7 @@ type decrypter struct {
 	rc           io.ReadCloser
 	nonce        nonce
 	initialNonce nonce
+	block        int64 // index of the next block to be read
 	c            *Cipher
 	buf          []byte
 	readBuf      []byte
@@ -867,11 +928,19 @@ func (fh *decrypter) fillBuffer() (err error) {
 		if err != nil {
 			return err // return pending error as it is likely more accurate
 		}
-		return ErrorEncryptedBadBlock
+		if !fh.c.passBadBlocks {
+			return ErrorEncryptedBadBlock
+		}
+		fs.Errorf(nil, "crypt: passing bad block at offset %d as zeros: %v", fh.block*blockDataSize, ErrorEncryptedBadBlock)
+		// Zero out the bad block and continue
+		for i := range fh.buf[:n-blockHeaderSize] {
+			fh.buf[i] = 0
+		}
 	}
 	fh.bufIndex = 0
 	fh.bufSize = n - blockHeaderSize
 	fh.nonce.increment()
+	fh.block++
 	return nil
 }
 
@@ -971,6 +1040,7 @@ func (fh *decrypter) RangeSeek(ctx context.Context, offset int64, whence int, li
 	// Move the nonce on the correct number of blocks from the start
 	fh.nonce = fh.initialNonce
 	fh.nonce.add(uint64(blocks))
+	fh.block = blocks
 
 	// Can we seek underlying stream directly?
 	if do, ok := fh.rc.(fs.RangeSeeker); ok {
@@ -14,6 +14,7 @@ import (
 
 	"github.com/Max-Sum/base32768"
 	"github.com/rclone/rclone/backend/crypt/pkcs7"
+	"github.com/rclone/rclone/fs"
 	"github.com/rclone/rclone/lib/readers"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -1537,6 +1538,86 @@ func TestDecrypterRead(t *testing.T) {
 	}
 }
 
+func TestDecrypterPassBadBlocks(t *testing.T) {
+	c, err := newCipher(NameEncryptionStandard, "", "", true, nil)
+	require.NoError(t, err)
+	c.cryptoRand = newRandomSource(1e8) // nodge the crypto rand generator
+
+	// Encrypt 3 blocks of data and corrupt the middle one
+	plaintext := make([]byte, 3*blockDataSize)
+	for i := range plaintext {
+		plaintext[i] = byte(i%255) + 1
+	}
+	encrypted, err := c.EncryptData(bytes.NewBuffer(plaintext))
+	require.NoError(t, err)
+	ciphertext, err := ioutil.ReadAll(encrypted)
+	require.NoError(t, err)
+	ciphertext[fileHeaderSize+blockSize+blockHeaderSize+10] ^= 0xFF
+
+	// Normally reading fails
+	fh, err := c.newDecrypter(ioutil.NopCloser(bytes.NewBuffer(ciphertext)))
+	require.NoError(t, err)
+	_, err = ioutil.ReadAll(fh)
+	assert.Equal(t, ErrorEncryptedBadBlock, err)
+
+	// With passBadBlocks the bad block is returned as zeros
+	c.setPassBadBlocks(true)
+	fh, err = c.newDecrypter(ioutil.NopCloser(bytes.NewBuffer(ciphertext)))
+	require.NoError(t, err)
+	out, err := ioutil.ReadAll(fh)
+	require.NoError(t, err)
+	require.Equal(t, len(plaintext), len(out))
+	assert.Equal(t, plaintext[:blockDataSize], out[:blockDataSize])
+	assert.Equal(t, make([]byte, blockDataSize), out[blockDataSize:2*blockDataSize])
+	assert.Equal(t, plaintext[2*blockDataSize:], out[2*blockDataSize:])
+}
+
+func TestCheckNameLength(t *testing.T) {
+	var logged []string
+	oldTee := fs.LogTee
+	defer func() { fs.LogTee = oldTee }()
+	fs.LogTee = func(level fs.LogLevel, o interface{}, text string) {
+		logged = append(logged, text)
+	}
+	long := strings.Repeat("a", 144)
+
+	// off by default
+	enc, err := NewNameEncoding("base32")
+	require.NoError(t, err)
+	c, err := newCipher(NameEncryptionStandard, "", "", true, enc)
+	require.NoError(t, err)
+	c.encryptSegment(long)
+	assert.Equal(t, 0, len(logged))
+
+	// base32 is counted in bytes
+	c.setMaxNameLength(255, false)
+	c.encryptSegment(long[:143])
+	assert.Equal(t, 0, len(logged))
+	c.encryptSegment(long)
+	require.Equal(t, 1, len(logged))
+	assert.Contains(t, logged[0], "is 256 long")
+
+	// only warned about once for each name
+	c.encryptSegment(long)
+	assert.Equal(t, 1, len(logged))
+	c.encryptSegment(long + "b")
+	assert.Equal(t, 2, len(logged))
+
+	// base32768 is counted in UTF-16 code units
+	logged = nil
+	enc, err = NewNameEncoding("base32768")
+	require.NoError(t, err)
+	c, err = newCipher(NameEncryptionStandard, "", "", true, enc)
+	require.NoError(t, err)
+	c.setMaxNameLength(255, true)
+	c.encryptSegment(long)
+	assert.Equal(t, 0, len(logged))
+	assert.Equal(t, 3, c.nameLength("\u4e00\U0001f600"))
+	c.setMaxNameLength(255, false)
+	c.encryptSegment(long)
+	assert.Equal(t, 1, len(logged))
+}
+
 func TestDecrypterClose(t *testing.T) {
 	c, err := newCipher(NameEncryptionStandard, "", "", true, nil)
 	assert.NoError(t, err)
@@ -102,8 +102,15 @@ names, or for debugging purposes.`,
 			Hide:     fs.OptionHideConfigurator,
 			Advanced: true,
 		}, {
-			Name:     "no_data_encryption",
-			Help:     "Option to either encrypt file data or leave it unencrypted.",
+			Name: "no_data_encryption",
+			Help: `Option to either encrypt file data or leave it unencrypted.
+
+If set then only the file names are encrypted and the file contents
+are stored in the clear on the remote, so anyone with access to the
+remote can read them. Hashes of the contents then match the plaintext
+and can be used by rclone check and sync.
+
+Remotes written with this set must be read with it set too.`,
 			Default:  false,
 			Advanced: true,
 			Examples: []fs.OptionExample{
@@ -122,7 +129,17 @@ names, or for debugging purposes.`,
 
 This option could help with shortening the encrypted filename. The 
 suitable option would depend on the way your remote count the filename
-length and if it's case sensitve.`,
+length and if it's case sensitve.
+
+With names limited to 255 characters, as on most remotes, the longest
+file or directory name which can be stored is about 143 characters
+with base32, 175 with base64 and 463 with base32768, but the last only
+if the remote counts a base32768 character as one.
+
+The encoding is part of how the names are stored, so don't change it
+for a remote which already has files in it as they won't be readable
+any more. Make a new crypt remote with the new encoding and copy the
+files to it instead.`,
 			Default: "base32",
 			Examples: []fs.OptionExample{
 				{
@@ -139,6 +156,39 @@ length and if it's case sensitve.`,
 				},
 			},
 			Advanced: true,
+		}, {
+			Name: "max_name_length",
+			Help: `Warn about encrypted names longer than this.
+
+Most remotes limit the length of each file or directory name, often
+to 255 bytes or characters. If the name of a file or directory is
+longer than this once it has been encrypted and encoded with
+filename_encoding, a warning is logged as the remote may refuse to
+store it, giving "path too long" or "name too long" errors. The
+warning is only logged once for each name.
+
+The length is counted in UTF-16 characters for base32768 and in bytes
+otherwise. Set this to the limit of the remote being wrapped or to 0
+to turn the warning off.`,
+			Default:  255,
+			Advanced: true,
+		}, {
+			Name: "pass_bad_blocks",
+			Help: `If set this will pass bad blocks through as all 0.
+
+This should not be set in normal operation, it should only be set if
+trying to recover an encrypted file with errors and it is desired to
+recover as much of the file as possible.
+
+Each block of a crypted file is authenticated when it is decrypted.
+Normally a block which fails authentication stops the read with an
+error. With this flag set it is returned as zeros instead and the
+offset of the block is logged as an ERROR, so the rest of the file can
+still be read.
+
+The data returned is not authenticated so it should not be trusted.`,
+			Default:  false,
+			Advanced: true,
 		}},
 	})
 }
@@ -171,6 +221,8 @@ func newCipherForConfig(opt *Options) (*Cipher, error) {
 	if err != nil {
 		return nil, fmt.Errorf("failed to make cipher: %w", err)
 	}
+	cipher.setPassBadBlocks(opt.PassBadBlocks)
+	cipher.setMaxNameLength(opt.MaxNameLength, strings.ToLower(opt.FilenameEncoding) == "base32768")
 	return cipher, nil
 }
 
@@ -229,6 +281,15 @@ func NewFs(ctx context.Context, name, rpath string, m configmap.Mapper) (fs.Fs,
 		cipher: cipher,
 	}
 	cache.PinUntilFinalized(f.Fs, f)
+	if opt.PassBadBlocks {
+		fs.Logf(f, "--crypt-pass-bad-blocks is set: blocks which fail authentication will be returned as zeros")
+	}
+	if cipher.NameEncryptionMode() == NameEncryptionStandard && strings.ToLower(opt.FilenameEncoding) == "base64" && wrappedFs.Features().CaseInsensitive {
+		fs.Logf(f, "--crypt-filename-encoding base64 needs a case sensitive remote but %v is case insensitive so names may not be readable - use base32 instead", wrappedFs)
+	}
+	if opt.NoDataEncryption {
+		fs.Logf(f, "--crypt-no-data-encryption is set: file contents are NOT encrypted, only file names are")
+	}
 	// the features here are ones we could support, and they are
 	// ANDed with the ones from wrappedFs
 	f.features = (&fs.Features{
@@ -257,6 +318,8 @@ type Options struct {
 	ServerSideAcrossConfigs bool   `config:"server_side_across_configs"`
 	ShowMapping             bool   `config:"show_mapping"`
 	FilenameEncoding        string `config:"filename_encoding"`
+	MaxNameLength           int    `config:"max_name_length"`
+	PassBadBlocks           bool   `config:"pass_bad_blocks"`
 }
 
 // Fs represents a wrapped fs.Fs
@@ -467,7 +530,13 @@ func (f *Fs) PutStream(ctx context.Context, in io.Reader, src fs.ObjectInfo, opt
 }
 
 // Hashes returns the supported hash sets.
+//
+// If the data isn't encrypted then these are the hashes of the
+// wrapped remote.
 func (f *Fs) Hashes() hash.Set {
+	if f.opt.NoDataEncryption {
+		return f.Fs.Hashes()
+	}
 	return hash.Set(hash.None)
 }
 
@@ -501,9 +570,9 @@ func (f *Fs) Purge(ctx context.Context, dir string) error {
 
 // Copy src to this remote using server-side copy operations.
 //
-// This is stored with the remote path given
+// This is stored with the remote path given.
 //
-// It returns the destination Object and a possible error
+// It returns the destination Object and a possible error.
 //
 // Will only be called if src.Fs().Name() == f.Name()
 //
@@ -526,9 +595,9 @@ func (f *Fs) Copy(ctx context.Context, src fs.Object, remote string) (fs.Object,
 
 // Move src to this remote using server-side move operations.
 //
-// This is stored with the remote path given
+// This is stored with the remote path given.
 //
-// It returns the destination Object and a possible error
+// It returns the destination Object and a possible error.
 //
 // Will only be called if src.Fs().Name() == f.Name()
 //
@@ -895,6 +964,9 @@ func (o *Object) Size() int64 {
 // Hash returns the selected checksum of the file
 // If no checksum is available it returns ""
 func (o *Object) Hash(ctx context.Context, ht hash.Type) (string, error) {
+	if o.f.opt.NoDataEncryption {
+		return o.Object.Hash(ctx, ht)
+	}
 	return "", hash.ErrUnsupported
 }
 
@@ -1036,6 +1108,10 @@ func (o *ObjectInfo) Size() int64 {
 // Hash returns the selected checksum of the file
 // If no checksum is available it returns ""
 func (o *ObjectInfo) Hash(ctx context.Context, hash hash.Type) (string, error) {
+	if o.f.opt.NoDataEncryption {
+		// the data is stored unchanged so has the same hash
+		return o.ObjectInfo.Hash(ctx, hash)
+	}
 	var srcObj fs.Object
 	var ok bool
 	// Get the underlying object if there is one
@@ -17,6 +17,7 @@ import (
 	"io/ioutil"
 	"mime"
 	"net/http"
+	"net/url"
 	"path"
 	"sort"
 	"strconv"
@@ -332,6 +333,26 @@ with you).
 This works both with the "list" (lsd, lsl, etc.) and the "copy"
 commands (copy, sync, etc.), and with all other commands too.`,
 			Advanced: true,
+		}, {
+			Name:    "all_shared_drives",
+			Default: false,
+			Help: `Show all Shared Drives in a virtual shared-drives directory.
+
+If set, the root of the remote shows My Drive as normal plus a
+virtual directory called "shared-drives" which contains a directory
+for each Shared Drive (Team Drive) you can access, e.g.
+
+    remote:shared-drives/Marketing/report.pdf
+
+Operations in those directories are done on the right Shared Drive
+so you don't need a separate remote for each one.
+
+If two Shared Drives have the same name, the directories are named
+"Name {ID}" where ID is the Shared Drive ID. Uploads to Shared Drives
+you can't add files to are refused with an error.
+
+This can't be used with team_drive.`,
+			Advanced: true,
 		}, {
 			Name:     "trashed_only",
 			Default:  false,
@@ -382,7 +403,13 @@ This feature was implemented to retain photos capture date as recorded
 by google photos. You will first need to check the "Create a Google
 Photos folder" option in your google drive settings. You can then copy
 or move the photos locally and use the date the image was taken
-(created) set as the modification date.`,
+(created) set as the modification date.
+
+The created date is used as the modification time of directories too,
+and for the server side search done for "--max-age" and "--min-age".
+Setting the modification time of a file still sets its modified date,
+as Drive doesn't allow the created date to be changed, so the file
+keeps reporting its created date.`,
 			Advanced: true,
 			Hide:     fs.OptionHideConfigurator,
 		}, {
@@ -394,7 +421,11 @@ Note that, as with "--drive-use-created-date", this flag may have
 unexpected consequences when uploading/downloading files.
 
 If both this flag and "--drive-use-created-date" are set, the created
-date is used.`,
+date is used.
+
+The shared date can't be searched on, so with this flag "--max-age"
+and "--min-age" are checked by rclone against each file listed rather
+than by Drive.`,
 			Advanced: true,
 			Hide:     fs.OptionHideConfigurator,
 		}, {
@@ -545,6 +576,24 @@ If this flag is set then rclone will ignore shortcut files completely.
 `,
 			Advanced: true,
 			Default:  false,
+		}, {
+			Name: "resource_keys",
+			Help: `Resource keys for link shared files and folders.
+
+Some files and folders shared by link need a resource key as well as
+their ID before they can be accessed, otherwise drive returns a 404
+error. The resource key is the value of the resourcekey parameter in
+the link, e.g.
+
+    https://drive.google.com/file/d/FILE_ID/view?resourcekey=RESOURCE_KEY
+
+This is a comma separated list where each entry is either the shared
+link itself or FILE_ID=RESOURCE_KEY. The keys are sent with every
+request so they work for the files and anything inside the folders.
+
+See [the resource keys section](#resource-keys) for more info.`,
+			Default:  fs.CommaSepList{},
+			Advanced: true,
 		}, {
 			Name:     config.ConfigEncoding,
 			Help:     config.ConfigEncodingHelp,
@@ -581,6 +630,7 @@ type Options struct {
 	SkipGdocs                 bool                 `config:"skip_gdocs"`
 	SkipChecksumGphotos       bool                 `config:"skip_checksum_gphotos"`
 	SharedWithMe              bool                 `config:"shared_with_me"`
+	AllSharedDrives           bool                 `config:"all_shared_drives"`
 	TrashedOnly               bool                 `config:"trashed_only"`
 	StarredOnly               bool                 `config:"starred_only"`
 	Extensions                string               `config:"formats"`
@@ -604,6 +654,7 @@ type Options struct {
 	StopOnUploadLimit         bool                 `config:"stop_on_upload_limit"`
 	StopOnDownloadLimit       bool                 `config:"stop_on_download_limit"`
 	SkipShortcuts             bool                 `config:"skip_shortcuts"`
+	ResourceKeys              fs.CommaSepList      `config:"resource_keys"`
 	Enc                       encoder.MultiEncoder `config:"encoding"`
 }
 
@@ -635,7 +686,7 @@ type baseObject struct {
 	fs           *Fs      // what this object is part of
 	remote       string   // The remote path
 	id           string   // Drive Id of this object
-	modifiedDate string   // RFC3339 time it was last modified
+	modifiedDate string   // RFC3339 time returned by ModTime, see fileModTime
 	mimeType     string   // The object MIME type
 	bytes        int64    // size of the object
 	parents      []string // IDs of the parent directories
@@ -760,6 +811,34 @@ func (f *Fs) getRootID(ctx context.Context) (string, error) {
 	return info.Id, nil
 }
 
+// modTimeQuery returns a search term selecting items whose time in
+// field compares with tm using op.
+//
+// Directories are always selected so they can still be recursed into.
+// Shortcuts are selected too if they are being resolved as the
+// modification time rclone uses is that of the target, not the shortcut.
+func modTimeQuery(field, op string, tm time.Time, withShortcuts bool) string {
+	// https://developers.google.com/drive/api/v3/ref-search-terms#operators
+	// Query times use RFC 3339 format, default timezone is UTC
+	timeStr := tm.UTC().Format("2006-01-02T15:04:05")
+	if withShortcuts {
+		return fmt.Sprintf("(%s %s '%s' or mimeType = '%s' or mimeType = '%s')", field, op, timeStr, driveFolderType, shortcutMimeType)
+	}
+	return fmt.Sprintf("(%s %s '%s' or mimeType = '%s')", field, op, timeStr, driveFolderType)
+}
+
+// modTimeField returns the field to search on for the time returned
+// by fileModTime, or "" if it can't be searched on.
+func (f *Fs) modTimeField() string {
+	if f.opt.UseCreatedDate {
+		return "createdTime"
+	} else if f.opt.UseSharedDate {
+		// the shared time can't be searched on
+		return ""
+	}
+	return "modifiedTime"
+}
+
 // Lists the directory required calling the user function on each item found
 //
 // If the user fn ever returns true then it early exits with found = true
@@ -835,16 +914,14 @@ func (f *Fs) list(ctx context.Context, dirIDs []string, title string, directorie
 	}
 
 	// Constrain query using filter if this remote is a sync/copy/walk source.
-	if fi, use := filter.GetConfig(ctx), filter.GetUseFilter(ctx); fi != nil && use {
+	// The filter still checks the times of the items listed if the
+	// time rclone uses can't be searched on.
+	if fi, use := filter.GetConfig(ctx), filter.GetUseFilter(ctx); fi != nil && use && f.modTimeField() != "" {
 		queryByTime := func(op string, tm time.Time) {
 			if tm.IsZero() {
 				return
 			}
-			// https://developers.google.com/drive/api/v3/ref-search-terms#operators
-			// Query times use RFC 3339 format, default timezone is UTC
-			timeStr := tm.UTC().Format("2006-01-02T15:04:05")
-			term := fmt.Sprintf("(modifiedTime %s '%s' or mimeType = '%s')", op, timeStr, driveFolderType)
-			query = append(query, term)
+			query = append(query, modTimeQuery(f.modTimeField(), op, tm, !f.opt.SkipShortcuts))
 		}
 		queryByTime(">=", fi.ModTimeFrom)
 		queryByTime("<=", fi.ModTimeTo)
@@ -1011,13 +1088,77 @@ func parseExtensions(extensionsIn ...string) (extensions, mimeTypes []string, er
 	return
 }
 
+// resourceKeysHeader is the header used to send resource keys
+const resourceKeysHeader = "X-Goog-Drive-Resource-Keys"
+
+// parseResourceKeys parses the resource_keys option into the value
+// for the resourceKeysHeader.
+//
+// Each key is either FILE_ID=RESOURCE_KEY or a shared link with the
+// resourcekey parameter in.
+func parseResourceKeys(keys fs.CommaSepList) (string, error) {
+	var out []string
+	for _, key := range keys {
+		key = strings.TrimSpace(key)
+		if key == "" {
+			continue
+		}
+		var id, resourceKey string
+		if strings.HasPrefix(key, "https://") || strings.HasPrefix(key, "http://") {
+			u, err := url.Parse(key)
+			if err != nil {
+				return "", fmt.Errorf("failed to parse resource key link %q: %w", key, err)
+			}
+			resourceKey = u.Query().Get("resourcekey")
+			id = u.Query().Get("id")
+			if id == "" {
+				// Links look like /file/d/ID/view or /drive/folders/ID
+				parts := strings.Split(strings.Trim(u.Path, "/"), "/")
+				for i, part := range parts {
+					if (part == "d" || part == "folders") && i+1 < len(parts) {
+						id = parts[i+1]
+						break
+					}
+				}
+			}
+		} else {
+			i := strings.IndexRune(key, '=')
+			if i >= 0 {
+				id, resourceKey = key[:i], key[i+1:]
+			}
+		}
+		if id == "" || resourceKey == "" {
+			return "", fmt.Errorf("resource key %q should be FILE_ID=RESOURCE_KEY or a link with a resourcekey in", key)
+		}
+		out = append(out, id+"/"+resourceKey)
+	}
+	return strings.Join(out, ","), nil
+}
+
+// resourceKeysTransport adds the resource keys to every request
+type resourceKeysTransport struct {
+	http.RoundTripper
+	keys string
+}
+
+// RoundTrip adds the resource keys header then does the request
+func (t *resourceKeysTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	req.Header.Set(resourceKeysHeader, t.keys)
+	return t.RoundTripper.RoundTrip(req)
+}
+
 // getClient makes an http client according to the options
 func getClient(ctx context.Context, opt *Options) *http.Client {
-	t := fshttp.NewTransportCustom(ctx, func(t *http.Transport) {
+	var t http.RoundTripper = fshttp.NewTransportCustom(ctx, func(t *http.Transport) {
 		if opt.DisableHTTP2 {
 			t.TLSNextProto = map[string]func(string, *tls.Conn) http.RoundTripper{}
 		}
 	})
+	// The keys are checked in newFs so ignore errors here
+	if keys, _ := parseResourceKeys(opt.ResourceKeys); keys != "" {
+		t = &resourceKeysTransport{RoundTripper: t, keys: keys}
+	}
 	return &http.Client{
 		Transport: t,
 	}
@@ -1112,6 +1253,10 @@ func newFs(ctx context.Context, name, path string, m configmap.Mapper) (*Fs, err
 	if err != nil {
 		return nil, fmt.Errorf("drive: chunk size: %w", err)
 	}
+	_, err = parseResourceKeys(opt.ResourceKeys)
+	if err != nil {
+		return nil, fmt.Errorf("drive: resource keys: %w", err)
+	}
 
 	oAuthClient, err := createOAuthClient(ctx, opt, name, m)
 	if err != nil {
@@ -1168,6 +1313,12 @@ func NewFs(ctx context.Context, name, path string, m configmap.Mapper) (fs.Fs, e
 	if err != nil {
 		return nil, err
 	}
+	if f.opt.AllSharedDrives {
+		if f.isTeamDrive {
+			return nil, errors.New("drive: can't use all_shared_drives with team_drive")
+		}
+		return newSharedDrivesFs(ctx, name, path, m)
+	}
 
 	// Set the root folder ID
 	if f.opt.RootFolderID != "" {
@@ -1242,13 +1393,21 @@ func NewFs(ctx context.Context, name, path string, m configmap.Mapper) (fs.Fs, e
 	return f, nil
 }
 
-func (f *Fs) newBaseObject(remote string, info *drive.File) baseObject {
-	modifiedDate := info.ModifiedTime
+// fileModTime returns the RFC3339 time to use as the modification
+// time of info.
+//
+// This is the modified time unless --drive-use-created-date or
+// --drive-use-shared-date select the created or shared time instead.
+func (f *Fs) fileModTime(info *drive.File) string {
 	if f.opt.UseCreatedDate {
-		modifiedDate = info.CreatedTime
+		return info.CreatedTime
 	} else if f.opt.UseSharedDate && info.SharedWithMeTime != "" {
-		modifiedDate = info.SharedWithMeTime
+		return info.SharedWithMeTime
 	}
+	return info.ModifiedTime
+}
+
+func (f *Fs) newBaseObject(remote string, info *drive.File) baseObject {
 	size := info.Size
 	if f.opt.SizeAsQuota {
 		size = info.QuotaBytesUsed
@@ -1257,7 +1416,7 @@ func (f *Fs) newBaseObject(remote string, info *drive.File) baseObject {
 		fs:           f,
 		remote:       remote,
 		id:           info.Id,
-		modifiedDate: modifiedDate,
+		modifiedDate: f.fileModTime(info),
 		mimeType:     info.MimeType,
 		bytes:        size,
 		parents:      info.Parents,
@@ -2042,7 +2201,7 @@ func (f *Fs) itemToDirEntry(ctx context.Context, remote string, item *drive.File
 	case item.MimeType == driveFolderType:
 		// cache the directory ID for later lookups
 		f.dirCache.Put(remote, item.Id)
-		when, _ := time.Parse(timeFormatIn, item.ModifiedTime)
+		when, _ := time.Parse(timeFormatIn, f.fileModTime(item))
 		d := fs.NewDir(remote, when).SetID(item.Id)
 		if len(item.Parents) > 0 {
 			d.SetParentID(item.Parents[0])
@@ -2981,9 +3140,14 @@ func (f *Fs) makeShortcut(ctx context.Context, srcPath string, dstFs *Fs, dstPat
 }
 
 // List all team drives
-func (f *Fs) listTeamDrives(ctx context.Context) (drives []*drive.Drive, err error) {
+//
+// If fields are supplied then only these fields of the drives are read.
+func (f *Fs) listTeamDrives(ctx context.Context, fields ...googleapi.Field) (drives []*drive.Drive, err error) {
 	drives = []*drive.Drive{}
 	listTeamDrives := f.svc.Drives.List().PageSize(100)
+	if len(fields) > 0 {
+		listTeamDrives.Fields(fields...)
+	}
 	var defaultFs Fs // default Fs with default Options
 	for {
 		var teamDrives *drive.DriveList
@@ -3107,6 +3271,98 @@ func (f *Fs) copyID(ctx context.Context, id, dest string) (err error) {
 	return nil
 }
 
+// revisionFields are the fields of the revisions shown by the
+// revisions backend command
+const revisionFields = "id,mimeType,modifiedTime,keepForever,md5Checksum,originalFilename,size"
+
+// fileID returns the ID of the file at remote
+func (f *Fs) fileID(ctx context.Context, remote string) (string, error) {
+	o, err := f.NewObject(ctx, strings.Trim(remote, "/"))
+	if err != nil {
+		return "", fmt.Errorf("can't find file: %w", err)
+	}
+	return o.(fs.IDer).ID(), nil
+}
+
+// listRevisions lists the revisions of the file with id
+//
+// Files without any revisions return an empty list.
+func (f *Fs) listRevisions(ctx context.Context, id string) (revisions []*drive.Revision, err error) {
+	revisions = []*drive.Revision{}
+	list := f.svc.Revisions.List(id).PageSize(1000).Fields(googleapi.Field("nextPageToken,revisions(" + revisionFields + ")"))
+	for {
+		var revisionList *drive.RevisionList
+		err = f.pacer.Call(func() (bool, error) {
+			revisionList, err = list.Context(ctx).Do()
+			return f.shouldRetry(ctx, err)
+		})
+		if err != nil {
+			return nil, fmt.Errorf("listing revisions failed: %w", err)
+		}
+		revisions = append(revisions, revisionList.Revisions...)
+		if revisionList.NextPageToken == "" {
+			break
+		}
+		list.PageToken(revisionList.NextPageToken)
+	}
+	return revisions, nil
+}
+
+// getRevision downloads the revision revisionID of the file at remote
+// to the rclone path dest in the same way as copyID
+func (f *Fs) getRevision(ctx context.Context, remote, revisionID, dest string) (err error) {
+	id, err := f.fileID(ctx, remote)
+	if err != nil {
+		return err
+	}
+	var revision *drive.Revision
+	err = f.pacer.Call(func() (bool, error) {
+		revision, err = f.svc.Revisions.Get(id, revisionID).Fields(revisionFields).Context(ctx).Do()
+		return f.shouldRetry(ctx, err)
+	})
+	if err != nil {
+		return fmt.Errorf("couldn't find revision: %w", err)
+	}
+	if strings.HasPrefix(revision.MimeType, "application/vnd.google-apps.") {
+		return fmt.Errorf("can't download revision %q as revisions of Google docs can only be exported", revisionID)
+	}
+	destDir, destLeaf, err := fspath.Split(dest)
+	if err != nil {
+		return err
+	}
+	if destLeaf == "" {
+		destLeaf = path.Base(strings.Trim(remote, "/"))
+	}
+	if destDir == "" {
+		destDir = "."
+	}
+	dstFs, err := cache.Get(ctx, destDir)
+	if err != nil {
+		return err
+	}
+	if operations.SkipDestructive(ctx, path.Join(destDir, destLeaf), "download revision") {
+		return nil
+	}
+	var res *http.Response
+	err = f.pacer.Call(func() (bool, error) {
+		res, err = f.svc.Revisions.Get(id, revisionID).AcknowledgeAbuse(f.opt.AcknowledgeAbuse).Context(ctx).Download()
+		return f.shouldRetry(ctx, err)
+	})
+	if err != nil {
+		return fmt.Errorf("download revision failed: %w", err)
+	}
+	defer fs.CheckClose(res.Body, &err)
+	modTime, err := time.Parse(timeFormatIn, revision.ModifiedTime)
+	if err != nil {
+		modTime = time.Now()
+	}
+	_, err = operations.Rcat(ctx, dstFs, destLeaf, res.Body, modTime)
+	if err != nil {
+		return fmt.Errorf("copy failed: %w", err)
+	}
+	return nil
+}
+
 var commandHelp = []fs.CommandHelp{{
 	Name:  "get",
 	Short: "Get command for fetching the drive config parameters",
@@ -3246,6 +3502,64 @@ attempted if possible.
 
 Use the -i flag to see what would be copied before copying.
 `,
+}, {
+	Name:  "revisions",
+	Short: "List the revisions of a file",
+	Long: `This command lists the revisions Google Drive keeps of a file.
+
+Usage:
+
+    rclone backend revisions drive: path/to/file
+
+The path is relative to "drive:". This will return a JSON list of
+objects like this, oldest first
+
+    [
+        {
+            "id": "0B9nmgCvYbGEXcVVLT2c1dE9WMEk0YkZ6SlJ4VlNKdEtzTjh3PQ",
+            "keepForever": true,
+            "md5Checksum": "5d41402abc4b2a76b9719d911017c592",
+            "mimeType": "text/plain",
+            "modifiedTime": "2022-03-19T10:11:12.000Z",
+            "originalFilename": "file.txt",
+            "size": "5"
+        }
+    ]
+
+Revisions which aren't kept forever are deleted by Google Drive after
+30 days or 100 revisions. Use --drive-keep-revision-forever when
+uploading to keep the new revisions of files forever.
+
+Files with no revision history return an empty list. Revisions of
+Google docs don't have a size or checksum and can't be downloaded with
+revision-get.
+
+Each page of up to 1000 revisions listed uses one query of the Drive
+API quota, as does finding the file, so listing the revisions of a lot
+of files may use up the quota quickly.
+`,
+}, {
+	Name:  "revision-get",
+	Short: "Download a revision of a file",
+	Long: `This command downloads a revision of a file by its ID.
+
+Usage:
+
+    rclone backend revision-get drive: path/to/file REVISION_ID path
+
+It downloads the revision with the ID given, as shown by the revisions
+command, of the file at "path/to/file" relative to "drive:" to the path
+(an rclone path which will be passed internally to rclone rcat).
+
+The path should end with a / to indicate download the revision with
+the name of the file to this directory. If it doesn't end with a /
+then the last path component will be used as the file name.
+
+The modification time of the downloaded file is set to that of the
+revision. Revisions of Google docs can't be downloaded.
+
+Use the -i flag to see what would be downloaded before downloading.
+`,
 }}
 
 // Command the backend to run a named command
@@ -3344,6 +3658,20 @@ func (f *Fs) Command(ctx context.Context, name string, arg []string, opt map[str
 			}
 		}
 		return nil, nil
+	case "revisions":
+		if len(arg) != 1 {
+			return nil, errors.New("need exactly 1 argument")
+		}
+		id, err := f.fileID(ctx, arg[0])
+		if err != nil {
+			return nil, err
+		}
+		return f.listRevisions(ctx, id)
+	case "revision-get":
+		if len(arg) != 3 {
+			return nil, errors.New("need exactly 3 arguments")
+		}
+		return nil, f.getRevision(ctx, arg[0], arg[1], arg[2])
 	default:
 		return nil, fs.ErrorCommandNotFound
 	}
@@ -3462,7 +3790,7 @@ func (o *baseObject) SetModTime(ctx context.Context, modTime time.Time) error {
 	err := o.fs.pacer.Call(func() (bool, error) {
 		var err error
 		info, err = o.fs.svc.Files.Update(actualID(o.id), updateInfo).
-			Fields(partialFields).
+			Fields(o.fs.fileFields).
 			SupportsAllDrives(true).
 			Context(ctx).Do()
 		return o.fs.shouldRetry(ctx, err)
@@ -3470,8 +3798,9 @@ func (o *baseObject) SetModTime(ctx context.Context, modTime time.Time) error {
 	if err != nil {
 		return err
 	}
-	// Update info from read data
-	o.modifiedDate = info.ModifiedTime
+	// Update info from read data - this is still the created or
+	// shared time with the options which select them
+	o.modifiedDate = o.fs.fileModTime(info)
 	return nil
 }
 
@@ -18,12 +18,14 @@ import (
 
 	_ "github.com/rclone/rclone/backend/local"
 	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fs/config/configmap"
 	"github.com/rclone/rclone/fs/filter"
 	"github.com/rclone/rclone/fs/hash"
 	"github.com/rclone/rclone/fs/operations"
 	"github.com/rclone/rclone/fs/sync"
 	"github.com/rclone/rclone/fstest"
 	"github.com/rclone/rclone/fstest/fstests"
+	"github.com/rclone/rclone/fstest/mockfs"
 	"github.com/rclone/rclone/lib/random"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -55,6 +57,74 @@ func TestDriveScopes(t *testing.T) {
 	}
 }
 
+func TestModTimeQuery(t *testing.T) {
+	tm := time.Date(2022, 3, 4, 5, 6, 7, 0, time.FixedZone("UTC+1", 3600))
+	assert.Equal(t,
+		"(modifiedTime >= '2022-03-04T04:06:07' or mimeType = 'application/vnd.google-apps.folder')",
+		modTimeQuery("modifiedTime", ">=", tm, false))
+	assert.Equal(t,
+		"(modifiedTime <= '2022-03-04T04:06:07' or mimeType = 'application/vnd.google-apps.folder' or mimeType = 'application/vnd.google-apps.shortcut')",
+		modTimeQuery("modifiedTime", "<=", tm, true))
+	assert.Equal(t,
+		"(createdTime >= '2022-03-04T04:06:07' or mimeType = 'application/vnd.google-apps.folder')",
+		modTimeQuery("createdTime", ">=", tm, false))
+}
+
+func TestFileModTime(t *testing.T) {
+	info := &drive.File{
+		ModifiedTime:     "2022-01-01T00:00:00.000Z",
+		CreatedTime:      "2021-01-01T00:00:00.000Z",
+		SharedWithMeTime: "2020-01-01T00:00:00.000Z",
+	}
+	notShared := &drive.File{
+		ModifiedTime: info.ModifiedTime,
+		CreatedTime:  info.CreatedTime,
+	}
+	for _, test := range []struct {
+		useCreated, useShared bool
+		want, wantNotShared   string
+		wantField             string
+	}{
+		{false, false, info.ModifiedTime, info.ModifiedTime, "modifiedTime"},
+		{true, false, info.CreatedTime, info.CreatedTime, "createdTime"},
+		{false, true, info.SharedWithMeTime, info.ModifiedTime, ""},
+		{true, true, info.CreatedTime, info.CreatedTime, "createdTime"},
+	} {
+		f := &Fs{opt: Options{UseCreatedDate: test.useCreated, UseSharedDate: test.useShared}}
+		what := fmt.Sprintf("created=%v shared=%v", test.useCreated, test.useShared)
+		assert.Equal(t, test.want, f.fileModTime(info), what)
+		assert.Equal(t, test.wantNotShared, f.fileModTime(notShared), what)
+		assert.Equal(t, test.wantField, f.modTimeField(), what)
+		o := f.newBaseObject("file", info)
+		assert.Equal(t, test.want, o.modifiedDate, what)
+	}
+}
+
+func TestParseResourceKeys(t *testing.T) {
+	for _, test := range []struct {
+		in      fs.CommaSepList
+		want    string
+		wantErr bool
+	}{
+		{nil, "", false},
+		{fs.CommaSepList{"id1=key1", " id2=key2 "}, "id1/key1,id2/key2", false},
+		{fs.CommaSepList{"https://drive.google.com/file/d/id1/view?usp=sharing&resourcekey=key1"}, "id1/key1", false},
+		{fs.CommaSepList{"https://drive.google.com/drive/folders/id2?resourcekey=key2"}, "id2/key2", false},
+		{fs.CommaSepList{"https://drive.google.com/open?id=id3&resourcekey=key3"}, "id3/key3", false},
+		{fs.CommaSepList{"id1"}, "", true},
+		{fs.CommaSepList{"id1="}, "", true},
+		{fs.CommaSepList{"https://drive.google.com/file/d/id1/view"}, "", true},
+	} {
+		got, err := parseResourceKeys(test.in)
+		if test.wantErr {
+			assert.Error(t, err, test.in)
+		} else {
+			assert.NoError(t, err, test.in)
+		}
+		assert.Equal(t, test.want, got, test.in)
+	}
+}
+
 /*
 var additionalMimeTypes = map[string]string{
 	"application/vnd.ms-excel.sheet.macroenabled.12":                          ".xlsm",
@@ -190,6 +260,75 @@ func TestExtensionsForImportFormats(t *testing.T) {
 	}
 }
 
+func TestInternalSharedDrivesRoute(t *testing.T) {
+	ctx := context.Background()
+	myDrive := &Fs{}
+	driveFs := mockfs.NewFs(ctx, "drive", "")
+	readOnlyFs := mockfs.NewFs(ctx, "drive", "")
+	f := &sharedDrivesFs{
+		myDrive: myDrive,
+		drives: map[string]*sharedDrive{
+			"Team":     {id: "id1", name: "Team"},
+			"ReadOnly": {id: "id2", name: "ReadOnly", readOnly: true},
+		},
+		fses: map[string]fs.Fs{
+			"id1": driveFs,
+			"id2": readOnlyFs,
+		},
+	}
+	for _, test := range []struct {
+		root      string
+		remote    string
+		target    fs.Fs
+		innerPath string
+		writeErr  bool
+	}{
+		{"", "", myDrive, "", false},
+		{"", "dir/file", myDrive, "dir/file", false},
+		{"", "shared-drivesX/file", myDrive, "shared-drivesX/file", false},
+		{"", "shared-drives", nil, "", true},
+		{"", "shared-drives/Team", driveFs, "", true},
+		{"", "shared-drives/Team/dir/file", driveFs, "dir/file", false},
+		{"shared-drives", "Team/file", driveFs, "file", false},
+		{"shared-drives/Team/dir", "file", driveFs, "dir/file", false},
+		{"", "shared-drives/ReadOnly/file", readOnlyFs, "file", true},
+	} {
+		what := fmt.Sprintf("root=%q, remote=%q", test.root, test.remote)
+		f.root = test.root
+		target, _, innerPath, err := f.route(ctx, test.remote)
+		require.NoError(t, err, what)
+		assert.True(t, test.target == target, what)
+		assert.Equal(t, test.innerPath, innerPath, what)
+		_, _, err = f.routeWrite(ctx, test.remote)
+		if test.writeErr {
+			assert.True(t, errors.Is(err, fs.ErrorPermissionDenied), what)
+		} else {
+			assert.NoError(t, err, what)
+		}
+	}
+}
+
+func TestInternalOverrideMapper(t *testing.T) {
+	m := overrideMapper{
+		Mapper:   configmap.Simple{"a": "1", "b": "2"},
+		override: configmap.Simple{"b": "3", "c": ""},
+	}
+	for _, test := range []struct {
+		key    string
+		want   string
+		wantOK bool
+	}{
+		{"a", "1", true},
+		{"b", "3", true},
+		{"c", "", true},
+		{"d", "", false},
+	} {
+		got, ok := m.Get(test.key)
+		assert.Equal(t, test.want, got, test.key)
+		assert.Equal(t, test.wantOK, ok, test.key)
+	}
+}
+
 func (f *Fs) InternalTestDocumentImport(t *testing.T) {
 	oldAllow := f.opt.AllowImportNameChange
 	f.opt.AllowImportNameChange = true
@@ -464,6 +603,47 @@ func (f *Fs) InternalTestCopyID(t *testing.T) {
 	})
 }
 
+// TestIntegration/FsMkdir/FsPutFiles/Internal/Revisions
+func (f *Fs) InternalTestRevisions(t *testing.T) {
+	ctx := context.Background()
+
+	dir, err := ioutil.TempDir("", "rclone-drive-revisions-test")
+	require.NoError(t, err)
+	defer func() {
+		_ = os.RemoveAll(dir)
+	}()
+
+	t.Run("NotFound", func(t *testing.T) {
+		_, err := f.Command(ctx, "revisions", []string{"not/found.txt"}, nil)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "can't find file")
+	})
+
+	var revisions []*drive.Revision
+	t.Run("List", func(t *testing.T) {
+		out, err := f.Command(ctx, "revisions", []string{existingFile}, nil)
+		require.NoError(t, err)
+		revisions = out.([]*drive.Revision)
+		require.NotEmpty(t, revisions)
+		assert.Equal(t, int64(100), revisions[len(revisions)-1].Size)
+	})
+
+	t.Run("BadID", func(t *testing.T) {
+		err := f.getRevision(ctx, existingFile, "ID-NOT-FOUND", dir+"/")
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "couldn't find revision")
+	})
+
+	t.Run("Get", func(t *testing.T) {
+		require.NotEmpty(t, revisions)
+		err := f.getRevision(ctx, existingFile, revisions[len(revisions)-1].Id, dir+"/potato.txt")
+		require.NoError(t, err)
+		fi, err := os.Stat(filepath.Join(dir, "potato.txt"))
+		require.NoError(t, err)
+		assert.Equal(t, int64(100), fi.Size())
+	})
+}
+
 // TestIntegration/FsMkdir/FsPutFiles/Internal/AgeQuery
 func (f *Fs) InternalTestAgeQuery(t *testing.T) {
 	opt := &filter.Opt{}
@@ -556,6 +736,7 @@ func (f *Fs) InternalTest(t *testing.T) {
 	t.Run("Shortcuts", f.InternalTestShortcuts)
 	t.Run("UnTrash", f.InternalTestUnTrash)
 	t.Run("CopyID", f.InternalTestCopyID)
+	t.Run("Revisions", f.InternalTestRevisions)
 	t.Run("AgeQuery", f.InternalTestAgeQuery)
 }
 
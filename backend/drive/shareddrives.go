@@ -0,0 +1,466 @@
+// This implements the all_shared_drives option which shows all the
+// Shared Drives the user can access under a virtual directory.
+
+package drive
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fs/config/configmap"
+	"github.com/rclone/rclone/fs/hash"
+	"github.com/rclone/rclone/fs/operations"
+)
+
+// sharedDrivesDir is the name of the virtual directory containing
+// the Shared Drives
+const sharedDrivesDir = "shared-drives"
+
+// overrideMapper is a configmap.Mapper which overrides some keys
+type overrideMapper struct {
+	configmap.Mapper
+	override configmap.Simple
+}
+
+// Get a value, looking in the overrides first
+func (m overrideMapper) Get(key string) (value string, ok bool) {
+	value, ok = m.override[key]
+	if ok {
+		return value, ok
+	}
+	return m.Mapper.Get(key)
+}
+
+// sharedDrive describes a Shared Drive in the sharedDrivesDir
+type sharedDrive struct {
+	id       string // ID of the drive
+	name     string // name of the drive as returned by the API
+	readOnly bool   // set if we can't add files to the drive
+}
+
+// sharedDrivesFs shows My Drive at the root and a directory for each
+// Shared Drive in sharedDrivesDir, passing each operation to the Fs
+// for the right drive.
+type sharedDrivesFs struct {
+	name     string           // name of this remote
+	root     string           // the path we are working on
+	m        configmap.Mapper // config for making the Fs for each drive
+	myDrive  *Fs              // My Drive, rooted at the top
+	features *fs.Features     // optional features
+
+	mu     sync.Mutex
+	drives map[string]*sharedDrive // drives by directory name, nil if not read yet
+	fses   map[string]fs.Fs        // Fs for each drive by ID
+}
+
+// newSharedDrivesFs makes an Fs for the all_shared_drives option
+func newSharedDrivesFs(ctx context.Context, name, root string, m configmap.Mapper) (fs.Fs, error) {
+	myDrive, err := NewFs(ctx, name, "", overrideMapper{
+		Mapper:   m,
+		override: configmap.Simple{"all_shared_drives": "false"},
+	})
+	if err != nil {
+		return nil, err
+	}
+	root, err = parseDrivePath(root)
+	if err != nil {
+		return nil, err
+	}
+	f := &sharedDrivesFs{
+		name:    name,
+		root:    root,
+		m:       m,
+		myDrive: myDrive.(*Fs),
+		fses:    make(map[string]fs.Fs),
+	}
+	f.features = (&fs.Features{
+		DuplicateFiles:          true,
+		ReadMimeType:            true,
+		WriteMimeType:           true,
+		CanHaveEmptyDirectories: true,
+		ServerSideAcrossConfigs: f.myDrive.opt.ServerSideAcrossConfigs,
+	}).Fill(ctx, f)
+
+	// See if the root points to a file
+	if root != "" {
+		parent, leaf := path.Split(root)
+		f.root = strings.TrimSuffix(parent, "/")
+		_, err := f.NewObject(ctx, leaf)
+		if err == nil {
+			return f, fs.ErrorIsFile
+		}
+		f.root = root
+	}
+	return f, nil
+}
+
+// readDrives reads the Shared Drives, naming the directory for each
+//
+// Call with f.mu held
+func (f *sharedDrivesFs) readDrives(ctx context.Context) error {
+	drives, err := f.myDrive.listTeamDrives(ctx, "nextPageToken", "drives(id,name,capabilities/canAddChildren)")
+	if err != nil {
+		return err
+	}
+	count := make(map[string]int, len(drives))
+	for _, d := range drives {
+		count[d.Name]++
+	}
+	f.drives = make(map[string]*sharedDrive, len(drives))
+	for _, d := range drives {
+		dirName := f.myDrive.opt.Enc.ToStandardName(d.Name)
+		if count[d.Name] > 1 {
+			dirName = fmt.Sprintf("%s {%s}", dirName, d.Id)
+			fs.Debugf(f, "Naming Shared Drive %q as %q as its name isn't unique", d.Name, dirName)
+		}
+		f.drives[dirName] = &sharedDrive{
+			id:       d.Id,
+			name:     d.Name,
+			readOnly: d.Capabilities != nil && !d.Capabilities.CanAddChildren,
+		}
+	}
+	return nil
+}
+
+// findDrive finds the Shared Drive with directory name dirName
+func (f *sharedDrivesFs) findDrive(ctx context.Context, dirName string) (*sharedDrive, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	d, found := f.drives[dirName]
+	if !found {
+		// Re-read the drives in case one has been added
+		err := f.readDrives(ctx)
+		if err != nil {
+			return nil, err
+		}
+		d, found = f.drives[dirName]
+	}
+	if !found {
+		return nil, fs.ErrorDirNotFound
+	}
+	return d, nil
+}
+
+// driveFs returns the Fs for the Shared Drive d
+func (f *sharedDrivesFs) driveFs(ctx context.Context, d *sharedDrive) (fs.Fs, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if driveFs, found := f.fses[d.id]; found {
+		return driveFs, nil
+	}
+	driveFs, err := NewFs(ctx, f.name, "", overrideMapper{
+		Mapper: f.m,
+		override: configmap.Simple{
+			"all_shared_drives": "false",
+			"team_drive":        d.id,
+			"root_folder_id":    "",
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open Shared Drive %q: %w", d.name, err)
+	}
+	f.fses[d.id] = driveFs
+	return driveFs, nil
+}
+
+// route finds the Fs which remote is on and the path within it.
+//
+// If remote is the sharedDrivesDir itself then target is nil. If it
+// is in a Shared Drive then d is set.
+func (f *sharedDrivesFs) route(ctx context.Context, remote string) (target fs.Fs, d *sharedDrive, innerPath string, err error) {
+	fullPath := path.Join(f.root, remote)
+	if fullPath != sharedDrivesDir && !strings.HasPrefix(fullPath, sharedDrivesDir+"/") {
+		return f.myDrive, nil, fullPath, nil
+	}
+	rest := strings.TrimPrefix(strings.TrimPrefix(fullPath, sharedDrivesDir), "/")
+	if rest == "" {
+		return nil, nil, "", nil
+	}
+	dirName, innerPath := rest, ""
+	if i := strings.IndexRune(rest, '/'); i >= 0 {
+		dirName, innerPath = rest[:i], rest[i+1:]
+	}
+	d, err = f.findDrive(ctx, dirName)
+	if err != nil {
+		return nil, nil, "", err
+	}
+	target, err = f.driveFs(ctx, d)
+	if err != nil {
+		return nil, nil, "", err
+	}
+	return target, d, innerPath, nil
+}
+
+// routeWrite is like route but returns an error if remote can't be
+// written to.
+func (f *sharedDrivesFs) routeWrite(ctx context.Context, remote string) (target fs.Fs, innerPath string, err error) {
+	target, d, innerPath, err := f.route(ctx, remote)
+	if err != nil {
+		return nil, "", err
+	}
+	if target == nil || (d != nil && innerPath == "") {
+		return nil, "", fmt.Errorf("can't write to %q: %w", remote, fs.ErrorPermissionDenied)
+	}
+	if d != nil && d.readOnly {
+		return nil, "", fmt.Errorf("can't write to read only Shared Drive %q: %w", d.name, fs.ErrorPermissionDenied)
+	}
+	return target, innerPath, nil
+}
+
+// wrapObject wraps o so it appears at remote in f
+func (f *sharedDrivesFs) wrapObject(o fs.Object, remote string) fs.Object {
+	return &sharedDrivesObject{Object: o, f: f, remote: remote}
+}
+
+// Name of the remote (as passed into NewFs)
+func (f *sharedDrivesFs) Name() string {
+	return f.name
+}
+
+// Root of the remote (as passed into NewFs)
+func (f *sharedDrivesFs) Root() string {
+	return f.root
+}
+
+// String converts this Fs to a string
+func (f *sharedDrivesFs) String() string {
+	return fmt.Sprintf("Google drive root '%s' with all Shared Drives", f.root)
+}
+
+// Features returns the optional features of this Fs
+func (f *sharedDrivesFs) Features() *fs.Features {
+	return f.features
+}
+
+// Precision of the ModTimes in this Fs
+func (f *sharedDrivesFs) Precision() time.Duration {
+	return f.myDrive.Precision()
+}
+
+// Hashes returns the supported hash sets.
+func (f *sharedDrivesFs) Hashes() hash.Set {
+	return f.myDrive.Hashes()
+}
+
+// List the objects and directories in dir into entries.
+func (f *sharedDrivesFs) List(ctx context.Context, dir string) (entries fs.DirEntries, err error) {
+	target, _, innerPath, err := f.route(ctx, dir)
+	if err != nil {
+		return nil, err
+	}
+	if target == nil {
+		f.mu.Lock()
+		err = f.readDrives(ctx)
+		for dirName, d := range f.drives {
+			entries = append(entries, fs.NewDir(path.Join(dir, dirName), time.Time{}).SetID(d.id))
+		}
+		f.mu.Unlock()
+		if err != nil {
+			return nil, err
+		}
+		sort.Sort(entries)
+		return entries, nil
+	}
+	innerEntries, err := target.List(ctx, innerPath)
+	if err != nil {
+		return nil, err
+	}
+	isTop := path.Join(f.root, dir) == ""
+	for _, entry := range innerEntries {
+		remote := path.Join(dir, path.Base(entry.Remote()))
+		if isTop && remote == sharedDrivesDir {
+			fs.Logf(f, "Hiding %q in My Drive as it has the same name as the virtual Shared Drives directory", sharedDrivesDir)
+			continue
+		}
+		switch x := entry.(type) {
+		case fs.Object:
+			entries = append(entries, f.wrapObject(x, remote))
+		case fs.Directory:
+			entries = append(entries, fs.NewDirCopy(ctx, x).SetRemote(remote))
+		}
+	}
+	if isTop {
+		entries = append(entries, fs.NewDir(path.Join(dir, sharedDrivesDir), time.Time{}))
+	}
+	return entries, nil
+}
+
+// NewObject finds the Object at remote.
+func (f *sharedDrivesFs) NewObject(ctx context.Context, remote string) (fs.Object, error) {
+	target, _, innerPath, err := f.route(ctx, remote)
+	if err == fs.ErrorDirNotFound {
+		return nil, fs.ErrorObjectNotFound
+	} else if err != nil {
+		return nil, err
+	}
+	if target == nil || innerPath == "" {
+		return nil, fs.ErrorIsDir
+	}
+	o, err := target.NewObject(ctx, innerPath)
+	if err != nil {
+		return nil, err
+	}
+	return f.wrapObject(o, remote), nil
+}
+
+// Put the object into the right drive
+func (f *sharedDrivesFs) Put(ctx context.Context, in io.Reader, src fs.ObjectInfo, options ...fs.OpenOption) (fs.Object, error) {
+	remote := src.Remote()
+	target, innerPath, err := f.routeWrite(ctx, remote)
+	if err != nil {
+		return nil, err
+	}
+	o, err := target.Put(ctx, in, operations.NewOverrideRemote(src, innerPath), options...)
+	if err != nil {
+		return nil, err
+	}
+	return f.wrapObject(o, remote), nil
+}
+
+// PutStream uploads to the right drive with indeterminate size
+func (f *sharedDrivesFs) PutStream(ctx context.Context, in io.Reader, src fs.ObjectInfo, options ...fs.OpenOption) (fs.Object, error) {
+	remote := src.Remote()
+	target, innerPath, err := f.routeWrite(ctx, remote)
+	if err != nil {
+		return nil, err
+	}
+	o, err := target.Features().PutStream(ctx, in, operations.NewOverrideRemote(src, innerPath), options...)
+	if err != nil {
+		return nil, err
+	}
+	return f.wrapObject(o, remote), nil
+}
+
+// Mkdir makes the directory (container, bucket)
+func (f *sharedDrivesFs) Mkdir(ctx context.Context, dir string) error {
+	target, d, innerPath, err := f.route(ctx, dir)
+	if err != nil {
+		return err
+	}
+	if target == nil || (d != nil && innerPath == "") {
+		// virtual directories and drive roots always exist
+		return nil
+	}
+	if d != nil && d.readOnly {
+		return fmt.Errorf("can't make directory in read only Shared Drive %q: %w", d.name, fs.ErrorPermissionDenied)
+	}
+	return target.Mkdir(ctx, innerPath)
+}
+
+// Rmdir removes the directory (container, bucket) if empty
+func (f *sharedDrivesFs) Rmdir(ctx context.Context, dir string) error {
+	target, innerPath, err := f.routeWrite(ctx, dir)
+	if err != nil {
+		return err
+	}
+	return target.Rmdir(ctx, innerPath)
+}
+
+// serverSide does a server-side Copy or Move of src to remote
+func (f *sharedDrivesFs) serverSide(ctx context.Context, src fs.Object, remote string, move bool) (fs.Object, error) {
+	srcObj, ok := src.(*sharedDrivesObject)
+	if !ok {
+		fs.Debugf(src, "Can't copy - not same remote type")
+		return nil, fs.ErrorCantCopy
+	}
+	target, innerPath, err := f.routeWrite(ctx, remote)
+	if err != nil {
+		return nil, err
+	}
+	var o fs.Object
+	if move {
+		o, err = target.Features().Move(ctx, srcObj.Object, innerPath)
+	} else {
+		o, err = target.Features().Copy(ctx, srcObj.Object, innerPath)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return f.wrapObject(o, remote), nil
+}
+
+// Copy src to this remote using server-side copy operations.
+func (f *sharedDrivesFs) Copy(ctx context.Context, src fs.Object, remote string) (fs.Object, error) {
+	return f.serverSide(ctx, src, remote, false)
+}
+
+// Move src to this remote using server-side move operations.
+func (f *sharedDrivesFs) Move(ctx context.Context, src fs.Object, remote string) (fs.Object, error) {
+	o, err := f.serverSide(ctx, src, remote, true)
+	if err == fs.ErrorCantCopy {
+		err = fs.ErrorCantMove
+	}
+	return o, err
+}
+
+// About gets quota information for My Drive
+func (f *sharedDrivesFs) About(ctx context.Context) (*fs.Usage, error) {
+	return f.myDrive.About(ctx)
+}
+
+// sharedDrivesObject is an Object from one of the drives shown in a
+// sharedDrivesFs
+type sharedDrivesObject struct {
+	fs.Object
+	f      *sharedDrivesFs
+	remote string
+}
+
+// Fs returns read only access to the Fs that this object is part of
+func (o *sharedDrivesObject) Fs() fs.Info {
+	return o.f
+}
+
+// Remote returns the remote path
+func (o *sharedDrivesObject) Remote() string {
+	return o.remote
+}
+
+// String returns a description of the Object
+func (o *sharedDrivesObject) String() string {
+	if o == nil {
+		return "<nil>"
+	}
+	return o.remote
+}
+
+// UnWrap returns the Object that this Object is wrapping
+func (o *sharedDrivesObject) UnWrap() fs.Object {
+	return o.Object
+}
+
+// MimeType of an Object if known, "" otherwise
+func (o *sharedDrivesObject) MimeType(ctx context.Context) string {
+	if do, ok := o.Object.(fs.MimeTyper); ok {
+		return do.MimeType(ctx)
+	}
+	return ""
+}
+
+// ID returns the ID of the Object if known, or "" if not
+func (o *sharedDrivesObject) ID() string {
+	if do, ok := o.Object.(fs.IDer); ok {
+		return do.ID()
+	}
+	return ""
+}
+
+// Check the interfaces are satisfied
+var (
+	_ fs.Fs              = (*sharedDrivesFs)(nil)
+	_ fs.PutStreamer     = (*sharedDrivesFs)(nil)
+	_ fs.Copier          = (*sharedDrivesFs)(nil)
+	_ fs.Mover           = (*sharedDrivesFs)(nil)
+	_ fs.Abouter         = (*sharedDrivesFs)(nil)
+	_ fs.Object          = (*sharedDrivesObject)(nil)
+	_ fs.ObjectUnWrapper = (*sharedDrivesObject)(nil)
+	_ fs.MimeTyper       = (*sharedDrivesObject)(nil)
+	_ fs.IDer            = (*sharedDrivesObject)(nil)
+)
@@ -143,6 +143,7 @@ func init() {
 		Name:        "dropbox",
 		Description: "Dropbox",
 		NewFs:       NewFs,
+		CommandHelp: commandHelp,
 		Config: func(ctx context.Context, name string, m configmap.Mapper, config fs.ConfigIn) (*fs.ConfigOut, error) {
 			return oauthutil.ConfigOut("", &oauthutil.Options{
 				OAuth2Config: getOauthConfig(m),
@@ -1453,6 +1454,90 @@ func (f *Fs) Shutdown(ctx context.Context) error {
 	return nil
 }
 
+var commandHelp = []fs.CommandHelp{{
+	Name:  "list-members",
+	Short: "List the members of a Dropbox for business team",
+	Long: `This lists the members of the team the remote is logged in to
+along with their team member IDs and status.
+
+    rclone backend list-members dropbox:
+
+The email addresses can be used with --dropbox-impersonate to work on
+the files of each team member from one config.
+
+This needs the "members.read" scope which rclone requests when
+"rclone config" is run with --dropbox-impersonate set, so it can only
+be used by a Dropbox Team Admin.
+`,
+}}
+
+// Command the backend to run a named command
+//
+// The command run is name
+// args may be used to read arguments from
+// opts may be used to read optional arguments from
+//
+// The result should be capable of being JSON encoded
+// If it is a string or a []string it will be shown to the user
+// otherwise it will be JSON encoded and shown to the user like that
+func (f *Fs) Command(ctx context.Context, name string, arg []string, opt map[string]string) (out interface{}, err error) {
+	switch name {
+	case "list-members":
+		return f.listMembers(ctx)
+	default:
+		return nil, fs.ErrorCommandNotFound
+	}
+}
+
+// member describes a team member for the list-members command
+type member struct {
+	Email        string `json:"email"`
+	Name         string `json:"name"`
+	TeamMemberID string `json:"teamMemberId"`
+	Status       string `json:"status"`
+}
+
+// listMembers lists the members of the team
+func (f *Fs) listMembers(ctx context.Context) (members []member, err error) {
+	var res *team.MembersListResult
+	err = f.pacer.Call(func() (bool, error) {
+		res, err = f.team.MembersList(team.NewMembersListArg())
+		return shouldRetry(ctx, err)
+	})
+	for {
+		if err != nil {
+			return nil, fmt.Errorf("failed to list team members: %w", err)
+		}
+		for _, info := range res.Members {
+			if info.Profile == nil {
+				continue
+			}
+			m := member{
+				Email:        info.Profile.Email,
+				TeamMemberID: info.Profile.TeamMemberId,
+			}
+			if info.Profile.Name != nil {
+				m.Name = info.Profile.Name.DisplayName
+			}
+			if info.Profile.Status != nil {
+				m.Status = info.Profile.Status.Tag
+			}
+			members = append(members, m)
+		}
+		if !res.HasMore {
+			break
+		}
+		arg := team.MembersListContinueArg{
+			Cursor: res.Cursor,
+		}
+		err = f.pacer.Call(func() (bool, error) {
+			res, err = f.team.MembersListContinue(&arg)
+			return shouldRetry(ctx, err)
+		})
+	}
+	return members, nil
+}
+
 // ------------------------------------------------------------
 
 // Fs returns the parent Fs
@@ -1804,6 +1889,7 @@ var (
 	_ fs.DirMover     = (*Fs)(nil)
 	_ fs.Abouter      = (*Fs)(nil)
 	_ fs.Shutdowner   = &Fs{}
+	_ fs.Commander    = &Fs{}
 	_ fs.Object       = (*Object)(nil)
 	_ fs.IDer         = (*Object)(nil)
 )
@@ -13,6 +13,7 @@ FIXME Patch/Delete/Get isn't working with files with spaces in - giving 404 erro
 */
 
 import (
+	"bytes"
 	"context"
 	"encoding/base64"
 	"encoding/hex"
@@ -740,9 +741,65 @@ func (f *Fs) PutStream(ctx context.Context, in io.Reader, src fs.ObjectInfo, opt
 }
 
 // Mkdir creates the bucket if it doesn't exist
+//
+// If --create-dir-markers is set it creates a directory marker for
+// dir too
 func (f *Fs) Mkdir(ctx context.Context, dir string) (err error) {
-	bucket, _ := f.split(dir)
-	return f.makeBucket(ctx, bucket)
+	bucket, directory := f.split(dir)
+	err = f.makeBucket(ctx, bucket)
+	if err != nil || directory == "" || !fs.GetConfig(ctx).CreateDirMarkers {
+		return err
+	}
+	return f.putDirMarker(ctx, bucket, directory)
+}
+
+// putDirMarker creates a zero length object called directory + "/"
+// which keeps the directory in existence when it is empty
+func (f *Fs) putDirMarker(ctx context.Context, bucket, directory string) (err error) {
+	object := storage.Object{
+		Bucket:      bucket,
+		Name:        directory + "/",
+		ContentType: "application/x-directory",
+	}
+	err = f.pacer.Call(func() (bool, error) {
+		insertObject := f.svc.Objects.Insert(bucket, &object).Media(bytes.NewReader(nil), googleapi.ContentType("")).Name(object.Name)
+		if !f.opt.BucketPolicyOnly {
+			insertObject.PredefinedAcl(f.opt.ObjectACL)
+		}
+		_, err = insertObject.Context(ctx).Do()
+		return shouldRetry(ctx, err)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create directory marker: %w", err)
+	}
+	fs.Debugf(f, "Created directory marker %q", object.Name)
+	return nil
+}
+
+// removeDirMarker removes the directory marker for directory
+//
+// Returns an error if the directory isn't empty
+func (f *Fs) removeDirMarker(ctx context.Context, bucket, directory string) (err error) {
+	empty := true
+	err = f.list(ctx, bucket, directory, f.rootDirectory, f.rootBucket == "", false, func(remote string, object *storage.Object, isDirectory bool) error {
+		empty = false
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if !empty {
+		return fs.ErrorDirectoryNotEmpty
+	}
+	err = f.pacer.Call(func() (bool, error) {
+		err = f.svc.Objects.Delete(bucket, directory+"/").Context(ctx).Do()
+		return shouldRetry(ctx, err)
+	})
+	if gErr, ok := err.(*googleapi.Error); ok && gErr.Code == http.StatusNotFound {
+		// No marker so nothing to remove
+		return nil
+	}
+	return err
 }
 
 // makeBucket creates the bucket if it doesn't exist
@@ -796,8 +853,14 @@ func (f *Fs) makeBucket(ctx context.Context, bucket string) (err error) {
 //
 // Returns an error if it isn't empty: Error 409: The bucket you tried
 // to delete was not empty.
+//
+// If --create-dir-markers is set it removes the directory marker for
+// dir if it isn't the root.
 func (f *Fs) Rmdir(ctx context.Context, dir string) (err error) {
 	bucket, directory := f.split(dir)
+	if bucket != "" && directory != "" && fs.GetConfig(ctx).CreateDirMarkers {
+		return f.removeDirMarker(ctx, bucket, directory)
+	}
 	if bucket == "" || directory != "" {
 		return nil
 	}
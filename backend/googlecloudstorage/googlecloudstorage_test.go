@@ -14,5 +14,6 @@ func TestIntegration(t *testing.T) {
 	fstests.Run(t, &fstests.Opt{
 		RemoteName: "TestGoogleCloudStorage:",
 		NilObject:  (*googlecloudstorage.Object)(nil),
+		DirMarkers: true,
 	})
 }
@@ -171,6 +171,31 @@ preallocated space, causing checksum and file size checks to fail.
 Use this flag to disable preallocation.`,
 			Default:  false,
 			Advanced: true,
+		}, {
+			Name: "fallocate_mode",
+			Help: `How to preallocate disk space on Linux.
+
+Rclone uses fallocate to preallocate the disk space on Linux. Not all
+file systems support all the ways of doing this, so by default rclone
+tries them in turn until one works.
+
+If fallocate isn't supported at all then rclone logs this once and
+carries on without preallocation rather than returning an error.
+
+This has no effect on other platforms or if --local-no-preallocate
+is set.`,
+			Default:  "auto",
+			Advanced: true,
+			Examples: []fs.OptionExample{{
+				Value: "auto",
+				Help:  "Try keep-size then punch-hole",
+			}, {
+				Value: "keep-size",
+				Help:  "Allocate space without changing the file size",
+			}, {
+				Value: "punch-hole",
+				Help:  "Allocate space and punch a hole - needed for ZFS",
+			}},
 		}, {
 			Name: "no_sparse",
 			Help: `Disable sparse files for multi-thread downloads.
@@ -192,6 +217,43 @@ when copying to a CIFS mount owned by another user. If this option is
 enabled, rclone will no longer update the modtime after copying a file.`,
 			Default:  false,
 			Advanced: true,
+		}, {
+			Name: "time_type",
+			Help: `Set what kind of time is used as the modification time.
+
+Normally rclone uses the mtime, the modification time, of files and
+directories. If you set this flag then rclone will use the time chosen
+here instead, for listing, comparing and copying. So if you use
+"rclone lsl --local-time-type btime" you will see the creation times
+in the listing and copying with it will replicate the creation times
+of the source as the modification times of the destination.
+
+If the OS can't supply the chosen time for a file then rclone will use
+the modification time instead, which all OSes support.
+
+- mtime is supported by all OSes
+- atime is supported on Linux, macOS, FreeBSD, NetBSD and Windows
+- btime is supported on macOS, FreeBSD, NetBSD, Windows and Linux
+  filesystems which record it (kernel 4.11 or later)
+- ctime is supported on Linux, macOS, FreeBSD and NetBSD
+
+Setting the time on a local file sets the atime if atime is chosen,
+otherwise it sets the mtime as the other times can't be set.`,
+			Default:  mTime,
+			Advanced: true,
+			Examples: []fs.OptionExample{{
+				Value: mTime,
+				Help:  "The last modification time.",
+			}, {
+				Value: aTime,
+				Help:  "The last access time.",
+			}, {
+				Value: cTime,
+				Help:  "The last status change time.",
+			}, {
+				Value: bTime,
+				Help:  "The creation time.",
+			}},
 		}, {
 			Name:     config.ConfigEncoding,
 			Help:     config.ConfigEncodingHelp,
@@ -214,8 +276,10 @@ type Options struct {
 	CaseSensitive     bool                 `config:"case_sensitive"`
 	CaseInsensitive   bool                 `config:"case_insensitive"`
 	NoPreAllocate     bool                 `config:"no_preallocate"`
+	FallocateMode     string               `config:"fallocate_mode"`
 	NoSparse          bool                 `config:"no_sparse"`
 	NoSetModTime      bool                 `config:"no_set_modtime"`
+	TimeType          string               `config:"time_type"`
 	Enc               encoder.MultiEncoder `config:"encoding"`
 }
 
@@ -226,6 +290,7 @@ type Fs struct {
 	opt         Options             // parsed config options
 	features    *fs.Features        // optional features
 	dev         uint64              // device number of root node
+	fallocMode  file.FallocateMode  // how to preallocate space
 	precisionOk sync.Once           // Whether we need to read the precision
 	precision   time.Duration       // precision of local filesystem
 	warnedMu    sync.Mutex          // used for locking access to 'warned'.
@@ -265,13 +330,22 @@ func NewFs(ctx context.Context, name, root string, m configmap.Mapper) (fs.Fs, e
 	if opt.TranslateSymlinks && opt.FollowSymlinks {
 		return nil, errLinksAndCopyLinks
 	}
+	fallocMode, err := file.ParseFallocateMode(opt.FallocateMode)
+	if err != nil {
+		return nil, err
+	}
+	err = checkTimeType(opt.TimeType)
+	if err != nil {
+		return nil, err
+	}
 
 	f := &Fs{
-		name:   name,
-		opt:    *opt,
-		warned: make(map[string]struct{}),
-		dev:    devUnset,
-		lstat:  os.Lstat,
+		name:       name,
+		opt:        *opt,
+		warned:     make(map[string]struct{}),
+		dev:        devUnset,
+		fallocMode: fallocMode,
+		lstat:      os.Lstat,
 	}
 	f.root = cleanRootPath(root, f.opt.NoUNC, f.opt.Enc)
 	f.features = (&fs.Features{
@@ -510,7 +584,7 @@ func (f *Fs) List(ctx context.Context, dir string) (entries fs.DirEntries, err e
 				// Ignore directories which are symlinks.  These are junction points under windows which
 				// are kind of a souped up symlink. Unix doesn't have directories which are symlinks.
 				if (mode&os.ModeSymlink) == 0 && f.dev == readDevice(fi, f.opt.OneFileSystem) {
-					d := fs.NewDir(newRemote, fi.ModTime())
+					d := fs.NewDir(newRemote, readTime(f.opt.TimeType, filepath.Join(fsDirPath, name), fi))
 					entries = append(entries, d)
 				}
 			} else {
@@ -942,11 +1016,20 @@ func (o *Object) SetModTime(ctx context.Context, modTime time.Time) error {
 	if o.fs.opt.NoSetModTime {
 		return nil
 	}
+	// Only the atime can be set as well as the mtime
+	atime, mtime := modTime, modTime
+	if o.fs.opt.TimeType == aTime {
+		fi, err := o.fs.lstat(o.path)
+		if err != nil {
+			return err
+		}
+		mtime = fi.ModTime()
+	}
 	var err error
 	if o.translatedLink {
-		err = lChtimes(o.path, modTime, modTime)
+		err = lChtimes(o.path, atime, mtime)
 	} else {
-		err = os.Chtimes(o.path, modTime, modTime)
+		err = os.Chtimes(o.path, atime, mtime)
 	}
 	if err != nil {
 		return err
@@ -999,8 +1082,12 @@ func (file *localOpenFile) Read(p []byte) (n int, err error) {
 		if oldsize != fi.Size() {
 			return 0, fserrors.NoLowLevelRetryError(fmt.Errorf("can't copy - source file is being updated (size changed from %d to %d)", oldsize, fi.Size()))
 		}
-		if !oldtime.Equal(fi.ModTime()) {
-			return 0, fserrors.NoLowLevelRetryError(fmt.Errorf("can't copy - source file is being updated (mod time changed from %v to %v)", oldtime, fi.ModTime()))
+		// Reading the file may change the atime so don't check it
+		if file.o.fs.opt.TimeType != aTime {
+			newtime := readTime(file.o.fs.opt.TimeType, file.o.path, fi)
+			if !oldtime.Equal(newtime) {
+				return 0, fserrors.NoLowLevelRetryError(fmt.Errorf("can't copy - source file is being updated (mod time changed from %v to %v)", oldtime, newtime))
+			}
 		}
 	}
 
@@ -1157,7 +1244,7 @@ func (o *Object) Update(ctx context.Context, in io.Reader, src fs.ObjectInfo, op
 		}
 		if !o.fs.opt.NoPreAllocate {
 			// Pre-allocate the file for performance reasons
-			err = file.PreAllocate(src.Size(), f)
+			err = file.PreAllocateWithMode(src.Size(), f, o.fs.fallocMode)
 			if err != nil {
 				fs.Debugf(o, "Failed to pre-allocate: %v", err)
 				if err == file.ErrDiskFull {
@@ -1252,7 +1339,7 @@ func (f *Fs) OpenWriterAt(ctx context.Context, remote string, size int64) (fs.Wr
 	}
 	// Pre-allocate the file for performance reasons
 	if !f.opt.NoPreAllocate {
-		err = file.PreAllocate(size, out)
+		err = file.PreAllocateWithMode(size, out, f.fallocMode)
 		if err != nil {
 			fs.Debugf(o, "Failed to pre-allocate: %v", err)
 		}
@@ -1271,6 +1358,40 @@ func (f *Fs) OpenWriterAt(ctx context.Context, remote string, size int64) (fs.Wr
 	return out, nil
 }
 
+// OpenWriterAtResume opens with a handle for random access writes
+// like OpenWriterAt but keeps the data already in the file so an
+// upload can be carried on where it left off.
+//
+// The file is truncated to size if it is longer but it isn't
+// pre-allocated, so its size is only what has been written.
+func (f *Fs) OpenWriterAtResume(ctx context.Context, remote string, size int64) (fs.WriterAtCloser, error) {
+	// Temporary Object under construction
+	o := f.newObject(remote)
+
+	err := o.mkdirAll()
+	if err != nil {
+		return nil, err
+	}
+
+	if o.translatedLink {
+		return nil, errors.New("can't open a symlink for random writing")
+	}
+
+	out, err := file.OpenFile(o.path, os.O_WRONLY|os.O_CREATE, 0666)
+	if err != nil {
+		return nil, err
+	}
+	fi, err := out.Stat()
+	if err == nil && fi.Size() > size {
+		err = out.Truncate(size)
+	}
+	if err != nil {
+		_ = out.Close()
+		return nil, err
+	}
+	return out, nil
+}
+
 // setMetadata sets the file info from the os.FileInfo passed in
 func (o *Object) setMetadata(info os.FileInfo) {
 	// if not checking updated then don't update the stat
@@ -1279,7 +1400,7 @@ func (o *Object) setMetadata(info os.FileInfo) {
 	}
 	o.fs.objectMetaMu.Lock()
 	o.size = info.Size()
-	o.modTime = info.ModTime()
+	o.modTime = readTime(o.fs.opt.TimeType, o.path, info)
 	o.mode = info.Mode()
 	o.fs.objectMetaMu.Unlock()
 	// Read the size of the link.
@@ -1351,12 +1472,13 @@ func cleanRootPath(s string, noUNC bool, enc encoder.MultiEncoder) string {
 
 // Check the interfaces are satisfied
 var (
-	_ fs.Fs             = &Fs{}
-	_ fs.Purger         = &Fs{}
-	_ fs.PutStreamer    = &Fs{}
-	_ fs.Mover          = &Fs{}
-	_ fs.DirMover       = &Fs{}
-	_ fs.Commander      = &Fs{}
-	_ fs.OpenWriterAter = &Fs{}
-	_ fs.Object         = &Object{}
+	_ fs.Fs                  = &Fs{}
+	_ fs.Purger              = &Fs{}
+	_ fs.PutStreamer         = &Fs{}
+	_ fs.Mover               = &Fs{}
+	_ fs.DirMover            = &Fs{}
+	_ fs.Commander           = &Fs{}
+	_ fs.OpenWriterAter      = &Fs{}
+	_ fs.OpenWriterAtResumer = &Fs{}
+	_ fs.Object              = &Object{}
 )
@@ -229,3 +229,45 @@ func TestHashOnDelete(t *testing.T) {
 	_, err = o.Hash(ctx, hash.MD5)
 	require.Error(t, err)
 }
+
+func TestTimeType(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	filePath := filepath.Join(dir, "file.txt")
+	require.NoError(t, ioutil.WriteFile(filePath, []byte("content"), 0600))
+	atime := time.Date(2001, 2, 3, 4, 5, 6, 0, time.UTC)
+	mtime := time.Date(2011, 12, 13, 14, 15, 16, 0, time.UTC)
+	require.NoError(t, os.Chtimes(filePath, atime, mtime))
+
+	_, err := NewFs(ctx, "local", dir, configmap.Simple{"time_type": "potato"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "unknown --local-time-type")
+
+	// Default is mtime
+	f, err := NewFs(ctx, "local", dir, configmap.Simple{})
+	require.NoError(t, err)
+	o, err := f.NewObject(ctx, "file.txt")
+	require.NoError(t, err)
+	assert.True(t, mtime.Equal(o.ModTime(ctx)), o.ModTime(ctx))
+
+	f, err = NewFs(ctx, "local", dir, configmap.Simple{"time_type": aTime})
+	require.NoError(t, err)
+	o, err = f.NewObject(ctx, "file.txt")
+	require.NoError(t, err)
+	if _, ok := readOtherTime(aTime, filePath, mustStat(t, filePath)); !ok {
+		t.Skip("atime not supported on this OS")
+	}
+	assert.True(t, atime.Equal(o.ModTime(ctx)), o.ModTime(ctx))
+
+	// Setting the time with atime should leave the mtime alone
+	newAtime := time.Date(2002, 3, 4, 5, 6, 7, 0, time.UTC)
+	require.NoError(t, o.SetModTime(ctx, newAtime))
+	assert.True(t, newAtime.Equal(o.ModTime(ctx)), o.ModTime(ctx))
+	assert.True(t, mtime.Equal(mustStat(t, filePath).ModTime()))
+}
+
+func mustStat(t *testing.T, path string) os.FileInfo {
+	fi, err := os.Stat(path)
+	require.NoError(t, err)
+	return fi
+}
@@ -0,0 +1,40 @@
+package local
+
+import (
+	"fmt"
+	"os"
+	"time"
+)
+
+// The kinds of time which can be set with --local-time-type
+const (
+	mTime = "mtime" // modification time
+	aTime = "atime" // access time
+	cTime = "ctime" // status change time
+	bTime = "btime" // birth or creation time
+)
+
+// checkTimeType returns an error if timeType isn't a known time type
+func checkTimeType(timeType string) error {
+	switch timeType {
+	case "", mTime, aTime, cTime, bTime:
+		return nil
+	}
+	return fmt.Errorf("unknown --local-time-type %q - must be one of %s, %s, %s or %s", timeType, mTime, aTime, cTime, bTime)
+}
+
+// readTime returns the time of kind timeType for the file at path
+// with info fi.
+//
+// If the OS can't supply that time then the modification time is
+// returned instead.
+func readTime(timeType string, path string, fi os.FileInfo) time.Time {
+	if timeType == mTime || timeType == "" {
+		return fi.ModTime()
+	}
+	t, ok := readOtherTime(timeType, path, fi)
+	if !ok || t.IsZero() {
+		return fi.ModTime()
+	}
+	return t
+}
@@ -0,0 +1,28 @@
+//go:build darwin || freebsd || netbsd
+// +build darwin freebsd netbsd
+
+package local
+
+import (
+	"os"
+	"syscall"
+	"time"
+)
+
+// readOtherTime returns the time of kind timeType for the file at
+// path or false if it isn't available
+func readOtherTime(timeType string, path string, fi os.FileInfo) (time.Time, bool) {
+	stat, ok := fi.Sys().(*syscall.Stat_t)
+	if !ok {
+		return time.Time{}, false
+	}
+	switch timeType {
+	case aTime:
+		return time.Unix(stat.Atimespec.Unix()), true
+	case cTime:
+		return time.Unix(stat.Ctimespec.Unix()), true
+	case bTime:
+		return time.Unix(stat.Birthtimespec.Unix()), true
+	}
+	return time.Time{}, false
+}
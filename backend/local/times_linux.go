@@ -0,0 +1,41 @@
+//go:build linux
+// +build linux
+
+package local
+
+import (
+	"os"
+	"syscall"
+	"time"
+
+	"golang.org/x/sys/unix"
+)
+
+// readOtherTime returns the time of kind timeType for the file at
+// path or false if it isn't available
+func readOtherTime(timeType string, path string, fi os.FileInfo) (time.Time, bool) {
+	if timeType == bTime {
+		// The birth time is only available from statx
+		flags := 0
+		if fi.Mode()&os.ModeSymlink != 0 {
+			flags = unix.AT_SYMLINK_NOFOLLOW
+		}
+		var stx unix.Statx_t
+		err := unix.Statx(unix.AT_FDCWD, path, flags, unix.STATX_BTIME, &stx)
+		if err != nil || stx.Mask&unix.STATX_BTIME == 0 {
+			return time.Time{}, false
+		}
+		return time.Unix(stx.Btime.Sec, int64(stx.Btime.Nsec)), true
+	}
+	stat, ok := fi.Sys().(*syscall.Stat_t)
+	if !ok {
+		return time.Time{}, false
+	}
+	switch timeType {
+	case aTime:
+		return time.Unix(stat.Atim.Unix()), true
+	case cTime:
+		return time.Unix(stat.Ctim.Unix()), true
+	}
+	return time.Time{}, false
+}
@@ -0,0 +1,17 @@
+//go:build !linux && !darwin && !freebsd && !netbsd && !windows
+// +build !linux,!darwin,!freebsd,!netbsd,!windows
+
+package local
+
+import (
+	"os"
+	"time"
+)
+
+// readOtherTime returns the time of kind timeType for the file at
+// path or false if it isn't available
+//
+// Only the modification time is supported on this OS
+func readOtherTime(timeType string, path string, fi os.FileInfo) (time.Time, bool) {
+	return time.Time{}, false
+}
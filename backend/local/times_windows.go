@@ -0,0 +1,28 @@
+//go:build windows
+// +build windows
+
+package local
+
+import (
+	"os"
+	"syscall"
+	"time"
+)
+
+// readOtherTime returns the time of kind timeType for the file at
+// path or false if it isn't available
+//
+// Windows doesn't have a status change time
+func readOtherTime(timeType string, path string, fi os.FileInfo) (time.Time, bool) {
+	stat, ok := fi.Sys().(*syscall.Win32FileAttributeData)
+	if !ok {
+		return time.Time{}, false
+	}
+	switch timeType {
+	case aTime:
+		return time.Unix(0, stat.LastAccessTime.Nanoseconds()), true
+	case bTime:
+		return time.Unix(0, stat.CreationTime.Nanoseconds()), true
+	}
+	return time.Time{}, false
+}
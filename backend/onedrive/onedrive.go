@@ -226,6 +226,18 @@ this flag there.
 			Help: `Set the password for links created by the link command.
 
 At the time of writing this only works with OneDrive personal paid accounts.
+`,
+			Advanced: true,
+		}, {
+			Name:    "av_override",
+			Default: false,
+			Help: `Allow download of files the server thinks have a virus.
+
+OneDrive blocks the download of files which its virus scanner thinks
+are infected. If this flag is set then rclone adds the AVOverride=1
+parameter to downloads which tells the server to allow them anyway.
+
+Only use this if you are sure the files are safe.
 `,
 			Advanced: true,
 		}, {
@@ -555,6 +567,7 @@ type Options struct {
 	LinkScope               string               `config:"link_scope"`
 	LinkType                string               `config:"link_type"`
 	LinkPassword            string               `config:"link_password"`
+	AVOverride              bool                 `config:"av_override"`
 	Enc                     encoder.MultiEncoder `config:"encoding"`
 }
 
@@ -1866,6 +1879,10 @@ func (o *Object) Open(ctx context.Context, options ...fs.OpenOption) (in io.Read
 	var resp *http.Response
 	opts := o.fs.newOptsCall(o.id, "GET", "/content")
 	opts.Options = options
+	if o.fs.opt.AVOverride {
+		fs.Debugf(o, "Downloading with AVOverride=1 so the virus scan won't block it")
+		opts.Parameters = url.Values{"AVOverride": {"1"}}
+	}
 
 	err = o.fs.pacer.Call(func() (bool, error) {
 		resp, err = o.fs.srv.Call(ctx, &opts)
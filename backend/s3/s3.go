@@ -51,6 +51,7 @@ import (
 	"github.com/rclone/rclone/lib/readers"
 	"github.com/rclone/rclone/lib/rest"
 	"github.com/rclone/rclone/lib/structs"
+	"github.com/shirou/gopsutil/v3/mem"
 	"golang.org/x/sync/errgroup"
 )
 
@@ -1272,6 +1273,24 @@ a file you can stream upload is 48 GiB.  If you wish to stream upload
 larger files then you will need to increase chunk_size.`,
 			Default:  minChunkSize,
 			Advanced: true,
+		}, {
+			Name: "chunk_size_auto",
+			Help: `Choose the chunk size from the memory available.
+
+If this is set then rclone works out the chunk size when the backend
+is created so that the "--transfers" x "--s3-upload-concurrency"
+chunks buffered in memory fit in half of the memory which is
+available. The chunk_size is then used as the minimum chunk size.
+
+This is only used for files of known size. The chunk size is reduced
+so that no more memory is used than is needed to upload the file with
+full concurrency, and increased if needed to stay below
+max_upload_parts. Streamed uploads of unknown size always use
+chunk_size as otherwise each one could buffer a lot of memory.
+
+The chosen chunk size is logged at INFO level.`,
+			Default:  false,
+			Advanced: true,
 		}, {
 			Name: "max_upload_parts",
 			Help: `Maximum number of parts in a multipart upload.
@@ -1292,11 +1311,34 @@ large file of a known size to stay below this number of chunks limit.
 			Help: `Cutoff for switching to multipart copy.
 
 Any files larger than this that need to be server-side copied will be
-copied in chunks of this size.
+copied in chunks of copy_chunk_size.
 
 The minimum is 0 and the maximum is 5 GiB.`,
 			Default:  fs.SizeSuffix(maxSizeForCopy),
 			Advanced: true,
+		}, {
+			Name: "copy_chunk_size",
+			Help: `Chunk size to use for multipart copy.
+
+Files larger than copy_cutoff are server-side copied in chunks of
+this size. If it is 0, the default, then copy_cutoff is used.
+
+Rclone will automatically increase the chunk size when copying a large
+file to stay below max_upload_parts.
+
+The minimum is 5 MiB and the maximum is 5 GiB.`,
+			Default:  fs.SizeSuffix(0),
+			Advanced: true,
+		}, {
+			Name: "copy_concurrency",
+			Help: `Concurrency for multipart copy.
+
+This is the number of chunks of the same file that are server-side
+copied concurrently.
+
+Increasing this can speed up server-side copies of large files.`,
+			Default:  4,
+			Advanced: true,
 		}, {
 			Name: "disable_checksum",
 			Help: `Don't store MD5 checksum with object metadata.
@@ -1304,7 +1346,32 @@ The minimum is 0 and the maximum is 5 GiB.`,
 Normally rclone will calculate the MD5 checksum of the input before
 uploading it so it can add it to metadata on the object. This is great
 for data integrity checking but can cause long delays for large files
-to start uploading.`,
+to start uploading.
+
+This only affects multipart uploads (and objects whose ETag isn't an
+MD5, e.g. when using SSE-KMS or SSE-C) as other objects have their MD5
+as their ETag. The MD5 of each part is still sent for S3 to verify
+unless --s3-disable-upload-checksum is set, so the upload is still
+checked, but rclone won't be able to check the MD5 of the whole object
+afterwards, e.g. with rclone check.`,
+			Default:  false,
+			Advanced: true,
+		}, {
+			Name: "disable_upload_checksum",
+			Help: `Don't send MD5 checksums with uploads for S3 to verify.
+
+Normally rclone sends the MD5 checksum of each single part upload and
+each part of a multipart upload with the request so S3 can check the
+data it received wasn't corrupted in transit. This needs the MD5 of
+the source for single part uploads and an MD5 of each part to be
+calculated as it is uploaded.
+
+Setting this saves that work but S3 will no longer verify the data on
+upload. The whole object MD5 is still stored in the metadata for
+multipart uploads unless --s3-disable-checksum is set too.
+
+Only leave both of these flags unset if you want end to end integrity
+checking of uploads.`,
 			Default:  false,
 			Advanced: true,
 		}, {
@@ -1440,6 +1507,10 @@ rclone does if you know the bucket exists already.
 It can also be needed if the user you are using does not have bucket
 creation permissions. Before v1.52.0 this would have passed silently
 due to a bug.
+
+When this is set rclone won't make any bucket level calls implicitly,
+so it won't read the bucket location to follow a redirect to another
+region either. Set the region explicitly instead.
 `,
 			Default:  false,
 			Advanced: true,
@@ -1543,6 +1614,7 @@ const (
 	maxSizeForCopy      = 4768 * 1024 * 1024
 	maxUploadParts      = 10000 // maximum allowed number of parts in a multi-part upload
 	minChunkSize        = fs.SizeSuffix(1024 * 1024 * 5)
+	maxChunkSize        = fs.SizeSuffix(5 * 1024 * 1024 * 1024) // maximum size of a part in a multi-part upload
 	defaultUploadCutoff = fs.SizeSuffix(200 * 1024 * 1024)
 	maxUploadCutoff     = fs.SizeSuffix(5 * 1024 * 1024 * 1024)
 	minSleep            = 10 * time.Millisecond // In case of error, start at 10ms sleep.
@@ -1572,9 +1644,13 @@ type Options struct {
 	StorageClass          string               `config:"storage_class"`
 	UploadCutoff          fs.SizeSuffix        `config:"upload_cutoff"`
 	CopyCutoff            fs.SizeSuffix        `config:"copy_cutoff"`
+	CopyChunkSize         fs.SizeSuffix        `config:"copy_chunk_size"`
+	CopyConcurrency       int                  `config:"copy_concurrency"`
 	ChunkSize             fs.SizeSuffix        `config:"chunk_size"`
+	ChunkSizeAuto         bool                 `config:"chunk_size_auto"`
 	MaxUploadParts        int64                `config:"max_upload_parts"`
 	DisableChecksum       bool                 `config:"disable_checksum"`
+	DisableUploadChecksum bool                 `config:"disable_upload_checksum"`
 	SharedCredentialsFile string               `config:"shared_credentials_file"`
 	Profile               string               `config:"profile"`
 	SessionToken          string               `config:"session_token"`
@@ -1614,6 +1690,7 @@ type Fs struct {
 	srvRest       *rest.Client     // the rest connection to the server
 	pool          *pool.Pool       // memory pool
 	etagIsNotMD5  bool             // if set ETags are not MD5s
+	chunkSizeAuto fs.SizeSuffix    // chunk size for files of known size chosen by chunk_size_auto
 }
 
 // Object describes a s3 object
@@ -1684,7 +1761,8 @@ func (f *Fs) shouldRetry(ctx context.Context, err error) (bool, error) {
 		// Failing that, if it's a RequestFailure it's probably got an http status code we can check
 		if reqErr, ok := err.(awserr.RequestFailure); ok {
 			// 301 if wrong region for bucket - can only update if running from a bucket
-			if f.rootBucket != "" {
+			// and reading the bucket location is allowed
+			if f.rootBucket != "" && !f.opt.NoCheckBucket {
 				if reqErr.StatusCode() == http.StatusMovedPermanently {
 					urfbErr := f.updateRegionForBucket(ctx, f.rootBucket)
 					if urfbErr != nil {
@@ -1878,6 +1956,26 @@ func checkUploadCutoff(cs fs.SizeSuffix) error {
 	return nil
 }
 
+func checkCopyCutoff(cs fs.SizeSuffix) error {
+	if cs > maxUploadCutoff {
+		return fmt.Errorf("%s is greater than %s", cs, maxUploadCutoff)
+	}
+	return nil
+}
+
+func checkCopyChunkSize(cs fs.SizeSuffix) error {
+	if cs == 0 {
+		return nil
+	}
+	if cs < minChunkSize {
+		return fmt.Errorf("%s is less than %s", cs, minChunkSize)
+	}
+	if cs > maxChunkSize {
+		return fmt.Errorf("%s is greater than %s", cs, maxChunkSize)
+	}
+	return nil
+}
+
 func (f *Fs) setUploadCutoff(cs fs.SizeSuffix) (old fs.SizeSuffix, err error) {
 	err = checkUploadCutoff(cs)
 	if err == nil {
@@ -1978,6 +2076,40 @@ func (f *Fs) setRoot(root string) {
 	f.rootBucket, f.rootDirectory = bucket.Split(f.root)
 }
 
+// autoChunkSize returns the chunk size to use so that buffers chunks
+// fit in half of the available memory.
+//
+// It is rounded down to a whole number of MiB and is never less than
+// minSize or more than the maximum part size.
+func autoChunkSize(available uint64, buffers int, minSize fs.SizeSuffix) fs.SizeSuffix {
+	if buffers < 1 {
+		buffers = 1
+	}
+	cs := fs.SizeSuffix(available/2/uint64(buffers)) &^ (1<<20 - 1)
+	if cs > maxChunkSize {
+		cs = maxChunkSize
+	}
+	if cs < minSize {
+		cs = minSize
+	}
+	return cs
+}
+
+// autoPartSize reduces partSize so that a file of size is uploaded in
+// at least concurrency parts, as bigger parts would only use more
+// memory. It is rounded up to a whole number of MiB and is never less
+// than minChunkSize.
+func autoPartSize(size int64, concurrency int, partSize int) int {
+	needed := int((((size / int64(concurrency)) >> 20) + 1) << 20)
+	if needed < partSize {
+		partSize = needed
+	}
+	if partSize < int(minChunkSize) {
+		partSize = int(minChunkSize)
+	}
+	return partSize
+}
+
 // NewFs constructs an Fs from the path, bucket:path
 func NewFs(ctx context.Context, name, root string, m configmap.Mapper) (fs.Fs, error) {
 	// Parse config into Options struct
@@ -1994,6 +2126,14 @@ func NewFs(ctx context.Context, name, root string, m configmap.Mapper) (fs.Fs, e
 	if err != nil {
 		return nil, fmt.Errorf("s3: upload cutoff: %w", err)
 	}
+	err = checkCopyCutoff(opt.CopyCutoff)
+	if err != nil {
+		return nil, fmt.Errorf("s3: copy cutoff: %w", err)
+	}
+	err = checkCopyChunkSize(opt.CopyChunkSize)
+	if err != nil {
+		return nil, fmt.Errorf("s3: copy chunk size: %w", err)
+	}
 	if opt.ACL == "" {
 		opt.ACL = "private"
 	}
@@ -2012,6 +2152,18 @@ func NewFs(ctx context.Context, name, root string, m configmap.Mapper) (fs.Fs, e
 	}
 
 	ci := fs.GetConfig(ctx)
+	chunkSizeAuto := opt.ChunkSize
+	if opt.ChunkSizeAuto {
+		buffers := opt.UploadConcurrency * ci.Transfers
+		vm, err := mem.VirtualMemory()
+		if err != nil {
+			fs.Logf(name, "Failed to read available memory - using chunk size %v: %v", opt.ChunkSize, err)
+		} else {
+			chunkSizeAuto = autoChunkSize(vm.Available, buffers, opt.ChunkSize)
+			fs.Infof(name, "Using chunk size %v for %d transfers x %d upload concurrency with %v memory available",
+				chunkSizeAuto, ci.Transfers, opt.UploadConcurrency, fs.SizeSuffix(vm.Available))
+		}
+	}
 	pc := fs.NewPacer(ctx, pacer.NewS3(pacer.MinSleep(minSleep)))
 	// Set pacer retries to 2 (1 try and 1 retry) because we are
 	// relying on SDK retry mechanism, but we allow 2 attempts to
@@ -2046,10 +2198,12 @@ func NewFs(ctx context.Context, name, root string, m configmap.Mapper) (fs.Fs, e
 		// MD5 digest of their object data.
 		f.etagIsNotMD5 = true
 	}
+	f.chunkSizeAuto = chunkSizeAuto
 	f.setRoot(root)
 	f.features = (&fs.Features{
 		ReadMimeType:      true,
 		WriteMimeType:     true,
+		WriteMetadata:     true,
 		BucketBased:       true,
 		BucketBasedRootOK: true,
 		SetTier:           true,
@@ -2520,9 +2674,75 @@ func (f *Fs) bucketExists(ctx context.Context, bucket string) (bool, error) {
 }
 
 // Mkdir creates the bucket if it doesn't exist
+//
+// If --create-dir-markers is set it creates a directory marker for
+// dir too
 func (f *Fs) Mkdir(ctx context.Context, dir string) error {
-	bucket, _ := f.split(dir)
-	return f.makeBucket(ctx, bucket)
+	bucket, directory := f.split(dir)
+	err := f.makeBucket(ctx, bucket)
+	if err != nil || directory == "" || !fs.GetConfig(ctx).CreateDirMarkers {
+		return err
+	}
+	return f.putDirMarker(ctx, bucket, directory)
+}
+
+// putDirMarker creates a zero length object called directory + "/"
+// which keeps the directory in existence when it is empty
+func (f *Fs) putDirMarker(ctx context.Context, bucket, directory string) error {
+	markerPath := directory + "/"
+	contentType := "application/x-directory"
+	req := s3.PutObjectInput{
+		Bucket:        &bucket,
+		ACL:           &f.opt.ACL,
+		Key:           &markerPath,
+		Body:          bytes.NewReader(nil),
+		ContentLength: aws.Int64(0),
+		ContentType:   &contentType,
+	}
+	if f.opt.RequesterPays {
+		req.RequestPayer = aws.String(s3.RequestPayerRequester)
+	}
+	if f.opt.ServerSideEncryption != "" {
+		req.ServerSideEncryption = &f.opt.ServerSideEncryption
+	}
+	err := f.pacer.Call(func() (bool, error) {
+		_, err := f.c.PutObjectWithContext(ctx, &req)
+		return f.shouldRetry(ctx, err)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create directory marker: %w", err)
+	}
+	fs.Debugf(f, "Created directory marker %q", markerPath)
+	return nil
+}
+
+// removeDirMarker removes the directory marker for directory
+//
+// Returns an error if the directory isn't empty
+func (f *Fs) removeDirMarker(ctx context.Context, bucket, directory string) error {
+	empty := true
+	err := f.list(ctx, bucket, directory, f.rootDirectory, f.rootBucket == "", false, func(remote string, object *s3.Object, isDirectory bool) error {
+		empty = false
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if !empty {
+		return fs.ErrorDirectoryNotEmpty
+	}
+	markerPath := directory + "/"
+	req := s3.DeleteObjectInput{
+		Bucket: &bucket,
+		Key:    &markerPath,
+	}
+	if f.opt.RequesterPays {
+		req.RequestPayer = aws.String(s3.RequestPayerRequester)
+	}
+	return f.pacer.Call(func() (bool, error) {
+		_, err := f.c.DeleteObjectWithContext(ctx, &req)
+		return f.shouldRetry(ctx, err)
+	})
 }
 
 // makeBucket creates the bucket if it doesn't exist
@@ -2560,9 +2780,15 @@ func (f *Fs) makeBucket(ctx context.Context, bucket string) error {
 
 // Rmdir deletes the bucket if the fs is at the root
 //
+// If --create-dir-markers is set it removes the directory marker for
+// dir if it isn't the root.
+//
 // Returns an error if it isn't empty
 func (f *Fs) Rmdir(ctx context.Context, dir string) error {
 	bucket, directory := f.split(dir)
+	if bucket != "" && directory != "" && fs.GetConfig(ctx).CreateDirMarkers {
+		return f.removeDirMarker(ctx, bucket, directory)
+	}
 	if bucket == "" || directory != "" {
 		return nil
 	}
@@ -2697,38 +2923,60 @@ func (f *Fs) copyMultipart(ctx context.Context, copyReq *s3.CopyObjectInput, dst
 	})()
 
 	srcSize := src.bytes
-	partSize := int64(f.opt.CopyCutoff)
+	partSize := f.copyPartSize(srcSize)
 	numParts := (srcSize-1)/partSize + 1
 
-	fs.Debugf(src, "Starting  multipart copy with %d parts", numParts)
+	concurrency := f.opt.CopyConcurrency
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	tokens := pacer.NewTokenDispenser(concurrency)
+
+	fs.Debugf(src, "Starting  multipart copy with %d parts of size %v", numParts, fs.SizeSuffix(partSize))
 
-	var parts []*s3.CompletedPart
+	var (
+		g, gCtx = errgroup.WithContext(ctx)
+		parts   = make([]*s3.CompletedPart, numParts)
+	)
 	for partNum := int64(1); partNum <= numParts; partNum++ {
-		if err := f.pacer.Call(func() (bool, error) {
-			partNum := partNum
-			uploadPartReq := &s3.UploadPartCopyInput{}
-			structs.SetFrom(uploadPartReq, copyReq)
-			uploadPartReq.Bucket = &dstBucket
-			uploadPartReq.Key = &dstPath
-			uploadPartReq.PartNumber = &partNum
-			uploadPartReq.UploadId = uid
-			uploadPartReq.CopySourceRange = aws.String(calculateRange(partSize, partNum-1, numParts, srcSize))
-			uout, err := f.c.UploadPartCopyWithContext(ctx, uploadPartReq)
-			if err != nil {
-				return f.shouldRetry(ctx, err)
-			}
-			parts = append(parts, &s3.CompletedPart{
-				PartNumber: &partNum,
-				ETag:       uout.CopyPartResult.ETag,
-			})
-			return false, nil
-		}); err != nil {
-			return err
+		tokens.Get()
+		// Fail fast, there is no point in copying the other parts.
+		if gCtx.Err() != nil {
+			tokens.Put()
+			break
 		}
+		partNum := partNum
+		g.Go(func() error {
+			defer tokens.Put()
+			return f.pacer.Call(func() (bool, error) {
+				uploadPartReq := &s3.UploadPartCopyInput{}
+				structs.SetFrom(uploadPartReq, copyReq)
+				uploadPartReq.Bucket = &dstBucket
+				uploadPartReq.Key = &dstPath
+				uploadPartReq.PartNumber = &partNum
+				uploadPartReq.UploadId = uid
+				uploadPartReq.CopySourceRange = aws.String(calculateRange(partSize, partNum-1, numParts, srcSize))
+				uout, err := f.c.UploadPartCopyWithContext(gCtx, uploadPartReq)
+				if err != nil {
+					return f.shouldRetry(gCtx, err)
+				}
+				parts[partNum-1] = &s3.CompletedPart{
+					PartNumber: &partNum,
+					ETag:       uout.CopyPartResult.ETag,
+				}
+				return false, nil
+			})
+		})
+	}
+	err = g.Wait()
+	if err != nil {
+		return err
 	}
 
-	return f.pacer.Call(func() (bool, error) {
-		_, err := f.c.CompleteMultipartUploadWithContext(ctx, &s3.CompleteMultipartUploadInput{
+	var complete *s3.CompleteMultipartUploadOutput
+	err = f.pacer.Call(func() (bool, error) {
+		var err error
+		complete, err = f.c.CompleteMultipartUploadWithContext(ctx, &s3.CompleteMultipartUploadInput{
 			Bucket: &dstBucket,
 			Key:    &dstPath,
 			MultipartUpload: &s3.CompletedMultipartUpload{
@@ -2739,6 +2987,84 @@ func (f *Fs) copyMultipart(ctx context.Context, copyReq *s3.CopyObjectInput, dst
 		})
 		return f.shouldRetry(ctx, err)
 	})
+	if err != nil {
+		return err
+	}
+
+	// Check the parts were put together properly if the ETags are MD5s
+	if !f.canCheckMultipartETag() {
+		return nil
+	}
+	gotETag := strings.Trim(aws.StringValue(complete.ETag), `"`)
+	wantETag := multipartETag(parts)
+	if wantETag != "" && multipartETagRe.MatchString(gotETag) && gotETag != wantETag {
+		fs.Debugf(src, "Removing multipart copy with ETag %q, expecting %q", gotETag, wantETag)
+		_ = f.pacer.Call(func() (bool, error) {
+			_, err := f.c.DeleteObjectWithContext(ctx, &s3.DeleteObjectInput{
+				Bucket:       &dstBucket,
+				Key:          &dstPath,
+				RequestPayer: req.RequestPayer,
+			})
+			return f.shouldRetry(ctx, err)
+		})
+		return fmt.Errorf("multipart copy corrupted: ETag %q doesn't match %q made from the parts", gotETag, wantETag)
+	}
+	return nil
+}
+
+// copyPartSize returns the size of the parts to copy a file of size
+// with multipart copy
+//
+// This is copy_chunk_size, or copy_cutoff if that isn't set, increased
+// if needed to stay below max_upload_parts.
+func (f *Fs) copyPartSize(size int64) int64 {
+	partSize := int64(f.opt.CopyChunkSize)
+	if partSize == 0 {
+		partSize = int64(f.opt.CopyCutoff)
+	}
+	if partSize < int64(minChunkSize) {
+		partSize = int64(minChunkSize)
+	}
+	uploadParts := f.opt.MaxUploadParts
+	if uploadParts < 1 {
+		uploadParts = 1
+	} else if uploadParts > maxUploadParts {
+		uploadParts = maxUploadParts
+	}
+	// Adjust partSize until the number of parts is small enough.
+	if size/partSize >= uploadParts {
+		// Calculate partition size rounded up to the nearest MiB
+		partSize = (((size / uploadParts) >> 20) + 1) << 20
+	}
+	return partSize
+}
+
+// canCheckMultipartETag returns true if the ETag of a multipart copy
+// can be checked against the ETags of its parts.
+//
+// This is only done on AWS without SSE-KMS or SSE-C. With those the
+// ETags aren't MD5s and other providers don't always make the ETag of
+// a multipart object in the same way.
+func (f *Fs) canCheckMultipartETag() bool {
+	return f.opt.Provider == "AWS" && !f.etagIsNotMD5
+}
+
+// multipartETagRe matches the ETag of an object uploaded in parts
+var multipartETagRe = regexp.MustCompile(`^[0-9a-f]{32}-[0-9]+$`)
+
+// multipartETag returns the ETag S3 gives to an object made from the
+// parts or "" if the ETags of the parts aren't MD5s, e.g. because
+// they are encrypted.
+func multipartETag(parts []*s3.CompletedPart) string {
+	h := md5.New()
+	for _, part := range parts {
+		sum, err := hex.DecodeString(strings.Trim(aws.StringValue(part.ETag), `"`))
+		if err != nil || len(sum) != md5.Size {
+			return ""
+		}
+		_, _ = h.Write(sum)
+	}
+	return fmt.Sprintf("%x-%d", h.Sum(nil), len(parts))
 }
 
 // Copy src to this remote using server-side copy operations.
@@ -2769,7 +3095,14 @@ func (f *Fs) Copy(ctx context.Context, src fs.Object, remote string) (fs.Object,
 	if err != nil {
 		return nil, err
 	}
-	return f.NewObject(ctx, remote)
+	dstObj, err := f.NewObject(ctx, remote)
+	if err != nil {
+		return nil, err
+	}
+	if dstObj.Size() != srcObj.Size() {
+		return nil, fmt.Errorf("server-side copy failed: size of copy %d doesn't match source %d", dstObj.Size(), srcObj.Size())
+	}
+	return dstObj, nil
 }
 
 // Hashes returns the supported hash sets.
@@ -3444,6 +3777,27 @@ func (o *Object) Open(ctx context.Context, options ...fs.OpenOption) (in io.Read
 
 var warnStreamUpload sync.Once
 
+// uploadPartSize returns the part size to upload a file of size in
+// with a multipart upload of at most uploadParts parts.
+//
+// If the size is unknown (-1) this is always chunk_size, as the auto
+// chunk size could make each streamed upload buffer a lot of memory.
+func (f *Fs) uploadPartSize(size int64, concurrency int, uploadParts int64) int {
+	partSize := int(f.opt.ChunkSize)
+	if size < 0 {
+		return partSize
+	}
+	if f.opt.ChunkSizeAuto {
+		partSize = autoPartSize(size, concurrency, int(f.chunkSizeAuto))
+	}
+	// Adjust partSize until the number of parts is small enough.
+	if size/int64(partSize) >= uploadParts {
+		// Calculate partition size rounded up to the nearest MiB
+		partSize = int((((size / uploadParts) >> 20) + 1) << 20)
+	}
+	return partSize
+}
+
 func (o *Object) uploadMultipart(ctx context.Context, req *s3.PutObjectInput, size int64, in io.Reader) (err error) {
 	f := o.fs
 
@@ -3462,7 +3816,7 @@ func (o *Object) uploadMultipart(ctx context.Context, req *s3.PutObjectInput, si
 	}
 
 	// calculate size of parts
-	partSize := int(f.opt.ChunkSize)
+	partSize := f.uploadPartSize(size, concurrency, uploadParts)
 
 	// size can be -1 here meaning we don't know the size of the incoming file. We use ChunkSize
 	// buffers here (default 5 MiB). With a maximum number of parts (10,000) this will be a file of
@@ -3472,12 +3826,8 @@ func (o *Object) uploadMultipart(ctx context.Context, req *s3.PutObjectInput, si
 			fs.Logf(f, "Streaming uploads using chunk size %v will have maximum file size of %v",
 				f.opt.ChunkSize, fs.SizeSuffix(int64(partSize)*uploadParts))
 		})
-	} else {
-		// Adjust partSize until the number of parts is small enough.
-		if size/int64(partSize) >= uploadParts {
-			// Calculate partition size rounded up to the nearest MiB
-			partSize = int((((size / uploadParts) >> 20) + 1) << 20)
-		}
+	} else if f.opt.ChunkSizeAuto {
+		fs.Debugf(o, "Multipart upload using part size %v", fs.SizeSuffix(partSize))
 	}
 
 	memPool := f.getMemoryPool(int64(partSize))
@@ -3563,8 +3913,11 @@ func (o *Object) uploadMultipart(ctx context.Context, req *s3.PutObjectInput, si
 			partLength := int64(len(buf))
 
 			// create checksum of buffer for integrity checking
-			md5sumBinary := md5.Sum(buf)
-			md5sum := base64.StdEncoding.EncodeToString(md5sumBinary[:])
+			var md5sum *string
+			if !f.opt.DisableUploadChecksum {
+				md5sumBinary := md5.Sum(buf)
+				md5sum = aws.String(base64.StdEncoding.EncodeToString(md5sumBinary[:]))
+			}
 
 			err = f.pacer.Call(func() (bool, error) {
 				uploadPartReq := &s3.UploadPartInput{
@@ -3573,7 +3926,7 @@ func (o *Object) uploadMultipart(ctx context.Context, req *s3.PutObjectInput, si
 					Key:                  req.Key,
 					PartNumber:           &partNum,
 					UploadId:             uid,
-					ContentMD5:           &md5sum,
+					ContentMD5:           md5sum,
 					ContentLength:        &partLength,
 					RequestPayer:         req.RequestPayer,
 					SSECustomerAlgorithm: req.SSECustomerAlgorithm,
@@ -3649,19 +4002,20 @@ func (o *Object) Update(ctx context.Context, in io.Reader, src fs.ObjectInfo, op
 	}
 
 	// read the md5sum if available
-	// - for non multipart
+	// - for non multipart provided upload checksums aren't disabled
 	//    - so we can add a ContentMD5
-	//    - so we can add the md5sum in the metadata as metaMD5Hash if using SSE/SSE-C
-	// - for multipart provided checksums aren't disabled
+	// - for multipart or if using SSE/SSE-C provided checksums aren't disabled
 	//    - so we can add the md5sum in the metadata as metaMD5Hash
 	var md5sum string
-	if !multipart || !o.fs.opt.DisableChecksum {
+	sendMD5 := !multipart && !o.fs.opt.DisableUploadChecksum
+	storeMD5 := (multipart || o.fs.etagIsNotMD5) && !o.fs.opt.DisableChecksum
+	if sendMD5 || storeMD5 {
 		hash, err := src.Hash(ctx, hash.MD5)
 		if err == nil && matchMd5.MatchString(hash) {
 			hashBytes, err := hex.DecodeString(hash)
 			if err == nil {
 				md5sum = base64.StdEncoding.EncodeToString(hashBytes)
-				if (multipart || o.fs.etagIsNotMD5) && !o.fs.opt.DisableChecksum {
+				if storeMD5 {
 					// Set the md5sum as metadata on the object if
 					// - a multipart upload
 					// - the Etag is not an MD5, eg when using SSE/SSE-C
@@ -3681,7 +4035,7 @@ func (o *Object) Update(ctx context.Context, in io.Reader, src fs.ObjectInfo, op
 		ContentType: &mimeType,
 		Metadata:    metadata,
 	}
-	if md5sum != "" {
+	if md5sum != "" && sendMD5 {
 		req.ContentMD5 = &md5sum
 	}
 	if o.fs.opt.RequesterPays {
@@ -3707,6 +4061,15 @@ func (o *Object) Update(ctx context.Context, in io.Reader, src fs.ObjectInfo, op
 	}
 	// Apply upload options
 	for _, option := range options {
+		if m, ok := option.(*fs.MetadataOption); ok {
+			metaKey := strings.ToLower(m.Key)
+			if _, found := req.Metadata[metaKey]; found {
+				fs.Errorf(o, "Not overriding metadata key %q used by rclone", metaKey)
+			} else {
+				req.Metadata[metaKey] = aws.String(m.Value)
+			}
+			continue
+		}
 		key, value := option.Header()
 		lowerKey := strings.ToLower(key)
 		switch lowerKey {
@@ -3848,6 +4211,20 @@ func (o *Object) MimeType(ctx context.Context) string {
 	return o.mimeType
 }
 
+// Metadata returns the user metadata of the object with the keys in
+// lower case
+func (o *Object) Metadata(ctx context.Context) (map[string]string, error) {
+	err := o.readMetaData(ctx)
+	if err != nil {
+		return nil, err
+	}
+	metadata := make(map[string]string, len(o.meta))
+	for key, value := range o.meta {
+		metadata[strings.ToLower(key)] = aws.StringValue(value)
+	}
+	return metadata, nil
+}
+
 // SetTier performs changing storage class
 func (o *Object) SetTier(tier string) (err error) {
 	ctx := context.TODO()
@@ -3885,4 +4262,5 @@ var (
 	_ fs.MimeTyper   = &Object{}
 	_ fs.GetTierer   = &Object{}
 	_ fs.SetTierer   = &Object{}
+	_ fs.Metadataer  = &Object{}
 )
@@ -0,0 +1,355 @@
+package s3
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fs/config/configmap"
+	"github.com/rclone/rclone/fs/object"
+	"github.com/rclone/rclone/lib/bucket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAutoChunkSize(t *testing.T) {
+	const (
+		MiB = 1024 * 1024
+		GiB = 1024 * MiB
+	)
+	for _, test := range []struct {
+		available uint64
+		buffers   int
+		minSize   fs.SizeSuffix
+		want      fs.SizeSuffix
+	}{
+		{available: 16 * GiB, buffers: 16, minSize: minChunkSize, want: 512 * MiB},
+		{available: 16*GiB + 123, buffers: 3, minSize: minChunkSize, want: 2730 * MiB},
+		{available: 1 * GiB, buffers: 4 * 1000, minSize: minChunkSize, want: minChunkSize},
+		{available: 1 * GiB, buffers: 4, minSize: 200 * MiB, want: 200 * MiB},
+		{available: 1024 * GiB, buffers: 4, minSize: minChunkSize, want: maxChunkSize},
+		{available: 1 * GiB, buffers: 0, minSize: minChunkSize, want: 512 * MiB},
+	} {
+		got := autoChunkSize(test.available, test.buffers, test.minSize)
+		assert.Equal(t, test.want, got, "available=%d buffers=%d", test.available, test.buffers)
+	}
+}
+
+func TestAutoPartSize(t *testing.T) {
+	const MiB = 1024 * 1024
+	for _, test := range []struct {
+		size        int64
+		concurrency int
+		partSize    int
+		want        int
+	}{
+		{size: 1000 * MiB, concurrency: 4, partSize: 512 * MiB, want: 251 * MiB},
+		{size: 1000 * MiB, concurrency: 4, partSize: 100 * MiB, want: 100 * MiB},
+		{size: 1 * MiB, concurrency: 4, partSize: 512 * MiB, want: int(minChunkSize)},
+	} {
+		got := autoPartSize(test.size, test.concurrency, test.partSize)
+		assert.Equal(t, test.want, got, "size=%d concurrency=%d", test.size, test.concurrency)
+	}
+}
+
+func TestUploadPartSize(t *testing.T) {
+	const (
+		MiB = 1024 * 1024
+		GiB = 1024 * MiB
+	)
+	f := &Fs{
+		opt:           Options{ChunkSize: 5 * MiB, ChunkSizeAuto: true},
+		chunkSizeAuto: 512 * MiB,
+	}
+	// Streamed uploads keep the configured chunk size
+	assert.Equal(t, 5*MiB, f.uploadPartSize(-1, 4, 10000))
+	// Files of known size use the auto chunk size
+	assert.Equal(t, 251*MiB, f.uploadPartSize(1000*MiB, 4, 10000))
+	assert.Equal(t, 512*MiB, f.uploadPartSize(100*GiB, 4, 10000))
+	// but stay below the maximum number of parts
+	assert.Equal(t, 1025*MiB, f.uploadPartSize(100*GiB, 4, 100))
+
+	f.opt.ChunkSizeAuto = false
+	assert.Equal(t, 5*MiB, f.uploadPartSize(1000*MiB, 4, 10000))
+	assert.Equal(t, 11*MiB, f.uploadPartSize(100*GiB, 4, 10000))
+}
+
+// fakeS3 is a minimal path style S3 server which records the bucket
+// level requests made to it
+type fakeS3 struct {
+	mu          sync.Mutex
+	objects     map[string][]byte
+	parts       map[int][]byte // parts of the multipart upload in progress
+	badETag     bool           // set to give multipart uploads the wrong ETag
+	bucketCalls []string
+	objectCalls []string
+}
+
+// multipartETag returns the ETag of an object made from parts
+func (s *fakeS3) multipartETag(parts [][]byte) string {
+	h := md5.New()
+	for _, part := range parts {
+		sum := md5.Sum(part)
+		_, _ = h.Write(sum[:])
+	}
+	if s.badETag {
+		_, _ = h.Write([]byte("potato"))
+	}
+	return fmt.Sprintf("%x-%d", h.Sum(nil), len(parts))
+}
+
+func (s *fakeS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	bucketName, key := bucket.Split(strings.TrimPrefix(r.URL.Path, "/"))
+	if key == "" {
+		if r.Method == "GET" && r.URL.Query().Get("list-type") != "" {
+			w.Header().Set("Content-Type", "application/xml")
+			_, _ = io.WriteString(w, `<ListBucketResult><Name>`+bucketName+`</Name><IsTruncated>false</IsTruncated></ListBucketResult>`)
+			return
+		}
+		s.bucketCalls = append(s.bucketCalls, r.Method+" "+r.URL.String())
+		return
+	}
+	s.objectCalls = append(s.objectCalls, r.Method+" "+key)
+	query := r.URL.Query()
+	switch r.Method {
+	case "HEAD", "GET":
+		data, ok := s.objects[key]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Length", strconv.Itoa(len(data)))
+		w.Header().Set("Last-Modified", time.Now().UTC().Format(http.TimeFormat))
+		w.Header().Set("ETag", fmt.Sprintf(`"%x"`, md5.Sum(data)))
+		if r.Method == "GET" {
+			_, _ = w.Write(data)
+		}
+	case "POST":
+		w.Header().Set("Content-Type", "application/xml")
+		if _, ok := query["uploads"]; ok {
+			s.parts = map[int][]byte{}
+			_, _ = fmt.Fprintf(w, `<InitiateMultipartUploadResult><Key>%s</Key><UploadId>upload</UploadId></InitiateMultipartUploadResult>`, key)
+			return
+		}
+		var complete struct {
+			Parts []struct {
+				PartNumber int
+			} `xml:"Part"`
+		}
+		if err := xml.NewDecoder(r.Body).Decode(&complete); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		var parts [][]byte
+		var data []byte
+		for _, part := range complete.Parts {
+			parts = append(parts, s.parts[part.PartNumber])
+			data = append(data, s.parts[part.PartNumber]...)
+		}
+		s.objects[key] = data
+		_, _ = fmt.Fprintf(w, `<CompleteMultipartUploadResult><Key>%s</Key><ETag>"%s"</ETag></CompleteMultipartUploadResult>`, key, s.multipartETag(parts))
+	case "DELETE":
+		if query.Get("uploadId") == "" {
+			delete(s.objects, key)
+		}
+		w.WriteHeader(http.StatusNoContent)
+	case "PUT":
+		var data []byte
+		if partNumber := query.Get("partNumber"); partNumber != "" {
+			// copy a part
+			n, _ := strconv.Atoi(partNumber)
+			_, srcKey := bucket.Split(strings.TrimPrefix(r.Header.Get("X-Amz-Copy-Source"), "/"))
+			var start, end int
+			_, _ = fmt.Sscanf(r.Header.Get("X-Amz-Copy-Source-Range"), "bytes=%d-%d", &start, &end)
+			data = s.objects[srcKey][start : end+1]
+			s.parts[n] = data
+			w.Header().Set("Content-Type", "application/xml")
+			_, _ = fmt.Fprintf(w, `<CopyPartResult><ETag>"%x"</ETag></CopyPartResult>`, md5.Sum(data))
+			return
+		}
+		if src := r.Header.Get("X-Amz-Copy-Source"); src != "" {
+			_, srcKey := bucket.Split(strings.TrimPrefix(src, "/"))
+			data = s.objects[srcKey]
+			w.Header().Set("Content-Type", "application/xml")
+			_, _ = fmt.Fprintf(w, `<CopyObjectResult><ETag>"%x"</ETag></CopyObjectResult>`, md5.Sum(data))
+		} else {
+			data, _ = ioutil.ReadAll(r.Body)
+			w.Header().Set("ETag", fmt.Sprintf(`"%x"`, md5.Sum(data)))
+		}
+		s.objects[key] = data
+	default:
+		w.WriteHeader(http.StatusNotImplemented)
+	}
+}
+
+// Check --s3-no-check-bucket stops all the implicit bucket level calls
+func TestNoCheckBucket(t *testing.T) {
+	// The SDK can't load a CA bundle into rclone's transport
+	t.Setenv("AWS_CA_BUNDLE", "")
+	for _, noCheckBucket := range []bool{false, true} {
+		t.Run(fmt.Sprintf("NoCheckBucket=%v", noCheckBucket), func(t *testing.T) {
+			ctx := context.Background()
+			fake := &fakeS3{objects: map[string][]byte{}}
+			srv := httptest.NewServer(fake)
+			defer srv.Close()
+
+			fsInfo, err := fs.Find("s3")
+			require.NoError(t, err)
+			m := fs.ConfigMap(fsInfo, "TestS3NoCheckBucket", configmap.Simple{
+				"provider":          "Other",
+				"endpoint":          srv.URL,
+				"access_key_id":     "key",
+				"secret_access_key": "secret",
+				"no_check_bucket":   strconv.FormatBool(noCheckBucket),
+			})
+			f, err := NewFs(ctx, "TestS3NoCheckBucket", "bucket/dir", m)
+			require.NoError(t, err)
+
+			require.NoError(t, f.Mkdir(ctx, ""))
+			contents := "hello"
+			src := object.NewStaticObjectInfo("file.txt", time.Now(), int64(len(contents)), true, nil, nil)
+			o, err := f.Put(ctx, strings.NewReader(contents), src)
+			require.NoError(t, err)
+			_, err = f.(fs.Copier).Copy(ctx, o, "copy.txt")
+			require.NoError(t, err)
+
+			assert.Contains(t, fake.objectCalls, "PUT dir/file.txt")
+			assert.Contains(t, fake.objectCalls, "PUT dir/copy.txt")
+			if noCheckBucket {
+				assert.Empty(t, fake.bucketCalls)
+			} else {
+				assert.NotEmpty(t, fake.bucketCalls)
+			}
+		})
+	}
+}
+
+func TestCopyPartSize(t *testing.T) {
+	const (
+		MiB = 1024 * 1024
+		GiB = 1024 * MiB
+	)
+	for _, test := range []struct {
+		copyCutoff     fs.SizeSuffix
+		copyChunkSize  fs.SizeSuffix
+		maxUploadParts int64
+		size           int64
+		want           int64
+	}{
+		{copyCutoff: 4768 * MiB, size: 50 * GiB, want: 4768 * MiB},
+		{copyCutoff: 4768 * MiB, copyChunkSize: 100 * MiB, size: 50 * GiB, want: 100 * MiB},
+		{copyCutoff: 0, size: 50 * GiB, want: int64(minChunkSize) + 1*MiB},
+		{copyCutoff: 1 * MiB, size: 1 * GiB, want: int64(minChunkSize)},
+		{copyCutoff: 100 * MiB, maxUploadParts: 10, size: 10 * GiB, want: 1025 * MiB},
+	} {
+		maxUploadParts := test.maxUploadParts
+		if maxUploadParts == 0 {
+			maxUploadParts = 10000
+		}
+		f := &Fs{opt: Options{
+			CopyCutoff:     test.copyCutoff,
+			CopyChunkSize:  test.copyChunkSize,
+			MaxUploadParts: maxUploadParts,
+		}}
+		got := f.copyPartSize(test.size)
+		assert.Equal(t, test.want, got, "%+v", test)
+		assert.Less(t, (test.size-1)/got+1, maxUploadParts+1, "too many parts for %+v", test)
+	}
+
+	assert.NoError(t, checkCopyChunkSize(0))
+	assert.NoError(t, checkCopyChunkSize(minChunkSize))
+	assert.Error(t, checkCopyChunkSize(minChunkSize-1))
+	assert.Error(t, checkCopyChunkSize(maxChunkSize+1))
+	assert.NoError(t, checkCopyCutoff(0))
+	assert.Error(t, checkCopyCutoff(maxUploadCutoff+1))
+}
+
+// Check large files are copied with concurrent multipart copies and
+// that a copy with the wrong ETag is removed on AWS
+func TestMultipartCopy(t *testing.T) {
+	// The SDK can't load a CA bundle into rclone's transport
+	t.Setenv("AWS_CA_BUNDLE", "")
+	ctx := context.Background()
+	fake := &fakeS3{objects: map[string][]byte{}}
+	srv := httptest.NewServer(fake)
+	defer srv.Close()
+
+	fsInfo, err := fs.Find("s3")
+	require.NoError(t, err)
+	m := fs.ConfigMap(fsInfo, "TestS3MultipartCopy", configmap.Simple{
+		"provider":          "Other",
+		"endpoint":          srv.URL,
+		"access_key_id":     "key",
+		"secret_access_key": "secret",
+		"no_check_bucket":   "true",
+		"copy_cutoff":       "1M",
+		"copy_chunk_size":   "5M",
+		"copy_concurrency":  "2",
+	})
+	f, err := NewFs(ctx, "TestS3MultipartCopy", "bucket", m)
+	require.NoError(t, err)
+	// Check the ETags as AWS would - the fake can't do virtual host style
+	f.(*Fs).opt.Provider = "AWS"
+
+	data := make([]byte, 12*1024*1024)
+	for i := range data {
+		data[i] = byte(i % 251)
+	}
+	fake.objects["src"] = data
+	src, err := f.NewObject(ctx, "src")
+	require.NoError(t, err)
+
+	dst, err := f.(fs.Copier).Copy(ctx, src, "dst")
+	require.NoError(t, err)
+	assert.Equal(t, int64(len(data)), dst.Size())
+	assert.Equal(t, data, fake.objects["dst"])
+	parts := 0
+	for _, call := range fake.objectCalls {
+		if call == "PUT dst" {
+			parts++
+		}
+	}
+	assert.Equal(t, 3, parts)
+
+	fake.badETag = true
+	_, err = f.(fs.Copier).Copy(ctx, src, "bad")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "multipart copy corrupted")
+	_, found := fake.objects["bad"]
+	assert.False(t, found)
+
+	// Without MD5 ETags the copy isn't checked or removed
+	f.(*Fs).etagIsNotMD5 = true
+	_, err = f.(fs.Copier).Copy(ctx, src, "unchecked")
+	require.NoError(t, err)
+	assert.Equal(t, data, fake.objects["unchecked"])
+}
+
+func TestCanCheckMultipartETag(t *testing.T) {
+	for _, test := range []struct {
+		provider     string
+		etagIsNotMD5 bool
+		want         bool
+	}{
+		{"AWS", false, true},
+		{"AWS", true, false},
+		{"Minio", false, false},
+		{"Other", false, false},
+	} {
+		f := &Fs{opt: Options{Provider: test.provider}, etagIsNotMD5: test.etagIsNotMD5}
+		assert.Equal(t, test.want, f.canCheckMultipartETag(), "%+v", test)
+	}
+}
@@ -50,11 +50,19 @@ var (
 	currentUser = env.CurrentUser()
 )
 
+// The commands tried in order to find one which can read hashes on the
+// remote. They must print the hash first like md5sum does.
+var (
+	md5sumCommands  = []string{"md5sum", "md5 -r", "gmd5sum", "busybox md5sum", "openssl md5 -r", "rhash --md5", "rclone md5sum"}
+	sha1sumCommands = []string{"sha1sum", "sha1 -r", "gsha1sum", "shasum -a 1", "busybox sha1sum", "openssl sha1 -r", "rhash --sha1", "rclone sha1sum"}
+)
+
 func init() {
 	fsi := &fs.RegInfo{
 		Name:        "sftp",
 		Description: "SSH/SFTP Connection",
 		NewFs:       NewFs,
+		CommandHelp: commandHelp,
 		Options: []fs.Option{{
 			Name:     "host",
 			Help:     "SSH host to connect to.\n\nE.g. \"example.com\".",
@@ -148,10 +156,11 @@ If this is set and no password is supplied then rclone will:
 		}, {
 			Name:    "path_override",
 			Default: "",
-			Help: `Override path used by SSH connection.
+			Help: `Override path used by SSH shell commands.
 
 This allows checksum calculation when SFTP and SSH paths are
-different. This issue affects among others Synology NAS boxes.
+different. This issue affects among others Synology NAS boxes and
+accounts where the SFTP server is chrooted.
 
 Shared folders can be found in directories representing volumes
 
@@ -159,7 +168,24 @@ Shared folders can be found in directories representing volumes
 
 Home directory can be found in a shared folder called "home"
 
-    rclone sync /home/local/directory remote:/home/directory --sftp-path-override /volume1/homes/USER/directory`,
+    rclone sync /home/local/directory remote:/home/directory --sftp-path-override /volume1/homes/USER/directory
+
+To give just the directory the SFTP server's root is found in and
+let rclone add the rest of the path, start the path with "@". The first
+example above can then be written as
+
+    rclone sync /home/local/directory remote:/directory --sftp-path-override @/volume2
+
+Relative paths on the remote are resolved by asking the SFTP server
+for the real path of the current directory, which is normally the
+home directory. On a chrooted (jailed) account this is the path
+inside the jail, e.g. "/" or "/upload". If this isn't set then rclone
+compares this with the working directory the SSH shell reports and
+if the SFTP path is a suffix of the shell path it uses the difference
+as the prefix for paths passed to shell commands, as if this had been
+set to "@" followed by that prefix. If the SFTP path is "/" nothing
+can be worked out, so for accounts chrooted to their home directory
+this needs to be set, e.g. to "@/home/USER".`,
 			Advanced: true,
 		}, {
 			Name:     "set_modtime",
@@ -169,12 +195,12 @@ Home directory can be found in a shared folder called "home"
 		}, {
 			Name:     "md5sum_command",
 			Default:  "",
-			Help:     "The command used to read md5 hashes.\n\nLeave blank for autodetect.",
+			Help:     "The command used to read md5 hashes.\n\nLeave blank for autodetect. This tries " + quoteCommands(md5sumCommands) + " in turn.",
 			Advanced: true,
 		}, {
 			Name:     "sha1sum_command",
 			Default:  "",
-			Help:     "The command used to read sha1 hashes.\n\nLeave blank for autodetect.",
+			Help:     "The command used to read sha1 hashes.\n\nLeave blank for autodetect. This tries " + quoteCommands(sha1sumCommands) + " in turn.",
 			Advanced: true,
 		}, {
 			Name:     "skip_links",
@@ -205,6 +231,13 @@ Fstat instead of Stat which is called on an already open file handle.
 It has been found that this helps with IBM Sterling SFTP servers which have
 "extractability" level set to 1 which means only 1 file can be opened at
 any given time.
+
+This also controls how the size of a file is read back after it is
+uploaded. Normally rclone uses Stat on the path, but if this flag is
+set it uses Fstat on the still open file handle. Some servers return
+the wrong size from one of these calls, which causes spurious size
+mismatches after upload. rclone will log a notice if the size it
+reads back doesn't match, suggesting this flag if appropriate.
 `,
 			Advanced: true,
 		}, {
@@ -246,6 +279,30 @@ If no connections have been returned to the connection pool in the time
 given, rclone will empty the connection pool.
 
 Set to 0 to keep connections indefinitely.
+`,
+			Advanced: true,
+		}, {
+			Name:    "copy_is_hardlink",
+			Default: false,
+			Help: `Set to enable server side copies using hardlinks.
+
+The SFTP protocol does not define a copy command so normally server
+side copies are not allowed with the sftp backend.
+
+If you set this flag then the sftp backend will advertise server side
+copies and implement them by making a hardlink from the source to the
+destination. This needs the server to support the
+"hardlink@openssh.com" extension, which OpenSSH servers do. If the
+server doesn't support it then this flag is ignored and files are
+copied normally.
+
+Not all sftp servers support this and the source and destination must
+be on the same filesystem on the server for it to work.
+
+Note that hardlinked files share their contents and metadata, so if
+one is modified in place the others change too. When this flag is set
+rclone removes a file before uploading a new version of it so that
+only that file is changed.
 `,
 			Advanced: true,
 		}},
@@ -279,6 +336,7 @@ type Options struct {
 	DisableConcurrentReads  bool        `config:"disable_concurrent_reads"`
 	DisableConcurrentWrites bool        `config:"disable_concurrent_writes"`
 	IdleTimeout             fs.Duration `config:"idle_timeout"`
+	CopyIsHardlink          bool        `config:"copy_is_hardlink"`
 }
 
 // Fs stores the interface to the remote SFTP files
@@ -299,7 +357,10 @@ type Fs struct {
 	drain        *time.Timer // used to drain the pool when we stop using the connections
 	pacer        *fs.Pacer   // pacer for operations
 	savedpswd    string
-	sessions     int32 // count in use sessions
+	sessions     int32     // count in use sessions
+	cwd          string    // working directory of the SFTP server or "" if unknown
+	shellOnce    sync.Once // used to set shellRoot once
+	shellRoot    string    // directory the SFTP root is in as seen by the SSH shell
 }
 
 // Object is a remote SFTP file that has been stat'd (so it exists, but is not necessarily open for reading)
@@ -785,18 +846,31 @@ func NewFsWithConnection(ctx context.Context, f *Fs, name string, root string, m
 		CanHaveEmptyDirectories: true,
 		SlowHash:                true,
 	}).Fill(ctx, f)
+	if !opt.CopyIsHardlink {
+		f.features.Copy = nil
+	}
 	// Make a connection and pool it to return errors early
 	c, err := f.getSftpConnection(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("NewFs: %w", err)
 	}
+	if opt.CopyIsHardlink {
+		if _, ok := c.sftpClient.HasExtension("hardlink@openssh.com"); !ok {
+			fs.Logf(f, "Server doesn't support hardlinks so ignoring copy_is_hardlink")
+			f.features.Copy = nil
+		}
+	}
 	cwd, err := c.sftpClient.Getwd()
 	f.putSftpConnection(&c, nil)
 	if err != nil {
 		fs.Debugf(f, "Failed to read current directory - using relative paths: %v", err)
-	} else if !path.IsAbs(f.root) {
-		f.absRoot = path.Join(cwd, f.root)
-		fs.Debugf(f, "Using absolute root directory %q", f.absRoot)
+	} else {
+		fs.Debugf(f, "SFTP server real path of current directory is %q", cwd)
+		f.cwd = cwd
+		if !path.IsAbs(f.root) {
+			f.absRoot = path.Join(cwd, f.root)
+			fs.Debugf(f, "Using absolute root directory %q", f.absRoot)
+		}
 	}
 	if root != "" {
 		// Check to see if the root actually an existing file
@@ -1038,6 +1112,62 @@ func (f *Fs) Rmdir(ctx context.Context, dir string) error {
 	return err
 }
 
+// Copy src to this remote using a hardlink
+//
+// This is only used if copy_is_hardlink is set and the server
+// supports hardlinks.
+//
+// This is stored with the remote path given.
+//
+// It returns the destination Object and a possible error.
+//
+// Will only be called if src.Fs().Name() == f.Name()
+//
+// If it isn't possible then return fs.ErrorCantCopy
+func (f *Fs) Copy(ctx context.Context, src fs.Object, remote string) (fs.Object, error) {
+	srcObj, ok := src.(*Object)
+	if !ok {
+		fs.Debugf(src, "Can't copy - not same remote type")
+		return nil, fs.ErrorCantCopy
+	}
+	err := f.mkParentDir(ctx, remote)
+	if err != nil {
+		return nil, fmt.Errorf("Copy mkParentDir failed: %w", err)
+	}
+	dstPath := path.Join(f.absRoot, remote)
+	if dstPath == srcObj.path() {
+		fs.Debugf(src, "Can't copy - source and destination are the same file")
+		return nil, fs.ErrorCantCopy
+	}
+	c, err := f.getSftpConnection(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("Copy: %w", err)
+	}
+	// Remove any existing destination as the hardlink can't replace it
+	err = c.sftpClient.Remove(dstPath)
+	if err != nil && !os.IsNotExist(err) {
+		f.putSftpConnection(&c, err)
+		return nil, fmt.Errorf("Copy Remove failed: %w", err)
+	}
+	err = c.sftpClient.Link(srcObj.path(), dstPath)
+	f.putSftpConnection(&c, err)
+	if err != nil {
+		// the server may not be able to link these files,
+		// e.g. if they are on different filesystems
+		fs.Debugf(src, "Can't copy - hardlink failed: %v", err)
+		return nil, fs.ErrorCantCopy
+	}
+	dstObj, err := f.NewObject(ctx, remote)
+	if err == fs.ErrorObjectNotFound {
+		fs.Debugf(src, "Can't copy - server didn't make the hardlink")
+		return nil, fs.ErrorCantCopy
+	}
+	if err != nil {
+		return nil, fmt.Errorf("Copy NewObject failed: %w", err)
+	}
+	return dstObj, nil
+}
+
 // Move renames a remote sftp file object
 func (f *Fs) Move(ctx context.Context, src fs.Object, remote string) (fs.Object, error) {
 	srcObj, ok := src.(*Object)
@@ -1164,7 +1294,7 @@ func (f *Fs) Hashes() hash.Set {
 	}
 
 	// look for a hash command which works
-	checkHash := func(commands []string, expected string, hashCommand *string, changed *bool) bool {
+	checkHash := func(ht hash.Type, commands []string, expected string, hashCommand *string, changed *bool) bool {
 		if *hashCommand == hashCommandNotSupported {
 			return false
 		}
@@ -1180,17 +1310,19 @@ func (f *Fs) Hashes() hash.Set {
 			output = bytes.TrimSpace(output)
 			fs.Debugf(f, "checking %q command: %q", command, output)
 			if parseHash(output) == expected {
+				fs.Infof(f, "Using %q to read %v hashes", command, ht)
 				*hashCommand = command
 				return true
 			}
 		}
+		fs.Infof(f, "No command found to read %v hashes - tried %s", ht, quoteCommands(commands))
 		*hashCommand = hashCommandNotSupported
 		return false
 	}
 
 	changed := false
-	md5Works := checkHash([]string{"md5sum", "md5 -r", "rclone md5sum"}, "d41d8cd98f00b204e9800998ecf8427e", &f.opt.Md5sumCommand, &changed)
-	sha1Works := checkHash([]string{"sha1sum", "sha1 -r", "rclone sha1sum"}, "da39a3ee5e6b4b0d3255bfef95601890afd80709", &f.opt.Sha1sumCommand, &changed)
+	md5Works := checkHash(hash.MD5, md5sumCommands, "d41d8cd98f00b204e9800998ecf8427e", &f.opt.Md5sumCommand, &changed)
+	sha1Works := checkHash(hash.SHA1, sha1sumCommands, "da39a3ee5e6b4b0d3255bfef95601890afd80709", &f.opt.Sha1sumCommand, &changed)
 
 	if changed {
 		f.m.Set("md5sum_command", f.opt.Md5sumCommand)
@@ -1209,12 +1341,122 @@ func (f *Fs) Hashes() hash.Set {
 	return set
 }
 
-// About gets usage stats
-func (f *Fs) About(ctx context.Context) (*fs.Usage, error) {
-	escapedPath := shellEscape(f.root)
+// quoteCommands returns commands quoted and separated by commas
+func quoteCommands(commands []string) string {
+	quoted := make([]string, len(commands))
+	for i, command := range commands {
+		quoted[i] = strconv.Quote(command)
+	}
+	return strings.Join(quoted, ", ")
+}
+
+var commandHelp = []fs.CommandHelp{{
+	Name:  "hash-commands",
+	Short: "Show the commands used to read hashes on the remote",
+	Long: `This command finds the commands the remote uses to read MD5 and
+SHA1 hashes, probing for them if they haven't been found already, and
+shows the result.
+
+    rclone backend hash-commands sftp:
+
+It returns a dictionary like this, where "none" means no working
+command was found
+
+    {
+        "md5sum_command": "md5sum",
+        "sha1sum_command": "none"
+    }
+
+The commands found are saved in the config file so the probe is only
+done once per remote. Set --sftp-md5sum-command or
+--sftp-sha1sum-command to override them.
+`,
+}}
+
+// Command the backend to run a named command
+//
+// The command run is name
+// args may be used to read arguments from
+// opts may be used to read optional arguments from
+//
+// The result should be capable of being JSON encoded
+// If it is a string or a []string it will be shown to the user
+// otherwise it will be JSON encoded and shown to the user like that
+func (f *Fs) Command(ctx context.Context, name string, arg []string, opt map[string]string) (out interface{}, err error) {
+	switch name {
+	case "hash-commands":
+		if f.opt.DisableHashCheck {
+			return nil, errors.New("hashes are disabled with --sftp-disable-hashcheck")
+		}
+		_ = f.Hashes()
+		return map[string]string{
+			"md5sum_command":  f.opt.Md5sumCommand,
+			"sha1sum_command": f.opt.Sha1sumCommand,
+		}, nil
+	default:
+		return nil, fs.ErrorCommandNotFound
+	}
+}
+
+// shellRootPrefix returns the directory the SFTP server's root is in
+// as seen by the SSH shell.
+//
+// This is only different from "" if the SFTP server is chrooted. It is
+// worked out the first time it is needed by comparing the working
+// directory of the SFTP server with that of the shell. The difference is
+// only used if the SFTP one is a proper suffix of the shell one, so if
+// the SFTP server starts in "/" nothing is guessed and path_override
+// must be set instead.
+func (f *Fs) shellRootPrefix(ctx context.Context) string {
+	f.shellOnce.Do(func() {
+		if f.cwd == "" || f.cwd == "/" {
+			return
+		}
+		out, err := f.run(ctx, "pwd")
+		if err != nil {
+			fs.Debugf(f, "Couldn't read shell working directory - assuming SFTP server isn't chrooted: %v", err)
+			return
+		}
+		shellCwd := strings.TrimSpace(string(out))
+		if !path.IsAbs(shellCwd) || shellCwd == f.cwd {
+			return
+		}
+		prefix := strings.TrimSuffix(shellCwd, f.cwd)
+		if prefix == shellCwd || !path.IsAbs(prefix) {
+			fs.Debugf(f, "Shell working directory %q doesn't match SFTP %q - not adjusting shell paths", shellCwd, f.cwd)
+			return
+		}
+		fs.Infof(f, "SFTP server appears to be chrooted to %q - using it as the root for shell commands", prefix)
+		f.shellRoot = prefix
+	})
+	return f.shellRoot
+}
+
+// shellPath returns the path of remote, relative to the root of the
+// Fs, as seen by the SSH shell.
+//
+// A path_override without the "@" prefix is the shell path of the root
+// of the Fs for objects, but About has always joined the root onto it
+// so that is kept as it was.
+func (f *Fs) shellPath(ctx context.Context, remote string) string {
 	if f.opt.PathOverride != "" {
-		escapedPath = shellEscape(path.Join(f.opt.PathOverride, f.root))
+		if strings.HasPrefix(f.opt.PathOverride, "@") {
+			return path.Join(f.opt.PathOverride[1:], f.absRoot, remote)
+		}
+		if remote == "" {
+			return path.Join(f.opt.PathOverride, f.root)
+		}
+		return path.Join(f.opt.PathOverride, remote)
 	}
+	if !path.IsAbs(f.absRoot) {
+		return path.Join(f.absRoot, remote)
+	}
+	return path.Join(f.shellRootPrefix(ctx), f.absRoot, remote)
+}
+
+// About gets usage stats
+func (f *Fs) About(ctx context.Context) (*fs.Usage, error) {
+	escapedPath := shellEscape(f.shellPath(ctx, ""))
 	if len(escapedPath) == 0 {
 		escapedPath = "/"
 	}
@@ -1287,10 +1529,7 @@ func (o *Object) Hash(ctx context.Context, r hash.Type) (string, error) {
 		return "", hash.ErrUnsupported
 	}
 
-	escapedPath := shellEscape(o.path())
-	if o.fs.opt.PathOverride != "" {
-		escapedPath = shellEscape(path.Join(o.fs.opt.PathOverride, o.remote))
-	}
+	escapedPath := shellEscape(o.fs.shellPath(ctx, o.remote))
 	b, err := o.fs.run(ctx, hashCmd+" "+escapedPath)
 	if err != nil {
 		return "", fmt.Errorf("failed to calculate %v hash: %w", r, err)
@@ -1540,6 +1779,14 @@ func (o *Object) Update(ctx context.Context, in io.Reader, src fs.ObjectInfo, op
 	if err != nil {
 		return fmt.Errorf("Update: %w", err)
 	}
+	if o.fs.opt.CopyIsHardlink {
+		// Remove the old file so any hardlinks to it aren't changed
+		err = c.sftpClient.Remove(o.path())
+		if err != nil && !os.IsNotExist(err) {
+			o.fs.putSftpConnection(&c, err)
+			return fmt.Errorf("Update Remove failed: %w", err)
+		}
+	}
 	file, err := c.sftpClient.OpenFile(o.path(), os.O_WRONLY|os.O_CREATE|os.O_TRUNC)
 	o.fs.putSftpConnection(&c, err)
 	if err != nil {
@@ -1565,6 +1812,16 @@ func (o *Object) Update(ctx context.Context, in io.Reader, src fs.ObjectInfo, op
 		remove()
 		return fmt.Errorf("Update ReadFrom failed: %w", err)
 	}
+	// Read the size from the open handle if required
+	var fstatInfo os.FileInfo
+	if o.fs.opt.UseFstat {
+		fstatInfo, err = file.Stat()
+		if err != nil {
+			_ = file.Close()
+			remove()
+			return fmt.Errorf("Update Fstat failed: %w", err)
+		}
+	}
 	err = file.Close()
 	if err != nil {
 		remove()
@@ -1591,10 +1848,30 @@ func (o *Object) Update(ctx context.Context, in io.Reader, src fs.ObjectInfo, op
 			return fmt.Errorf("Update stat failed: %w", err)
 		}
 	}
+	o.checkUploadedSize(src, fstatInfo)
 
 	return nil
 }
 
+// checkUploadedSize checks the size read back with stat after an
+// upload.
+//
+// If fstatInfo is set (use_fstat) then the size from that is used
+// instead, logging if stat disagrees. Otherwise a size which differs
+// from the source is logged with a hint to try use_fstat.
+func (o *Object) checkUploadedSize(src fs.ObjectInfo, fstatInfo os.FileInfo) {
+	if fstatInfo != nil {
+		if fstatInfo.Size() != o.size {
+			fs.Logf(o, "Size from stat %d differs from size from fstat %d - using fstat as use_fstat is set", o.size, fstatInfo.Size())
+		}
+		o.size = fstatInfo.Size()
+		return
+	}
+	if size := src.Size(); size >= 0 && o.size != size {
+		fs.Logf(o, "Size from stat %d differs from uploaded size %d - if the server reports the wrong size try --sftp-use-fstat", o.size, size)
+	}
+}
+
 // Remove a remote sftp file object
 func (o *Object) Remove(ctx context.Context) error {
 	c, err := o.fs.getSftpConnection(ctx)
@@ -1610,9 +1887,11 @@ func (o *Object) Remove(ctx context.Context) error {
 var (
 	_ fs.Fs          = &Fs{}
 	_ fs.PutStreamer = &Fs{}
+	_ fs.Copier      = &Fs{}
 	_ fs.Mover       = &Fs{}
 	_ fs.DirMover    = &Fs{}
 	_ fs.Abouter     = &Fs{}
 	_ fs.Shutdowner  = &Fs{}
+	_ fs.Commander   = &Fs{}
 	_ fs.Object      = &Object{}
 )
@@ -4,6 +4,7 @@
 package sftp
 
 import (
+	"context"
 	"fmt"
 	"testing"
 
@@ -31,6 +32,8 @@ func TestParseHash(t *testing.T) {
 	}{
 		{"8dbc7733dbd10d2efc5c0a0d8dad90f958581821  RELEASE.md\n", "8dbc7733dbd10d2efc5c0a0d8dad90f958581821"},
 		{"03cfd743661f07975fa2f1220c5194cbaff48451  -\n", "03cfd743661f07975fa2f1220c5194cbaff48451"},
+		{"D41D8CD98F00B204E9800998ECF8427E *stdin\n", "d41d8cd98f00b204e9800998ecf8427e"},
+		{"d41d8cd98f00b204e9800998ecf8427e  (stdin)\n", "d41d8cd98f00b204e9800998ecf8427e"},
 	} {
 		got := parseHash([]byte(test.sshOutput))
 		assert.Equal(t, test.checksum, got, fmt.Sprintf("Test %d sshOutput = %q", i, test.sshOutput))
@@ -50,3 +53,35 @@ func TestParseUsage(t *testing.T) {
 		assert.Equal(t, test.usage, [3]int64{gotSpaceTotal, gotSpaceUsed, gotSpaceAvail}, fmt.Sprintf("Test %d sshOutput = %q", i, test.sshOutput))
 	}
 }
+
+func TestShellPath(t *testing.T) {
+	ctx := context.Background()
+	for i, test := range []struct {
+		root, absRoot, pathOverride, shellRoot, remote, want string
+	}{
+		{"dir", "/home/user/dir", "", "", "file.txt", "/home/user/dir/file.txt"},
+		{"dir", "dir", "", "/jail", "file.txt", "dir/file.txt"},
+		{"dir", "/upload/dir", "", "/srv/jail/user", "file.txt", "/srv/jail/user/upload/dir/file.txt"},
+		{"dir", "/upload/dir", "/volume2/directory", "/srv/jail/user", "file.txt", "/volume2/directory/file.txt"},
+		{"directory", "/directory", "/volume2", "", "", "/volume2/directory"},
+		{"dir", "/upload/dir", "@/volume2", "/srv/jail/user", "file.txt", "/volume2/upload/dir/file.txt"},
+		{"dir", "/upload/dir", "@/volume2", "", "", "/volume2/upload/dir"},
+	} {
+		f := &Fs{
+			root:      test.root,
+			absRoot:   test.absRoot,
+			opt:       Options{PathOverride: test.pathOverride},
+			shellRoot: test.shellRoot,
+		}
+		f.shellOnce.Do(func() {})
+		got := f.shellPath(ctx, test.remote)
+		assert.Equal(t, test.want, got, fmt.Sprintf("Test %d %+v", i, test))
+	}
+}
+
+func TestShellRootPrefixNoGuess(t *testing.T) {
+	// With the SFTP server starting in "/" nothing is guessed so
+	// no shell command should be run
+	f := &Fs{cwd: "/"}
+	assert.Equal(t, "", f.shellRootPrefix(context.Background()))
+}
@@ -30,3 +30,16 @@ func TestIntegration2(t *testing.T) {
 		NilObject:  (*sftp.Object)(nil),
 	})
 }
+
+func TestIntegration3(t *testing.T) {
+	if *fstest.RemoteName != "" {
+		t.Skip("skipping as -remote is set")
+	}
+	fstests.Run(t, &fstests.Opt{
+		RemoteName: "TestSFTPOpenssh:",
+		NilObject:  (*sftp.Object)(nil),
+		ExtraConfig: []fstests.ExtraConfigItem{
+			{Name: "TestSFTPOpenssh", Key: "copy_is_hardlink", Value: "true"},
+		},
+	})
+}
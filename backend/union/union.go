@@ -8,6 +8,7 @@ import (
 	"io"
 	"path"
 	"path/filepath"
+	"sort"
 	"strings"
 	"sync"
 	"time"
@@ -48,6 +49,46 @@ func init() {
 			Name:    "cache_time",
 			Help:    "Cache time of usage and free space (in seconds).\n\nThis option is only useful when a path preserving policy is used.",
 			Default: 120,
+		}, {
+			Name: "min_free_space",
+			Help: `Minimum free space an upstream must have to create files on it.
+
+Upstreams with less free space than this are treated as no-create
+by the create policies so new files are written to the other
+upstreams instead. If all the upstreams have less free space than
+this then creating a file will fail.
+
+Upstreams which don't report their free space are always used. The
+free space is cached for cache_time. Set to 0 to disable.`,
+			Default: fs.SizeSuffix(0),
+		}, {
+			Name: "dedupe",
+			Help: `How to decide whether copies of a file in several upstreams are the same.
+
+When a file exists at the same path in more than one upstream the
+search policy picks which copy is shown in listings and read from.
+
+If this is set then the other copies are compared against the chosen
+one and only the copies with identical content are treated as the
+same file. Writes, deletes and modification time changes are then
+applied to the chosen copy and its identical copies only. Copies
+with different content are left alone and reported in the log so
+they can be reconciled by hand.
+
+This makes the combined view deterministic when upstreams overlap,
+at the cost of reading hashes or modification times from each copy.`,
+			Default:  "",
+			Advanced: true,
+			Examples: []fs.OptionExample{{
+				Value: "",
+				Help:  "Don't compare copies - all copies are treated as the same file.",
+			}, {
+				Value: dedupeByHash,
+				Help:  "Compare copies by size and hash, falling back to modification time\nif the upstreams have no hash in common.",
+			}, {
+				Value: dedupeBySizeModTime,
+				Help:  "Compare copies by size and modification time.",
+			}},
 		}},
 	}
 	fs.Register(fsi)
@@ -61,8 +102,16 @@ type Options struct {
 	CreatePolicy string          `config:"create_policy"`
 	SearchPolicy string          `config:"search_policy"`
 	CacheTime    int             `config:"cache_time"`
+	MinFreeSpace fs.SizeSuffix   `config:"min_free_space"`
+	Dedupe       string          `config:"dedupe"`
 }
 
+// Values for the dedupe option
+const (
+	dedupeByHash        = "by-hash"
+	dedupeBySizeModTime = "by-size-modtime"
+)
+
 // Fs represents a union of upstreams
 type Fs struct {
 	name         string         // name of this remote
@@ -622,7 +671,7 @@ func (f *Fs) List(ctx context.Context, dir string) (entries fs.DirEntries, err e
 		}
 		return nil, errs.Err()
 	}
-	return f.mergeDirEntries(entriesList)
+	return f.mergeDirEntries(ctx, entriesList)
 }
 
 // ListR lists the objects and directories of the Fs starting
@@ -681,7 +730,7 @@ func (f *Fs) ListR(ctx context.Context, dir string, callback fs.ListRCallback) (
 		}
 		return errs.Err()
 	}
-	entries, err := f.mergeDirEntries(entriesList)
+	entries, err := f.mergeDirEntries(ctx, entriesList)
 	if err != nil {
 		return err
 	}
@@ -714,7 +763,9 @@ func (f *Fs) NewObject(ctx context.Context, remote string) (fs.Object, error) {
 	if err != nil {
 		return nil, err
 	}
-	return e.(*Object), errs.Err()
+	o := e.(*Object)
+	f.dedupeObject(ctx, o)
+	return o, errs.Err()
 }
 
 // Precision is the greatest Precision of all upstreams
@@ -752,7 +803,12 @@ func (f *Fs) searchEntries(entries ...upstream.Entry) (upstream.Entry, error) {
 	return f.searchPolicy.SearchEntries(entries...)
 }
 
-func (f *Fs) mergeDirEntries(entriesList [][]upstream.Entry) (fs.DirEntries, error) {
+func (f *Fs) mergeDirEntries(ctx context.Context, entriesList [][]upstream.Entry) (fs.DirEntries, error) {
+	// Index of each upstream so candidates can be kept in upstream order
+	upstreamIndex := make(map[*upstream.Fs]int, len(f.upstreams))
+	for i, u := range f.upstreams {
+		upstreamIndex[u] = i
+	}
 	entryMap := make(map[string]([]upstream.Entry))
 	for _, en := range entriesList {
 		if en == nil {
@@ -767,16 +823,77 @@ func (f *Fs) mergeDirEntries(entriesList [][]upstream.Entry) (fs.DirEntries, err
 		}
 	}
 	var entries fs.DirEntries
-	for path := range entryMap {
-		e, err := f.wrapEntries(entryMap[path]...)
+	for _, candidates := range entryMap {
+		// ListR delivers the upstreams in the order they finish so
+		// sort the candidates to make the policies deterministic
+		sort.SliceStable(candidates, func(i, j int) bool {
+			return upstreamIndex[candidates[i].UpstreamFs()] < upstreamIndex[candidates[j].UpstreamFs()]
+		})
+		e, err := f.wrapEntries(candidates...)
 		if err != nil {
 			return nil, err
 		}
+		if o, ok := e.(*Object); ok {
+			f.dedupeObject(ctx, o)
+		}
 		entries = append(entries, e)
 	}
 	return entries, nil
 }
 
+// dedupeObject removes the candidates of o whose content differs from
+// the candidate chosen by the search policy if the dedupe option is set.
+//
+// The differing candidates are logged so the user can reconcile them.
+func (f *Fs) dedupeObject(ctx context.Context, o *Object) {
+	if f.opt.Dedupe == "" || len(o.co) <= 1 {
+		return
+	}
+	chosen := o.Object
+	same := make([]upstream.Entry, 0, len(o.co))
+	for _, e := range o.co {
+		candidate, ok := e.(*upstream.Object)
+		if !ok {
+			continue
+		}
+		if candidate == chosen || f.sameContent(ctx, chosen, candidate) {
+			same = append(same, candidate)
+		} else {
+			fs.Logf(chosen, "Ignoring copy in %v as its content differs from the copy in %v", candidate.UpstreamFs(), chosen.UpstreamFs())
+		}
+	}
+	o.co = same
+}
+
+// sameContent returns whether a and b have the same content according
+// to the dedupe option.
+func (f *Fs) sameContent(ctx context.Context, a, b *upstream.Object) bool {
+	if a.Size() != b.Size() {
+		return false
+	}
+	if f.opt.Dedupe == dedupeByHash {
+		ht := a.UpstreamFs().Hashes().Overlap(b.UpstreamFs().Hashes()).GetOne()
+		if ht != hash.None {
+			aSum, aErr := a.Hash(ctx, ht)
+			bSum, bErr := b.Hash(ctx, ht)
+			if aErr == nil && bErr == nil && aSum != "" && bSum != "" {
+				return aSum == bSum
+			}
+		}
+		fs.Debugf(a, "No common hash with %v - comparing modification times instead", b.UpstreamFs())
+	}
+	// Compare modification times to the precision of the less precise upstream
+	precision := a.UpstreamFs().Precision()
+	if bPrecision := b.UpstreamFs().Precision(); bPrecision > precision {
+		precision = bPrecision
+	}
+	if precision == fs.ModTimeNotSupported {
+		return true
+	}
+	dt := a.ModTime(ctx).Sub(b.ModTime(ctx))
+	return dt < precision && dt > -precision
+}
+
 // Shutdown the backend, closing any background tasks and any
 // cached connections.
 func (f *Fs) Shutdown(ctx context.Context) error {
@@ -826,7 +943,7 @@ func NewFs(ctx context.Context, name, root string, m configmap.Mapper) (fs.Fs, e
 	errs := Errors(make([]error, len(opt.Upstreams)))
 	multithread(len(opt.Upstreams), func(i int) {
 		u := opt.Upstreams[i]
-		upstreams[i], errs[i] = upstream.New(ctx, u, root, time.Duration(opt.CacheTime)*time.Second)
+		upstreams[i], errs[i] = upstream.New(ctx, u, root, time.Duration(opt.CacheTime)*time.Second, int64(opt.MinFreeSpace))
 	})
 	var usedUpstreams []*upstream.Fs
 	var fserr error
@@ -862,6 +979,11 @@ func NewFs(ctx context.Context, name, root string, m configmap.Mapper) (fs.Fs, e
 	if err != nil {
 		return nil, err
 	}
+	switch opt.Dedupe {
+	case "", dedupeByHash, dedupeBySizeModTime:
+	default:
+		return nil, fmt.Errorf("unknown dedupe mode %q - expecting %q or %q", opt.Dedupe, dedupeByHash, dedupeBySizeModTime)
+	}
 	fs.Debugf(f, "actionPolicy = %T, createPolicy = %T, searchPolicy = %T", f.actionPolicy, f.createPolicy, f.searchPolicy)
 	var features = (&fs.Features{
 		CaseInsensitive:         true,
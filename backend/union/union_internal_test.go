@@ -9,6 +9,7 @@ import (
 	"testing"
 	"time"
 
+	_ "github.com/rclone/rclone/backend/memory"
 	"github.com/rclone/rclone/fs"
 	"github.com/rclone/rclone/fs/object"
 	"github.com/rclone/rclone/fs/operations"
@@ -166,3 +167,105 @@ func TestMoveCopy(t *testing.T) {
 		})
 	})
 }
+
+// Check the dedupe option only treats copies with the same content
+// as the same file and that the chosen copy is deterministic
+func TestDedupe(t *testing.T) {
+	if *fstest.RemoteName != "" {
+		t.Skip("Skipping as -remote set")
+	}
+	ctx := context.Background()
+	for _, mode := range []string{dedupeByHash, dedupeBySizeModTime} {
+		t.Run(mode, func(t *testing.T) {
+			dirs, clean := MakeTestDirs(t, 2)
+			defer clean()
+			fsString := fmt.Sprintf(":union,upstreams='%s %s',search_policy=ff,dedupe=%s:", dirs[0], dirs[1], mode)
+			f, err := fs.NewFs(ctx, fsString)
+			require.NoError(t, err)
+			unionFs := f.(*Fs)
+
+			t1 := fstest.Time("2001-02-03T04:05:06.499999999Z")
+			t2 := fstest.Time("2011-12-25T12:59:59.123456789Z")
+			same := random.String(50)
+			for _, u := range unionFs.upstreams {
+				item := fstest.NewItem("same.txt", same, t1)
+				_, _ = fstests.PutTestContents(ctx, t, u.Fs, &item, same, true)
+			}
+			for i, u := range unionFs.upstreams {
+				contents := random.String(60)
+				item := fstest.NewItem("differ.txt", contents, []time.Time{t1, t2}[i])
+				_, _ = fstests.PutTestContents(ctx, t, u.Fs, &item, contents, true)
+			}
+
+			check := func(t *testing.T, o *Object, name string, wantCandidates int) {
+				assert.Equal(t, name, o.Remote())
+				assert.Equal(t, unionFs.upstreams[0], o.UpstreamFs())
+				assert.Len(t, o.candidates(), wantCandidates)
+			}
+
+			o, err := f.NewObject(ctx, "same.txt")
+			require.NoError(t, err)
+			check(t, o.(*Object), "same.txt", 2)
+			o, err = f.NewObject(ctx, "differ.txt")
+			require.NoError(t, err)
+			check(t, o.(*Object), "differ.txt", 1)
+
+			var entries fs.DirEntries
+			err = unionFs.ListR(ctx, "", func(newEntries fs.DirEntries) error {
+				entries = append(entries, newEntries...)
+				return nil
+			})
+			require.NoError(t, err)
+			require.Len(t, entries, 2)
+			for _, entry := range entries {
+				o := entry.(*Object)
+				if o.Remote() == "same.txt" {
+					check(t, o, "same.txt", 2)
+				} else {
+					check(t, o, "differ.txt", 1)
+				}
+			}
+		})
+	}
+}
+
+// Check upstreams with less than min_free_space aren't created on
+func TestMinFreeSpace(t *testing.T) {
+	if *fstest.RemoteName != "" {
+		t.Skip("Skipping as -remote set")
+	}
+	ctx := context.Background()
+	dirs, clean := MakeTestDirs(t, 2)
+	defer clean()
+	put := func(t *testing.T, f fs.Fs) (fs.Object, error) {
+		contents := random.String(50)
+		src := object.NewStaticObjectInfo("file.txt", time.Now(), int64(len(contents)), true, nil, nil)
+		return f.Put(ctx, bytes.NewBufferString(contents), src)
+	}
+
+	t.Run("Steer", func(t *testing.T) {
+		// The memory upstream doesn't report its free space so is always used
+		fsString := fmt.Sprintf(":union,upstreams='%s :memory:%s',create_policy=ff,min_free_space=1P:", dirs[0], dirs[1])
+		f, err := fs.NewFs(ctx, fsString)
+		require.NoError(t, err)
+		o, err := put(t, f)
+		require.NoError(t, err)
+		assert.Equal(t, f.(*Fs).upstreams[1], o.(*Object).UpstreamFs())
+	})
+
+	t.Run("AllBelow", func(t *testing.T) {
+		fsString := fmt.Sprintf(":union,upstreams='%s %s',create_policy=ff,min_free_space=1P:", dirs[0], dirs[1])
+		f, err := fs.NewFs(ctx, fsString)
+		require.NoError(t, err)
+		_, err = put(t, f)
+		assert.Equal(t, fs.ErrorPermissionDenied, err)
+	})
+
+	t.Run("Above", func(t *testing.T) {
+		fsString := fmt.Sprintf(":union,upstreams='%s %s',create_policy=ff,min_free_space=1:", dirs[0], dirs[1])
+		f, err := fs.NewFs(ctx, fsString)
+		require.NoError(t, err)
+		_, err = put(t, f)
+		require.NoError(t, err)
+	})
+}
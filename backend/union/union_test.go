@@ -18,7 +18,7 @@ func TestIntegration(t *testing.T) {
 	}
 	fstests.Run(t, &fstests.Opt{
 		RemoteName:                   *fstest.RemoteName,
-		UnimplementableFsMethods:     []string{"OpenWriterAt", "DuplicateFiles"},
+		UnimplementableFsMethods:     []string{"OpenWriterAt", "OpenWriterAtResume", "DuplicateFiles"},
 		UnimplementableObjectMethods: []string{"MimeType"},
 	})
 }
@@ -40,7 +40,7 @@ func TestStandard(t *testing.T) {
 			{Name: name, Key: "create_policy", Value: "epmfs"},
 			{Name: name, Key: "search_policy", Value: "ff"},
 		},
-		UnimplementableFsMethods:     []string{"OpenWriterAt", "DuplicateFiles"},
+		UnimplementableFsMethods:     []string{"OpenWriterAt", "OpenWriterAtResume", "DuplicateFiles"},
 		UnimplementableObjectMethods: []string{"MimeType"},
 	})
 }
@@ -62,7 +62,7 @@ func TestRO(t *testing.T) {
 			{Name: name, Key: "create_policy", Value: "epmfs"},
 			{Name: name, Key: "search_policy", Value: "ff"},
 		},
-		UnimplementableFsMethods:     []string{"OpenWriterAt", "DuplicateFiles"},
+		UnimplementableFsMethods:     []string{"OpenWriterAt", "OpenWriterAtResume", "DuplicateFiles"},
 		UnimplementableObjectMethods: []string{"MimeType"},
 	})
 }
@@ -84,7 +84,7 @@ func TestNC(t *testing.T) {
 			{Name: name, Key: "create_policy", Value: "epmfs"},
 			{Name: name, Key: "search_policy", Value: "ff"},
 		},
-		UnimplementableFsMethods:     []string{"OpenWriterAt", "DuplicateFiles"},
+		UnimplementableFsMethods:     []string{"OpenWriterAt", "OpenWriterAtResume", "DuplicateFiles"},
 		UnimplementableObjectMethods: []string{"MimeType"},
 	})
 }
@@ -106,7 +106,7 @@ func TestPolicy1(t *testing.T) {
 			{Name: name, Key: "create_policy", Value: "lus"},
 			{Name: name, Key: "search_policy", Value: "all"},
 		},
-		UnimplementableFsMethods:     []string{"OpenWriterAt", "DuplicateFiles"},
+		UnimplementableFsMethods:     []string{"OpenWriterAt", "OpenWriterAtResume", "DuplicateFiles"},
 		UnimplementableObjectMethods: []string{"MimeType"},
 	})
 }
@@ -128,7 +128,7 @@ func TestPolicy2(t *testing.T) {
 			{Name: name, Key: "create_policy", Value: "rand"},
 			{Name: name, Key: "search_policy", Value: "ff"},
 		},
-		UnimplementableFsMethods:     []string{"OpenWriterAt", "DuplicateFiles"},
+		UnimplementableFsMethods:     []string{"OpenWriterAt", "OpenWriterAtResume", "DuplicateFiles"},
 		UnimplementableObjectMethods: []string{"MimeType"},
 	})
 }
@@ -150,7 +150,7 @@ func TestPolicy3(t *testing.T) {
 			{Name: name, Key: "create_policy", Value: "all"},
 			{Name: name, Key: "search_policy", Value: "all"},
 		},
-		UnimplementableFsMethods:     []string{"OpenWriterAt", "DuplicateFiles"},
+		UnimplementableFsMethods:     []string{"OpenWriterAt", "OpenWriterAtResume", "DuplicateFiles"},
 		UnimplementableObjectMethods: []string{"MimeType"},
 	})
 }
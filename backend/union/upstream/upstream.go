@@ -24,16 +24,17 @@ var (
 // Fs is a wrap of any fs and its configs
 type Fs struct {
 	fs.Fs
-	RootFs      fs.Fs
-	RootPath    string
-	writable    bool
-	creatable   bool
-	usage       *fs.Usage     // Cache the usage
-	cacheTime   time.Duration // cache duration
-	cacheExpiry int64         // usage cache expiry time
-	cacheMutex  sync.RWMutex
-	cacheOnce   sync.Once
-	cacheUpdate bool // if the cache is updating
+	RootFs       fs.Fs
+	RootPath     string
+	writable     bool
+	creatable    bool
+	minFreeSpace int64         // don't create on this upstream with less free space than this
+	usage        *fs.Usage     // Cache the usage
+	cacheTime    time.Duration // cache duration
+	cacheExpiry  int64         // usage cache expiry time
+	cacheMutex   sync.RWMutex
+	cacheOnce    sync.Once
+	cacheUpdate  bool // if the cache is updating
 }
 
 // Directory describes a wrapped Directory
@@ -61,18 +62,19 @@ type Entry interface {
 
 // New creates a new Fs based on the
 // string formatted `type:root_path(:ro/:nc)`
-func New(ctx context.Context, remote, root string, cacheTime time.Duration) (*Fs, error) {
+func New(ctx context.Context, remote, root string, cacheTime time.Duration, minFreeSpace int64) (*Fs, error) {
 	configName, fsPath, err := fspath.SplitFs(remote)
 	if err != nil {
 		return nil, err
 	}
 	f := &Fs{
-		RootPath:    strings.TrimRight(root, "/"),
-		writable:    true,
-		creatable:   true,
-		cacheExpiry: time.Now().Unix(),
-		cacheTime:   cacheTime,
-		usage:       &fs.Usage{},
+		RootPath:     strings.TrimRight(root, "/"),
+		writable:     true,
+		creatable:    true,
+		minFreeSpace: minFreeSpace,
+		cacheExpiry:  time.Now().Unix(),
+		cacheTime:    cacheTime,
+		usage:        &fs.Usage{},
 	}
 	if strings.HasSuffix(fsPath, ":ro") {
 		f.writable = false
@@ -153,8 +155,20 @@ func (o *Object) UnWrap() fs.Object {
 }
 
 // IsCreatable return if the fs is allowed to create new objects
+//
+// This is false if the fs has less free space than the minimum
 func (f *Fs) IsCreatable() bool {
-	return f.creatable
+	if !f.creatable {
+		return false
+	}
+	if f.minFreeSpace > 0 {
+		space, err := f.GetFreeSpace()
+		if err == nil && space < f.minFreeSpace {
+			fs.Debugf(f, "Not creating on upstream as free space %v is below the minimum %v", fs.SizeSuffix(space), fs.SizeSuffix(f.minFreeSpace))
+			return false
+		}
+	}
+	return true
 }
 
 // IsWritable return if the fs is allowed to write
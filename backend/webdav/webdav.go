@@ -105,6 +105,26 @@ func init() {
 			Name:     "bearer_token_command",
 			Help:     "Command to run to get a bearer token.",
 			Advanced: true,
+		}, {
+			Name: "auth_redirect",
+			Help: `Detect redirects to a login page.
+
+Servers behind a single sign on (SSO) system often answer requests
+which aren't authenticated with a redirect to a login page.
+
+If this is set then a redirect to somewhere outside the url is taken
+to mean that authentication is required. If bearer_token_command is
+set then rclone will run it to get a fresh token and retry, otherwise
+it will fail with an error rather than misreading the login page.
+
+Downloads may be redirected anywhere as some servers redirect them to
+a content delivery network.
+
+This is off by default as some servers redirect other requests
+outside the url as a matter of course. Enable it if your server is
+behind an SSO system.`,
+			Default:  false,
+			Advanced: true,
 		}, {
 			Name:     config.ConfigEncoding,
 			Help:     configEncodingHelp,
@@ -136,6 +156,7 @@ type Options struct {
 	Pass               string               `config:"pass"`
 	BearerToken        string               `config:"bearer_token"`
 	BearerTokenCommand string               `config:"bearer_token_command"`
+	AuthRedirect       bool                 `config:"auth_redirect"`
 	Enc                encoder.MultiEncoder `config:"encoding"`
 	Headers            fs.CommaSepList      `config:"headers"`
 }
@@ -213,7 +234,7 @@ func (f *Fs) shouldRetry(ctx context.Context, resp *http.Response, err error) (b
 		return false, err
 	}
 	// If we have a bearer token command and it has expired then refresh it
-	if f.opt.BearerTokenCommand != "" && resp != nil && resp.StatusCode == 401 {
+	if f.opt.BearerTokenCommand != "" && (resp != nil && resp.StatusCode == 401 || errors.Is(err, errAuthRedirect)) {
 		fs.Debugf(f, "Bearer token expired: %v", err)
 		authErr := f.fetchAndSetBearerToken()
 		if authErr != nil {
@@ -318,6 +339,45 @@ func (f *Fs) readMetaDataForPath(ctx context.Context, path string, depth string)
 	return &item.Props, nil
 }
 
+// errAuthRedirect is returned if the server redirects a request to
+// what looks like a login page
+var errAuthRedirect = errors.New("authentication required: server redirected to a login page - SSO logins are not supported so use bearer_token or bearer_token_command")
+
+// isAuthRedirect returns true if a redirect to u leaves the endpoint
+// which most likely means the server wants us to log in
+func (f *Fs) isAuthRedirect(u *url.URL) bool {
+	if !f.opt.AuthRedirect {
+		return false
+	}
+	return u.Host != f.endpoint.Host || !strings.HasPrefix(u.Path, f.endpoint.Path)
+}
+
+// checkRedirect stops the http client following a redirect to a login
+// page for anything other than a download. Otherwise go would turn the
+// request into a GET of the login page and report success.
+func (f *Fs) checkRedirect(req *http.Request, via []*http.Request) error {
+	if len(via) >= 10 {
+		return errors.New("stopped after 10 redirects")
+	}
+	if method := via[0].Method; method != "GET" && method != "HEAD" && f.isAuthRedirect(req.URL) {
+		return fmt.Errorf("%w: %s", errAuthRedirect, req.URL)
+	}
+	return nil
+}
+
+// authErrorHandler returns errAuthRedirect for redirects to a login
+// page which weren't followed, otherwise it calls errorHandler.
+func (f *Fs) authErrorHandler(resp *http.Response) error {
+	switch resp.StatusCode {
+	case http.StatusMovedPermanently, http.StatusFound, http.StatusSeeOther, http.StatusTemporaryRedirect, http.StatusPermanentRedirect:
+		if location, err := resp.Location(); err == nil && f.isAuthRedirect(location) {
+			_ = resp.Body.Close()
+			return fmt.Errorf("%w: %s", errAuthRedirect, location)
+		}
+	}
+	return errorHandler(resp)
+}
+
 // errorHandler parses a non 2xx error response into an error
 func errorHandler(resp *http.Response) error {
 	body, err := rest.ReadBody(resp)
@@ -416,6 +476,7 @@ func NewFs(ctx context.Context, name, root string, m configmap.Mapper) (fs.Fs, e
 	}
 
 	client := fshttp.NewClient(ctx)
+	client.CheckRedirect = f.checkRedirect
 	if opt.Vendor == "sharepoint-ntlm" {
 		// Disable transparent HTTP/2 support as per https://golang.org/pkg/net/http/ ,
 		// otherwise any connection to IIS 10.0 fails with 'stream error: stream ID 39; HTTP_1_1_REQUIRED'
@@ -449,7 +510,7 @@ func NewFs(ctx context.Context, name, root string, m configmap.Mapper) (fs.Fs, e
 	if opt.Headers != nil {
 		f.addHeaders(opt.Headers)
 	}
-	f.srv.SetErrorHandler(errorHandler)
+	f.srv.SetErrorHandler(f.authErrorHandler)
 	err = f.setQuirks(ctx, opt.Vendor)
 	if err != nil {
 		return nil, err
@@ -72,3 +72,46 @@ func TestHeaders(t *testing.T) {
 	_, err := f.Features().About(context.Background())
 	require.NoError(t, err)
 }
+
+// TestAuthRedirect checks redirects to a login page are reported as
+// needing authentication
+func TestAuthRedirect(t *testing.T) {
+	ctx := context.Background()
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasPrefix(r.URL.Path, "/login") {
+			_, _ = w.Write([]byte("<html>Please log in</html>"))
+			return
+		}
+		http.Redirect(w, r, "/login?next="+r.URL.Path, http.StatusFound)
+	})
+	ts := httptest.NewServer(handler)
+	defer ts.Close()
+	configfile.Install()
+
+	m := configmap.Simple{
+		"type":          "webdav",
+		"url":           ts.URL + "/dav/",
+		"auth_redirect": "true",
+	}
+	f, err := webdav.NewFs(ctx, remoteName, "", m)
+	require.NoError(t, err)
+
+	// PROPFIND doesn't follow redirects
+	_, err = f.List(ctx, "")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "authentication required")
+
+	// MKCOL would follow the redirect and GET the login page
+	err = f.Mkdir(ctx, "dir")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "authentication required")
+
+	// Check it is off by default - the login page is then
+	// mistaken for an empty directory
+	delete(m, "auth_redirect")
+	f, err = webdav.NewFs(ctx, remoteName, "", m)
+	require.NoError(t, err)
+	entries, err := f.List(ctx, "")
+	require.NoError(t, err)
+	assert.Len(t, entries, 0)
+}
@@ -156,7 +156,7 @@ def read_command(command):
 
 def read_commands(docpath):
     """Reads the commands an makes them into a single page"""
-    files = set(f for f in os.listdir(docpath + "/commands") if f.endswith(".md"))
+    files = set(f for f in os.listdir(docpath + "/commands") if f.endswith(".md") and not f.startswith("_"))
     docs = []
     for command in commands_order:
         docs.append(read_command(command))
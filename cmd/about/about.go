@@ -10,12 +10,14 @@ import (
 	"github.com/rclone/rclone/cmd"
 	"github.com/rclone/rclone/fs"
 	"github.com/rclone/rclone/fs/config/flags"
+	"github.com/rclone/rclone/fs/operations"
 	"github.com/spf13/cobra"
 )
 
 var (
 	jsonOutput bool
 	fullOutput bool
+	tierOutput bool
 )
 
 func init() {
@@ -23,6 +25,17 @@ func init() {
 	cmdFlags := commandDefinition.Flags()
 	flags.BoolVarP(cmdFlags, &jsonOutput, "json", "", false, "Format output as JSON")
 	flags.BoolVarP(cmdFlags, &fullOutput, "full", "", false, "Full numbers instead of human-readable")
+	flags.BoolVarP(cmdFlags, &tierOutput, "tiers", "", false, "Show the bytes and objects in each storage tier, listing the remote if needed")
+}
+
+// formatValue formats v to be output
+func formatValue(v int64, isSize bool) string {
+	if fullOutput {
+		return fmt.Sprintf("%d", v)
+	} else if isSize {
+		return fs.SizeSuffix(v).ByteUnit()
+	}
+	return fs.CountSuffix(v).String()
 }
 
 // printValue formats uv to be output
@@ -31,15 +44,57 @@ func printValue(what string, uv *int64, isSize bool) {
 	if uv == nil {
 		return
 	}
-	var val string
-	if fullOutput {
-		val = fmt.Sprintf("%d", *uv)
-	} else if isSize {
-		val = fs.SizeSuffix(*uv).ByteUnit()
-	} else {
-		val = fs.CountSuffix(*uv).String()
+	fmt.Printf("%-9s%v\n", what, formatValue(*uv, isSize))
+}
+
+// printTiers prints the usage of each tier in tiers
+func printTiers(tiers []fs.TierUsage) {
+	fmt.Printf("Tiers:\n")
+	for _, tier := range tiers {
+		name := tier.Tier
+		if name == "" {
+			name = "(none)"
+		}
+		line := fmt.Sprintf("  %-11s", name+":")
+		if tier.Used != nil {
+			line += formatValue(*tier.Used, true)
+		}
+		if tier.Objects != nil {
+			if tier.Used != nil {
+				line += " in "
+			}
+			line += formatValue(*tier.Objects, false) + " objects"
+		}
+		fmt.Println(line)
 	}
-	fmt.Printf("%-9s%v\n", what, val)
+}
+
+// addTiers adds the usage of each storage tier of f to u by listing f
+// unless the backend has already supplied them.
+//
+// If f couldn't do an About then the totals are set from the listing.
+// If none of the objects have a tier then u.Tiers is left empty.
+func addTiers(ctx context.Context, f fs.Fs, u *fs.Usage, haveAbout bool) error {
+	if len(u.Tiers) > 0 {
+		return nil
+	}
+	tiers, err := operations.CountTiers(ctx, f)
+	if err != nil {
+		return fmt.Errorf("failed to count the storage tiers: %w", err)
+	}
+	if !haveAbout {
+		u.Used, u.Objects = fs.NewUsageValue(0), fs.NewUsageValue(0)
+		for _, tier := range tiers {
+			*u.Used += *tier.Used
+			*u.Objects += *tier.Objects
+		}
+	}
+	if len(tiers) == 0 || (len(tiers) == 1 && tiers[0].Tier == "") {
+		fs.Logf(f, "Storage tiers aren't available on this remote so only showing the totals")
+		return nil
+	}
+	u.Tiers = tiers
+	return nil
 }
 
 var commandDefinition = &cobra.Command{
@@ -89,23 +144,55 @@ A ` + "`--json`" + ` flag generates conveniently machine-readable output, e.g.
 Not all backends print all fields. Information is not included if it is not
 provided by a backend. Where the value is unlimited it is omitted.
 
+A ` + "`--tiers`" + ` flag adds the bytes and number of objects in each storage
+tier or class, e.g. STANDARD and GLACIER on S3 or Hot, Cool and Archive
+on Azure Blob, for backends which support tiers, e.g.
+
+    Used:    7.444 GiB
+    Objects: 1.234k
+    Tiers:
+      GLACIER:   5.000 GiB in 1k objects
+      STANDARD:  2.444 GiB in 234 objects
+
+These are under ` + "`tiers`" + ` in the ` + "`--json`" + ` output. None of the
+backends can report these directly, so rclone lists the whole remote
+to count them, which may take a long time and be charged for on large
+remotes. The filters can be used to count only some of it. On backends
+without ` + "`rclone about`" + ` support the totals used and objects are from
+the listing too. If the objects have no tiers only the totals are
+shown.
+
 Some backends does not support the ` + "`rclone about`" + ` command at all,
 see complete list in [documentation](https://rclone.org/overview/#optional-features).
 `,
+	Annotations: map[string]string{
+		"rc": "operations/about",
+	},
 	Run: func(command *cobra.Command, args []string) {
 		cmd.CheckArgs(1, 1, command, args)
 		f := cmd.NewFsSrc(args)
 		cmd.Run(false, false, command, func() error {
+			ctx := context.Background()
 			doAbout := f.Features().About
-			if doAbout == nil {
+			if doAbout == nil && !tierOutput {
 				return fmt.Errorf("%v doesn't support about", f)
 			}
-			u, err := doAbout(context.Background())
-			if err != nil {
-				return fmt.Errorf("About call failed: %w", err)
+			u := &fs.Usage{}
+			if doAbout != nil {
+				var err error
+				u, err = doAbout(ctx)
+				if err != nil {
+					return fmt.Errorf("About call failed: %w", err)
+				}
+				if u == nil {
+					return errors.New("nil usage returned")
+				}
 			}
-			if u == nil {
-				return errors.New("nil usage returned")
+			if tierOutput {
+				err := addTiers(ctx, f, u, doAbout != nil)
+				if err != nil {
+					return err
+				}
 			}
 			if jsonOutput {
 				out := json.NewEncoder(os.Stdout)
@@ -119,6 +206,9 @@ see complete list in [documentation](https://rclone.org/overview/#optional-featu
 			printValue("Trashed", u.Trashed, true)
 			printValue("Other", u.Other, true)
 			printValue("Objects", u.Objects, false)
+			if len(u.Tiers) > 0 {
+				printTiers(u.Tiers)
+			}
 			return nil
 		})
 	},
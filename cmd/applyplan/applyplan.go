@@ -0,0 +1,73 @@
+// Package applyplan provides the apply-plan command.
+package applyplan
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/rclone/rclone/cmd"
+	"github.com/rclone/rclone/fs/sync"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	cmd.Root.AddCommand(commandDefinition)
+}
+
+var commandDefinition = &cobra.Command{
+	Use:   "apply-plan plan.json [source:path dest:path]",
+	Short: `Make the changes recorded in a plan made by check --plan.`,
+	Long: strings.ReplaceAll(`
+Makes exactly the changes recorded in a plan written by
+[rclone check --plan](/commands/rclone_check/) to the destination.
+
+This lets you review the changes a sync would make, and then be sure
+that only those changes are made.
+
+    rclone check --plan plan.json source:path dest:path
+    # review plan.json
+    rclone apply-plan plan.json
+
+The source and destination recorded in the plan are used unless they
+are supplied on the command line.
+
+Before each file is copied, updated or deleted rclone checks that the
+files the change is based on (the source file for copies, both the
+source and destination files for updates and the destination file
+for deletes) still have the size, modification time and hash
+recorded in the plan. If they don't, or if a file the plan expected
+to be missing on the destination now exists, that change is skipped
+with an error. The other changes are still made.
+
+Files not in the plan are never touched, so anything which changed
+after the plan was made will need another check and plan.
+
+Use |--dry-run| to see what would be done.
+`, "|", "`"),
+	RunE: func(command *cobra.Command, args []string) error {
+		cmd.CheckArgs(1, 3, command, args)
+		if len(args) == 2 {
+			cmd.CheckArgs(3, 3, command, args)
+		}
+		in, err := os.Open(args[0])
+		if err != nil {
+			return fmt.Errorf("failed to open plan: %w", err)
+		}
+		plan, err := sync.ReadPlan(in)
+		_ = in.Close()
+		if err != nil {
+			return err
+		}
+		remotes := args[1:]
+		if len(remotes) == 0 {
+			remotes = []string{plan.Src, plan.Dst}
+		}
+		fsrc, fdst := cmd.NewFsSrcDst(remotes)
+		cmd.Run(true, true, command, func() error {
+			return sync.ApplyPlan(context.Background(), fdst, fsrc, plan)
+		})
+		return nil
+	},
+}
@@ -21,6 +21,7 @@ var (
 	offset  = int64(0)
 	count   = int64(-1)
 	discard = false
+	lines   = false
 )
 
 func init() {
@@ -31,6 +32,7 @@ func init() {
 	flags.Int64VarP(cmdFlags, &offset, "offset", "", offset, "Start printing at offset N (or from end if -ve)")
 	flags.Int64VarP(cmdFlags, &count, "count", "", count, "Only print N characters")
 	flags.BoolVarP(cmdFlags, &discard, "discard", "", discard, "Discard the output instead of printing")
+	flags.BoolVarP(cmdFlags, &lines, "lines", "", lines, "Make --head and --tail count lines instead of characters")
 }
 
 var commandDefinition = &cobra.Command{
@@ -56,6 +58,13 @@ Use the |--head| flag to print characters only at the start, |--tail| for
 the end and |--offset| and |--count| to print a section in the middle.
 Note that if offset is negative it will count from the end, so
 |--offset -1 --count 1| is equivalent to |--tail 1|.
+
+Add the |--lines| flag to make |--head| and |--tail| count lines
+rather than characters, which is useful for looking at log files, e.g.
+
+    rclone cat --tail 20 --lines remote:path/to/log.txt
+
+With |--tail| only the end of the file is read if its size is known.
 `, "|", "`"),
 	Run: func(command *cobra.Command, args []string) {
 		usedOffset := offset != 0 || count >= 0
@@ -64,6 +73,12 @@ Note that if offset is negative it will count from the end, so
 		if usedHead && usedTail || usedHead && usedOffset || usedTail && usedOffset {
 			log.Fatalf("Can only use one of  --head, --tail or --offset with --count")
 		}
+		if lines && usedOffset {
+			log.Fatalf("Can't use --lines with --offset or --count")
+		}
+		if lines && !usedHead && !usedTail {
+			log.Fatalf("Need --head or --tail with --lines")
+		}
 		if head > 0 {
 			offset = 0
 			count = head
@@ -79,6 +94,9 @@ Note that if offset is negative it will count from the end, so
 			w = ioutil.Discard
 		}
 		cmd.Run(false, false, command, func() error {
+			if lines {
+				return operations.CatLines(context.Background(), fsrc, w, head, tail)
+			}
 			return operations.Cat(context.Background(), fsrc, w, offset, count)
 		})
 	},
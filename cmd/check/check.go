@@ -2,6 +2,7 @@ package check
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"os"
@@ -12,6 +13,7 @@ import (
 	"github.com/rclone/rclone/fs/config/flags"
 	"github.com/rclone/rclone/fs/hash"
 	"github.com/rclone/rclone/fs/operations"
+	"github.com/rclone/rclone/fs/sync"
 	"github.com/spf13/cobra"
 	"github.com/spf13/pflag"
 )
@@ -27,6 +29,8 @@ var (
 	differ            = ""
 	errFile           = ""
 	checkFileHashType = ""
+	planFile          = ""
+	reportHash        = false
 )
 
 func init() {
@@ -34,6 +38,8 @@ func init() {
 	cmdFlags := commandDefinition.Flags()
 	flags.BoolVarP(cmdFlags, &download, "download", "", download, "Check by downloading rather than with hash")
 	flags.StringVarP(cmdFlags, &checkFileHashType, "checkfile", "C", checkFileHashType, "Treat source:path as a SUM file with hashes of given type")
+	flags.StringVarP(cmdFlags, &planFile, "plan", "", planFile, "Write a plan of the changes needed to make dest match source to this file")
+	flags.BoolVarP(cmdFlags, &reportHash, "report-hash", "", reportHash, "Print the hash that would be used for the check and exit")
 	AddFlags(cmdFlags)
 }
 
@@ -149,6 +155,21 @@ to check all the data.
 
 If you supply the |--checkfile HASH| flag with a valid hash name,
 the |source:path| must point to a text file in the SUM format.
+
+If you supply the |--report-hash| flag, it will print the name of the
+hash the source and destination have in common, which is the one the
+check would use, and exit without checking anything. If there is no
+hash in common it prints |none| and the check would need |--download|
+or |--size-only|.
+
+If you supply the |--plan FILE| flag (or |-| for stdout) then a JSON
+plan of the changes needed to make the destination match the source is
+written to the file. This lists the files to copy (missing on the
+destination), update (different on the destination) and delete
+(missing on the source) along with the size, modification time and
+hash they had when checked. The plan can be reviewed and then run
+exactly as written with [rclone apply-plan](/commands/rclone_apply-plan/).
+With |--one-way| the plan won't contain any deletions.
 `, "|", "`") + FlagsHelp,
 	RunE: func(command *cobra.Command, args []string) error {
 		cmd.CheckArgs(2, 2, command, args)
@@ -167,20 +188,44 @@ the |source:path| must point to a text file in the SUM format.
 		} else {
 			fsrc, fdst = cmd.NewFsSrcDst(args)
 		}
+		if planFile != "" && checkFileHashType != "" {
+			return errors.New("can't use --plan with --checkfile")
+		}
+		if reportHash && checkFileHashType != "" {
+			return errors.New("can't use --report-hash with --checkfile")
+		}
 
-		cmd.Run(false, true, command, func() error {
+		ctx := context.Background()
+		if reportHash {
+			cmd.Run(false, false, command, func() error {
+				return reportCommonHash(ctx, os.Stdout, fsrc, fdst)
+			})
+			return nil
+		}
+		cmd.Run(false, true, command, func() (err error) {
 			opt, close, err := GetCheckOpt(fsrc, fdst)
 			if err != nil {
 				return err
 			}
 			defer close()
 
+			if planFile != "" {
+				plan := sync.NewPlan(fdst, fsrc)
+				addToPlan(ctx, plan, opt)
+				defer func() {
+					planErr := writePlan(plan)
+					if err == nil {
+						err = planErr
+					}
+				}()
+			}
+
 			if checkFileHashType != "" {
-				return operations.CheckSum(context.Background(), fsrc, fsum, sumFile, hashType, opt, download)
+				return operations.CheckSum(ctx, fsrc, fsum, sumFile, hashType, opt, download)
 			}
 
 			if download {
-				return operations.CheckDownload(context.Background(), opt)
+				return operations.CheckDownload(ctx, opt)
 			}
 			hashType := fsrc.Hashes().Overlap(fdst.Hashes()).GetOne()
 			if hashType == hash.None {
@@ -188,8 +233,65 @@ the |source:path| must point to a text file in the SUM format.
 			} else {
 				fs.Infof(nil, "Using %v for hash comparisons", hashType)
 			}
-			return operations.Check(context.Background(), opt)
+			return operations.Check(ctx, opt)
 		})
 		return nil
 	},
 }
+
+// reportCommonHash writes the name of the hash fsrc and fdst have in
+// common to out, or "none" if there isn't one
+func reportCommonHash(ctx context.Context, out io.Writer, fsrc, fdst fs.Fs) error {
+	hashType, _ := operations.CommonHash(ctx, fsrc, fdst)
+	if hashType == hash.None {
+		fs.Infof(nil, "No common hash found between %v and %v", fsrc, fdst)
+	}
+	_, err := fmt.Fprintln(out, hashType)
+	return err
+}
+
+// addToPlan sets up opt to record the changes found into plan
+func addToPlan(ctx context.Context, plan *sync.Plan, opt *operations.CheckOpt) {
+	opt.ReportFn = func(sigil rune, entry fs.DirEntry) {
+		o, ok := entry.(fs.Object)
+		if !ok {
+			return
+		}
+		var action sync.PlanAction
+		switch sigil {
+		case '+':
+			action = sync.PlanCopy
+		case '*':
+			action = sync.PlanUpdate
+		case '-':
+			action = sync.PlanDelete
+		default:
+			return
+		}
+		err := plan.Add(ctx, action, o)
+		if err != nil {
+			err = fs.CountError(err)
+			fs.Errorf(o, "Failed to add to plan: %v", err)
+		}
+	}
+}
+
+// writePlan writes the plan to planFile
+func writePlan(plan *sync.Plan) error {
+	if planFile == "-" {
+		return plan.Write(os.Stdout)
+	}
+	out, err := os.Create(planFile)
+	if err != nil {
+		return fmt.Errorf("failed to create plan: %w", err)
+	}
+	err = plan.Write(out)
+	closeErr := out.Close()
+	if err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("failed to write plan: %w", err)
+	}
+	return nil
+}
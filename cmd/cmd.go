@@ -54,6 +54,7 @@ var (
 	version         bool
 	retries         = flags.IntP("retries", "", 3, "Retry operations this many times if they fail")
 	retriesInterval = flags.DurationP("retries-sleep", "", 0, "Interval between retrying operations if they fail, e.g. 500ms, 60s, 5m (0 to disable)")
+	retryFailedOnly = flags.BoolP("retry-failed-only", "", false, "Only retry the files which failed rather than the whole operation")
 	// Errors
 	errorCommandNotFound    = errors.New("command not found")
 	errorUncategorized      = errors.New("uncategorized error")
@@ -237,6 +238,28 @@ func ShowStats() bool {
 	return statsIntervalFlag != nil && statsIntervalFlag.Changed
 }
 
+// retryOnly restricts the global filter to the files which failed in
+// stats if all the errors came from them, so the next attempt only
+// retries those. Otherwise the whole operation will be retried.
+//
+// If a sync skipped its deletions because of the errors then the whole
+// operation is retried too, as retrying only the failed files would
+// never do them.
+func retryOnly(stats *accounting.StatsInfo) {
+	if stats.HadSkippedDeletes() {
+		fs.Infof(nil, "Deletions were skipped because of errors - retrying everything")
+		return
+	}
+	remotes, ok := stats.FailedRemotes()
+	if !ok {
+		fs.Infof(nil, "Not all errors came from file transfers - retrying everything")
+		return
+	}
+	fs.Infof(nil, "Only retrying the %d files which failed", len(remotes))
+	fi := filter.GetConfig(context.Background())
+	*fi = *fi.OnlyFiles(remotes)
+}
+
 // Run the function with stats and retries if required
 func Run(Retry bool, showStats bool, cmd *cobra.Command, f func() error) {
 	ci := fs.GetConfig(context.Background())
@@ -285,6 +308,9 @@ func Run(Retry bool, showStats bool, cmd *cobra.Command, f func() error) {
 			fs.Errorf(nil, "Attempt %d/%d failed with %d errors", try, *retries, accounting.GlobalStats().GetErrors())
 		}
 		if try < *retries {
+			if *retryFailedOnly {
+				retryOnly(accounting.GlobalStats())
+			}
 			accounting.GlobalStats().ResetErrors()
 		}
 		if *retriesInterval > 0 {
@@ -295,6 +321,9 @@ func Run(Retry bool, showStats bool, cmd *cobra.Command, f func() error) {
 	if showStats && (accounting.GlobalStats().Errored() || *statsInterval > 0) {
 		accounting.GlobalStats().Log()
 	}
+	if showStats {
+		accounting.GlobalStats().LogHistogram()
+	}
 	fs.Debugf(nil, "%d go routines active\n", runtime.NumGoroutine())
 
 	if ci.Progress && ci.ProgressTerminalTitle {
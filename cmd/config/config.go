@@ -14,6 +14,7 @@ import (
 	"github.com/rclone/rclone/fs/config"
 	"github.com/rclone/rclone/fs/config/flags"
 	"github.com/rclone/rclone/fs/rc"
+	"github.com/rclone/rclone/lib/oauthutil"
 	"github.com/spf13/cobra"
 	"github.com/spf13/pflag"
 )
@@ -119,6 +120,15 @@ var configProvidersCommand = &cobra.Command{
 
 var updateRemoteOpt config.UpdateRemoteOpt
 
+// authBindAddress is the address to receive the OAuth callback on
+var authBindAddress string
+
+// authContext returns the context for configuring a remote which makes
+// any OAuth listen on --auth-bind-address if set
+func authContext() context.Context {
+	return oauthutil.WithBindAddress(context.Background(), authBindAddress)
+}
+
 var configPasswordHelp = strings.ReplaceAll(`
 Note that if the config process would normally ask a question the
 default is taken (unless |--non-interactive| is used).  Each time
@@ -207,6 +217,21 @@ defaults for questions as usual.
 Note that |bin/config.py| in the rclone source implements this protocol
 as a readable demonstration.
 `, "|", "`")
+var authBindAddressHelp = strings.ReplaceAll(`
+To finish an OAuth on a headless machine through an SSH tunnel, forward
+port 53682 from the machine with the browser, e.g. with
+|ssh -L 53682:localhost:53682 headless|, then answer |y| to the auto
+config question, or use |config_is_local=true|, and open the link
+rclone shows in the browser. Use |--auth-bind-address| to make rclone
+listen for the OAuth callback on another address, e.g.
+|--auth-bind-address 0.0.0.0:53682| so the callback can be forwarded
+from another machine. The browser must still reach it as
+|http://127.0.0.1:53682/|, which is where the provider sends it.
+
+For a scriptable way of doing this see the |config/oauthStart| and
+|config/oauthFinish| rc commands.
+`, "|", "`")
+
 var configCreateCommand = &cobra.Command{
 	Use:   "create name type [key value]*",
 	Short: `Create a new remote with name, type and options.`,
@@ -224,7 +249,7 @@ So for example if you wanted to configure a Google Drive remote but
 using remote authorization you would do this:
 
     rclone config create mydrive drive config_is_local=false
-`, "|", "`") + configPasswordHelp,
+`, "|", "`") + authBindAddressHelp + configPasswordHelp,
 	RunE: func(command *cobra.Command, args []string) error {
 		cmd.CheckArgs(2, 256, command, args)
 		in, err := argsToMap(args[2:])
@@ -232,7 +257,7 @@ using remote authorization you would do this:
 			return err
 		}
 		return doConfig(args[0], in, func(opts config.UpdateRemoteOpt) (*fs.ConfigOut, error) {
-			return config.CreateRemote(context.Background(), args[0], args[1], in, opts)
+			return config.CreateRemote(authContext(), args[0], args[1], in, opts)
 		})
 	},
 }
@@ -259,6 +284,9 @@ func doConfig(name string, in rc.Params, do func(config.UpdateRemoteOpt) (*fs.Co
 }
 
 func init() {
+	for _, cmdFlags := range []*pflag.FlagSet{configCreateCommand.Flags(), configUpdateCommand.Flags(), configReconnectCommand.Flags()} {
+		flags.StringVarP(cmdFlags, &authBindAddress, "auth-bind-address", "", "", "Address to listen on for the OAuth callback (default 127.0.0.1:53682)")
+	}
 	for _, cmdFlags := range []*pflag.FlagSet{configCreateCommand.Flags(), configUpdateCommand.Flags()} {
 		flags.BoolVarP(cmdFlags, &updateRemoteOpt.Obscure, "obscure", "", false, "Force any passwords to be obscured")
 		flags.BoolVarP(cmdFlags, &updateRemoteOpt.NoObscure, "no-obscure", "", false, "Force any passwords not to be obscured")
@@ -287,7 +315,7 @@ If the remote uses OAuth the token will be updated, if you don't
 require this add an extra parameter thus:
 
     rclone config update myremote env_auth=true config_refresh_token=false
-`, "|", "`") + configPasswordHelp,
+`, "|", "`") + authBindAddressHelp + configPasswordHelp,
 	RunE: func(command *cobra.Command, args []string) error {
 		cmd.CheckArgs(1, 256, command, args)
 		in, err := argsToMap(args[1:])
@@ -295,7 +323,7 @@ require this add an extra parameter thus:
 			return err
 		}
 		return doConfig(args[0], in, func(opts config.UpdateRemoteOpt) (*fs.ConfigOut, error) {
-			return config.UpdateRemote(context.Background(), args[0], in, opts)
+			return config.UpdateRemote(authContext(), args[0], in, opts)
 		})
 	},
 }
@@ -371,9 +399,9 @@ This reconnects remote: passed in to the cloud storage system.
 To disconnect the remote use "rclone config disconnect".
 
 This normally means going through the interactive oauth flow again.
-`,
+` + authBindAddressHelp,
 	RunE: func(command *cobra.Command, args []string) error {
-		ctx := context.Background()
+		ctx := authContext()
 		cmd.CheckArgs(1, 1, command, args)
 		fsInfo, configName, _, m, err := fs.ConfigFs(args[0])
 		if err != nil {
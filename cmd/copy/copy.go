@@ -5,20 +5,27 @@ import (
 	"strings"
 
 	"github.com/rclone/rclone/cmd"
+	"github.com/rclone/rclone/fs"
 	"github.com/rclone/rclone/fs/config/flags"
+	"github.com/rclone/rclone/fs/filter"
+	"github.com/rclone/rclone/fs/hash"
 	"github.com/rclone/rclone/fs/operations"
 	"github.com/rclone/rclone/fs/sync"
 	"github.com/spf13/cobra"
 )
 
 var (
-	createEmptySrcDirs = false
+	createEmptySrcDirs   = false
+	contentAddressed     = false
+	contentAddressedHash = hash.MD5
 )
 
 func init() {
 	cmd.Root.AddCommand(commandDefinition)
 	cmdFlags := commandDefinition.Flags()
 	flags.BoolVarP(cmdFlags, &createEmptySrcDirs, "create-empty-src-dirs", "", createEmptySrcDirs, "Create empty source dirs on destination after copy")
+	flags.BoolVarP(cmdFlags, &contentAddressed, "content-addressed", "", contentAddressed, "Store files under a path made from their hash and record their paths in an index")
+	flags.FVarP(cmdFlags, &contentAddressedHash, "content-addressed-hash", "", "Hash to use for --content-addressed")
 }
 
 var commandDefinition = &cobra.Command{
@@ -76,12 +83,37 @@ recently very efficiently like this:
 **Note**: Use the |-P|/|--progress| flag to view real-time transfer statistics.
 
 **Note**: Use the |--dry-run| or the |--interactive|/|-i| flag to test without copying anything.
+
+### Content addressed copies
+
+Use |--content-addressed| to build a deduplicating archive. Each file
+is stored under a path made from its hash, for example
+|5d/41/5d41402abc4b2a76b9719d911017c592|, rather than under its own
+name, and files whose contents are already stored in the destination
+aren't uploaded again.
+
+    rclone copy --content-addressed /path/to/src remote:archive
+
+The hash used is MD5 unless set with |--content-addressed-hash|. It
+is read from the source if it supports it, otherwise rclone reads the
+file to calculate it.
+
+The original path of each file and its hash are recorded in an index
+file, |index.md5| for MD5, in the root of the destination in the same
+format as |rclone md5sum| produces. The index is updated on each copy
+so it can be used to find the stored copy of any file when restoring.
 `, "|", "`"),
+	Annotations: map[string]string{
+		"rc": "sync/copy",
+	},
 	Run: func(command *cobra.Command, args []string) {
 
 		cmd.CheckArgs(2, 2, command, args)
 		fsrc, srcFileName, fdst := cmd.NewFsSrcFileDst(args)
 		cmd.Run(true, true, command, func() error {
+			if contentAddressed {
+				return copyContentAddressed(context.Background(), fdst, fsrc, srcFileName)
+			}
 			if srcFileName == "" {
 				return sync.CopyDir(context.Background(), fdst, fsrc, createEmptySrcDirs)
 			}
@@ -89,3 +121,11 @@ recently very efficiently like this:
 		})
 	},
 }
+
+// copyContentAddressed does the copy for --content-addressed
+func copyContentAddressed(ctx context.Context, fdst, fsrc fs.Fs, srcFileName string) error {
+	if srcFileName != "" {
+		ctx = filter.ReplaceConfig(ctx, filter.GetConfig(ctx).OnlyFiles([]string{srcFileName}))
+	}
+	return operations.CopyContentAddressed(ctx, fdst, fsrc, contentAddressedHash)
+}
@@ -45,8 +45,17 @@ Setting ` + "`--no-clobber`" + ` will prevent overwriting file on the
 destination if there is one with the same name.
 
 Setting ` + "`--stdout`" + ` or making the output file name ` + "`-`" + `
-will cause the output to be written to standard output.
+will cause the output to be written to standard output. The download
+is shown in the stats and limited by ` + "`--bwlimit`" + ` as usual. If it fails
+part way through it is resumed from where it got to, up to
+` + "`--low-level-retries`" + ` times, if the server supports Range requests
+and sent an ETag or Last-Modified header, which is used to check the
+rest comes from the same file. It isn't started again from the
+beginning by ` + "`--retries`" + ` once some of it has been written.
 `,
+	Annotations: map[string]string{
+		"rc": "operations/copyurl",
+	},
 	RunE: func(command *cobra.Command, args []string) (err error) {
 		cmd.CheckArgs(1, 2, command, args)
 
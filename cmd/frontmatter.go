@@ -0,0 +1,51 @@
+package cmd
+
+import (
+	"sync"
+
+	"github.com/spf13/cobra"
+)
+
+// FrontmatterFunc is the type of the function called to get extra
+// values for the frontmatter of the docs of a command, e.g. tags or
+// categories.
+type FrontmatterFunc func() map[string]string
+
+var (
+	frontmatterMu    sync.Mutex
+	frontmatterFuncs = map[*cobra.Command][]FrontmatterFunc{}
+)
+
+// AddFrontmatter registers fn to provide values for the frontmatter
+// of the docs of c. It is intended for commands registered by
+// backends and plugins which can't change gendocs.
+//
+// The values are added to the frontmatter by gendocs in the same way
+// as annotations of c, so only the keys given with
+// --frontmatter-annotations are used. If c has an annotation with the
+// same key as a value the annotation is used.
+func AddFrontmatter(c *cobra.Command, fn FrontmatterFunc) {
+	frontmatterMu.Lock()
+	defer frontmatterMu.Unlock()
+	frontmatterFuncs[c] = append(frontmatterFuncs[c], fn)
+}
+
+// Frontmatter returns the frontmatter values for c from the functions
+// registered with AddFrontmatter. If more than one function returns
+// the same key the one registered last is used. It returns nil if
+// there are none.
+func Frontmatter(c *cobra.Command) map[string]string {
+	frontmatterMu.Lock()
+	fns := frontmatterFuncs[c]
+	frontmatterMu.Unlock()
+	if len(fns) == 0 {
+		return nil
+	}
+	out := map[string]string{}
+	for _, fn := range fns {
+		for key, value := range fn() {
+			out[key] = value
+		}
+	}
+	return out
+}
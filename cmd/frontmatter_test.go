@@ -0,0 +1,22 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFrontmatter(t *testing.T) {
+	c := &cobra.Command{Use: "potato"}
+	assert.Nil(t, Frontmatter(c))
+
+	AddFrontmatter(c, func() map[string]string {
+		return map[string]string{"tags": "one", "category": "food"}
+	})
+	AddFrontmatter(c, func() map[string]string {
+		return map[string]string{"tags": "two"}
+	})
+	assert.Equal(t, map[string]string{"tags": "two", "category": "food"}, Frontmatter(c))
+	assert.Nil(t, Frontmatter(&cobra.Command{Use: "other"}))
+}
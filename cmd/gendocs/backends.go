@@ -0,0 +1,108 @@
+package gendocs
+
+import (
+	"bytes"
+	"fmt"
+	"path"
+	"reflect"
+	"runtime"
+	"strings"
+
+	"github.com/rclone/rclone/cmd"
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fs/config/flags"
+	"github.com/spf13/pflag"
+)
+
+// backendOptions are the options for the backend docs
+type backendOptions struct {
+	Enabled bool // write a page for each backend
+}
+
+// backendOpt are the options set with the flags
+var backendOpt backendOptions
+
+// addFlags adds the flags for opt to flagSet
+func (opt *backendOptions) addFlags(flagSet *pflag.FlagSet) {
+	flags.BoolVarP(flagSet, &opt.Enabled, "backends", "", opt.Enabled, "Write a page listing the options of each backend to the backends directory too")
+}
+
+// writeBackendDocs writes a page for each backend to the backends
+// directory
+func (g *generator) writeBackendDocs() error {
+	err := g.w.mkdir(backendsDir)
+	if err != nil {
+		return err
+	}
+	for _, ri := range fs.Registry {
+		doc, err := backendDoc(ri)
+		if err != nil {
+			return err
+		}
+		err = g.writeDoc(path.Join(backendsDir, backendFileName(ri)), doc)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// backendsDir is the directory the backend docs are written to with
+// --backends
+const backendsDir = "backends"
+
+// backendFileName returns the name of the docs file for the backend
+// ri, named after its flag prefix as the names can have spaces
+func backendFileName(ri *fs.RegInfo) string {
+	return ri.Prefix + ".md"
+}
+
+// backendURL returns the URL of the docs page for the backend ri
+func backendURL(ri *fs.RegInfo) string {
+	return pageOpt.BaseURL + "/" + backendsDir + "/" + strings.ToLower(ri.Prefix) + "/"
+}
+
+// backendSource returns the directory of the source of the backend ri
+// relative to the top of the source, found from the package its NewFs
+// function is in, e.g. "backend/s3/".
+func backendSource(ri *fs.RegInfo) string {
+	const module = "github.com/rclone/rclone/"
+	if ri.NewFs != nil {
+		fn := runtime.FuncForPC(reflect.ValueOf(ri.NewFs).Pointer())
+		if fn != nil && strings.HasPrefix(fn.Name(), module) {
+			pkg := strings.TrimPrefix(fn.Name(), module)
+			slash := strings.LastIndex(pkg, "/")
+			if dot := strings.Index(pkg[slash+1:], "."); dot >= 0 {
+				pkg = pkg[:slash+1+dot]
+			}
+			return pkg + "/"
+		}
+	}
+	return "backend/" + ri.Prefix + "/"
+}
+
+// backendDoc returns the markdown docs page for the backend ri listing
+// its options, as shown by "rclone help backend", with the same
+// frontmatter as the command docs.
+func backendDoc(ri *fs.RegInfo) (string, error) {
+	var buf bytes.Buffer
+	err := frontmatterTemplate.Execute(&buf, frontmatter{
+		Date:        frontmatterOpt.parsedDate,
+		Title:       ri.Name,
+		Description: oneLine(ri.Description),
+		Slug:        ri.Prefix,
+		URL:         backendURL(ri),
+		Source:      backendSource(ri),
+		EditWarning: !frontmatterOpt.NoEditWarning,
+		Locale:      translationOpt.Locale,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to make %s for backend %q: failed to render frontmatter template: %w", path.Join(backendsDir, backendFileName(ri)), ri.Name, err)
+	}
+	fmt.Fprintf(&buf, "# %s\n\n%s\n\n", ri.Name, ri.Description)
+	var options bytes.Buffer
+	cmd.WriteBackendOptions(&options, ri)
+	// the options sections are level 3 so make them level 2 below the title
+	buf.WriteString(shiftHeadings(options.String(), -1))
+	return addHeadingAnchors(buf.String(), ri.Prefix), nil
+}
@@ -0,0 +1,159 @@
+package gendocs
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/rclone/rclone/cmd"
+	"github.com/rclone/rclone/fs"
+	"github.com/spf13/cobra"
+)
+
+// checkCommands checks top and the commands below it, and the aliases
+// of all the commands, before any docs are written
+func checkCommands(top *cobra.Command) error {
+	commands := docCommands(top)
+	if opt.RequireShort {
+		err := checkDescriptions(commands)
+		if err != nil {
+			return err
+		}
+	}
+	if incrementalOpt.VerifySource {
+		err := checkSources(commands, incrementalOpt.SourceRoot)
+		if err != nil {
+			return err
+		}
+	}
+	err := checkCommandNames(commands)
+	if err != nil {
+		return err
+	}
+	err = checkAliases(cmd.Root)
+	if err != nil {
+		if !opt.WarnOnly {
+			return err
+		}
+		fs.Logf(nil, "%v", err)
+	}
+	return nil
+}
+
+// checkCommandNames returns an error listing the commands whose
+// names can't be used in the names of their docs files as they have a
+// path separator in or are "." or "..", which could write the docs
+// outside the docs directory, e.g. for a command added by a plugin.
+func checkCommandNames(commands []*cobra.Command) error {
+	var bad []string
+	for _, c := range commands {
+		name := c.Name()
+		if name == "." || name == ".." || strings.ContainsAny(name, "/\\\x00") {
+			bad = append(bad, fmt.Sprintf("%q", c.CommandPath()))
+		}
+	}
+	if len(bad) > 0 {
+		return fmt.Errorf("commands with names which can't be used in file names: %s", strings.Join(bad, ", "))
+	}
+	return nil
+}
+
+// checkAliases returns an error listing the command paths which more
+// than one command below top can be run with, either as the path of
+// the command or as an alias, and the commands using each of them
+func checkAliases(top *cobra.Command) error {
+	users := map[string][]string{}
+	use := func(commandPath string, c *cobra.Command) {
+		cs := users[commandPath]
+		if len(cs) > 0 && cs[len(cs)-1] == c.CommandPath() {
+			// an alias given twice or the same as the name
+			return
+		}
+		users[commandPath] = append(cs, c.CommandPath())
+	}
+	_ = cmd.WalkCommandTree(top, func(c *cobra.Command, aliases []string) error {
+		use(c.CommandPath(), c)
+		for _, alias := range aliases {
+			use(alias, c)
+		}
+		return nil
+	})
+	var clashing []string
+	for commandPath, cs := range users {
+		if len(cs) > 1 {
+			clashing = append(clashing, commandPath)
+		}
+	}
+	if len(clashing) == 0 {
+		return nil
+	}
+	sort.Strings(clashing)
+	clashes := make([]string, len(clashing))
+	for i, commandPath := range clashing {
+		clashes[i] = fmt.Sprintf("%q (%s)", commandPath, strings.Join(users[commandPath], ", "))
+	}
+	return fmt.Errorf("command paths used by more than one command: %s", strings.Join(clashes, ", "))
+}
+
+// checkDescriptions returns an error listing the command paths of the
+// commands which have an empty short description
+func checkDescriptions(commands []*cobra.Command) error {
+	var missing []string
+	for _, c := range commands {
+		if strings.TrimSpace(c.Short) == "" {
+			missing = append(missing, c.CommandPath())
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("commands with no description: %s", strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+// commandLinkRe returns a regexp matching markdown links to the
+// command pages in section, with or without base in front, capturing
+// the link and the page name
+func commandLinkRe(base, section string) *regexp.Regexp {
+	return regexp.MustCompile(`\]\(((?:` + regexp.QuoteMeta(base) + `)?/` + regexp.QuoteMeta(section) + `/([^/)#]*)/?[^)]*)\)`)
+}
+
+// linkChecker finds links in the docs to commands which don't exist
+type linkChecker struct {
+	linkRe *regexp.Regexp  // matches the links to the command pages
+	known  map[string]bool // lower case base names of the docs files
+	broken []string        // "file: link" for each broken link found
+}
+
+// newLinkChecker makes a linkChecker for the docs of root and the
+// commands below it linked under the --section-name
+func newLinkChecker(root *cobra.Command) *linkChecker {
+	lc := &linkChecker{
+		linkRe: commandLinkRe(pageOpt.BaseURL, pageOpt.SectionName),
+		known:  map[string]bool{},
+	}
+	for _, c := range docCommands(root) {
+		name := commandFileName(c)
+		lc.known[strings.ToLower(strings.TrimSuffix(name, path.Ext(name)))] = true
+	}
+	return lc
+}
+
+// check records the links to commands which don't exist in the docs
+// file name
+func (lc *linkChecker) check(name string, doc []byte) {
+	for _, match := range lc.linkRe.FindAllSubmatch(doc, -1) {
+		if !lc.known[strings.ToLower(string(match[2]))] {
+			lc.broken = append(lc.broken, name+": "+string(match[1]))
+		}
+	}
+}
+
+// err returns an error listing the broken links if there were any
+func (lc *linkChecker) err() error {
+	if len(lc.broken) == 0 {
+		return nil
+	}
+	return fmt.Errorf("links to commands which don't exist: %s", strings.Join(lc.broken, ", "))
+}
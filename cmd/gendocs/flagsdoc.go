@@ -0,0 +1,173 @@
+package gendocs
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+	"text/template"
+
+	"github.com/rclone/rclone/cmd"
+	"github.com/rclone/rclone/fs"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// tableCellEscaper escapes text so it can go in a markdown table cell
+var tableCellEscaper = strings.NewReplacer("|", `\|`, "\r\n", " ", "\n", " ")
+
+// flagTable returns a markdown table of the flags which aren't hidden
+// with columns for the flag, shorthand, type, default and help
+func flagTable(flags *pflag.FlagSet) string {
+	var buf strings.Builder
+	buf.WriteString("| Flag | Shorthand | Type | Default | Help |\n")
+	buf.WriteString("|------|-----------|------|---------|------|\n")
+	code := func(s string) string {
+		if s == "" {
+			return ""
+		}
+		return "`" + tableCellEscaper.Replace(s) + "`"
+	}
+	flags.VisitAll(func(flag *pflag.Flag) {
+		if flag.Hidden {
+			return
+		}
+		shorthand := ""
+		if flag.Shorthand != "" {
+			shorthand = "-" + flag.Shorthand
+		}
+		_, _ = fmt.Fprintf(&buf, "| %s | %s | %s | %s | %s |\n",
+			code("--"+flag.Name),
+			code(shorthand),
+			tableCellEscaper.Replace(flag.Value.Type()),
+			code(flag.DefValue),
+			tableCellEscaper.Replace(flag.Usage),
+		)
+	})
+	return buf.String()
+}
+
+// flagsGroup is a group of global flags on the flags page
+type flagsGroup struct {
+	Name  string // heading of the group
+	Help  string // description of the group
+	Flags string // code block or table of the flags
+}
+
+var flagsTemplate = template.Must(template.New("flags").Parse(`---
+title: "Global Flags"
+description: "Rclone Global Flags"
+---
+
+# Global Flags
+
+This describes the global flags available to every rclone command
+split into two groups, non backend and backend flags. The backend
+flags are grouped by the backend they are for.
+
+## Non Backend Flags
+
+These flags are available for every command.
+
+{{ .NonBackend }}
+## Backend Flags
+
+These flags are available for every command. They control the backends
+and may be set in the config file.
+{{ range .Backends }}
+### {{ .Name }}
+
+{{ .Help }}
+
+{{ .Flags }}{{ end }}`))
+
+// flagsBlock returns the flags in flagSet in the style of the Options
+// sections of the command docs
+func flagsBlock(flagSet *pflag.FlagSet) string {
+	if pageOpt.FlagStyle == "table" {
+		return flagTable(flagSet)
+	}
+	return "```\n" + flagSet.FlagUsages() + "```\n"
+}
+
+// flagsDoc returns the global flags page made from the same flags as
+// "rclone help flags" shows, with the backend flags in a group for
+// each backend in registry, sorted by name
+func flagsDoc(root *cobra.Command, registry []*fs.RegInfo) (string, error) {
+	helpFlags, _, err := root.Find([]string{"help", "flags"})
+	if err != nil {
+		return "", fmt.Errorf("failed to find the help flags command: %w", err)
+	}
+	global := helpFlags.InheritedFlags()
+	inBackend := map[string]struct{}{}
+	registry = append([]*fs.RegInfo(nil), registry...)
+	sort.Slice(registry, func(i, j int) bool {
+		return registry[i].Name < registry[j].Name
+	})
+	var backends []flagsGroup
+	for _, ri := range registry {
+		flagSet := pflag.NewFlagSet(ri.Name, pflag.ContinueOnError)
+		for i := range ri.Options {
+			name := ri.Options[i].FlagName(ri.Prefix)
+			flag := global.Lookup(name)
+			if _, found := inBackend[name]; found || flag == nil || flag.Hidden {
+				continue
+			}
+			inBackend[name] = struct{}{}
+			flagSet.AddFlag(flag)
+		}
+		if !flagSet.HasFlags() {
+			continue
+		}
+		backends = append(backends, flagsGroup{
+			Name:  ri.Name,
+			Help:  "Flags for the " + ri.Description + " backend.",
+			Flags: flagsBlock(flagSet),
+		})
+	}
+	nonBackend := pflag.NewFlagSet("non backend", pflag.ContinueOnError)
+	global.VisitAll(func(flag *pflag.Flag) {
+		if _, found := inBackend[flag.Name]; !found {
+			nonBackend.AddFlag(flag)
+		}
+	})
+	var buf bytes.Buffer
+	err = flagsTemplate.Execute(&buf, struct {
+		NonBackend string
+		Backends   []flagsGroup
+	}{
+		NonBackend: flagsBlock(nonBackend),
+		Backends:   backends,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to make flags.md: %w", err)
+	}
+	return buf.String(), nil
+}
+
+// writeFlagsPage writes the global flags page
+//
+// With --incremental it isn't written if it is newer than the source
+// of the global flags and the backends.
+func (g *generator) writeFlagsPage() error {
+	if incrementalOpt.Incremental && flagsUpToDate(g.w.root) {
+		fs.Debugf("flags.md", "Skipping as docs are newer than the source")
+		return nil
+	}
+	if opt.FlagsRaw {
+		var buf bytes.Buffer
+		cmd.Root.SetOutput(&buf)
+		cmd.Root.SetArgs([]string{"help", "flags"})
+		cmd.GeneratingDocs = true
+		err := cmd.Root.Execute()
+		if err != nil {
+			return err
+		}
+		return g.writeDoc("flags.md", buf.String())
+	}
+	doc, err := flagsDoc(cmd.Root, fs.Registry)
+	if err != nil {
+		return err
+	}
+	return g.writeDoc("flags.md", doc)
+}
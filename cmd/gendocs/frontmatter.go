@@ -0,0 +1,343 @@
+package gendocs
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"regexp"
+	"strings"
+	"text/template"
+	"time"
+	"unicode/utf8"
+
+	"github.com/rclone/rclone/cmd"
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fs/config/flags"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// frontmatterOptions are the options for the frontmatter of the docs
+// and the other templates used on each page
+type frontmatterOptions struct {
+	Annotations      fs.CommaSepList // command annotations to add to the frontmatter
+	Date             string          // date to put in the frontmatter, "now" or "" for none
+	Format           string          // format of the frontmatter: yaml or toml
+	TemplateFile     string          // file with the template for the frontmatter
+	VersionBadgeFile string          // file with the template for the version badge
+	SeeAlsoFile      string          // file with the template for the SEE ALSO list
+	NoEditWarning    bool            // leave the DO NOT EDIT comment out
+	ReadingTime      bool            // add the word count and reading time
+	parsedDate       string          // date to put in the frontmatter from Date, if any
+}
+
+// frontmatterOpt are the options set with the flags
+var frontmatterOpt = frontmatterOptions{
+	Format: "yaml",
+}
+
+// addFlags adds the flags for opt to flagSet
+func (opt *frontmatterOptions) addFlags(flagSet *pflag.FlagSet) {
+	flags.FVarP(flagSet, &opt.Annotations, "frontmatter-annotations", "", "Comma separated list of command annotations to add to the frontmatter")
+	flags.StringVarP(flagSet, &opt.Date, "date", "", opt.Date, "Date to put in the frontmatter in RFC3339 format or \"now\" (default none)")
+	flags.StringVarP(flagSet, &opt.Format, "frontmatter-format", "", opt.Format, "Format of the frontmatter of the docs: yaml or toml")
+	flags.StringVarP(flagSet, &opt.TemplateFile, "frontmatter-template", "", opt.TemplateFile, "Template file for the frontmatter of each command's docs instead of the built in one")
+	flags.StringVarP(flagSet, &opt.VersionBadgeFile, "version-badge-template", "", opt.VersionBadgeFile, "Template file for the badge shown on commands with a versionIntroduced annotation")
+	flags.StringVarP(flagSet, &opt.SeeAlsoFile, "see-also-template", "", opt.SeeAlsoFile, "Template file for the list of related commands in the SEE ALSO section of each command's docs")
+	flags.BoolVarP(flagSet, &opt.NoEditWarning, "no-edit-warning", "", opt.NoEditWarning, "Don't put the autogenerated - DO NOT EDIT comment in the frontmatter")
+	flags.BoolVarP(flagSet, &opt.ReadingTime, "reading-time", "", opt.ReadingTime, "Put the word count and reading time of each command's docs in the frontmatter")
+}
+
+// setup checks the options and loads the templates
+//
+// This is done before any docs are written so mistakes in them are
+// found first.
+func (opt *frontmatterOptions) setup() (err error) {
+	err = checkAnnotationKeys(opt.Annotations)
+	if err != nil {
+		return err
+	}
+	opt.parsedDate, err = parseDate(opt.Date)
+	if err != nil {
+		return err
+	}
+	if writerOpt.Checksum != "" {
+		err = checkChecksumDate(opt.Date, manOpt.Man)
+		if err != nil {
+			return err
+		}
+	}
+	err = setFrontmatterFormat(opt.Format)
+	if err != nil {
+		return err
+	}
+	if opt.TemplateFile != "" {
+		err = loadFrontmatterTemplate(opt.TemplateFile)
+		if err != nil {
+			return err
+		}
+	}
+	if opt.VersionBadgeFile != "" {
+		err = loadVersionBadgeTemplate(opt.VersionBadgeFile)
+		if err != nil {
+			return err
+		}
+	}
+	if opt.SeeAlsoFile != "" {
+		err = loadSeeAlsoTemplate(opt.SeeAlsoFile)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// define things which go into the frontmatter
+type frontmatter struct {
+	Date        string
+	Title       string
+	Description string
+	Slug        string
+	URL         string
+	Source      string
+	EditWarning bool   // set unless --no-edit-warning
+	Locale      string // from --locale or "" if not set
+	WordCount   int    // words in the page with --reading-time or 0
+	ReadingTime int    // minutes to read the page with --reading-time or 0
+	Annotations []frontmatterAnnotation
+}
+
+// an annotation of the command to add to the frontmatter
+type frontmatterAnnotation struct {
+	Key   string
+	Value string // quoted for the frontmatter
+}
+
+// annotationKeyRe matches annotation keys which can be used in the frontmatter
+var annotationKeyRe = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// checkAnnotationKeys checks the keys can be used as frontmatter keys
+// without clashing with the ones gendocs writes
+func checkAnnotationKeys(keys []string) error {
+	for _, key := range keys {
+		switch strings.ToLower(key) {
+		case "title", "description", "slug", "url", "date", "lang", "wordcount", "readingtime":
+			return fmt.Errorf("can't use annotation %q in the frontmatter as gendocs sets it", key)
+		}
+		if !annotationKeyRe.MatchString(key) {
+			return fmt.Errorf("can't use annotation %q in the frontmatter as it isn't a simple key", key)
+		}
+	}
+	return nil
+}
+
+// parseDate returns the date for the frontmatter from the --date flag
+//
+// This is empty unless a date is set so that docs made from the same
+// source are always the same.
+func parseDate(date string) (string, error) {
+	switch date {
+	case "":
+		return "", nil
+	case "now":
+		return time.Now().Format(time.RFC3339), nil
+	}
+	t, err := time.Parse(time.RFC3339, date)
+	if err != nil {
+		return "", fmt.Errorf("invalid --date: %w", err)
+	}
+	return t.Format(time.RFC3339), nil
+}
+
+// quoteString returns s as a double quoted string which is valid in
+// both YAML and TOML
+func quoteString(s string) (string, error) {
+	if !utf8.ValidString(s) {
+		return "", errors.New("not valid UTF-8")
+	}
+	// JSON strings are valid double quoted YAML strings and TOML basic
+	// strings, apart from DEL which neither allows unescaped
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	err := enc.Encode(s)
+	if err != nil {
+		return "", err
+	}
+	quoted := strings.TrimSuffix(buf.String(), "\n")
+	return strings.Replace(quoted, "\x7f", `\u007f`, -1), nil
+}
+
+// oneLine returns s with each run of white space, including
+// newlines, replaced by a single space and none at either end so it
+// can be used as a value in the frontmatter.
+func oneLine(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// templateFuncs are the functions the frontmatter templates can use
+var templateFuncs = template.FuncMap{
+	"quote": quoteString,
+}
+
+// frontmatterAnnotations returns the annotations of c with the keys
+// given quoted for the frontmatter.
+//
+// The values registered for c with cmd.AddFrontmatter are used for
+// the keys c doesn't have an annotation for.
+func frontmatterAnnotations(c *cobra.Command, keys []string) ([]frontmatterAnnotation, error) {
+	var out []frontmatterAnnotation
+	var provided map[string]string
+	if len(keys) > 0 {
+		provided = cmd.Frontmatter(c)
+	}
+	for _, key := range keys {
+		value, ok := c.Annotations[key]
+		if !ok {
+			value, ok = provided[key]
+		}
+		if !ok {
+			continue
+		}
+		quoted, err := quoteString(value)
+		if err != nil {
+			return nil, fmt.Errorf("can't write annotation %q to the frontmatter: %w", key, err)
+		}
+		out = append(out, frontmatterAnnotation{Key: key, Value: quoted})
+	}
+	return out, nil
+}
+
+var frontmatterTemplate = template.Must(template.New("frontmatter").Funcs(templateFuncs).Parse(`---
+title: {{ quote .Title }}
+description: {{ quote .Description }}
+{{- if .Date }}
+date: {{ quote .Date }}
+{{- end }}
+{{- if .Locale }}
+lang: {{ quote .Locale }}
+{{- end }}
+slug: {{ .Slug }}
+url: {{ .URL }}
+{{- if .WordCount }}
+wordCount: {{ .WordCount }}
+readingTime: {{ .ReadingTime }}
+{{- end }}
+{{- range .Annotations }}
+{{ .Key }}: {{ .Value }}
+{{- end }}
+{{- if .EditWarning }}
+# autogenerated - DO NOT EDIT, instead edit the source code in {{ .Source }} and as part of making a release run "make commanddocs"
+{{- end }}
+---
+`))
+
+// tomlFrontmatterTemplate is used instead of frontmatterTemplate with
+// --frontmatter-format toml
+var tomlFrontmatterTemplate = template.Must(template.New("frontmatter").Funcs(templateFuncs).Parse(`+++
+title = {{ quote .Title }}
+description = {{ quote .Description }}
+{{- if .Date }}
+date = {{ quote .Date }}
+{{- end }}
+{{- if .Locale }}
+lang = {{ quote .Locale }}
+{{- end }}
+slug = {{ quote .Slug }}
+url = {{ quote .URL }}
+{{- if .WordCount }}
+wordCount = {{ .WordCount }}
+readingTime = {{ .ReadingTime }}
+{{- end }}
+{{- range .Annotations }}
+{{ .Key }} = {{ .Value }}
+{{- end }}
+{{- if .EditWarning }}
+# autogenerated - DO NOT EDIT, instead edit the source code in {{ .Source }} and as part of making a release run "make commanddocs"
+{{- end }}
++++
+`))
+
+// setFrontmatterFormat sets the templates used for the frontmatter
+// from the --frontmatter-format flag
+func setFrontmatterFormat(format string) error {
+	switch format {
+	case "yaml":
+	case "toml":
+		frontmatterTemplate = tomlFrontmatterTemplate
+		singlePageTemplate = tomlSinglePageTemplate
+	default:
+		return fmt.Errorf("unknown --frontmatter-format %q: must be yaml or toml", format)
+	}
+	return nil
+}
+
+// loadFrontmatterTemplate replaces frontmatterTemplate with the
+// template in the file name
+//
+// The template is tried out on an empty frontmatter so mistakes, like
+// fields which don't exist, are found before any docs are written.
+func loadFrontmatterTemplate(name string) error {
+	data, err := ioutil.ReadFile(name)
+	if err != nil {
+		return fmt.Errorf("failed to read --frontmatter-template: %w", err)
+	}
+	// name the template after the file so errors point at it
+	tmpl, err := template.New(name).Funcs(templateFuncs).Parse(string(data))
+	if err != nil {
+		return fmt.Errorf("failed to parse --frontmatter-template: %w", err)
+	}
+	err = tmpl.Execute(ioutil.Discard, frontmatter{})
+	if err != nil {
+		return fmt.Errorf("failed to render --frontmatter-template: %w", err)
+	}
+	frontmatterTemplate = tmpl
+	return nil
+}
+
+// versionBadgeTemplate renders the badge put after the frontmatter of
+// commands with a versionIntroduced annotation
+var versionBadgeTemplate = template.Must(template.New("versionBadge").Parse(`**New in {{ .Version }}**
+
+`))
+
+// versionBadgeData is passed to versionBadgeTemplate
+type versionBadgeData struct {
+	Version string // the versionIntroduced annotation
+	Command string // the command path, e.g. "rclone copy"
+}
+
+// loadVersionBadgeTemplate replaces versionBadgeTemplate with the
+// template in the file name
+func loadVersionBadgeTemplate(name string) error {
+	data, err := ioutil.ReadFile(name)
+	if err != nil {
+		return fmt.Errorf("failed to read --version-badge-template: %w", err)
+	}
+	tmpl, err := template.New("versionBadge").Parse(string(data))
+	if err != nil {
+		return fmt.Errorf("failed to parse --version-badge-template: %w", err)
+	}
+	versionBadgeTemplate = tmpl
+	return nil
+}
+
+// versionBadge returns the version badge for c or "" if it doesn't
+// have a versionIntroduced annotation
+func versionBadge(c *cobra.Command) (string, error) {
+	version := c.Annotations["versionIntroduced"]
+	if version == "" {
+		return "", nil
+	}
+	var buf bytes.Buffer
+	err := versionBadgeTemplate.Execute(&buf, versionBadgeData{
+		Version: version,
+		Command: c.CommandPath(),
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to render version badge: %w", err)
+	}
+	return buf.String(), nil
+}
@@ -1,46 +1,88 @@
 package gendocs
 
 import (
-	"bytes"
-	"io/ioutil"
-	"log"
-	"os"
+	"context"
+	"errors"
+	"fmt"
 	"path"
-	"path/filepath"
-	"regexp"
+	"sort"
 	"strings"
-	"text/template"
 	"time"
 
 	"github.com/rclone/rclone/cmd"
-	"github.com/rclone/rclone/lib/file"
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fs/config/flags"
 	"github.com/spf13/cobra"
-	"github.com/spf13/cobra/doc"
 	"github.com/spf13/pflag"
 )
 
-func init() {
-	cmd.Root.AddCommand(commandDefinition)
+// options are the options of gendocs which aren't for any one of
+// the outputs
+type options struct {
+	OutputFormat   string // format of the docs: markdown, json or both
+	CommandPath    string // only write the docs for this command and the ones below it
+	RequireShort   bool   // fail if any command has no short description
+	WarnOnly       bool   // log the problems found with the commands instead of failing
+	SkipDeprecated bool   // don't write docs for commands with a deprecated annotation
+	FlagsRaw       bool   // write the flags page as "rclone help flags" shows it
+	Completions    string // file to write the flags of each command to
+	Redirects      string // file to write the redirects for the aliases to
+}
+
+// opt are the options set with the flags
+var opt = options{
+	OutputFormat: "markdown",
 }
 
-// define things which go into the frontmatter
-type frontmatter struct {
-	Date        string
-	Title       string
-	Description string
-	Slug        string
-	URL         string
-	Source      string
+// addFlags adds the flags for opt to flagSet
+func (opt *options) addFlags(flagSet *pflag.FlagSet) {
+	flags.StringVarP(flagSet, &opt.OutputFormat, "output-format", "", opt.OutputFormat, "Format of the docs to write: markdown, json or both")
+	flags.StringVarP(flagSet, &opt.Redirects, "redirects", "", opt.Redirects, "Write redirects from the URLs of the command aliases to the command docs to this file")
+	flags.StringVarP(flagSet, &opt.Completions, "completions-dump", "", opt.Completions, "Write the flags of each command to this file as tab separated command path, flag and type")
+	flags.BoolVarP(flagSet, &opt.RequireShort, "require-description", "", opt.RequireShort, "Fail if any command has an empty short description")
+	flags.BoolVarP(flagSet, &opt.WarnOnly, "warn-only", "", opt.WarnOnly, "Only warn about links to commands which don't exist, clashing aliases and unknown commands in --translations instead of failing")
+	flags.BoolVarP(flagSet, &opt.SkipDeprecated, "skip-deprecated", "", opt.SkipDeprecated, "Don't write docs for commands with a deprecated annotation")
+	flags.BoolVarP(flagSet, &opt.FlagsRaw, "flags-raw", "", opt.FlagsRaw, "Write the global flags page as shown by \"rclone help flags\" instead of grouped by backend")
+	flags.StringVarP(flagSet, &opt.CommandPath, "command-path", "", opt.CommandPath, "Only write the docs for this command and the commands below it, e.g. \"rclone mount\"")
 }
 
-var frontmatterTemplate = template.Must(template.New("frontmatter").Parse(`---
-title: "{{ .Title }}"
-description: "{{ .Description }}"
-slug: {{ .Slug }}
-url: {{ .URL }}
-# autogenerated - DO NOT EDIT, instead edit the source code in {{ .Source }} and as part of making a release run "make commanddocs"
----
-`))
+// outputs returns whether the markdown docs and the JSON metadata
+// are written from --output-format
+func (opt *options) outputs() (writeMarkdown, writeJSON bool, err error) {
+	switch opt.OutputFormat {
+	case "markdown":
+		writeMarkdown = true
+	case "json":
+		writeJSON = true
+	case "both":
+		writeMarkdown, writeJSON = true, true
+	default:
+		return false, false, fmt.Errorf("unknown --output-format %q: must be markdown, json or both", opt.OutputFormat)
+	}
+	return writeMarkdown, writeJSON, nil
+}
+
+// check checks the options can be used with the outputs written
+func (opt *options) check(writeMarkdown bool) error {
+	if opt.Redirects != "" && (!writeMarkdown || pageOpt.SinglePage != "") {
+		return errors.New("can't use --redirects without the markdown docs for each command")
+	}
+	return nil
+}
+
+func init() {
+	cmd.Root.AddCommand(commandDefinition)
+	cmdFlags := commandDefinition.Flags()
+	opt.addFlags(cmdFlags)
+	writerOpt.addFlags(cmdFlags)
+	incrementalOpt.addFlags(cmdFlags)
+	frontmatterOpt.addFlags(cmdFlags)
+	pageOpt.addFlags(cmdFlags)
+	manOpt.addFlags(cmdFlags)
+	translationOpt.addFlags(cmdFlags)
+	backendOpt.addFlags(cmdFlags)
+	rcOpt.addFlags(cmdFlags)
+}
 
 var commandDefinition = &cobra.Command{
 	Use:   "gendocs output_directory",
@@ -48,108 +90,537 @@ var commandDefinition = &cobra.Command{
 	Long: `
 This produces markdown docs for the rclone commands to the directory
 supplied.  These are in a format suitable for hugo to render into the
-rclone.org website.`,
+rclone.org website.
+
+Each section heading is given an explicit anchor made from the command
+path and the heading text, e.g. "#copy-options" for the Options
+section of "rclone copy", so links to sections don't change when hugo
+does.
+
+Use ` + "`--output-format json`" + ` to write the metadata for each command
+to commands.json in the directory supplied instead, or
+` + "`--output-format both`" + ` to write the markdown docs and commands.json.
+The metadata is a JSON list with an object for each command, sorted
+by the command path, giving its path, short and long help, aliases,
+annotations and flags.
+
+Use ` + "`--command-path`" + ` to only write the docs for one command and the
+commands below it, e.g. ` + "`--command-path \"rclone config\"`" + `. The global
+flags page isn't written when this is set.
+
+Hidden commands never have docs written for them. Use
+` + "`--skip-deprecated`" + ` to skip commands with a ` + "`deprecated`" + ` annotation too,
+along with the links to them from the other commands. A deprecated
+command is still written if any of the commands below it are.
+
+The docs files are written with the permissions given by
+` + "`--file-perms`" + ` (default 0644) and the directories created with
+` + "`--dir-perms`" + ` (default 0755). These are set explicitly so they don't
+depend on the umask.
+
+Use ` + "`--manifest FILE`" + ` to write the paths of all the files written,
+relative to the directory supplied, to FILE one per line once the docs
+are complete. This can be compared with the files in the directory to
+find docs left over from commands which no longer exist.
+
+Use ` + "`--checksum-file FILE`" + ` to write a checksum of all the files
+written to FILE once the docs are complete, or ` + "`--checksum-file -`" + `
+to print it. This is the SHA256 of the lines ` + "`sha256sum`" + ` prints for
+the files, relative to the directory supplied, in order of their
+paths, so it only changes when the docs do. It can be compared with a
+committed checksum to check the docs are up to date without writing
+them using ` + "`--dry-run --checksum-file -`" + `. The docs must be the same
+each time they are made from the same source so ` + "`--date now`" + ` can't
+be used with it, and with ` + "`--man`" + ` the date of the man pages must be
+set with ` + "`--date`" + ` or the ` + "`SOURCE_DATE_EPOCH`" + ` environment variable.
+
+Use ` + "`--post-process COMMAND`" + ` to run COMMAND on each docs file once
+they are all written, e.g. for transforms particular to the site. The
+command is split on spaces and the path of the file is added to the
+end of its arguments. If it fails for any file gendocs fails saying
+which file it was. The manifest and checksum are written after it has
+run, so the checksum is of the files it leaves. It isn't run with
+` + "`--dry-run`" + `.
+
+Use ` + "`--completions-dump FILE`" + ` to write the flags of each command to
+FILE as well, for making shell completions. There is a line for each
+flag of each command, including the global flags, with the command
+path, the flag and its type separated by tabs, e.g.
+
+    rclone copy	--dry-run	bool
+
+Flags with a shorthand, e.g. ` + "`-n`" + `, have a line for that too.
+
+Use ` + "`--redirects FILE`" + ` to write a redirect for each alias of the
+commands to FILE, e.g. for a CDN. There is a line for each command
+path a command can be run with apart from its own, with the URL the
+docs of that path would have and the URL of the docs of the command
+separated by a space, as used in a Netlify _redirects file, e.g.
+
+    /commands/rclone_self-update/ /commands/rclone_selfupdate/
+
+The paths used by more than one command are left out. This can't be
+used with ` + "`--single-page`" + ` or without the markdown docs.
+
+With ` + "`--dry-run`" + ` the docs are made as usual but nothing is written.
+Instead each file which would be created or modified is logged along
+with its size.
+
+Use ` + "`--require-description`" + ` to fail without writing anything if any
+command has an empty short description, as this is used for the
+description in the frontmatter. All the commands with no description
+are listed in the error.
+
+The frontmatter of each command says which directory its source is
+in, made from the command name, e.g. ` + "`cmd/config/create/`" + ` for
+"rclone config create". Use ` + "`--verify-source`" + ` to fail without writing
+anything if any of these directories don't exist, listing the
+commands, for example because the command is defined in the source
+of its parent or somewhere else. The directories are looked for in
+the current directory, or in the directory given with
+` + "`--source-root DIR`" + `.
+
+Use ` + "`--incremental`" + ` when making the docs again after editing the
+source to only write the docs of the commands whose source has
+changed since. The docs of a command are skipped if they were
+modified after all the Go files in its source directory and in the
+source directories of its parent and subcommands, as their short
+descriptions are on its page. The docs are always written for a
+command whose source directory has no Go files or which gets values
+for its frontmatter with ` + "`cmd.AddFrontmatter`" + `. The flags page is only
+written if the source of the global flags or of a backend has changed
+and the index page only if any command docs were written. Only the
+markdown docs of the commands are skipped, not the man pages or other
+files, and it can't be used with ` + "`--manifest`" + ` or ` + "`--checksum-file`" + `.
+Make all the docs again without it after changing the gendocs flags.
+
+Use ` + "`--frontmatter-annotations key1,key2`" + ` to add the command
+annotations with those keys to the frontmatter of each command which
+has them. Commands can also provide values for these keys with
+` + "`cmd.AddFrontmatter`" + `, which is how commands added by backends and
+plugins add to their frontmatter. If a command has an annotation with
+the same key its annotation is used. The values are quoted so they
+are always valid YAML or TOML, and gendocs fails if a value can't be
+written, e.g. if it isn't valid UTF-8.
+
+The frontmatter doesn't have a date so making the docs again from
+the same source doesn't change them. Use ` + "`--date`" + ` with a date in
+RFC3339 format, e.g. ` + "`--date 2022-03-18T12:00:00Z`" + `, or
+` + "`--date now`" + ` to add one.
+
+The frontmatter is written for hugo in YAML between ` + "`---`" + ` lines.
+Use ` + "`--frontmatter-format toml`" + ` to write the same fields in TOML
+between ` + "`+++`" + ` lines instead, as some hugo setups and other site
+generators prefer. The title and description are quoted in either
+format so characters like quotes in them are escaped.
+
+Use ` + "`--frontmatter-template FILE`" + ` to write it with the Go template in
+FILE instead, e.g. for another static site generator. The template is given ` + "`.Title`" + `, ` + "`.Description`" + `, ` + "`.Date`" + `,
+` + "`.Slug`" + `, ` + "`.URL`" + `, ` + "`.Source`" + `, ` + "`.EditWarning`" + ` and ` + "`.Annotations`" + `, a list of
+` + "`.Key`" + ` and ` + "`.Value`" + ` pairs with the values quoted. Use ` + "`{{ quote .Title }}`" + `
+to quote the other fields the same way. The template is checked
+before any docs are written.
+
+The frontmatter ends with a ` + "`# autogenerated - DO NOT EDIT`" + ` comment
+saying which source to edit instead. Use ` + "`--no-edit-warning`" + ` to leave
+it out, e.g. for tools which don't accept comments in the frontmatter.
+` + "`.EditWarning`" + ` is false then but ` + "`.Source`" + ` is still set.
+
+Use ` + "`--locale LOCALE --translations FILE`" + ` to write the docs in
+another language, e.g. ` + "`--locale de --translations de.json`" + `. The file
+is a JSON object with the translated text keyed by command path, e.g.
+` + "`{\"rclone copy\": {\"title\": \"...\", \"short\": \"...\", \"long\": \"...\"}}`" + `.
+The short and long descriptions of each command in it are replaced by
+their translations everywhere they are used, including the
+description in the frontmatter, and the title replaces the one in the
+frontmatter. Commands or fields which aren't translated are left in
+English. The locale is put in the frontmatter as ` + "`lang`" + ` and is
+` + "`.Locale`" + ` in the frontmatter template. It is an error if the file has
+commands which don't exist unless ` + "`--warn-only`" + ` is set.
+
+The headings of the docs cobra makes for each command are outdented
+by one level, so the title is level 1 and the sections are level 2,
+as hugo wants. Use ` + "`--heading-shift N`" + ` to move them by N levels
+instead, negative to outdent and positive to indent, e.g.
+` + "`--heading-shift 1`" + ` for pages which are embedded below another
+heading. The headings are kept between level 1 and 6. This doesn't
+change the docs made with ` + "`--single-page`" + ` which are nested by the
+depth of each command.
+
+The Options sections list the flags of each command in a code block.
+Use ` + "`--flag-style table`" + ` to list them in a markdown table instead
+with columns for the flag, its shorthand, type, default and help.
+
+The global flags page, flags.md, lists the non backend flags and then
+the flags of each backend under a heading for the backend, sorted by
+name, in the same style as the Options sections. Use ` + "`--flags-raw`" + ` to
+write it as shown by ` + "`rclone help flags`" + ` instead, with all the backend
+flags in one list.
+
+Use ` + "`--reading-time`" + ` to put the number of words in the docs of each
+command and the minutes it takes to read them, at 200 words a minute,
+in the frontmatter as ` + "`wordCount`" + ` and ` + "`readingTime`" + `. These are
+` + "`.WordCount`" + ` and ` + "`.ReadingTime`" + ` in the frontmatter template. The
+code blocks, including the Options sections, tables and the URLs of
+links aren't counted.
+
+Use ` + "`--examples-as-code`" + ` to put the examples of each command in bash
+code blocks, so they can be copied with the site's copy button. The
+code blocks in the Examples section of a page are marked as bash, and
+its indented lines are put in a bash code block without the indent.
+Pages without an Examples section aren't changed.
+
+An index page listing all the commands is written to _index.md in the
+commands directory, so hugo uses it for the page of the section. The
+commands are in a bulleted list with links to their docs and their
+short descriptions, with the commands below each command nested under
+it. Use ` + "`--index-name NAME`" + ` to call it NAME instead, e.g.
+` + "`--index-name index.md`" + `, or ` + "`--no-index`" + ` not to write it. It isn't written
+when ` + "`--command-path`" + ` or ` + "`--single-page`" + ` are set.
+
+Use ` + "`--backends`" + ` to write a page for each backend to the backends
+directory too, named after the prefix of its flags, e.g.
+backends/s3.md. Each page lists the standard and advanced options of
+the backend with their flags, environment variables, types, defaults
+and examples, as shown by ` + "`rclone help backend NAME`" + `, and has the same
+frontmatter as the command docs.
+
+Use ` + "`--rc-docs`" + ` to write a page for each rc call to the rc directory too,
+named after the path of the call, e.g. rc/operations_about.md, for the
+commands which name the call they do the same as in their ` + "`rc`" + `
+annotation. Each page has the help of the call, as shown by
+` + "`rclone rc --help`" + ` with the call, with a table of the flags of the
+commands. If the output format is json or both the calls and the flags
+are written to rc.json as well. It is an error for a command to name a
+call which doesn't exist.
+
+Use ` + "`--single-page FILE`" + ` to write the docs for all the commands to
+FILE, relative to the directory supplied, instead of a file for each
+command. The headings are nested by the depth of the command and the
+links between the commands become links to their sections in the
+page.
+
+Use ` + "`--man`" + ` to write section 1 man pages for the commands to the man
+directory as well as the markdown docs, or ` + "`--man-only`" + ` to only write
+the man pages. The version in the footer of each page is taken from
+the ` + "`versionIntroduced`" + ` annotation of the command if it has one,
+otherwise it is the rclone version. The date is taken from ` + "`--date`" + ` if
+set.
+
+Commands with a ` + "`versionIntroduced`" + ` annotation have a badge saying
+which version they were introduced in put after the frontmatter, e.g.
+"**New in v1.58**". Use ` + "`--version-badge-template FILE`" + ` to render the
+badge with the Go template in FILE instead, for example to use a hugo
+shortcode. The template is given ` + "`.Version`" + ` and ` + "`.Command`" + `, the command
+path. Commands without the annotation don't have a badge.
+
+The SEE ALSO section of each command lists the commands above and
+below it. Use ` + "`--see-also-template FILE`" + ` to render the list with the Go
+template in FILE instead, for example as a grid of cards. The template
+is given ` + "`.Command`" + `, the command path, and ` + "`.Commands`" + `, the related
+commands, each with ` + "`.Path`" + `, ` + "`.Short`" + ` and ` + "`.Link`" + `, e.g.
+
+    {{ range .Commands }}- [{{ .Path }}]({{ .Link }}): {{ .Short }}
+    {{ end }}
+
+The command docs are written to the commands directory and linked to
+as ` + "`/commands/`" + ` pages. Use ` + "`--section-name NAME`" + ` to use NAME instead,
+e.g. ` + "`--section-name de/befehle`" + ` for docs hosted under a localized
+path.
+
+The links made to the command pages and the global flags page, and
+the ` + "`url`" + ` in the frontmatter, are absolute paths from the root of the
+site. Use ` + "`--base-url URL`" + ` to put URL before them for docs which are
+served under another path, e.g. ` + "`--base-url https://example.com/rclone/`" + `
+makes links like ` + "`https://example.com/rclone/commands/rclone_copy/`" + `.
+
+The docs for the commands are made in parallel, by as many at once
+as there are CPUs. Use ` + "`--parallel N`" + ` to make N at once instead.
+The docs are the same whatever N is.
+
+gendocs only logs problems by default. Use ` + "`-v`" + ` to log each command
+as its docs are made and a summary of the number of files written and
+how long it took at the end, or ` + "`-vv`" + ` to log each page as its
+frontmatter and heading anchors are added too.
+
+Once the docs are written the links to the command pages in them are
+checked and gendocs fails, listing the file and link, if any of them
+are to a command which doesn't exist.
+
+Before any docs are written gendocs also checks that no two commands
+can be run with the same command path, using their aliases and the
+aliases of the commands above them, as the paths would lead to
+clashing pages. It fails listing each path and the commands using it
+if they do. It also fails if any command has a name with a path
+separator in or a name of "." or "..", as its docs could be written
+outside the docs directory.
+
+Use ` + "`--warn-only`" + ` to log the broken links and clashing aliases
+instead.`,
 	RunE: func(command *cobra.Command, args []string) error {
 		cmd.CheckArgs(1, 1, command, args)
-		now := time.Now().Format(time.RFC3339)
+		return run(args[0])
+	},
+}
+
+// generator writes the docs for top and the commands below it
+type generator struct {
+	top           *cobra.Command // the command the docs are written from
+	w             *docsWriter    // writes the docs files
+	links         *linkChecker   // checks the links in the docs written
+	writeMarkdown bool           // set if the markdown docs are written
+	writeJSON     bool           // set if the JSON metadata is written
+	endpoints     []rcEndpoint   // the rc calls written with --rc-docs
+	commandCount  int            // number of commands whose docs are made
+}
 
-		// Create the directory structure
-		root := args[0]
-		out := filepath.Join(root, "commands")
-		err := file.MkdirAll(out, 0777)
+// newGenerator checks the options and the commands and returns a
+// generator to write the docs to the directory root
+//
+// Nothing is written if any of the checks fail.
+func newGenerator(root string) (g *generator, err error) {
+	g = &generator{top: cmd.Root}
+	g.writeMarkdown, g.writeJSON, err = opt.outputs()
+	if err != nil {
+		return nil, err
+	}
+	g.writeMarkdown = manOpt.setup(g.writeMarkdown)
+	if opt.CommandPath != "" {
+		g.top, err = findCommand(cmd.Root, opt.CommandPath)
 		if err != nil {
-			return err
+			return nil, err
+		}
+	}
+	if opt.SkipDeprecated {
+		hideDeprecated(cmd.Root)
+	}
+	err = frontmatterOpt.setup()
+	if err != nil {
+		return nil, err
+	}
+	err = translationOpt.setup(cmd.Root, opt.WarnOnly)
+	if err != nil {
+		return nil, err
+	}
+	err = pageOpt.setup()
+	if err != nil {
+		return nil, err
+	}
+	err = opt.check(g.writeMarkdown)
+	if err != nil {
+		return nil, err
+	}
+	err = incrementalOpt.check(g.writeMarkdown)
+	if err != nil {
+		return nil, err
+	}
+	err = checkCommands(g.top)
+	if err != nil {
+		return nil, err
+	}
+	if rcOpt.Enabled {
+		g.endpoints, err = rcEndpoints(g.top)
+		if err != nil {
+			return nil, err
+		}
+	}
+	g.w = &docsWriter{
+		root:   root,
+		dryRun: fs.GetConfig(context.Background()).DryRun,
+	}
+	g.links = newLinkChecker(cmd.Root)
+	return g, nil
+}
+
+// run writes the docs to the directory root
+func run(root string) error {
+	start := time.Now()
+	g, err := newGenerator(root)
+	if err != nil {
+		return err
+	}
+	err = g.write()
+	if err != nil {
+		return err
+	}
+	fs.Infof(nil, "Wrote %d files with the docs of %d commands in %v", len(g.w.written), g.commandCount, time.Since(start).Round(time.Millisecond))
+
+	// Check the links to the commands once all the docs are written
+	if opt.WarnOnly {
+		for _, link := range g.links.broken {
+			fs.Logf(nil, "Link to a command which doesn't exist in %s", link)
 		}
+		return nil
+	}
+	return g.links.err()
+}
 
-		// Write the flags page
-		var buf bytes.Buffer
-		cmd.Root.SetOutput(&buf)
-		cmd.Root.SetArgs([]string{"help", "flags"})
-		cmd.GeneratingDocs = true
-		err = cmd.Root.Execute()
+// write writes each of the outputs asked for in turn
+func (g *generator) write() (err error) {
+	// Create the directory structure
+	err = g.w.mkdir("")
+	if err != nil {
+		return err
+	}
+	if g.writeMarkdown && pageOpt.SinglePage == "" {
+		err = g.w.mkdir(pageOpt.SectionName)
 		if err != nil {
 			return err
 		}
-		err = ioutil.WriteFile(filepath.Join(root, "flags.md"), buf.Bytes(), 0777)
+	}
+	if manOpt.Man {
+		err = g.w.mkdir("man")
 		if err != nil {
 			return err
 		}
+	}
 
-		// Look up name => description for prepender
-		var description = map[string]string{}
-		var addDescription func(root *cobra.Command)
-		addDescription = func(root *cobra.Command) {
-			name := strings.Replace(root.CommandPath(), " ", "_", -1) + ".md"
-			description[name] = root.Short
-			for _, c := range root.Commands() {
-				addDescription(c)
-			}
-		}
-		addDescription(cmd.Root)
-
-		// markup for the docs files
-		prepender := func(filename string) string {
-			name := filepath.Base(filename)
-			base := strings.TrimSuffix(name, path.Ext(name))
-			data := frontmatter{
-				Date:        now,
-				Title:       strings.Replace(base, "_", " ", -1),
-				Description: description[name],
-				Slug:        base,
-				URL:         "/commands/" + strings.ToLower(base) + "/",
-				Source:      strings.Replace(strings.Replace(base, "rclone", "cmd", -1), "_", "/", -1) + "/",
-			}
-			var buf bytes.Buffer
-			err := frontmatterTemplate.Execute(&buf, data)
-			if err != nil {
-				log.Fatalf("Failed to render frontmatter template: %v", err)
-			}
-			return buf.String()
-		}
-		linkHandler := func(name string) string {
-			base := strings.TrimSuffix(name, path.Ext(name))
-			return "/commands/" + strings.ToLower(base) + "/"
-		}
-
-		// Hide all of the root entries flags
-		cmd.Root.Flags().VisitAll(func(flag *pflag.Flag) {
-			flag.Hidden = true
-		})
-		err = doc.GenMarkdownTreeCustom(cmd.Root, out, prepender, linkHandler)
+	// Write the flags page unless only some commands are wanted
+	if g.writeMarkdown && g.top == cmd.Root {
+		err = g.writeFlagsPage()
 		if err != nil {
 			return err
 		}
+	}
+
+	// Dump the flags before the global ones are hidden
+	var completionsData []byte
+	if opt.Completions != "" {
+		completionsData = completionsDump(g.top)
+	}
 
-		var outdentTitle = regexp.MustCompile(`(?m)^#(#+)`)
-
-		// Munge the files to add a link to the global flags page
-		err = filepath.Walk(out, func(path string, info os.FileInfo, err error) error {
-			if err != nil {
-				return err
-			}
-			if !info.IsDir() {
-				b, err := ioutil.ReadFile(path)
-				if err != nil {
-					return err
-				}
-				doc := string(b)
-				doc = strings.Replace(doc, "\n### SEE ALSO", `
-See the [global flags page](/flags/) for global options not listed here.
-
-### SEE ALSO`, 1)
-				// outdent all the titles by one
-				doc = outdentTitle.ReplaceAllString(doc, `$1`)
-				err = ioutil.WriteFile(path, []byte(doc), 0777)
-				if err != nil {
-					return err
-				}
-			}
-			return nil
-		})
+	hideRootFlags()
+	if g.writeMarkdown && pageOpt.SinglePage != "" {
+		err = g.writeSinglePage()
+	} else if g.writeMarkdown {
+		err = g.writeCommandDocs()
+	}
+	if err != nil {
+		return err
+	}
+	if g.writeMarkdown && backendOpt.Enabled {
+		err = g.writeBackendDocs()
+		if err != nil {
+			return err
+		}
+	}
+	if g.writeMarkdown && rcOpt.Enabled {
+		err = g.writeRcDocs()
+		if err != nil {
+			return err
+		}
+	}
+	if manOpt.Man {
+		err = g.writeManPages()
+		if err != nil {
+			return err
+		}
+	}
+	if g.writeJSON {
+		err = g.writeJSONFiles()
 		if err != nil {
 			return err
 		}
+	}
 
+	if opt.Completions != "" {
+		err = g.w.write(opt.Completions, completionsData)
+		if err != nil {
+			return fmt.Errorf("failed to write completions dump: %w", err)
+		}
+	}
+	if opt.Redirects != "" {
+		err = g.w.write(opt.Redirects, commandRedirects(g.top))
+		if err != nil {
+			return fmt.Errorf("failed to write redirects: %w", err)
+		}
+	}
+	return g.w.finish()
+}
+
+// writeDoc checks the links in the markdown doc and writes it to name
+// relative to the docs directory
+func (g *generator) writeDoc(name, doc string) error {
+	g.links.check(name, []byte(doc))
+	return g.w.writeFile(name, []byte(doc))
+}
+
+// docCommands returns root and all the commands below it which have
+// docs written for them, sorted by command path
+func docCommands(root *cobra.Command) (commands []*cobra.Command) {
+	_ = cmd.WalkCommandTree(root, func(c *cobra.Command, aliases []string) error {
+		if c != root && (!c.IsAvailableCommand() || c.IsAdditionalHelpTopicCommand()) {
+			return cmd.ErrorSkipCommand
+		}
+		commands = append(commands, c)
 		return nil
-	},
+	})
+	sort.Slice(commands, func(i, j int) bool {
+		return commands[i].CommandPath() < commands[j].CommandPath()
+	})
+	return commands
+}
+
+// insideDocs returns true if name is a relative slash separated path
+// which stays inside the docs directory
+func insideDocs(name string) bool {
+	switch {
+	case name == "", name == ".", name == "..":
+	case path.IsAbs(name), path.Clean(name) != name:
+	case strings.HasPrefix(name, "../"), strings.Contains(name, "\\"):
+	default:
+		return true
+	}
+	return false
+}
+
+// findCommand returns the command with the command path given, e.g.
+// "rclone config create"
+func findCommand(root *cobra.Command, commandPath string) (*cobra.Command, error) {
+	args := strings.Fields(commandPath)
+	if len(args) > 0 && args[0] == root.Name() {
+		c, rest, err := root.Find(args[1:])
+		if err == nil && len(rest) == 0 {
+			return c, nil
+		}
+	}
+	return nil, fmt.Errorf("command path %q doesn't name an rclone command", commandPath)
+}
+
+// hideRootFlags hides all of the root entries flags so they aren't
+// documented with each command
+func hideRootFlags() {
+	cmd.Root.Flags().VisitAll(func(flag *pflag.Flag) {
+		flag.Hidden = true
+	})
+}
+
+// hideDeprecated hides the commands below root with a deprecated
+// annotation so no docs are written for them or links made to them.
+//
+// A deprecated command is left alone if any of the commands below it
+// are still available so they can be reached from it.
+func hideDeprecated(root *cobra.Command) {
+	var hide func(c *cobra.Command)
+	hide = func(c *cobra.Command) {
+		for _, child := range c.Commands() {
+			hide(child)
+		}
+		if _, deprecated := c.Annotations["deprecated"]; deprecated && c != root && !c.HasAvailableSubCommands() {
+			c.Hidden = true
+		}
+	}
+	hide(root)
+}
+
+// commandFileName returns the name of the docs file for c, e.g.
+// "rclone_config_create.md"
+func commandFileName(c *cobra.Command) string {
+	return strings.Replace(c.CommandPath(), " ", "_", -1) + ".md"
+}
+
+// pageError adds the name of the docs file being made and the path of
+// the command c it was being made for to err
+func pageError(name string, c *cobra.Command, err error) error {
+	return fmt.Errorf("failed to make %s for %q: %w", name, c.CommandPath(), err)
 }
@@ -0,0 +1,1567 @@
+package gendocs
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"path"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"text/template"
+	"time"
+
+	"github.com/rclone/rclone/cmd"
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fs/config/configmap"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v2"
+)
+
+func TestAnchorPrefix(t *testing.T) {
+	for _, test := range []struct {
+		in   string
+		want string
+	}{
+		{"rclone", "rclone"},
+		{"rclone_copy", "copy"},
+		{"rclone_config_create", "config-create"},
+	} {
+		assert.Equal(t, test.want, anchorPrefix(test.in), test.in)
+	}
+}
+
+func TestAddHeadingAnchors(t *testing.T) {
+	in := `---
+title: "rclone copy"
+# autogenerated - DO NOT EDIT
+---
+# rclone copy
+
+Copy files
+
+## Synopsis
+
+Some text
+
+` + "```" + `
+# not a heading
+` + "```" + `
+
+### Sub section: with punctuation!
+
+## Options
+
+## Already anchored {#custom}
+
+## Options
+
+## SEE ALSO
+`
+	want := `---
+title: "rclone copy"
+# autogenerated - DO NOT EDIT
+---
+# rclone copy
+
+Copy files
+
+## Synopsis {#copy-synopsis}
+
+Some text
+
+` + "```" + `
+# not a heading
+` + "```" + `
+
+### Sub section: with punctuation! {#copy-sub-section-with-punctuation}
+
+## Options {#copy-options}
+
+## Already anchored {#custom}
+
+## Options {#copy-options-1}
+
+## SEE ALSO {#copy-see-also}
+`
+	assert.Equal(t, want, addHeadingAnchors(in, "copy"))
+}
+
+func TestCommandsJSON(t *testing.T) {
+	root := &cobra.Command{Use: "rclone", Short: "Root"}
+	child := &cobra.Command{
+		Use:         "child",
+		Short:       "Child",
+		Long:        "Child long",
+		Aliases:     []string{"kid"},
+		Annotations: map[string]string{"b": "2", "a": "1"},
+		Run:         func(*cobra.Command, []string) {},
+	}
+	child.Flags().BoolP("zebra", "z", false, "Zebra help")
+	child.Flags().String("alpha", "x", "Alpha help")
+	hidden := &cobra.Command{Use: "hidden", Hidden: true, Run: func(*cobra.Command, []string) {}}
+	root.AddCommand(child, hidden)
+	// hide the global flags as gendocs does
+	root.NonInheritedFlags().VisitAll(func(flag *pflag.Flag) {
+		flag.Hidden = true
+	})
+
+	b, err := commandsJSON(root)
+	require.NoError(t, err)
+	want := `[
+	{
+		"aliases": [],
+		"annotations": {},
+		"flags": [],
+		"long": "",
+		"path": "rclone",
+		"short": "Root"
+	},
+	{
+		"aliases": [
+			"kid"
+		],
+		"annotations": {
+			"a": "1",
+			"b": "2"
+		},
+		"flags": [
+			{
+				"default": "x",
+				"help": "Alpha help",
+				"name": "alpha",
+				"shorthand": "",
+				"type": "string"
+			},
+			{
+				"default": "false",
+				"help": "Zebra help",
+				"name": "zebra",
+				"shorthand": "z",
+				"type": "bool"
+			}
+		],
+		"long": "Child long",
+		"path": "rclone child",
+		"short": "Child"
+	}
+]
+`
+	assert.Equal(t, want, string(b))
+}
+
+func TestFindCommand(t *testing.T) {
+	root := &cobra.Command{Use: "rclone"}
+	config := &cobra.Command{Use: "config"}
+	create := &cobra.Command{Use: "create", Run: func(*cobra.Command, []string) {}}
+	config.AddCommand(create)
+	root.AddCommand(config)
+
+	for _, test := range []struct {
+		in   string
+		want *cobra.Command
+	}{
+		{"rclone", root},
+		{"rclone config", config},
+		{" rclone  config create ", create},
+		{"", nil},
+		{"config", nil},
+		{"rclone potato", nil},
+		{"rclone config create potato", nil},
+	} {
+		got, err := findCommand(root, test.in)
+		if test.want == nil {
+			assert.Error(t, err, test.in)
+		} else {
+			require.NoError(t, err, test.in)
+			assert.Equal(t, test.want, got, test.in)
+		}
+	}
+}
+
+func TestWriteFilePerms(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("Skipping as permissions aren't supported on Windows")
+	}
+	w := &docsWriter{root: t.TempDir()}
+	require.NoError(t, w.mkdir("commands"))
+	name := filepath.Join(w.root, "commands", "file.md")
+	require.NoError(t, ioutil.WriteFile(name, []byte("old"), 0777))
+	require.NoError(t, w.writeFile("commands/file.md", []byte("new")))
+
+	fi, err := os.Stat(filepath.Join(w.root, "commands"))
+	require.NoError(t, err)
+	assert.Equal(t, writerOpt.DirPerms, fi.Mode().Perm())
+	fi, err = os.Stat(name)
+	require.NoError(t, err)
+	assert.Equal(t, writerOpt.FilePerms, fi.Mode().Perm())
+}
+
+func TestWriteManifest(t *testing.T) {
+	w := &docsWriter{root: t.TempDir()}
+	require.NoError(t, w.mkdir("commands"))
+	require.NoError(t, w.writeFile("flags.md", []byte("flags")))
+	require.NoError(t, w.writeFile("commands/rclone_copy.md", []byte("copy")))
+	require.NoError(t, w.writeFile("commands/rclone.md", []byte("rclone")))
+
+	manifest := filepath.Join(t.TempDir(), "manifest.txt")
+	require.NoError(t, w.writeManifest(manifest))
+	b, err := ioutil.ReadFile(manifest)
+	require.NoError(t, err)
+	assert.Equal(t, "commands/rclone.md\ncommands/rclone_copy.md\nflags.md\n", string(b))
+}
+
+func TestWriteChecksum(t *testing.T) {
+	write := func(names ...string) string {
+		w := &docsWriter{root: t.TempDir()}
+		require.NoError(t, w.mkdir("commands"))
+		for _, name := range names {
+			require.NoError(t, w.writeFile(name, []byte(path.Base(name))))
+		}
+		checksum := filepath.Join(t.TempDir(), "checksum.txt")
+		require.NoError(t, w.writeChecksum(checksum))
+		b, err := ioutil.ReadFile(checksum)
+		require.NoError(t, err)
+		return string(b)
+	}
+	// the SHA256 of the output of sha256sum commands/rclone.md flags.md
+	want := "daeab7cac494ae6941853fa1d3571d78073828bb04a121aa09982642aec397c1\n"
+	assert.Equal(t, want, write("flags.md", "commands/rclone.md"))
+	assert.Equal(t, want, write("commands/rclone.md", "flags.md"))
+	assert.NotEqual(t, want, write("flags.md"))
+}
+
+func TestCheckChecksumDate(t *testing.T) {
+	t.Setenv("SOURCE_DATE_EPOCH", "")
+	assert.NoError(t, checkChecksumDate("", false))
+	assert.NoError(t, checkChecksumDate("2022-03-18T12:00:00Z", true))
+	assert.Error(t, checkChecksumDate("now", false))
+	assert.Error(t, checkChecksumDate("", true))
+	t.Setenv("SOURCE_DATE_EPOCH", "1647604800")
+	assert.NoError(t, checkChecksumDate("", true))
+}
+
+func TestPostProcess(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("needs a shell script")
+	}
+	dir := t.TempDir()
+	script := filepath.Join(dir, "post.sh")
+	require.NoError(t, ioutil.WriteFile(script, []byte(`#!/bin/sh
+case "$2" in
+*fail.md) exit 3 ;;
+esac
+echo "$1" >> "$2"
+`), 0777))
+
+	w := &docsWriter{root: t.TempDir()}
+	require.NoError(t, w.mkdir("commands"))
+	require.NoError(t, w.writeFile("commands/rclone.md", []byte("rclone\n")))
+	require.NoError(t, w.writeFile("flags.md", []byte("flags\n")))
+	oldSum := w.sums["flags.md"]
+	require.NoError(t, w.postProcess([]string{script, "processed"}))
+	for _, name := range []string{"commands/rclone.md", "flags.md"} {
+		b, err := ioutil.ReadFile(filepath.Join(w.root, name))
+		require.NoError(t, err)
+		assert.Equal(t, path.Base(strings.TrimSuffix(name, ".md"))+"\nprocessed\n", string(b))
+	}
+	assert.NotEqual(t, oldSum, w.sums["flags.md"])
+
+	// a failure says which file it was
+	require.NoError(t, w.writeFile("fail.md", []byte("fail\n")))
+	err := w.postProcess([]string{script, "again"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "fail.md")
+
+	// nothing is run in a dry run
+	w.dryRun = true
+	assert.NoError(t, w.postProcess([]string{filepath.Join(dir, "missing")}))
+}
+
+func TestCompletionsDump(t *testing.T) {
+	root := &cobra.Command{Use: "rclone", Short: "Root"}
+	root.PersistentFlags().BoolP("verbose", "v", false, "Verbose")
+	root.PersistentFlags().String("hidden", "", "Hidden")
+	require.NoError(t, root.PersistentFlags().MarkHidden("hidden"))
+	child := &cobra.Command{Use: "child", Short: "Child", Run: func(*cobra.Command, []string) {}}
+	child.Flags().Int("zebra", 0, "Zebra")
+	child.Flags().StringP("alpha", "a", "", "Alpha")
+	gone := &cobra.Command{Use: "gone", Hidden: true, Run: func(*cobra.Command, []string) {}}
+	gone.Flags().Bool("gone", false, "Gone")
+	root.AddCommand(child, gone)
+
+	want := "rclone\t--verbose\tbool\n" +
+		"rclone\t-v\tbool\n" +
+		"rclone child\t--alpha\tstring\n" +
+		"rclone child\t--verbose\tbool\n" +
+		"rclone child\t--zebra\tint\n" +
+		"rclone child\t-a\tstring\n" +
+		"rclone child\t-v\tbool\n"
+	assert.Equal(t, want, string(completionsDump(root)))
+}
+
+func TestWriteDryRun(t *testing.T) {
+	w := &docsWriter{root: filepath.Join(t.TempDir(), "docs"), dryRun: true}
+	require.NoError(t, w.mkdir("commands"))
+	require.NoError(t, w.writeFile("commands/rclone.md", []byte("rclone")))
+	assert.Equal(t, []string{"commands/rclone.md"}, w.written)
+	_, err := os.Stat(w.root)
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestCheckDescriptions(t *testing.T) {
+	root := &cobra.Command{Use: "rclone", Short: "Root"}
+	good := &cobra.Command{Use: "good", Short: "Good", Run: func(*cobra.Command, []string) {}}
+	bad := &cobra.Command{Use: "bad", Run: func(*cobra.Command, []string) {}}
+	blank := &cobra.Command{Use: "blank", Short: " ", Run: func(*cobra.Command, []string) {}}
+	root.AddCommand(good)
+	assert.NoError(t, checkDescriptions(docCommands(root)))
+
+	root.AddCommand(bad, blank)
+	err := checkDescriptions(docCommands(root))
+	require.Error(t, err)
+	assert.Equal(t, "commands with no description: rclone bad, rclone blank", err.Error())
+}
+
+func TestCheckAliases(t *testing.T) {
+	run := func(*cobra.Command, []string) {}
+	root := &cobra.Command{Use: "rclone", Short: "Root"}
+	config := &cobra.Command{Use: "config", Short: "Config", Aliases: []string{"cfg"}, Run: run}
+	ls := &cobra.Command{Use: "ls", Short: "List", Aliases: []string{"list", "list"}, Run: run}
+	lsd := &cobra.Command{Use: "lsd", Short: "List dirs", Run: run}
+	root.AddCommand(config)
+	config.AddCommand(ls, lsd)
+	assert.NoError(t, checkAliases(root))
+
+	// an alias the same as another command
+	lsd.Aliases = []string{"ls"}
+	// an alias the same as another alias including one from the parent
+	cfg := &cobra.Command{Use: "configure", Short: "Configure", Aliases: []string{"cfg"}, Run: run}
+	root.AddCommand(cfg)
+	err := checkAliases(root)
+	require.Error(t, err)
+	assert.Equal(t, `command paths used by more than one command: "rclone cfg" (rclone config, rclone configure), "rclone cfg ls" (rclone config ls, rclone config lsd), "rclone config ls" (rclone config ls, rclone config lsd)`, err.Error())
+}
+
+func TestCommandRedirects(t *testing.T) {
+	run := func(*cobra.Command, []string) {}
+	root := &cobra.Command{Use: "rclone", Short: "Root"}
+	config := &cobra.Command{Use: "config", Short: "Config", Aliases: []string{"cfg"}, Run: run}
+	ls := &cobra.Command{Use: "ls", Short: "List", Aliases: []string{"list", "list"}, Run: run}
+	lsd := &cobra.Command{Use: "lsd", Short: "List dirs", Aliases: []string{"ls"}, Run: run}
+	cfg := &cobra.Command{Use: "configure", Short: "Configure", Aliases: []string{"cfg"}, Run: run}
+	hidden := &cobra.Command{Use: "hidden", Short: "Hidden", Aliases: []string{"secret"}, Hidden: true, Run: run}
+	cp := &cobra.Command{Use: "copy", Short: "Copy", Aliases: []string{"cp"}, Run: run}
+	root.AddCommand(config, cfg, hidden, cp)
+	config.AddCommand(ls, lsd)
+
+	// hidden commands aren't redirected and rclone cfg is used by two commands and rclone config ls is a
+	// command so they and the paths below them aren't redirected
+	assert.Equal(t, `/commands/rclone_cfg_list/ /commands/rclone_config_ls/
+/commands/rclone_cfg_lsd/ /commands/rclone_config_lsd/
+/commands/rclone_config_list/ /commands/rclone_config_ls/
+/commands/rclone_cp/ /commands/rclone_copy/
+`, string(commandRedirects(root)))
+
+	// only the commands from top down are redirected, using the
+	// aliases of the commands above it
+	assert.Equal(t, `/commands/rclone_cfg_list/ /commands/rclone_config_ls/
+/commands/rclone_config_list/ /commands/rclone_config_ls/
+`, string(commandRedirects(ls)))
+
+	defer func() { pageOpt.BaseURL = "" }()
+	pageOpt.BaseURL = "/rclone"
+	assert.Equal(t, "/rclone/commands/rclone_cp/ /rclone/commands/rclone_copy/\n", string(commandRedirects(cp)))
+}
+
+func TestCheckSources(t *testing.T) {
+	root := &cobra.Command{Use: "rclone", Short: "Root"}
+	config := &cobra.Command{Use: "config", Short: "Config", Run: func(*cobra.Command, []string) {}}
+	create := &cobra.Command{Use: "create", Short: "Create", Run: func(*cobra.Command, []string) {}}
+	plugin := &cobra.Command{Use: "plugin", Short: "Plugin", Run: func(*cobra.Command, []string) {}}
+	root.AddCommand(config)
+	config.AddCommand(create)
+	assert.Equal(t, "cmd/", commandSource(root))
+	assert.Equal(t, "cmd/config/create/", commandSource(create))
+
+	dir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "cmd", "config", "create"), 0777))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "cmd", "plugin"), nil, 0666))
+	assert.NoError(t, checkSources(docCommands(root), dir))
+
+	require.NoError(t, os.Remove(filepath.Join(dir, "cmd", "config", "create")))
+	root.AddCommand(plugin)
+	err := checkSources(docCommands(root), dir)
+	require.Error(t, err)
+	assert.Equal(t, fmt.Sprintf("commands with no source directory in %q: rclone config create (cmd/config/create/), rclone plugin (cmd/plugin/)", dir), err.Error())
+}
+
+func TestIncremental(t *testing.T) {
+	root := &cobra.Command{Use: "rclone", Short: "Root"}
+	copyCmd := &cobra.Command{Use: "copy", Short: "Copy", Run: func(*cobra.Command, []string) {}}
+	move := &cobra.Command{Use: "move", Short: "Move", Run: func(*cobra.Command, []string) {}}
+	plugin := &cobra.Command{Use: "plugin", Short: "Plugin", Run: func(*cobra.Command, []string) {}}
+	root.AddCommand(copyCmd, move, plugin)
+	cmd.AddFrontmatter(plugin, func() map[string]string { return nil })
+
+	oldSourceRoot := incrementalOpt.SourceRoot
+	defer func() { incrementalOpt.SourceRoot = oldSourceRoot }()
+	incrementalOpt.SourceRoot = t.TempDir()
+	docsRoot := t.TempDir()
+	t1 := time.Date(2001, 2, 3, 4, 5, 6, 0, time.UTC)
+	t2 := t1.Add(time.Hour)
+	touch := func(name string, modTime time.Time) {
+		require.NoError(t, os.MkdirAll(filepath.Dir(name), 0777))
+		require.NoError(t, ioutil.WriteFile(name, nil, 0666))
+		require.NoError(t, os.Chtimes(name, modTime, modTime))
+	}
+	touch(filepath.Join(incrementalOpt.SourceRoot, "cmd", "cmd.go"), t1)
+	touch(filepath.Join(incrementalOpt.SourceRoot, "cmd", "copy", "copy.go"), t1)
+	touch(filepath.Join(incrementalOpt.SourceRoot, "cmd", "copy", "copy_test.go"), t2)
+	touch(filepath.Join(incrementalOpt.SourceRoot, "cmd", "move", "move.go"), t2)
+	touch(filepath.Join(incrementalOpt.SourceRoot, "cmd", "plugin", "plugin.go"), t1)
+
+	newest, ok := sourceModTime(incrementalOpt.SourceRoot, []string{"cmd/", "cmd/copy/"})
+	assert.True(t, ok)
+	assert.True(t, newest.Equal(t1))
+	_, ok = sourceModTime(incrementalOpt.SourceRoot, []string{"cmd/missing/"})
+	assert.False(t, ok)
+
+	// no docs yet so all are written
+	var paths []string
+	for _, c := range changedCommands(docsRoot, docCommands(root)) {
+		paths = append(paths, c.CommandPath())
+	}
+	assert.Equal(t, []string{"rclone", "rclone copy", "rclone move", "rclone plugin"}, paths)
+
+	// move is newer than its docs, rclone is as it lists move, and
+	// plugin provides frontmatter
+	for _, name := range []string{"rclone.md", "rclone_copy.md", "rclone_move.md", "rclone_plugin.md"} {
+		touch(filepath.Join(docsRoot, "commands", name), t1)
+	}
+	paths = nil
+	for _, c := range changedCommands(docsRoot, docCommands(root)) {
+		paths = append(paths, c.CommandPath())
+	}
+	assert.Equal(t, []string{"rclone", "rclone move", "rclone plugin"}, paths)
+}
+
+func TestFrontmatterAnnotations(t *testing.T) {
+	c := &cobra.Command{
+		Use: "copy",
+		Annotations: map[string]string{
+			"versionIntroduced": "v1.0",
+			"colon":             ": leading space: and \"quotes\" <&>",
+			"bad":               "\xff",
+		},
+	}
+
+	got, err := frontmatterAnnotations(c, []string{"versionIntroduced", "missing", "colon"})
+	require.NoError(t, err)
+	assert.Equal(t, []frontmatterAnnotation{
+		{Key: "versionIntroduced", Value: `"v1.0"`},
+		{Key: "colon", Value: `": leading space: and \"quotes\" <&>"`},
+	}, got)
+
+	_, err = frontmatterAnnotations(c, []string{"bad"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `"bad"`)
+
+	frontmatterOpt.Annotations = fs.CommaSepList{"bad"}
+	defer func() { frontmatterOpt.Annotations = nil }()
+	_, err = markdownDoc(c)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `"bad"`)
+	assert.Contains(t, err.Error(), `commands/copy.md for "copy"`)
+
+	frontmatterOpt.Annotations = fs.CommaSepList{"versionIntroduced"}
+	doc, err := markdownDoc(c)
+	require.NoError(t, err)
+	assert.Contains(t, doc, "url: /commands/copy/\nversionIntroduced: \"v1.0\"\n# autogenerated")
+
+	// values from cmd.AddFrontmatter are used unless there is an annotation
+	cmd.AddFrontmatter(c, func() map[string]string {
+		return map[string]string{"versionIntroduced": "v9.9", "tags": "sync", "hidden": "x"}
+	})
+	got, err = frontmatterAnnotations(c, []string{"versionIntroduced", "tags"})
+	require.NoError(t, err)
+	assert.Equal(t, []frontmatterAnnotation{
+		{Key: "versionIntroduced", Value: `"v1.0"`},
+		{Key: "tags", Value: `"sync"`},
+	}, got)
+
+	assert.NoError(t, checkAnnotationKeys([]string{"versionIntroduced", "a_b-c"}))
+	assert.Error(t, checkAnnotationKeys([]string{"Title"}))
+	assert.Error(t, checkAnnotationKeys([]string{"a: b"}))
+}
+
+func TestParseDate(t *testing.T) {
+	got, err := parseDate("")
+	require.NoError(t, err)
+	assert.Equal(t, "", got)
+
+	got, err = parseDate("2022-03-18T12:00:00+01:00")
+	require.NoError(t, err)
+	assert.Equal(t, "2022-03-18T12:00:00+01:00", got)
+
+	got, err = parseDate("now")
+	require.NoError(t, err)
+	_, err = time.Parse(time.RFC3339, got)
+	assert.NoError(t, err)
+
+	_, err = parseDate("yesterday")
+	assert.Error(t, err)
+}
+
+func TestMarkdownDocDate(t *testing.T) {
+	c := &cobra.Command{Use: "copy", Short: "Copy files", Run: func(*cobra.Command, []string) {}}
+
+	// with no date the docs are the same each time
+	doc1, err := markdownDoc(c)
+	require.NoError(t, err)
+	doc2, err := markdownDoc(c)
+	require.NoError(t, err)
+	assert.Equal(t, doc1, doc2)
+	assert.NotContains(t, doc1, "date:")
+
+	frontmatterOpt.parsedDate = "2022-03-18T12:00:00Z"
+	defer func() { frontmatterOpt.parsedDate = "" }()
+	doc, err := markdownDoc(c)
+	require.NoError(t, err)
+	assert.Contains(t, doc, "description: \"Copy files\"\ndate: \"2022-03-18T12:00:00Z\"\nslug: copy\n")
+}
+
+func TestShiftHeadings(t *testing.T) {
+	in := "# one\n" + "```\n## code\n```\n" + "### three\n###### six\n"
+	assert.Equal(t, "## one\n```\n## code\n```\n#### three\n###### six\n", shiftHeadings(in, 1))
+	assert.Equal(t, "# one\n```\n## code\n```\n## three\n##### six\n", shiftHeadings(in, -1))
+}
+
+func TestSinglePageDoc(t *testing.T) {
+	root := &cobra.Command{Use: "rclone", Short: "Root", DisableAutoGenTag: true}
+	config := &cobra.Command{Use: "config", Short: "Config", Run: func(*cobra.Command, []string) {}}
+	create := &cobra.Command{Use: "create", Short: "Create", Run: func(*cobra.Command, []string) {}}
+	config.AddCommand(create)
+	root.AddCommand(config)
+
+	doc, err := singlePageDoc(config)
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(doc, "---\ntitle: \"rclone config reference\"\ndescription: \"Config\"\n"))
+	assert.Equal(t, 1, strings.Count(doc, "\n---\n"), "one frontmatter")
+	assert.Contains(t, doc, "\n# rclone config {#config}\n")
+	assert.Contains(t, doc, "\n## Options {#config-options}\n")
+	assert.Contains(t, doc, "\n## rclone config create {#config-create}\n")
+	assert.Contains(t, doc, "\n### Options {#config-create-options}\n")
+	// links in the page are anchors, others are URLs
+	assert.Contains(t, doc, "* [rclone config create](#config-create)")
+	assert.Contains(t, doc, "* [rclone config](#config)")
+	assert.Contains(t, doc, "* [rclone](/commands/rclone/)")
+	assert.Less(t, strings.Index(doc, "{#config}"), strings.Index(doc, "{#config-create}"))
+}
+
+func TestManPage(t *testing.T) {
+	oldDate := frontmatterOpt.parsedDate
+	defer func() { frontmatterOpt.parsedDate = oldDate }()
+	frontmatterOpt.parsedDate = "2022-03-18T12:00:00Z"
+
+	root := &cobra.Command{Use: "rclone", Short: "Root", DisableAutoGenTag: true}
+	create := &cobra.Command{Use: "create", Short: "Create a remote", Run: func(*cobra.Command, []string) {}}
+	root.AddCommand(create)
+	assert.Equal(t, "rclone-create.1", manFileName(create))
+
+	page, err := manPage(create)
+	require.NoError(t, err)
+	assert.Contains(t, string(page), `.TH "RCLONE-CREATE" "1" "Mar 2022" "rclone `+fs.Version+`" "User Commands"`)
+	assert.Contains(t, string(page), "rclone-create - Create a remote")
+
+	create.Annotations = map[string]string{"versionIntroduced": "v1.2"}
+	page, err = manPage(create)
+	require.NoError(t, err)
+	assert.Contains(t, string(page), `"Mar 2022" "rclone v1.2" "User Commands"`)
+}
+
+func TestDocsDeterministic(t *testing.T) {
+	root := &cobra.Command{Use: "rclone", Short: "Root", DisableAutoGenTag: true}
+	c := &cobra.Command{Use: "copy", Short: "Copy", Run: func(*cobra.Command, []string) {}}
+	for _, name := range []string{"zeta", "alpha", "mu", "beta", "omega", "kappa"} {
+		c.Flags().String(name, "", "Flag "+name)
+	}
+	root.AddCommand(c)
+
+	gen := func() (doc string, js []byte) {
+		doc, err := markdownDoc(c)
+		require.NoError(t, err)
+		js, err = commandsJSON(root)
+		require.NoError(t, err)
+		return doc, js
+	}
+	doc1, js1 := gen()
+	for i := 0; i < 10; i++ {
+		doc2, js2 := gen()
+		assert.Equal(t, doc1, doc2)
+		assert.Equal(t, js1, js2)
+	}
+
+	// the flags are written in sorted order
+	last := -1
+	for _, name := range []string{"alpha", "beta", "kappa", "mu", "omega", "zeta"} {
+		i := strings.Index(doc1, "--"+name)
+		assert.Greater(t, i, last, name)
+		last = i
+	}
+}
+
+func TestCommandOrder(t *testing.T) {
+	root := &cobra.Command{Use: "rclone", Short: "Root", DisableAutoGenTag: true}
+	backend := &cobra.Command{Use: "backend", Short: "Backend", Run: func(*cobra.Command, []string) {}}
+	root.AddCommand(backend)
+	// register the commands out of order
+	for _, name := range []string{"zeta", "alpha", "mu"} {
+		root.AddCommand(&cobra.Command{Use: name, Short: "Command " + name, Run: func(*cobra.Command, []string) {}})
+		backend.AddCommand(&cobra.Command{Use: name, Short: "Command " + name, Run: func(*cobra.Command, []string) {}})
+	}
+
+	// the docs are written in order of command path
+	var paths []string
+	for _, c := range docCommands(root) {
+		paths = append(paths, c.CommandPath())
+	}
+	assert.Equal(t, []string{
+		"rclone",
+		"rclone alpha",
+		"rclone backend",
+		"rclone backend alpha",
+		"rclone backend mu",
+		"rclone backend zeta",
+		"rclone mu",
+		"rclone zeta",
+	}, paths)
+
+	// and the commands below each command are listed in name order
+	doc, err := markdownDoc(backend)
+	require.NoError(t, err)
+	last := -1
+	for _, name := range []string{"alpha", "mu", "zeta"} {
+		i := strings.Index(doc, "[rclone backend "+name+"]")
+		assert.Greater(t, i, last, name)
+		last = i
+	}
+}
+
+func TestVersionBadge(t *testing.T) {
+	oldTemplate := versionBadgeTemplate
+	defer func() { versionBadgeTemplate = oldTemplate }()
+
+	root := &cobra.Command{Use: "rclone", Short: "Root", DisableAutoGenTag: true}
+	c := &cobra.Command{Use: "copy", Short: "Copy files", Run: func(*cobra.Command, []string) {}}
+	root.AddCommand(c)
+
+	// without the annotation there is no badge
+	doc, err := markdownDoc(c)
+	require.NoError(t, err)
+	assert.Contains(t, doc, "---\n# rclone copy\n")
+
+	c.Annotations = map[string]string{"versionIntroduced": "v1.58"}
+	doc, err = markdownDoc(c)
+	require.NoError(t, err)
+	assert.Contains(t, doc, "---\n**New in v1.58**\n\n# rclone copy\n")
+
+	dir := t.TempDir()
+	name := filepath.Join(dir, "badge.tmpl")
+	require.NoError(t, ioutil.WriteFile(name, []byte(`{{"{{"}}< version "{{ .Version }}" >{{"}}"}} for {{ .Command }}`+"\n\n"), 0666))
+	require.NoError(t, loadVersionBadgeTemplate(name))
+	doc, err = markdownDoc(c)
+	require.NoError(t, err)
+	assert.Contains(t, doc, "---\n{{< version \"v1.58\" >}} for rclone copy\n\n# rclone copy\n")
+
+	doc, err = singlePageDoc(root)
+	require.NoError(t, err)
+	assert.Contains(t, doc, "## rclone copy {#copy}\n\n{{< version \"v1.58\" >}} for rclone copy\n\nCopy files\n")
+
+	require.NoError(t, ioutil.WriteFile(name, []byte("{{ .Potato"), 0666))
+	assert.Error(t, loadVersionBadgeTemplate(name))
+	assert.Error(t, loadVersionBadgeTemplate(filepath.Join(dir, "missing")))
+}
+
+func TestSeeAlsoTemplate(t *testing.T) {
+	defer func() { seeAlsoTemplate = nil }()
+
+	root := &cobra.Command{Use: "rclone", Short: "Root", DisableAutoGenTag: true}
+	config := &cobra.Command{Use: "config", Short: "Enter an interactive\nconfiguration session.", Run: func(*cobra.Command, []string) {}}
+	config.AddCommand(&cobra.Command{Use: "create", Short: "Create a new remote.", Run: func(*cobra.Command, []string) {}})
+	root.AddCommand(config)
+
+	body, err := commandMarkdown(config, linkHandler)
+	require.NoError(t, err)
+	commands, start, end, ok := parseSeeAlso(body)
+	require.True(t, ok)
+	assert.Equal(t, []seeAlsoCommand{
+		{Path: "rclone", Short: "Root", Link: "/commands/rclone/"},
+		{Path: "rclone config create", Short: "Create a new remote.", Link: "/commands/rclone_config_create/"},
+	}, commands)
+	assert.True(t, strings.HasPrefix(body[start:], "* [rclone]("), body[start:])
+	assert.Equal(t, "* [rclone config create](/commands/rclone_config_create/)\t - Create a new remote.\n", body[strings.LastIndex(body[:end-1], "\n")+1:end])
+
+	// the short description over two lines is put on one
+	create := config.Commands()[0]
+	body, err = commandMarkdown(create, linkHandler)
+	require.NoError(t, err)
+	commands, _, _, ok = parseSeeAlso(body)
+	require.True(t, ok)
+	assert.Equal(t, []seeAlsoCommand{
+		{Path: "rclone config", Short: "Enter an interactive configuration session.", Link: "/commands/rclone_config/"},
+	}, commands)
+
+	// without a template the list is left alone
+	doc, err := markdownDoc(config)
+	require.NoError(t, err)
+	assert.Contains(t, doc, "## SEE ALSO {#config-see-also}\n\n* [rclone](/commands/rclone/)\t - Root\n")
+
+	dir := t.TempDir()
+	name := filepath.Join(dir, "see-also.tmpl")
+	require.NoError(t, ioutil.WriteFile(name, []byte(`<div class="cards" data-command="{{ .Command }}">
+{{ range .Commands }}<a href="{{ .Link }}">{{ .Path }}: {{ .Short }}</a>
+{{ end }}</div>`), 0666))
+	require.NoError(t, loadSeeAlsoTemplate(name))
+	doc, err = markdownDoc(config)
+	require.NoError(t, err)
+	assert.Contains(t, doc, `## SEE ALSO {#config-see-also}
+
+<div class="cards" data-command="rclone config">
+<a href="/commands/rclone/">rclone: Root</a>
+<a href="/commands/rclone_config_create/">rclone config create: Create a new remote.</a>
+</div>
+`)
+	assert.NotContains(t, doc, "* [rclone]")
+
+	require.NoError(t, ioutil.WriteFile(name, []byte("{{ .Potato }}"), 0666))
+	assert.Error(t, loadSeeAlsoTemplate(name))
+	assert.Error(t, loadSeeAlsoTemplate(filepath.Join(dir, "missing")))
+	assert.NotNil(t, seeAlsoTemplate)
+}
+
+func TestPageErrors(t *testing.T) {
+	oldFrontmatter, oldSinglePage, oldBadge := frontmatterTemplate, singlePageTemplate, versionBadgeTemplate
+	defer func() {
+		frontmatterTemplate, singlePageTemplate, versionBadgeTemplate = oldFrontmatter, oldSinglePage, oldBadge
+		pageOpt.SinglePage = ""
+	}()
+
+	root := &cobra.Command{Use: "rclone", Short: "Root", DisableAutoGenTag: true}
+	c := &cobra.Command{Use: "copy", Short: "Copy files", Run: func(*cobra.Command, []string) {}}
+	root.AddCommand(c)
+
+	// templates which parse but fail when they are rendered
+	bad := template.Must(template.New("bad").Parse("{{ .Potato }}"))
+	frontmatterTemplate = bad
+	_, err := markdownDoc(c)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `failed to make commands/rclone_copy.md for "rclone copy": failed to render frontmatter template:`)
+
+	singlePageTemplate = bad
+	_, err = indexDoc(root)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `failed to make commands/_index.md for "rclone":`)
+
+	pageOpt.SinglePage = "docs/rclone.md"
+	_, err = singlePageDoc(root)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `failed to make docs/rclone.md for "rclone":`)
+
+	frontmatterTemplate, singlePageTemplate = oldFrontmatter, oldSinglePage
+	c.Annotations = map[string]string{"versionIntroduced": "v1.58"}
+	versionBadgeTemplate = bad
+	_, err = singlePageDoc(root)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `failed to make docs/rclone.md for "rclone copy": failed to render version badge:`)
+}
+
+func TestLinkChecker(t *testing.T) {
+	root := &cobra.Command{Use: "rclone", Short: "Root", DisableAutoGenTag: true}
+	c := &cobra.Command{
+		Use:   "copy",
+		Short: "Copy files",
+		Long:  "See [rclone sync](/commands/rclone_sync/) and [rclone cpy](/commands/rclone_cpy/#options).",
+		Run:   func(*cobra.Command, []string) {},
+	}
+	root.AddCommand(c)
+	root.AddCommand(&cobra.Command{Use: "sync", Short: "Sync files", Run: func(*cobra.Command, []string) {}})
+
+	lc := newLinkChecker(root)
+	assert.NoError(t, lc.err())
+	doc, err := markdownDoc(c)
+	require.NoError(t, err)
+	lc.check("commands/rclone_copy.md", []byte(doc))
+	lc.check("flags.md", []byte("[rclone](/commands/rclone/) [Copy](/commands/rclone_COPY/) [x](/commands/rclone_x)"))
+	assert.Equal(t, []string{
+		"commands/rclone_copy.md: /commands/rclone_cpy/#options",
+		"flags.md: /commands/rclone_x",
+	}, lc.broken)
+	assert.EqualError(t, lc.err(), "links to commands which don't exist: commands/rclone_copy.md: /commands/rclone_cpy/#options, flags.md: /commands/rclone_x")
+}
+
+func TestSectionName(t *testing.T) {
+	root := &cobra.Command{Use: "rclone", Short: "Root", DisableAutoGenTag: true}
+	c := &cobra.Command{
+		Use:   "copy",
+		Short: "Copy files",
+		Long:  "See [rclone sync](/de/befehle/rclone_sync/) and [rclone old](/commands/rclone_old/).",
+		Run:   func(*cobra.Command, []string) {},
+	}
+	root.AddCommand(c)
+
+	pageOpt.SectionName = "de/befehle"
+	defer func() { pageOpt.SectionName = "commands" }()
+	doc, err := markdownDoc(c)
+	require.NoError(t, err)
+	assert.Contains(t, doc, "url: /de/befehle/rclone_copy/\n")
+	assert.Contains(t, doc, "* [rclone](/de/befehle/rclone/)")
+	assert.NotContains(t, doc, "](/commands/rclone/)")
+
+	lc := newLinkChecker(root)
+	lc.check("de/befehle/rclone_copy.md", []byte(doc))
+	assert.Equal(t, []string{"de/befehle/rclone_copy.md: /de/befehle/rclone_sync/"}, lc.broken)
+
+	for _, name := range []string{"commands", "de/befehle", "a-b_c"} {
+		assert.NoError(t, checkSectionName(name), name)
+	}
+	for _, name := range []string{"", ".", "..", "/commands", "../commands", "commands/", "a//b", "a/../b", `a\b`} {
+		assert.Error(t, checkSectionName(name), name)
+	}
+}
+
+func TestCheckCommandNames(t *testing.T) {
+	run := func(*cobra.Command, []string) {}
+	root := &cobra.Command{Use: "rclone", Short: "Root"}
+	root.AddCommand(&cobra.Command{Use: "copy", Short: "Copy", Run: run})
+	assert.NoError(t, checkCommandNames(docCommands(root)))
+
+	root.AddCommand(&cobra.Command{Use: "../../evil", Short: "Evil", Run: run})
+	root.AddCommand(&cobra.Command{Use: "..", Short: "Dots", Run: run})
+	err := checkCommandNames(docCommands(root))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `"rclone ../../evil"`)
+	assert.Contains(t, err.Error(), `"rclone .."`)
+	assert.NotContains(t, err.Error(), "copy")
+
+	// the docs writer won't write outside the docs directory either
+	dir := t.TempDir()
+	w := &docsWriter{root: filepath.Join(dir, "docs")}
+	require.NoError(t, w.mkdir(""))
+	for _, name := range []string{"../evil.md", "commands/../../evil.md", "/evil.md", `commands\evil.md`} {
+		assert.Error(t, w.writeFile(name, []byte("evil")), name)
+	}
+	assert.Equal(t, 0, len(w.written))
+	_, err = os.Stat(filepath.Join(dir, "evil.md"))
+	assert.True(t, os.IsNotExist(err))
+	assert.NoError(t, w.writeFile("commands.json", []byte("[]\n")))
+}
+
+func TestHideDeprecated(t *testing.T) {
+	run := func(*cobra.Command, []string) {}
+	deprecated := map[string]string{"deprecated": "true"}
+	root := &cobra.Command{Use: "rclone", Short: "Root", DisableAutoGenTag: true}
+	old := &cobra.Command{Use: "old", Short: "Old", Run: run, Annotations: deprecated}
+	parent := &cobra.Command{Use: "parent", Short: "Parent", Run: run, Annotations: deprecated}
+	parent.AddCommand(&cobra.Command{Use: "child", Short: "Child", Run: run})
+	group := &cobra.Command{Use: "group", Short: "Group"}
+	group.AddCommand(&cobra.Command{Use: "gone", Short: "Gone", Run: run, Annotations: deprecated})
+	root.AddCommand(old, parent, group, &cobra.Command{Use: "copy", Short: "Copy", Run: run})
+
+	hideDeprecated(root)
+	var paths []string
+	for _, c := range docCommands(root) {
+		paths = append(paths, c.CommandPath())
+	}
+	assert.Equal(t, []string{"rclone", "rclone copy", "rclone parent", "rclone parent child"}, paths)
+
+	doc, err := markdownDoc(root)
+	require.NoError(t, err)
+	assert.Contains(t, doc, "(/commands/rclone_parent/)")
+	assert.NotContains(t, doc, "rclone_old")
+	assert.NotContains(t, doc, "rclone_group")
+}
+
+func TestFrontmatterTemplate(t *testing.T) {
+	oldTemplate := frontmatterTemplate
+	defer func() { frontmatterTemplate = oldTemplate }()
+
+	c := &cobra.Command{Use: "copy", Short: "Copy files", Run: func(*cobra.Command, []string) {}}
+	dir := t.TempDir()
+	name := filepath.Join(dir, "frontmatter.tmpl")
+	require.NoError(t, ioutil.WriteFile(name, []byte("+++\ntitle = \"{{ .Title }}\"\nurl = \"{{ .URL }}\"\n+++\n"), 0666))
+	require.NoError(t, loadFrontmatterTemplate(name))
+	doc, err := markdownDoc(c)
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(doc, "+++\ntitle = \"copy\"\nurl = \"/commands/copy/\"\n+++\n# copy\n"), doc)
+
+	require.NoError(t, ioutil.WriteFile(name, []byte("---\ntitle: {{ .Title\n---\n"), 0666))
+	err = loadFrontmatterTemplate(name)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), name+":")
+
+	require.NoError(t, ioutil.WriteFile(name, []byte("---\ntitle: {{ .Potato }}\n---\n"), 0666))
+	err = loadFrontmatterTemplate(name)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "Potato")
+
+	assert.Error(t, loadFrontmatterTemplate(filepath.Join(dir, "missing")))
+
+	// the template isn't replaced if it fails to load
+	doc, err = markdownDoc(c)
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(doc, "+++\n"), doc)
+}
+
+func TestFlagStyleTable(t *testing.T) {
+	defer func() { pageOpt.FlagStyle = "code" }()
+
+	c := &cobra.Command{Use: "copy", Short: "Copy files", Run: func(*cobra.Command, []string) {}}
+	c.Flags().StringP("filter", "f", "a|b", "Filter to use, e.g. x|y")
+	c.Flags().Bool("fast", false, "Go fast\nand safe")
+	c.Flags().Int("secret", 0, "Hidden flag")
+	require.NoError(t, c.Flags().MarkHidden("secret"))
+
+	doc, err := commandMarkdown(c, linkHandler)
+	require.NoError(t, err)
+	assert.Contains(t, doc, "### Options\n\n```\n")
+	assert.Contains(t, doc, "Filter to use, e.g. x|y")
+
+	pageOpt.FlagStyle = "table"
+	doc, err = commandMarkdown(c, linkHandler)
+	require.NoError(t, err)
+	assert.Contains(t, doc, "### Options\n\n"+`| Flag | Shorthand | Type | Default | Help |
+|------|-----------|------|---------|------|
+| `+"`--fast`"+` |  | bool | `+"`false`"+` | Go fast and safe |
+| `+"`--filter`"+` | `+"`-f`"+` | string | `+"`a\\|b`"+` | Filter to use, e.g. x\|y |
+| `+"`--help`"+` | `+"`-h`"+` | bool | `+"`false`"+` | help for copy |
+`)
+	assert.NotContains(t, doc, "### Options\n\n```")
+	assert.NotContains(t, doc, "secret")
+}
+
+func TestFlagsDoc(t *testing.T) {
+	defer func() { pageOpt.FlagStyle = "code" }()
+
+	root := &cobra.Command{Use: "rclone"}
+	root.PersistentFlags().Bool("dry-run", false, "Do a trial run")
+	root.PersistentFlags().String("b-region", "", "Region to use")
+	root.PersistentFlags().Int("a-chunk-size", 0, "Chunk size")
+	root.PersistentFlags().Int("a-secret", 0, "Hidden flag")
+	require.NoError(t, root.PersistentFlags().MarkHidden("a-secret"))
+	help := &cobra.Command{Use: "help"}
+	help.AddCommand(&cobra.Command{Use: "flags", Run: func(*cobra.Command, []string) {}})
+	root.AddCommand(help)
+	registry := []*fs.RegInfo{{
+		Name:        "bee",
+		Description: "Bee",
+		Prefix:      "b",
+		Options:     []fs.Option{{Name: "region"}},
+	}, {
+		Name:        "aye",
+		Description: "Aye",
+		Prefix:      "a",
+		Options:     []fs.Option{{Name: "chunk_size"}, {Name: "secret"}},
+	}, {
+		Name:        "none",
+		Description: "No flags",
+		Prefix:      "none",
+		Options:     []fs.Option{{Name: "missing"}},
+	}}
+
+	doc, err := flagsDoc(root, registry)
+	require.NoError(t, err)
+	assert.Contains(t, doc, "## Non Backend Flags\n\nThese flags are available for every command.\n\n```\n      --dry-run   Do a trial run\n```\n")
+	assert.Contains(t, doc, "### aye\n\nFlags for the Aye backend.\n\n```\n      --a-chunk-size int   Chunk size\n```\n")
+	assert.Contains(t, doc, "### bee\n\nFlags for the Bee backend.\n\n```\n      --b-region string   Region to use\n```\n")
+	assert.Less(t, strings.Index(doc, "### aye"), strings.Index(doc, "### bee"))
+	assert.NotContains(t, doc, "secret")
+	assert.NotContains(t, doc, "### none")
+	assert.Equal(t, "bee", registry[0].Name)
+
+	pageOpt.FlagStyle = "table"
+	doc, err = flagsDoc(root, registry)
+	require.NoError(t, err)
+	assert.Contains(t, doc, "### bee\n\nFlags for the Bee backend.\n\n| Flag |")
+	assert.NotContains(t, doc, "```")
+}
+
+func TestCountWords(t *testing.T) {
+	doc := "## rclone copy\n" +
+		"\n" +
+		"Copy files from source to dest, see [the docs](https://rclone.org/docs/) or <https://rclone.org/>.\n" +
+		"\n" +
+		"    rclone copy source:path dest:path\n" +
+		"    rclone copy -v a: b:\n" +
+		"\n" +
+		"* one item\n" +
+		"\n" +
+		"    continues here\n" +
+		"\n" +
+		"![an image](image.png) - done\n" +
+		"\n" +
+		"[ref]: https://rclone.org/\n" +
+		"\n" +
+		"### Options\n" +
+		"\n" +
+		"```\n" +
+		"  -h, --help   help for copy\n" +
+		"```\n" +
+		"\n" +
+		"| Flag | Help |\n" +
+		"|------|------|\n" +
+		"| `--help` | help for copy |\n"
+	words := strings.Fields("rclone copy Copy files from source to dest, see the docs or one item continues here an image done Options")
+	assert.Equal(t, len(words), countWords(doc))
+	assert.Equal(t, 0, countWords(""))
+
+	assert.Equal(t, 0, readingMinutes(0))
+	assert.Equal(t, 1, readingMinutes(1))
+	assert.Equal(t, 1, readingMinutes(wordsPerMinute))
+	assert.Equal(t, 2, readingMinutes(wordsPerMinute+1))
+}
+
+func TestReadingTime(t *testing.T) {
+	oldTemplate, oldSinglePage := frontmatterTemplate, singlePageTemplate
+	defer func() {
+		frontmatterOpt.ReadingTime = false
+		frontmatterTemplate, singlePageTemplate = oldTemplate, oldSinglePage
+	}()
+
+	c := &cobra.Command{Use: "copy", Short: "Copy files", Long: "Copy the files from source to dest.\n", DisableAutoGenTag: true, Run: func(*cobra.Command, []string) {}}
+	doc, err := markdownDoc(c)
+	require.NoError(t, err)
+	assert.NotContains(t, doc, "wordCount")
+
+	frontmatterOpt.ReadingTime = true
+	doc, err = markdownDoc(c)
+	require.NoError(t, err)
+	assert.Contains(t, doc, "url: /commands/copy/\nwordCount: 12\nreadingTime: 1\n")
+
+	require.NoError(t, setFrontmatterFormat("toml"))
+	doc, err = markdownDoc(c)
+	require.NoError(t, err)
+	assert.Contains(t, doc, "url = \"/commands/copy/\"\nwordCount = 12\nreadingTime = 1\n")
+
+	assert.Error(t, checkAnnotationKeys([]string{"wordCount"}))
+}
+
+func TestExamplesAsCode(t *testing.T) {
+	for _, test := range []struct {
+		in   string
+		want string
+	}{
+		{
+			in:   "## rclone copy\n\n    rclone copy a b\n",
+			want: "## rclone copy\n\n    rclone copy a b\n",
+		},
+		{
+			in:   "### Examples\n\n```\nrclone copy a b\n```\n\n### Options\n\n```\n  -h, --help\n```\n",
+			want: "### Examples\n\n```bash\nrclone copy a b\n```\n\n### Options\n\n```\n  -h, --help\n```\n",
+		},
+		{
+			in:   "## Examples\n\nCopy a to b\n\n    rclone copy a b\n\n    rclone copy b a\n\nThen\n\n\trclone check a b\n## Next\n\n    indented\n",
+			want: "## Examples\n\nCopy a to b\n\n```bash\nrclone copy a b\n\nrclone copy b a\n```\n\nThen\n\n```bash\nrclone check a b\n```\n## Next\n\n    indented\n",
+		},
+		{
+			in:   "### Examples\n\n```sh\nrclone copy a b\n```\n",
+			want: "### Examples\n\n```sh\nrclone copy a b\n```\n",
+		},
+	} {
+		assert.Equal(t, test.want, examplesAsCode(test.in), test.in)
+	}
+
+	defer func() { pageOpt.ExamplesAsCode = false }()
+	c := &cobra.Command{Use: "copy", Short: "Copy files", Example: "rclone copy a b", Run: func(*cobra.Command, []string) {}}
+	doc, err := commandMarkdown(c, linkHandler)
+	require.NoError(t, err)
+	assert.Contains(t, doc, "### Examples\n\n```\nrclone copy a b\n```\n")
+
+	pageOpt.ExamplesAsCode = true
+	doc, err = commandMarkdown(c, linkHandler)
+	require.NoError(t, err)
+	assert.Contains(t, doc, "### Examples\n\n```bash\nrclone copy a b\n```\n")
+	assert.Contains(t, doc, "### Options\n\n```\n")
+}
+
+func TestMarkdownDocsParallel(t *testing.T) {
+	root := &cobra.Command{Use: "rclone", Short: "Root", DisableAutoGenTag: true}
+	root.PersistentFlags().String("config", "", "Config file")
+	for i := 0; i < 20; i++ {
+		c := &cobra.Command{Use: fmt.Sprintf("cmd%02d", i), Short: "Command", Run: func(*cobra.Command, []string) {}}
+		c.Flags().Int("size", i, "Size")
+		c.AddCommand(&cobra.Command{Use: "sub", Short: "Sub command", Run: func(*cobra.Command, []string) {}})
+		root.AddCommand(c)
+	}
+	commands := docCommands(root)
+
+	var want []string
+	for _, c := range commands {
+		doc, err := markdownDoc(c)
+		require.NoError(t, err)
+		want = append(want, doc)
+	}
+	for _, n := range []int{0, 1, 4, 100} {
+		got, err := markdownDocs(commands, n)
+		require.NoError(t, err)
+		assert.Equal(t, want, got, n)
+	}
+
+	// the error is for the first command which failed
+	commands[3].Annotations = map[string]string{"bad": "\xff"}
+	commands[7].Annotations = map[string]string{"bad": "\xff"}
+	frontmatterOpt.Annotations = fs.CommaSepList{"bad"}
+	defer func() { frontmatterOpt.Annotations = nil }()
+	_, err := markdownDocs(commands, 4)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `"`+commands[3].CommandPath()+`"`)
+}
+
+func TestProgressLogging(t *testing.T) {
+	ci := fs.GetConfig(context.Background())
+	oldLogLevel := ci.LogLevel
+	defer func() { ci.LogLevel = oldLogLevel }()
+	var buf bytes.Buffer
+	log.SetOutput(&buf)
+	defer log.SetOutput(os.Stderr)
+
+	root := &cobra.Command{Use: "rclone"}
+	c := &cobra.Command{Use: "copy", Short: "Copy files", Run: func(*cobra.Command, []string) {}}
+	root.AddCommand(c)
+
+	// quiet by default
+	ci.LogLevel = fs.LogLevelNotice
+	_, err := markdownDoc(c)
+	require.NoError(t, err)
+	assert.Equal(t, "", buf.String())
+
+	// each command with -v
+	ci.LogLevel = fs.LogLevelInfo
+	_, err = markdownDoc(c)
+	require.NoError(t, err)
+	assert.Contains(t, buf.String(), "rclone copy: Making docs")
+	assert.NotContains(t, buf.String(), "frontmatter")
+
+	// and each page with -vv
+	buf.Reset()
+	ci.LogLevel = fs.LogLevelDebug
+	_, err = markdownDoc(c)
+	require.NoError(t, err)
+	assert.Contains(t, buf.String(), "commands/rclone_copy.md: Adding frontmatter and heading anchors")
+}
+
+func TestBaseURL(t *testing.T) {
+	for _, test := range []struct {
+		in   string
+		want string
+	}{
+		{"", ""},
+		{"/", ""},
+		{"/rclone", "/rclone"},
+		{"/rclone/", "/rclone"},
+		{"rclone//", "/rclone"},
+		{"https://example.com/rclone/", "https://example.com/rclone"},
+		{"https://example.com/", "https://example.com"},
+	} {
+		assert.Equal(t, test.want, normalizeBaseURL(test.in), test.in)
+	}
+
+	root := &cobra.Command{Use: "rclone", Short: "Root", DisableAutoGenTag: true}
+	c := &cobra.Command{
+		Use:   "copy",
+		Short: "Copy files",
+		Long:  "See [rclone sync](/commands/rclone_sync/) and [rclone old](https://example.com/rclone/commands/rclone_old/).",
+		Run:   func(*cobra.Command, []string) {},
+	}
+	root.AddCommand(c)
+
+	pageOpt.BaseURL = normalizeBaseURL("https://example.com/rclone/")
+	defer func() { pageOpt.BaseURL = "" }()
+	doc, err := markdownDoc(c)
+	require.NoError(t, err)
+	assert.Contains(t, doc, "url: https://example.com/rclone/commands/rclone_copy/\n")
+	assert.Contains(t, doc, "* [rclone](https://example.com/rclone/commands/rclone/)")
+	assert.Contains(t, doc, "[global flags page](https://example.com/rclone/flags/)")
+
+	// links with and without the base URL are checked
+	lc := newLinkChecker(root)
+	lc.check("commands/rclone_copy.md", []byte(doc))
+	assert.Equal(t, []string{
+		"commands/rclone_copy.md: /commands/rclone_sync/",
+		"commands/rclone_copy.md: https://example.com/rclone/commands/rclone_old/",
+	}, lc.broken)
+}
+
+func TestNoEditWarning(t *testing.T) {
+	root := &cobra.Command{Use: "rclone", Short: "Root", DisableAutoGenTag: true}
+	c := &cobra.Command{Use: "copy", Short: "Copy files", Run: func(*cobra.Command, []string) {}}
+	root.AddCommand(c)
+
+	doc, err := markdownDoc(c)
+	require.NoError(t, err)
+	assert.Contains(t, doc, "url: /commands/rclone_copy/\n# autogenerated - DO NOT EDIT, instead edit the source code in cmd/copy/ and")
+	page, err := singlePageDoc(root)
+	require.NoError(t, err)
+	assert.Contains(t, page, "# autogenerated - DO NOT EDIT")
+
+	frontmatterOpt.NoEditWarning = true
+	defer func() { frontmatterOpt.NoEditWarning = false }()
+	noWarnDoc, err := markdownDoc(c)
+	require.NoError(t, err)
+	assert.NotContains(t, noWarnDoc, "autogenerated")
+	assert.Contains(t, noWarnDoc, "url: /commands/rclone_copy/\n---\n")
+	// only the warning line is removed
+	warning := doc[strings.Index(doc, "# autogenerated"):]
+	warning = warning[:strings.Index(warning, "\n")+1]
+	assert.Equal(t, strings.Replace(doc, warning, "", 1), noWarnDoc)
+	page, err = singlePageDoc(root)
+	require.NoError(t, err)
+	assert.NotContains(t, page, "autogenerated")
+
+	// custom templates can still use the source
+	oldTemplate := frontmatterTemplate
+	defer func() { frontmatterTemplate = oldTemplate }()
+	name := filepath.Join(t.TempDir(), "frontmatter.tmpl")
+	require.NoError(t, ioutil.WriteFile(name, []byte("---\nsource: {{ .Source }}\nwarn: {{ .EditWarning }}\n---\n"), 0666))
+	require.NoError(t, loadFrontmatterTemplate(name))
+	doc, err = markdownDoc(c)
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(doc, "---\nsource: cmd/copy/\nwarn: false\n---\n"), doc)
+}
+
+func TestFrontmatterFormat(t *testing.T) {
+	oldTemplate, oldSinglePage := frontmatterTemplate, singlePageTemplate
+	defer func() { frontmatterTemplate, singlePageTemplate = oldTemplate, oldSinglePage }()
+
+	root := &cobra.Command{Use: "rclone", Short: "Root"}
+	c := &cobra.Command{
+		Use:         "copy",
+		Short:       "Copy \"files\" \\ dirs\x7f",
+		Run:         func(*cobra.Command, []string) {},
+		Annotations: map[string]string{"status": "Beta"},
+	}
+	root.AddCommand(c)
+	saveAnnotations := frontmatterOpt.Annotations
+	defer func() { frontmatterOpt.Annotations = saveAnnotations }()
+	frontmatterOpt.Annotations = []string{"status"}
+
+	// the special characters are escaped in YAML
+	require.NoError(t, setFrontmatterFormat("yaml"))
+	doc, err := markdownDoc(c)
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(doc, `---
+title: "rclone copy"
+description: "Copy \"files\" \\ dirs\u007f"
+slug: rclone_copy
+url: /commands/rclone_copy/
+status: "Beta"
+`), doc)
+
+	// and in TOML
+	require.NoError(t, setFrontmatterFormat("toml"))
+	doc, err = markdownDoc(c)
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(doc, `+++
+title = "rclone copy"
+description = "Copy \"files\" \\ dirs\u007f"
+slug = "rclone_copy"
+url = "/commands/rclone_copy/"
+status = "Beta"
+# autogenerated - DO NOT EDIT, instead edit the source code in cmd/copy/ and as part of making a release run "make commanddocs"
++++
+# rclone copy
+`), doc)
+	// the headings after the frontmatter still get anchors
+	assert.Contains(t, doc, "## Options {#copy-options}\n")
+
+	page, err := singlePageDoc(root)
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(page, "+++\ntitle = \"rclone reference\"\ndescription = \"Root\"\n"), page)
+	index, err := indexDoc(root)
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(index, "+++\ntitle = \"Commands\"\n"), index)
+
+	assert.Error(t, setFrontmatterFormat("potato"))
+}
+
+func TestFrontmatterMultilineShort(t *testing.T) {
+	c := &cobra.Command{
+		Use:   "copy",
+		Short: "Copy \"files\"\n   to the  destination\n",
+		Run:   func(*cobra.Command, []string) {},
+	}
+	assert.Equal(t, `Copy "files" to the destination`, oneLine(c.Short))
+
+	doc, err := markdownDoc(c)
+	require.NoError(t, err)
+	require.True(t, strings.HasPrefix(doc, "---\n"), doc)
+	end := strings.Index(doc[4:], "\n---\n")
+	require.True(t, end >= 0, doc)
+	var values map[string]string
+	require.NoError(t, yaml.Unmarshal([]byte(doc[4:4+end]), &values))
+	assert.Equal(t, `Copy "files" to the destination`, values["description"])
+	assert.Equal(t, "copy", values["title"])
+}
+
+func TestLoadTranslations(t *testing.T) {
+	dir := t.TempDir()
+	name := filepath.Join(dir, "de.json")
+	require.NoError(t, ioutil.WriteFile(name, []byte(`{"rclone copy": {"title": "rclone kopieren", "short": "Kopiert Dateien"}}`), 0666))
+	catalog, err := loadTranslations(name)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]translation{
+		"rclone copy": {Title: "rclone kopieren", Short: "Kopiert Dateien"},
+	}, catalog)
+
+	require.NoError(t, ioutil.WriteFile(name, []byte(`["rclone copy"]`), 0666))
+	_, err = loadTranslations(name)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to parse --translations")
+	_, err = loadTranslations(filepath.Join(dir, "missing.json"))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to read --translations")
+}
+
+func TestTranslateCommands(t *testing.T) {
+	oldTitles, oldLocale := translationOpt.titles, translationOpt.Locale
+	defer func() { translationOpt.titles, translationOpt.Locale = oldTitles, oldLocale }()
+
+	root := &cobra.Command{Use: "rclone", Short: "Root"}
+	c := &cobra.Command{Use: "copy", Short: "Copy files", Long: "Copy the files.\n", Run: func(*cobra.Command, []string) {}}
+	sync := &cobra.Command{Use: "sync", Short: "Sync files", Long: "Sync the files.\n", Run: func(*cobra.Command, []string) {}}
+	root.AddCommand(c, sync)
+
+	var err error
+	translationOpt.titles, err = translateCommands(root, map[string]translation{
+		"rclone copy": {Title: "rclone kopieren", Short: "Kopiert Dateien", Long: "Kopiert die Dateien.\n"},
+		"rclone sync": {Short: "Synchronisiert Dateien"},
+		"rclone nope": {Short: "Gibt es nicht"},
+		"rclone gone": {Short: "Auch nicht"},
+	})
+	assert.EqualError(t, err, `--translations has translations for commands which don't exist: "rclone gone", "rclone nope"`)
+	assert.Equal(t, map[string]string{"rclone copy": "rclone kopieren"}, translationOpt.titles)
+	assert.Equal(t, "Kopiert Dateien", c.Short)
+	assert.Equal(t, "Synchronisiert Dateien", sync.Short)
+	// the untranslated fields are left in English
+	assert.Equal(t, "Sync the files.\n", sync.Long)
+	assert.Equal(t, "Root", root.Short)
+
+	translationOpt.Locale = "de"
+	doc, err := markdownDoc(c)
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(doc, `---
+title: "rclone kopieren"
+description: "Kopiert Dateien"
+lang: "de"
+slug: rclone_copy
+`), doc)
+	assert.Contains(t, doc, "Kopiert die Dateien.\n")
+	assert.NotContains(t, doc, "Copy")
+
+	doc, err = markdownDoc(sync)
+	require.NoError(t, err)
+	assert.Contains(t, doc, "title: \"rclone sync\"\ndescription: \"Synchronisiert Dateien\"\nlang: \"de\"\n")
+	assert.Contains(t, doc, "Sync the files.\n")
+
+	index, err := indexDoc(root)
+	require.NoError(t, err)
+	assert.Contains(t, index, "lang: \"de\"\n")
+	assert.Contains(t, index, " - Kopiert Dateien\n")
+
+	assert.Error(t, checkAnnotationKeys([]string{"lang"}))
+	assert.True(t, localeRe.MatchString("pt-BR"))
+	assert.False(t, localeRe.MatchString("../de"))
+}
+
+func TestHeadingShift(t *testing.T) {
+	root := &cobra.Command{Use: "rclone", Short: "Root"}
+	c := &cobra.Command{Use: "copy", Short: "Copy files", Run: func(*cobra.Command, []string) {}}
+	root.AddCommand(c)
+	body := "## rclone copy\n\nCopy files\n\n### Synopsis\n\n#### Deep\n\n##### Deeper\n"
+
+	defer func() { pageOpt.HeadingShift = -1 }()
+	for _, test := range []struct {
+		shift int
+		want  string
+	}{
+		{-1, "# rclone copy\n\nCopy files\n\n## Synopsis {#copy-synopsis}\n\n### Deep {#copy-deep}\n\n#### Deeper {#copy-deeper}\n"},
+		{0, "## rclone copy\n\nCopy files\n\n### Synopsis {#copy-synopsis}\n\n#### Deep {#copy-deep}\n\n##### Deeper {#copy-deeper}\n"},
+		{2, "#### rclone copy\n\nCopy files\n\n##### Synopsis {#copy-synopsis}\n\n###### Deep {#copy-deep}\n\n###### Deeper {#copy-deeper}\n"},
+		{-3, "# rclone copy\n\nCopy files\n\n# Synopsis {#copy-synopsis}\n\n# Deep {#copy-deep}\n\n## Deeper {#copy-deeper}\n"},
+	} {
+		pageOpt.HeadingShift = test.shift
+		doc, err := markdownPage(c, body, nil)
+		require.NoError(t, err)
+		i := strings.Index(doc, "\n---\n")
+		require.True(t, i >= 0)
+		assert.Equal(t, test.want, doc[i+len("\n---\n"):], "shift %d", test.shift)
+	}
+}
+
+// testNewFs is used as the NewFs of a backend in TestBackendDoc
+func testNewFs(ctx context.Context, name, root string, m configmap.Mapper) (fs.Fs, error) {
+	return nil, nil
+}
+
+func TestBackendDoc(t *testing.T) {
+	ri := &fs.RegInfo{
+		Name:        "test backend",
+		Description: "Test \"Backend\"",
+		Prefix:      "test",
+		NewFs:       testNewFs,
+		Options: []fs.Option{{
+			Name:    "region",
+			Help:    "Region to connect to.",
+			Default: "",
+			Examples: []fs.OptionExample{{
+				Value: "eu",
+				Help:  "Europe",
+			}},
+		}, {
+			Name:     "chunk_size",
+			Help:     "Chunk size.",
+			Default:  fs.SizeSuffix(1024),
+			Advanced: true,
+		}},
+	}
+	assert.Equal(t, "test.md", backendFileName(ri))
+	assert.Equal(t, "cmd/gendocs/", backendSource(ri))
+
+	doc, err := backendDoc(ri)
+	require.NoError(t, err)
+	assert.Equal(t, `---
+title: "test backend"
+description: "Test \"Backend\""
+slug: test
+url: /backends/test/
+# autogenerated - DO NOT EDIT, instead edit the source code in cmd/gendocs/ and as part of making a release run "make commanddocs"
+---
+# test backend
+
+Test "Backend"
+
+## Standard options {#test-standard-options}
+
+Here are the standard options specific to test backend (Test "Backend").
+
+### --test-region {#test-test-region}
+
+Region to connect to.
+
+- Config:      region
+- Env Var:     RCLONE_TEST_REGION
+- Type:        string
+- Default:     ""
+- Examples:
+    - "eu"
+        - Europe
+
+## Advanced options {#test-advanced-options}
+
+Here are the advanced options specific to test backend (Test "Backend").
+
+### --test-chunk-size {#test-test-chunk-size}
+
+Chunk size.
+
+- Config:      chunk_size
+- Env Var:     RCLONE_TEST_CHUNK_SIZE
+- Type:        SizeSuffix
+- Default:     1Ki
+
+`, doc)
+
+	// if the package can't be found the source is assumed to be in backend
+	ri.NewFs = nil
+	assert.Equal(t, "backend/test/", backendSource(ri))
+}
+
+func TestRcDoc(t *testing.T) {
+	root := &cobra.Command{Use: "rclone"}
+	run := func(*cobra.Command, []string) {}
+	echo := &cobra.Command{
+		Use:         "echo",
+		Short:       "Echo the input",
+		Annotations: map[string]string{"rc": "rc/noop"},
+		Run:         run,
+	}
+	echo.Flags().Bool("loud", false, "Echo loudly")
+	root.AddCommand(echo)
+	root.AddCommand(&cobra.Command{Use: "other", Short: "Not an rc call", Run: run})
+
+	endpoints, err := rcEndpoints(root)
+	require.NoError(t, err)
+	require.Equal(t, 1, len(endpoints))
+	assert.Equal(t, "rc/noop", endpoints[0].Call.Path)
+	assert.Equal(t, []*cobra.Command{echo}, endpoints[0].Commands)
+	assert.Equal(t, "rc_noop.md", rcFileName("rc/noop"))
+
+	doc, err := rcDoc(endpoints[0])
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(doc, `---
+title: "rc/noop"
+description: "Echo the input to the output parameters"
+slug: rc_noop
+url: /rc/rc_noop/
+`), doc)
+	assert.Contains(t, doc, "# rc/noop\n\nEcho the input to the output parameters\n\nThis does the same as the [rclone echo](/commands/rclone_echo/) command.\n\n## Help {#rc-noop-help}\n\nThis echoes the input parameters")
+	assert.Contains(t, doc, "| `--loud` |  | bool | `false` | Echo loudly |")
+	assert.NotContains(t, doc, "Authentication is required")
+
+	b, err := rcJSON(endpoints)
+	require.NoError(t, err)
+	var calls []rcCallJSON
+	require.NoError(t, json.Unmarshal(b, &calls))
+	require.Equal(t, 1, len(calls))
+	assert.Equal(t, "rc/noop", calls[0].Path)
+	require.Equal(t, 1, len(calls[0].Commands))
+	assert.Equal(t, "rclone echo", calls[0].Commands[0].Path)
+	var names []string
+	for _, flag := range calls[0].Commands[0].Flags {
+		names = append(names, flag.Name)
+	}
+	assert.Equal(t, []string{"loud"}, names)
+
+	// a call which doesn't exist is an error
+	root.AddCommand(&cobra.Command{
+		Use:         "missing",
+		Annotations: map[string]string{"rc": "rc/potato"},
+		Run:         run,
+	})
+	_, err = rcEndpoints(root)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `"rclone missing" has "rc/potato"`)
+}
+
+func TestIndexDoc(t *testing.T) {
+	root := &cobra.Command{Use: "rclone", Short: "Root"}
+	config := &cobra.Command{Use: "config", Short: "Config", Run: func(*cobra.Command, []string) {}}
+	create := &cobra.Command{Use: "create", Short: "Create", Run: func(*cobra.Command, []string) {}}
+	cp := &cobra.Command{Use: "copy", Run: func(*cobra.Command, []string) {}}
+	hidden := &cobra.Command{Use: "hidden", Short: "Hidden", Hidden: true, Run: func(*cobra.Command, []string) {}}
+	config.AddCommand(create)
+	root.AddCommand(config, cp, hidden)
+
+	doc, err := indexDoc(root)
+	require.NoError(t, err)
+	assert.Equal(t, `---
+title: "Commands"
+description: "An index of all the rclone commands"
+# autogenerated - DO NOT EDIT, instead edit the source code in cmd/ and as part of making a release run "make commanddocs"
+---
+# Commands
+
+* [rclone](/commands/rclone/) - Root
+  * [rclone config](/commands/rclone_config/) - Config
+    * [rclone config create](/commands/rclone_config_create/) - Create
+  * [rclone copy](/commands/rclone_copy/)
+`, doc)
+}
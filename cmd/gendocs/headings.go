@@ -0,0 +1,99 @@
+package gendocs
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+var (
+	headingRe       = regexp.MustCompile(`^(#+)\s+(.*?)\s*$`)
+	headingAnchorRe = regexp.MustCompile(`\{#[^}]*\}$`)
+	nonAnchorRe     = regexp.MustCompile(`[^a-z0-9]+`)
+)
+
+// anchorPrefix returns the prefix for the heading anchors of the docs
+// page with the base name base, e.g. "rclone_config_create" becomes
+// "config-create".
+func anchorPrefix(base string) string {
+	if base != "rclone" {
+		base = strings.TrimPrefix(base, "rclone_")
+	}
+	return strings.Replace(base, "_", "-", -1)
+}
+
+// addHeadingAnchors adds an explicit anchor made from prefix and the
+// heading text to each heading in the markdown doc after the title, so
+// the anchor stays the same even if hugo changes how it makes them,
+// e.g. "# Options" becomes "# Options {#copy-options}".
+//
+// Headings which already have an anchor and lines in the frontmatter
+// or in fenced code blocks are left alone.
+func addHeadingAnchors(doc, prefix string) string {
+	lines := strings.Split(doc, "\n")
+	inFrontmatter := len(lines) > 0 && (lines[0] == "---" || lines[0] == "+++")
+	inCode := false
+	seenTitle := false
+	used := map[string]int{}
+	for i, line := range lines {
+		switch {
+		case inFrontmatter:
+			if i > 0 && line == lines[0] {
+				inFrontmatter = false
+			}
+			continue
+		case strings.HasPrefix(line, "```"):
+			inCode = !inCode
+			continue
+		case inCode:
+			continue
+		}
+		match := headingRe.FindStringSubmatch(line)
+		if match == nil || headingAnchorRe.MatchString(match[2]) {
+			continue
+		}
+		if !seenTitle {
+			seenTitle = true
+			continue
+		}
+		anchor := strings.Trim(nonAnchorRe.ReplaceAllString(strings.ToLower(match[2]), "-"), "-")
+		anchor = prefix + "-" + anchor
+		if n := used[anchor]; n > 0 {
+			used[anchor]++
+			anchor = fmt.Sprintf("%s-%d", anchor, n)
+		} else {
+			used[anchor] = 1
+		}
+		lines[i] = fmt.Sprintf("%s %s {#%s}", match[1], match[2], anchor)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// shiftHeadings moves the level of each heading in the markdown doc by
+// delta, keeping it between 1 and 6. Lines in fenced code blocks are
+// left alone.
+func shiftHeadings(doc string, delta int) string {
+	lines := strings.Split(doc, "\n")
+	inCode := false
+	for i, line := range lines {
+		if strings.HasPrefix(line, "```") {
+			inCode = !inCode
+			continue
+		}
+		if inCode {
+			continue
+		}
+		match := headingRe.FindStringSubmatch(line)
+		if match == nil {
+			continue
+		}
+		level := len(match[1]) + delta
+		if level < 1 {
+			level = 1
+		} else if level > 6 {
+			level = 6
+		}
+		lines[i] = strings.Repeat("#", level) + " " + match[2]
+	}
+	return strings.Join(lines, "\n")
+}
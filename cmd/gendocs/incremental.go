@@ -0,0 +1,187 @@
+package gendocs
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/rclone/rclone/cmd"
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fs/config/flags"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// incrementalOptions are the options which look at the source of the
+// commands in the --source-root
+type incrementalOptions struct {
+	Incremental  bool   // only write the docs of the commands whose source is newer
+	VerifySource bool   // fail if the source directory of any command doesn't exist
+	SourceRoot   string // root of the source code
+}
+
+// incrementalOpt are the options set with the flags
+var incrementalOpt = incrementalOptions{
+	SourceRoot: ".",
+}
+
+// addFlags adds the flags for opt to flagSet
+func (opt *incrementalOptions) addFlags(flagSet *pflag.FlagSet) {
+	flags.BoolVarP(flagSet, &opt.VerifySource, "verify-source", "", opt.VerifySource, "Fail if the source directory of any command in the frontmatter doesn't exist")
+	flags.StringVarP(flagSet, &opt.SourceRoot, "source-root", "", opt.SourceRoot, "Root of the source code the directories are checked in by --verify-source and --incremental")
+	flags.BoolVarP(flagSet, &opt.Incremental, "incremental", "", opt.Incremental, "Only write the docs of the commands whose source is newer than their docs")
+}
+
+// check checks --incremental can be used with the outputs written
+func (opt *incrementalOptions) check(writeMarkdown bool) error {
+	if !opt.Incremental {
+		return nil
+	}
+	if !writeMarkdown || pageOpt.SinglePage != "" {
+		return errors.New("can't use --incremental without the markdown docs for each command")
+	}
+	if writerOpt.Manifest != "" || writerOpt.Checksum != "" {
+		return errors.New("can't use --incremental with --manifest or --checksum-file as they need all the docs to be written")
+	}
+	return nil
+}
+
+// commandSource returns the directory the source of c is expected to
+// be in, relative to the root of the source, as put in the frontmatter
+func commandSource(c *cobra.Command) string {
+	name := commandFileName(c)
+	base := strings.TrimSuffix(name, path.Ext(name))
+	return strings.Replace(strings.Replace(base, "rclone", "cmd", -1), "_", "/", -1) + "/"
+}
+
+// checkSources returns an error listing the command paths of the
+// commands whose source directory isn't a directory in root
+func checkSources(commands []*cobra.Command, root string) error {
+	var missing []string
+	for _, c := range commands {
+		fi, err := os.Stat(filepath.Join(root, filepath.FromSlash(commandSource(c))))
+		if err != nil || !fi.IsDir() {
+			missing = append(missing, fmt.Sprintf("%s (%s)", c.CommandPath(), commandSource(c)))
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("commands with no source directory in %q: %s", root, strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+// flagSources are the directories of the source of the global flags
+// shown on the flags page, relative to the root of the source, apart
+// from the backends
+var flagSources = []string{
+	"cmd/",
+	"fs/",
+	"fs/config/configflags/",
+	"fs/filter/",
+	"fs/filter/filterflags/",
+	"fs/log/",
+	"fs/log/logflags/",
+	"fs/rc/",
+	"fs/rc/rcflags/",
+}
+
+// sourceModTime returns the newest modification time of the Go files,
+// apart from the tests, in the directories dirs relative to root.
+//
+// It returns false if any of the directories has no Go files, as then
+// the source can't be found.
+func sourceModTime(root string, dirs []string) (newest time.Time, ok bool) {
+	for _, dir := range dirs {
+		entries, err := ioutil.ReadDir(filepath.Join(root, filepath.FromSlash(dir)))
+		if err != nil {
+			return newest, false
+		}
+		found := false
+		for _, entry := range entries {
+			name := entry.Name()
+			if entry.IsDir() || path.Ext(name) != ".go" || strings.HasSuffix(name, "_test.go") {
+				continue
+			}
+			found = true
+			if entry.ModTime().After(newest) {
+				newest = entry.ModTime()
+			}
+		}
+		if !found {
+			return newest, false
+		}
+	}
+	return newest, true
+}
+
+// commandSources returns the source directories of c and the commands
+// whose short descriptions are on its page, or false if they aren't
+// known because c gets values for its frontmatter from elsewhere
+func commandSources(c *cobra.Command) ([]string, bool) {
+	if cmd.Frontmatter(c) != nil {
+		return nil, false
+	}
+	dirs := []string{commandSource(c)}
+	if c.HasParent() {
+		dirs = append(dirs, commandSource(c.Parent()))
+	}
+	for _, child := range c.Commands() {
+		dirs = append(dirs, commandSource(child))
+	}
+	return dirs, true
+}
+
+// upToDate returns true if the file name relative to the docs
+// directory docsRoot exists and was modified no earlier than the
+// source in the directories dirs relative to --source-root. If the
+// source can't be found it returns false.
+func upToDate(docsRoot, name string, dirs []string) bool {
+	newest, ok := sourceModTime(incrementalOpt.SourceRoot, dirs)
+	if !ok {
+		return false
+	}
+	if translationOpt.Translations != "" {
+		// the docs need writing again if the translations changed
+		fi, err := os.Stat(translationOpt.Translations)
+		if err != nil {
+			return false
+		}
+		if fi.ModTime().After(newest) {
+			newest = fi.ModTime()
+		}
+	}
+	fi, err := os.Stat(filepath.Join(docsRoot, filepath.FromSlash(name)))
+	if err != nil {
+		return false
+	}
+	return !fi.ModTime().Before(newest)
+}
+
+// changedCommands returns the commands whose docs aren't up to date
+// with their source in the docs directory root
+func changedCommands(root string, commands []*cobra.Command) (changed []*cobra.Command) {
+	for _, c := range commands {
+		dirs, ok := commandSources(c)
+		if ok && upToDate(root, path.Join(pageOpt.SectionName, commandFileName(c)), dirs) {
+			fs.Debugf(c.CommandPath(), "Skipping as docs are newer than the source")
+			continue
+		}
+		changed = append(changed, c)
+	}
+	return changed
+}
+
+// flagsUpToDate returns true if the flags page in the docs directory
+// root is up to date with the source of the global flags
+func flagsUpToDate(root string) bool {
+	dirs := append([]string(nil), flagSources...)
+	for _, ri := range fs.Registry {
+		dirs = append(dirs, backendSource(ri))
+	}
+	return upToDate(root, "flags.md", dirs)
+}
@@ -0,0 +1,61 @@
+package gendocs
+
+import (
+	"bytes"
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/rclone/rclone/cmd"
+	"github.com/rclone/rclone/fs"
+	"github.com/spf13/cobra"
+)
+
+// indexDoc returns the index page of top and all the commands below
+// it as a bulleted list with the commands below each command nested
+// under it.
+func indexDoc(top *cobra.Command) (string, error) {
+	var buf bytes.Buffer
+	err := singlePageTemplate.Execute(&buf, frontmatter{
+		Title:       "Commands",
+		Description: "An index of all the " + top.CommandPath() + " commands",
+		Date:        frontmatterOpt.parsedDate,
+		EditWarning: !frontmatterOpt.NoEditWarning,
+		Locale:      translationOpt.Locale,
+	})
+	if err != nil {
+		return "", pageError(path.Join(pageOpt.SectionName, pageOpt.IndexName), top, fmt.Errorf("failed to render frontmatter template: %w", err))
+	}
+	buf.WriteString("# Commands\n\n")
+	topDepth := strings.Count(top.CommandPath(), " ")
+	for _, c := range docCommands(top) {
+		depth := strings.Count(c.CommandPath(), " ") - topDepth
+		fmt.Fprintf(&buf, "%s* [%s](%s)", strings.Repeat("  ", depth), c.CommandPath(), linkHandler(commandFileName(c)))
+		if c.Short != "" {
+			fmt.Fprintf(&buf, " - %s", c.Short)
+		}
+		buf.WriteString("\n")
+	}
+	return buf.String(), nil
+}
+
+// writeIndex writes the index page of the commands unless --no-index
+// is set or only some commands are wanted
+//
+// With --incremental it isn't written if it is there already and
+// changed is false as no command docs were written.
+func (g *generator) writeIndex(changed bool) error {
+	if pageOpt.NoIndex || g.top != cmd.Root {
+		return nil
+	}
+	name := path.Join(pageOpt.SectionName, pageOpt.IndexName)
+	if incrementalOpt.Incremental && !changed && upToDate(g.w.root, name, nil) {
+		fs.Debugf(name, "Skipping as no command docs have changed")
+		return nil
+	}
+	doc, err := indexDoc(g.top)
+	if err != nil {
+		return err
+	}
+	return g.writeDoc(name, doc)
+}
@@ -0,0 +1,137 @@
+package gendocs
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// flagJSON describes a flag in the JSON command metadata
+//
+// The fields are in alphabetical order so the output is sorted
+type flagJSON struct {
+	Default   string `json:"default"`
+	Help      string `json:"help"`
+	Name      string `json:"name"`
+	Shorthand string `json:"shorthand"`
+	Type      string `json:"type"`
+}
+
+// commandJSON describes a command in the JSON command metadata
+//
+// The fields are in alphabetical order so the output is sorted
+type commandJSON struct {
+	Aliases     []string          `json:"aliases"`
+	Annotations map[string]string `json:"annotations"`
+	Flags       []flagJSON        `json:"flags"`
+	Long        string            `json:"long"`
+	Path        string            `json:"path"`
+	Short       string            `json:"short"`
+}
+
+// commandsJSON returns the metadata for root and all the commands
+// below it as JSON. The commands are sorted by command path and the
+// flags of each command by name so the output is deterministic.
+func commandsJSON(root *cobra.Command) ([]byte, error) {
+	var commands = []commandJSON{}
+	for _, c := range docCommands(root) {
+		item := commandJSON{
+			Aliases:     c.Aliases,
+			Annotations: c.Annotations,
+			Long:        c.Long,
+			Path:        c.CommandPath(),
+			Short:       c.Short,
+		}
+		if item.Aliases == nil {
+			item.Aliases = []string{}
+		}
+		if item.Annotations == nil {
+			item.Annotations = map[string]string{}
+		}
+		item.Flags = flagsJSON(c.NonInheritedFlags())
+		commands = append(commands, item)
+	}
+	out, err := json.MarshalIndent(commands, "", "\t")
+	if err != nil {
+		return nil, err
+	}
+	return append(out, '\n'), nil
+}
+
+// flagsJSON returns the visible flags in flagSet for the JSON
+// metadata, sorted by name
+func flagsJSON(flagSet *pflag.FlagSet) []flagJSON {
+	out := []flagJSON{}
+	flagSet.VisitAll(func(flag *pflag.Flag) {
+		if flag.Hidden {
+			return
+		}
+		out = append(out, flagJSON{
+			Default:   flag.DefValue,
+			Help:      flag.Usage,
+			Name:      flag.Name,
+			Shorthand: flag.Shorthand,
+			Type:      flag.Value.Type(),
+		})
+	})
+	return out
+}
+
+// writeJSONFiles writes the metadata of the commands to commands.json
+// and with --rc-docs the metadata of the rc calls to rc.json
+func (g *generator) writeJSONFiles() error {
+	b, err := commandsJSON(g.top)
+	if err != nil {
+		return fmt.Errorf("failed to make command metadata: %w", err)
+	}
+	err = g.w.writeFile("commands.json", b)
+	if err != nil {
+		return err
+	}
+	if rcOpt.Enabled {
+		b, err = rcJSON(g.endpoints)
+		if err != nil {
+			return fmt.Errorf("failed to make rc call metadata: %w", err)
+		}
+		err = g.w.writeFile("rc.json", b)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// completionsDump returns a line for each flag of root and of each
+// command below it with the command path, the flag and its type
+// separated by tabs, e.g. "rclone copy\t--dry-run\tbool".
+//
+// The flags include the global flags the commands inherit. Flags with
+// a shorthand get a line for that too. The lines are sorted by command
+// path and then by flag so the output is deterministic.
+func completionsDump(root *cobra.Command) []byte {
+	var buf bytes.Buffer
+	for _, c := range docCommands(root) {
+		var lines []string
+		addFlag := func(flag *pflag.Flag) {
+			if flag.Hidden {
+				return
+			}
+			lines = append(lines, c.CommandPath()+"\t--"+flag.Name+"\t"+flag.Value.Type())
+			if flag.Shorthand != "" {
+				lines = append(lines, c.CommandPath()+"\t-"+flag.Shorthand+"\t"+flag.Value.Type())
+			}
+		}
+		c.NonInheritedFlags().VisitAll(addFlag)
+		c.InheritedFlags().VisitAll(addFlag)
+		sort.Strings(lines)
+		for _, line := range lines {
+			buf.WriteString(line)
+			buf.WriteByte('\n')
+		}
+	}
+	return buf.Bytes()
+}
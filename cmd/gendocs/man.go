@@ -0,0 +1,96 @@
+package gendocs
+
+import (
+	"bytes"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fs/config/flags"
+	"github.com/spf13/cobra"
+	"github.com/spf13/cobra/doc"
+	"github.com/spf13/pflag"
+)
+
+// manOptions are the options for the man pages
+type manOptions struct {
+	Man     bool // write man pages as well as the markdown docs
+	ManOnly bool // write man pages instead of the markdown docs
+}
+
+// manOpt are the options set with the flags
+var manOpt manOptions
+
+// addFlags adds the flags for opt to flagSet
+func (opt *manOptions) addFlags(flagSet *pflag.FlagSet) {
+	flags.BoolVarP(flagSet, &opt.Man, "man", "", opt.Man, "Write man pages for the commands to the man directory too")
+	flags.BoolVarP(flagSet, &opt.ManOnly, "man-only", "", opt.ManOnly, "Write man pages for the commands instead of the markdown docs")
+}
+
+// setup sets Man if ManOnly is set and returns whether the markdown
+// docs are still written, given that writeMarkdown says whether they
+// were asked for
+func (opt *manOptions) setup(writeMarkdown bool) bool {
+	if opt.ManOnly {
+		opt.Man = true
+		return false
+	}
+	return writeMarkdown
+}
+
+// writeManPages writes a man page for each command to the man
+// directory
+func (g *generator) writeManPages() error {
+	commands := docCommands(g.top)
+	if !g.writeMarkdown {
+		g.commandCount = len(commands)
+	}
+	for _, c := range commands {
+		page, err := manPage(c)
+		if err != nil {
+			return err
+		}
+		err = g.w.writeFile(path.Join("man", manFileName(c)), page)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// manFileName returns the name of the man page for c, e.g.
+// "rclone-config-create.1"
+func manFileName(c *cobra.Command) string {
+	return strings.Replace(c.CommandPath(), " ", "-", -1) + ".1"
+}
+
+// manPage returns the section 1 man page for c
+//
+// The version in the footer is taken from the versionIntroduced
+// annotation of c if it has one, otherwise the rclone version is used.
+func manPage(c *cobra.Command) ([]byte, error) {
+	fs.Infof(c.CommandPath(), "Making man page")
+	version := c.Annotations["versionIntroduced"]
+	if version == "" {
+		version = fs.Version
+	}
+	header := &doc.GenManHeader{
+		Section: "1",
+		Source:  "rclone " + version,
+		Manual:  "User Commands",
+	}
+	if frontmatterOpt.parsedDate != "" {
+		t, err := time.Parse(time.RFC3339, frontmatterOpt.parsedDate)
+		if err != nil {
+			return nil, pageError(path.Join("man", manFileName(c)), c, err)
+		}
+		header.Date = &t
+	}
+	var buf bytes.Buffer
+	err := doc.GenMan(c, header, &buf)
+	if err != nil {
+		return nil, pageError(path.Join("man", manFileName(c)), c, err)
+	}
+	return buf.Bytes(), nil
+}
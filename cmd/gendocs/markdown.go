@@ -0,0 +1,347 @@
+package gendocs
+
+import (
+	"bytes"
+	"fmt"
+	"path"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strings"
+	"sync"
+
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fs/config/flags"
+	"github.com/spf13/cobra"
+	"github.com/spf13/cobra/doc"
+	"github.com/spf13/pflag"
+)
+
+// pageOptions are the options for the markdown pages of the commands
+type pageOptions struct {
+	SectionName    string // directory the command docs are written to and linked under
+	BaseURL        string // URL or path the docs are served under
+	SinglePage     string // file to write the docs of all the commands to instead
+	IndexName      string // name of the index page of the commands
+	NoIndex        bool   // don't write the index page
+	HeadingShift   int    // number of levels to move the headings by
+	FlagStyle      string // style of the Options sections: code or table
+	ExamplesAsCode bool   // put the examples in bash code blocks
+	Parallel       int    // number of docs to make at once or 0 for the number of CPUs
+}
+
+// pageOpt are the options set with the flags
+var pageOpt = pageOptions{
+	SectionName:  "commands",
+	IndexName:    "_index.md",
+	HeadingShift: -1,
+	FlagStyle:    "code",
+}
+
+// addFlags adds the flags for opt to flagSet
+func (opt *pageOptions) addFlags(flagSet *pflag.FlagSet) {
+	flags.StringVarP(flagSet, &opt.SinglePage, "single-page", "", opt.SinglePage, "Write the docs for all the commands to this one file instead of one file per command")
+	flags.StringVarP(flagSet, &opt.IndexName, "index-name", "", opt.IndexName, "Name of the index page of the commands written to the commands directory")
+	flags.BoolVarP(flagSet, &opt.NoIndex, "no-index", "", opt.NoIndex, "Don't write the index page of the commands")
+	flags.StringVarP(flagSet, &opt.SectionName, "section-name", "", opt.SectionName, "Name of the directory the command docs are written to and linked under")
+	flags.StringVarP(flagSet, &opt.BaseURL, "base-url", "", opt.BaseURL, "URL or path the docs are served under to put before the links made to them")
+	flags.IntVarP(flagSet, &opt.HeadingShift, "heading-shift", "", opt.HeadingShift, "Number of levels to move the headings of each command's docs by, negative to outdent them")
+	flags.StringVarP(flagSet, &opt.FlagStyle, "flag-style", "", opt.FlagStyle, "Style of the Options sections of the command docs: code or table")
+	flags.BoolVarP(flagSet, &opt.ExamplesAsCode, "examples-as-code", "", opt.ExamplesAsCode, "Put the examples of each command's docs in bash code blocks")
+	flags.IntVarP(flagSet, &opt.Parallel, "parallel", "", opt.Parallel, "Number of command docs to make at once (0 for the number of CPUs)")
+}
+
+// setup checks the options and normalizes the --base-url
+func (opt *pageOptions) setup() error {
+	err := checkSectionName(opt.SectionName)
+	if err != nil {
+		return err
+	}
+	if opt.SinglePage != "" && !insideDocs(filepath.ToSlash(opt.SinglePage)) {
+		return fmt.Errorf("invalid --single-page %q: must be a relative path inside the output directory", opt.SinglePage)
+	}
+	opt.BaseURL = normalizeBaseURL(opt.BaseURL)
+	if !opt.NoIndex && (opt.IndexName == "" || opt.IndexName != path.Base(opt.IndexName) || path.Ext(opt.IndexName) != ".md") {
+		return fmt.Errorf("invalid --index-name %q: must be a file name ending in .md", opt.IndexName)
+	}
+	switch opt.FlagStyle {
+	case "code", "table":
+	default:
+		return fmt.Errorf("unknown --flag-style %q: must be code or table", opt.FlagStyle)
+	}
+	return nil
+}
+
+// writeCommandDocs writes the markdown docs of the commands to the
+// commands directory, only the ones whose source has changed with
+// --incremental, and then the index page
+func (g *generator) writeCommandDocs() error {
+	commands := docCommands(g.top)
+	if incrementalOpt.Incremental {
+		commands = changedCommands(g.w.root, commands)
+	}
+	g.commandCount = len(commands)
+	docs, err := markdownDocs(commands, pageOpt.Parallel)
+	if err != nil {
+		return err
+	}
+	for i, c := range commands {
+		err = g.writeDoc(path.Join(pageOpt.SectionName, commandFileName(c)), docs[i])
+		if err != nil {
+			return err
+		}
+	}
+	return g.writeIndex(len(commands) > 0)
+}
+
+// checkSectionName checks the section name is a relative slash
+// separated path which stays inside the docs directory
+func checkSectionName(section string) error {
+	if insideDocs(section) {
+		return nil
+	}
+	return fmt.Errorf("invalid --section-name %q: must be a relative path like \"commands\"", section)
+}
+
+// normalizeBaseURL returns the --base-url base without any trailing
+// slashes and with a leading slash if it is a path, so it can be put
+// straight before the root-absolute links, e.g. "rclone/" becomes
+// "/rclone" and "/" becomes "".
+func normalizeBaseURL(base string) string {
+	base = strings.TrimRight(base, "/")
+	if base != "" && !strings.HasPrefix(base, "/") && !strings.Contains(base, "://") {
+		base = "/" + base
+	}
+	return base
+}
+
+// commandURL returns the URL of the docs page with the base name given
+// in the --section-name under the --base-url
+func commandURL(base string) string {
+	return pageOpt.BaseURL + "/" + pageOpt.SectionName + "/" + strings.ToLower(base) + "/"
+}
+
+// linkHandler returns the URL of the docs page for the docs file name
+func linkHandler(name string) string {
+	base := strings.TrimSuffix(name, path.Ext(name))
+	return commandURL(base)
+}
+
+// markdownDoc returns the markdown docs page for c including the
+// frontmatter
+func markdownDoc(c *cobra.Command) (string, error) {
+	body, err := commandMarkdown(c, linkHandler)
+	return markdownPage(c, body, err)
+}
+
+// markdownPage returns the markdown docs page for c made from body,
+// its markdown docs from commandMarkdown, or bodyErr if that failed
+//
+// This only reads c so it can be used on many commands at once.
+func markdownPage(c *cobra.Command, body string, bodyErr error) (string, error) {
+	name := commandFileName(c)
+	file := path.Join(pageOpt.SectionName, name)
+	base := strings.TrimSuffix(name, path.Ext(name))
+	data := frontmatter{
+		Date:        frontmatterOpt.parsedDate,
+		Title:       strings.Replace(base, "_", " ", -1),
+		Description: oneLine(c.Short),
+		Slug:        base,
+		URL:         commandURL(base),
+		Source:      commandSource(c),
+		EditWarning: !frontmatterOpt.NoEditWarning,
+		Locale:      translationOpt.Locale,
+	}
+	if title := translationOpt.titles[c.CommandPath()]; title != "" {
+		data.Title = title
+	}
+	if frontmatterOpt.ReadingTime && bodyErr == nil {
+		data.WordCount = countWords(body)
+		data.ReadingTime = readingMinutes(data.WordCount)
+	}
+	var err error
+	data.Annotations, err = frontmatterAnnotations(c, frontmatterOpt.Annotations)
+	if err != nil {
+		return "", pageError(file, c, err)
+	}
+	var buf bytes.Buffer
+	err = frontmatterTemplate.Execute(&buf, data)
+	if err != nil {
+		return "", pageError(file, c, fmt.Errorf("failed to render frontmatter template: %w", err))
+	}
+	badge, err := versionBadge(c)
+	if err != nil {
+		return "", pageError(file, c, err)
+	}
+	if bodyErr != nil {
+		return "", pageError(file, c, bodyErr)
+	}
+	fs.Debugf(file, "Adding frontmatter and heading anchors")
+	// move the headings to the level wanted, by default outdenting
+	// them by one so the sections are level 2 below the title
+	body = shiftHeadings(body, pageOpt.HeadingShift)
+	doc := buf.String() + badge + body
+	// give the sections anchors which don't change
+	doc = addHeadingAnchors(doc, anchorPrefix(base))
+	return doc, nil
+}
+
+// markdownDocs returns the markdown docs pages for commands, in the
+// same order, as made by markdownDoc.
+//
+// The markdown from cobra is made one command at a time as cobra
+// changes the commands as it goes. The pages are then made from it
+// by up to parallel at once, or one per CPU if parallel is 0.
+//
+// If any fail then the error for the first command in commands which
+// failed is returned, as when making them one at a time.
+func markdownDocs(commands []*cobra.Command, parallel int) ([]string, error) {
+	if parallel <= 0 {
+		parallel = runtime.GOMAXPROCS(0)
+	}
+	bodies := make([]string, len(commands))
+	errs := make([]error, len(commands))
+	for i, c := range commands {
+		bodies[i], errs[i] = commandMarkdown(c, linkHandler)
+	}
+	docs := make([]string, len(commands))
+	todo := make(chan int)
+	var wg sync.WaitGroup
+	for i := 0; i < parallel; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range todo {
+				docs[i], errs[i] = markdownPage(commands[i], bodies[i], errs[i])
+			}
+		}()
+	}
+	for i := range commands {
+		todo <- i
+	}
+	close(todo)
+	wg.Wait()
+	for _, err := range errs {
+		if err != nil {
+			return nil, err
+		}
+	}
+	return docs, nil
+}
+
+// commandMarkdown returns the markdown docs for c without any
+// frontmatter, with the title at level 2
+func commandMarkdown(c *cobra.Command, linkHandler func(string) string) (string, error) {
+	fs.Infof(c.CommandPath(), "Making docs")
+	var buf bytes.Buffer
+	err := doc.GenMarkdownCustom(c, &buf, linkHandler)
+	if err != nil {
+		return "", err
+	}
+	body := buf.String()
+	if pageOpt.FlagStyle == "table" {
+		body = replaceOptions(body, "### Options", flagTable(c.NonInheritedFlags()))
+		body = replaceOptions(body, "### Options inherited from parent commands", flagTable(c.InheritedFlags()))
+	}
+	if pageOpt.ExamplesAsCode {
+		body = examplesAsCode(body)
+	}
+	if seeAlsoTemplate != nil {
+		body, err = renderSeeAlso(c, body)
+		if err != nil {
+			return "", err
+		}
+	}
+	// add a link to the global flags page
+	return strings.Replace(body, "\n### SEE ALSO", `
+See the [global flags page](`+pageOpt.BaseURL+`/flags/) for global options not listed here.
+
+### SEE ALSO`, 1), nil
+}
+
+// replaceOptions replaces the code block after the heading in the
+// markdown doc with table
+func replaceOptions(doc, heading, table string) string {
+	start := strings.Index(doc, "\n"+heading+"\n\n```\n")
+	if start < 0 {
+		return doc
+	}
+	start += len("\n" + heading + "\n\n")
+	end := strings.Index(doc[start+len("```\n"):], "```\n")
+	if end < 0 {
+		return doc
+	}
+	end += start + 2*len("```\n")
+	return doc[:start] + table + doc[end:]
+}
+
+// examplesHeadingRe matches the heading of the Examples section of
+// the docs of a command, either from cobra or from its help
+var examplesHeadingRe = regexp.MustCompile(`^#{2,3}\s+Examples\s*$`)
+
+// examplesAsCode puts the examples in the Examples sections of the
+// markdown doc in bash code blocks.
+//
+// The code blocks without a language in the section are given bash
+// and the indented lines are put in a code block without the indent.
+// Blank lines between indented lines are kept in the code block.
+func examplesAsCode(doc string) string {
+	lines := strings.Split(doc, "\n")
+	out := make([]string, 0, len(lines))
+	inExamples, inCode := false, false
+	var indented []string
+	flush := func() {
+		if len(indented) == 0 {
+			return
+		}
+		// blank lines at the end stay outside the code block
+		end := len(indented)
+		for end > 0 && strings.TrimSpace(indented[end-1]) == "" {
+			end--
+		}
+		out = append(out, "```bash")
+		for _, line := range indented[:end] {
+			if strings.HasPrefix(line, "\t") {
+				line = line[1:]
+			} else {
+				line = strings.TrimPrefix(line, "    ")
+			}
+			out = append(out, line)
+		}
+		out = append(out, "```")
+		out = append(out, indented[end:]...)
+		indented = nil
+	}
+	for _, line := range lines {
+		if strings.HasPrefix(line, "```") {
+			flush()
+			if !inCode && inExamples && line == "```" {
+				line = "```bash"
+			}
+			inCode = !inCode
+			out = append(out, line)
+			continue
+		}
+		if inCode {
+			out = append(out, line)
+			continue
+		}
+		if headingRe.MatchString(line) {
+			flush()
+			inExamples = examplesHeadingRe.MatchString(line)
+			out = append(out, line)
+			continue
+		}
+		if inExamples {
+			isIndented := strings.HasPrefix(line, "    ") || strings.HasPrefix(line, "\t")
+			if isIndented || (len(indented) > 0 && strings.TrimSpace(line) == "") {
+				indented = append(indented, line)
+				continue
+			}
+			flush()
+		}
+		out = append(out, line)
+	}
+	flush()
+	return strings.Join(out, "\n")
+}
@@ -0,0 +1,205 @@
+package gendocs
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/rclone/rclone/fs/config/flags"
+	"github.com/rclone/rclone/fs/rc"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// rcOptions are the options for the rc call docs
+type rcOptions struct {
+	Enabled bool // write a page for each rc call named by a command
+}
+
+// rcOpt are the options set with the flags
+var rcOpt rcOptions
+
+// addFlags adds the flags for opt to flagSet
+func (opt *rcOptions) addFlags(flagSet *pflag.FlagSet) {
+	flags.BoolVarP(flagSet, &opt.Enabled, "rc-docs", "", opt.Enabled, "Write a page for each rc call named in the rc annotation of a command to the rc directory too")
+}
+
+// writeRcDocs writes a page for each of the rc calls to the rc
+// directory
+func (g *generator) writeRcDocs() error {
+	err := g.w.mkdir(rcDir)
+	if err != nil {
+		return err
+	}
+	for _, e := range g.endpoints {
+		doc, err := rcDoc(e)
+		if err != nil {
+			return err
+		}
+		err = g.writeDoc(path.Join(rcDir, rcFileName(e.Call.Path)), doc)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// rcDir is the directory the rc call docs are written to with --rc-docs
+const rcDir = "rc"
+
+// rcBaseName returns the base name of the docs file for the rc call
+// with path callPath, e.g. "operations_about"
+func rcBaseName(callPath string) string {
+	return strings.Replace(callPath, "/", "_", -1)
+}
+
+// rcFileName returns the name of the docs file for the rc call with
+// path callPath, e.g. "operations_about.md"
+func rcFileName(callPath string) string {
+	return rcBaseName(callPath) + ".md"
+}
+
+// rcURL returns the URL of the docs page for the rc call with path
+// callPath
+func rcURL(callPath string) string {
+	return pageOpt.BaseURL + "/" + rcDir + "/" + strings.ToLower(rcBaseName(callPath)) + "/"
+}
+
+// rcEndpoint is an rc call with the commands which do the same thing
+type rcEndpoint struct {
+	Call     *rc.Call
+	Commands []*cobra.Command // sorted by command path
+}
+
+// rcEndpoints returns the rc calls named in the rc annotation of top
+// and the commands below it which have docs, with the commands for
+// each, sorted by the path of the call.
+//
+// It returns an error if any command names a call which doesn't exist.
+func rcEndpoints(top *cobra.Command) (endpoints []rcEndpoint, err error) {
+	byPath := map[string]*rcEndpoint{}
+	var unknown []string
+	for _, c := range docCommands(top) {
+		callPath := c.Annotations["rc"]
+		if callPath == "" {
+			continue
+		}
+		call := rc.Calls.Get(callPath)
+		if call == nil {
+			unknown = append(unknown, fmt.Sprintf("%q has %q", c.CommandPath(), callPath))
+			continue
+		}
+		e := byPath[callPath]
+		if e == nil {
+			e = &rcEndpoint{Call: call}
+			byPath[callPath] = e
+		}
+		e.Commands = append(e.Commands, c)
+	}
+	if len(unknown) > 0 {
+		return nil, fmt.Errorf("commands have an rc annotation which isn't an rc call: %s", strings.Join(unknown, ", "))
+	}
+	for _, e := range byPath {
+		endpoints = append(endpoints, *e)
+	}
+	sort.Slice(endpoints, func(i, j int) bool {
+		return endpoints[i].Call.Path < endpoints[j].Call.Path
+	})
+	return endpoints, nil
+}
+
+// rcDoc returns the markdown docs page for the rc call of e with its
+// help, as shown by "rclone rc --help" for the call, and the flags of
+// the commands which do the same thing, with the same frontmatter as
+// the command docs.
+func rcDoc(e rcEndpoint) (string, error) {
+	call := e.Call
+	name := path.Join(rcDir, rcFileName(call.Path))
+	var buf bytes.Buffer
+	err := frontmatterTemplate.Execute(&buf, frontmatter{
+		Date:        frontmatterOpt.parsedDate,
+		Title:       call.Path,
+		Description: oneLine(call.Title),
+		Slug:        rcBaseName(call.Path),
+		URL:         rcURL(call.Path),
+		Source:      commandSource(e.Commands[0]),
+		EditWarning: !frontmatterOpt.NoEditWarning,
+		Locale:      translationOpt.Locale,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to make %s for rc call %q: failed to render frontmatter template: %w", name, call.Path, err)
+	}
+	fmt.Fprintf(&buf, "# %s\n\n%s\n\n", call.Path, call.Title)
+	if call.AuthRequired {
+		buf.WriteString("Authentication is required for this call.\n\n")
+	}
+	var links []string
+	for _, c := range e.Commands {
+		links = append(links, fmt.Sprintf("[%s](%s)", c.CommandPath(), linkHandler(commandFileName(c))))
+	}
+	if len(links) == 1 {
+		fmt.Fprintf(&buf, "This does the same as the %s command.\n\n", links[0])
+	} else {
+		fmt.Fprintf(&buf, "This does the same as the commands %s.\n\n", strings.Join(links, ", "))
+	}
+	fmt.Fprintf(&buf, "## Help\n\n%s\n\n", strings.TrimSpace(call.Help))
+	buf.WriteString("## Command flags\n\n")
+	buf.WriteString("These are the flags of the command, which the parameters of the call may not match. ")
+	buf.WriteString("The global flags can be set in the call with `_config` and the filter flags with `_filter`.\n\n")
+	for _, c := range e.Commands {
+		if len(e.Commands) > 1 {
+			fmt.Fprintf(&buf, "### %s\n\n", c.CommandPath())
+		}
+		buf.WriteString(flagTable(c.NonInheritedFlags()))
+		buf.WriteString("\n")
+	}
+	doc := strings.TrimSuffix(buf.String(), "\n")
+	return addHeadingAnchors(doc, strings.Replace(call.Path, "/", "-", -1)), nil
+}
+
+// rcCommandJSON describes a command in the JSON rc call metadata
+type rcCommandJSON struct {
+	Flags []flagJSON `json:"flags"`
+	Path  string     `json:"path"`
+}
+
+// rcCallJSON describes an rc call in the JSON rc call metadata
+//
+// The fields are in alphabetical order so the output is sorted
+type rcCallJSON struct {
+	AuthRequired bool            `json:"authRequired"`
+	Commands     []rcCommandJSON `json:"commands"`
+	Help         string          `json:"help"`
+	Path         string          `json:"path"`
+	Title        string          `json:"title"`
+}
+
+// rcJSON returns the metadata for the rc calls of endpoints, with
+// the commands for each and their flags, as JSON
+func rcJSON(endpoints []rcEndpoint) ([]byte, error) {
+	var calls = []rcCallJSON{}
+	for _, e := range endpoints {
+		item := rcCallJSON{
+			AuthRequired: e.Call.AuthRequired,
+			Commands:     []rcCommandJSON{},
+			Help:         strings.TrimSpace(e.Call.Help),
+			Path:         e.Call.Path,
+			Title:        e.Call.Title,
+		}
+		for _, c := range e.Commands {
+			item.Commands = append(item.Commands, rcCommandJSON{
+				Flags: flagsJSON(c.NonInheritedFlags()),
+				Path:  c.CommandPath(),
+			})
+		}
+		calls = append(calls, item)
+	}
+	out, err := json.MarshalIndent(calls, "", "\t")
+	if err != nil {
+		return nil, err
+	}
+	return append(out, '\n'), nil
+}
@@ -0,0 +1,60 @@
+package gendocs
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/rclone/rclone/cmd"
+	"github.com/spf13/cobra"
+)
+
+// commandRedirects returns the redirects from the URLs the docs of the
+// aliases of the commands from top down would have to the URLs of the
+// docs of the commands, as "from to" lines sorted by from.
+//
+// The aliases used by more than one command in the whole tree, or
+// which are the command path of another command, are left out as they
+// can't be redirected.
+func commandRedirects(top *cobra.Command) []byte {
+	inDocs := map[*cobra.Command]bool{}
+	pages := map[string]bool{}
+	for _, c := range docCommands(top.Root()) {
+		inDocs[c] = true
+		pages[commandURL(strings.TrimSuffix(commandFileName(c), ".md"))] = true
+	}
+	wanted := map[string]bool{}
+	for _, c := range docCommands(top) {
+		wanted[commandURL(strings.TrimSuffix(commandFileName(c), ".md"))] = true
+	}
+	targets := map[string][]string{}
+	_ = cmd.WalkCommandTree(top.Root(), func(c *cobra.Command, aliases []string) error {
+		if !inDocs[c] {
+			return cmd.ErrorSkipCommand
+		}
+		to := commandURL(strings.TrimSuffix(commandFileName(c), ".md"))
+		for _, alias := range aliases {
+			from := commandURL(strings.Replace(alias, " ", "_", -1))
+			if pages[from] {
+				continue
+			}
+			if tos := targets[from]; len(tos) == 0 || tos[len(tos)-1] != to {
+				targets[from] = append(tos, to)
+			}
+		}
+		return nil
+	})
+	var froms []string
+	for from, tos := range targets {
+		if len(tos) == 1 && wanted[tos[0]] {
+			froms = append(froms, from)
+		}
+	}
+	sort.Strings(froms)
+	var buf bytes.Buffer
+	for _, from := range froms {
+		fmt.Fprintf(&buf, "%s %s\n", from, targets[from][0])
+	}
+	return buf.Bytes()
+}
@@ -0,0 +1,120 @@
+package gendocs
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"regexp"
+	"strings"
+	"text/template"
+
+	"github.com/spf13/cobra"
+)
+
+// seeAlsoTemplate renders the list of related commands in the SEE
+// ALSO section with --see-also-template or is nil to leave cobra's
+// list as it is
+var seeAlsoTemplate *template.Template
+
+// seeAlsoData is passed to seeAlsoTemplate
+type seeAlsoData struct {
+	Command  string           // the command path, e.g. "rclone copy"
+	Commands []seeAlsoCommand // the related commands in the order cobra lists them
+}
+
+// seeAlsoCommand is a related command in the SEE ALSO section
+type seeAlsoCommand struct {
+	Path  string // the command path, e.g. "rclone config create"
+	Short string // the short description of the command
+	Link  string // the link to the docs of the command
+}
+
+// loadSeeAlsoTemplate replaces seeAlsoTemplate with the template in
+// the file name
+//
+// The template is tried out on an empty list so mistakes, like fields
+// which don't exist, are found before any docs are written.
+func loadSeeAlsoTemplate(name string) error {
+	data, err := ioutil.ReadFile(name)
+	if err != nil {
+		return fmt.Errorf("failed to read --see-also-template: %w", err)
+	}
+	tmpl, err := template.New(name).Parse(string(data))
+	if err != nil {
+		return fmt.Errorf("failed to parse --see-also-template: %w", err)
+	}
+	err = tmpl.Execute(ioutil.Discard, seeAlsoData{})
+	if err != nil {
+		return fmt.Errorf("failed to render --see-also-template: %w", err)
+	}
+	seeAlsoTemplate = tmpl
+	return nil
+}
+
+// seeAlsoHeading starts the SEE ALSO section in the docs from cobra
+const seeAlsoHeading = "\n### SEE ALSO\n\n"
+
+// seeAlsoItemRe matches an item of the list of related commands cobra
+// writes in the SEE ALSO section
+var seeAlsoItemRe = regexp.MustCompile(`(?s)^\* \[(.*?)\]\((.*?)\)\t - (.*)$`)
+
+// parseSeeAlso returns the related commands in the list in the SEE
+// ALSO section of the markdown doc from cobra and the start and end
+// of the list in doc. It returns false if there is no SEE ALSO section.
+func parseSeeAlso(doc string) (commands []seeAlsoCommand, start, end int, ok bool) {
+	start = strings.Index(doc, seeAlsoHeading)
+	if start < 0 {
+		return nil, 0, 0, false
+	}
+	start += len(seeAlsoHeading)
+	// the list ends at the first blank line
+	end = strings.Index(doc[start:], "\n\n")
+	if end < 0 {
+		end = len(doc)
+	} else {
+		end += start + 1
+	}
+	var items []string
+	for _, line := range strings.SplitAfter(doc[start:end], "\n") {
+		if strings.HasPrefix(line, "* [") || len(items) == 0 {
+			items = append(items, line)
+		} else {
+			// an item with a short description over more than one line
+			items[len(items)-1] += line
+		}
+	}
+	for _, item := range items {
+		match := seeAlsoItemRe.FindStringSubmatch(item)
+		if match == nil {
+			continue
+		}
+		commands = append(commands, seeAlsoCommand{
+			Path:  match[1],
+			Link:  match[2],
+			Short: oneLine(match[3]),
+		})
+	}
+	return commands, start, end, true
+}
+
+// renderSeeAlso replaces the list of related commands of c in the SEE
+// ALSO section of its markdown doc with seeAlsoTemplate
+func renderSeeAlso(c *cobra.Command, doc string) (string, error) {
+	commands, start, end, ok := parseSeeAlso(doc)
+	if !ok {
+		return doc, nil
+	}
+	var buf bytes.Buffer
+	err := seeAlsoTemplate.Execute(&buf, seeAlsoData{
+		Command:  c.CommandPath(),
+		Commands: commands,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to render see also template: %w", err)
+	}
+	list := buf.String()
+	if list != "" && !strings.HasSuffix(list, "\n") {
+		list += "\n"
+	}
+	return doc[:start] + list + doc[end:], nil
+}
@@ -0,0 +1,112 @@
+package gendocs
+
+import (
+	"bytes"
+	"fmt"
+	"path"
+	"path/filepath"
+	"strings"
+	"text/template"
+
+	"github.com/spf13/cobra"
+)
+
+var singlePageTemplate = template.Must(template.New("singlePage").Funcs(templateFuncs).Parse(`---
+title: {{ quote .Title }}
+description: {{ quote .Description }}
+{{- if .Date }}
+date: {{ quote .Date }}
+{{- end }}
+{{- if .Locale }}
+lang: {{ quote .Locale }}
+{{- end }}
+{{- if .EditWarning }}
+# autogenerated - DO NOT EDIT, instead edit the source code in cmd/ and as part of making a release run "make commanddocs"
+{{- end }}
+---
+`))
+
+// tomlSinglePageTemplate is used instead of singlePageTemplate with
+// --frontmatter-format toml
+var tomlSinglePageTemplate = template.Must(template.New("singlePage").Funcs(templateFuncs).Parse(`+++
+title = {{ quote .Title }}
+description = {{ quote .Description }}
+{{- if .Date }}
+date = {{ quote .Date }}
+{{- end }}
+{{- if .Locale }}
+lang = {{ quote .Locale }}
+{{- end }}
+{{- if .EditWarning }}
+# autogenerated - DO NOT EDIT, instead edit the source code in cmd/ and as part of making a release run "make commanddocs"
+{{- end }}
++++
+`))
+
+// writeSinglePage writes the docs of all the commands to --single-page
+func (g *generator) writeSinglePage() error {
+	doc, err := singlePageDoc(g.top)
+	if err != nil {
+		return err
+	}
+	g.commandCount = len(docCommands(g.top))
+	return g.writeDoc(filepath.ToSlash(pageOpt.SinglePage), doc)
+}
+
+// singlePageDoc returns the docs for top and all the commands below it
+// as a single markdown doc.
+//
+// The commands are in tree order with the headings of each command
+// nested below those of its parent. The links between the commands are
+// links to the anchors of their titles in the page.
+func singlePageDoc(top *cobra.Command) (string, error) {
+	commands := docCommands(top)
+	inPage := map[string]bool{}
+	for _, c := range commands {
+		inPage[commandFileName(c)] = true
+	}
+	singlePageLink := func(name string) string {
+		if !inPage[name] {
+			return linkHandler(name)
+		}
+		return "#" + anchorPrefix(strings.TrimSuffix(name, path.Ext(name)))
+	}
+
+	file := filepath.ToSlash(pageOpt.SinglePage)
+	var buf bytes.Buffer
+	err := singlePageTemplate.Execute(&buf, frontmatter{
+		Title:       top.CommandPath() + " reference",
+		Description: oneLine(top.Short),
+		Date:        frontmatterOpt.parsedDate,
+		EditWarning: !frontmatterOpt.NoEditWarning,
+		Locale:      translationOpt.Locale,
+	})
+	if err != nil {
+		return "", pageError(file, top, fmt.Errorf("failed to render frontmatter template: %w", err))
+	}
+	topDepth := strings.Count(top.CommandPath(), " ")
+	for _, c := range commands {
+		body, err := commandMarkdown(c, singlePageLink)
+		if err != nil {
+			return "", pageError(file, c, err)
+		}
+		// the title of top is level 1 and each level below is one more
+		depth := strings.Count(c.CommandPath(), " ") - topDepth
+		body = shiftHeadings(body, depth-1)
+		prefix := anchorPrefix(strings.TrimSuffix(commandFileName(c), ".md"))
+		body = addHeadingAnchors(body, prefix)
+		// anchor the title so the links to the command work
+		title := strings.SplitN(body, "\n", 2)
+		title[0] += " {#" + prefix + "}"
+		badge, err := versionBadge(c)
+		if err != nil {
+			return "", pageError(file, c, err)
+		}
+		if badge != "" && len(title) == 2 {
+			title[1] = "\n" + badge + strings.TrimPrefix(title[1], "\n")
+		}
+		buf.WriteString(strings.Join(title, "\n"))
+		buf.WriteString("\n")
+	}
+	return buf.String(), nil
+}
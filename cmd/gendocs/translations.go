@@ -0,0 +1,132 @@
+package gendocs
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fs/config/flags"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// translationOptions are the options for writing the docs in another
+// language
+type translationOptions struct {
+	Locale       string            // locale of the translations, e.g. "de"
+	Translations string            // JSON file of the translations
+	titles       map[string]string // titles of the docs pages from Translations keyed by command path
+}
+
+// translationOpt are the options set with the flags
+var translationOpt translationOptions
+
+// addFlags adds the flags for opt to flagSet
+func (opt *translationOptions) addFlags(flagSet *pflag.FlagSet) {
+	flags.StringVarP(flagSet, &opt.Locale, "locale", "", opt.Locale, "Locale of the --translations, e.g. \"de\", put in the frontmatter as lang")
+	flags.StringVarP(flagSet, &opt.Translations, "translations", "", opt.Translations, "JSON file of the translated titles and descriptions of the commands for --locale")
+}
+
+// setup checks the options and translates root and the commands
+// below it
+//
+// If warnOnly is set the commands in the translations which don't
+// exist are logged instead of returned as an error.
+func (opt *translationOptions) setup(root *cobra.Command, warnOnly bool) (err error) {
+	if (opt.Locale == "") != (opt.Translations == "") {
+		return errors.New("--locale and --translations must be used together")
+	}
+	if opt.Translations == "" {
+		return nil
+	}
+	if !localeRe.MatchString(opt.Locale) {
+		return fmt.Errorf("invalid --locale %q: must be a language code like \"de\" or \"pt-BR\"", opt.Locale)
+	}
+	catalog, err := loadTranslations(opt.Translations)
+	if err != nil {
+		return err
+	}
+	opt.titles, err = translateCommands(root, catalog)
+	if err != nil {
+		if !warnOnly {
+			return err
+		}
+		fs.Logf(nil, "%v", err)
+	}
+	return nil
+}
+
+// translation is the translated text of a command from --translations
+type translation struct {
+	Title string `json:"title"` // title of the docs page
+	Short string `json:"short"` // short description of the command
+	Long  string `json:"long"`  // long description of the command
+}
+
+// localeRe matches the locales which can be used with --locale
+var localeRe = regexp.MustCompile(`^[A-Za-z]{2,3}([-_][A-Za-z0-9]{2,8})*$`)
+
+// loadTranslations reads the --translations file name, a JSON object
+// with the translations keyed by command path, e.g.
+//
+//	{"rclone copy": {"short": "...", "long": "..."}}
+func loadTranslations(name string) (catalog map[string]translation, err error) {
+	data, err := ioutil.ReadFile(name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read --translations: %w", err)
+	}
+	err = json.Unmarshal(data, &catalog)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse --translations %q: %w", name, err)
+	}
+	return catalog, nil
+}
+
+// translateCommands replaces the Short and Long of the commands below
+// root with their translations in catalog so the docs are made with
+// them. The commands without a translation, or with only some fields
+// translated, are left in English for the rest.
+//
+// It returns the translated titles keyed by command path. If catalog
+// has any command paths which don't exist it returns an error listing
+// them, having translated the rest.
+func translateCommands(root *cobra.Command, catalog map[string]translation) (titles map[string]string, err error) {
+	titles = map[string]string{}
+	found := map[string]bool{}
+	var walk func(c *cobra.Command)
+	walk = func(c *cobra.Command) {
+		commandPath := c.CommandPath()
+		if t, ok := catalog[commandPath]; ok {
+			found[commandPath] = true
+			if t.Short != "" {
+				c.Short = t.Short
+			}
+			if t.Long != "" {
+				c.Long = t.Long
+			}
+			if t.Title != "" {
+				titles[commandPath] = t.Title
+			}
+		}
+		for _, child := range c.Commands() {
+			walk(child)
+		}
+	}
+	walk(root)
+	var unknown []string
+	for commandPath := range catalog {
+		if !found[commandPath] {
+			unknown = append(unknown, fmt.Sprintf("%q", commandPath))
+		}
+	}
+	if len(unknown) > 0 {
+		sort.Strings(unknown)
+		err = fmt.Errorf("--translations has translations for commands which don't exist: %s", strings.Join(unknown, ", "))
+	}
+	return titles, err
+}
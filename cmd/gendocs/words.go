@@ -0,0 +1,80 @@
+package gendocs
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// wordsPerMinute is the reading speed used for --reading-time
+const wordsPerMinute = 200
+
+// markdownLinkRe matches markdown links and images, with the text in
+// the first group
+var markdownLinkRe = regexp.MustCompile(`!?\[([^\]]*)\]\([^)]*\)`)
+
+// autoLinkRe matches markdown autolinks like <https://rclone.org/>
+var autoLinkRe = regexp.MustCompile(`<[a-zA-Z][a-zA-Z0-9+.-]*:[^>\s]*>`)
+
+// listItemRe matches the start of a markdown list item
+var listItemRe = regexp.MustCompile(`^\s*([*+-]|[0-9]+[.)])\s`)
+
+// countWords returns the number of words in the markdown doc for
+// --reading-time.
+//
+// Code blocks, both fenced and indented, the rows of tables, like the
+// Options sections, and link reference definitions aren't counted.
+// Only the text of links is counted, not their URLs. A word is
+// anything between spaces with a letter or digit in it, so markdown
+// syntax like "#" and "*" isn't counted.
+func countWords(doc string) (words int) {
+	inFence, inIndented, inList, prevBlank := false, false, false, true
+	for _, line := range strings.Split(doc, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~") {
+			inFence = !inFence
+			prevBlank = false
+			continue
+		}
+		if inFence {
+			continue
+		}
+		if trimmed == "" {
+			prevBlank = true
+			continue
+		}
+		indented := strings.HasPrefix(line, "    ") || strings.HasPrefix(line, "\t")
+		if indented && (inIndented || (prevBlank && !inList)) {
+			inIndented = true
+			prevBlank = false
+			continue
+		}
+		inIndented = false
+		if listItemRe.MatchString(line) {
+			inList = true
+		} else if !indented && prevBlank {
+			inList = false
+		}
+		prevBlank = false
+		if strings.HasPrefix(trimmed, "|") || strings.HasPrefix(trimmed, "[") && strings.Contains(trimmed, "]:") {
+			continue
+		}
+		trimmed = headingAnchorRe.ReplaceAllString(trimmed, "")
+		trimmed = markdownLinkRe.ReplaceAllString(trimmed, "$1")
+		trimmed = autoLinkRe.ReplaceAllString(trimmed, "")
+		for _, field := range strings.Fields(trimmed) {
+			if strings.IndexFunc(field, func(r rune) bool {
+				return unicode.IsLetter(r) || unicode.IsDigit(r)
+			}) >= 0 {
+				words++
+			}
+		}
+	}
+	return words
+}
+
+// readingMinutes returns the number of minutes, rounded up, it takes
+// to read words at wordsPerMinute
+func readingMinutes(words int) int {
+	return (words + wordsPerMinute - 1) / wordsPerMinute
+}
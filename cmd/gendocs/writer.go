@@ -0,0 +1,231 @@
+package gendocs
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fs/config/flags"
+	"github.com/rclone/rclone/lib/file"
+	"github.com/rclone/rclone/vfs/vfsflags"
+	"github.com/spf13/pflag"
+)
+
+// writerOptions are the options for writing the docs files
+type writerOptions struct {
+	FilePerms   os.FileMode     // permissions of the docs files written
+	DirPerms    os.FileMode     // permissions of the docs directories created
+	Manifest    string          // file to write the paths of the files written to
+	Checksum    string          // file to write the checksum of the files written to, or -
+	PostProcess fs.SpaceSepList // command to run on each file written
+}
+
+// writerOpt are the options set with the flags
+var writerOpt = writerOptions{
+	FilePerms: os.FileMode(0644),
+	DirPerms:  os.FileMode(0755),
+}
+
+// addFlags adds the flags for opt to flagSet
+func (opt *writerOptions) addFlags(flagSet *pflag.FlagSet) {
+	flags.FVarP(flagSet, &vfsflags.FileMode{Mode: &opt.FilePerms}, "file-perms", "", "Permissions of the docs files written")
+	flags.FVarP(flagSet, &vfsflags.FileMode{Mode: &opt.DirPerms}, "dir-perms", "", "Permissions of the docs directories created")
+	flags.StringVarP(flagSet, &opt.Manifest, "manifest", "", opt.Manifest, "Write the paths of the docs files written to this file")
+	flags.StringVarP(flagSet, &opt.Checksum, "checksum-file", "", opt.Checksum, "Write a SHA256 checksum of all the docs files written to this file, or - for stdout")
+	flags.FVarP(flagSet, &opt.PostProcess, "post-process", "", "Command to run on each docs file written, with the path of the file added to its arguments")
+}
+
+// docsWriter writes the docs files into the root directory and
+// records which files were written
+type docsWriter struct {
+	root    string            // directory to write the docs to
+	dryRun  bool              // if set log what would be written instead
+	written []string          // paths of the files written relative to root
+	sums    map[string]string // SHA256 of the files written by path
+}
+
+// mkdir creates dir relative to the root with --dir-perms if it doesn't exist
+//
+// The permissions are set explicitly so they don't depend on the umask
+func (w *docsWriter) mkdir(dir string) error {
+	dir = filepath.Join(w.root, filepath.FromSlash(dir))
+	if _, err := os.Stat(dir); err == nil {
+		return nil
+	}
+	if w.dryRun {
+		fs.Logf(dir, "Not creating directory as --dry-run is set")
+		return nil
+	}
+	err := file.MkdirAll(dir, writerOpt.DirPerms)
+	if err != nil {
+		return err
+	}
+	return os.Chmod(dir, writerOpt.DirPerms)
+}
+
+// writeFile writes data to name relative to the root with --file-perms
+//
+// The permissions are set explicitly so they don't depend on the umask
+// or the permissions of an existing file. It returns an error rather
+// than write outside the root.
+func (w *docsWriter) writeFile(name string, data []byte) error {
+	if !insideDocs(name) {
+		return fmt.Errorf("not writing %q as it is outside the docs directory", name)
+	}
+	w.written = append(w.written, name)
+	if w.sums == nil {
+		w.sums = map[string]string{}
+	}
+	sum := sha256.Sum256(data)
+	w.sums[name] = hex.EncodeToString(sum[:])
+	return w.write(filepath.Join(w.root, filepath.FromSlash(name)), data)
+}
+
+// write writes data to the file called name unless doing a dry run in
+// which case it logs whether it would be created or modified
+func (w *docsWriter) write(name string, data []byte) error {
+	if !w.dryRun {
+		return writeFile(name, data)
+	}
+	old, err := ioutil.ReadFile(name)
+	switch {
+	case err != nil:
+		fs.Logf(name, "Not creating file of %d bytes as --dry-run is set", len(data))
+	case !bytes.Equal(old, data):
+		fs.Logf(name, "Not modifying file to %d bytes as --dry-run is set", len(data))
+	default:
+		fs.Debugf(name, "Unchanged")
+	}
+	return nil
+}
+
+// postProcess runs command on each of the files written in turn with
+// the path of the file added to its arguments, then updates the
+// SHA256 of the file
+func (w *docsWriter) postProcess(command []string) error {
+	if w.dryRun {
+		fs.Logf(nil, "Not running --post-process as --dry-run is set")
+		return nil
+	}
+	for _, name := range w.written {
+		localName := filepath.Join(w.root, filepath.FromSlash(name))
+		args := append(append([]string(nil), command[1:]...), localName)
+		c := exec.Command(command[0], args...)
+		c.Stdout = os.Stderr
+		c.Stderr = os.Stderr
+		err := c.Run()
+		if err != nil {
+			return fmt.Errorf("--post-process failed on %s: %w", name, err)
+		}
+		data, err := ioutil.ReadFile(localName)
+		if err != nil {
+			return fmt.Errorf("failed to read %s after --post-process: %w", name, err)
+		}
+		sum := sha256.Sum256(data)
+		w.sums[name] = hex.EncodeToString(sum[:])
+	}
+	return nil
+}
+
+// writeManifest writes the sorted paths of the files written, one per
+// line, to the file manifest
+func (w *docsWriter) writeManifest(manifest string) error {
+	written := append([]string(nil), w.written...)
+	sort.Strings(written)
+	var buf bytes.Buffer
+	for _, name := range written {
+		buf.WriteString(name)
+		buf.WriteByte('\n')
+	}
+	err := w.write(manifest, buf.Bytes())
+	if err != nil {
+		return fmt.Errorf("failed to write manifest: %w", err)
+	}
+	return nil
+}
+
+// checksum returns the SHA256 of the lines sha256sum prints for the
+// files written, sorted by path, as a hex string
+func (w *docsWriter) checksum() string {
+	written := append([]string(nil), w.written...)
+	sort.Strings(written)
+	h := sha256.New()
+	for _, name := range written {
+		_, _ = fmt.Fprintf(h, "%s  %s\n", w.sums[name], name)
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// writeChecksum writes the checksum of the files written to the file
+// name, or to stdout if it is "-"
+//
+// This is printed with --dry-run too so the docs can be checked
+// without writing them.
+func (w *docsWriter) writeChecksum(name string) error {
+	sum := w.checksum() + "\n"
+	if name == "-" {
+		_, err := os.Stdout.WriteString(sum)
+		return err
+	}
+	err := w.write(name, []byte(sum))
+	if err != nil {
+		return fmt.Errorf("failed to write checksum: %w", err)
+	}
+	return nil
+}
+
+// finish runs the --post-process command on the files written and
+// then writes the --manifest and the --checksum-file
+func (w *docsWriter) finish() error {
+	if len(writerOpt.PostProcess) > 0 {
+		err := w.postProcess(writerOpt.PostProcess)
+		if err != nil {
+			return err
+		}
+	}
+	if writerOpt.Manifest != "" {
+		err := w.writeManifest(writerOpt.Manifest)
+		if err != nil {
+			return err
+		}
+	}
+	if writerOpt.Checksum != "" {
+		err := w.writeChecksum(writerOpt.Checksum)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkChecksumDate checks the --date flag is set so that the docs
+// made with --checksum-file are the same each time
+//
+// The man pages are dated with the current month unless --date or
+// SOURCE_DATE_EPOCH are set.
+func checkChecksumDate(date string, man bool) error {
+	if date == "now" {
+		return errors.New("can't use --date now with --checksum-file as the docs would change each time")
+	}
+	if man && date == "" && os.Getenv("SOURCE_DATE_EPOCH") == "" {
+		return errors.New("--checksum-file with --man needs --date or SOURCE_DATE_EPOCH set so the man pages are the same each time")
+	}
+	return nil
+}
+
+// writeFile writes data to name with --file-perms
+func writeFile(name string, data []byte) error {
+	err := ioutil.WriteFile(name, data, writerOpt.FilePerms)
+	if err != nil {
+		return err
+	}
+	return os.Chmod(name, writerOpt.FilePerms)
+}
@@ -2,6 +2,7 @@ package hashsum
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
 
@@ -20,6 +21,7 @@ var (
 	DownloadFlag   = false
 	HashsumOutfile = ""
 	ChecksumFile   = ""
+	ResumeFlag     = false
 )
 
 func init() {
@@ -28,12 +30,13 @@ func init() {
 	AddHashsumFlags(cmdFlags)
 }
 
-// AddHashsumFlags is a convenience function to add the command flags OutputBase64 and DownloadFlag to hashsum, md5sum, sha1sum
+// AddHashsumFlags is a convenience function to add the command flags OutputBase64, DownloadFlag and ResumeFlag to hashsum, md5sum, sha1sum
 func AddHashsumFlags(cmdFlags *pflag.FlagSet) {
 	flags.BoolVarP(cmdFlags, &OutputBase64, "base64", "", OutputBase64, "Output base64 encoded hashsum")
 	flags.StringVarP(cmdFlags, &HashsumOutfile, "output-file", "", HashsumOutfile, "Output hashsums to a file rather than the terminal")
 	flags.StringVarP(cmdFlags, &ChecksumFile, "checkfile", "C", ChecksumFile, "Validate hashes against a given SUM file instead of printing them")
 	flags.BoolVarP(cmdFlags, &DownloadFlag, "download", "", DownloadFlag, "Download the file and hash it locally; if this flag is not specified, the hash is requested from the remote")
+	flags.BoolVarP(cmdFlags, &ResumeFlag, "resume", "", ResumeFlag, "Resume an interrupted --download to --output-file, skipping files already hashed")
 }
 
 // GetHashsumOutput opens and closes the output file when using the output-file flag
@@ -54,6 +57,29 @@ func GetHashsumOutput(filename string) (out *os.File, close func(), err error) {
 	return out, close, nil
 }
 
+// HashLister outputs the hashes of the objects in fsrc to the terminal
+// or to the output-file.
+//
+// With the download flag and an output-file the run can be resumed
+// with the resume flag if it is interrupted.
+func HashLister(ctx context.Context, ht hash.Type, fsrc fs.Fs) error {
+	if ResumeFlag && (!DownloadFlag || HashsumOutfile == "") {
+		return errors.New("--resume needs --download and --output-file")
+	}
+	if HashsumOutfile == "" {
+		return operations.HashLister(ctx, ht, OutputBase64, DownloadFlag, fsrc, nil)
+	}
+	if DownloadFlag {
+		return resumableHashLister(ctx, ht, fsrc)
+	}
+	output, close, err := GetHashsumOutput(HashsumOutfile)
+	if err != nil {
+		return err
+	}
+	defer close()
+	return operations.HashLister(ctx, ht, OutputBase64, DownloadFlag, fsrc, output)
+}
+
 // CreateFromStdinArg checks args and produces hashsum from standard input if it is requested
 func CreateFromStdinArg(ht hash.Type, args []string, startArg int) (bool, error) {
 	var stdinArg bool
@@ -107,7 +133,7 @@ Then
     $ rclone hashsum MD5 remote:path
 
 Note that hash names are case insensitive and values are output in lower case.
-`,
+` + ResumeHelp,
 	RunE: func(command *cobra.Command, args []string) error {
 		cmd.CheckArgs(0, 2, command, args)
 		if len(args) == 0 {
@@ -129,15 +155,7 @@ Note that hash names are case insensitive and values are output in lower case.
 				fsum, sumFile := cmd.NewFsFile(ChecksumFile)
 				return operations.CheckSum(context.Background(), fsrc, fsum, sumFile, ht, nil, DownloadFlag)
 			}
-			if HashsumOutfile == "" {
-				return operations.HashLister(context.Background(), ht, OutputBase64, DownloadFlag, fsrc, nil)
-			}
-			output, close, err := GetHashsumOutput(HashsumOutfile)
-			if err != nil {
-				return err
-			}
-			defer close()
-			return operations.HashLister(context.Background(), ht, OutputBase64, DownloadFlag, fsrc, output)
+			return HashLister(context.Background(), ht, fsrc)
 		})
 		return nil
 	},
@@ -0,0 +1,313 @@
+package hashsum
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fs/accounting"
+	"github.com/rclone/rclone/fs/hash"
+	"github.com/rclone/rclone/fs/operations"
+)
+
+// ResumeHelp describes resuming with the resume flag for the help of
+// the commands which use AddHashsumFlags
+const ResumeHelp = `
+With --download and --output-file each hash is written to the output
+file as soon as the file is hashed, with up to --transfers files being
+hashed at once. The size and modification time of each file hashed is
+recorded in a file named as the output file with "` + resumeSuffix + `" on
+the end, which is removed when all the files have been hashed.
+
+If this is interrupted, run the same command again with --resume to
+carry on where it left off. The files in the output file which haven't
+changed size or modification time since they were hashed are skipped
+and the rest are hashed. When it is complete the output file is
+rewritten sorted by path, without the files which have been removed.
+`
+
+// resumeSuffix is added to the output file name to make the name of
+// the file holding the state for --resume
+const resumeSuffix = ".resume"
+
+var (
+	sumLineRe   = regexp.MustCompile(`^([^ ]+) [ *](.+)$`)
+	stateLineRe = regexp.MustCompile(`^(\d+ \S+) (.+)$`)
+)
+
+// hashResume writes the output file for a --download with the state
+// needed to resume it.
+//
+// The state file has a header line saying which hash is being made
+// followed by a line with the size, modification time and path of
+// each file hashed. This is written after the line in the output
+// file so the output file always has the hash of each file in the
+// state file, whatever order they are hashed in.
+type hashResume struct {
+	mu        sync.Mutex
+	header    string
+	width     int
+	outName   string
+	stateName string
+	out       *os.File
+	state     *os.File
+	lines     map[string]string   // line in the output file for each path
+	stamps    map[string]string   // size and modification time for each path
+	seen      map[string]struct{} // paths found while listing
+}
+
+// newHashResume makes a hashResume for ht, loading the state of the
+// run to be resumed if resume is set.
+func newHashResume(ht hash.Type, resume bool) (*hashResume, error) {
+	encoding := "hex"
+	if OutputBase64 {
+		encoding = "base64"
+	}
+	r := &hashResume{
+		header:    fmt.Sprintf("rclone hashsum %v %s", ht, encoding),
+		width:     hash.Width(ht, OutputBase64),
+		outName:   HashsumOutfile,
+		stateName: HashsumOutfile + resumeSuffix,
+		lines:     map[string]string{},
+		stamps:    map[string]string{},
+		seen:      map[string]struct{}{},
+	}
+	if resume {
+		err := r.load()
+		if err != nil {
+			return nil, err
+		}
+	}
+	err := r.open()
+	if err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// readLines returns the complete lines in the file name, dropping a
+// last line which was only partly written
+func readLines(name string) ([]string, error) {
+	data, err := ioutil.ReadFile(name)
+	if err != nil {
+		return nil, err
+	}
+	lines := strings.Split(string(data), "\n")
+	return lines[:len(lines)-1], nil
+}
+
+// load the output and state files of the run to be resumed, keeping
+// the paths which are in both. If a path is in a file more than once
+// the last line is used.
+func (r *hashResume) load() error {
+	stateLines, err := readLines(r.stateName)
+	if err != nil {
+		return fmt.Errorf("can't resume: %w", err)
+	}
+	if len(stateLines) == 0 || stateLines[0] != r.header {
+		return fmt.Errorf("can't resume: %s wasn't made by %q", r.stateName, r.header)
+	}
+	for _, line := range stateLines[1:] {
+		fields := stateLineRe.FindStringSubmatch(line)
+		if fields != nil {
+			r.stamps[fields[2]] = fields[1]
+		}
+	}
+	outLines, err := readLines(r.outName)
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("can't resume: %w", err)
+	}
+	for _, line := range outLines {
+		fields := sumLineRe.FindStringSubmatch(line)
+		if fields != nil && len(fields[1]) == r.width {
+			r.lines[fields[2]] = line + "\n"
+		}
+	}
+	for remote := range r.stamps {
+		if _, ok := r.lines[remote]; !ok {
+			delete(r.stamps, remote)
+		}
+	}
+	for remote := range r.lines {
+		if _, ok := r.stamps[remote]; !ok {
+			delete(r.lines, remote)
+		}
+	}
+	fs.Infof(nil, "Resuming with %d files already hashed in %q", len(r.lines), r.outName)
+	return nil
+}
+
+// sortedRemotes returns the paths in r.lines which keep returns true
+// for in order
+func (r *hashResume) sortedRemotes(keep func(remote string) bool) []string {
+	var remotes []string
+	for remote := range r.lines {
+		if keep(remote) {
+			remotes = append(remotes, remote)
+		}
+	}
+	sort.Strings(remotes)
+	return remotes
+}
+
+// replaceFile replaces the file name with what write writes, writing
+// it to a temporary file first so name is complete if this is
+// interrupted
+func replaceFile(name string, write func(out io.Writer) error) error {
+	tmpName := name + ".tmp"
+	out, err := os.Create(tmpName)
+	if err != nil {
+		return fmt.Errorf("failed to open output file %v: %w", tmpName, err)
+	}
+	err = write(out)
+	closeErr := out.Close()
+	if err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		_ = os.Remove(tmpName)
+		return fmt.Errorf("failed to write output file %v: %w", tmpName, err)
+	}
+	return os.Rename(tmpName, name)
+}
+
+// writeOut replaces the output file with the lines for remotes
+func (r *hashResume) writeOut(remotes []string) error {
+	return replaceFile(r.outName, func(out io.Writer) error {
+		for _, remote := range remotes {
+			if _, err := io.WriteString(out, r.lines[remote]); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// open writes the output and state files with what was loaded then
+// opens them to append the files hashed
+func (r *hashResume) open() error {
+	remotes := r.sortedRemotes(func(string) bool { return true })
+	err := r.writeOut(remotes)
+	if err != nil {
+		return err
+	}
+	err = replaceFile(r.stateName, func(out io.Writer) error {
+		if _, err := fmt.Fprintln(out, r.header); err != nil {
+			return err
+		}
+		for _, remote := range remotes {
+			if _, err := fmt.Fprintf(out, "%s %s\n", r.stamps[remote], remote); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	r.out, err = os.OpenFile(r.outName, os.O_WRONLY|os.O_APPEND, 0666)
+	if err != nil {
+		return fmt.Errorf("failed to open output file %v: %w", r.outName, err)
+	}
+	r.state, err = os.OpenFile(r.stateName, os.O_WRONLY|os.O_APPEND, 0666)
+	if err != nil {
+		_ = r.out.Close()
+		return fmt.Errorf("failed to open output file %v: %w", r.stateName, err)
+	}
+	return nil
+}
+
+// stamp returns the size and modification time of o as stored in the
+// state file
+func stamp(ctx context.Context, o fs.Object) string {
+	return fmt.Sprintf("%d %s", o.Size(), o.ModTime(ctx).UTC().Format(time.RFC3339Nano))
+}
+
+// skip returns true if o was hashed by the run being resumed and
+// hasn't changed since
+func (r *hashResume) skip(ctx context.Context, o fs.Object) bool {
+	remote := o.Remote()
+	r.mu.Lock()
+	old, ok := r.stamps[remote]
+	r.mu.Unlock()
+	if !ok || old != stamp(ctx, o) {
+		return false
+	}
+	r.mu.Lock()
+	r.seen[remote] = struct{}{}
+	r.mu.Unlock()
+	fs.Debugf(o, "Skipping as already hashed")
+	return true
+}
+
+// add the hash sum of o to the output and state files
+func (r *hashResume) add(ctx context.Context, o fs.Object, sum string) {
+	remote := o.Remote()
+	line := fmt.Sprintf("%*s  %s\n", r.width, sum, remote)
+	objStamp := stamp(ctx, o)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.seen[remote] = struct{}{}
+	r.lines[remote] = line
+	_, err := io.WriteString(r.out, line)
+	if err == nil {
+		_, err = fmt.Fprintf(r.state, "%s %s\n", objStamp, remote)
+	}
+	if err != nil {
+		err = fs.CountError(fmt.Errorf("failed to write output file: %w", err))
+		fs.Errorf(o, "%v", err)
+	}
+}
+
+// finish closes the files after hashing finished with err.
+//
+// If everything was hashed the output file is rewritten sorted
+// without the duplicate lines and lines for the files not found and
+// the state file is removed.
+func (r *hashResume) finish(ctx context.Context, err error) error {
+	for _, f := range []*os.File{r.out, r.state} {
+		closeErr := f.Close()
+		if closeErr != nil {
+			fs.Errorf(nil, "Failed to close output file %v: %v", f.Name(), closeErr)
+			if err == nil {
+				err = closeErr
+			}
+		}
+	}
+	if err != nil || accounting.Stats(ctx).Errored() {
+		fs.Logf(nil, "Not all files were hashed - use --resume to carry on")
+		return err
+	}
+	remotes := r.sortedRemotes(func(remote string) bool {
+		_, ok := r.seen[remote]
+		return ok
+	})
+	err = r.writeOut(remotes)
+	if err != nil {
+		return err
+	}
+	return os.Remove(r.stateName)
+}
+
+// resumableHashLister downloads and hashes the objects in fsrc writing
+// them to the output file so the run can be resumed.
+func resumableHashLister(ctx context.Context, ht hash.Type, fsrc fs.Fs) error {
+	r, err := newHashResume(ht, ResumeFlag)
+	if err != nil {
+		return err
+	}
+	err = operations.HashListerFn(ctx, ht, OutputBase64, true, fsrc, func(o fs.Object) bool {
+		return r.skip(ctx, o)
+	}, func(o fs.Object, sum string) {
+		r.add(ctx, o, sum)
+	})
+	return r.finish(ctx, err)
+}
@@ -0,0 +1,93 @@
+package hashsum
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	_ "github.com/rclone/rclone/backend/memory"
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fs/hash"
+	"github.com/rclone/rclone/fs/operations"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResumableHashLister(t *testing.T) {
+	ctx := context.Background()
+	f, err := fs.NewFs(ctx, ":memory:TestResumableHashLister")
+	require.NoError(t, err)
+	t1 := time.Date(2001, 2, 3, 4, 5, 6, 0, time.UTC)
+	for _, name := range []string{"a", "b", "c d"} {
+		_, err = operations.Rcat(ctx, f, name, ioutil.NopCloser(strings.NewReader(name)), t1)
+		require.NoError(t, err)
+	}
+
+	oldOutfile, oldResume, oldDownload := HashsumOutfile, ResumeFlag, DownloadFlag
+	defer func() {
+		HashsumOutfile, ResumeFlag, DownloadFlag = oldOutfile, oldResume, oldDownload
+	}()
+	HashsumOutfile = filepath.Join(t.TempDir(), "MD5SUMS")
+	stateName := HashsumOutfile + resumeSuffix
+	DownloadFlag = true
+
+	const (
+		sumA = "0cc175b9c0f1b6a831c399e269772661  a\n"
+		sumB = "92eb5ffee6ae2fec3ad71c777531578f  b\n"
+		sumC = "a761a01e4e85131529c1b1948648cd9a  c d\n"
+	)
+	read := func() string {
+		data, err := ioutil.ReadFile(HashsumOutfile)
+		require.NoError(t, err)
+		return string(data)
+	}
+
+	// a run which isn't interrupted
+	require.NoError(t, HashLister(ctx, hash.MD5, f))
+	assert.Equal(t, sumA+sumB+sumC, read())
+	_, err = os.Stat(stateName)
+	assert.True(t, os.IsNotExist(err))
+
+	// there is nothing to resume
+	ResumeFlag = true
+	err = HashLister(ctx, hash.MD5, f)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "can't resume")
+
+	// the state is for a different hash
+	require.NoError(t, ioutil.WriteFile(stateName, []byte("rclone hashsum sha1 hex\n"), 0666))
+	err = HashLister(ctx, hash.MD5, f)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "can't resume")
+
+	// an interrupted run - the zero hash of a shows it isn't hashed
+	// again, b has changed since it was hashed, gone has been
+	// removed, c d is in the state but not in the output and the last
+	// lines were only partly written
+	stampA := "1 " + t1.Format(time.RFC3339Nano)
+	sumOldA := "00000000000000000000000000000000  a\n"
+	require.NoError(t, ioutil.WriteFile(HashsumOutfile, []byte(
+		"11111111111111111111111111111111  b\n"+
+			sumOldA+
+			"22222222222222222222222222222222  gone\n"+
+			"3333"), 0666))
+	require.NoError(t, ioutil.WriteFile(stateName, []byte(
+		"rclone hashsum md5 hex\n"+
+			"2 "+t1.Format(time.RFC3339Nano)+" b\n"+
+			stampA+" a\n"+
+			stampA+" gone\n"+
+			stampA+" c d\n"+
+			"1 2001"), 0666))
+	require.NoError(t, HashLister(ctx, hash.MD5, f))
+	assert.Equal(t, sumOldA+sumB+sumC, read())
+	_, err = os.Stat(stateName)
+	assert.True(t, os.IsNotExist(err))
+
+	// --resume needs --download
+	DownloadFlag = false
+	assert.Error(t, HashLister(ctx, hash.MD5, f))
+}
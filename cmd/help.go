@@ -3,6 +3,7 @@ package cmd
 import (
 	"context"
 	"fmt"
+	"io"
 	"log"
 	"os"
 	"regexp"
@@ -296,6 +297,12 @@ func showBackend(name string) {
 	if err != nil {
 		log.Fatal(err)
 	}
+	WriteBackendOptions(os.Stdout, backend)
+}
+
+// WriteBackendOptions writes the standard and advanced options of
+// backend to out in markdown, as shown by "rclone help backend".
+func WriteBackendOptions(out io.Writer, backend *fs.RegInfo) {
 	var standardOptions, advancedOptions fs.Options
 	done := map[string]struct{}{}
 	for _, opt := range backend.Options {
@@ -315,8 +322,8 @@ func showBackend(name string) {
 			optionsType = "advanced"
 			continue
 		}
-		fmt.Printf("### %s options\n\n", strings.Title(optionsType))
-		fmt.Printf("Here are the %s options specific to %s (%s).\n\n", optionsType, backend.Name, backend.Description)
+		fmt.Fprintf(out, "### %s options\n\n", strings.Title(optionsType))
+		fmt.Fprintf(out, "Here are the %s options specific to %s (%s).\n\n", optionsType, backend.Name, backend.Description)
 		optionsType = "advanced"
 		for _, opt := range opts {
 			done[opt.Name] = struct{}{}
@@ -324,25 +331,25 @@ func showBackend(name string) {
 			if opt.ShortOpt != "" {
 				shortOpt = fmt.Sprintf(" / -%s", opt.ShortOpt)
 			}
-			fmt.Printf("#### --%s%s\n\n", opt.FlagName(backend.Prefix), shortOpt)
-			fmt.Printf("%s\n\n", opt.Help)
+			fmt.Fprintf(out, "#### --%s%s\n\n", opt.FlagName(backend.Prefix), shortOpt)
+			fmt.Fprintf(out, "%s\n\n", opt.Help)
 			if opt.IsPassword {
-				fmt.Printf("**NB** Input to this must be obscured - see [rclone obscure](/commands/rclone_obscure/).\n\n")
+				fmt.Fprintf(out, "**NB** Input to this must be obscured - see [rclone obscure](/commands/rclone_obscure/).\n\n")
 			}
-			fmt.Printf("- Config:      %s\n", opt.Name)
-			fmt.Printf("- Env Var:     %s\n", opt.EnvVarName(backend.Prefix))
-			fmt.Printf("- Type:        %s\n", opt.Type())
-			fmt.Printf("- Default:     %s\n", quoteString(opt.GetValue()))
+			fmt.Fprintf(out, "- Config:      %s\n", opt.Name)
+			fmt.Fprintf(out, "- Env Var:     %s\n", opt.EnvVarName(backend.Prefix))
+			fmt.Fprintf(out, "- Type:        %s\n", opt.Type())
+			fmt.Fprintf(out, "- Default:     %s\n", quoteString(opt.GetValue()))
 			if len(opt.Examples) > 0 {
-				fmt.Printf("- Examples:\n")
+				fmt.Fprintf(out, "- Examples:\n")
 				for _, ex := range opt.Examples {
-					fmt.Printf("    - %s\n", quoteString(ex.Value))
+					fmt.Fprintf(out, "    - %s\n", quoteString(ex.Value))
 					for _, line := range strings.Split(ex.Help, "\n") {
-						fmt.Printf("        - %s\n", line)
+						fmt.Fprintf(out, "        - %s\n", line)
 					}
 				}
 			}
-			fmt.Printf("\n")
+			fmt.Fprintf(out, "\n")
 		}
 	}
 }
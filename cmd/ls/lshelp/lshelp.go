@@ -7,7 +7,11 @@ import (
 // Help describes the common help for all the list commands
 // Warning! "|" will be replaced by backticks below
 var Help = strings.ReplaceAll(`
-Any of the filtering options can be applied to this command.
+Any of the filtering options can be applied to this command. For
+example |--max-age 1d| lists only the objects modified in the last
+day. Where the backend is able to, as Google Drive is, the |--max-age|
+and |--min-age| filters are applied by the server so only matching
+objects are listed, which makes listing large remotes much quicker.
 
 There are several related list commands
 
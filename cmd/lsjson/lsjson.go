@@ -71,7 +71,13 @@ request (e.g. s3, swift).
 
 If --no-mimetype is specified then MimeType will be blank. This can
 speed things up on remotes where reading the MimeType takes an extra
-request (e.g. s3, swift).
+request (e.g. s3, qingstor).
+
+The MimeType is the content type stored by the backend if it has one,
+otherwise it is guessed from the file extension. Most backends which
+store a content type, such as azureblob, b2, drive, gcs, onedrive and
+swift, return it in the listing so reading it is free. The s3 and
+qingstor backends need an extra HEAD request per file to read it.
 
 If --encrypted is not specified the Encrypted won't be emitted.
 
@@ -32,7 +32,7 @@ This command can also hash data received on standard input (stdin),
 by not passing a remote:path, or by passing a hyphen as remote:path
 when there is data to read (if not, the hypen will be treated literaly,
 as a relative path).
-`,
+` + hashsum.ResumeHelp,
 	RunE: func(command *cobra.Command, args []string) error {
 		cmd.CheckArgs(0, 1, command, args)
 		if found, err := hashsum.CreateFromStdinArg(hash.MD5, args, 0); found {
@@ -44,15 +44,7 @@ as a relative path).
 				fsum, sumFile := cmd.NewFsFile(hashsum.ChecksumFile)
 				return operations.CheckSum(context.Background(), fsrc, fsum, sumFile, hash.MD5, nil, hashsum.DownloadFlag)
 			}
-			if hashsum.HashsumOutfile == "" {
-				return operations.HashLister(context.Background(), hash.MD5, hashsum.OutputBase64, hashsum.DownloadFlag, fsrc, nil)
-			}
-			output, close, err := hashsum.GetHashsumOutput(hashsum.HashsumOutfile)
-			if err != nil {
-				return err
-			}
-			defer close()
-			return operations.HashLister(context.Background(), hash.MD5, hashsum.OutputBase64, hashsum.DownloadFlag, fsrc, output)
+			return hashsum.HashLister(context.Background(), hash.MD5, fsrc)
 		})
 		return nil
 	},
@@ -45,6 +45,10 @@ into |dest:path| then delete the original (if no errors on copy) in
 
 If you want to delete empty source directories after move, use the --delete-empty-src-dirs flag.
 
+To keep the top levels of the source directory structure use
+|--delete-empty-src-dirs-below N| with it and only the empty directories
+more than N levels deep will be deleted.
+
 See the [--no-traverse](/docs/#no-traverse) option for controlling
 whether rclone lists the destination directory or not.  Supplying this
 option when moving a small number of files into a large destination
@@ -55,6 +59,9 @@ can speed transfers up greatly.
 
 **Note**: Use the |-P|/|--progress| flag to view real-time transfer statistics.
 `, "|", "`"),
+	Annotations: map[string]string{
+		"rc": "sync/move",
+	},
 	Run: func(command *cobra.Command, args []string) {
 		cmd.CheckArgs(2, 2, command, args)
 		fsrc, srcFileName, fdst := cmd.NewFsSrcFileDst(args)
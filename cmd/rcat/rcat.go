@@ -49,7 +49,12 @@ and actually stream it, even if remote backend doesn't support streaming.
 
 |--size| should be the exact size of the input stream in bytes. If the
 size of the stream is different in length to the |--size| passed in
-then the transfer will likely fail.
+then the transfer will fail with an error. If the stream turns out to
+be longer than |--size| after the upload has finished then the
+uploaded file will be removed.
+
+Knowing the size in advance lets the backend choose its multipart
+upload parameters, such as the chunk size, to suit the file.
 
 Note that the upload can also not be retried because the data is
 not kept around until the upload succeeds. If you need to transfer
@@ -23,6 +23,9 @@ to do that.
 
 To delete a path and any objects in it, use ` + "`purge`" + ` command.
 `,
+	Annotations: map[string]string{
+		"rc": "operations/rmdir",
+	},
 	Run: func(command *cobra.Command, args []string) {
 		cmd.CheckArgs(1, 1, command, args)
 		fdst := cmd.NewFsDir(args)
@@ -36,6 +36,9 @@ option ` + "`--rmdirs`" + `).
 
 To delete a path and any objects in it, use ` + "`purge`" + ` command.
 `,
+	Annotations: map[string]string{
+		"rc": "operations/rmdirs",
+	},
 	Run: func(command *cobra.Command, args []string) {
 		cmd.CheckArgs(1, 1, command, args)
 		fdst := cmd.NewFsDir(args)
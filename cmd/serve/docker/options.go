@@ -250,6 +250,8 @@ func getVFSOption(vfsOpt *vfscommon.Options, opt rc.Params, key string) (ok bool
 		err = getFVarP(&vfsOpt.ReadAhead, opt, key)
 	case "vfs-used-is-size":
 		vfsOpt.UsedIsSize, err = opt.GetBool(key)
+	case "vfs-disk-space-total-size":
+		err = getFVarP(&vfsOpt.DiskSpaceTotal, opt, key)
 
 	// unprefixed vfs options
 	case "no-modtime":
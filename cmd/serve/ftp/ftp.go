@@ -42,6 +42,8 @@ type Options struct {
 	BasicPass    string // password for BasicUser
 	TLSCert      string // TLS PEM key (concatenation of certificate and CA certificate)
 	TLSKey       string // TLS PEM Private key
+	ExplicitTLS  bool   // use explicit FTPS (AUTH TLS) rather than implicit
+	TLSRequired  bool   // refuse commands until the client has upgraded to TLS
 }
 
 // DefaultOpt is the default values used for Options
@@ -66,6 +68,8 @@ func AddFlags(flagSet *pflag.FlagSet) {
 	flags.StringVarP(flagSet, &Opt.BasicPass, "pass", "", Opt.BasicPass, "Password for authentication (empty value allow every password)")
 	flags.StringVarP(flagSet, &Opt.TLSCert, "cert", "", Opt.TLSCert, "TLS PEM key (concatenation of certificate and CA certificate)")
 	flags.StringVarP(flagSet, &Opt.TLSKey, "key", "", Opt.TLSKey, "TLS PEM Private key")
+	flags.BoolVarP(flagSet, &Opt.ExplicitTLS, "explicit-tls", "", Opt.ExplicitTLS, "Use explicit FTPS (AUTH TLS) instead of implicit FTPS")
+	flags.BoolVarP(flagSet, &Opt.TLSRequired, "tls-required", "", Opt.TLSRequired, "Refuse logins from clients which haven't upgraded to TLS with AUTH TLS")
 }
 
 func init() {
@@ -98,6 +102,22 @@ then using Authentication is advised - see the next section for info.
 By default this will serve files without needing a login.
 
 You can set a single username and password with the --user and --pass flags.
+
+#### TLS
+
+If you supply --cert and --key then the server will use TLS. By
+default this is implicit FTPS, where the client must start TLS as
+soon as it connects, normally on port 990.
+
+Use --explicit-tls to use explicit FTPS (RFC 4217) instead. Clients
+connect in plain text and then upgrade the connection with AUTH TLS.
+Data connections (including passive mode ones) are encrypted with TLS
+once the connection is upgraded and the client has sent PBSZ 0 and
+PROT P.
+
+With explicit FTPS plain text clients can still log in unless you
+also use --tls-required, which makes the server refuse any command
+other than AUTH TLS until the connection has been upgraded.
 ` + vfs.Help + proxy.Help,
 	Run: func(command *cobra.Command, args []string) {
 		var f fs.Fs
@@ -139,6 +159,17 @@ func newServer(ctx context.Context, f fs.Fs, opt *Options) (*server, error) {
 		return nil, errors.New("Failed to parse host:port")
 	}
 
+	useTLS := opt.TLSKey != ""
+	if (opt.TLSCert != "") != useTLS {
+		return nil, errors.New("need both --cert and --key to use TLS")
+	}
+	if !useTLS && (opt.ExplicitTLS || opt.TLSRequired) {
+		return nil, errors.New("--explicit-tls and --tls-required need --cert and --key")
+	}
+	if opt.TLSRequired && !opt.ExplicitTLS {
+		return nil, errors.New("--tls-required needs --explicit-tls as implicit FTPS always uses TLS")
+	}
+
 	s := &server{
 		f:   f,
 		ctx: ctx,
@@ -149,7 +180,7 @@ func newServer(ctx context.Context, f fs.Fs, opt *Options) (*server, error) {
 	} else {
 		s.vfs = vfs.New(f, &vfsflags.Opt)
 	}
-	s.useTLS = s.opt.TLSKey != ""
+	s.useTLS = useTLS
 
 	ftpopt := &ftp.ServerOpts{
 		Name:           "Rclone FTP Server",
@@ -164,9 +195,13 @@ func newServer(ctx context.Context, f fs.Fs, opt *Options) (*server, error) {
 		TLS:            s.useTLS,
 		CertFile:       s.opt.TLSCert,
 		KeyFile:        s.opt.TLSKey,
+		ExplicitFTPS:   s.opt.ExplicitTLS,
+		ForceTLS:       s.opt.TLSRequired,
 		//TODO implement a maximum of https://godoc.org/goftp.io/server#ServerOpts
 	}
 	s.srv = ftp.NewServer(ftpopt)
+	// NewServer doesn't copy ForceTLS into the options it uses
+	s.srv.ForceTLS = ftpopt.ForceTLS
 	return s, nil
 }
 
@@ -9,15 +9,31 @@
 package ftp
 
 import (
+	"bytes"
 	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
+	_ "github.com/rclone/rclone/backend/ftp"
 	_ "github.com/rclone/rclone/backend/local"
 	"github.com/rclone/rclone/cmd/serve/servetest"
 	"github.com/rclone/rclone/fs"
 	"github.com/rclone/rclone/fs/config/configmap"
 	"github.com/rclone/rclone/fs/config/obscure"
+	"github.com/rclone/rclone/fs/object"
+	"github.com/rclone/rclone/fs/operations"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	ftp "goftp.io/server/core"
 )
 
@@ -70,3 +86,104 @@ func TestFTP(t *testing.T) {
 
 	servetest.Run(t, "ftp", start)
 }
+
+// writeTestCert writes a self signed certificate and key for
+// localhost into dir returning their paths
+func writeTestCert(t *testing.T, dir string) (certFile, keyFile string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: testHOST},
+		DNSNames:     []string{testHOST},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, &template, &template, &key.PublicKey, key)
+	require.NoError(t, err)
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+	certFile = filepath.Join(dir, "cert.pem")
+	keyFile = filepath.Join(dir, "key.pem")
+	require.NoError(t, ioutil.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600))
+	require.NoError(t, ioutil.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer}), 0600))
+	return certFile, keyFile
+}
+
+// TestFTPExplicitTLS checks explicit FTPS with --tls-required works
+// for logins and passive data connections and refuses plain text
+// clients.
+func TestFTPExplicitTLS(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	f, err := fs.NewFs(ctx, filepath.Join(dir, "root"))
+	require.NoError(t, err)
+	require.NoError(t, f.Mkdir(ctx, ""))
+
+	opt := DefaultOpt
+	opt.ListenAddr = testHOST + ":" + testPORT
+	opt.PassivePorts = testPASSIVEPORTRANGE
+	opt.BasicUser = testUSER
+	opt.BasicPass = testPASS
+	opt.TLSCert, opt.TLSKey = writeTestCert(t, dir)
+	opt.ExplicitTLS = true
+	opt.TLSRequired = true
+
+	w, err := newServer(ctx, f, &opt)
+	require.NoError(t, err)
+	quit := make(chan struct{})
+	go func() {
+		err := w.serve()
+		close(quit)
+		if err != ftp.ErrServerClosed {
+			assert.NoError(t, err)
+		}
+	}()
+	defer func() {
+		assert.NoError(t, w.close())
+		<-quit
+	}()
+
+	remote := ":ftp,host=" + testHOST + ",port=" + testPORT + ",user=" + testUSER + ",pass=" + obscure.MustObscure(testPASS)
+
+	// Plain text logins should be refused
+	_, err = fs.NewFs(ctx, remote+":")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "AUTH TLS required")
+
+	// TLS logins and data transfers should work
+	ftls, err := fs.NewFs(ctx, remote+",explicit_tls=true,no_check_certificate=true:")
+	require.NoError(t, err)
+	contents := "hello over TLS"
+	src := object.NewStaticObjectInfo("file.txt", time.Now(), int64(len(contents)), true, nil, nil)
+	_, err = ftls.Put(ctx, strings.NewReader(contents), src)
+	require.NoError(t, err)
+	var buf bytes.Buffer
+	require.NoError(t, operations.Cat(ctx, ftls, &buf, 0, -1))
+	assert.Equal(t, contents, buf.String())
+}
+
+func TestFTPTLSOptions(t *testing.T) {
+	ctx := context.Background()
+	for _, test := range []struct {
+		cert, key   string
+		explicitTLS bool
+		tlsRequired bool
+		wantErr     string
+	}{
+		{"cert.pem", "", false, false, "need both --cert and --key"},
+		{"", "key.pem", false, false, "need both --cert and --key"},
+		{"", "", true, false, "need --cert and --key"},
+		{"", "", false, true, "need --cert and --key"},
+		{"cert.pem", "key.pem", false, true, "--tls-required needs --explicit-tls"},
+	} {
+		opt := DefaultOpt
+		opt.TLSCert, opt.TLSKey = test.cert, test.key
+		opt.ExplicitTLS, opt.TLSRequired = test.explicitTLS, test.tlsRequired
+		_, err := newServer(ctx, nil, &opt)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), test.wantErr)
+	}
+}
@@ -37,10 +37,15 @@ to be used within the template to server pages:
 |-- .Text     | The Name of the directory. |
 | .Entries    | Information about a specific file/directory. |
 |-- .URL      | The 'url' of an entry.  |
-|-- .Leaf     | Currently same as 'URL' but intended to be 'just' the name. |
+|-- .Leaf     | The name of the entry, not URL encoded. |
 |-- .IsDir    | Boolean for if an entry is a directory or not. |
 |-- .Size     | Size in Bytes of the entry. |
 |-- .ModTime  | The UTC timestamp of an entry. |
+
+The default template is shipped in the rclone source as
+[index.html](https://github.com/rclone/rclone/blob/master/cmd/serve/http/data/templates/index.html)
+and makes a good starting point for a custom one. Templates use the
+Go [html/template](https://golang.org/pkg/html/template/) syntax.
 `
 
 // Options for the templating functionality
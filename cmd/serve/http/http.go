@@ -1,6 +1,7 @@
 package http
 
 import (
+	"compress/flate"
 	"html/template"
 	"io"
 	"log"
@@ -17,6 +18,7 @@ import (
 	"github.com/rclone/rclone/cmd/serve/http/data"
 	"github.com/rclone/rclone/fs"
 	"github.com/rclone/rclone/fs/accounting"
+	"github.com/rclone/rclone/fs/config/flags"
 	httplib "github.com/rclone/rclone/lib/http"
 	"github.com/rclone/rclone/lib/http/auth"
 	"github.com/rclone/rclone/lib/http/serve"
@@ -28,6 +30,7 @@ import (
 // Options required for http server
 type Options struct {
 	data.Options
+	Gzip bool // compress compressible content
 }
 
 // DefaultOpt is the default values used for Options
@@ -38,6 +41,7 @@ var Opt = DefaultOpt
 
 func init() {
 	data.AddFlags(Command.Flags(), "", &Opt.Options)
+	flags.BoolVarP(Command.Flags(), &Opt.Gzip, "gzip", "", Opt.Gzip, "Compress compressible content with gzip if the client accepts it")
 	httplib.AddFlags(Command.Flags())
 	auth.AddFlags(Command.Flags())
 	vfsflags.AddFlags(Command.Flags())
@@ -58,6 +62,12 @@ The server will log errors.  Use -v to see access logs.
 
 --bwlimit will be respected for file transfers.  Use --stats to
 control the stats printing.
+
+Use --gzip to compress text, JSON, XML and JavaScript files and the
+directory listings on the fly for clients which send an
+"Accept-Encoding: gzip" header. Files of other types, which are
+usually compressed already, are served as they are. Range requests are
+never compressed so they return the bytes asked for.
 ` + httplib.Help + data.Help + auth.Help + vfs.Help,
 	Run: func(command *cobra.Command, args []string) {
 		cmd.CheckArgs(1, 1, command, args)
@@ -80,6 +90,7 @@ type server struct {
 	f            fs.Fs
 	vfs          *vfs.VFS
 	HTMLTemplate *template.Template // HTML template for web interface
+	gzip         bool               // set to compress compressible content
 }
 
 func newServer(f fs.Fs, templatePath string) *server {
@@ -91,6 +102,7 @@ func newServer(f fs.Fs, templatePath string) *server {
 		f:            f,
 		vfs:          vfs.New(f, &vfsflags.Opt),
 		HTMLTemplate: htmlTemplate,
+		gzip:         Opt.Gzip,
 	}
 	return s
 }
@@ -103,10 +115,40 @@ func (s *server) Bind(router chi.Router) {
 		middleware.SetHeader("Accept-Ranges", "bytes"),
 		middleware.SetHeader("Server", "rclone/"+fs.Version),
 	)
+	if s.gzip {
+		router.Use(compressUnlessRange)
+	}
 	router.Get("/*", s.handler)
 	router.Head("/*", s.handler)
 }
 
+// compressibleTypes are the content types compressed with --gzip
+var compressibleTypes = []string{
+	"text/*",
+	"application/javascript",
+	"application/x-javascript",
+	"application/json",
+	"application/x-ndjson",
+	"application/xml",
+	"application/atom+xml",
+	"application/rss+xml",
+	"image/svg+xml",
+}
+
+// compressUnlessRange compresses the responses to requests which
+// aren't range requests, as the byte ranges are of the uncompressed
+// content
+func compressUnlessRange(next http.Handler) http.Handler {
+	compressed := middleware.Compress(flate.DefaultCompression, compressibleTypes...)(next)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Range") != "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		compressed.ServeHTTP(w, r)
+	})
+}
+
 // handler reads incoming requests and dispatches them
 func (s *server) handler(w http.ResponseWriter, r *http.Request) {
 	isDir := strings.HasSuffix(r.URL.Path, "/")
@@ -157,6 +199,10 @@ func (s *server) serveDir(w http.ResponseWriter, r *http.Request, dirRemote stri
 	// Set the Last-Modified header to the timestamp
 	w.Header().Set("Last-Modified", dir.ModTime().UTC().Format(http.TimeFormat))
 
+	// Set the content type rather than letting the http server sniff
+	// it so --gzip can see it
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+
 	directory.Serve(w, r)
 }
 
@@ -1,14 +1,17 @@
 package http
 
 import (
+	"compress/gzip"
 	"context"
 	"flag"
 	"io/ioutil"
 	"net/http"
+	"net/http/httptest"
 	"strings"
 	"testing"
 	"time"
 
+	"github.com/go-chi/chi/v5"
 	_ "github.com/rclone/rclone/backend/local"
 	"github.com/rclone/rclone/fs"
 	"github.com/rclone/rclone/fs/config/configfile"
@@ -229,6 +232,64 @@ func TestGET(t *testing.T) {
 	}
 }
 
+func TestGzip(t *testing.T) {
+	oldGzip := Opt.Gzip
+	Opt.Gzip = true
+	defer func() { Opt.Gzip = oldGzip }()
+	router := chi.NewRouter()
+	newServer(httpServer.f, testTemplate).Bind(router)
+	ts := httptest.NewServer(router)
+	defer ts.Close()
+
+	// Don't let the client decompress the responses
+	client := &http.Client{Transport: &http.Transport{DisableCompression: true}}
+	get := func(url, acceptEncoding, rangeHeader string) (*http.Response, []byte) {
+		req, err := http.NewRequest("GET", ts.URL+"/"+url, nil)
+		require.NoError(t, err)
+		if acceptEncoding != "" {
+			req.Header.Set("Accept-Encoding", acceptEncoding)
+		}
+		if rangeHeader != "" {
+			req.Header.Set("Range", rangeHeader)
+		}
+		resp, err := client.Do(req)
+		require.NoError(t, err)
+		body, err := ioutil.ReadAll(resp.Body)
+		require.NoError(t, resp.Body.Close())
+		require.NoError(t, err)
+		return resp, body
+	}
+	gunzip := func(body []byte) string {
+		zr, err := gzip.NewReader(strings.NewReader(string(body)))
+		require.NoError(t, err)
+		out, err := ioutil.ReadAll(zr)
+		require.NoError(t, err)
+		return string(out)
+	}
+
+	resp, body := get("two.txt", "gzip", "")
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "gzip", resp.Header.Get("Content-Encoding"))
+	assert.NotEqual(t, "11", resp.Header.Get("Content-Length"))
+	assert.Equal(t, "0123456789\n", gunzip(body))
+
+	resp, body = get("three/", "gzip", "")
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "gzip", resp.Header.Get("Content-Encoding"))
+	assert.Contains(t, gunzip(body), "a.txt")
+
+	// Not compressed unless the client accepts it
+	resp, body = get("two.txt", "", "")
+	assert.Equal(t, "", resp.Header.Get("Content-Encoding"))
+	assert.Equal(t, "0123456789\n", string(body))
+
+	// Range requests aren't compressed
+	resp, body = get("two.txt", "gzip", "bytes=2-5")
+	assert.Equal(t, http.StatusPartialContent, resp.StatusCode)
+	assert.Equal(t, "", resp.Header.Get("Content-Encoding"))
+	assert.Equal(t, "2345", string(body))
+}
+
 func TestFinalise(t *testing.T) {
 	_ = httplib.Shutdown()
 }
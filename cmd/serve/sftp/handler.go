@@ -83,6 +83,9 @@ func (v vfsHandler) Filecmd(r *sftp.Request) error {
 		// link.symlink = r.Filepath
 		// v.files[r.Target] = link
 		return sftp.ErrSshFxOpUnsupported
+	case "Link":
+		// hardlinks aren't supported by the VFS
+		return sftp.ErrSshFxOpUnsupported
 	}
 	return nil
 }
@@ -35,7 +35,7 @@ as a relative path).
 
 This command can also hash data received on STDIN, if not passing
 a remote:path.
-`,
+` + hashsum.ResumeHelp,
 	RunE: func(command *cobra.Command, args []string) error {
 		cmd.CheckArgs(0, 1, command, args)
 		if found, err := hashsum.CreateFromStdinArg(hash.SHA1, args, 0); found {
@@ -47,15 +47,7 @@ a remote:path.
 				fsum, sumFile := cmd.NewFsFile(hashsum.ChecksumFile)
 				return operations.CheckSum(context.Background(), fsrc, fsum, sumFile, hash.SHA1, nil, hashsum.DownloadFlag)
 			}
-			if hashsum.HashsumOutfile == "" {
-				return operations.HashLister(context.Background(), hash.SHA1, hashsum.OutputBase64, hashsum.DownloadFlag, fsrc, nil)
-			}
-			output, close, err := hashsum.GetHashsumOutput(hashsum.HashsumOutfile)
-			if err != nil {
-				return err
-			}
-			defer close()
-			return operations.HashLister(context.Background(), hash.SHA1, hashsum.OutputBase64, hashsum.DownloadFlag, fsrc, output)
+			return hashsum.HashLister(context.Background(), hash.SHA1, fsrc)
 		})
 		return nil
 	},
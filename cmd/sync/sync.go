@@ -2,8 +2,12 @@ package sync
 
 import (
 	"context"
+	"errors"
+	"io"
+	"os"
 
 	"github.com/rclone/rclone/cmd"
+	"github.com/rclone/rclone/fs"
 	"github.com/rclone/rclone/fs/config/flags"
 	"github.com/rclone/rclone/fs/operations"
 	"github.com/rclone/rclone/fs/sync"
@@ -12,12 +16,14 @@ import (
 
 var (
 	createEmptySrcDirs = false
+	changesFrom        = ""
 )
 
 func init() {
 	cmd.Root.AddCommand(commandDefinition)
 	cmdFlags := commandDefinition.Flags()
 	flags.BoolVarP(cmdFlags, &createEmptySrcDirs, "create-empty-src-dirs", "", createEmptySrcDirs, "Create empty source dirs on destination after sync")
+	flags.StringVarP(cmdFlags, &changesFrom, "changes-from", "", changesFrom, "Only sync the changed paths read from this file (use - for stdin)")
 }
 
 var commandDefinition = &cobra.Command{
@@ -51,10 +57,40 @@ go there.
 
 **Note**: Use the ` + "`rclone dedupe`" + ` command to deal with "Duplicate object/directory found in source/destination - ignoring" errors.
 See [this forum post](https://forum.rclone.org/t/sync-not-clearing-duplicates/14372) for more info.
+
+### Syncing changed paths only
+
+Use ` + "`--changes-from`" + ` to sync only the paths which have changed rather
+than comparing the whole of the source and destination. It reads the
+changed paths, relative to source:path, one per line from a file or
+from stdin if set to ` + "`-`" + `, for example from a change notification
+feed. A changed path which no longer exists in the source is deleted
+from the destination.
+
+    my-change-feed | rclone sync --changes-from - source:path dest:path
+
+The paths are synced in batches as they arrive so rclone keeps running
+until the input is closed. The paths should be files - use the usual
+filter flags to restrict which of them are synced. ` + "`--delete-excluded`" + `
+is ignored with ` + "`--changes-from`" + ` as every path not in a batch would be
+excluded from the sync of that batch.
 `,
+	Annotations: map[string]string{
+		"rc": "sync/sync",
+	},
 	Run: func(command *cobra.Command, args []string) {
 		cmd.CheckArgs(2, 2, command, args)
 		fsrc, srcFileName, fdst := cmd.NewFsSrcFileDst(args)
+		if changesFrom != "" {
+			// The changes can't be read again so don't retry
+			cmd.Run(false, true, command, func() error {
+				if srcFileName != "" {
+					return errors.New("can't use --changes-from when the source is a file")
+				}
+				return syncChanges(context.Background(), fdst, fsrc)
+			})
+			return
+		}
 		cmd.Run(true, true, command, func() error {
 			if srcFileName == "" {
 				return sync.Sync(context.Background(), fdst, fsrc, createEmptySrcDirs)
@@ -63,3 +99,17 @@ See [this forum post](https://forum.rclone.org/t/sync-not-clearing-duplicates/14
 		})
 	},
 }
+
+// syncChanges syncs the paths read from --changes-from
+func syncChanges(ctx context.Context, fdst, fsrc fs.Fs) (err error) {
+	var in io.Reader = os.Stdin
+	if changesFrom != "-" {
+		f, err := os.Open(changesFrom)
+		if err != nil {
+			return err
+		}
+		defer fs.CheckClose(f, &err)
+		in = f
+	}
+	return sync.SyncChanges(ctx, fdst, fsrc, in, createEmptySrcDirs)
+}
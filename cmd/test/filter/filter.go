@@ -0,0 +1,51 @@
+package filter
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/rclone/rclone/cmd"
+	"github.com/rclone/rclone/cmd/test"
+	"github.com/rclone/rclone/fs/filter"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	test.Command.AddCommand(commandDefinition)
+}
+
+var commandDefinition = &cobra.Command{
+	Use:   "filter path [path]*",
+	Short: `Explain which filter rule includes or excludes each path.`,
+	Long: `This command evaluates the filter flags, such as ` + "`--filter-from`" + `, for
+each path given and reports whether it would be included or excluded
+and which rule decided it.
+
+Paths are relative to the root of the transfer, as used in the filter
+rules. A path ending in ` + "`/`" + ` is treated as a directory and checked
+against the directory rules.
+
+    rclone test filter --filter-from rules.txt dir/file.jpg secret/
+    dir/file.jpg: included by rule "+ (^|/)[^/]*\.jpg$" from --filter-from rules.txt:4
+    secret/: excluded by rule "- ^secret/.*$" from --filter-from rules.txt:2
+
+The rules are shown as the regular expressions they were converted to
+along with the flag, file and line number they came from. Use
+` + "`--dump filters`" + ` to see all the rules.
+
+Only the path is considered so the size and age filters and
+` + "`--exclude-if-present`" + ` are not checked.
+`,
+	Run: func(command *cobra.Command, args []string) {
+		cmd.CheckArgs(1, 1e6, command, args)
+		cmd.Run(false, false, command, func() error {
+			fi := filter.GetConfig(context.Background())
+			for _, remote := range args {
+				isDir := strings.HasSuffix(remote, "/")
+				fmt.Printf("%s: %v\n", remote, fi.Explain(remote, isDir))
+			}
+			return nil
+		})
+	},
+}
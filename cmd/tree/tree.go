@@ -8,6 +8,7 @@ import (
 	"path"
 	"path/filepath"
 	"strings"
+	gosync "sync"
 	"time"
 
 	"github.com/a8m/tree"
@@ -25,6 +26,7 @@ var (
 	outFileName string
 	noReport    bool
 	sort        string
+	human       bool
 )
 
 func init() {
@@ -43,6 +45,7 @@ func init() {
 	flags.StringVarP(cmdFlags, &outFileName, "output", "o", "", "Output to file instead of stdout")
 	// Files
 	flags.BoolVarP(cmdFlags, &opts.ByteSize, "size", "s", false, "Print the size in bytes of each file.")
+	flags.BoolVarP(cmdFlags, &human, "human", "", false, "Print the size in a more human readable way.")
 	flags.BoolVarP(cmdFlags, &opts.FileMode, "protections", "p", false, "Print the protections for each file.")
 	// flags.BoolVarP(cmdFlags, &opts.ShowUid, "uid", "", false, "Displays file owner or UID number.")
 	// flags.BoolVarP(cmdFlags, &opts.ShowGid, "gid", "", false, "Displays file group owner or GID number.")
@@ -66,7 +69,7 @@ func init() {
 var commandDefinition = &cobra.Command{
 	Use:   "tree remote:path",
 	Short: `List the contents of the remote in a tree like fashion.`,
-	Long: `
+	Long: strings.ReplaceAll(`
 rclone tree lists the contents of a remote in a similar way to the
 unix tree command.
 
@@ -89,7 +92,15 @@ You can use any of the filtering options with the tree command (e.g.
 The tree command has many options for controlling the listing which
 are compatible with the tree command.  Note that not all of them have
 short options as they conflict with rclone's short options.
-`,
+
+With |--size| directories show the total size of the files in them,
+like |du|, and |--human| or |--human-readable| shows the sizes in a
+human readable format. If the depth of the tree is limited with
+|--level| or |--max-depth| the sizes of the directories at the bottom
+of the tree still include all the files below them. These are found
+with a separate listing which only keeps the totals so it works with
+large trees.
+`, "|", "`"),
 	RunE: func(command *cobra.Command, args []string) error {
 		cmd.CheckArgs(1, 1, command, args)
 		fsrc := cmd.NewFsSrc(args)
@@ -107,7 +118,7 @@ short options as they conflict with rclone's short options.
 		opts.NameSort = sort == "name"
 		opts.SizeSort = sort == "size"
 		ci := fs.GetConfig(context.Background())
-		opts.UnitSize = ci.HumanReadable
+		opts.UnitSize = ci.HumanReadable || human
 		if opts.DeepLevel == 0 {
 			opts.DeepLevel = ci.MaxDepth
 		}
@@ -124,6 +135,15 @@ func Tree(fsrc fs.Fs, outFile io.Writer, opts *tree.Options) error {
 	if err != nil {
 		return err
 	}
+	if opts.DeepLevel > 0 && (opts.ByteSize || opts.UnitSize) {
+		// The directories at the bottom of the tree don't have their
+		// contents so find their sizes separately
+		sizes, err := dirSizes(context.Background(), fsrc, opts.DeepLevel)
+		if err != nil {
+			return err
+		}
+		opts = showDirSizes(opts, sizes)
+	}
 	opts.Fs = NewFs(dirs)
 	opts.OutFile = outFile
 	inf := tree.New("/")
@@ -143,6 +163,117 @@ func Tree(fsrc fs.Fs, outFile io.Writer, opts *tree.Options) error {
 	return nil
 }
 
+// dirSizes returns the total size of the files in each directory up
+// to maxLevel deep in fsrc. The directories at maxLevel include the
+// sizes of all the files below them.
+//
+// Only the totals are kept so this can be used on large trees.
+func dirSizes(ctx context.Context, fsrc fs.Fs, maxLevel int) (map[string]int64, error) {
+	var mu gosync.Mutex
+	sizes := map[string]int64{}
+	err := walk.ListR(ctx, fsrc, "", false, -1, walk.ListObjects, func(entries fs.DirEntries) error {
+		mu.Lock()
+		defer mu.Unlock()
+		for _, entry := range entries {
+			size := entry.Size()
+			if size < 0 {
+				continue
+			}
+			dir := path.Dir(entry.Remote())
+			var parts []string
+			if dir != "." {
+				parts = strings.Split(dir, "/")
+			}
+			for i := 0; i <= len(parts) && i <= maxLevel; i++ {
+				sizes[strings.Join(parts[:i], "/")] += size
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to find directory sizes: %w", err)
+	}
+	return sizes, nil
+}
+
+// showDirSizes returns a copy of opts which shows the size of each
+// directory from sizes rather than the total of the files in the
+// tree. The other properties are shown as they would be by tree.
+func showDirSizes(opts *tree.Options, sizes map[string]int64) *tree.Options {
+	newOpts := *opts
+	newOpts.ByteSize, newOpts.UnitSize = false, false
+	newOpts.FileMode, newOpts.LastMod = false, false
+	newOpts.Colorize = true
+	formatSize := func(size int64) string {
+		if opts.UnitSize {
+			return fmt.Sprintf("%4s", formatBytes(size))
+		}
+		return fmt.Sprintf("%11d", size)
+	}
+	newOpts.Color = func(node *tree.Node, name string) string {
+		var props []string
+		if node.IsDir() {
+			dir := strings.TrimLeft(filepath.ToSlash(node.Path()), "/")
+			props = append(props, formatSize(sizes[dir]))
+		} else {
+			if opts.FileMode {
+				props = append(props, node.Mode().String())
+			}
+			props = append(props, formatSize(node.Size()))
+			if opts.LastMod {
+				props = append(props, node.ModTime().Format("Jan 02 15:04"))
+			}
+		}
+		if opts.Colorize {
+			name = tree.ANSIColor(node, name)
+		}
+		return "[" + strings.Join(props, " ") + "]  " + name
+	}
+	return &newOpts
+}
+
+// formatBytes converts bytes to a human readable string in the same
+// way as tree, e.g. 2.0M, 64K, 52
+func formatBytes(i int64) string {
+	const (
+		KB int64 = 1 << (10 * (iota + 1))
+		MB
+		GB
+		TB
+		PB
+		EB
+	)
+	var n float64
+	sFmt, eFmt := "%.01f", ""
+	switch {
+	case i > EB:
+		eFmt = "E"
+		n = float64(i) / float64(EB)
+	case i > PB:
+		eFmt = "P"
+		n = float64(i) / float64(PB)
+	case i > TB:
+		eFmt = "T"
+		n = float64(i) / float64(TB)
+	case i > GB:
+		eFmt = "G"
+		n = float64(i) / float64(GB)
+	case i > MB:
+		eFmt = "M"
+		n = float64(i) / float64(MB)
+	case i > KB:
+		eFmt = "K"
+		n = float64(i) / float64(KB)
+	default:
+		sFmt = "%.0f"
+		n = float64(i)
+	}
+	if eFmt != "" && n >= 10 {
+		sFmt = "%.0f"
+	}
+	return fmt.Sprintf(sFmt+eFmt, n)
+}
+
 // FileInfo maps an fs.DirEntry into an os.FileInfo
 type FileInfo struct {
 	entry fs.DirEntry
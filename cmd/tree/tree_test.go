@@ -3,12 +3,17 @@ package tree
 import (
 	"bytes"
 	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/a8m/tree"
 	_ "github.com/rclone/rclone/backend/local"
 	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fs/log/logflags"
 	"github.com/rclone/rclone/fstest"
+	"github.com/spf13/pflag"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -33,3 +38,40 @@ func TestTree(t *testing.T) {
 1 directories, 5 files
 `, buf.String())
 }
+
+func TestTreeSizeLevel(t *testing.T) {
+	fstest.Initialise()
+
+	dir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "a", "b", "c"), 0777))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "a", "b", "c", "file1"), make([]byte, 100), 0666))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "a", "b", "file2"), make([]byte, 20), 0666))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "a", "file3"), make([]byte, 3), 0666))
+
+	f, err := fs.NewFs(context.Background(), dir)
+	require.NoError(t, err)
+
+	buf := new(bytes.Buffer)
+	err = Tree(f, buf, &tree.Options{ByteSize: true, DeepLevel: 2})
+	require.NoError(t, err)
+	assert.Equal(t, `[        123]  /
+└── [        123]  a
+    ├── [        120]  b
+    └── [          3]  file3
+
+2 directories, 1 files
+`, buf.String())
+}
+
+func TestTreeColorFlag(t *testing.T) {
+	// The global log flags mustn't clash with tree's -C/--color
+	global := pflag.NewFlagSet("global", pflag.ContinueOnError)
+	logflags.AddFlags(global)
+	assert.Nil(t, global.Lookup("color"))
+	assert.Nil(t, global.ShorthandLookup("C"))
+
+	flag := commandDefinition.Flags().Lookup("color")
+	require.NotNil(t, flag)
+	assert.Equal(t, "C", flag.Shorthand)
+	assert.Equal(t, "bool", flag.Value.Type())
+}
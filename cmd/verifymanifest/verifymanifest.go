@@ -0,0 +1,67 @@
+package verifymanifest
+
+import (
+	"context"
+	"strings"
+
+	"github.com/rclone/rclone/cmd"
+	"github.com/rclone/rclone/cmd/check" // for common flags
+	"github.com/rclone/rclone/fs/config/flags"
+	"github.com/rclone/rclone/fs/operations"
+	"github.com/spf13/cobra"
+)
+
+var download = false
+
+func init() {
+	cmd.Root.AddCommand(commandDefinition)
+	cmdFlags := commandDefinition.Flags()
+	flags.BoolVarP(cmdFlags, &download, "download", "", download, "Check by hashing the contents")
+	check.AddFlags(cmdFlags)
+}
+
+var commandDefinition = &cobra.Command{
+	Use:   "verify-manifest manifest remote:path",
+	Short: `Checks the files in the remote against a manifest.`,
+	Long: strings.ReplaceAll(`
+Checks that the files in the remote still match a manifest written by
+an earlier run. This can be used to check an archive hasn't changed
+since a known good snapshot without needing the original source. It
+doesn't alter the remote.
+
+The manifest is a JSON list of files in the format written by
+|rclone lsjson -R --hash|, so a manifest can be made with, for example
+
+    rclone lsjson -R --files-only --hash remote:path > manifest.json
+
+The paths in the manifest are relative to |remote:path|.
+
+The size of each file is checked and its hash if the manifest has a
+hash of a type the remote supports. If you supply the |--download|
+flag, it will download the data from the remote and calculate the
+hash on the fly instead, so any hash in the manifest can be checked.
+This can be useful for remotes that don't support hashes or if you
+really want to check all the data.
+
+Any differences are reported and it exits with a non-zero exit code
+if there are any, just like |rclone check|. The manifest is treated as
+the source and the remote as the destination in the help below.
+
+Note that hash values in the manifest are treated as case insensitive.
+`, "|", "`") + check.FlagsHelp,
+	RunE: func(command *cobra.Command, args []string) error {
+		cmd.CheckArgs(2, 2, command, args)
+		fmanifest, manifestFile, fdst := cmd.NewFsSrcFileDst(args)
+
+		cmd.Run(false, true, command, func() error {
+			opt, close, err := check.GetCheckOpt(nil, fdst)
+			if err != nil {
+				return err
+			}
+			defer close()
+
+			return operations.VerifyManifest(context.Background(), fdst, fmanifest, manifestFile, opt, download)
+		})
+		return nil
+	},
+}
@@ -0,0 +1,94 @@
+package cmd
+
+import (
+	"errors"
+
+	"github.com/spf13/cobra"
+)
+
+// ErrorSkipCommand is used as a return value from a WalkFunc to
+// indicate that the commands below the command in the call are to be
+// skipped. It is not returned as an error by any function.
+var ErrorSkipCommand = errors.New("skip the commands below this command")
+
+// WalkFunc is the type of the function called for each command
+// visited by WalkCommands.
+//
+// aliases are the other command paths the command can be run with,
+// using the aliases of the command and of the commands above it, e.g.
+// "rclone config ls" and "rclone cfg list". It is empty if there are
+// none.
+//
+// If an error is returned the walk stops and it is returned, unless it
+// is ErrorSkipCommand, in which case the commands below c are skipped.
+type WalkFunc func(c *cobra.Command, aliases []string) error
+
+// WalkCommands calls fn for Root and each command below it.
+//
+// Each command is visited before the commands below it and the
+// commands below each command are visited in the order cobra keeps
+// them in, which is sorted by name unless sorting has been turned off.
+func WalkCommands(fn WalkFunc) error {
+	return WalkCommandTree(Root, fn)
+}
+
+// WalkCommandTree calls fn for root and each command below it in the
+// same way as WalkCommands.
+//
+// root doesn't have to be the top of its tree. The aliases of the
+// commands above it are used for the aliases passed to fn.
+func WalkCommandTree(root *cobra.Command, fn WalkFunc) error {
+	err := walkCommands(root, commandAliases(root), fn)
+	if err == ErrorSkipCommand {
+		err = nil
+	}
+	return err
+}
+
+// walkCommands calls fn for c and the commands below it
+func walkCommands(c *cobra.Command, aliases []string, fn WalkFunc) error {
+	err := fn(c, aliases)
+	if err == ErrorSkipCommand {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	for _, child := range c.Commands() {
+		err = walkCommands(child, childAliases(c, aliases, child), fn)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// commandAliases returns the other command paths c can be run with
+func commandAliases(c *cobra.Command) []string {
+	if !c.HasParent() {
+		return childAliases(nil, nil, c)
+	}
+	parent := c.Parent()
+	return childAliases(parent, commandAliases(parent), c)
+}
+
+// childAliases returns the other command paths child can be run with
+// given its parent and the aliases of the parent.
+//
+// These are the path of the parent followed by the aliases of child
+// and the aliases of the parent followed by the name or the aliases of
+// child.
+func childAliases(parent *cobra.Command, parentAliases []string, child *cobra.Command) (aliases []string) {
+	if parent == nil {
+		return append(aliases, child.Aliases...)
+	}
+	for _, alias := range child.Aliases {
+		aliases = append(aliases, parent.CommandPath()+" "+alias)
+	}
+	for _, parentAlias := range parentAliases {
+		aliases = append(aliases, parentAlias+" "+child.Name())
+		for _, alias := range child.Aliases {
+			aliases = append(aliases, parentAlias+" "+alias)
+		}
+	}
+	return aliases
+}
@@ -0,0 +1,77 @@
+package cmd
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWalkCommandTree(t *testing.T) {
+	root := &cobra.Command{Use: "rclone"}
+	config := &cobra.Command{Use: "config", Aliases: []string{"cfg"}}
+	ls := &cobra.Command{Use: "ls", Aliases: []string{"list"}}
+	create := &cobra.Command{Use: "create"}
+	mount := &cobra.Command{Use: "mount"}
+	unmount := &cobra.Command{Use: "unmount"}
+	root.AddCommand(config, mount)
+	config.AddCommand(ls, create)
+	mount.AddCommand(unmount)
+
+	visited := map[string][]string{}
+	var order []string
+	err := WalkCommandTree(root, func(c *cobra.Command, aliases []string) error {
+		order = append(order, c.CommandPath())
+		visited[c.CommandPath()] = aliases
+		if c == mount {
+			return ErrorSkipCommand
+		}
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"rclone", "rclone config", "rclone config create", "rclone config ls", "rclone mount"}, order)
+	assert.Empty(t, visited["rclone"])
+	assert.Equal(t, []string{"rclone cfg"}, visited["rclone config"])
+	assert.Equal(t, []string{"rclone cfg create"}, visited["rclone config create"])
+	assert.Equal(t, []string{"rclone config list", "rclone cfg ls", "rclone cfg list"}, visited["rclone config ls"])
+
+	// starting below the top uses the aliases above
+	var lsAliases []string
+	err = WalkCommandTree(ls, func(c *cobra.Command, aliases []string) error {
+		lsAliases = aliases
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, visited["rclone config ls"], lsAliases)
+
+	// errors stop the walk
+	errPotato := errors.New("potato")
+	order = nil
+	err = WalkCommandTree(root, func(c *cobra.Command, aliases []string) error {
+		order = append(order, c.CommandPath())
+		if c == config {
+			return errPotato
+		}
+		return nil
+	})
+	assert.Equal(t, errPotato, err)
+	assert.Equal(t, []string{"rclone", "rclone config"}, order)
+
+	// skipping the root isn't an error
+	assert.NoError(t, WalkCommandTree(root, func(c *cobra.Command, aliases []string) error {
+		return ErrorSkipCommand
+	}))
+}
+
+func TestWalkCommands(t *testing.T) {
+	found := false
+	err := WalkCommands(func(c *cobra.Command, aliases []string) error {
+		if c == Root {
+			found = true
+		}
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.True(t, found)
+}
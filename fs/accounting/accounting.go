@@ -29,6 +29,12 @@ var ErrorMaxTransferLimitReachedFatal = fserrors.FatalError(ErrorMaxTransferLimi
 // transfer limit is reached and a graceful stop is required.
 var ErrorMaxTransferLimitReachedGraceful = fserrors.NoRetryError(ErrorMaxTransferLimitReached)
 
+// ErrorMaxTransferLimitSkipped is returned from operations.Copy with
+// --cutoff-mode soft when a transfer can't complete under the max
+// transfer limit. Only that transfer is skipped - others which fit
+// under the limit carry on.
+var ErrorMaxTransferLimitSkipped = fserrors.NoRetryError(fmt.Errorf("not transferring as it would go over the limit: %w", ErrorMaxTransferLimitReached))
+
 // Start sets up the accounting, in particular the bandwidth limiting
 func Start(ctx context.Context) {
 	// Start the token bucket limiter
@@ -57,6 +63,7 @@ type Account struct {
 	close   io.Closer
 	size    int64
 	name    string
+	key     string        // key in the inProgress map
 	closed  bool          // set if the file is closed
 	exit    chan struct{} // channel that will be closed when transfer is finished
 	withBuf bool          // is using a buffered in
@@ -82,6 +89,12 @@ const averagePeriod = 16 // period to do exponentially weighted averages over
 // newAccountSizeName makes an Account reader for an io.ReadCloser of
 // the given size and name
 func newAccountSizeName(ctx context.Context, stats *StatsInfo, in io.ReadCloser, size int64, name string) *Account {
+	return newAccountSizeNameKey(ctx, stats, in, size, name, name)
+}
+
+// newAccountSizeNameKey makes an Account reader for an io.ReadCloser
+// of the given size and name which is stored under key in the stats
+func newAccountSizeNameKey(ctx context.Context, stats *StatsInfo, in io.ReadCloser, size int64, name, key string) *Account {
 	acc := &Account{
 		stats:  stats,
 		in:     in,
@@ -91,6 +104,7 @@ func newAccountSizeName(ctx context.Context, stats *StatsInfo, in io.ReadCloser,
 		origIn: in,
 		size:   size,
 		name:   name,
+		key:    key,
 		exit:   make(chan struct{}),
 		values: accountValues{
 			avg:    0,
@@ -108,7 +122,7 @@ func newAccountSizeName(ctx context.Context, stats *StatsInfo, in io.ReadCloser,
 	}
 
 	go acc.averageLoop()
-	stats.inProgress.set(acc.name, acc)
+	stats.inProgress.set(acc.key, acc)
 	return acc
 }
 
@@ -331,6 +345,9 @@ func (acc *Account) accountRead(n int) {
 // read bytes from the io.Reader passed in and account them
 func (acc *Account) read(in io.Reader, p []byte) (n int, err error) {
 	bytesUntilLimit, err := acc.checkReadBefore()
+	if err == nil {
+		err = TransferPause.wait(acc.ctx, true)
+	}
 	if err == nil {
 		n, err = in.Read(p)
 		acc.accountRead(n)
@@ -416,7 +433,7 @@ func (acc *Account) Done() {
 	acc.mu.Lock()
 	defer acc.mu.Unlock()
 	close(acc.exit)
-	acc.stats.inProgress.clear(acc.name)
+	acc.stats.inProgress.clear(acc.key)
 }
 
 // progress returns bytes read as well as the size.
@@ -0,0 +1,158 @@
+package accounting
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fs/rc"
+)
+
+// histogramSizes are the upper limits of the size buckets used by
+// --stats-histogram. The last bucket has no upper limit.
+var histogramSizes = [...]fs.SizeSuffix{
+	fs.Kibi,
+	64 * fs.Kibi,
+	fs.Mebi,
+	16 * fs.Mebi,
+	256 * fs.Mebi,
+	fs.Gibi,
+}
+
+// histogramPercentiles are the duration percentiles shown
+var histogramPercentiles = []float64{50, 90, 99}
+
+// sizeBucket holds the completed transfers of one size range
+type sizeBucket struct {
+	count    int64         // number of transfers
+	bytes    int64         // bytes transferred
+	duration time.Duration // total time taken by the transfers
+}
+
+// speed returns the average speed of the transfers in the bucket in
+// bytes/second
+func (b *sizeBucket) speed() float64 {
+	if b.duration <= 0 {
+		return 0
+	}
+	return float64(b.bytes) / b.duration.Seconds()
+}
+
+// histogram records the sizes and durations of completed transfers
+// for --stats-histogram
+type histogram struct {
+	sizes     [len(histogramSizes) + 1]sizeBucket
+	durations []time.Duration // duration of every transfer
+}
+
+// add records a transfer of size bytes which took duration
+func (h *histogram) add(size int64, duration time.Duration) {
+	i := sort.Search(len(histogramSizes), func(i int) bool {
+		return size < int64(histogramSizes[i])
+	})
+	b := &h.sizes[i]
+	b.count++
+	b.bytes += size
+	b.duration += duration
+	h.durations = append(h.durations, duration)
+}
+
+// merge adds the transfers recorded in other into h
+func (h *histogram) merge(other *histogram) {
+	for i := range h.sizes {
+		h.sizes[i].count += other.sizes[i].count
+		h.sizes[i].bytes += other.sizes[i].bytes
+		h.sizes[i].duration += other.sizes[i].duration
+	}
+	h.durations = append(h.durations, other.durations...)
+}
+
+// sizeLabel returns the name of the size range of bucket i
+func sizeLabel(i int) string {
+	switch {
+	case i == 0:
+		return "< " + histogramSizes[0].String()
+	case i == len(histogramSizes):
+		return ">= " + histogramSizes[i-1].String()
+	}
+	return histogramSizes[i-1].String() + " - " + histogramSizes[i].String()
+}
+
+// percentiles returns the durations at histogramPercentiles and the
+// maximum duration
+func (h *histogram) percentiles() (values []time.Duration, max time.Duration) {
+	if len(h.durations) == 0 {
+		return nil, 0
+	}
+	sorted := append([]time.Duration(nil), h.durations...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	for _, p := range histogramPercentiles {
+		// nearest rank
+		i := int(math.Ceil(p/100*float64(len(sorted)))) - 1
+		if i < 0 {
+			i = 0
+		}
+		values = append(values, sorted[i])
+	}
+	return values, sorted[len(sorted)-1]
+}
+
+// write writes the histogram in a compact form to out
+func (h *histogram) write(out io.Writer) {
+	_, _ = fmt.Fprintf(out, "Transfer sizes:\n")
+	for i := range h.sizes {
+		b := &h.sizes[i]
+		if b.count == 0 {
+			continue
+		}
+		_, _ = fmt.Fprintf(out, " * %-13s %8d files, %10s, %s per transfer\n",
+			sizeLabel(i)+":",
+			b.count,
+			fs.SizeSuffix(b.bytes).ByteUnit(),
+			fs.SizeSuffix(b.speed()).ByteRateUnit(),
+		)
+	}
+	values, max := h.percentiles()
+	if values == nil {
+		return
+	}
+	_, _ = fmt.Fprintf(out, "Transfer durations: ")
+	for i, p := range histogramPercentiles {
+		_, _ = fmt.Fprintf(out, "p%g %v, ", p, values[i].Truncate(time.Millisecond))
+	}
+	_, _ = fmt.Fprintf(out, "max %v\n", max.Truncate(time.Millisecond))
+}
+
+// rcStats returns the histogram for the rc
+func (h *histogram) rcStats() rc.Params {
+	sizes := make([]rc.Params, 0, len(h.sizes))
+	for i := range h.sizes {
+		b := &h.sizes[i]
+		maxSize := int64(-1)
+		if i < len(histogramSizes) {
+			maxSize = int64(histogramSizes[i])
+		}
+		sizes = append(sizes, rc.Params{
+			"maxSize":  maxSize,
+			"count":    b.count,
+			"bytes":    b.bytes,
+			"duration": b.duration.Seconds(),
+			"speed":    b.speed(),
+		})
+	}
+	durations := rc.Params{}
+	values, max := h.percentiles()
+	if values != nil {
+		for i, p := range histogramPercentiles {
+			durations[fmt.Sprintf("p%g", p)] = values[i].Seconds()
+		}
+		durations["max"] = max.Seconds()
+	}
+	return rc.Params{
+		"sizes":     sizes,
+		"durations": durations,
+	}
+}
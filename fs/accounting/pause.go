@@ -0,0 +1,184 @@
+package accounting
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fs/rc"
+)
+
+// transferPause controls pausing of the transfer queue
+type transferPause struct {
+	isPaused int32 // 1 if paused - read atomically to avoid the lock when not paused
+	mu       sync.Mutex
+	paused   bool          // set if new transfers shouldn't start
+	inFlight bool          // set if transfers in progress should stop too
+	resume   chan struct{} // closed when the pause is lifted
+	resumeIn chan struct{} // closed when the pause of transfers in progress is lifted
+}
+
+// TransferPause is the global transfer pause control
+var TransferPause transferPause
+
+// Pause stops new transfers being started until Resume is called.
+//
+// If inFlight is set then transfers in progress are stopped too.
+func (tp *transferPause) Pause(inFlight bool) {
+	tp.mu.Lock()
+	defer tp.mu.Unlock()
+	if !tp.paused {
+		tp.paused = true
+		tp.resume = make(chan struct{})
+		atomic.StoreInt32(&tp.isPaused, 1)
+	}
+	if inFlight && !tp.inFlight {
+		tp.resumeIn = make(chan struct{})
+	} else if !inFlight && tp.inFlight {
+		// Let the transfers in progress carry on
+		close(tp.resumeIn)
+	}
+	tp.inFlight = inFlight
+	if inFlight {
+		fs.Logf(nil, "Transfers paused including those in progress")
+	} else {
+		fs.Logf(nil, "Transfers paused")
+	}
+}
+
+// Resume restarts transfers stopped by Pause
+func (tp *transferPause) Resume() {
+	tp.mu.Lock()
+	defer tp.mu.Unlock()
+	if !tp.paused {
+		return
+	}
+	if tp.inFlight {
+		close(tp.resumeIn)
+	}
+	tp.paused = false
+	tp.inFlight = false
+	atomic.StoreInt32(&tp.isPaused, 0)
+	close(tp.resume)
+	fs.Logf(nil, "Transfers resumed")
+}
+
+// Paused returns whether transfers are paused and whether that
+// includes the transfers in progress
+func (tp *transferPause) Paused() (paused, inFlight bool) {
+	tp.mu.Lock()
+	defer tp.mu.Unlock()
+	return tp.paused, tp.inFlight
+}
+
+// wait blocks until the pause is lifted or ctx is cancelled. If
+// inFlight is set it only blocks if transfers in progress are paused
+// and returns as soon as they aren't.
+func (tp *transferPause) wait(ctx context.Context, inFlight bool) error {
+	// This is called on every read so don't take the lock unless paused
+	if atomic.LoadInt32(&tp.isPaused) == 0 {
+		return nil
+	}
+	tp.mu.Lock()
+	paused := tp.paused && (!inFlight || tp.inFlight)
+	resume := tp.resume
+	if inFlight {
+		resume = tp.resumeIn
+	}
+	tp.mu.Unlock()
+	if !paused {
+		return nil
+	}
+	select {
+	case <-resume:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Wait blocks while transfers are paused. It is called by
+// operations.Copy, Rcat and RcatSize before they start a transfer.
+func (tp *transferPause) Wait(ctx context.Context) error {
+	return tp.wait(ctx, false)
+}
+
+// rcPause pauses the transfers
+func (tp *transferPause) rcPause(ctx context.Context, in rc.Params) (out rc.Params, err error) {
+	inFlight, err := in.GetBool("inFlight")
+	if rc.NotErrParamNotFound(err) {
+		return nil, err
+	}
+	tp.Pause(inFlight)
+	return tp.rcStatus(), nil
+}
+
+// rcResume resumes the transfers
+func (tp *transferPause) rcResume(ctx context.Context, in rc.Params) (out rc.Params, err error) {
+	tp.Resume()
+	return tp.rcStatus(), nil
+}
+
+// rcStatus returns the pause state for the rc
+func (tp *transferPause) rcStatus() rc.Params {
+	paused, inFlight := tp.Paused()
+	return rc.Params{
+		"paused":         paused,
+		"pausedInFlight": inFlight,
+	}
+}
+
+// Remote control for pausing transfers
+func init() {
+	rc.Add(rc.Call{
+		Path: "core/pause",
+		Fn: func(ctx context.Context, in rc.Params) (out rc.Params, err error) {
+			return TransferPause.rcPause(ctx, in)
+		},
+		Title: "Pause the transfer queue.",
+		Help: `
+This stops any new file transfers - copies, moves and uploads with
+rcat - from starting until core/resume is called. Running jobs are not
+cancelled - they wait and carry on where they left off when the
+transfers are resumed.
+
+This takes the following parameters:
+
+- inFlight - set to true to stop the transfers in progress too (optional)
+
+Transfers in progress are stopped by blocking their reads so the
+connections stay open. Calling core/pause again without inFlight lets
+them carry on while still stopping new transfers. Note that some backends may time out if they
+are paused for a long time.
+
+Eg
+
+    rclone rc core/pause
+    {
+        "paused": true,
+        "pausedInFlight": false
+    }
+
+The paused state is also shown in core/stats.
+`,
+	})
+	rc.Add(rc.Call{
+		Path: "core/resume",
+		Fn: func(ctx context.Context, in rc.Params) (out rc.Params, err error) {
+			return TransferPause.rcResume(ctx, in)
+		},
+		Title: "Resume the transfer queue.",
+		Help: `
+This restarts the transfers paused by core/pause.
+
+Eg
+
+    rclone rc core/resume
+    {
+        "paused": false,
+        "pausedInFlight": false
+    }
+`,
+	})
+}
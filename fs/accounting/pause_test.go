@@ -0,0 +1,103 @@
+package accounting
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/rclone/rclone/fs/rc"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTransferPause(t *testing.T) {
+	var tp transferPause
+	ctx := context.Background()
+
+	// Not paused so shouldn't block
+	require.NoError(t, tp.Wait(ctx))
+	require.NoError(t, tp.wait(ctx, true))
+
+	// Paused but not in flight
+	tp.Pause(false)
+	paused, inFlight := tp.Paused()
+	assert.True(t, paused)
+	assert.False(t, inFlight)
+	assert.Equal(t, int32(1), tp.isPaused)
+	require.NoError(t, tp.wait(ctx, true))
+
+	timeoutCtx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	assert.Equal(t, context.DeadlineExceeded, tp.Wait(timeoutCtx))
+
+	// Check Resume unblocks waiters
+	done := make(chan error)
+	go func() {
+		done <- tp.Wait(ctx)
+	}()
+	select {
+	case <-done:
+		t.Fatal("Wait returned while paused")
+	case <-time.After(10 * time.Millisecond):
+	}
+	tp.Resume()
+	require.NoError(t, <-done)
+	paused, _ = tp.Paused()
+	assert.False(t, paused)
+	assert.Equal(t, int32(0), tp.isPaused)
+
+	// Resume when not paused is OK
+	tp.Resume()
+}
+
+func TestTransferPauseDowngrade(t *testing.T) {
+	var tp transferPause
+	ctx := context.Background()
+
+	// Readers blocked by a pause of the transfers in progress carry
+	// on when it is changed to a pause of new transfers only
+	tp.Pause(true)
+	done := make(chan error)
+	go func() {
+		done <- tp.wait(ctx, true)
+	}()
+	select {
+	case <-done:
+		t.Fatal("wait returned while paused in flight")
+	case <-time.After(10 * time.Millisecond):
+	}
+	tp.Pause(false)
+	select {
+	case err := <-done:
+		require.NoError(t, err)
+	case <-time.After(10 * time.Second):
+		t.Fatal("wait didn't return when the pause was downgraded")
+	}
+	paused, inFlight := tp.Paused()
+	assert.True(t, paused)
+	assert.False(t, inFlight)
+
+	// Pausing in flight again and resuming is OK
+	tp.Pause(true)
+	tp.Pause(true)
+	tp.Resume()
+	require.NoError(t, tp.wait(ctx, true))
+}
+
+func TestRcPause(t *testing.T) {
+	call := rc.Calls.Get("core/pause")
+	require.NotNil(t, call)
+	out, err := call.Fn(context.Background(), rc.Params{"inFlight": true})
+	require.NoError(t, err)
+	assert.Equal(t, rc.Params{"paused": true, "pausedInFlight": true}, out)
+
+	stats, err := GlobalStats().RemoteStats()
+	require.NoError(t, err)
+	assert.Equal(t, true, stats["paused"])
+
+	call = rc.Calls.Get("core/resume")
+	require.NotNil(t, call)
+	out, err = call.Fn(context.Background(), rc.Params{})
+	require.NoError(t, err)
+	assert.Equal(t, rc.Params{"paused": false, "pausedInFlight": false}, out)
+}
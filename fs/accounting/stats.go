@@ -47,6 +47,8 @@ type StatsInfo struct {
 	renames           int64
 	renameQueue       int
 	renameQueueSize   int64
+	quarantined       int64 // files quarantined by --on-hash-mismatch quarantine
+	stalls            int64 // transfers aborted by --transfer-stall-timeout
 	deletes           int64
 	deletedDirs       int64
 	inProgress        *inProgress
@@ -56,6 +58,19 @@ type StatsInfo struct {
 	group             string
 	startTime         time.Time // the moment these stats were initialized or reset
 	average           averageValues
+	dstTotals         map[string]*dstTotal      // completed transfers by destination
+	transferErrors    int64                     // number of errors which came from transfers
+	failedRemotes     map[string]struct{}       // transfers which failed with a retryable error
+	skippedDeletes    bool                      // set if a sync didn't delete because of errors
+	histogram         *histogram                // completed transfers if --stats-histogram is set
+	uploaded          map[interface{}]fs.Object // objects uploaded for --dedupe-on-upload
+}
+
+// dstTotal holds the totals for the completed transfers to a single
+// destination
+type dstTotal struct {
+	transfers int64 // number of successful transfers
+	bytes     int64 // bytes transferred, including failed transfers
 }
 
 type averageValues struct {
@@ -107,6 +122,8 @@ func (s *StatsInfo) RemoteStats() (out rc.Params, err error) {
 	out["deletes"] = s.deletes
 	out["deletedDirs"] = s.deletedDirs
 	out["renames"] = s.renames
+	out["quarantined"] = s.quarantined
+	out["stalls"] = s.stalls
 	out["elapsedTime"] = time.Since(s.startTime).Seconds()
 	eta, etaOK := eta(s.bytes, ts.totalBytes, ts.speed)
 	if etaOK {
@@ -115,6 +132,7 @@ func (s *StatsInfo) RemoteStats() (out rc.Params, err error) {
 		out["eta"] = nil
 	}
 	s.mu.RUnlock()
+	out["paused"], _ = TransferPause.Paused()
 
 	if !s.checking.empty() {
 		out["checking"] = s.checking.remotes()
@@ -125,6 +143,11 @@ func (s *StatsInfo) RemoteStats() (out rc.Params, err error) {
 	if s.errors > 0 {
 		out["lastError"] = s.lastError.Error()
 	}
+	s.mu.RLock()
+	if s.histogram != nil {
+		out["histogram"] = s.histogram.rcStats()
+	}
+	s.mu.RUnlock()
 
 	return out, nil
 }
@@ -435,6 +458,12 @@ func (s *StatsInfo) String() string {
 		if s.renames != 0 {
 			_, _ = fmt.Fprintf(buf, "Renamed:       %10d\n", s.renames)
 		}
+		if s.quarantined != 0 {
+			_, _ = fmt.Fprintf(buf, "Quarantined:   %10d\n", s.quarantined)
+		}
+		if s.stalls != 0 {
+			_, _ = fmt.Fprintf(buf, "Stalled:       %10d\n", s.stalls)
+		}
 		if s.transfers != 0 || ts.totalTransfers != 0 {
 			_, _ = fmt.Fprintf(buf, "Transferred:   %10d / %d, %s\n",
 				s.transfers, ts.totalTransfers, percent(s.transfers, ts.totalTransfers))
@@ -452,7 +481,7 @@ func (s *StatsInfo) String() string {
 			_, _ = fmt.Fprintf(buf, "Checking:\n%s\n", s.checking.String(s.ctx, s.inProgress, s.transferring))
 		}
 		if !s.transferring.empty() {
-			_, _ = fmt.Fprintf(buf, "Transferring:\n%s\n", s.transferring.String(s.ctx, s.inProgress, nil))
+			_, _ = buf.WriteString(s.transferringString())
 		}
 	}
 
@@ -486,6 +515,23 @@ func (s *StatsInfo) Log() {
 
 }
 
+// LogHistogram outputs the sizes and durations of the completed
+// transfers to the log if --stats-histogram is set
+func (s *StatsInfo) LogHistogram() {
+	if !s.ci.StatsHistogram {
+		return
+	}
+	var buf bytes.Buffer
+	s.mu.RLock()
+	if s.histogram != nil {
+		s.histogram.write(&buf)
+	}
+	s.mu.RUnlock()
+	if buf.Len() > 0 {
+		fs.LogLevelPrintf(s.ci.StatsLogLevel, nil, "\n%s", buf.String())
+	}
+}
+
 // Bytes updates the stats for bytes bytes
 func (s *StatsInfo) Bytes(bytes int64) {
 	s.average.mu.Lock()
@@ -508,6 +554,14 @@ func (s *StatsInfo) GetBytes() int64 {
 func (s *StatsInfo) GetBytesWithPending() int64 {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
+	return s.bytes + s._pending()
+}
+
+// _pending returns the number of bytes still to be transferred by
+// the transfers in progress
+//
+// Call with lock held
+func (s *StatsInfo) _pending() int64 {
 	pending := int64(0)
 	for _, tr := range s.startedTransfers {
 		if tr.acc != nil {
@@ -517,7 +571,24 @@ func (s *StatsInfo) GetBytesWithPending() int64 {
 			}
 		}
 	}
-	return s.bytes + pending
+	return pending
+}
+
+// SoftCutoffReached returns true if a transfer of size bytes
+// shouldn't be started with --cutoff-mode soft and a limit of
+// maxTransfer bytes.
+//
+// A transfer isn't started if the bytes transferred so far plus its
+// size would go over the limit. If the size is unknown (size < 0) the
+// bytes still to come from the transfers in progress are counted too
+// and the transfer is only started if that is under the limit.
+func (s *StatsInfo) SoftCutoffReached(size, maxTransfer int64) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if size < 0 {
+		return s.bytes+s._pending() >= maxTransfer
+	}
+	return s.bytes >= maxTransfer || s.bytes+size > maxTransfer
 }
 
 // Errors updates the stats for errors
@@ -600,7 +671,25 @@ func (s *StatsInfo) Renames(renames int64) int64 {
 	return s.renames
 }
 
-// ResetCounters sets the counters (bytes, checks, errors, transfers, deletes, renames) to 0 and resets lastError, fatalError and retryError
+// Quarantined updates the stats for files quarantined by
+// --on-hash-mismatch quarantine
+func (s *StatsInfo) Quarantined(quarantined int64) int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.quarantined += quarantined
+	return s.quarantined
+}
+
+// Stalls updates the stats for transfers aborted by
+// --transfer-stall-timeout
+func (s *StatsInfo) Stalls(stalls int64) int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.stalls += stalls
+	return s.stalls
+}
+
+// ResetCounters sets the counters (bytes, checks, errors, transfers, deletes, renames, quarantined, stalls) to 0 and resets lastError, fatalError and retryError
 func (s *StatsInfo) ResetCounters() {
 	s.mu.Lock()
 	defer s.mu.Unlock()
@@ -615,8 +704,16 @@ func (s *StatsInfo) ResetCounters() {
 	s.deletes = 0
 	s.deletedDirs = 0
 	s.renames = 0
+	s.quarantined = 0
+	s.stalls = 0
 	s.startedTransfers = nil
 	s.oldDuration = 0
+	s.dstTotals = nil
+	s.transferErrors = 0
+	s.failedRemotes = nil
+	s.skippedDeletes = false
+	s.histogram = nil
+	s.uploaded = nil
 
 	s.stopAverageLoop()
 	s.average = averageValues{stop: make(chan bool)}
@@ -631,6 +728,80 @@ func (s *StatsInfo) ResetErrors() {
 	s.fatalError = false
 	s.retryError = false
 	s.retryAfter = time.Time{}
+	s.transferErrors = 0
+	s.failedRemotes = nil
+	s.skippedDeletes = false
+}
+
+// transferFailed records that the transfer of remote failed with err
+// which should already have been counted.
+func (s *StatsInfo) transferFailed(remote string, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.transferErrors++
+	if remote == "" || fserrors.IsFatalError(err) || fserrors.IsNoRetryError(err) {
+		return
+	}
+	if s.failedRemotes == nil {
+		s.failedRemotes = make(map[string]struct{})
+	}
+	s.failedRemotes[remote] = struct{}{}
+}
+
+// FailedRemotes returns a sorted list of the remotes whose transfers
+// failed with a retryable error.
+//
+// ok is only true if there were some and every error counted came
+// from a transfer, so retrying just those remotes retries all the
+// errors which can be retried.
+func (s *StatsInfo) FailedRemotes() (remotes []string, ok bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for remote := range s.failedRemotes {
+		remotes = append(remotes, remote)
+	}
+	sort.Strings(remotes)
+	return remotes, len(remotes) > 0 && s.errors == s.transferErrors
+}
+
+// SkippedDeletes records that a sync didn't delete files or
+// directories on the destination because there were errors.
+func (s *StatsInfo) SkippedDeletes() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.skippedDeletes = true
+}
+
+// HadSkippedDeletes returns whether a sync didn't delete files or
+// directories on the destination because there were errors.
+func (s *StatsInfo) HadSkippedDeletes() bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.skippedDeletes
+}
+
+// Uploaded returns the object recorded under key by AddUploaded or
+// nil if there isn't one.
+func (s *StatsInfo) Uploaded(key interface{}) fs.Object {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.uploaded[key]
+}
+
+// AddUploaded records o as uploaded under key unless an object has
+// already been recorded there.
+//
+// This is used by --dedupe-on-upload to find the objects with the
+// same content uploaded with these stats.
+func (s *StatsInfo) AddUploaded(key interface{}, o fs.Object) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.uploaded == nil {
+		s.uploaded = make(map[interface{}]fs.Object)
+	}
+	if _, found := s.uploaded[key]; !found {
+		s.uploaded[key] = o
+	}
 }
 
 // Errored returns whether there have been any errors
@@ -698,7 +869,15 @@ func (s *StatsInfo) GetTransfers() int64 {
 
 // NewTransfer adds a transfer to the stats from the object.
 func (s *StatsInfo) NewTransfer(obj fs.Object) *Transfer {
-	tr := newTransfer(s, obj)
+	return s.NewTransferDst(obj, nil)
+}
+
+// NewTransferDst adds a transfer to the stats from the object.
+//
+// dstFs is the Fs being transferred to or nil if there isn't one. It
+// is used to group the transfers when showing the stats.
+func (s *StatsInfo) NewTransferDst(obj fs.Object, dstFs fs.Fs) *Transfer {
+	tr := newTransfer(s, obj, dstFs)
 	s.transferring.add(tr)
 	s.startAverageLoop()
 	return tr
@@ -706,7 +885,15 @@ func (s *StatsInfo) NewTransfer(obj fs.Object) *Transfer {
 
 // NewTransferRemoteSize adds a transfer to the stats based on remote and size.
 func (s *StatsInfo) NewTransferRemoteSize(remote string, size int64) *Transfer {
-	tr := newTransferRemoteSize(s, remote, size, false)
+	return s.NewTransferRemoteSizeDst(remote, size, nil)
+}
+
+// NewTransferRemoteSizeDst adds a transfer to the stats based on
+// remote and size.
+//
+// dstFs is the Fs being transferred to or nil if there isn't one.
+func (s *StatsInfo) NewTransferRemoteSizeDst(remote string, size int64, dstFs fs.Fs) *Transfer {
+	tr := newTransferRemoteSize(s, remote, size, false, dstFs)
 	s.transferring.add(tr)
 	s.startAverageLoop()
 	return tr
@@ -716,7 +903,14 @@ func (s *StatsInfo) NewTransferRemoteSize(remote string, size int64) *Transfer {
 //
 // if ok is true then it increments the transfers count
 func (s *StatsInfo) DoneTransferring(remote string, ok bool) {
-	s.transferring.del(remote)
+	s.doneTransferring(remote, ok)
+}
+
+// doneTransferring removes the transfer with key from the stats
+//
+// if ok is true then it increments the transfers count
+func (s *StatsInfo) doneTransferring(key string, ok bool) {
+	s.transferring.del(key)
 	if ok {
 		s.mu.Lock()
 		s.transfers++
@@ -727,6 +921,90 @@ func (s *StatsInfo) DoneTransferring(remote string, ok bool) {
 	}
 }
 
+// doneTransferringTo adds a finished transfer of bytes to the totals
+// for dstFs
+func (s *StatsInfo) doneTransferringTo(dstFs string, bytes int64, ok bool) {
+	if dstFs == "" {
+		return
+	}
+	var transfers int64
+	if ok {
+		transfers = 1
+	}
+	s.mu.Lock()
+	s._addDstTotal(dstFs, transfers, bytes)
+	s.mu.Unlock()
+}
+
+// addToHistogram records a successful transfer of bytes which took
+// duration if --stats-histogram is set
+func (s *StatsInfo) addToHistogram(bytes int64, duration time.Duration) {
+	if !s.ci.StatsHistogram {
+		return
+	}
+	s.mu.Lock()
+	if s.histogram == nil {
+		s.histogram = new(histogram)
+	}
+	s.histogram.add(bytes, duration)
+	s.mu.Unlock()
+}
+
+// _addDstTotal adds transfers and bytes to the totals for dstFs
+//
+// Call with the lock held
+func (s *StatsInfo) _addDstTotal(dstFs string, transfers, bytes int64) {
+	if s.dstTotals == nil {
+		s.dstTotals = make(map[string]*dstTotal)
+	}
+	total := s.dstTotals[dstFs]
+	if total == nil {
+		total = new(dstTotal)
+		s.dstTotals[dstFs] = total
+	}
+	total.transfers += transfers
+	total.bytes += bytes
+}
+
+// getDstTotal returns a copy of the totals for dstFs
+func (s *StatsInfo) getDstTotal(dstFs string) (total dstTotal) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if t := s.dstTotals[dstFs]; t != nil {
+		total = *t
+	}
+	return total
+}
+
+// transferringString returns the list of transfers in progress.
+//
+// If the transfers are to more than one destination then they are
+// shown in a section per destination with its own totals.
+func (s *StatsInfo) transferringString() string {
+	dsts := s.transferring.destinations()
+	if len(dsts) <= 1 {
+		return fmt.Sprintf("Transferring:\n%s\n", s.transferring.String(s.ctx, s.inProgress, nil))
+	}
+	var buf strings.Builder
+	for _, dst := range dsts {
+		total := s.getDstTotal(dst)
+		count, bytes, size := s.transferring.progressTo(s, dst)
+		name := dst
+		if name == "" {
+			name = "unknown destination"
+		}
+		_, _ = fmt.Fprintf(&buf, "Transferring to %s: %d done, %d in progress, %s / %s\n%s\n",
+			name,
+			total.transfers,
+			count,
+			fs.SizeSuffix(total.bytes+bytes).ByteUnit(),
+			fs.SizeSuffix(total.bytes+size).ByteUnit(),
+			s.transferring.stringTo(s.ctx, s.inProgress, dst),
+		)
+	}
+	return buf.String()
+}
+
 // SetCheckQueue sets the number of queued checks
 func (s *StatsInfo) SetCheckQueue(n int, size int64) {
 	s.mu.Lock()
@@ -59,7 +59,7 @@ func rcRemoteStats(ctx context.Context, in rc.Params) (rc.Params, error) {
 		return rc.Params{}, err
 	}
 	if group != "" {
-		return StatsGroup(ctx, group).RemoteStats()
+		return lookupStatsGroup(ctx, group).RemoteStats()
 	}
 
 	return groups.sum(ctx).RemoteStats()
@@ -76,7 +76,8 @@ This returns all available stats:
 	rclone rc core/stats
 
 If group is not provided then summed up stats for all groups will be
-returned.
+returned. Looking up a group which doesn't exist returns empty stats
+and doesn't create the group.
 
 Parameters
 
@@ -93,10 +94,18 @@ Returns the following values:
 	"errors": number of errors,
 	"eta": estimated time in seconds until the group completes,
 	"fatalError": boolean whether there has been at least one fatal error,
+	"histogram": sizes and durations of completed transfers if --stats-histogram is set:
+		{
+			"sizes": an array of size buckets with "maxSize" (-1 for no limit), "count", "bytes", "duration" and "speed",
+			"durations": transfer duration percentiles in seconds "p50", "p90", "p99" and "max"
+		},
 	"lastError": last error string,
+	"paused": boolean showing whether transfers are paused with core/pause,
+	"quarantined": number of files quarantined by --on-hash-mismatch quarantine,
 	"renames" : number of files renamed,
 	"retryError": boolean showing whether there has been at least one non-NoRetryError,
 	"speed": average speed in bytes per second since start of the group,
+	"stalls": number of transfers aborted by --transfer-stall-timeout,
 	"totalBytes": total number of bytes in the group,
 	"totalChecks": total number of checks in the group,
 	"totalTransfers": total number of transfers in the group,
@@ -118,7 +127,7 @@ Returns the following values:
 		[]
 }
 ` + "```" + `
-Values for "transferring", "checking" and "lastError" are only assigned if data is available.
+Values for "transferring", "checking", "lastError" and "histogram" are only assigned if data is available.
 The value for "eta" is null if an eta cannot be determined.
 `,
 	})
@@ -133,7 +142,7 @@ func rcTransferredStats(ctx context.Context, in rc.Params) (rc.Params, error) {
 
 	out := make(rc.Params)
 	if group != "" {
-		out["transferred"] = StatsGroup(ctx, group).Transferred()
+		out["transferred"] = lookupStatsGroup(ctx, group).Transferred()
 	} else {
 		out["transferred"] = groups.sum(ctx).Transferred()
 	}
@@ -190,8 +199,10 @@ func rcResetStats(ctx context.Context, in rc.Params) (rc.Params, error) {
 
 	if group != "" {
 		stats := groups.get(group)
-		stats.ResetErrors()
-		stats.ResetCounters()
+		if stats != nil {
+			stats.ResetErrors()
+			stats.ResetCounters()
+		}
 	} else {
 		groups.reset()
 	}
@@ -272,11 +283,23 @@ func Stats(ctx context.Context) *StatsInfo {
 	return StatsGroup(ctx, group)
 }
 
-// StatsGroup gets stats by group name.
+// StatsGroup gets stats by group name, creating the group if it
+// doesn't exist.
+//
+// This is safe to call concurrently - all callers asking for the same
+// group will get the same stats.
 func StatsGroup(ctx context.Context, group string) *StatsInfo {
+	return groups.getOrCreate(ctx, group)
+}
+
+// lookupStatsGroup gets stats by group name for reporting.
+//
+// If the group doesn't exist it returns empty stats without creating
+// the group so that querying doesn't evict groups which are in use.
+func lookupStatsGroup(ctx context.Context, group string) *StatsInfo {
 	stats := groups.get(group)
 	if stats == nil {
-		return NewStatsGroup(ctx, group)
+		return NewStats(ctx)
 	}
 	return stats
 }
@@ -312,6 +335,25 @@ func newStatsGroups() *statsGroups {
 func (sg *statsGroups) set(ctx context.Context, group string, stats *StatsInfo) {
 	sg.mu.Lock()
 	defer sg.mu.Unlock()
+	sg.setLocked(ctx, group, stats)
+}
+
+// getOrCreate gets the stats for group, making a new stats group if
+// it doesn't exist
+func (sg *statsGroups) getOrCreate(ctx context.Context, group string) *StatsInfo {
+	sg.mu.Lock()
+	defer sg.mu.Unlock()
+	stats, ok := sg.m[group]
+	if !ok {
+		stats = NewStats(ctx)
+		stats.group = group
+		sg.setLocked(ctx, group, stats)
+	}
+	return stats
+}
+
+// setLocked marks the stats as belonging to a group - call with the lock held
+func (sg *statsGroups) setLocked(ctx context.Context, group string, stats *StatsInfo) {
 	ci := fs.GetConfig(ctx)
 
 	// Limit number of groups kept in memory.
@@ -344,7 +386,9 @@ func (sg *statsGroups) get(group string) *StatsInfo {
 func (sg *statsGroups) names() []string {
 	sg.mu.Lock()
 	defer sg.mu.Unlock()
-	return sg.order
+	names := make([]string, len(sg.order))
+	copy(names, sg.order)
+	return names
 }
 
 // sum returns aggregate stats that contains summation of all groups.
@@ -376,6 +420,8 @@ func (sg *statsGroups) sum(ctx context.Context) *StatsInfo {
 			sum.transferring.merge(stats.transferring)
 			sum.transferQueueSize += stats.transferQueueSize
 			sum.renames += stats.renames
+			sum.quarantined += stats.quarantined
+			sum.stalls += stats.stalls
 			sum.renameQueue += stats.renameQueue
 			sum.renameQueueSize += stats.renameQueueSize
 			sum.deletes += stats.deletes
@@ -384,6 +430,15 @@ func (sg *statsGroups) sum(ctx context.Context) *StatsInfo {
 			sum.startedTransfers = append(sum.startedTransfers, stats.startedTransfers...)
 			sum.oldTimeRanges = append(sum.oldTimeRanges, stats.oldTimeRanges...)
 			sum.oldDuration += stats.oldDuration
+			for dst, total := range stats.dstTotals {
+				sum._addDstTotal(dst, total.transfers, total.bytes)
+			}
+			if stats.histogram != nil {
+				if sum.histogram == nil {
+					sum.histogram = new(histogram)
+				}
+				sum.histogram.merge(stats.histogram)
+			}
 			stats.average.mu.Lock()
 			sum.average.speed += stats.average.speed
 			stats.average.mu.Unlock()
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"runtime"
+	"sync"
 	"testing"
 	"time"
 
@@ -89,6 +90,37 @@ func TestStatsGroupOperations(t *testing.T) {
 		}
 	})
 
+	t.Run("getOrCreate is safe to call concurrently", func(t *testing.T) {
+		t.Parallel()
+		sg := newStatsGroups()
+		const n = 10
+		results := make([]*StatsInfo, n)
+		var wg sync.WaitGroup
+		for i := 0; i < n; i++ {
+			i := i
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				results[i] = sg.getOrCreate(ctx, "test")
+			}()
+		}
+		wg.Wait()
+		for i := 1; i < n; i++ {
+			assert.True(t, results[0] == results[i], "stats %d differ", i)
+		}
+		assert.Equal(t, []string{"test"}, sg.names())
+		assert.Equal(t, "test", results[0].group)
+	})
+
+	t.Run("names returns a copy", func(t *testing.T) {
+		t.Parallel()
+		sg := newStatsGroups()
+		sg.set(ctx, "test", NewStats(ctx))
+		names := sg.names()
+		names[0] = "changed"
+		assert.Equal(t, []string{"test"}, sg.names())
+	})
+
 	t.Run("memory is reclaimed", func(t *testing.T) {
 		testy.SkipUnreliable(t)
 		var (
@@ -1,7 +1,9 @@
 package accounting
 
 import (
+	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"testing"
@@ -9,6 +11,8 @@ import (
 
 	"github.com/rclone/rclone/fs"
 	"github.com/rclone/rclone/fs/fserrors"
+	"github.com/rclone/rclone/fs/rc"
+	"github.com/rclone/rclone/fstest/mockfs"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -132,6 +136,32 @@ func TestStatsError(t *testing.T) {
 	assert.Equal(t, time.Time{}, s.RetryAfter())
 }
 
+func TestSoftCutoffReached(t *testing.T) {
+	ctx := context.Background()
+	s := NewStats(ctx)
+	s.Bytes(100)
+
+	// Known sizes only count the bytes transferred so far
+	assert.False(t, s.SoftCutoffReached(0, 1000))
+	assert.False(t, s.SoftCutoffReached(900, 1000))
+	assert.True(t, s.SoftCutoffReached(901, 1000))
+	assert.True(t, s.SoftCutoffReached(0, 100))
+
+	// A transfer in progress with 500 bytes still to come
+	tr := s.NewTransferRemoteSize("file", 600)
+	acc := tr.Account(ctx, io.NopCloser(bytes.NewBuffer(make([]byte, 600))))
+	acc.values.bytes = 100
+	s.Bytes(100)
+	assert.Equal(t, int64(200), s.GetBytes())
+	assert.False(t, s.SoftCutoffReached(800, 1000))
+	assert.True(t, s.SoftCutoffReached(801, 1000))
+
+	// Unknown sizes count the transfers in progress too
+	assert.False(t, s.SoftCutoffReached(-1, 701))
+	assert.True(t, s.SoftCutoffReached(-1, 700))
+	tr.Done(ctx, nil)
+}
+
 func TestStatsTotalDuration(t *testing.T) {
 	ctx := context.Background()
 	startTime := time.Now()
@@ -435,3 +465,135 @@ func TestPruneTransfers(t *testing.T) {
 		})
 	}
 }
+
+func TestStatsStringDestinations(t *testing.T) {
+	ctx := context.Background()
+	ctx, ci := fs.AddConfig(ctx)
+	ci.StatsFileNameLength = 5
+	f1 := mockfs.NewFs(ctx, "dst1", "path")
+	f2 := mockfs.NewFs(ctx, "dst2", "path")
+
+	// A single destination is shown in the normal way
+	s := NewStats(ctx)
+	tr1 := s.NewTransferRemoteSizeDst("file1", 100, f1)
+	out := s.String()
+	assert.Contains(t, out, "Transferring:\n * file1: transferring\n")
+	assert.NotContains(t, out, "Transferring to")
+
+	// More than one destination is shown in sections
+	tr2 := s.NewTransferRemoteSizeDst("file2", 200, f2)
+	tr3 := s.NewTransferRemoteSizeDst("file3", 300, f1)
+	out = s.String()
+	assert.NotContains(t, out, "Transferring:\n")
+	assert.Contains(t, out, "Transferring to dst1:path: 0 done, 2 in progress, 0 B / 0 B\n * file1: transferring\n * file3: transferring\n")
+	assert.Contains(t, out, "Transferring to dst2:path: 0 done, 1 in progress, 0 B / 0 B\n * file2: transferring\n")
+
+	// Completed transfers are counted per destination
+	tr3.Done(ctx, nil)
+	out = s.String()
+	assert.Contains(t, out, "Transferring to dst1:path: 1 done, 1 in progress, 0 B / 0 B\n * file1: transferring\n")
+	assert.Equal(t, dstTotal{transfers: 1}, s.getDstTotal("dst1:path"))
+
+	tr1.Done(ctx, nil)
+	tr2.Done(ctx, nil)
+	assert.Equal(t, dstTotal{transfers: 1}, s.getDstTotal("dst2:path"))
+
+	// The same remote may be transferred to several destinations
+	tr1 = s.NewTransferRemoteSizeDst("file4", 100, f1)
+	tr2 = s.NewTransferRemoteSizeDst("file4", 100, f2)
+	assert.Equal(t, 2, s.transferring.count())
+	out = s.String()
+	assert.Contains(t, out, "Transferring to dst1:path: 2 done, 1 in progress, 0 B / 0 B\n * file4: transferring\n")
+	assert.Contains(t, out, "Transferring to dst2:path: 1 done, 1 in progress, 0 B / 0 B\n * file4: transferring\n")
+	tr1.Done(ctx, nil)
+	assert.Equal(t, 1, s.transferring.count())
+	tr2.Done(ctx, nil)
+	assert.True(t, s.transferring.empty())
+}
+
+func TestStatsFailedRemotes(t *testing.T) {
+	ctx := context.Background()
+	s := NewStats(ctx)
+
+	remotes, ok := s.FailedRemotes()
+	assert.False(t, ok)
+	assert.Nil(t, remotes)
+
+	// Retryable errors are recorded, others aren't
+	s.NewTransferRemoteSize("b", 1).Done(ctx, errors.New("retry me"))
+	s.NewTransferRemoteSize("a", 1).Done(ctx, errors.New("retry me too"))
+	s.NewTransferRemoteSize("c", 1).Done(ctx, fserrors.NoRetryError(errors.New("don't retry")))
+	s.NewTransferRemoteSize("d", 1).Done(ctx, nil)
+	remotes, ok = s.FailedRemotes()
+	assert.True(t, ok)
+	assert.Equal(t, []string{"a", "b"}, remotes)
+
+	// An error which didn't come from a transfer means everything
+	// must be retried
+	_ = s.Error(errors.New("listing failed"))
+	remotes, ok = s.FailedRemotes()
+	assert.False(t, ok)
+	assert.Equal(t, []string{"a", "b"}, remotes)
+
+	s.ResetErrors()
+	remotes, ok = s.FailedRemotes()
+	assert.False(t, ok)
+	assert.Nil(t, remotes)
+}
+
+func TestStatsSkippedDeletes(t *testing.T) {
+	s := NewStats(context.Background())
+	assert.False(t, s.HadSkippedDeletes())
+	s.SkippedDeletes()
+	assert.True(t, s.HadSkippedDeletes())
+	s.ResetErrors()
+	assert.False(t, s.HadSkippedDeletes())
+}
+
+func TestStatsHistogram(t *testing.T) {
+	ctx := context.Background()
+
+	// Nothing is recorded unless --stats-histogram is set
+	s := NewStats(ctx)
+	s.addToHistogram(100, time.Second)
+	assert.Nil(t, s.histogram)
+
+	ctx, ci := fs.AddConfig(ctx)
+	ci.StatsHistogram = true
+	s = NewStats(ctx)
+	for i := 1; i <= 10; i++ {
+		s.addToHistogram(100, time.Duration(i)*time.Second)
+	}
+	s.addToHistogram(2*int64(fs.Mebi), time.Second)
+	s.addToHistogram(2*int64(fs.Gibi), 20*time.Second)
+
+	assert.Equal(t, int64(10), s.histogram.sizes[0].count)
+	assert.Equal(t, int64(1000), s.histogram.sizes[0].bytes)
+	assert.Equal(t, int64(1), s.histogram.sizes[3].count)
+	assert.Equal(t, int64(1), s.histogram.sizes[len(histogramSizes)].count)
+
+	values, max := s.histogram.percentiles()
+	assert.Equal(t, []time.Duration{5 * time.Second, 10 * time.Second, 20 * time.Second}, values)
+	assert.Equal(t, 20*time.Second, max)
+
+	var buf bytes.Buffer
+	s.histogram.write(&buf)
+	out := buf.String()
+	assert.Contains(t, out, "< 1Ki:")
+	assert.Contains(t, out, "1Mi - 16Mi:")
+	assert.Contains(t, out, ">= 1Gi:")
+	assert.Contains(t, out, "p50 5s, p90 10s, p99 20s, max 20s")
+
+	rcOut, err := s.RemoteStats()
+	require.NoError(t, err)
+	hist := rcOut["histogram"].(rc.Params)
+	sizes := hist["sizes"].([]rc.Params)
+	require.Len(t, sizes, len(histogramSizes)+1)
+	assert.Equal(t, int64(1024), sizes[0]["maxSize"])
+	assert.Equal(t, int64(10), sizes[0]["count"])
+	assert.Equal(t, int64(-1), sizes[len(histogramSizes)]["maxSize"])
+	assert.Equal(t, 20.0, hist["durations"].(rc.Params)["max"])
+
+	s.ResetCounters()
+	assert.Nil(t, s.histogram)
+}
@@ -21,6 +21,7 @@ type TransferSnapshot struct {
 	CompletedAt time.Time `json:"completed_at,omitempty"`
 	Error       error     `json:"-"`
 	Group       string    `json:"group"`
+	DstFs       string    `json:"dst_fs,omitempty"`
 }
 
 // MarshalJSON implements json.Marshaler interface.
@@ -50,6 +51,9 @@ type Transfer struct {
 	size      int64
 	startedAt time.Time
 	checking  bool
+	dstFs     string  // destination Fs as a config string or "" if unknown
+	srcFs     fs.Info // source Fs used to find the buffer size or nil if unknown
+	key       string  // key of the transfer in the transferMap
 
 	// Protects all below
 	//
@@ -64,15 +68,17 @@ type Transfer struct {
 
 // newCheckingTransfer instantiates new checking of the object.
 func newCheckingTransfer(stats *StatsInfo, obj fs.Object) *Transfer {
-	return newTransferRemoteSize(stats, obj.Remote(), obj.Size(), true)
+	return newTransferRemoteSize(stats, obj.Remote(), obj.Size(), true, nil)
 }
 
-// newTransfer instantiates new transfer.
-func newTransfer(stats *StatsInfo, obj fs.Object) *Transfer {
-	return newTransferRemoteSize(stats, obj.Remote(), obj.Size(), false)
+// newTransfer instantiates new transfer to dstFs which may be nil.
+func newTransfer(stats *StatsInfo, obj fs.Object, dstFs fs.Fs) *Transfer {
+	tr := newTransferRemoteSize(stats, obj.Remote(), obj.Size(), false, dstFs)
+	tr.srcFs = obj.Fs()
+	return tr
 }
 
-func newTransferRemoteSize(stats *StatsInfo, remote string, size int64, checking bool) *Transfer {
+func newTransferRemoteSize(stats *StatsInfo, remote string, size int64, checking bool, dstFs fs.Fs) *Transfer {
 	tr := &Transfer{
 		stats:     stats,
 		remote:    remote,
@@ -80,15 +86,31 @@ func newTransferRemoteSize(stats *StatsInfo, remote string, size int64, checking
 		startedAt: time.Now(),
 		checking:  checking,
 	}
+	if dstFs != nil {
+		tr.dstFs = fs.ConfigString(dstFs)
+	}
+	tr.key = transferKey(tr.dstFs, remote)
 	stats.AddTransfer(tr)
 	return tr
 }
 
+// transferKey returns the key for the transfer of remote to dstFs.
+//
+// The same remote may be transferred to several destinations at once
+// so the key includes dstFs if known.
+func transferKey(dstFs, remote string) string {
+	if dstFs == "" {
+		return remote
+	}
+	return dstFs + "\x00" + remote
+}
+
 // Done ends the transfer.
 // Must be called after transfer is finished to run proper cleanups.
 func (tr *Transfer) Done(ctx context.Context, err error) {
 	if err != nil {
 		err = tr.stats.Error(err)
+		tr.stats.transferFailed(tr.remote, err)
 
 		tr.mu.Lock()
 		tr.err = err
@@ -100,7 +122,9 @@ func (tr *Transfer) Done(ctx context.Context, err error) {
 	tr.mu.RUnlock()
 
 	ci := fs.GetConfig(ctx)
+	var bytes int64
 	if acc != nil {
+		bytes, _ = acc.progress()
 		// Close the file if it is still open
 		if err := acc.Close(); err != nil {
 			fs.LogLevelPrintf(ci.StatsLogLevel, nil, "can't close account: %+v\n", err)
@@ -113,12 +137,17 @@ func (tr *Transfer) Done(ctx context.Context, err error) {
 
 	tr.mu.Lock()
 	tr.completedAt = time.Now()
+	duration := tr.completedAt.Sub(tr.startedAt)
 	tr.mu.Unlock()
 
 	if tr.checking {
 		tr.stats.DoneChecking(tr.remote)
 	} else {
-		tr.stats.DoneTransferring(tr.remote, err == nil)
+		if err == nil {
+			tr.stats.addToHistogram(bytes, duration)
+		}
+		tr.stats.doneTransferringTo(tr.dstFs, bytes, err == nil)
+		tr.stats.doneTransferring(tr.key, err == nil)
 	}
 	tr.stats.PruneTransfers()
 }
@@ -143,7 +172,12 @@ func (tr *Transfer) Reset(ctx context.Context) {
 func (tr *Transfer) Account(ctx context.Context, in io.ReadCloser) *Account {
 	tr.mu.Lock()
 	if tr.acc == nil {
-		tr.acc = newAccountSizeName(ctx, tr.stats, in, tr.size, tr.remote)
+		if bufferSize := fs.BufferSize(ctx, tr.srcFs); bufferSize != fs.GetConfig(ctx).BufferSize {
+			var ci *fs.ConfigInfo
+			ctx, ci = fs.AddConfig(ctx)
+			ci.BufferSize = bufferSize
+		}
+		tr.acc = newAccountSizeNameKey(ctx, tr.stats, in, tr.size, tr.remote, tr.key)
 	} else {
 		tr.acc.UpdateReader(ctx, in)
 	}
@@ -184,6 +218,7 @@ func (tr *Transfer) Snapshot() TransferSnapshot {
 		CompletedAt: tr.completedAt,
 		Error:       tr.err,
 		Group:       tr.stats.group,
+		DstFs:       tr.dstFs,
 	}
 }
 
@@ -29,14 +29,14 @@ func newTransferMap(size int, name string) *transferMap {
 // add adds a new transfer to the map
 func (tm *transferMap) add(tr *Transfer) {
 	tm.mu.Lock()
-	tm.items[tr.remote] = tr
+	tm.items[tr.key] = tr
 	tm.mu.Unlock()
 }
 
-// del removes a transfer from the map by name
-func (tm *transferMap) del(remote string) {
+// del removes a transfer from the map by key
+func (tm *transferMap) del(key string) {
 	tm.mu.Lock()
-	delete(tm.items, remote)
+	delete(tm.items, key)
 	tm.mu.Unlock()
 }
 
@@ -90,21 +90,31 @@ func (tm *transferMap) _sortedSlice() []*Transfer {
 // String returns string representation of map items excluding any in
 // exclude (if set).
 func (tm *transferMap) String(ctx context.Context, progress *inProgress, exclude *transferMap) string {
+	return tm._string(ctx, progress, exclude, func(tr *Transfer) bool { return true })
+}
+
+// stringTo returns string representation of the map items which are
+// being transferred to dstFs.
+func (tm *transferMap) stringTo(ctx context.Context, progress *inProgress, dstFs string) string {
+	return tm._string(ctx, progress, nil, func(tr *Transfer) bool { return tr.dstFs == dstFs })
+}
+
+// _string returns string representation of the map items for which
+// include returns true excluding any in exclude (if set).
+func (tm *transferMap) _string(ctx context.Context, progress *inProgress, exclude *transferMap, include func(tr *Transfer) bool) string {
 	tm.mu.RLock()
 	defer tm.mu.RUnlock()
 	ci := fs.GetConfig(ctx)
 	stringList := make([]string, 0, len(tm.items))
 	for _, tr := range tm._sortedSlice() {
-		if exclude != nil {
-			exclude.mu.RLock()
-			_, found := exclude.items[tr.remote]
-			exclude.mu.RUnlock()
-			if found {
-				continue
-			}
+		if !include(tr) {
+			continue
+		}
+		if exclude != nil && exclude.haveRemote(tr.remote) {
+			continue
 		}
 		var out string
-		if acc := progress.get(tr.remote); acc != nil {
+		if acc := progress.get(tr.key); acc != nil {
 			out = acc.String()
 		} else {
 			out = fmt.Sprintf("%*s: %s",
@@ -118,6 +128,19 @@ func (tm *transferMap) String(ctx context.Context, progress *inProgress, exclude
 	return strings.Join(stringList, "\n")
 }
 
+// haveRemote returns whether any of the items are transfers of remote
+// to any destination.
+func (tm *transferMap) haveRemote(remote string) bool {
+	tm.mu.RLock()
+	defer tm.mu.RUnlock()
+	for _, tr := range tm.items {
+		if tr.remote == remote {
+			return true
+		}
+	}
+	return false
+}
+
 // progress returns total bytes read as well as the size.
 func (tm *transferMap) progress(stats *StatsInfo) (totalBytes, totalSize int64) {
 	tm.mu.RLock()
@@ -134,6 +157,42 @@ func (tm *transferMap) progress(stats *StatsInfo) (totalBytes, totalSize int64)
 	return totalBytes, totalSize
 }
 
+// progressTo returns the number of items being transferred to dstFs
+// and the total bytes read as well as the size of them.
+func (tm *transferMap) progressTo(stats *StatsInfo, dstFs string) (count int, totalBytes, totalSize int64) {
+	tm.mu.RLock()
+	defer tm.mu.RUnlock()
+	for name, tr := range tm.items {
+		if tr.dstFs != dstFs {
+			continue
+		}
+		count++
+		if acc := stats.inProgress.get(name); acc != nil {
+			bytes, size := acc.progress()
+			if size >= 0 && bytes >= 0 {
+				totalBytes += bytes
+				totalSize += size
+			}
+		}
+	}
+	return count, totalBytes, totalSize
+}
+
+// destinations returns the destinations of the items in the
+// transferMap in the order they were first started.
+func (tm *transferMap) destinations() (dsts []string) {
+	tm.mu.RLock()
+	defer tm.mu.RUnlock()
+	seen := make(map[string]struct{})
+	for _, tr := range tm._sortedSlice() {
+		if _, found := seen[tr.dstFs]; !found {
+			seen[tr.dstFs] = struct{}{}
+			dsts = append(dsts, tr.dstFs)
+		}
+	}
+	return dsts
+}
+
 // remotes returns a []string of the remote names for the transferMap
 func (tm *transferMap) remotes() (c []string) {
 	tm.mu.RLock()
@@ -149,7 +208,7 @@ func (tm *transferMap) rcStats(progress *inProgress) (t []rc.Params) {
 	tm.mu.RLock()
 	defer tm.mu.RUnlock()
 	for _, tr := range tm._sortedSlice() {
-		if acc := progress.get(tr.remote); acc != nil {
+		if acc := progress.get(tr.key); acc != nil {
 			t = append(t, acc.rcStats())
 		} else {
 			t = append(t, tr.rcStats())
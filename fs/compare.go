@@ -0,0 +1,130 @@
+package fs
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// CompareCriterion is one of the ways of comparing files with --compare
+type CompareCriterion byte
+
+// CompareCriterion constants
+const (
+	CompareSize CompareCriterion = iota
+	CompareModTime
+	CompareHash
+)
+
+var compareCriterionToString = []string{
+	CompareSize:    "size",
+	CompareModTime: "modtime",
+	CompareHash:    "hash",
+}
+
+// CompareCriteriaList is a list of the criteria used in the help
+var CompareCriteriaList = strings.Join(compareCriterionToString, ",")
+
+// String turns a CompareCriterion into a string
+func (c CompareCriterion) String() string {
+	if c >= CompareCriterion(len(compareCriterionToString)) {
+		return fmt.Sprintf("CompareCriterion(%d)", c)
+	}
+	return compareCriterionToString[c]
+}
+
+// CompareItem is a criterion in a CompareList
+type CompareItem struct {
+	Criterion CompareCriterion
+	Window    time.Duration // tolerance for CompareModTime, or 0 to use --modify-window
+}
+
+// String turns a CompareItem into a string
+func (item CompareItem) String() string {
+	if item.Criterion == CompareModTime && item.Window > 0 {
+		return item.Criterion.String() + ":" + item.Window.String()
+	}
+	return item.Criterion.String()
+}
+
+// CompareList is the list of criteria set with --compare in the order
+// they are used to decide whether two files are equal
+type CompareList []CompareItem
+
+// String turns a CompareList into a string
+func (l CompareList) String() string {
+	out := make([]string, len(l))
+	for i, item := range l {
+		out[i] = item.String()
+	}
+	return strings.Join(out, ",")
+}
+
+// Set a CompareList from a comma separated list of criteria, eg
+// "size,modtime:2s,hash"
+//
+// An empty string clears the list.
+func (l *CompareList) Set(s string) error {
+	var list CompareList
+	for _, part := range strings.Split(s, ",") {
+		part = strings.ToLower(strings.TrimSpace(part))
+		if part == "" {
+			continue
+		}
+		name, window, hasWindow := part, "", false
+		if i := strings.IndexRune(part, ':'); i >= 0 {
+			name, window, hasWindow = part[:i], part[i+1:], true
+		}
+		item := CompareItem{Criterion: CompareCriterion(len(compareCriterionToString))}
+		for n, criterion := range compareCriterionToString {
+			if name == criterion {
+				item.Criterion = CompareCriterion(n)
+			}
+		}
+		if item.Criterion >= CompareCriterion(len(compareCriterionToString)) {
+			return fmt.Errorf("Unknown compare criterion %q - expecting one of %s", name, CompareCriteriaList)
+		}
+		if hasWindow {
+			if item.Criterion != CompareModTime {
+				return fmt.Errorf("compare criterion %q doesn't take a tolerance", name)
+			}
+			d, err := ParseDuration(window)
+			if err != nil {
+				return fmt.Errorf("bad tolerance for compare criterion %q: %w", name, err)
+			}
+			if d <= 0 {
+				return fmt.Errorf("tolerance for compare criterion %q must be positive", name)
+			}
+			item.Window = d
+		}
+		for _, old := range list {
+			if old.Criterion == item.Criterion {
+				return fmt.Errorf("compare criterion %q used more than once", name)
+			}
+		}
+		list = append(list, item)
+	}
+	*l = list
+	return nil
+}
+
+// Type of the value
+func (l *CompareList) Type() string {
+	return "CompareList"
+}
+
+// UnmarshalJSON unmarshals a string value
+func (l *CompareList) UnmarshalJSON(in []byte) error {
+	var s string
+	err := json.Unmarshal(in, &s)
+	if err != nil {
+		return err
+	}
+	return l.Set(s)
+}
+
+// MarshalJSON marshals as a string value
+func (l CompareList) MarshalJSON() ([]byte, error) {
+	return json.Marshal(l.String())
+}
@@ -0,0 +1,65 @@
+package fs
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Check it satisfies the interface
+var _ flagger = (*CompareList)(nil)
+
+func TestCompareListString(t *testing.T) {
+	for _, test := range []struct {
+		in   CompareList
+		want string
+	}{
+		{nil, ""},
+		{CompareList{{Criterion: CompareSize}, {Criterion: CompareModTime}, {Criterion: CompareHash}}, "size,modtime,hash"},
+		{CompareList{{Criterion: CompareModTime, Window: 2 * time.Second}}, "modtime:2s"},
+		{CompareList{{Criterion: 99}}, "CompareCriterion(99)"},
+	} {
+		assert.Equal(t, test.want, test.in.String())
+	}
+}
+
+func TestCompareListSet(t *testing.T) {
+	for _, test := range []struct {
+		in   string
+		want CompareList
+		err  bool
+	}{
+		{"", nil, false},
+		{"size", CompareList{{Criterion: CompareSize}}, false},
+		{" Hash , size ", CompareList{{Criterion: CompareHash}, {Criterion: CompareSize}}, false},
+		{"size,modtime:1m30s,hash", CompareList{{Criterion: CompareSize}, {Criterion: CompareModTime, Window: 90 * time.Second}, {Criterion: CompareHash}}, false},
+		{"modtime:1d", CompareList{{Criterion: CompareModTime, Window: 24 * time.Hour}}, false},
+		{"potato", nil, true},
+		{"size:1s", nil, true},
+		{"modtime:potato", nil, true},
+		{"modtime:0s", nil, true},
+		{"size,hash,size", nil, true},
+	} {
+		var got CompareList
+		err := got.Set(test.in)
+		if test.err {
+			require.Error(t, err, test.in)
+		} else {
+			require.NoError(t, err, test.in)
+		}
+		assert.Equal(t, test.want, got, test.in)
+	}
+}
+
+func TestCompareListJSON(t *testing.T) {
+	var l CompareList
+	require.NoError(t, json.Unmarshal([]byte(`"size,modtime:2s"`), &l))
+	assert.Equal(t, CompareList{{Criterion: CompareSize}, {Criterion: CompareModTime, Window: 2 * time.Second}}, l)
+	out, err := json.Marshal(l)
+	require.NoError(t, err)
+	assert.Equal(t, `"size,modtime:2s"`, string(out))
+	assert.Error(t, json.Unmarshal([]byte(`"potato"`), &l))
+}
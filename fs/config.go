@@ -47,24 +47,31 @@ var (
 type ConfigInfo struct {
 	LogLevel               LogLevel
 	StatsLogLevel          LogLevel
+	LogLevelCategories     LogCategories // log levels overriding the defaults for categories of event
 	UseJSONLog             bool
 	DryRun                 bool
 	Interactive            bool
 	CheckSum               bool
+	CheckSumPartialSize    SizeSuffix
 	SizeOnly               bool
+	Compare                CompareList // criteria to decide whether files are the same, overriding --checksum, --size-only and --ignore-size
 	IgnoreTimes            bool
 	IgnoreExisting         bool
 	IgnoreErrors           bool
 	ModifyWindow           time.Duration
 	Checkers               int
 	Transfers              int
+	MaxConnections         int           // Maximum number of simultaneous connections, 0 for unlimited
 	ConnectTimeout         time.Duration // Connect timeout
 	Timeout                time.Duration // Data channel timeout
+	TransferStallTimeout   time.Duration // Abort and retry a transfer which moves no data for this long, 0 for off
 	ExpectContinueTimeout  time.Duration
 	Dump                   DumpFlags
 	InsecureSkipVerify     bool // Skip server certificate verification
 	DeleteMode             DeleteMode
 	MaxDelete              int64
+	DeleteManifest         string // file to write the files to be deleted by sync to
+	DeleteSrcDirsBelow     int    // only delete empty source dirs deeper than this when moving
 	TrackRenames           bool   // Track file renames.
 	TrackRenamesStrategy   string // Comma separated list of strategies used to track renames
 	LowLevelRetries        int
@@ -76,11 +83,13 @@ type ConfigInfo struct {
 	IgnoreCaseSync         bool
 	NoTraverse             bool
 	CheckFirst             bool
+	FailFast               bool
 	NoCheckDest            bool
 	NoUnicodeNormalization bool
 	NoUpdateModTime        bool
 	DataRateUnit           string
 	CompareDest            []string
+	ProtectDestFrom        []string // filter files of destination paths sync must not change
 	CopyDest               []string
 	BackupDir              string
 	Suffix                 string
@@ -95,15 +104,20 @@ type ConfigInfo struct {
 	DisableFeatures        []string
 	UserAgent              string
 	Immutable              bool
+	DedupeOnUpload         bool // server-side copy uploads whose content was already uploaded
+	CreateDirMarkers       bool // make Mkdir create directory markers on bucket based remotes
 	AutoConfirm            bool
 	StreamingUploadCutoff  SizeSuffix
 	StatsFileNameLength    int
+	StatsHistogram         bool // show the sizes and durations of transfers at the end
 	AskPassword            bool
 	PasswordCommand        SpaceSepList
 	UseServerModTime       bool
 	MaxTransfer            SizeSuffix
 	MaxDuration            time.Duration
 	CutoffMode             CutoffMode
+	OnHashMismatch         HashMismatchMode // what to do if the hashes differ after a transfer
+	QuarantineSuffix       string           // suffix for the files quarantined by OnHashMismatch
 	MaxBacklog             int
 	MaxStatsGroups         int
 	StatsOneLine           bool
@@ -120,10 +134,13 @@ type ConfigInfo struct {
 	MultiThreadCutoff      SizeSuffix
 	MultiThreadStreams     int
 	MultiThreadSet         bool   // whether MultiThreadStreams was set (set in fs/config/configflags)
+	PartialResume          bool   // save the progress of uploads so later runs can resume them
 	OrderBy                string // instructions on how to order the transfer
 	UploadHeaders          []*HTTPOption
 	DownloadHeaders        []*HTTPOption
 	Headers                []*HTTPOption
+	MetadataSet            []*MetadataOption // metadata to set on all uploads
+	MetadataSetWarn        bool              // warn rather than fail if metadata can't be set
 	RefreshTimes           bool
 	NoConsole              bool
 	TrafficClass           uint8
@@ -162,6 +179,7 @@ func NewConfig() *ConfigInfo {
 	c.AskPassword = true
 	c.TPSLimitBurst = 1
 	c.MaxTransfer = -1
+	c.QuarantineSuffix = ".failed"
 	c.MaxBacklog = 10000
 	// We do not want to set the default here. We use this variable being empty as part of the fall-through of options.
 	//	c.StatsOneLineDateFormat = "2006/01/02 15:04:05 - "
@@ -4,6 +4,8 @@ package configflags
 
 // Options set by command line flags
 import (
+	"errors"
+	"fmt"
 	"log"
 	"net"
 	"os"
@@ -37,6 +39,7 @@ var (
 	uploadHeaders   []string
 	downloadHeaders []string
 	headers         []string
+	metadataSet     []string
 )
 
 // AddFlags adds the non filing system specific flags to the command
@@ -47,12 +50,15 @@ func AddFlags(ci *fs.ConfigInfo, flagSet *pflag.FlagSet) {
 	flags.BoolVarP(flagSet, &quiet, "quiet", "q", false, "Print as little stuff as possible")
 	flags.DurationVarP(flagSet, &ci.ModifyWindow, "modify-window", "", ci.ModifyWindow, "Max time diff to be considered the same")
 	flags.IntVarP(flagSet, &ci.Checkers, "checkers", "", ci.Checkers, "Number of checkers to run in parallel")
-	flags.IntVarP(flagSet, &ci.Transfers, "transfers", "", ci.Transfers, "Number of file transfers to run in parallel")
+	flags.IntVarP(flagSet, &ci.Transfers, "transfers", "", ci.Transfers, "Number of file transfers to run in parallel, 0 for --max-connections less --checkers")
+	flags.IntVarP(flagSet, &ci.MaxConnections, "max-connections", "", ci.MaxConnections, "Maximum number of simultaneous backend connections, 0 for unlimited")
 	flags.StringVarP(flagSet, &configPath, "config", "", config.GetConfigPath(), "Config file")
 	flags.StringVarP(flagSet, &cacheDir, "cache-dir", "", config.GetCacheDir(), "Directory rclone will use for caching")
 	flags.StringVarP(flagSet, &tempDir, "temp-dir", "", os.TempDir(), "Directory rclone will use for temporary files")
 	flags.BoolVarP(flagSet, &ci.CheckSum, "checksum", "c", ci.CheckSum, "Skip based on checksum (if available) & size, not mod-time & size")
+	flags.FVarP(flagSet, &ci.CheckSumPartialSize, "checksum-partial-size", "", "With --checksum, compare this many leading bytes of files with no common hash instead of just their sizes")
 	flags.BoolVarP(flagSet, &ci.SizeOnly, "size-only", "", ci.SizeOnly, "Skip based on size only, not mod-time or checksum")
+	flags.FVarP(flagSet, &ci.Compare, "compare", "", "Decide if files are the same with these criteria in order, overriding --checksum and --size-only: "+fs.CompareCriteriaList)
 	flags.BoolVarP(flagSet, &ci.IgnoreTimes, "ignore-times", "I", ci.IgnoreTimes, "Don't skip files that match size and time - transfer all files")
 	flags.BoolVarP(flagSet, &ci.IgnoreExisting, "ignore-existing", "", ci.IgnoreExisting, "Skip all files that exist on destination")
 	flags.BoolVarP(flagSet, &ci.IgnoreErrors, "ignore-errors", "", ci.IgnoreErrors, "Delete even if there are I/O errors")
@@ -60,6 +66,7 @@ func AddFlags(ci *fs.ConfigInfo, flagSet *pflag.FlagSet) {
 	flags.BoolVarP(flagSet, &ci.Interactive, "interactive", "i", ci.Interactive, "Enable interactive mode")
 	flags.DurationVarP(flagSet, &ci.ConnectTimeout, "contimeout", "", ci.ConnectTimeout, "Connect timeout")
 	flags.DurationVarP(flagSet, &ci.Timeout, "timeout", "", ci.Timeout, "IO idle timeout")
+	flags.DurationVarP(flagSet, &ci.TransferStallTimeout, "transfer-stall-timeout", "", ci.TransferStallTimeout, "Abort and retry a transfer if no data moves for this long (0 to disable)")
 	flags.DurationVarP(flagSet, &ci.ExpectContinueTimeout, "expect-continue-timeout", "", ci.ExpectContinueTimeout, "Timeout when using expect / 100-continue in HTTP")
 	flags.BoolVarP(flagSet, &dumpHeaders, "dump-headers", "", false, "Dump HTTP headers - may contain sensitive info")
 	flags.BoolVarP(flagSet, &dumpBodies, "dump-bodies", "", false, "Dump HTTP headers and bodies - may contain sensitive info")
@@ -70,6 +77,8 @@ func AddFlags(ci *fs.ConfigInfo, flagSet *pflag.FlagSet) {
 	flags.BoolVarP(flagSet, &deleteDuring, "delete-during", "", false, "When synchronizing, delete files during transfer")
 	flags.BoolVarP(flagSet, &deleteAfter, "delete-after", "", false, "When synchronizing, delete files on destination after transferring (default)")
 	flags.Int64VarP(flagSet, &ci.MaxDelete, "max-delete", "", -1, "When synchronizing, limit the number of deletes")
+	flags.StringVarP(flagSet, &ci.DeleteManifest, "delete-manifest", "", ci.DeleteManifest, "When synchronizing, write the files to be deleted to this file before deleting them")
+	flags.IntVarP(flagSet, &ci.DeleteSrcDirsBelow, "delete-empty-src-dirs-below", "", ci.DeleteSrcDirsBelow, "With --delete-empty-src-dirs, only delete empty source dirs deeper than this (0 for no limit)")
 	flags.BoolVarP(flagSet, &ci.TrackRenames, "track-renames", "", ci.TrackRenames, "When synchronizing, track file renames and do a server-side move if possible")
 	flags.StringVarP(flagSet, &ci.TrackRenamesStrategy, "track-renames-strategy", "", ci.TrackRenamesStrategy, "Strategies to use when synchronizing using track-renames hash|modtime|leaf")
 	flags.IntVarP(flagSet, &ci.LowLevelRetries, "low-level-retries", "", ci.LowLevelRetries, "Number of low level retries to do")
@@ -82,11 +91,13 @@ func AddFlags(ci *fs.ConfigInfo, flagSet *pflag.FlagSet) {
 	flags.BoolVarP(flagSet, &ci.IgnoreCaseSync, "ignore-case-sync", "", ci.IgnoreCaseSync, "Ignore case when synchronizing")
 	flags.BoolVarP(flagSet, &ci.NoTraverse, "no-traverse", "", ci.NoTraverse, "Don't traverse destination file system on copy")
 	flags.BoolVarP(flagSet, &ci.CheckFirst, "check-first", "", ci.CheckFirst, "Do all the checks before starting transfers")
+	flags.BoolVarP(flagSet, &ci.FailFast, "fail-fast", "", ci.FailFast, "With --check-first, abort on the first error found while checking before transferring anything")
 	flags.BoolVarP(flagSet, &ci.NoCheckDest, "no-check-dest", "", ci.NoCheckDest, "Don't check the destination, copy regardless")
 	flags.BoolVarP(flagSet, &ci.NoUnicodeNormalization, "no-unicode-normalization", "", ci.NoUnicodeNormalization, "Don't normalize unicode characters in filenames")
 	flags.BoolVarP(flagSet, &ci.NoUpdateModTime, "no-update-modtime", "", ci.NoUpdateModTime, "Don't update destination mod-time if files identical")
 	flags.StringArrayVarP(flagSet, &ci.CompareDest, "compare-dest", "", nil, "Include additional comma separated server-side paths during comparison")
 	flags.StringArrayVarP(flagSet, &ci.CopyDest, "copy-dest", "", nil, "Implies --compare-dest but also copies files from paths into destination")
+	flags.StringArrayVarP(flagSet, &ci.ProtectDestFrom, "protect-dest-from", "", nil, "Never change destination paths matching the rules in this file")
 	flags.StringVarP(flagSet, &ci.BackupDir, "backup-dir", "", ci.BackupDir, "Make backups into hierarchy based in DIR")
 	flags.StringVarP(flagSet, &ci.Suffix, "suffix", "", ci.Suffix, "Suffix to add to changed files")
 	flags.BoolVarP(flagSet, &ci.SuffixKeepExtension, "suffix-keep-extension", "", ci.SuffixKeepExtension, "Preserve the extension when using --suffix")
@@ -97,10 +108,13 @@ func AddFlags(ci *fs.ConfigInfo, flagSet *pflag.FlagSet) {
 	flags.StringVarP(flagSet, &disableFeatures, "disable", "", "", "Disable a comma separated list of features (use --disable help to see a list)")
 	flags.StringVarP(flagSet, &ci.UserAgent, "user-agent", "", ci.UserAgent, "Set the user-agent to a specified string")
 	flags.BoolVarP(flagSet, &ci.Immutable, "immutable", "", ci.Immutable, "Do not modify files, fail if existing files have been modified")
+	flags.BoolVarP(flagSet, &ci.DedupeOnUpload, "dedupe-on-upload", "", ci.DedupeOnUpload, "Server-side copy files whose content has already been uploaded instead of uploading them again")
+	flags.BoolVarP(flagSet, &ci.CreateDirMarkers, "create-dir-markers", "", ci.CreateDirMarkers, "Make mkdir create directory markers on bucket based remotes so empty directories persist")
 	flags.BoolVarP(flagSet, &ci.AutoConfirm, "auto-confirm", "", ci.AutoConfirm, "If enabled, do not request console confirmation")
 	flags.IntVarP(flagSet, &ci.StatsFileNameLength, "stats-file-name-length", "", ci.StatsFileNameLength, "Max file name length in stats (0 for no limit)")
+	flags.BoolVarP(flagSet, &ci.StatsHistogram, "stats-histogram", "", ci.StatsHistogram, "Show a histogram of transfer sizes and durations at the end")
 	flags.FVarP(flagSet, &ci.LogLevel, "log-level", "", "Log level DEBUG|INFO|NOTICE|ERROR")
-	flags.FVarP(flagSet, &ci.StatsLogLevel, "stats-log-level", "", "Log level to show --stats output DEBUG|INFO|NOTICE|ERROR")
+	flags.FVarP(flagSet, statsLogLevel{ci}, "stats-log-level", "", "Log level to show --stats output DEBUG|INFO|NOTICE|ERROR, optionally with comma separated category=LEVEL overrides for "+fs.LogCategoryList)
 	flags.FVarP(flagSet, &ci.BwLimit, "bwlimit", "", "Bandwidth limit in KiB/s, or use suffix B|K|M|G|T|P or a full timetable")
 	flags.FVarP(flagSet, &ci.BwLimitFile, "bwlimit-file", "", "Bandwidth limit per file in KiB/s, or use suffix B|K|M|G|T|P or a full timetable")
 	flags.FVarP(flagSet, &ci.BufferSize, "buffer-size", "", "In memory buffer size when reading files for each --transfer")
@@ -109,6 +123,8 @@ func AddFlags(ci *fs.ConfigInfo, flagSet *pflag.FlagSet) {
 	flags.FVarP(flagSet, &ci.MaxTransfer, "max-transfer", "", "Maximum size of data to transfer")
 	flags.DurationVarP(flagSet, &ci.MaxDuration, "max-duration", "", 0, "Maximum duration rclone will transfer data for")
 	flags.FVarP(flagSet, &ci.CutoffMode, "cutoff-mode", "", "Mode to stop transfers when reaching the max transfer limit HARD|SOFT|CAUTIOUS")
+	flags.FVarP(flagSet, &ci.OnHashMismatch, "on-hash-mismatch", "", "What to do if the hash differs after a transfer retry|quarantine|fail")
+	flags.StringVarP(flagSet, &ci.QuarantineSuffix, "quarantine-suffix", "", ci.QuarantineSuffix, "Suffix to add to the files quarantined by --on-hash-mismatch quarantine")
 	flags.IntVarP(flagSet, &ci.MaxBacklog, "max-backlog", "", ci.MaxBacklog, "Maximum number of objects in sync or check backlog")
 	flags.IntVarP(flagSet, &ci.MaxStatsGroups, "max-stats-groups", "", ci.MaxStatsGroups, "Maximum number of stats groups to keep in memory, on max oldest is discarded")
 	flags.BoolVarP(flagSet, &ci.StatsOneLine, "stats-one-line", "", ci.StatsOneLine, "Make the stats fit on one line")
@@ -124,11 +140,14 @@ func AddFlags(ci *fs.ConfigInfo, flagSet *pflag.FlagSet) {
 	flags.StringVarP(flagSet, &ci.ClientKey, "client-key", "", ci.ClientKey, "Client SSL private key (PEM) for mutual TLS auth")
 	flags.FVarP(flagSet, &ci.MultiThreadCutoff, "multi-thread-cutoff", "", "Use multi-thread downloads for files above this size")
 	flags.IntVarP(flagSet, &ci.MultiThreadStreams, "multi-thread-streams", "", ci.MultiThreadStreams, "Max number of streams to use for multi-thread downloads")
+	flags.BoolVarP(flagSet, &ci.PartialResume, "partial-resume-across-runs", "", ci.PartialResume, "Save the progress of uploads to backends which can resume them so a later run carries on where it left off")
 	flags.BoolVarP(flagSet, &ci.UseJSONLog, "use-json-log", "", ci.UseJSONLog, "Use json log format")
 	flags.StringVarP(flagSet, &ci.OrderBy, "order-by", "", ci.OrderBy, "Instructions on how to order the transfers, e.g. 'size,descending'")
 	flags.StringArrayVarP(flagSet, &uploadHeaders, "header-upload", "", nil, "Set HTTP header for upload transactions")
 	flags.StringArrayVarP(flagSet, &downloadHeaders, "header-download", "", nil, "Set HTTP header for download transactions")
 	flags.StringArrayVarP(flagSet, &headers, "header", "", nil, "Set HTTP header for all transactions")
+	flags.StringArrayVarP(flagSet, &metadataSet, "metadata-set", "", nil, "Set metadata key=value on all uploaded objects")
+	flags.BoolVarP(flagSet, &ci.MetadataSetWarn, "metadata-set-warn", "", false, "Warn rather than fail if --metadata-set isn't supported by the destination")
 	flags.BoolVarP(flagSet, &ci.RefreshTimes, "refresh-times", "", ci.RefreshTimes, "Refresh the modtime of remote files")
 	flags.BoolVarP(flagSet, &ci.NoConsole, "no-console", "", ci.NoConsole, "Hide console window (supported on Windows only)")
 	flags.StringVarP(flagSet, &dscp, "dscp", "", "", "Set DSCP value to connections, value or name, e.g. CS1, LE, DF, AF21")
@@ -139,6 +158,49 @@ func AddFlags(ci *fs.ConfigInfo, flagSet *pflag.FlagSet) {
 	flags.DurationVarP(flagSet, &ci.KvLockTime, "kv-lock-time", "", ci.KvLockTime, "Maximum time to keep key-value database locked by process")
 }
 
+// statsLogLevel is the value of the --stats-log-level flag
+//
+// This is a comma separated list of a log level for the stats output
+// and/or category=LEVEL items setting the log level for categories
+// of events, e.g. "INFO,transfer=INFO,skip=DEBUG".
+type statsLogLevel struct {
+	ci *fs.ConfigInfo
+}
+
+// String turns a statsLogLevel into a string
+func (s statsLogLevel) String() string {
+	out := s.ci.StatsLogLevel.String()
+	if len(s.ci.LogLevelCategories) > 0 {
+		out += "," + s.ci.LogLevelCategories.String()
+	}
+	return out
+}
+
+// Set a statsLogLevel
+func (s statsLogLevel) Set(in string) error {
+	for _, part := range strings.Split(in, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		var err error
+		if strings.Contains(part, "=") {
+			err = s.ci.LogLevelCategories.Set(part)
+		} else {
+			err = s.ci.StatsLogLevel.Set(part)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Type of the value
+func (s statsLogLevel) Type() string {
+	return "string"
+}
+
 // ParseHeaders converts the strings passed in via the header flags into HTTPOptions
 func ParseHeaders(headers []string) []*fs.HTTPOption {
 	opts := []*fs.HTTPOption{}
@@ -156,6 +218,23 @@ func ParseHeaders(headers []string) []*fs.HTTPOption {
 	return opts
 }
 
+// ParseMetadata converts the strings passed in via the metadata flags into MetadataOptions
+func ParseMetadata(metadata []string) []*fs.MetadataOption {
+	opts := []*fs.MetadataOption{}
+	for _, item := range metadata {
+		parts := strings.SplitN(item, "=", 2)
+		if len(parts) == 1 || strings.TrimSpace(parts[0]) == "" {
+			log.Fatalf("Failed to parse '%s' as metadata. Expecting a string like: 'key=value'", item)
+		}
+		option := &fs.MetadataOption{
+			Key:   strings.TrimSpace(parts[0]),
+			Value: parts[1],
+		}
+		opts = append(opts, option)
+	}
+	return opts
+}
+
 // SetFlags converts any flags into config which weren't straight forward
 func SetFlags(ci *fs.ConfigInfo) {
 	if dumpHeaders {
@@ -270,6 +349,9 @@ func SetFlags(ci *fs.ConfigInfo) {
 	if len(headers) != 0 {
 		ci.Headers = ParseHeaders(headers)
 	}
+	if len(metadataSet) != 0 {
+		ci.MetadataSet = ParseMetadata(metadataSet)
+	}
 	if len(dscp) != 0 {
 		if value, ok := parseDSCP(dscp); ok {
 			ci.TrafficClass = value << 2
@@ -304,8 +386,29 @@ func SetFlags(ci *fs.ConfigInfo) {
 		}
 	}
 	nonZero(&ci.LowLevelRetries)
-	nonZero(&ci.Transfers)
 	nonZero(&ci.Checkers)
+	if err := setTransfers(ci); err != nil {
+		log.Fatal(err)
+	}
+	nonZero(&ci.Transfers)
+}
+
+// setTransfers works out the number of transfers for --transfers 0
+// which is --max-connections less --checkers. This isn't unlimited so
+// --max-connections must be set.
+func setTransfers(ci *fs.ConfigInfo) error {
+	if ci.Transfers != 0 {
+		return nil
+	}
+	if ci.MaxConnections <= 0 {
+		return errors.New("--transfers 0 needs --max-connections to be set")
+	}
+	ci.Transfers = ci.MaxConnections - ci.Checkers
+	if ci.Transfers <= 0 {
+		return fmt.Errorf("--max-connections %d must be bigger than --checkers %d to use --transfers 0", ci.MaxConnections, ci.Checkers)
+	}
+	fs.Debugf(nil, "Running %d transfers from --max-connections %d less --checkers %d", ci.Transfers, ci.MaxConnections, ci.Checkers)
+	return nil
 }
 
 // parseHeaders converts DSCP names to value
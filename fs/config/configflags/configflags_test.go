@@ -0,0 +1,39 @@
+package configflags
+
+import (
+	"testing"
+
+	"github.com/rclone/rclone/fs"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSetTransfers(t *testing.T) {
+	for _, test := range []struct {
+		transfers      int
+		maxConnections int
+		checkers       int
+		want           int
+		wantErr        string
+	}{
+		{transfers: 4, checkers: 8, want: 4},
+		{transfers: 4, maxConnections: 2, checkers: 8, want: 4},
+		{transfers: 0, maxConnections: 64, checkers: 16, want: 48},
+		{transfers: 0, checkers: 8, wantErr: "needs --max-connections"},
+		{transfers: 0, maxConnections: 8, checkers: 8, wantErr: "must be bigger than --checkers"},
+	} {
+		ci := &fs.ConfigInfo{
+			Transfers:      test.transfers,
+			MaxConnections: test.maxConnections,
+			Checkers:       test.checkers,
+		}
+		err := setTransfers(ci)
+		if test.wantErr != "" {
+			if assert.Error(t, err, test) {
+				assert.Contains(t, err.Error(), test.wantErr, test)
+			}
+			continue
+		}
+		assert.NoError(t, err, test)
+		assert.Equal(t, test.want, ci.Transfers, test)
+	}
+}
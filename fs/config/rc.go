@@ -3,8 +3,12 @@ package config
 import (
 	"context"
 	"errors"
+	"fmt"
+	"sort"
 
 	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fs/cache"
+	"github.com/rclone/rclone/fs/fspath"
 	"github.com/rclone/rclone/fs/rc"
 )
 
@@ -220,3 +224,249 @@ func rcDelete(ctx context.Context, in rc.Params) (out rc.Params, err error) {
 	DeleteRemote(name)
 	return nil, nil
 }
+
+// redactedValue replaces secrets in the output of config/export
+const redactedValue = "XXX-REDACTED-XXX"
+
+func init() {
+	rc.Add(rc.Call{
+		Path:         "config/export",
+		Fn:           rcExport,
+		Title:        "Exports the whole config.",
+		AuthRequired: true,
+		Help: `
+This returns the config for all the remotes in a form which can be
+passed to config/import.
+
+Parameters:
+
+- redact - set to false to include the secrets (optional, default true)
+
+Returns:
+
+- config - an object with the remote names as keys and the config parameters as values
+- redacted - true if the secrets were redacted
+
+By default passwords, client secrets, tokens, access keys and the
+other credentials of the backends are replaced with
+"` + redactedValue + `". With redact=false they are returned as they are
+stored in the config file, so passwords are obscured but not
+encrypted and tokens and keys are in plain text.
+`,
+	})
+}
+
+// credentialKeys are the config keys used by the backends for
+// credentials which aren't marked as passwords, e.g. the s3
+// secret_access_key. They are redacted whatever the backend.
+var credentialKeys = map[string]struct{}{
+	ConfigToken:                     {},
+	ConfigClientSecret:              {},
+	"access_grant":                  {},
+	"access_token":                  {},
+	"api_key":                       {},
+	"application_credential_secret": {},
+	"auth_token":                    {},
+	"authorization":                 {},
+	"bearer_token":                  {},
+	"key":                           {},
+	"key_pem":                       {},
+	"link_password":                 {},
+	"passphrase":                    {},
+	"permanent_token":               {},
+	"plex_token":                    {},
+	"private_access_key":            {},
+	"refresh_token":                 {},
+	"sas_url":                       {},
+	"secret_access_key":             {},
+	"service_account_credentials":   {},
+	"session_token":                 {},
+	"sse_customer_key":              {},
+}
+
+// isSecret returns true if key in the config for a remote of type
+// fsType should be redacted
+func isSecret(fsType, key string) bool {
+	if key == "type" {
+		return false
+	}
+	if _, found := credentialKeys[key]; found {
+		return true
+	}
+	ri, err := fs.Find(fsType)
+	if err != nil {
+		// Unknown backend so redact everything to be safe
+		return true
+	}
+	for _, option := range ri.Options {
+		if option.Name == key {
+			return option.IsPassword
+		}
+	}
+	return false
+}
+
+// Export the config
+func rcExport(ctx context.Context, in rc.Params) (out rc.Params, err error) {
+	redact, err := in.GetBool("redact")
+	if rc.IsErrParamNotFound(err) {
+		redact = true
+	} else if err != nil {
+		return nil, err
+	}
+	dump := rc.Params{}
+	for _, name := range LoadedData().GetSectionList() {
+		fsType := FileGet(name, "type")
+		params := rc.Params{}
+		for _, key := range LoadedData().GetKeyList(name) {
+			value := FileGet(name, key)
+			if redact && isSecret(fsType, key) {
+				value = redactedValue
+			}
+			params[key] = value
+		}
+		dump[name] = params
+	}
+	return rc.Params{
+		"config":   dump,
+		"redacted": redact,
+	}, nil
+}
+
+func init() {
+	rc.Add(rc.Call{
+		Path:         "config/import",
+		Fn:           rcImport,
+		Title:        "Imports config for many remotes at once.",
+		AuthRequired: true,
+		Help: `
+This adds or replaces remotes in the config file from an object in
+the same form as returned by config/export with redact=false.
+
+Parameters:
+
+- config - an object with the remote names as keys and the config parameters as values
+- replace - set to true to delete any remotes not in config (optional, default false)
+
+Each remote in config replaces the existing remote of the same name
+completely. The config parameters are stored as they are given, so
+passwords should be obscured as they are in the config file.
+
+The whole import is checked before any changes are made. It fails if
+any remote name is invalid, has an unknown type or contains a value
+which was redacted by config/export.
+
+Any remotes which were changed or deleted are removed from the cache
+of remotes in use so they are recreated with the new config.
+
+Returns:
+
+- added - names of the remotes added
+- updated - names of the remotes which were changed
+- deleted - names of the remotes deleted
+
+This can replace the whole config of a running rclone so it must only
+be used with an rc server which is secured with authentication.
+`,
+	})
+}
+
+// Import config for many remotes
+func rcImport(ctx context.Context, in rc.Params) (out rc.Params, err error) {
+	var config map[string]rc.Params
+	err = in.GetStruct("config", &config)
+	if err != nil {
+		return nil, err
+	}
+	replace, err := in.GetBool("replace")
+	if rc.NotErrParamNotFound(err) {
+		return nil, err
+	}
+
+	// Check everything before changing anything
+	remotes := make(map[string]map[string]string, len(config))
+	for name, params := range config {
+		err = fspath.CheckConfigName(name)
+		if err != nil {
+			return nil, fmt.Errorf("remote %q: %w", name, err)
+		}
+		values := make(map[string]string, len(params))
+		for key, value := range params {
+			vStr := fmt.Sprint(value)
+			if vStr == redactedValue {
+				return nil, fmt.Errorf("remote %q: value for %q was redacted by config/export", name, key)
+			}
+			values[key] = vStr
+		}
+		fsType := values["type"]
+		if fsType == "" {
+			return nil, fmt.Errorf("remote %q: missing type", name)
+		}
+		if _, err := fs.Find(fsType); err != nil {
+			return nil, fmt.Errorf("remote %q: couldn't find backend for type %q", name, fsType)
+		}
+		remotes[name] = values
+	}
+
+	// Now make the changes
+	var (
+		added   = []string{}
+		updated = []string{}
+		deleted = []string{}
+		data    = LoadedData()
+	)
+	if replace {
+		for _, name := range data.GetSectionList() {
+			if _, found := remotes[name]; !found {
+				data.DeleteSection(name)
+				deleted = append(deleted, name)
+			}
+		}
+	}
+	for name, values := range remotes {
+		if data.HasSection(name) {
+			if sectionEqual(data, name, values) {
+				continue
+			}
+			data.DeleteSection(name)
+			updated = append(updated, name)
+		} else {
+			added = append(added, name)
+		}
+		for key, value := range values {
+			data.SetValue(name, key, value)
+		}
+	}
+	if len(added)+len(updated)+len(deleted) > 0 {
+		SaveConfig()
+	}
+	for _, names := range [][]string{updated, deleted} {
+		for _, name := range names {
+			cache.ClearConfig(name)
+		}
+	}
+	sort.Strings(added)
+	sort.Strings(updated)
+	sort.Strings(deleted)
+	return rc.Params{
+		"added":   added,
+		"updated": updated,
+		"deleted": deleted,
+	}, nil
+}
+
+// sectionEqual returns true if the section name in data has exactly
+// the keys and values passed in
+func sectionEqual(data Storage, name string, values map[string]string) bool {
+	keys := data.GetKeyList(name)
+	if len(keys) != len(values) {
+		return false
+	}
+	for _, key := range keys {
+		value, found := data.GetValue(name, key)
+		if !found || values[key] != value {
+			return false
+		}
+	}
+	return true
+}
@@ -2,9 +2,14 @@ package config_test
 
 import (
 	"context"
+	"path/filepath"
+	"sort"
 	"testing"
 
+	_ "github.com/rclone/rclone/backend/azureblob"
+	_ "github.com/rclone/rclone/backend/b2"
 	_ "github.com/rclone/rclone/backend/local"
+	_ "github.com/rclone/rclone/backend/s3"
 	"github.com/rclone/rclone/fs"
 	"github.com/rclone/rclone/fs/config"
 	"github.com/rclone/rclone/fs/config/configfile"
@@ -153,3 +158,201 @@ func TestRcProviders(t *testing.T) {
 	}
 	assert.True(t, foundLocal, "didn't find local provider")
 }
+
+func TestRcExportImport(t *testing.T) {
+	ctx := context.Background()
+	configfile.Install()
+	name1, name2 := testName+"Import1", testName+"Import2"
+	defer func() {
+		config.DeleteRemote(name1)
+		config.DeleteRemote(name2)
+	}()
+	importCall := rc.Calls.Get("config/import")
+	require.NotNil(t, importCall)
+	exportCall := rc.Calls.Get("config/export")
+	require.NotNil(t, exportCall)
+
+	doImport := func(remotes rc.Params) (rc.Params, error) {
+		return importCall.Fn(ctx, rc.Params{"config": remotes})
+	}
+
+	out, err := doImport(rc.Params{
+		name1: rc.Params{"type": "local", "token": "secret", "test_key": "potato"},
+		name2: rc.Params{"type": "local"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, rc.Params{
+		"added":   []string{name1, name2},
+		"updated": []string{},
+		"deleted": []string{},
+	}, out)
+	assert.Equal(t, "potato", config.FileGet(name1, "test_key"))
+
+	// Export redacts the secrets by default
+	out, err = exportCall.Fn(ctx, rc.Params{})
+	require.NoError(t, err)
+	assert.Equal(t, true, out["redacted"])
+	remote := out["config"].(rc.Params)[name1].(rc.Params)
+	assert.Equal(t, "local", remote["type"])
+	assert.Equal(t, "potato", remote["test_key"])
+	assert.NotEqual(t, "secret", remote["token"])
+
+	// A redacted export can't be imported
+	_, err = doImport(rc.Params{name1: remote})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "redacted")
+
+	out, err = exportCall.Fn(ctx, rc.Params{"redact": false})
+	require.NoError(t, err)
+	assert.Equal(t, false, out["redacted"])
+	remote = out["config"].(rc.Params)[name1].(rc.Params)
+	assert.Equal(t, "secret", remote["token"])
+
+	// Importing the same again changes nothing
+	out, err = doImport(rc.Params{name1: remote})
+	require.NoError(t, err)
+	assert.Equal(t, []string{}, out["added"])
+	assert.Equal(t, []string{}, out["updated"])
+
+	// Changed remotes are replaced completely
+	out, err = doImport(rc.Params{name1: rc.Params{"type": "local", "test_key": "carrot"}})
+	require.NoError(t, err)
+	assert.Equal(t, []string{name1}, out["updated"])
+	assert.Equal(t, "carrot", config.FileGet(name1, "test_key"))
+	assert.Equal(t, "", config.FileGet(name1, "token"))
+
+	// Nothing is changed if any remote is invalid
+	for _, remotes := range []rc.Params{
+		{name1: rc.Params{"type": "local", "test_key": "turnip"}, name2: rc.Params{"type": "notABackend"}},
+		{name1: rc.Params{"type": "local", "test_key": "turnip"}, name2: rc.Params{"test_key": "turnip"}},
+		{name1: rc.Params{"type": "local", "test_key": "turnip"}, "bad/name": rc.Params{"type": "local"}},
+	} {
+		_, err = doImport(remotes)
+		assert.Error(t, err)
+		assert.Equal(t, "carrot", config.FileGet(name1, "test_key"))
+	}
+}
+
+// useTempConfig makes the config an empty file in a temporary
+// directory for the rest of the test
+func useTempConfig(t *testing.T) {
+	oldConfigPath := config.GetConfigPath()
+	require.NoError(t, config.SetConfigPath(filepath.Join(t.TempDir(), "rclone.conf")))
+	configfile.Install()
+	t.Cleanup(func() {
+		require.NoError(t, config.SetConfigPath(oldConfigPath))
+		configfile.Install()
+	})
+}
+
+func TestRcExportRedact(t *testing.T) {
+	ctx := context.Background()
+	useTempConfig(t)
+	importCall := rc.Calls.Get("config/import")
+	require.NotNil(t, importCall)
+	exportCall := rc.Calls.Get("config/export")
+	require.NotNil(t, exportCall)
+
+	remotes := rc.Params{
+		"s3": rc.Params{
+			"type":              "s3",
+			"provider":          "AWS",
+			"access_key_id":     "AKIAEXAMPLE",
+			"secret_access_key": "s3secret",
+			"session_token":     "s3session",
+			"sse_customer_key":  "s3customerkey",
+		},
+		"b2": rc.Params{
+			"type":    "b2",
+			"account": "b2account",
+			"key":     "b2key",
+		},
+		"azureblob": rc.Params{
+			"type":    "azureblob",
+			"account": "azaccount",
+			"key":     "azkey",
+			"sas_url": "https://azaccount.blob.core.windows.net/?sig=azsig",
+		},
+	}
+	_, err := importCall.Fn(ctx, rc.Params{"config": remotes})
+	require.NoError(t, err)
+
+	out, err := exportCall.Fn(ctx, rc.Params{})
+	require.NoError(t, err)
+	exported := out["config"].(rc.Params)
+	for _, test := range []struct {
+		remote string
+		kept   []string
+		secret []string
+	}{
+		{"s3", []string{"type", "provider", "access_key_id"}, []string{"secret_access_key", "session_token", "sse_customer_key"}},
+		{"b2", []string{"type", "account"}, []string{"key"}},
+		{"azureblob", []string{"type", "account"}, []string{"key", "sas_url"}},
+	} {
+		got := exported[test.remote].(rc.Params)
+		want := remotes[test.remote].(rc.Params)
+		for _, key := range test.kept {
+			assert.Equal(t, want[key], got[key], "%s %s", test.remote, key)
+		}
+		for _, key := range test.secret {
+			assert.Equal(t, "XXX-REDACTED-XXX", got[key], "%s %s", test.remote, key)
+		}
+	}
+
+	// they are all there without redact
+	out, err = exportCall.Fn(ctx, rc.Params{"redact": false})
+	require.NoError(t, err)
+	assert.Equal(t, remotes, out["config"])
+}
+
+func TestRcImportReplace(t *testing.T) {
+	ctx := context.Background()
+	useTempConfig(t)
+	importCall := rc.Calls.Get("config/import")
+	require.NotNil(t, importCall)
+	sections := func() []string {
+		names := config.FileSections()
+		sort.Strings(names)
+		return names
+	}
+
+	_, err := importCall.Fn(ctx, rc.Params{"config": rc.Params{
+		"one":   rc.Params{"type": "local"},
+		"two":   rc.Params{"type": "local", "test_key": "potato"},
+		"three": rc.Params{"type": "local"},
+	}})
+	require.NoError(t, err)
+
+	// without replace the other remotes are kept
+	out, err := importCall.Fn(ctx, rc.Params{"config": rc.Params{
+		"two": rc.Params{"type": "local", "test_key": "carrot"},
+	}})
+	require.NoError(t, err)
+	assert.Equal(t, []string{}, out["deleted"])
+	assert.Equal(t, []string{"one", "three", "two"}, sections())
+
+	// with replace they are deleted
+	out, err = importCall.Fn(ctx, rc.Params{
+		"config": rc.Params{
+			"two":  rc.Params{"type": "local", "test_key": "carrot"},
+			"four": rc.Params{"type": "local"},
+		},
+		"replace": true,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, rc.Params{
+		"added":   []string{"four"},
+		"updated": []string{},
+		"deleted": []string{"one", "three"},
+	}, out)
+	assert.Equal(t, []string{"four", "two"}, sections())
+	assert.Equal(t, "carrot", config.FileGet("two", "test_key"))
+
+	// nothing is deleted if the import is invalid
+	_, err = importCall.Fn(ctx, rc.Params{
+		"config":  rc.Params{"five": rc.Params{"type": "notABackend"}},
+		"replace": true,
+	})
+	require.Error(t, err)
+	assert.Equal(t, []string{"four", "two"}, sections())
+}
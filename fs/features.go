@@ -17,6 +17,7 @@ type Features struct {
 	DuplicateFiles          bool // allows duplicate files
 	ReadMimeType            bool // can read the mime type of objects
 	WriteMimeType           bool // can set the mime type of objects
+	WriteMetadata           bool // can set user metadata on objects with MetadataOption
 	CanHaveEmptyDirectories bool // can have empty directories
 	BucketBased             bool // is bucket based (like s3, swift, etc.)
 	BucketBasedRootOK       bool // is bucket based and can use from root
@@ -27,6 +28,11 @@ type Features struct {
 	SlowModTime             bool // if calling ModTime() generally takes an extra transaction
 	SlowHash                bool // if calling Hash() generally takes an extra transaction
 
+	// BufferSize overrides --buffer-size when reading from this Fs
+	// if set. NewFs sets it from buffer_size in the config of the
+	// remote and Mask passes it on to the backends wrapping it.
+	BufferSize *SizeSuffix
+
 	// Purge all files in the directory specified
 	//
 	// Implement this if you have a way of deleting all the files
@@ -143,6 +149,14 @@ type Features struct {
 	// It truncates any existing object
 	OpenWriterAt func(ctx context.Context, remote string, size int64) (WriterAtCloser, error)
 
+	// OpenWriterAtResume opens with a handle for random access writes
+	// like OpenWriterAt but keeps the data in any existing object
+	// so an upload can be carried on where it left off
+	//
+	// Pass in the remote desired and the size. The existing object
+	// is truncated to size if it is longer.
+	OpenWriterAtResume func(ctx context.Context, remote string, size int64) (WriterAtCloser, error)
+
 	// UserInfo returns info about the connected user
 	UserInfo func(ctx context.Context) (map[string]string, error)
 
@@ -278,6 +292,9 @@ func (ft *Features) Fill(ctx context.Context, f Fs) *Features {
 	if do, ok := f.(OpenWriterAter); ok {
 		ft.OpenWriterAt = do.OpenWriterAt
 	}
+	if do, ok := f.(OpenWriterAtResumer); ok {
+		ft.OpenWriterAtResume = do.OpenWriterAtResume
+	}
 	if do, ok := f.(UserInfoer); ok {
 		ft.UserInfo = do.UserInfo
 	}
@@ -305,6 +322,7 @@ func (ft *Features) Mask(ctx context.Context, f Fs) *Features {
 	ft.DuplicateFiles = ft.DuplicateFiles && mask.DuplicateFiles
 	ft.ReadMimeType = ft.ReadMimeType && mask.ReadMimeType
 	ft.WriteMimeType = ft.WriteMimeType && mask.WriteMimeType
+	ft.WriteMetadata = ft.WriteMetadata && mask.WriteMetadata
 	ft.CanHaveEmptyDirectories = ft.CanHaveEmptyDirectories && mask.CanHaveEmptyDirectories
 	ft.BucketBased = ft.BucketBased && mask.BucketBased
 	ft.BucketBasedRootOK = ft.BucketBasedRootOK && mask.BucketBasedRootOK
@@ -314,6 +332,9 @@ func (ft *Features) Mask(ctx context.Context, f Fs) *Features {
 	// ft.IsLocal = ft.IsLocal && mask.IsLocal Don't propagate IsLocal
 	ft.SlowModTime = ft.SlowModTime && mask.SlowModTime
 	ft.SlowHash = ft.SlowHash && mask.SlowHash
+	if ft.BufferSize == nil {
+		ft.BufferSize = mask.BufferSize
+	}
 
 	if mask.Purge == nil {
 		ft.Purge = nil
@@ -363,6 +384,9 @@ func (ft *Features) Mask(ctx context.Context, f Fs) *Features {
 	if mask.OpenWriterAt == nil {
 		ft.OpenWriterAt = nil
 	}
+	if mask.OpenWriterAtResume == nil {
+		ft.OpenWriterAtResume = nil
+	}
 	if mask.UserInfo == nil {
 		ft.UserInfo = nil
 	}
@@ -593,6 +617,17 @@ type OpenWriterAter interface {
 	OpenWriterAt(ctx context.Context, remote string, size int64) (WriterAtCloser, error)
 }
 
+// OpenWriterAtResumer is an optional interface for Fs
+type OpenWriterAtResumer interface {
+	// OpenWriterAtResume opens with a handle for random access writes
+	// like OpenWriterAt but keeps the data in any existing object
+	// so an upload can be carried on where it left off
+	//
+	// Pass in the remote desired and the size. The existing object
+	// is truncated to size if it is longer.
+	OpenWriterAtResume(ctx context.Context, remote string, size int64) (WriterAtCloser, error)
+}
+
 // UserInfoer is an optional interface for Fs
 type UserInfoer interface {
 	// UserInfo returns info about the connected user
@@ -26,6 +26,7 @@ var globalConfig = mustNewFilter(nil)
 type rule struct {
 	Include bool
 	Regexp  *regexp.Regexp
+	Source  string // where the rule came from, eg "--filter-from rules.txt:3"
 }
 
 // Match returns true if rule matches path
@@ -46,29 +47,44 @@ func (r *rule) String() string {
 type rules struct {
 	rules    []rule
 	existing map[string]struct{}
+	matcher  ruleMatcher // to find the first rule which matches quickly
 }
 
 // add adds a rule if it doesn't exist already
-func (rs *rules) add(Include bool, re *regexp.Regexp) {
+func (rs *rules) add(Include bool, re *regexp.Regexp, source string) {
 	if rs.existing == nil {
 		rs.existing = make(map[string]struct{})
 	}
 	newRule := rule{
 		Include: Include,
 		Regexp:  re,
+		Source:  source,
 	}
 	newRuleString := newRule.String()
 	if _, ok := rs.existing[newRuleString]; ok {
 		return // rule already exists
 	}
+	rs.matcher.add(len(rs.rules), re.String())
 	rs.rules = append(rs.rules, newRule)
 	rs.existing[newRuleString] = struct{}{}
 }
 
+// match returns the first rule which matches path or nil if none do
+func (rs *rules) match(path string) *rule {
+	i := rs.matcher.match(path, func(i int) bool {
+		return rs.rules[i].Match(path)
+	})
+	if i < 0 {
+		return nil
+	}
+	return &rs.rules[i]
+}
+
 // clear clears all the rules
 func (rs *rules) clear() {
 	rs.rules = nil
 	rs.existing = nil
+	rs.matcher = ruleMatcher{}
 }
 
 // len returns the number of rules
@@ -76,26 +92,44 @@ func (rs *rules) len() int {
 	return len(rs.rules)
 }
 
+// metadataRule is one rule matching the user metadata of objects
+type metadataRule struct {
+	Include bool
+	Key     string // key of the metadata, in lower case
+	Value   string // value the metadata must have to match
+}
+
+// String the rule
+func (r metadataRule) String() string {
+	c := "-"
+	if r.Include {
+		c = "+"
+	}
+	return fmt.Sprintf("%s %s=%s", c, r.Key, r.Value)
+}
+
 // FilesMap describes the map of files to transfer
 type FilesMap map[string]struct{}
 
 // Opt configures the filter
 type Opt struct {
-	DeleteExcluded bool
-	FilterRule     []string
-	FilterFrom     []string
-	ExcludeRule    []string
-	ExcludeFrom    []string
-	ExcludeFile    string
-	IncludeRule    []string
-	IncludeFrom    []string
-	FilesFrom      []string
-	FilesFromRaw   []string
-	MinAge         fs.Duration
-	MaxAge         fs.Duration
-	MinSize        fs.SizeSuffix
-	MaxSize        fs.SizeSuffix
-	IgnoreCase     bool
+	DeleteExcluded  bool
+	FilterRule      []string
+	FilterFrom      []string
+	ExcludeRule     []string
+	ExcludeFrom     []string
+	ExcludeFile     string
+	IncludeRule     []string
+	IncludeFrom     []string
+	FilesFrom       []string
+	FilesFromRaw    []string
+	MinAge          fs.Duration
+	MaxAge          fs.Duration
+	MinSize         fs.SizeSuffix
+	MaxSize         fs.SizeSuffix
+	IgnoreCase      bool
+	MetadataInclude []string
+	MetadataExclude []string
 }
 
 // DefaultOpt is the default config for the filter
@@ -113,8 +147,10 @@ type Filter struct {
 	ModTimeTo   time.Time
 	fileRules   rules
 	dirRules    rules
+	metaRules   []metadataRule
 	files       FilesMap // files if filesFrom
 	dirs        FilesMap // dirs from filesFrom
+	source      string   // source of the rules being added for Explain
 }
 
 // NewFilter parses the command line options and creates a Filter
@@ -146,6 +182,7 @@ func NewFilter(opt *Opt) (f *Filter, err error) {
 	foundExcludeRule := false
 
 	for _, rule := range f.Opt.IncludeRule {
+		f.source = "--include"
 		err = f.Add(true, rule)
 		if err != nil {
 			return nil, err
@@ -153,7 +190,8 @@ func NewFilter(opt *Opt) (f *Filter, err error) {
 		addImplicitExclude = true
 	}
 	for _, rule := range f.Opt.IncludeFrom {
-		err := forEachLine(rule, false, func(line string) error {
+		err := forEachNumberedLine(rule, false, func(n int, line string) error {
+			f.source = fmt.Sprintf("--include-from %s:%d", rule, n)
 			return f.Add(true, line)
 		})
 		if err != nil {
@@ -162,6 +200,7 @@ func NewFilter(opt *Opt) (f *Filter, err error) {
 		addImplicitExclude = true
 	}
 	for _, rule := range f.Opt.ExcludeRule {
+		f.source = "--exclude"
 		err = f.Add(false, rule)
 		if err != nil {
 			return nil, err
@@ -169,7 +208,8 @@ func NewFilter(opt *Opt) (f *Filter, err error) {
 		foundExcludeRule = true
 	}
 	for _, rule := range f.Opt.ExcludeFrom {
-		err := forEachLine(rule, false, func(line string) error {
+		err := forEachNumberedLine(rule, false, func(n int, line string) error {
+			f.source = fmt.Sprintf("--exclude-from %s:%d", rule, n)
 			return f.Add(false, line)
 		})
 		if err != nil {
@@ -183,13 +223,30 @@ func NewFilter(opt *Opt) (f *Filter, err error) {
 	}
 
 	for _, rule := range f.Opt.FilterRule {
+		f.source = "--filter"
 		err = f.AddRule(rule)
 		if err != nil {
 			return nil, err
 		}
 	}
 	for _, rule := range f.Opt.FilterFrom {
-		err := forEachLine(rule, false, f.AddRule)
+		err := forEachNumberedLine(rule, false, func(n int, line string) error {
+			f.source = fmt.Sprintf("--filter-from %s:%d", rule, n)
+			return f.AddRule(line)
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	for _, rule := range f.Opt.MetadataInclude {
+		err = f.addMetadataRule(true, rule)
+		if err != nil {
+			return nil, err
+		}
+	}
+	for _, rule := range f.Opt.MetadataExclude {
+		err = f.addMetadataRule(false, rule)
 		if err != nil {
 			return nil, err
 		}
@@ -226,11 +283,13 @@ func NewFilter(opt *Opt) (f *Filter, err error) {
 	}
 
 	if addImplicitExclude {
+		f.source = "implicit exclude added by --include"
 		err = f.Add(false, "/**")
 		if err != nil {
 			return nil, err
 		}
 	}
+	f.source = ""
 	if fs.GetConfig(context.Background()).Dump&fs.DumpFilters != 0 {
 		fmt.Println("--- start filters ---")
 		fmt.Println(f.DumpFilters())
@@ -258,11 +317,26 @@ func (f *Filter) addDirGlobs(Include bool, glob string) error {
 		if err != nil {
 			return err
 		}
-		f.dirRules.add(Include, dirRe)
+		f.dirRules.add(Include, dirRe, f.source)
 	}
 	return nil
 }
 
+// addMetadataRule adds a rule in the form key=value matching the user
+// metadata of objects
+func (f *Filter) addMetadataRule(Include bool, rule string) error {
+	i := strings.IndexRune(rule, '=')
+	if i <= 0 {
+		return fmt.Errorf("metadata filter %q must be in the form key=value", rule)
+	}
+	f.metaRules = append(f.metaRules, metadataRule{
+		Include: Include,
+		Key:     strings.ToLower(rule[:i]),
+		Value:   rule[i+1:],
+	})
+	return nil
+}
+
 // Add adds a filter rule with include or exclude status indicated
 func (f *Filter) Add(Include bool, glob string) error {
 	isDirRule := strings.HasSuffix(glob, "/")
@@ -279,7 +353,7 @@ func (f *Filter) Add(Include bool, glob string) error {
 		return err
 	}
 	if isFileRule {
-		f.fileRules.add(Include, re)
+		f.fileRules.add(Include, re, f.source)
 		// If include rule work out what directories are needed to scan
 		// if exclude rule, we can't rule anything out
 		// Unless it is `*` which matches everything
@@ -292,7 +366,7 @@ func (f *Filter) Add(Include bool, glob string) error {
 		}
 	}
 	if isDirRule {
-		f.dirRules.add(Include, re)
+		f.dirRules.add(Include, re, f.source)
 	}
 	return nil
 }
@@ -348,6 +422,23 @@ func (f *Filter) AddFile(file string) error {
 	return nil
 }
 
+// OnlyFiles returns a copy of f which only includes the files passed
+// in, replacing any `--files-from` list. The other rules still apply.
+//
+// DeleteExcluded is turned off in the copy as otherwise a sync with it
+// would delete every file on the destination which isn't in files.
+func (f *Filter) OnlyFiles(files []string) *Filter {
+	newF := *f
+	newF.Opt.DeleteExcluded = false
+	newF.files = nil
+	newF.dirs = nil
+	newF.initAddFile()
+	for _, file := range files {
+		_ = newF.AddFile(file)
+	}
+	return &newF
+}
+
 // Files returns all the files from the `--files-from` list
 //
 // It may be nil if the list is empty
@@ -370,15 +461,14 @@ func (f *Filter) InActive() bool {
 		f.Opt.MaxSize < 0 &&
 		f.fileRules.len() == 0 &&
 		f.dirRules.len() == 0 &&
+		len(f.metaRules) == 0 &&
 		len(f.Opt.ExcludeFile) == 0)
 }
 
 // IncludeRemote returns whether this remote passes the filter rules.
 func (f *Filter) IncludeRemote(remote string) bool {
-	for _, rule := range f.fileRules.rules {
-		if rule.Match(remote) {
-			return rule.Include
-		}
+	if rule := f.fileRules.match(remote); rule != nil {
+		return rule.Include
 	}
 	return true
 }
@@ -421,10 +511,8 @@ func (f *Filter) IncludeDirectory(ctx context.Context, fs fs.Fs) func(string) (b
 			return include, nil
 		}
 		remote += "/"
-		for _, rule := range f.dirRules.rules {
-			if rule.Match(remote) {
-				return rule.Include, nil
-			}
+		if rule := f.dirRules.match(remote); rule != nil {
+			return rule.Include, nil
 		}
 
 		return true, nil
@@ -482,13 +570,77 @@ func (f *Filter) IncludeObject(ctx context.Context, o fs.Object) bool {
 		modTime = time.Unix(0, 0)
 	}
 
-	return f.Include(o.Remote(), o.Size(), modTime)
+	if !f.Include(o.Remote(), o.Size(), modTime) {
+		return false
+	}
+	if IsDestination(ctx) {
+		// the metadata rules are only for the source
+		return true
+	}
+	return f.includeMetadata(ctx, o)
+}
+
+// HaveMetadataRules returns true if there are any metadata rules
+func (f *Filter) HaveMetadataRules() bool {
+	return len(f.metaRules) > 0
+}
+
+// includeMetadata returns whether the user metadata of o passes the
+// metadata rules. The object is excluded if it matches any exclude
+// rule, or if there are include rules and it matches none of them.
+//
+// Objects whose backend can't read metadata are treated as having
+// none.
+func (f *Filter) includeMetadata(ctx context.Context, o fs.Object) bool {
+	if len(f.metaRules) == 0 {
+		return true
+	}
+	metadata, err := objectMetadata(ctx, o)
+	if err != nil {
+		fs.Errorf(o, "Failed to read metadata to filter with: %v", err)
+	}
+	haveInclude, included := false, false
+	for _, rule := range f.metaRules {
+		value, found := metadata[rule.Key]
+		matched := found && value == rule.Value
+		if !rule.Include {
+			if matched {
+				return false
+			}
+			continue
+		}
+		haveInclude = true
+		if matched {
+			included = true
+		}
+	}
+	return !haveInclude || included
+}
+
+// objectMetadata returns the user metadata of o, or of the object it
+// wraps, or nil if neither can read it
+func objectMetadata(ctx context.Context, o fs.Object) (map[string]string, error) {
+	if do, ok := o.(fs.Metadataer); ok {
+		return do.Metadata(ctx)
+	}
+	if do, ok := fs.UnWrapObject(o).(fs.Metadataer); ok {
+		return do.Metadata(ctx)
+	}
+	return nil, nil
 }
 
 // forEachLine calls fn on every line in the file pointed to by path
 //
 // It ignores empty lines and lines starting with '#' or ';' if raw is false
 func forEachLine(path string, raw bool, fn func(string) error) (err error) {
+	return forEachNumberedLine(path, raw, func(_ int, line string) error {
+		return fn(line)
+	})
+}
+
+// forEachNumberedLine is like forEachLine but also passes the line
+// number, starting from 1, to fn
+func forEachNumberedLine(path string, raw bool, fn func(int, string) error) (err error) {
 	var scanner *bufio.Scanner
 	if path == "-" {
 		scanner = bufio.NewScanner(os.Stdin)
@@ -500,15 +652,17 @@ func forEachLine(path string, raw bool, fn func(string) error) (err error) {
 		scanner = bufio.NewScanner(in)
 		defer fs.CheckClose(in, &err)
 	}
+	n := 0
 	for scanner.Scan() {
 		line := scanner.Text()
+		n++
 		if !raw {
 			line = strings.TrimSpace(line)
 			if len(line) == 0 || line[0] == '#' || line[0] == ';' {
 				continue
 			}
 		}
-		err := fn(line)
+		err := fn(n, line)
 		if err != nil {
 			return err
 		}
@@ -533,9 +687,73 @@ func (f *Filter) DumpFilters() string {
 	for _, dirRule := range f.dirRules.rules {
 		rules = append(rules, dirRule.String())
 	}
+	if len(f.metaRules) > 0 {
+		rules = append(rules, "--- Metadata filter rules ---")
+		for _, metaRule := range f.metaRules {
+			rules = append(rules, metaRule.String())
+		}
+	}
 	return strings.Join(rules, "\n")
 }
 
+// Explanation describes why a path was included or excluded
+type Explanation struct {
+	Include bool   // whether the path is included
+	Rule    string // the rule which matched, or "" if none did
+	Source  string // where the rule came from, or "" if unknown
+	Reason  string // why the decision was made if no rule matched
+}
+
+// String returns the explanation in textual form
+func (e Explanation) String() string {
+	decision := "excluded"
+	if e.Include {
+		decision = "included"
+	}
+	if e.Rule == "" {
+		return fmt.Sprintf("%s: %s", decision, e.Reason)
+	}
+	if e.Source == "" {
+		return fmt.Sprintf(`%s by rule "%s"`, decision, e.Rule)
+	}
+	return fmt.Sprintf(`%s by rule "%s" from %s`, decision, e.Rule, e.Source)
+}
+
+// Explain returns which rule decides whether remote is included.
+// If isDir is set remote is treated as a directory and the directory
+// rules are used. It only considers the path so doesn't check the
+// size or age filters, nor --exclude-if-present.
+func (f *Filter) Explain(remote string, isDir bool) Explanation {
+	remote = strings.Trim(remote, "/")
+	// filesFrom takes precedence
+	if f.files != nil {
+		list, entries := f.files, "files"
+		if isDir {
+			list, entries = f.dirs, "directories"
+		}
+		if _, include := list[remote]; include {
+			return Explanation{Include: true, Reason: "in the --files-from " + entries}
+		}
+		return Explanation{Include: false, Reason: "not in the --files-from " + entries}
+	}
+	rules := &f.fileRules
+	if isDir {
+		if remote == "" {
+			return Explanation{Include: true, Reason: "the root is always included"}
+		}
+		rules = &f.dirRules
+		remote += "/"
+	}
+	if rule := rules.match(remote); rule != nil {
+		return Explanation{
+			Include: rule.Include,
+			Rule:    rule.String(),
+			Source:  rule.Source,
+		}
+	}
+	return Explanation{Include: true, Reason: "no rule matched"}
+}
+
 // HaveFilesFrom returns true if --files-from has been supplied
 func (f *Filter) HaveFilesFrom() bool {
 	return f.files != nil
@@ -671,3 +889,29 @@ func SetUseFilter(ctx context.Context, useFilter bool) context.Context {
 	*pVal = useFilter
 	return context.WithValue(ctx, useFlagContextKey, pVal)
 }
+
+// Context key for the "destination" flag
+type destFlagContextKeyType struct{}
+
+var destFlagContextKey = destFlagContextKeyType{}
+
+// IsDestination returns true if the context was marked with
+// SetDestination
+func IsDestination(ctx context.Context) bool {
+	if ctx == nil {
+		return false
+	}
+	isDst, _ := ctx.Value(destFlagContextKey).(bool)
+	return isDst
+}
+
+// SetDestination returns a context marking the listings made with it
+// as being of the destination of a sync, copy or check. The metadata
+// rules aren't applied to them as they select the source files, and
+// the destination may not have the same metadata or any at all.
+func SetDestination(ctx context.Context) context.Context {
+	if IsDestination(ctx) {
+		return ctx
+	}
+	return context.WithValue(ctx, destFlagContextKey, true)
+}
@@ -2,6 +2,7 @@ package filter
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io/ioutil"
 	"os"
@@ -116,6 +117,25 @@ func TestNewFilterWithFilesFromAlone(t *testing.T) {
 	}
 }
 
+func TestFilterOnlyFiles(t *testing.T) {
+	f, err := NewFilter(nil)
+	require.NoError(t, err)
+	require.NoError(t, f.AddRule("- *.bak"))
+	require.NoError(t, f.AddFile("dir/old"))
+	f.Opt.DeleteExcluded = true
+
+	g := f.OnlyFiles([]string{"dir/sub/file1", "file2", "file3.bak"})
+	assert.Equal(t, FilesMap{"dir/sub/file1": {}, "file2": {}, "file3.bak": {}}, g.files)
+	assert.Equal(t, FilesMap{"dir": {}, "dir/sub": {}}, g.dirs)
+	assert.False(t, g.IncludeRemote("file3.bak"))
+
+	assert.False(t, g.Opt.DeleteExcluded)
+
+	// The original is unchanged
+	assert.Equal(t, FilesMap{"dir/old": {}}, f.files)
+	assert.True(t, f.Opt.DeleteExcluded)
+}
+
 func TestNewFilterWithFilesFromRaw(t *testing.T) {
 	Opt := DefaultOpt
 
@@ -386,6 +406,68 @@ func TestNewFilterMaxSize(t *testing.T) {
 	assert.False(t, f.InActive())
 }
 
+// metadataObject is an object with user metadata
+type metadataObject struct {
+	mockobject.Object
+	metadata map[string]string
+	err      error
+}
+
+func (o metadataObject) Metadata(ctx context.Context) (map[string]string, error) {
+	return o.metadata, o.err
+}
+
+func TestNewFilterMetadata(t *testing.T) {
+	ctx := context.Background()
+	opt := DefaultOpt
+	opt.MetadataInclude = []string{"archive"}
+	_, err := NewFilter(&opt)
+	assert.Error(t, err)
+	opt.MetadataInclude = []string{"=true"}
+	_, err = NewFilter(&opt)
+	assert.Error(t, err)
+
+	opt.MetadataInclude = []string{"Archive=true", "tier=cold"}
+	opt.MetadataExclude = []string{"secret=yes"}
+	f, err := NewFilter(&opt)
+	require.NoError(t, err)
+	assert.False(t, f.InActive())
+	assert.True(t, f.HaveMetadataRules())
+	assert.Contains(t, f.DumpFilters(), "--- Metadata filter rules ---\n+ archive=true\n+ tier=cold\n- secret=yes")
+
+	object := func(metadata map[string]string) fs.Object {
+		return metadataObject{Object: mockobject.Object("file.txt"), metadata: metadata}
+	}
+	for _, test := range []struct {
+		in   fs.Object
+		want bool
+	}{
+		{object(map[string]string{"archive": "true"}), true},
+		{object(map[string]string{"tier": "cold", "archive": "false"}), true},
+		{object(map[string]string{"archive": "TRUE"}), false},
+		{object(map[string]string{"archive": "true", "secret": "yes"}), false},
+		{object(nil), false},
+		{metadataObject{Object: mockobject.Object("file.txt"), err: errors.New("boom")}, false},
+		{mockobject.Object("file.txt"), false},
+	} {
+		assert.Equal(t, test.want, f.IncludeObject(ctx, test.in), fmt.Sprintf("%v", test.in))
+	}
+
+	// the metadata rules aren't applied to the destination
+	dstCtx := SetDestination(ctx)
+	assert.True(t, IsDestination(dstCtx))
+	assert.False(t, IsDestination(ctx))
+	assert.True(t, f.IncludeObject(dstCtx, mockobject.Object("file.txt")))
+
+	// only exclude rules include objects without metadata
+	opt.MetadataInclude = nil
+	f, err = NewFilter(&opt)
+	require.NoError(t, err)
+	assert.True(t, f.IncludeObject(ctx, mockobject.Object("file.txt")))
+	assert.True(t, f.IncludeObject(ctx, object(map[string]string{"secret": "no"})))
+	assert.False(t, f.IncludeObject(ctx, object(map[string]string{"secret": "yes"})))
+}
+
 func TestNewFilterMinAndMaxAge(t *testing.T) {
 	f, err := NewFilter(nil)
 	require.NoError(t, err)
@@ -795,3 +877,41 @@ func TestGetConfig(t *testing.T) {
 	ctx3 := ReplaceConfig(ctx, f)
 	assert.Equal(t, globalConfig, GetConfig(ctx3))
 }
+
+func TestFilterExplain(t *testing.T) {
+	Opt := DefaultOpt
+	filterFrom := testFile(t, "#comment\n- /secret/**\n\n+ *.jpg\n")
+	defer func() {
+		require.NoError(t, os.Remove(filterFrom))
+	}()
+	Opt.FilterFrom = []string{filterFrom}
+	Opt.ExcludeRule = []string{"*.bak"}
+	f, err := NewFilter(&Opt)
+	require.NoError(t, err)
+
+	for _, test := range []struct {
+		in    string
+		isDir bool
+		want  Explanation
+	}{
+		{"file.jpg", false, Explanation{Include: true, Rule: `+ (^|/)[^/]*\.jpg$`, Source: "--filter-from " + filterFrom + ":4"}},
+		{"secret/file.jpg", false, Explanation{Include: false, Rule: `- ^secret/.*$`, Source: "--filter-from " + filterFrom + ":2"}},
+		{"dir/file.bak", false, Explanation{Include: false, Rule: `- (^|/)[^/]*\.bak$`, Source: "--exclude"}},
+		{"file.txt", false, Explanation{Include: true, Reason: "no rule matched"}},
+		{"secret", true, Explanation{Include: false, Rule: `- ^secret/.*$`, Source: "--filter-from " + filterFrom + ":2"}},
+		{"dir", true, Explanation{Include: true, Rule: `+ ^.*$`, Source: "--filter-from " + filterFrom + ":4"}},
+		{"", true, Explanation{Include: true, Reason: "the root is always included"}},
+	} {
+		got := f.Explain(test.in, test.isDir)
+		assert.Equal(t, test.want, got, test.in)
+	}
+
+	assert.Equal(t, `excluded by rule "- (^|/)[^/]*\.bak$" from --exclude`, f.Explain("file.bak", false).String())
+	assert.Equal(t, "included: no rule matched", f.Explain("file.txt", false).String())
+
+	// Check --files-from
+	require.NoError(t, f.AddFile("dir/file.txt"))
+	assert.Equal(t, Explanation{Include: true, Reason: "in the --files-from files"}, f.Explain("dir/file.txt", false))
+	assert.Equal(t, Explanation{Include: false, Reason: "not in the --files-from files"}, f.Explain("file.jpg", false))
+	assert.Equal(t, Explanation{Include: true, Reason: "in the --files-from directories"}, f.Explain("dir", true))
+}
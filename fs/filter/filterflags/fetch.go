@@ -0,0 +1,142 @@
+package filterflags
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fs/config"
+	"github.com/rclone/rclone/fs/fshttp"
+	"github.com/rclone/rclone/fs/fspath"
+)
+
+// FetchTimeout is the maximum time to spend fetching a rule file from
+// a URL or a remote
+var FetchTimeout = fs.Duration(time.Minute)
+
+// isURL returns true if source should be fetched over HTTP
+func isURL(source string) bool {
+	return strings.HasPrefix(source, "http://") || strings.HasPrefix(source, "https://")
+}
+
+// isRemote returns true if source refers to a file on a remote
+func isRemote(source string) bool {
+	if source == "-" {
+		return false
+	}
+	parsed, err := fspath.Parse(source)
+	return err == nil && parsed.Name != ""
+}
+
+// cachePath returns the local file the rules fetched from source are
+// cached in
+func cachePath(source string) string {
+	sum := md5.Sum([]byte(source))
+	return filepath.Join(config.GetCacheDir(), "filters", hex.EncodeToString(sum[:]))
+}
+
+// openURL opens the rule file at url using rclone's HTTP client
+func openURL(ctx context.Context, url string) (io.ReadCloser, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := fshttp.NewClient(ctx).Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		_ = resp.Body.Close()
+		return nil, fmt.Errorf("HTTP error %d (%s)", resp.StatusCode, resp.Status)
+	}
+	return resp.Body, nil
+}
+
+// openRemote opens the rule file at remote
+func openRemote(ctx context.Context, remote string) (io.ReadCloser, error) {
+	parent, leaf, err := fspath.Split(remote)
+	if err != nil {
+		return nil, err
+	}
+	if leaf == "" {
+		return nil, errors.New("is a directory")
+	}
+	f, err := fs.NewFs(ctx, parent)
+	if err != nil {
+		return nil, err
+	}
+	o, err := f.NewObject(ctx, leaf)
+	if err != nil {
+		return nil, err
+	}
+	return o.Open(ctx)
+}
+
+// fetch copies the rules from source into the local cache file
+func fetch(ctx context.Context, source, cacheFile string) (err error) {
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(FetchTimeout))
+	defer cancel()
+	var in io.ReadCloser
+	if isURL(source) {
+		in, err = openURL(ctx, source)
+	} else {
+		in, err = openRemote(ctx, source)
+	}
+	if err != nil {
+		return err
+	}
+	defer fs.CheckClose(in, &err)
+	data, err := ioutil.ReadAll(in)
+	if err != nil {
+		return err
+	}
+	err = os.MkdirAll(filepath.Dir(cacheFile), 0700)
+	if err != nil {
+		return fmt.Errorf("failed to make cache directory: %w", err)
+	}
+	// Write to a temporary file first so a failed write can't
+	// destroy the previous cached copy
+	tmpFile := cacheFile + ".tmp"
+	err = ioutil.WriteFile(tmpFile, data, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to write cache file: %w", err)
+	}
+	return os.Rename(tmpFile, cacheFile)
+}
+
+// fetchRuleFiles returns sources with any URLs or remotes replaced by
+// the path of a local copy of the rule file.
+//
+// If a rule file can't be fetched then the copy cached by a previous
+// run is used instead with a warning. It is an error if there isn't
+// one.
+func fetchRuleFiles(ctx context.Context, sources []string) (paths []string, err error) {
+	for _, source := range sources {
+		if !isURL(source) && !isRemote(source) {
+			paths = append(paths, source)
+			continue
+		}
+		cacheFile := cachePath(source)
+		err = fetch(ctx, source, cacheFile)
+		if err != nil {
+			if _, statErr := os.Stat(cacheFile); statErr != nil {
+				return nil, fmt.Errorf("failed to fetch filter rules from %q: %w", source, err)
+			}
+			fs.Logf(nil, "Failed to fetch filter rules from %q - using cached copy: %v", source, err)
+		} else {
+			fs.Debugf(nil, "Fetched filter rules from %q", source)
+		}
+		paths = append(paths, cacheFile)
+	}
+	return paths, nil
+}
@@ -0,0 +1,101 @@
+package filterflags
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	_ "github.com/rclone/rclone/backend/local"
+	"github.com/rclone/rclone/fs/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setCacheDir(t *testing.T) {
+	oldCacheDir := config.GetCacheDir()
+	require.NoError(t, config.SetCacheDir(t.TempDir()))
+	t.Cleanup(func() {
+		require.NoError(t, config.SetCacheDir(oldCacheDir))
+	})
+}
+
+func TestIsRemote(t *testing.T) {
+	for _, test := range []struct {
+		in   string
+		want bool
+	}{
+		{"-", false},
+		{"rules.txt", false},
+		{"/path/to/rules.txt", false},
+		{"remote:rules.txt", true},
+		{":local:/path/to/rules.txt", true},
+	} {
+		assert.Equal(t, test.want, isRemote(test.in), test.in)
+	}
+}
+
+func TestFetchRuleFilesURL(t *testing.T) {
+	ctx := context.Background()
+	setCacheDir(t)
+	rules := "- *.bak\n+ *.jpg\n- *\n"
+	fail := false
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if fail {
+			http.Error(w, "broken", http.StatusInternalServerError)
+			return
+		}
+		_, _ = w.Write([]byte(rules))
+	}))
+	defer ts.Close()
+	url := ts.URL + "/rules.txt"
+
+	// Local paths are passed through unchanged
+	paths, err := fetchRuleFiles(ctx, []string{"-", "local.txt", url})
+	require.NoError(t, err)
+	require.Equal(t, 3, len(paths))
+	assert.Equal(t, []string{"-", "local.txt"}, paths[:2])
+	assert.Equal(t, cachePath(url), paths[2])
+	data, err := ioutil.ReadFile(paths[2])
+	require.NoError(t, err)
+	assert.Equal(t, rules, string(data))
+
+	// A failed fetch uses the cached copy
+	fail = true
+	paths, err = fetchRuleFiles(ctx, []string{url})
+	require.NoError(t, err)
+	assert.Equal(t, []string{cachePath(url)}, paths)
+
+	// Unless there isn't one
+	_, err = fetchRuleFiles(ctx, []string{ts.URL + "/other.txt"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "HTTP error 500")
+}
+
+func TestFetchRuleFilesRemote(t *testing.T) {
+	ctx := context.Background()
+	setCacheDir(t)
+	dir := t.TempDir()
+	rules := "- *.bak\n"
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "rules.txt"), []byte(rules), 0600))
+	remote := ":local:" + filepath.ToSlash(dir) + "/rules.txt"
+
+	paths, err := fetchRuleFiles(ctx, []string{remote})
+	require.NoError(t, err)
+	require.Equal(t, []string{cachePath(remote)}, paths)
+	data, err := ioutil.ReadFile(paths[0])
+	require.NoError(t, err)
+	assert.Equal(t, rules, string(data))
+
+	// Remove the source and check the cached copy is used
+	require.NoError(t, os.Remove(filepath.Join(dir, "rules.txt")))
+	paths, err = fetchRuleFiles(ctx, []string{remote})
+	require.NoError(t, err)
+	assert.Equal(t, []string{cachePath(remote)}, paths)
+
+	_, err = fetchRuleFiles(ctx, []string{":local:" + filepath.ToSlash(dir) + "/missing.txt"})
+	assert.Error(t, err)
+}
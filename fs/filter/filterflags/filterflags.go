@@ -18,10 +18,16 @@ var (
 // Reload the filters from the flags
 func Reload(ctx context.Context) (err error) {
 	fi := filter.GetConfig(ctx)
-	newFilter, err := filter.NewFilter(&Opt)
+	opt := Opt
+	opt.FilterFrom, err = fetchRuleFiles(ctx, Opt.FilterFrom)
 	if err != nil {
 		return err
 	}
+	newFilter, err := filter.NewFilter(&opt)
+	if err != nil {
+		return err
+	}
+	newFilter.Opt.FilterFrom = Opt.FilterFrom
 	*fi = *newFilter
 	return nil
 }
@@ -31,7 +37,8 @@ func AddFlags(flagSet *pflag.FlagSet) {
 	rc.AddOptionReload("filter", &Opt, Reload)
 	flags.BoolVarP(flagSet, &Opt.DeleteExcluded, "delete-excluded", "", false, "Delete files on dest excluded from sync")
 	flags.StringArrayVarP(flagSet, &Opt.FilterRule, "filter", "f", nil, "Add a file-filtering rule")
-	flags.StringArrayVarP(flagSet, &Opt.FilterFrom, "filter-from", "", nil, "Read filtering patterns from a file (use - to read from stdin)")
+	flags.StringArrayVarP(flagSet, &Opt.FilterFrom, "filter-from", "", nil, "Read filtering patterns from a file, URL or remote (use - to read from stdin)")
+	flags.FVarP(flagSet, &FetchTimeout, "filter-from-timeout", "", "Timeout for fetching --filter-from rules from a URL or remote")
 	flags.StringArrayVarP(flagSet, &Opt.ExcludeRule, "exclude", "", nil, "Exclude files matching pattern")
 	flags.StringArrayVarP(flagSet, &Opt.ExcludeFrom, "exclude-from", "", nil, "Read exclude patterns from file (use - to read from stdin)")
 	flags.StringVarP(flagSet, &Opt.ExcludeFile, "exclude-if-present", "", "", "Exclude directories if filename is present")
@@ -43,6 +50,8 @@ func AddFlags(flagSet *pflag.FlagSet) {
 	flags.FVarP(flagSet, &Opt.MaxAge, "max-age", "", "Only transfer files younger than this in s or suffix ms|s|m|h|d|w|M|y")
 	flags.FVarP(flagSet, &Opt.MinSize, "min-size", "", "Only transfer files bigger than this in KiB or suffix B|K|M|G|T|P")
 	flags.FVarP(flagSet, &Opt.MaxSize, "max-size", "", "Only transfer files smaller than this in KiB or suffix B|K|M|G|T|P")
+	flags.StringArrayVarP(flagSet, &Opt.MetadataInclude, "metadata-include", "", nil, "Include files with this user metadata in the form key=value")
+	flags.StringArrayVarP(flagSet, &Opt.MetadataExclude, "metadata-exclude", "", nil, "Exclude files with this user metadata in the form key=value")
 	flags.BoolVarP(flagSet, &Opt.IgnoreCase, "ignore-case", "", false, "Ignore case in filters (case insensitive)")
 	//cvsExclude     = BoolP("cvs-exclude", "C", false, "Exclude files in the same way CVS does")
 }
@@ -0,0 +1,339 @@
+// Fast matching of large numbers of rules
+
+package filter
+
+import (
+	"regexp/syntax"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// ruleMatcher finds the first of a list of rules which matches a path
+// without trying every rule in turn.
+//
+// Rules for a literal path, e.g. "/dir/file.txt" or "file.txt", for
+// everything below a literal path, e.g. "/dir/**" or "dir/**", or for
+// file names ending in a literal, e.g. "*.jpg", are put in tries so
+// all of them are checked by walking the path once.
+// The other rules are tried as regexps in order, but only those which
+// come before the first rule found in the tries.
+type ruleMatcher struct {
+	tries   []*ruleTrie // the tries, created as needed
+	regexps []int       // indexes of the rules which aren't in a trie
+}
+
+// ruleTrie holds literal rules of one kind in a radix tree
+type ruleTrie struct {
+	anchored bool // if set match at the start of the path only, otherwise after any "/" too
+	fold     bool // if set the literals and paths are case folded
+	suffix   bool // if set the literals are suffixes of the file name and are stored reversed
+	root     trieNode
+}
+
+// trieNode is a node in a ruleTrie
+type trieNode struct {
+	label    string      // the bytes of the literal between the parent and this node
+	children []*trieNode // sorted by the first byte of the label
+	exact    int         // index of the first rule matching the path to here exactly or -1
+	prefix   int         // index of the first rule matching any path starting with the path to here or -1, or ending with it for suffix tries
+}
+
+// literalRule describes a rule which can be put in a trie
+type literalRule struct {
+	literal  string // the literal the path must match
+	anchored bool   // the literal must be at the start of the path, otherwise after any "/" too
+	fold     bool   // the literal is case insensitive
+	prefix   bool   // anything which isn't a newline may come after the literal
+	suffix   bool   // anything which isn't a "/" may come before the literal
+}
+
+// parseLiteralRule returns the rule the regexp re made by
+// GlobToRegexp matches if it can be put in a trie
+func parseLiteralRule(re string) (lr literalRule, ok bool) {
+	parsed, err := syntax.Parse(re, syntax.Perl)
+	if err != nil {
+		return lr, false
+	}
+	parsed = parsed.Simplify()
+	if parsed.Op != syntax.OpConcat {
+		return lr, false
+	}
+	subs := parsed.Sub
+	// the start - "^" or "(^|/)"
+	switch {
+	case len(subs) > 0 && subs[0].Op == syntax.OpBeginText:
+		lr.anchored = true
+	case len(subs) > 0 && isStartOrSlash(subs[0]):
+	default:
+		return lr, false
+	}
+	subs = subs[1:]
+	// the end - "$"
+	if len(subs) == 0 || subs[len(subs)-1].Op != syntax.OpEndText {
+		return lr, false
+	}
+	subs = subs[:len(subs)-1]
+	// anything in the file name before the literal - "[^/]*"
+	if len(subs) > 0 && subs[0].Op == syntax.OpStar && isNotSlash(subs[0].Sub[0]) {
+		lr.suffix = true
+		subs = subs[1:]
+	}
+	// the literal, if any
+	if len(subs) > 0 && subs[0].Op == syntax.OpLiteral {
+		lit := string(subs[0].Rune)
+		if strings.ContainsRune(lit, utf8.RuneError) {
+			// the regexp matches invalid UTF-8 with this
+			return lr, false
+		}
+		lr.fold = subs[0].Flags&syntax.FoldCase != 0
+		if lr.fold {
+			lit = foldString(lit)
+		}
+		lr.literal = lit
+		subs = subs[1:]
+	}
+	// anything after the literal - ".*"
+	if len(subs) > 0 && subs[0].Op == syntax.OpStar && subs[0].Sub[0].Op == syntax.OpAnyCharNotNL {
+		lr.prefix = true
+		subs = subs[1:]
+	}
+	switch {
+	case len(subs) != 0:
+		return lr, false
+	case lr.suffix && (lr.prefix || lr.literal == "" || strings.Contains(lr.literal, "/")):
+		return lr, false
+	case lr.literal == "" && !lr.prefix:
+		return lr, false
+	}
+	return lr, true
+}
+
+// isNotSlash returns true if re is "[^/]"
+func isNotSlash(re *syntax.Regexp) bool {
+	return re.Op == syntax.OpCharClass && len(re.Rune) == 4 &&
+		re.Rune[0] == 0 && re.Rune[1] == '/'-1 && re.Rune[2] == '/'+1 && re.Rune[3] == unicode.MaxRune
+}
+
+// reverseString returns s with its bytes in reverse order
+func reverseString(s string) string {
+	b := make([]byte, len(s))
+	for i := range b {
+		b[i] = s[len(s)-1-i]
+	}
+	return string(b)
+}
+
+// isStartOrSlash returns true if re is "(^|/)"
+func isStartOrSlash(re *syntax.Regexp) bool {
+	if re.Op != syntax.OpCapture || re.Sub[0].Op != syntax.OpAlternate {
+		return false
+	}
+	alt := re.Sub[0].Sub
+	return len(alt) == 2 && alt[0].Op == syntax.OpBeginText &&
+		alt[1].Op == syntax.OpLiteral && string(alt[1].Rune) == "/"
+}
+
+// foldRune returns the smallest rune which is the same as r ignoring
+// case, as the regexp package does
+func foldRune(r rune) rune {
+	min := r
+	for f := unicode.SimpleFold(r); f != r; f = unicode.SimpleFold(f) {
+		if f < min {
+			min = f
+		}
+	}
+	return min
+}
+
+// foldString returns s with each rune replaced by foldRune
+func foldString(s string) string {
+	var b strings.Builder
+	b.Grow(len(s))
+	for _, r := range s {
+		_, _ = b.WriteRune(foldRune(r))
+	}
+	return b.String()
+}
+
+// add adds the regexp of rule i, which must be greater than the
+// index of the rules added already
+func (m *ruleMatcher) add(i int, re string) {
+	lr, ok := parseLiteralRule(re)
+	if !ok {
+		m.regexps = append(m.regexps, i)
+		return
+	}
+	if lr.suffix {
+		m.trie(lr).insert(reverseString(lr.literal), true, i)
+		return
+	}
+	m.trie(lr).insert(lr.literal, lr.prefix, i)
+}
+
+// trie returns the trie for the kind of rule lr, creating it if needed
+func (m *ruleMatcher) trie(lr literalRule) *ruleTrie {
+	for _, t := range m.tries {
+		if t.anchored == lr.anchored && t.fold == lr.fold && t.suffix == lr.suffix {
+			return t
+		}
+	}
+	t := &ruleTrie{
+		anchored: lr.anchored,
+		fold:     lr.fold,
+		suffix:   lr.suffix,
+		root:     trieNode{exact: -1, prefix: -1},
+	}
+	m.tries = append(m.tries, t)
+	return t
+}
+
+// match returns the index of the first rule which matches path, using
+// matchRegexp to try the rules which aren't in a trie, or -1 if none do
+func (m *ruleMatcher) match(path string, matchRegexp func(i int) bool) int {
+	best := -1
+	var folded string
+	haveFolded := false
+	for _, t := range m.tries {
+		p := path
+		if t.fold {
+			if !haveFolded {
+				folded, haveFolded = foldString(path), true
+			}
+			p = folded
+		}
+		if i := t.match(p); i >= 0 && (best < 0 || i < best) {
+			best = i
+		}
+	}
+	for _, i := range m.regexps {
+		if best >= 0 && i > best {
+			break
+		}
+		if matchRegexp(i) {
+			return i
+		}
+	}
+	return best
+}
+
+// insert adds the rule with index i matching literal, or matching
+// everything starting with literal if prefix is set
+func (t *ruleTrie) insert(literal string, prefix bool, i int) {
+	node := &t.root
+	for literal != "" {
+		j := sort.Search(len(node.children), func(j int) bool {
+			return node.children[j].label[0] >= literal[0]
+		})
+		if j == len(node.children) || node.children[j].label[0] != literal[0] {
+			// no child shares a prefix so add a new one
+			child := &trieNode{label: literal, exact: -1, prefix: -1}
+			node.children = append(node.children, nil)
+			copy(node.children[j+1:], node.children[j:])
+			node.children[j] = child
+			node = child
+			break
+		}
+		child := node.children[j]
+		n := commonPrefixLen(child.label, literal)
+		if n < len(child.label) {
+			// split the child at the end of the common prefix
+			split := &trieNode{
+				label:    child.label[:n],
+				children: []*trieNode{child},
+				exact:    -1,
+				prefix:   -1,
+			}
+			child.label = child.label[n:]
+			node.children[j] = split
+			child = split
+		}
+		node = child
+		literal = literal[n:]
+	}
+	// keep the first rule as it takes precedence
+	if prefix {
+		if node.prefix < 0 {
+			node.prefix = i
+		}
+	} else if node.exact < 0 {
+		node.exact = i
+	}
+}
+
+// commonPrefixLen returns the length of the common prefix of a and b
+func commonPrefixLen(a, b string) int {
+	n := 0
+	for n < len(a) && n < len(b) && a[n] == b[n] {
+		n++
+	}
+	return n
+}
+
+// match returns the index of the first rule in the trie which matches
+// path or -1 if none do
+func (t *ruleTrie) match(path string) int {
+	if t.suffix {
+		// match the end of the path, and if anchored there mustn't
+		// be a "/" before the suffix
+		path = reverseString(path)
+		lastSlash := -1
+		if t.anchored {
+			lastSlash = strings.LastIndexByte(path, '/')
+		}
+		return t.matchAt(path, 0, lastSlash)
+	}
+	// ".*" doesn't match a newline so prefix rules only match if
+	// there isn't one after the prefix
+	lastNewline := strings.LastIndexByte(path, '\n')
+	best := t.matchAt(path, 0, lastNewline)
+	if t.anchored {
+		return best
+	}
+	for start := 0; start < len(path); start++ {
+		if path[start] != '/' {
+			continue
+		}
+		if i := t.matchAt(path, start+1, lastNewline); i >= 0 && (best < 0 || i < best) {
+			best = i
+		}
+	}
+	return best
+}
+
+// matchAt returns the index of the first rule in the trie which
+// matches path from start or -1 if none do
+//
+// Prefix rules only match if the rest of the path is after lastBad.
+func (t *ruleTrie) matchAt(path string, start, lastBad int) int {
+	best := -1
+	better := func(i int) {
+		if i >= 0 && (best < 0 || i < best) {
+			best = i
+		}
+	}
+	node := &t.root
+	pos := start
+	for {
+		if lastBad < pos {
+			better(node.prefix)
+		}
+		if pos == len(path) {
+			better(node.exact)
+			return best
+		}
+		c := path[pos]
+		j := sort.Search(len(node.children), func(j int) bool {
+			return node.children[j].label[0] >= c
+		})
+		if j == len(node.children) || node.children[j].label[0] != c {
+			return best
+		}
+		node = node.children[j]
+		if !strings.HasPrefix(path[pos:], node.label) {
+			return best
+		}
+		pos += len(node.label)
+	}
+}
@@ -0,0 +1,162 @@
+package filter
+
+import (
+	"fmt"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseLiteralRule(t *testing.T) {
+	for _, test := range []struct {
+		glob       string
+		ignoreCase bool
+		want       literalRule
+		wantOK     bool
+	}{
+		{glob: "/dir/file.txt", want: literalRule{literal: "dir/file.txt", anchored: true}, wantOK: true},
+		{glob: "file.txt", want: literalRule{literal: "file.txt"}, wantOK: true},
+		{glob: "/dir/**", want: literalRule{literal: "dir/", anchored: true, prefix: true}, wantOK: true},
+		{glob: "dir/**", want: literalRule{literal: "dir/", prefix: true}, wantOK: true},
+		{glob: "/**", want: literalRule{anchored: true, prefix: true}, wantOK: true},
+		{glob: "**", want: literalRule{prefix: true}, wantOK: true},
+		{glob: "File.TXT", ignoreCase: true, want: literalRule{literal: "FILE.TXT", fold: true}, wantOK: true},
+		{glob: "*.txt", want: literalRule{literal: ".txt", suffix: true}, wantOK: true},
+		{glob: "/*.txt", want: literalRule{literal: ".txt", anchored: true, suffix: true}, wantOK: true},
+		{glob: "*.TXT", ignoreCase: true, want: literalRule{literal: ".TXT", fold: true, suffix: true}, wantOK: true},
+		{glob: "*"},
+		{glob: "*a/b"},
+		{glob: "*.txt/**"},
+		{glob: "dir/**.txt"},
+		{glob: "file?.txt"},
+		{glob: "{a,b}.txt"},
+		{glob: "[ab].txt"},
+	} {
+		re, err := GlobToRegexp(test.glob, test.ignoreCase)
+		require.NoError(t, err)
+		got, ok := parseLiteralRule(re.String())
+		assert.Equal(t, test.wantOK, ok, test.glob)
+		if test.wantOK {
+			assert.Equal(t, test.want, got, test.glob)
+		}
+	}
+}
+
+// linearMatch finds the first rule which matches path by trying each
+// in turn - this is what ruleMatcher must agree with
+func linearMatch(rs *rules, path string) *rule {
+	for i := range rs.rules {
+		if rs.rules[i].Match(path) {
+			return &rs.rules[i]
+		}
+	}
+	return nil
+}
+
+func TestRuleMatcher(t *testing.T) {
+	parts := []string{"a", "b", "A", "dir", "Dir", "file.txt", "ſ", "S", "K", "K", "x\ny", "\xff"}
+	randomPath := func(r *rand.Rand) string {
+		path := ""
+		for n := r.Intn(4) + 1; n > 0; n-- {
+			if path != "" {
+				path += "/"
+			}
+			path += parts[r.Intn(len(parts))]
+		}
+		return path
+	}
+	randomGlob := func(r *rand.Rand) string {
+		glob := randomPath(r)
+		switch r.Intn(8) {
+		case 0:
+			glob = "/" + glob
+		case 1:
+			glob += "/**"
+		case 2:
+			glob = "/" + glob + "**"
+		case 3:
+			glob += "*"
+		case 4:
+			glob = "**"
+		case 5:
+			glob = "*" + parts[r.Intn(len(parts))]
+		case 6:
+			glob = "/*" + parts[r.Intn(len(parts))]
+		}
+		return glob
+	}
+	r := rand.New(rand.NewSource(1))
+	for _, ignoreCase := range []bool{false, true} {
+		for try := 0; try < 100; try++ {
+			var rs rules
+			for n := r.Intn(20); n > 0; n-- {
+				re, err := GlobToRegexp(randomGlob(r), ignoreCase)
+				require.NoError(t, err)
+				rs.add(r.Intn(2) == 0, re, "")
+			}
+			for n := 0; n < 50; n++ {
+				path := randomPath(r)
+				want := linearMatch(&rs, path)
+				got := rs.match(path)
+				if want == nil {
+					assert.Nil(t, got, "%q with %v", path, rs.rules)
+				} else if assert.NotNil(t, got, "%q with %v", path, rs.rules) {
+					assert.Equal(t, want.String(), got.String(), "%q with %v", path, rs.rules)
+				}
+			}
+		}
+	}
+}
+
+// makeBenchmarkFilter makes a filter with n rules, mostly for literal
+// files and directories with some wildcards mixed in
+func makeBenchmarkFilter(b *testing.B, n int) *Filter {
+	f, err := NewFilter(nil)
+	require.NoError(b, err)
+	for i := 0; i < n; i++ {
+		var rule string
+		switch i % 10 {
+		case 0:
+			rule = fmt.Sprintf("- *.tmp%d", i)
+		case 1, 2, 3:
+			rule = fmt.Sprintf("- /dir%d/**", i)
+		case 4, 5:
+			rule = fmt.Sprintf("+ dir%d/file%d.txt", i%100, i)
+		default:
+			rule = fmt.Sprintf("- /dir%d/file%d.txt", i%1000, i)
+		}
+		require.NoError(b, f.AddRule(rule))
+	}
+	require.NoError(b, f.AddRule("- **"))
+	return f
+}
+
+func BenchmarkIncludeRemote(b *testing.B) {
+	f := makeBenchmarkFilter(b, 50000)
+	paths := []string{
+		"dir7/file7.txt",
+		"dir12345/sub/file.txt",
+		"dir45/file49945.txt",
+		"other/dir/file.jpg",
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		f.IncludeRemote(paths[i%len(paths)])
+	}
+}
+
+func BenchmarkIncludeRemoteLinear(b *testing.B) {
+	f := makeBenchmarkFilter(b, 50000)
+	paths := []string{
+		"dir7/file7.txt",
+		"dir12345/sub/file.txt",
+		"dir45/file49945.txt",
+		"other/dir/file.jpg",
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		linearMatch(&f.fileRules, paths[i%len(paths)])
+	}
+}
@@ -51,6 +51,9 @@ func NewTransportCustom(ctx context.Context, customize func(*http.Transport)) ht
 	t.Proxy = http.ProxyFromEnvironment
 	t.MaxIdleConnsPerHost = 2 * (ci.Checkers + ci.Transfers + 1)
 	t.MaxIdleConns = 2 * t.MaxIdleConnsPerHost
+	if ci.MaxConnections > 0 {
+		t.MaxConnsPerHost = ci.MaxConnections
+	}
 	t.TLSHandshakeTimeout = ci.ConnectTimeout
 	t.ResponseHeaderTimeout = ci.Timeout
 
@@ -11,7 +11,9 @@ import (
 	"hash"
 	"hash/crc32"
 	"io"
+	"runtime"
 	"strings"
+	"sync"
 
 	"github.com/jzelinskie/whirlpool"
 )
@@ -181,16 +183,53 @@ func fromTypes(set Set) (map[Type]hash.Hash, error) {
 	return hashers, nil
 }
 
+// parallelThreshold is the size of write above which the hashers are
+// run concurrently. Below this the goroutine overhead isn't worth it.
+const parallelThreshold = 16 * 1024
+
+// multiWriter writes to several hashers, running them on separate
+// cores for large writes so calculating several hashes at once isn't
+// limited to the speed of a single core.
+type multiWriter struct {
+	hashers  []hash.Hash
+	parallel bool // set if there is more than one hasher and core
+}
+
+// Write writes p to all the hashers. hash.Hash never returns an error
+// from Write so neither does this.
+func (m *multiWriter) Write(p []byte) (n int, err error) {
+	if !m.parallel || len(p) < parallelThreshold {
+		for _, h := range m.hashers {
+			_, _ = h.Write(p)
+		}
+		return len(p), nil
+	}
+	var wg sync.WaitGroup
+	wg.Add(len(m.hashers) - 1)
+	for _, h := range m.hashers[1:] {
+		go func(h hash.Hash) {
+			defer wg.Done()
+			_, _ = h.Write(p)
+		}(h)
+	}
+	_, _ = m.hashers[0].Write(p)
+	wg.Wait()
+	return len(p), nil
+}
+
 // toMultiWriter will return a set of hashers into a
 // single multiwriter, where one write will update all
 // the hashers.
 func toMultiWriter(h map[Type]hash.Hash) io.Writer {
 	// Convert to to slice
-	var w = make([]io.Writer, 0, len(h))
+	var w = make([]hash.Hash, 0, len(h))
 	for _, v := range h {
 		w = append(w, v)
 	}
-	return io.MultiWriter(w...)
+	return &multiWriter{
+		hashers:  w,
+		parallel: len(w) > 1 && runtime.GOMAXPROCS(0) > 1,
+	}
 }
 
 // A MultiHasher will construct various hashes on
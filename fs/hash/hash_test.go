@@ -112,6 +112,22 @@ func TestMultiHasher(t *testing.T) {
 	}
 }
 
+// Check writes big enough to be hashed in parallel give the same
+// results as hashing each type on its own
+func TestMultiHasherLarge(t *testing.T) {
+	input := bytes.Repeat([]byte("potato"), 1024*1024)
+	mh := hash.NewMultiHasher()
+	n, err := mh.Write(input)
+	require.NoError(t, err)
+	assert.Equal(t, len(input), n)
+	assert.Equal(t, int64(len(input)), mh.Size())
+	for k, v := range mh.Sums() {
+		expect, err := hash.StreamTypes(bytes.NewBuffer(input), hash.NewHashSet(k))
+		require.NoError(t, err)
+		assert.Equal(t, expect[k], v, k.String())
+	}
+}
+
 func TestMultiHasherTypes(t *testing.T) {
 	h := hash.SHA1
 	for _, test := range hashTestSet {
@@ -208,3 +224,51 @@ func TestHashTypeStability(t *testing.T) {
 	assert.True(t, hash.Supported().Contains(hash.SHA1))
 	assert.False(t, hash.Supported().Contains(hash.None))
 }
+
+func benchmarkMultiHasher(b *testing.B, newWriter func() io.Writer) {
+	buf := bytes.Repeat([]byte{0x5A}, 1024*1024)
+	b.SetBytes(int64(len(buf)))
+	w := newWriter()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, err := w.Write(buf)
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// Benchmark each hash type on its own
+func BenchmarkHash(b *testing.B) {
+	for _, t := range hash.Supported().Array() {
+		t := t
+		b.Run(t.String(), func(b *testing.B) {
+			benchmarkMultiHasher(b, func() io.Writer {
+				mh, err := hash.NewMultiHasherTypes(hash.NewHashSet(t))
+				require.NoError(b, err)
+				return mh
+			})
+		})
+	}
+}
+
+// Benchmark all hash types calculated concurrently by the MultiHasher
+// against calculating them one after another
+func BenchmarkMultiHasher(b *testing.B) {
+	b.Run("Parallel", func(b *testing.B) {
+		benchmarkMultiHasher(b, func() io.Writer {
+			return hash.NewMultiHasher()
+		})
+	})
+	b.Run("Sequential", func(b *testing.B) {
+		benchmarkMultiHasher(b, func() io.Writer {
+			var ws []io.Writer
+			for _, t := range hash.Supported().Array() {
+				mh, err := hash.NewMultiHasherTypes(hash.NewHashSet(t))
+				require.NoError(b, err)
+				ws = append(ws, mh)
+			}
+			return io.MultiWriter(ws...)
+		})
+	})
+}
@@ -0,0 +1,59 @@
+package fs
+
+import (
+	"fmt"
+	"strings"
+)
+
+// HashMismatchMode describes what to do when the hashes differ after
+// a transfer
+type HashMismatchMode byte
+
+// HashMismatchMode constants
+const (
+	HashMismatchRetry HashMismatchMode = iota
+	HashMismatchQuarantine
+	HashMismatchFail
+	HashMismatchDefault = HashMismatchRetry
+)
+
+var hashMismatchModeToString = []string{
+	HashMismatchRetry:      "retry",
+	HashMismatchQuarantine: "quarantine",
+	HashMismatchFail:       "fail",
+}
+
+// String turns a HashMismatchMode into a string
+func (m HashMismatchMode) String() string {
+	if m >= HashMismatchMode(len(hashMismatchModeToString)) {
+		return fmt.Sprintf("HashMismatchMode(%d)", m)
+	}
+	return hashMismatchModeToString[m]
+}
+
+// Set a HashMismatchMode
+func (m *HashMismatchMode) Set(s string) error {
+	for n, name := range hashMismatchModeToString {
+		if s != "" && name == strings.ToLower(s) {
+			*m = HashMismatchMode(n)
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown hash mismatch mode %q", s)
+}
+
+// Type of the value
+func (m *HashMismatchMode) Type() string {
+	return "string"
+}
+
+// UnmarshalJSON makes sure the value can be parsed as a string or integer in JSON
+func (m *HashMismatchMode) UnmarshalJSON(in []byte) error {
+	return UnmarshalJSONFlag(in, m, func(i int64) error {
+		if i < 0 || i >= int64(len(hashMismatchModeToString)) {
+			return fmt.Errorf("out of range hash mismatch mode %d", i)
+		}
+		*m = (HashMismatchMode)(i)
+		return nil
+	})
+}
@@ -0,0 +1,75 @@
+package fs
+
+import (
+	"encoding/json"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Check it satisfies the interface
+var _ flagger = (*HashMismatchMode)(nil)
+
+func TestHashMismatchModeString(t *testing.T) {
+	for _, test := range []struct {
+		in   HashMismatchMode
+		want string
+	}{
+		{HashMismatchRetry, "retry"},
+		{HashMismatchQuarantine, "quarantine"},
+		{HashMismatchFail, "fail"},
+		{99, "HashMismatchMode(99)"},
+	} {
+		got := test.in.String()
+		assert.Equal(t, test.want, got, test.in)
+	}
+}
+
+func TestHashMismatchModeSet(t *testing.T) {
+	for _, test := range []struct {
+		in   string
+		want HashMismatchMode
+		err  bool
+	}{
+		{"retry", HashMismatchRetry, false},
+		{"QUARANTINE", HashMismatchQuarantine, false},
+		{"Fail", HashMismatchFail, false},
+		{"Potato", 0, true},
+		{"", 0, true},
+	} {
+		m := HashMismatchMode(0)
+		err := m.Set(test.in)
+		if test.err {
+			require.Error(t, err, test.in)
+		} else {
+			require.NoError(t, err, test.in)
+		}
+		assert.Equal(t, test.want, m, test.in)
+	}
+}
+
+func TestHashMismatchModeUnmarshalJSON(t *testing.T) {
+	for _, test := range []struct {
+		in   string
+		want HashMismatchMode
+		err  bool
+	}{
+		{`"retry"`, HashMismatchRetry, false},
+		{`"quarantine"`, HashMismatchQuarantine, false},
+		{`"Potato"`, 0, true},
+		{strconv.Itoa(int(HashMismatchFail)), HashMismatchFail, false},
+		{`99`, 0, true},
+		{`-99`, 0, true},
+	} {
+		var m HashMismatchMode
+		err := json.Unmarshal([]byte(test.in), &m)
+		if test.err {
+			require.Error(t, err, test.in)
+		} else {
+			require.NoError(t, err, test.in)
+		}
+		assert.Equal(t, test.want, m, test.in)
+	}
+}
@@ -92,6 +92,16 @@ var LogPrint = func(level LogLevel, text string) {
 	_ = log.Output(4, text)
 }
 
+// LogPrintObject, if set, is used instead of LogPrint to send the text
+// about o, which may be nil, to the logger of level. This is for log
+// formats which lay out the object separately from the text.
+var LogPrintObject func(level LogLevel, o interface{}, text string)
+
+// LogTee, if set, is called with the text about o, which may be nil,
+// of each message logged as well as sending it to the logger. This is
+// used to keep the recent log messages.
+var LogTee func(level LogLevel, o interface{}, text string)
+
 // LogValueItem describes keyed item for a JSON log entry
 type LogValueItem struct {
 	key    string
@@ -135,6 +145,10 @@ func (j LogValueItem) String() string {
 func LogPrintf(level LogLevel, o interface{}, text string, args ...interface{}) {
 	out := fmt.Sprintf(text, args...)
 
+	if LogTee != nil {
+		LogTee(level, o, out)
+	}
+
 	if GetConfig(context.TODO()).UseJSONLog {
 		fields := logrus.Fields{}
 		if o != nil {
@@ -162,6 +176,8 @@ func LogPrintf(level LogLevel, o interface{}, text string, args ...interface{})
 		case LogLevelEmergency, LogLevelAlert:
 			logrus.WithFields(fields).Panic(out)
 		}
+	} else if LogPrintObject != nil {
+		LogPrintObject(level, o, out)
 	} else {
 		if o != nil {
 			out = fmt.Sprintf("%v: %s", o, out)
@@ -0,0 +1,135 @@
+// In memory buffer of the recent log lines
+
+package log
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fs/rc"
+)
+
+// logLine is a log line kept in the log buffer
+type logLine struct {
+	Time   time.Time `json:"time"`
+	Level  string    `json:"level"`
+	Object string    `json:"object,omitempty"`
+	Text   string    `json:"text"`
+}
+
+// logBuffer is a ring buffer of the most recent log lines
+type logBuffer struct {
+	mu    sync.Mutex
+	lines []logLine // the lines, oldest at next once full
+	next  int       // index to write the next line to
+	full  bool      // set once lines has wrapped round
+}
+
+// newLogBuffer makes a logBuffer which keeps the last size lines
+func newLogBuffer(size int) *logBuffer {
+	return &logBuffer{
+		lines: make([]logLine, size),
+	}
+}
+
+// add a line about o, which may be nil, to the buffer
+func (b *logBuffer) add(now time.Time, level fs.LogLevel, o interface{}, text string) {
+	line := logLine{
+		Time:  now,
+		Level: level.String(),
+		Text:  text,
+	}
+	if o != nil {
+		line.Object = fmt.Sprint(o)
+	}
+	b.mu.Lock()
+	b.lines[b.next] = line
+	b.next++
+	if b.next >= len(b.lines) {
+		b.next = 0
+		b.full = true
+	}
+	b.mu.Unlock()
+}
+
+// tail returns the last n lines, oldest first, or all of them if n
+// is 0 or more than are in the buffer
+func (b *logBuffer) tail(n int) []logLine {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	count := b.next
+	if b.full {
+		count = len(b.lines)
+	}
+	if n <= 0 || n > count {
+		n = count
+	}
+	out := make([]logLine, 0, n)
+	start := b.next - n
+	if start < 0 {
+		out = append(out, b.lines[start+len(b.lines):]...)
+		start = 0
+	}
+	return append(out, b.lines[start:b.next]...)
+}
+
+// buffer is the log buffer or nil if it is off
+var buffer *logBuffer
+
+// startLogBuffer keeps the last size log lines in the buffer
+func startLogBuffer(size int) {
+	if size <= 0 {
+		return
+	}
+	buffer = newLogBuffer(size)
+	fs.LogTee = func(level fs.LogLevel, o interface{}, text string) {
+		buffer.add(time.Now(), level, o, text)
+	}
+}
+
+func init() {
+	rc.Add(rc.Call{
+		Path:         "core/logtail",
+		AuthRequired: true,
+		Fn:           rcLogTail,
+		Title:        "Returns the most recent log lines",
+		Help: `
+This returns the most recent lines logged by rclone, which are kept in
+memory. Only the lines at or above the log level set, e.g. with -v,
+are kept. The number of lines kept is set with --log-buffer-size.
+
+Parameters
+
+- n - the number of lines to return (optional, default 100, 0 for all kept)
+
+Returns
+
+- lines - a list of the lines logged, oldest first, each with
+    - time - when it was logged
+    - level - the log level, e.g. "ERROR"
+    - object - the file or remote it was about, if any
+    - text - the message
+`,
+	})
+}
+
+// Return the most recent log lines
+func rcLogTail(ctx context.Context, in rc.Params) (out rc.Params, err error) {
+	n, err := in.GetInt64("n")
+	if rc.IsErrParamNotFound(err) {
+		n = 100
+	} else if err != nil {
+		return nil, err
+	}
+	if n < 0 {
+		return nil, rc.NewErrParamInvalid(fmt.Errorf("n must not be negative, got %d", n))
+	}
+	if buffer == nil {
+		return nil, fmt.Errorf("the log buffer is off - set --log-buffer-size to turn it on")
+	}
+	lines := buffer.tail(int(n))
+	return rc.Params{"lines": lines}, nil
+}
@@ -0,0 +1,76 @@
+package log
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fs/rc"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLogBuffer(t *testing.T) {
+	now := time.Date(2022, 3, 18, 12, 34, 56, 0, time.UTC)
+	texts := func(lines []logLine) (out []string) {
+		for _, line := range lines {
+			out = append(out, line.Text)
+		}
+		return out
+	}
+
+	b := newLogBuffer(3)
+	assert.Equal(t, 0, len(b.tail(0)))
+	b.add(now, fs.LogLevelError, "file.txt", "one")
+	b.add(now, fs.LogLevelNotice, nil, "two")
+	assert.Equal(t, []logLine{
+		{Time: now, Level: "ERROR", Object: "file.txt", Text: "one"},
+		{Time: now, Level: "NOTICE", Text: "two"},
+	}, b.tail(0))
+	assert.Equal(t, []string{"two"}, texts(b.tail(1)))
+
+	// the oldest lines are dropped once full
+	b.add(now, fs.LogLevelNotice, nil, "three")
+	b.add(now, fs.LogLevelNotice, nil, "four")
+	b.add(now, fs.LogLevelNotice, nil, "five")
+	assert.Equal(t, []string{"three", "four", "five"}, texts(b.tail(0)))
+	assert.Equal(t, []string{"four", "five"}, texts(b.tail(2)))
+	assert.Equal(t, []string{"three", "four", "five"}, texts(b.tail(10)))
+}
+
+func TestRcLogTail(t *testing.T) {
+	oldBuffer, oldTee := buffer, fs.LogTee
+	defer func() {
+		buffer, fs.LogTee = oldBuffer, oldTee
+	}()
+	ctx := context.Background()
+	call := rc.Calls.Get("core/logtail")
+	require.NotNil(t, call)
+	assert.True(t, call.AuthRequired)
+
+	buffer = nil
+	_, err := call.Fn(ctx, rc.Params{})
+	require.Error(t, err)
+
+	startLogBuffer(2)
+	fs.Errorf("file.txt", "failed")
+	fs.Debugf(nil, "not logged at the default log level")
+	fs.Logf(nil, "done")
+
+	out, err := call.Fn(ctx, rc.Params{})
+	require.NoError(t, err)
+	lines := out["lines"].([]logLine)
+	require.Equal(t, 2, len(lines))
+	assert.Equal(t, "ERROR", lines[0].Level)
+	assert.Equal(t, "file.txt", lines[0].Object)
+	assert.Equal(t, "failed", lines[0].Text)
+	assert.Equal(t, "done", lines[1].Text)
+
+	out, err = call.Fn(ctx, rc.Params{"n": 1})
+	require.NoError(t, err)
+	assert.Equal(t, "done", out["lines"].([]logLine)[0].Text)
+
+	_, err = call.Fn(ctx, rc.Params{"n": -1})
+	assert.Error(t, err)
+}
@@ -22,12 +22,16 @@ type Options struct {
 	UseSyslog         bool   // Use Syslog for logging
 	SyslogFacility    string // Facility for syslog, e.g. KERN,USER,...
 	LogSystemdSupport bool   // set if using systemd logging
+	Color             string // When to color the pretty log: auto, always or never
+	BufferSize        int    // Number of log lines to keep in memory for core/logtail
 }
 
 // DefaultOpt is the default values used for Opt
 var DefaultOpt = Options{
 	Format:         "date,time",
 	SyslogFacility: "DAEMON",
+	Color:          "auto",
+	BufferSize:     100,
 }
 
 // Opt is the options for the logger
@@ -150,6 +154,14 @@ func InitLogging() {
 	if Opt.LogSystemdSupport {
 		startSystemdLog()
 	}
+
+	// Keep the recent log lines for core/logtail
+	startLogBuffer(Opt.BufferSize)
+
+	// Pretty logging output
+	if strings.Contains(flagsStr, ",pretty,") && !Redirected() && !Opt.LogSystemdSupport {
+		startPrettyLog(flagsStr)
+	}
 }
 
 // Redirected returns true if the log has been redirected from stdout
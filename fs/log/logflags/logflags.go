@@ -16,5 +16,7 @@ func AddFlags(flagSet *pflag.FlagSet) {
 	flags.StringVarP(flagSet, &log.Opt.Format, "log-format", "", log.Opt.Format, "Comma separated list of log format options")
 	flags.BoolVarP(flagSet, &log.Opt.UseSyslog, "syslog", "", log.Opt.UseSyslog, "Use Syslog for logging")
 	flags.StringVarP(flagSet, &log.Opt.SyslogFacility, "syslog-facility", "", log.Opt.SyslogFacility, "Facility for syslog, e.g. KERN,USER,...")
+	flags.StringVarP(flagSet, &log.Opt.Color, "log-color", "", log.Opt.Color, "When to color the pretty log: auto, always or never")
+	flags.IntVarP(flagSet, &log.Opt.BufferSize, "log-buffer-size", "", log.Opt.BufferSize, "Number of recent log lines to keep in memory for core/logtail, 0 to disable")
 	flags.BoolVarP(flagSet, &log.Opt.LogSystemdSupport, "log-systemd", "", log.Opt.LogSystemdSupport, "Activate systemd integration for the logger")
 }
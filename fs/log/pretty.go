@@ -0,0 +1,151 @@
+// Pretty log output for interactive use
+
+package log
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	colorable "github.com/mattn/go-colorable"
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/lib/terminal"
+)
+
+const (
+	prettyLevelWidth     = 7  // wide enough for all the levels normally used
+	prettyMaxObjectWidth = 40 // objects are padded to at most this
+)
+
+var prettyLevelColors = map[fs.LogLevel]string{
+	fs.LogLevelEmergency: terminal.Bright + terminal.RedFg,
+	fs.LogLevelAlert:     terminal.Bright + terminal.RedFg,
+	fs.LogLevelCritical:  terminal.Bright + terminal.RedFg,
+	fs.LogLevelError:     terminal.RedFg,
+	fs.LogLevelWarning:   terminal.YellowFg,
+	fs.LogLevelNotice:    terminal.YellowFg,
+	fs.LogLevelInfo:      terminal.GreenFg,
+	fs.LogLevelDebug:     terminal.HiBlackFg,
+}
+
+// prettyLog lays out the log in aligned columns of time, level,
+// object and text, optionally colored by level
+type prettyLog struct {
+	mu          sync.Mutex
+	color       bool   // set to color the output
+	timeFormat  string // format of the time column or "" for none
+	utc         bool   // set to show the time in UTC
+	pid         bool   // set to show the process id
+	objectWidth int    // width of the widest object seen so far
+}
+
+// newPrettyLog makes a prettyLog from the comma separated log format
+// options in flagsStr, e.g. ",date,time,"
+func newPrettyLog(flagsStr string, color bool) *prettyLog {
+	var timeFormat []string
+	if strings.Contains(flagsStr, ",date,") {
+		timeFormat = append(timeFormat, "2006/01/02")
+	}
+	if strings.Contains(flagsStr, ",microseconds,") {
+		timeFormat = append(timeFormat, "15:04:05.000000")
+	} else if strings.Contains(flagsStr, ",time,") {
+		timeFormat = append(timeFormat, "15:04:05")
+	}
+	return &prettyLog{
+		color:      color,
+		timeFormat: strings.Join(timeFormat, " "),
+		utc:        strings.Contains(flagsStr, ",UTC,"),
+		pid:        strings.Contains(flagsStr, ",pid,"),
+	}
+}
+
+// paint returns s in the color if coloring the output
+func (p *prettyLog) paint(color, s string) string {
+	if !p.color || color == "" || s == "" {
+		return s
+	}
+	return color + s + terminal.Reset
+}
+
+// format returns the log line for the text about o at level
+func (p *prettyLog) format(now time.Time, level fs.LogLevel, o interface{}, text string) string {
+	var out strings.Builder
+	if p.pid {
+		_, _ = fmt.Fprintf(&out, "[%d] ", os.Getpid())
+	}
+	if p.timeFormat != "" {
+		if p.utc {
+			now = now.UTC()
+		}
+		out.WriteString(p.paint(terminal.Dim, now.Format(p.timeFormat)))
+		out.WriteString(" ")
+	}
+	out.WriteString(p.paint(prettyLevelColors[level], fmt.Sprintf("%-*s", prettyLevelWidth, level)))
+	out.WriteString(" ")
+
+	object := ""
+	if o != nil {
+		object = fmt.Sprint(o)
+	}
+	width := utf8.RuneCountInString(object)
+	p.mu.Lock()
+	if width > p.objectWidth && width <= prettyMaxObjectWidth {
+		p.objectWidth = width
+	}
+	objectWidth := p.objectWidth
+	p.mu.Unlock()
+	if objectWidth > 0 {
+		pad := ""
+		if width < objectWidth {
+			pad = strings.Repeat(" ", objectWidth-width)
+		}
+		out.WriteString(p.paint(terminal.CyanFg, object))
+		out.WriteString(pad)
+		out.WriteString("  ")
+	}
+	out.WriteString(text)
+	return out.String()
+}
+
+// useColor returns whether the log output should be colored for the
+// --log-color setting
+func useColor(color string) (bool, error) {
+	switch strings.ToLower(color) {
+	case "always":
+		return true, nil
+	case "never":
+		return false, nil
+	case "auto", "":
+		if os.Getenv("NO_COLOR") != "" {
+			return false, nil
+		}
+		return terminal.IsTerminal(int(os.Stderr.Fd())), nil
+	}
+	return false, fmt.Errorf("unknown --log-color %q: must be auto, always or never", color)
+}
+
+// startPrettyLog starts the pretty log output if the log is going to a
+// terminal or coloring has been forced on. Otherwise the log is left
+// as it is.
+func startPrettyLog(flagsStr string) {
+	color, err := useColor(Opt.Color)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if !color && !terminal.IsTerminal(int(os.Stderr.Fd())) {
+		return
+	}
+	p := newPrettyLog(flagsStr, color)
+	// Only keep the file flags as the time is shown by the pretty log
+	log.SetFlags(log.Flags() & (log.Llongfile | log.Lshortfile))
+	if color {
+		log.SetOutput(colorable.NewColorable(os.Stderr))
+	}
+	fs.LogPrintObject = func(level fs.LogLevel, o interface{}, text string) {
+		_ = log.Output(4, p.format(time.Now(), level, o, text))
+	}
+}
@@ -0,0 +1,50 @@
+package log
+
+import (
+	"testing"
+	"time"
+
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/lib/terminal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPrettyLogFormat(t *testing.T) {
+	now := time.Date(2022, 3, 18, 12, 34, 56, 789000000, time.UTC)
+
+	p := newPrettyLog(",date,time,", false)
+	assert.Equal(t, "2022/03/18 12:34:56 NOTICE  hello", p.format(now, fs.LogLevelNotice, nil, "hello"))
+	// objects are aligned to the widest seen so far
+	assert.Equal(t, "2022/03/18 12:34:56 INFO    file.txt  copied", p.format(now, fs.LogLevelInfo, "file.txt", "copied"))
+	assert.Equal(t, "2022/03/18 12:34:56 ERROR   a.txt     failed", p.format(now, fs.LogLevelError, "a.txt", "failed"))
+	assert.Equal(t, "2022/03/18 12:34:56 NOTICE            done", p.format(now, fs.LogLevelNotice, nil, "done"))
+
+	p = newPrettyLog(",microseconds,", false)
+	assert.Equal(t, "12:34:56.789000 DEBUG   potato", p.format(now, fs.LogLevelDebug, nil, "potato"))
+
+	p = newPrettyLog(",pretty,", true)
+	assert.Equal(t, terminal.RedFg+"ERROR  "+terminal.Reset+" "+terminal.CyanFg+"a.txt"+terminal.Reset+"  failed", p.format(now, fs.LogLevelError, "a.txt", "failed"))
+}
+
+func TestUseColor(t *testing.T) {
+	color, err := useColor("always")
+	require.NoError(t, err)
+	assert.True(t, color)
+
+	color, err = useColor("NEVER")
+	require.NoError(t, err)
+	assert.False(t, color)
+
+	// NO_COLOR turns off auto but not always
+	t.Setenv("NO_COLOR", "1")
+	color, err = useColor("auto")
+	require.NoError(t, err)
+	assert.False(t, color)
+	color, err = useColor("always")
+	require.NoError(t, err)
+	assert.True(t, color)
+
+	_, err = useColor("potato")
+	assert.EqualError(t, err, `unknown --log-color "potato": must be auto, always or never`)
+}
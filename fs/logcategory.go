@@ -0,0 +1,84 @@
+package fs
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Categories of event whose log level can be overridden with
+// --stats-log-level category=LEVEL
+const (
+	LogCategoryTransfer = "transfer" // completed copies, moves and deletes
+	LogCategoryCheck    = "check"    // files found to be identical by check
+	LogCategorySkip     = "skip"     // files skipped as they don't need transferring
+)
+
+// LogCategoryList is a list of log categories used in the help
+var LogCategoryList = strings.Join([]string{LogCategoryTransfer, LogCategoryCheck, LogCategorySkip}, ",")
+
+// LogCategories maps log categories onto the log level to use for
+// events in that category
+type LogCategories map[string]LogLevel
+
+// String turns LogCategories into a string
+func (c LogCategories) String() string {
+	out := make([]string, 0, len(c))
+	for category, level := range c {
+		out = append(out, category+"="+level.String())
+	}
+	sort.Strings(out)
+	return strings.Join(out, ",")
+}
+
+// Set LogCategories from a comma separated list of category=LEVEL
+//
+// The new values are merged with any already set
+func (c *LogCategories) Set(s string) error {
+	categories := LogCategories{}
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		kv := strings.SplitN(part, "=", 2)
+		if len(kv) != 2 {
+			return fmt.Errorf("expecting category=LEVEL but got %q", part)
+		}
+		category := strings.ToLower(strings.TrimSpace(kv[0]))
+		switch category {
+		case LogCategoryTransfer, LogCategoryCheck, LogCategorySkip:
+		default:
+			return fmt.Errorf("Unknown log category %q - expecting one of %s", category, LogCategoryList)
+		}
+		var level LogLevel
+		if err := level.Set(strings.ToUpper(strings.TrimSpace(kv[1]))); err != nil {
+			return err
+		}
+		categories[category] = level
+	}
+	if *c == nil {
+		*c = LogCategories{}
+	}
+	for category, level := range categories {
+		(*c)[category] = level
+	}
+	return nil
+}
+
+// Type of the value
+func (c *LogCategories) Type() string {
+	return "LogCategories"
+}
+
+// LogCategoryPrintf writes logs for an event in category at the level
+// set for it with --stats-log-level, or at defaultLevel if it hasn't
+// been overridden.
+func LogCategoryPrintf(ctx context.Context, category string, defaultLevel LogLevel, o interface{}, text string, args ...interface{}) {
+	level := defaultLevel
+	if override, ok := GetConfig(ctx).LogLevelCategories[category]; ok {
+		level = override
+	}
+	LogLevelPrintf(level, o, text, args...)
+}
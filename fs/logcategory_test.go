@@ -0,0 +1,56 @@
+package fs
+
+import (
+	"testing"
+
+	"github.com/spf13/pflag"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Check it satisfies the interface
+var _ pflag.Value = (*LogCategories)(nil)
+
+func TestLogCategoriesString(t *testing.T) {
+	assert.Equal(t, "", LogCategories(nil).String())
+	assert.Equal(t, "check=DEBUG,transfer=INFO", LogCategories{
+		LogCategoryTransfer: LogLevelInfo,
+		LogCategoryCheck:    LogLevelDebug,
+	}.String())
+}
+
+func TestLogCategoriesSet(t *testing.T) {
+	for _, test := range []struct {
+		in      string
+		want    LogCategories
+		wantErr string
+	}{
+		{"", LogCategories{}, ""},
+		{"transfer=INFO", LogCategories{LogCategoryTransfer: LogLevelInfo}, ""},
+		{"transfer=INFO, Check=debug,skip=NOTICE", LogCategories{LogCategoryTransfer: LogLevelInfo, LogCategoryCheck: LogLevelDebug, LogCategorySkip: LogLevelNotice}, ""},
+		{"transfer", nil, "expecting category=LEVEL"},
+		{"potato=INFO", nil, "Unknown log category \"potato\""},
+		{"transfer=LOUD", nil, "Unknown log level \"LOUD\""},
+	} {
+		var c LogCategories
+		err := c.Set(test.in)
+		if test.wantErr != "" {
+			require.Error(t, err, test.in)
+			assert.Contains(t, err.Error(), test.wantErr, test.in)
+			assert.Nil(t, c, test.in)
+		} else {
+			require.NoError(t, err, test.in)
+			assert.Equal(t, test.want, c, test.in)
+		}
+	}
+
+	// Check values are merged
+	c := LogCategories{LogCategoryTransfer: LogLevelInfo, LogCategoryCheck: LogLevelInfo}
+	require.NoError(t, c.Set("check=DEBUG"))
+	assert.Equal(t, LogCategories{LogCategoryTransfer: LogLevelInfo, LogCategoryCheck: LogLevelDebug}, c)
+}
+
+func TestLogCategoriesType(t *testing.T) {
+	var c LogCategories
+	assert.Equal(t, "LogCategories", c.Type())
+}
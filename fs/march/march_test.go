@@ -217,6 +217,43 @@ func TestMarch(t *testing.T) {
 	}
 }
 
+// Test the metadata rules are only applied to the source
+func TestMarchMetadataFilter(t *testing.T) {
+	r := fstest.NewRun(t)
+	defer r.Finalise()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	file1 := r.WriteBoth(ctx, "file1", "hello world", t1)
+
+	// the files have no metadata so are excluded by this
+	opt := filter.DefaultOpt
+	opt.MetadataInclude = []string{"archive=true"}
+	fi, err := filter.NewFilter(&opt)
+	require.NoError(t, err)
+	ctx = filter.ReplaceConfig(ctx, fi)
+
+	mt := &marchTester{
+		ctx:    ctx,
+		cancel: cancel,
+	}
+	m := &March{
+		Ctx:      ctx,
+		Fdst:     r.Fremote,
+		Fsrc:     r.Flocal,
+		Dir:      "",
+		Callback: mt,
+	}
+
+	mt.processError(m.Run(ctx))
+	mt.cancel()
+	require.NoError(t, mt.currentError())
+
+	precision := fs.GetModifyWindow(ctx, r.Fremote, r.Flocal)
+	fstest.CompareItems(t, mt.srcOnly, nil, nil, precision, "srcOnly")
+	fstest.CompareItems(t, mt.dstOnly, []fstest.Item{file1}, nil, precision, "dstOnly")
+	fstest.CompareItems(t, mt.match, nil, nil, precision, "match")
+}
+
 func TestMarchNoTraverse(t *testing.T) {
 	for _, test := range []struct {
 		what        string
@@ -6,6 +6,7 @@ import (
 	"context"
 	"crypto/md5"
 	"encoding/base64"
+	"fmt"
 	"io/ioutil"
 	"os"
 	"path/filepath"
@@ -48,7 +49,49 @@ func NewFs(ctx context.Context, path string) (Fs, error) {
 		// These need to work as filesystem names as the VFS cache will use them
 		configName += suffix
 	}
-	return fsInfo.NewFs(ctx, configName, fsPath, config)
+	f, err := fsInfo.NewFs(ctx, configName, fsPath, config)
+	if f != nil {
+		bufErr := setBufferSize(f, config)
+		if bufErr != nil {
+			return nil, bufErr
+		}
+	}
+	return f, err
+}
+
+// setBufferSize reads the buffer_size key from the config of f and
+// stores it in the features of f as the buffer size to use when
+// reading from f.
+//
+// This works with any backend so can be set in the config file, the
+// connection string or with RCLONE_CONFIG_NAME_BUFFER_SIZE. If it
+// isn't set f keeps any buffer size it inherited from the Fs it wraps.
+func setBufferSize(f Fs, config configmap.Getter) error {
+	value, ok := config.Get("buffer_size")
+	if !ok || value == "" {
+		return nil
+	}
+	var size SizeSuffix
+	err := size.Set(value)
+	if err != nil {
+		return fmt.Errorf("%s: bad buffer_size %q: %w", f.Name(), value, err)
+	}
+	f.Features().BufferSize = &size
+	return nil
+}
+
+// BufferSize returns the size of the in memory buffer to use when
+// reading from f.
+//
+// This is the buffer_size set in the config of f, or of the Fs it
+// wraps, if present, otherwise --buffer-size. f may be nil.
+func BufferSize(ctx context.Context, f Info) SizeSuffix {
+	if do, ok := f.(Fs); ok {
+		if size := do.Features().BufferSize; size != nil {
+			return *size
+		}
+	}
+	return GetConfig(ctx).BufferSize
 }
 
 // ConfigFs makes the config for calling NewFs with.
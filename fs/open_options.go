@@ -209,6 +209,32 @@ func (o *HTTPOption) Mandatory() bool {
 	return false
 }
 
+// MetadataOption defines an item of user metadata to set on an object
+// when it is uploaded.
+//
+// It is only understood by backends which set the WriteMetadata
+// feature flag. It has no HTTP header form as each backend stores
+// metadata differently.
+type MetadataOption struct {
+	Key   string
+	Value string
+}
+
+// Header formats the option as an http header
+func (o *MetadataOption) Header() (key string, value string) {
+	return "", ""
+}
+
+// String formats the option into human-readable form
+func (o *MetadataOption) String() string {
+	return fmt.Sprintf("MetadataOption(%q,%q)", o.Key, o.Value)
+}
+
+// Mandatory returns whether the option must be parsed or can be ignored
+func (o *MetadataOption) Mandatory() bool {
+	return false
+}
+
 // HashesOption defines an option used to tell the local fs to limit
 // the number of hashes it calculates.
 type HashesOption struct {
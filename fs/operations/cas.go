@@ -0,0 +1,155 @@
+package operations
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fs/hash"
+)
+
+// ContentAddressedPath returns the path an object with hash sum is
+// stored under in a content addressed layout, eg "ab/cd/abcdef..."
+func ContentAddressedPath(sum string) string {
+	return path.Join(sum[:2], sum[2:4], sum)
+}
+
+// ContentAddressedIndex returns the name of the index file which maps
+// the original paths to their hashes of type ht, eg "index.md5"
+func ContentAddressedIndex(ht hash.Type) string {
+	return "index." + strings.ToLower(ht.String())
+}
+
+// casClaim is the result of storing a hash in a content addressed copy
+type casClaim struct {
+	done chan struct{} // closed when the content is stored or failed
+	err  error         // error storing the content, valid when done
+}
+
+// CopyContentAddressed copies every object in fsrc into fdst under a
+// path derived from its hash of type ht as given by
+// ContentAddressedPath.
+//
+// The hash is read from fsrc if it supports ht otherwise it is
+// calculated by reading the object. If an object with that hash is
+// already stored in fdst it isn't uploaded again.
+//
+// The original path of each object and its hash are recorded in the
+// index file, named by ContentAddressedIndex, in the root of fdst in
+// the same format as md5sum. An existing index is updated so it can
+// be used for restores.
+func CopyContentAddressed(ctx context.Context, fdst, fsrc fs.Fs, ht hash.Type) error {
+	if ht == hash.None {
+		return errors.New("a hash type is needed for a content addressed copy")
+	}
+	ci := fs.GetConfig(ctx)
+	indexName := ContentAddressedIndex(ht)
+
+	// Read the existing index if any
+	index := HashSums{}
+	indexObj, err := fdst.NewObject(ctx, indexName)
+	if err == nil {
+		index, err = ParseSumFile(ctx, indexObj)
+		if err != nil {
+			return fmt.Errorf("failed to read content addressed index: %w", err)
+		}
+	} else if err != fs.ErrorObjectNotFound && err != fs.ErrorNotAFile {
+		return fmt.Errorf("failed to find content addressed index: %w", err)
+	}
+
+	var (
+		mu      sync.Mutex // protects index, claimed and lastErr
+		lastErr error
+		claimed = map[string]*casClaim{} // hashes being copied in this run
+		wg      sync.WaitGroup
+		objects = make(chan fs.Object, ci.Transfers)
+	)
+	setErr := func(o fs.Object, err error) {
+		err = fs.CountError(err)
+		fs.Errorf(o, "Content addressed copy failed: %v", err)
+		mu.Lock()
+		lastErr = err
+		mu.Unlock()
+	}
+	wg.Add(ci.Transfers)
+	for i := 0; i < ci.Transfers; i++ {
+		go func() {
+			defer wg.Done()
+			for src := range objects {
+				sum, err := hashSum(ctx, ht, false, !fsrc.Hashes().Contains(ht), src)
+				if err != nil {
+					setErr(src, err)
+					continue
+				}
+				if len(sum) < 4 {
+					setErr(src, fmt.Errorf("%v hash %q too short to use", ht, sum))
+					continue
+				}
+				dstPath := ContentAddressedPath(sum)
+				mu.Lock()
+				claim, isClaimed := claimed[sum]
+				if !isClaimed {
+					claim = &casClaim{done: make(chan struct{})}
+					claimed[sum] = claim
+				}
+				mu.Unlock()
+				if isClaimed {
+					// Only record src once its content is stored
+					fs.Debugf(src, "Not copying as content is being stored as %q already", dstPath)
+					<-claim.done
+					if claim.err != nil {
+						setErr(src, fmt.Errorf("failed to store content as %q: %w", dstPath, claim.err))
+						continue
+					}
+				} else if dst, err := fdst.NewObject(ctx, dstPath); err == nil && dst.Size() == src.Size() {
+					fs.Debugf(src, "Not copying as content already stored as %q", dstPath)
+					close(claim.done)
+				} else {
+					_, claim.err = Copy(ctx, fdst, nil, dstPath, src)
+					close(claim.done)
+					if claim.err != nil {
+						setErr(src, claim.err)
+						continue
+					}
+				}
+				mu.Lock()
+				index[src.Remote()] = sum
+				mu.Unlock()
+			}
+		}()
+	}
+	err = ListFn(ctx, fsrc, func(o fs.Object) {
+		objects <- o
+	})
+	close(objects)
+	wg.Wait()
+	if err != nil {
+		return err
+	}
+
+	if SkipDestructive(ctx, indexName, "update content addressed index") {
+		return lastErr
+	}
+	remotes := make([]string, 0, len(index))
+	for remote := range index {
+		remotes = append(remotes, remote)
+	}
+	sort.Strings(remotes)
+	var buf bytes.Buffer
+	for _, remote := range remotes {
+		_, _ = fmt.Fprintf(&buf, "%s  %s\n", index[remote], remote)
+	}
+	_, err = Rcat(ctx, fdst, indexName, ioutil.NopCloser(&buf), time.Now())
+	if err != nil {
+		return fmt.Errorf("failed to write content addressed index: %w", err)
+	}
+	return lastErr
+}
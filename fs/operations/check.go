@@ -43,6 +43,10 @@ type CheckOpt struct {
 	Match        io.Writer // matching files
 	Differ       io.Writer // differing files
 	Error        io.Writer // files with errors of some kind
+	// ReportFn, if set, is called with each file reported and the
+	// sigil it has in the Combined output. It may be called from
+	// multiple goroutines.
+	ReportFn func(sigil rune, o fs.DirEntry)
 }
 
 // checkMarch is used to march over two Fses in the same way as
@@ -62,6 +66,9 @@ type checkMarch struct {
 // report outputs the fileName to out if required and to the combined log
 func (c *checkMarch) report(o fs.DirEntry, out io.Writer, sigil rune) {
 	c.reportFilename(o.String(), out, sigil)
+	if c.opt.ReportFn != nil {
+		c.opt.ReportFn(sigil, o)
+	}
 }
 
 func (c *checkMarch) reportFilename(filename string, out io.Writer, sigil rune) {
@@ -167,9 +174,9 @@ func (c *checkMarch) Match(ctx context.Context, dst, src fs.DirEntry) (recurse b
 					c.report(src, c.opt.Match, '=')
 					if noHash {
 						atomic.AddInt32(&c.noHashes, 1)
-						fs.Debugf(dstX, "OK - could not check hash")
+						fs.LogCategoryPrintf(ctx, fs.LogCategoryCheck, fs.LogLevelDebug, dstX, "OK - could not check hash")
 					} else {
-						fs.Debugf(dstX, "OK")
+						fs.LogCategoryPrintf(ctx, fs.LogCategoryCheck, fs.LogLevelDebug, dstX, "OK")
 					}
 				}
 			}()
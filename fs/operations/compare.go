@@ -0,0 +1,113 @@
+package operations
+
+import (
+	"context"
+	"time"
+
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fs/hash"
+)
+
+// compareEqual checks to see if src and dst are equal using the
+// criteria set with --compare in opt.compare, in the order given.
+//
+// Sizes which differ mean the objects aren't equal. Sizes which are
+// the same are never enough on their own, so the next criterion is
+// used.
+//
+// Modification times within the tolerance or identical hashes mean
+// the objects are equal and the criteria after them aren't used.
+//
+// Hashes which differ mean the objects aren't equal. Modification
+// times which differ only mean that when they are the last criterion.
+// Otherwise the criteria after them decide, and if a hash then shows
+// the objects are the same the modification time of dst is updated,
+// as it is without --compare.
+//
+// A criterion which can't be used is skipped, e.g. modification times
+// on a remote which doesn't support them or hashes when src and dst
+// have none in common. With --checksum-partial-size the first bytes of
+// the objects are compared instead of the hashes in that case.
+//
+// If the criteria run out the objects are equal unless the
+// modification times differed.
+func compareEqual(ctx context.Context, src fs.ObjectInfo, dst fs.Object, opt equalOpt) bool {
+	ci := fs.GetConfig(ctx)
+	modTimeDiffers := false
+	var srcModTime time.Time
+	for _, item := range opt.compare {
+		switch item.Criterion {
+		case fs.CompareSize:
+			if src.Size() >= 0 && dst.Size() >= 0 && src.Size() != dst.Size() {
+				fs.Debugf(src, "Sizes differ (src %d vs dst %d)", src.Size(), dst.Size())
+				return false
+			}
+		case fs.CompareModTime:
+			modifyWindow := compareModifyWindow(ctx, item, src, dst)
+			if modifyWindow == fs.ModTimeNotSupported {
+				fs.Debugf(src, "Modification times not supported so not comparing them")
+				continue
+			}
+			srcModTime = src.ModTime(ctx)
+			dstModTime := dst.ModTime(ctx)
+			dt := dstModTime.Sub(srcModTime)
+			if dt < modifyWindow && dt > -modifyWindow {
+				fs.Debugf(src, "Modification times the same (differ by %s, within tolerance %s)", dt, modifyWindow)
+				return true
+			}
+			fs.Debugf(src, "Modification times differ by %s: %v, %v", dt, srcModTime, dstModTime)
+			modTimeDiffers = true
+		case fs.CompareHash:
+			same, ht, _ := CheckHashes(ctx, src, dst)
+			if !same {
+				fs.Debugf(src, "%v differ", ht)
+				return false
+			}
+			if ht == hash.None {
+				if ci.CheckSumPartialSize <= 0 {
+					fs.Debugf(src, "No hashes in common so not comparing them")
+					continue
+				}
+				differ, ok := checkPartialContent(ctx, src, dst, int64(ci.CheckSumPartialSize))
+				if !ok {
+					continue
+				}
+				if differ {
+					fs.Debugf(src, "First %v of src and dst objects differ", ci.CheckSumPartialSize)
+					return false
+				}
+				fs.Debugf(src, "First %v of src and dst objects identical", ci.CheckSumPartialSize)
+			} else {
+				fs.Debugf(src, "%v of src and dst objects identical", ht)
+			}
+			if modTimeDiffers && opt.updateModTime {
+				return updateModTime(ctx, src, dst, srcModTime)
+			}
+			return true
+		}
+	}
+	if modTimeDiffers {
+		return false
+	}
+	fs.Debugf(src, "Objects identical comparing %v", opt.compare)
+	return true
+}
+
+// compareModifyWindow returns the tolerance to compare the
+// modification times of src and dst with for item.
+//
+// This is the tolerance set in item if there is one, otherwise
+// --modify-window, raised to the precision of the remotes.
+func compareModifyWindow(ctx context.Context, item fs.CompareItem, src fs.ObjectInfo, dst fs.Object) time.Duration {
+	window := fs.GetModifyWindow(ctx, src.Fs(), dst.Fs())
+	if item.Window <= 0 || window == fs.ModTimeNotSupported {
+		return window
+	}
+	window = item.Window
+	for _, f := range []fs.Info{src.Fs(), dst.Fs()} {
+		if f != nil && f.Precision() > window {
+			window = f.Precision()
+		}
+	}
+	return window
+}
@@ -0,0 +1,74 @@
+package operations
+
+import (
+	"context"
+
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fs/accounting"
+	"github.com/rclone/rclone/fs/hash"
+)
+
+// uploadedKey identifies the content of an object uploaded to a
+// destination
+type uploadedKey struct {
+	dst      string    // config string of the destination Fs
+	hashType hash.Type // type of hash below
+	hash     string    // hash of the source
+	size     int64     // size of the source
+}
+
+// uploadedKeyFor returns the key to look up src being uploaded to f
+// with and whether it is usable.
+//
+// The content of src is identified by its size and a hash, preferring
+// the hash type common to src and f.
+func uploadedKeyFor(ctx context.Context, f fs.Fs, src fs.Object, hashType hash.Type) (key uploadedKey, ok bool) {
+	if src.Size() < 0 {
+		return key, false
+	}
+	if hashType == hash.None {
+		hashType = src.Fs().Hashes().GetOne()
+		if hashType == hash.None {
+			return key, false
+		}
+	}
+	sum, err := src.Hash(ctx, hashType)
+	if err != nil || sum == "" {
+		return key, false
+	}
+	return uploadedKey{
+		dst:      fs.ConfigString(f),
+		hashType: hashType,
+		hash:     sum,
+		size:     src.Size(),
+	}, true
+}
+
+// findUploaded returns an object already uploaded to f with the same
+// stats group as ctx with the same content as src or nil if there
+// isn't one or --dedupe-on-upload isn't in use.
+func findUploaded(ctx context.Context, f fs.Fs, src fs.Object, hashType hash.Type) fs.Object {
+	ci := fs.GetConfig(ctx)
+	if !ci.DedupeOnUpload || f.Features().Copy == nil {
+		return nil
+	}
+	key, ok := uploadedKeyFor(ctx, f, src, hashType)
+	if !ok {
+		return nil
+	}
+	return accounting.Stats(ctx).Uploaded(key)
+}
+
+// recordUploaded remembers that dst on f has the same content as src
+// so later duplicates of src can be server-side copied from it.
+func recordUploaded(ctx context.Context, f fs.Fs, src, dst fs.Object, hashType hash.Type) {
+	ci := fs.GetConfig(ctx)
+	if !ci.DedupeOnUpload || f.Features().Copy == nil {
+		return
+	}
+	key, ok := uploadedKeyFor(ctx, f, src, hashType)
+	if !ok {
+		return
+	}
+	accounting.Stats(ctx).AddUploaded(key, dst)
+}
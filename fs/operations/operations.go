@@ -2,6 +2,7 @@
 package operations
 
 import (
+	"bufio"
 	"bytes"
 	"context"
 	"encoding/base64"
@@ -26,6 +27,7 @@ import (
 	"github.com/rclone/rclone/fs/accounting"
 	"github.com/rclone/rclone/fs/cache"
 	"github.com/rclone/rclone/fs/config"
+	"github.com/rclone/rclone/fs/config/obscure"
 	"github.com/rclone/rclone/fs/filter"
 	"github.com/rclone/rclone/fs/fserrors"
 	"github.com/rclone/rclone/fs/fshttp"
@@ -52,14 +54,20 @@ import (
 // err - may return an error which will already have been logged
 //
 // If an error is returned it will return equal as false
+//
+// The common hash types are tried in turn until one is found which
+// both objects have a value for, so hash is only HashNone if there
+// isn't one.
 func CheckHashes(ctx context.Context, src fs.ObjectInfo, dst fs.Object) (equal bool, ht hash.Type, err error) {
 	common := src.Fs().Hashes().Overlap(dst.Fs().Hashes())
 	// fs.Debugf(nil, "Shared hashes: %v", common)
-	if common.Count() == 0 {
-		return true, hash.None, nil
+	for _, commonType := range common.Array() {
+		equal, ht, _, _, err = checkHashes(ctx, src, dst, commonType)
+		if err != nil || ht != hash.None {
+			return equal, ht, err
+		}
 	}
-	equal, ht, _, _, err = checkHashes(ctx, src, dst, common.GetOne())
-	return equal, ht, err
+	return true, hash.None, nil
 }
 
 // checkHashes does the work of CheckHashes but takes a hash.Type and
@@ -102,6 +110,49 @@ func checkHashes(ctx context.Context, src fs.ObjectInfo, dst fs.Object, ht hash.
 	return srcHash == dstHash, ht, srcHash, dstHash, nil
 }
 
+// checkPartialContent compares the first n bytes of src and dst.
+//
+// It is used with --checksum when there is no hash to compare. It
+// returns ok false if the comparison couldn't be done, in which case
+// the caller should fall back to the size only.
+func checkPartialContent(ctx context.Context, src fs.ObjectInfo, dst fs.Object, n int64) (differ bool, ok bool) {
+	srcObj, isObject := src.(fs.Object)
+	if !isObject {
+		fs.Debugf(src, "Can't compare first %v as source isn't an object", fs.SizeSuffix(n))
+		return false, false
+	}
+	open := func(o fs.Object) (io.ReadCloser, error) {
+		in, err := o.Open(ctx, &fs.RangeOption{Start: 0, End: n - 1})
+		if err != nil {
+			return nil, err
+		}
+		// limit the read in case the backend ignored the range
+		return readers.NewLimitedReadCloser(in, n), nil
+	}
+	in1, err := open(srcObj)
+	if err != nil {
+		fs.Logf(src, "Failed to open source to compare first %v - comparing size only: %v", fs.SizeSuffix(n), err)
+		return false, false
+	}
+	defer func() {
+		_ = in1.Close()
+	}()
+	in2, err := open(dst)
+	if err != nil {
+		fs.Logf(dst, "Failed to open destination to compare first %v - comparing size only: %v", fs.SizeSuffix(n), err)
+		return false, false
+	}
+	defer func() {
+		_ = in2.Close()
+	}()
+	differ, err = CheckEqualReaders(in1, in2)
+	if err != nil {
+		fs.Logf(src, "Failed to compare first %v - comparing size only: %v", fs.SizeSuffix(n), err)
+		return false, false
+	}
+	return differ, true
+}
+
 // Equal checks to see if the src and dst objects are equal by looking at
 // size, mtime and hash
 //
@@ -138,10 +189,11 @@ var checksumWarning sync.Once
 
 // options for equal function()
 type equalOpt struct {
-	sizeOnly          bool // if set only check size
-	checkSum          bool // if set check checksum+size instead of modtime+size
-	updateModTime     bool // if set update the modtime if hashes identical and checking with modtime+size
-	forceModTimeMatch bool // if set assume modtimes match
+	sizeOnly          bool           // if set only check size
+	checkSum          bool           // if set check checksum+size instead of modtime+size
+	updateModTime     bool           // if set update the modtime if hashes identical and checking with modtime+size
+	forceModTimeMatch bool           // if set assume modtimes match
+	compare           fs.CompareList // if set use these criteria instead of the above
 }
 
 // default set of options for equal()
@@ -152,6 +204,7 @@ func defaultEqualOpt(ctx context.Context) equalOpt {
 		checkSum:          ci.CheckSum,
 		updateModTime:     !ci.NoUpdateModTime,
 		forceModTimeMatch: false,
+		compare:           ci.Compare,
 	}
 }
 
@@ -165,6 +218,9 @@ func logModTimeUpload(dst fs.Object) {
 }
 
 func equal(ctx context.Context, src fs.ObjectInfo, dst fs.Object, opt equalOpt) bool {
+	if opt.compare != nil {
+		return compareEqual(ctx, src, dst, opt)
+	}
 	ci := fs.GetConfig(ctx)
 	if sizeDiffers(ctx, src, dst) {
 		fs.Debugf(src, "Sizes differ (src %d vs dst %d)", src.Size(), dst.Size())
@@ -189,9 +245,24 @@ func equal(ctx context.Context, src fs.ObjectInfo, dst fs.Object, opt equalOpt)
 			common := src.Fs().Hashes().Overlap(dst.Fs().Hashes())
 			if common.Count() == 0 {
 				checksumWarning.Do(func() {
-					fs.Logf(dst.Fs(), "--checksum is in use but the source and destination have no hashes in common; falling back to --size-only")
+					if ci.CheckSumPartialSize > 0 {
+						fs.Logf(dst.Fs(), "--checksum is in use but the source and destination have no hashes in common; falling back to comparing the first %v of each file", ci.CheckSumPartialSize)
+					} else {
+						fs.Logf(dst.Fs(), "--checksum is in use but the source and destination have no hashes in common; falling back to --size-only")
+					}
 				})
 			}
+			if ci.CheckSumPartialSize > 0 {
+				differ, ok := checkPartialContent(ctx, src, dst, int64(ci.CheckSumPartialSize))
+				if ok {
+					if differ {
+						fs.Debugf(src, "First %v of src and dst objects differ", ci.CheckSumPartialSize)
+						return false
+					}
+					fs.Debugf(src, "Size and first %v of src and dst objects identical", ci.CheckSumPartialSize)
+					return true
+				}
+			}
 			fs.Debugf(src, "Size of src and dst objects identical")
 		} else {
 			fs.Debugf(src, "Size and %v of src and dst objects identical", ht)
@@ -230,38 +301,49 @@ func equal(ctx context.Context, src fs.ObjectInfo, dst fs.Object, opt equalOpt)
 
 	// mod time differs but hash is the same to reset mod time if required
 	if opt.updateModTime {
-		if !SkipDestructive(ctx, src, "update modification time") {
-			// Size and hash the same but mtime different
-			// Error if objects are treated as immutable
-			if ci.Immutable {
-				fs.Errorf(dst, "Timestamp mismatch between immutable objects")
-				return false
-			}
-			// Update the mtime of the dst object here
-			err := dst.SetModTime(ctx, srcModTime)
-			if err == fs.ErrorCantSetModTime {
-				logModTimeUpload(dst)
-				fs.Infof(dst, "src and dst identical but can't set mod time without re-uploading")
-				return false
-			} else if err == fs.ErrorCantSetModTimeWithoutDelete {
-				logModTimeUpload(dst)
-				fs.Infof(dst, "src and dst identical but can't set mod time without deleting and re-uploading")
-				// Remove the file if BackupDir isn't set.  If BackupDir is set we would rather have the old file
-				// put in the BackupDir than deleted which is what will happen if we don't delete it.
-				if ci.BackupDir == "" {
-					err = dst.Remove(ctx)
-					if err != nil {
-						fs.Errorf(dst, "failed to delete before re-upload: %v", err)
-					}
-				}
-				return false
-			} else if err != nil {
-				err = fs.CountError(err)
-				fs.Errorf(dst, "Failed to set modification time: %v", err)
-			} else {
-				fs.Infof(src, "Updated modification time in destination")
+		return updateModTime(ctx, src, dst, srcModTime)
+	}
+	return true
+}
+
+// updateModTime sets the modification time of dst to srcModTime after
+// src and dst have been found to be identical apart from it.
+//
+// It returns whether dst can still be considered equal to src.
+func updateModTime(ctx context.Context, src fs.ObjectInfo, dst fs.Object, srcModTime time.Time) bool {
+	ci := fs.GetConfig(ctx)
+	if SkipDestructive(ctx, src, "update modification time") {
+		return true
+	}
+	// Size and hash the same but mtime different
+	// Error if objects are treated as immutable
+	if ci.Immutable {
+		fs.Errorf(dst, "Timestamp mismatch between immutable objects")
+		return false
+	}
+	// Update the mtime of the dst object here
+	err := dst.SetModTime(ctx, srcModTime)
+	if err == fs.ErrorCantSetModTime {
+		logModTimeUpload(dst)
+		fs.Infof(dst, "src and dst identical but can't set mod time without re-uploading")
+		return false
+	} else if err == fs.ErrorCantSetModTimeWithoutDelete {
+		logModTimeUpload(dst)
+		fs.Infof(dst, "src and dst identical but can't set mod time without deleting and re-uploading")
+		// Remove the file if BackupDir isn't set.  If BackupDir is set we would rather have the old file
+		// put in the BackupDir than deleted which is what will happen if we don't delete it.
+		if ci.BackupDir == "" {
+			err = dst.Remove(ctx)
+			if err != nil {
+				fs.Errorf(dst, "failed to delete before re-upload: %v", err)
 			}
 		}
+		return false
+	} else if err != nil {
+		err = fs.CountError(err)
+		fs.Errorf(dst, "Failed to set modification time: %v", err)
+	} else {
+		fs.Infof(src, "Updated modification time in destination")
 	}
 	return true
 }
@@ -282,6 +364,40 @@ func removeFailedCopy(ctx context.Context, dst fs.Object) bool {
 	return true
 }
 
+// Used to quarantine a copy whose hash differs by moving it to its
+// name with --quarantine-suffix added so it can be looked at later
+//
+// It is removed instead if it can't be moved server-side
+func quarantineFailedCopy(ctx context.Context, f fs.Fs, dst fs.Object) {
+	if dst == nil {
+		return
+	}
+	ci := fs.GetConfig(ctx)
+	doMove := f.Features().Move
+	switch {
+	case ci.QuarantineSuffix == "":
+		fs.Errorf(dst, "Can't quarantine failed copy as --quarantine-suffix is empty")
+	case doMove == nil:
+		fs.Errorf(dst, "Can't quarantine failed copy as %v doesn't support server-side move", f)
+	default:
+		remote := dst.Remote() + ci.QuarantineSuffix
+		if existing, err := f.NewObject(ctx, remote); err == nil {
+			err = existing.Remove(ctx)
+			if err != nil {
+				fs.Errorf(existing, "Failed to remove old quarantined copy: %v", err)
+			}
+		}
+		_, err := doMove(ctx, dst, remote)
+		if err == nil {
+			accounting.Stats(ctx).Quarantined(1)
+			fs.Errorf(dst, "Quarantined failed copy to %q", remote)
+			return
+		}
+		fs.Errorf(dst, "Failed to quarantine failed copy: %v", err)
+	}
+	removeFailedCopy(ctx, dst)
+}
+
 // OverrideRemote is a wrapper to override the Remote for an
 // ObjectInfo
 type OverrideRemote struct {
@@ -357,6 +473,36 @@ func CommonHash(ctx context.Context, fa, fb fs.Info) (hash.Type, *fs.HashesOptio
 	return hashType, &fs.HashesOption{Hashes: common}
 }
 
+// metadataWarned records the remotes we have warned can't take the
+// --metadata-set metadata
+var metadataWarned sync.Map
+
+// metadataOptions returns the options to set the --metadata-set
+// metadata on objects uploaded to f.
+//
+// If f can't store metadata it returns a fatal error, or warns once and
+// returns no options if --metadata-set-warn is set.
+func metadataOptions(ctx context.Context, f fs.Fs) ([]fs.OpenOption, error) {
+	ci := fs.GetConfig(ctx)
+	if len(ci.MetadataSet) == 0 {
+		return nil, nil
+	}
+	if !f.Features().WriteMetadata {
+		if !ci.MetadataSetWarn {
+			return nil, fserrors.FatalError(fmt.Errorf("%v doesn't support setting metadata with --metadata-set", f))
+		}
+		if _, warned := metadataWarned.LoadOrStore(fs.ConfigString(f), struct{}{}); !warned {
+			fs.Logf(f, "Doesn't support setting metadata - ignoring --metadata-set")
+		}
+		return nil, nil
+	}
+	options := make([]fs.OpenOption, 0, len(ci.MetadataSet))
+	for _, option := range ci.MetadataSet {
+		options = append(options, option)
+	}
+	return options, nil
+}
+
 // Copy src object to dst or f if nil.  If dst is nil then it uses
 // remote as the name of the new object.
 //
@@ -364,7 +510,11 @@ func CommonHash(ctx context.Context, fa, fb fs.Info) (hash.Type, *fs.HashesOptio
 // be nil.
 func Copy(ctx context.Context, f fs.Fs, dst fs.Object, remote string, src fs.Object) (newDst fs.Object, err error) {
 	ci := fs.GetConfig(ctx)
-	tr := accounting.Stats(ctx).NewTransfer(src)
+	// Don't start the transfer while transfers are paused
+	if err := accounting.TransferPause.Wait(ctx); err != nil {
+		return nil, err
+	}
+	tr := accounting.Stats(ctx).NewTransferDst(src, f)
 	defer func() {
 		tr.Done(ctx, err)
 	}()
@@ -378,30 +528,56 @@ func Copy(ctx context.Context, f fs.Fs, dst fs.Object, remote string, src fs.Obj
 	tries := 0
 	doUpdate := dst != nil
 	hashType, hashOption := CommonHash(ctx, f, src.Fs())
+	metadata, err := metadataOptions(ctx, f)
+	if err != nil {
+		return nil, err
+	}
+	// With --dedupe-on-upload look for an object with the same
+	// content uploaded earlier to server-side copy from
+	var duplicateOf fs.Object
+	if !canServerSide(f, src.Fs()) && len(metadata) == 0 {
+		duplicateOf = findUploaded(ctx, f, src, hashType)
+	}
 
 	var actionTaken string
 	for {
 		// Try server-side copy first - if has optional interface and
 		// is same underlying remote
 		actionTaken = "Copied (server-side copy)"
+		copySrc := src
+		if duplicateOf != nil {
+			actionTaken = "Copied (server-side copy of duplicate " + duplicateOf.Remote() + ")"
+			copySrc = duplicateOf
+		}
 		if ci.MaxTransfer >= 0 {
-			var bytesSoFar int64
-			if ci.CutoffMode == fs.CutoffModeCautious {
-				bytesSoFar = accounting.Stats(ctx).GetBytesWithPending() + src.Size()
-			} else {
-				bytesSoFar = accounting.Stats(ctx).GetBytes()
+			var limitReached bool
+			switch ci.CutoffMode {
+			case fs.CutoffModeCautious:
+				limitReached = accounting.Stats(ctx).GetBytesWithPending()+src.Size() >= int64(ci.MaxTransfer)
+			case fs.CutoffModeSoft:
+				limitReached = accounting.Stats(ctx).GetBytes() >= int64(ci.MaxTransfer)
+				if !limitReached && accounting.Stats(ctx).SoftCutoffReached(src.Size(), int64(ci.MaxTransfer)) {
+					return nil, accounting.ErrorMaxTransferLimitSkipped
+				}
+			default:
+				limitReached = accounting.Stats(ctx).GetBytes() >= int64(ci.MaxTransfer)
 			}
-			if bytesSoFar >= int64(ci.MaxTransfer) {
+			if limitReached {
 				if ci.CutoffMode == fs.CutoffModeHard {
 					return nil, accounting.ErrorMaxTransferLimitReachedFatal
 				}
 				return nil, accounting.ErrorMaxTransferLimitReachedGraceful
 			}
 		}
-		if doCopy := f.Features().Copy; doCopy != nil && (SameConfig(src.Fs(), f) || (SameRemoteType(src.Fs(), f) && f.Features().ServerSideAcrossConfigs)) {
+		// Server-side copies can't set the metadata so upload instead
+		if doCopy := f.Features().Copy; doCopy != nil && (canServerSide(f, src.Fs()) || duplicateOf != nil) && len(metadata) == 0 {
 			in := tr.Account(ctx, nil) // account the transfer
 			in.ServerSideCopyStart()
-			newDst, err = doCopy(ctx, src, remote)
+			newDst, err = doCopy(ctx, copySrc, remote)
+			if err == nil && duplicateOf != nil && !ci.NoUpdateModTime && f.Precision() != fs.ModTimeNotSupported {
+				// The copy has the modification time of the duplicate
+				err = newDst.SetModTime(ctx, src.ModTime(ctx))
+			}
 			if err == nil {
 				dst = newDst
 				in.ServerSideCopyEnd(dst.Size()) // account the bytes for the server-side transfer
@@ -409,6 +585,13 @@ func Copy(ctx context.Context, f fs.Fs, dst fs.Object, remote string, src fs.Obj
 			} else {
 				_ = in.Close()
 			}
+			if err != nil && duplicateOf != nil {
+				// Fall back to uploading the file
+				fs.Debugf(src, "Failed to server-side copy duplicate %v - uploading instead: %v", duplicateOf, err)
+				duplicateOf = nil
+				newDst = dst
+				err = fs.ErrorCantCopy
+			}
 			if err == fs.ErrorCantCopy {
 				tr.Reset(ctx) // skip incomplete accounting - will be overwritten by the manual copy below
 			}
@@ -417,7 +600,17 @@ func Copy(ctx context.Context, f fs.Fs, dst fs.Object, remote string, src fs.Obj
 		}
 		// If can't server-side copy, do it manually
 		if err == fs.ErrorCantCopy {
-			if doMultiThreadCopy(ctx, f, src) {
+			// With --transfer-stall-timeout abort the transfer if no data moves
+			ctx, stall := newStallWatchdog(ctx, src, tr)
+			if doResumableCopy(ctx, f, src) {
+				dst, err = resumableCopy(ctx, f, remote, src, tr)
+				newDst = dst
+				if doUpdate {
+					actionTaken = "Resumable Copied (replaced existing)"
+				} else {
+					actionTaken = "Resumable Copied (new)"
+				}
+			} else if doMultiThreadCopy(ctx, f, src) {
 				// Number of streams proportional to size
 				streams := src.Size() / int64(ci.MultiThreadCutoff)
 				// With maximum
@@ -451,7 +644,7 @@ func Copy(ctx context.Context, f fs.Fs, dst fs.Object, remote string, src fs.Obj
 							actionTaken = "Copied (Rcat, new)"
 						}
 						// NB Rcat closes in0
-						dst, err = Rcat(ctx, f, remote, in0, src.ModTime(ctx))
+						dst, err = Rcat(ctx, f, remote, stall.wrap(in0), src.ModTime(ctx))
 						newDst = dst
 					} else {
 						in := tr.Account(ctx, in0).WithBuffer() // account and buffer the transfer
@@ -464,6 +657,7 @@ func Copy(ctx context.Context, f fs.Fs, dst fs.Object, remote string, src fs.Obj
 						for _, option := range ci.UploadHeaders {
 							options = append(options, option)
 						}
+						options = append(options, metadata...)
 						if doUpdate {
 							actionTaken = "Copied (replaced existing)"
 							err = dst.Update(ctx, in, wrappedSrc, options...)
@@ -479,6 +673,7 @@ func Copy(ctx context.Context, f fs.Fs, dst fs.Object, remote string, src fs.Obj
 					}
 				}
 			}
+			err = stall.stop(ctx, err)
 		}
 		tries++
 		if tries >= maxTries {
@@ -526,15 +721,28 @@ func Copy(ctx context.Context, f fs.Fs, dst fs.Object, remote string, src fs.Obj
 		if !equal {
 			err = fmt.Errorf("corrupted on transfer: %v hash differ %q vs %q", hashType, srcSum, dstSum)
 			fs.Errorf(dst, "%v", err)
-			err = fs.CountError(err)
-			removeFailedCopy(ctx, dst)
+			switch ci.OnHashMismatch {
+			case fs.HashMismatchQuarantine:
+				// carry on with the other files without retrying
+				err = fs.CountError(fserrors.NoRetryError(err))
+				quarantineFailedCopy(ctx, f, dst)
+			case fs.HashMismatchFail:
+				err = fs.CountError(fserrors.FatalError(err))
+				removeFailedCopy(ctx, dst)
+			default:
+				err = fs.CountError(err)
+				removeFailedCopy(ctx, dst)
+			}
 			return newDst, err
 		}
 	}
+	if newDst != nil && duplicateOf == nil {
+		recordUploaded(ctx, f, src, newDst, hashType)
+	}
 	if newDst != nil && src.String() != newDst.String() {
-		fs.Infof(src, "%s to: %s", actionTaken, newDst.String())
+		fs.LogCategoryPrintf(ctx, fs.LogCategoryTransfer, fs.LogLevelInfo, src, "%s to: %s", actionTaken, newDst.String())
 	} else {
-		fs.Infof(src, actionTaken)
+		fs.LogCategoryPrintf(ctx, fs.LogCategoryTransfer, fs.LogLevelInfo, src, actionTaken)
 	}
 	return newDst, err
 }
@@ -589,7 +797,7 @@ func Move(ctx context.Context, fdst fs.Fs, dst fs.Object, remote string, src fs.
 		return newDst, nil
 	}
 	// See if we have Move available
-	if doMove := fdst.Features().Move; doMove != nil && (SameConfig(src.Fs(), fdst) || (SameRemoteType(src.Fs(), fdst) && fdst.Features().ServerSideAcrossConfigs)) {
+	if doMove := fdst.Features().Move; doMove != nil && canServerSide(fdst, src.Fs()) {
 		// Delete destination if it exists and is not the same file as src (could be same file while seemingly different if the remote is case insensitive)
 		if dst != nil && !SameObject(src, dst) {
 			err = DeleteFile(ctx, dst)
@@ -604,9 +812,9 @@ func Move(ctx context.Context, fdst fs.Fs, dst fs.Object, remote string, src fs.
 		switch err {
 		case nil:
 			if newDst != nil && src.String() != newDst.String() {
-				fs.Infof(src, "Moved (server-side) to: %s", newDst.String())
+				fs.LogCategoryPrintf(ctx, fs.LogCategoryTransfer, fs.LogLevelInfo, src, "Moved (server-side) to: %s", newDst.String())
 			} else {
-				fs.Infof(src, "Moved (server-side)")
+				fs.LogCategoryPrintf(ctx, fs.LogCategoryTransfer, fs.LogLevelInfo, src, "Moved (server-side)")
 			}
 			in.ServerSideCopyEnd(newDst.Size()) // account the bytes for the server-side transfer
 			_ = in.Close()
@@ -620,6 +828,10 @@ func Move(ctx context.Context, fdst fs.Fs, dst fs.Object, remote string, src fs.
 			_ = in.Close()
 			return newDst, err
 		}
+	} else if fdst.Features().Move == nil {
+		fs.Debugf(src, "Can't move as %v doesn't support server-side move, switching to copy", fdst)
+	} else {
+		fs.Debugf(src, "Can't move server-side from %v, switching to copy", src.Fs())
 	}
 	// Move not found or didn't work so copy dst <- src
 	newDst, err = Copy(ctx, fdst, dst, remote, src)
@@ -688,7 +900,7 @@ func DeleteFileWithBackupDir(ctx context.Context, dst fs.Object, backupDir fs.Fs
 		fs.Errorf(dst, "Couldn't %s: %v", action, err)
 		err = fs.CountError(err)
 	} else if !skip {
-		fs.Infof(dst, actioned)
+		fs.LogCategoryPrintf(ctx, fs.LogCategoryTransfer, fs.LogLevelInfo, dst, actioned)
 	}
 	return err
 }
@@ -757,6 +969,102 @@ func SameConfig(fdst, fsrc fs.Info) bool {
 	return fdst.Name() == fsrc.Name()
 }
 
+// serverSideDomainKeys are the config keys for each backend type
+// which identify the service a remote is connected to. Remotes of the
+// same type which differ in any of these can't server-side copy
+// between each other even if --server-side-across-configs is set.
+//
+// Only keys which really stop the copy from working belong here. E.g.
+// onedrive can't copy between personal and business drives or
+// between its national clouds, and crypt and chunker can only copy
+// the files of the wrapped remote if they are encrypted or chunked in
+// the same way.
+var serverSideDomainKeys = map[string][]string{
+	"azureblob": {"endpoint"},
+	"chunker":   {"name_format", "start_from", "meta_format", "hash_type"},
+	"crypt":     {"filename_encryption", "directory_name_encryption", "filename_encoding", "no_data_encryption", "password", "password2"},
+	"onedrive":  {"drive_type", "region"},
+	"s3":        {"provider", "endpoint", "region"},
+}
+
+// serverSideDomainWarned records the differing config values which
+// have been warned about so the warning is only logged once.
+var serverSideDomainWarned sync.Map
+
+// canServerSide returns true if a server-side Copy or Move from fsrc
+// to fdst should be attempted.
+//
+// This is always the case for remotes with the same config. For
+// remotes with different configs it needs ServerSideAcrossConfigs set
+// and the two configs must point to the same service. If they don't a
+// warning is logged once and the caller should stream the data
+// instead.
+func canServerSide(fdst, fsrc fs.Info) bool {
+	if SameConfig(fdst, fsrc) {
+		return true
+	}
+	if !SameRemoteType(fdst, fsrc) || !fdst.Features().ServerSideAcrossConfigs {
+		return false
+	}
+	return sameServerSideDomain(fdst, fsrc)
+}
+
+// sameServerSideDomain checks the config of fdst and fsrc to see if
+// they point to the same service, logging a warning if they don't.
+//
+// The config is read each time as it may have been changed.
+func sameServerSideDomain(fdst, fsrc fs.Info) bool {
+	dstConfig := fs.ConfigMap(nil, fdst.Name(), nil)
+	srcConfig := fs.ConfigMap(nil, fsrc.Name(), nil)
+	backend, _ := dstConfig.Get("type")
+	ri, _ := fs.Find(backend)
+	for _, key := range serverSideDomainKeys[backend] {
+		dstValue, _ := dstConfig.Get(key)
+		srcValue, _ := srcConfig.Get(key)
+		isPassword := isPasswordOption(ri, key)
+		if isPassword {
+			// the same password is obscured differently each time
+			dstValue, srcValue = revealServerSideValue(dstValue), revealServerSideValue(srcValue)
+		}
+		if dstValue != srcValue {
+			warned := strings.Join([]string{fsrc.Name(), fdst.Name(), key, srcValue, dstValue}, "\x00")
+			if _, found := serverSideDomainWarned.LoadOrStore(warned, struct{}{}); !found {
+				differs := fmt.Sprintf("(%q vs %q)", srcValue, dstValue)
+				if isPassword {
+					differs = "(values not shown)"
+				}
+				fs.Logf(fdst, "Not using --server-side-across-configs from %q: config %q differs %s so the remotes can't share server-side copies - streaming instead", fsrc.Name(), key, differs)
+			}
+			return false
+		}
+	}
+	return true
+}
+
+// isPasswordOption returns true if key is a password option of the
+// backend ri, which is stored obscured in the config
+func isPasswordOption(ri *fs.RegInfo, key string) bool {
+	if ri == nil {
+		return false
+	}
+	for _, o := range ri.Options {
+		if o.Name == key {
+			return o.IsPassword
+		}
+	}
+	return false
+}
+
+// revealServerSideValue returns the obscured password value revealed
+// for comparing, or value as it is if it can't be revealed
+func revealServerSideValue(value string) string {
+	revealed, err := obscure.Reveal(value)
+	if err != nil {
+		return value
+	}
+	return revealed
+}
+
 // SameConfigArr returns true if any of []fsrcs has same config file entry with fdst
 func SameConfigArr(fdst fs.Info, fsrcs []fs.Fs) bool {
 	for _, fsrc := range fsrcs {
@@ -1023,9 +1331,24 @@ func hashSum(ctx context.Context, ht hash.Type, base64Encoded bool, downloadFlag
 // Updated to perform multiple hashes concurrently
 func HashLister(ctx context.Context, ht hash.Type, outputBase64 bool, downloadFlag bool, f fs.Fs, w io.Writer) error {
 	width := hash.Width(ht, outputBase64)
+	return HashListerFn(ctx, ht, outputBase64, downloadFlag, f, nil, func(o fs.Object, sum string) {
+		syncFprintf(w, "%*s  %s\n", width, sum, o.Remote())
+	})
+}
+
+// HashListerFn hashes the objects in f as HashLister does but calls fn
+// with each object and its hash rather than writing them out.
+//
+// If skip is set the objects it returns true for aren't hashed.
+//
+// skip and fn are called from many go routines at once.
+func HashListerFn(ctx context.Context, ht hash.Type, outputBase64 bool, downloadFlag bool, f fs.Fs, skip func(fs.Object) bool, fn func(o fs.Object, sum string)) error {
 	concurrencyControl := make(chan struct{}, fs.GetConfig(ctx).Transfers)
 	var wg sync.WaitGroup
 	err := ListFn(ctx, f, func(o fs.Object) {
+		if skip != nil && skip(o) {
+			return
+		}
 		wg.Add(1)
 		concurrencyControl <- struct{}{}
 		go func() {
@@ -1038,7 +1361,7 @@ func HashLister(ctx context.Context, ht hash.Type, outputBase64 bool, downloadFl
 				fs.Errorf(o, "%v", fs.CountError(err))
 				return
 			}
-			syncFprintf(w, "%*s  %s\n", width, sum, o.Remote())
+			fn(o, sum)
 		}()
 	})
 	wg.Wait()
@@ -1081,6 +1404,49 @@ func Count(ctx context.Context, f fs.Fs) (objects int64, size int64, err error)
 	return
 }
 
+// CountTiers counts the number of objects and their total size in
+// each storage tier of f, as returned by GetTier, by listing it.
+//
+// The objects which don't have a tier are counted in a TierUsage with
+// an empty Tier. The tiers are sorted by name.
+func CountTiers(ctx context.Context, f fs.Fs) (tiers []fs.TierUsage, err error) {
+	var mu sync.Mutex
+	byTier := map[string]*fs.TierUsage{}
+	err = ListFn(ctx, f, func(o fs.Object) {
+		tier := ""
+		if do, ok := o.(fs.GetTierer); ok {
+			tier = do.GetTier()
+		}
+		objectSize := o.Size()
+		if objectSize < 0 {
+			objectSize = 0
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		usage := byTier[tier]
+		if usage == nil {
+			usage = &fs.TierUsage{
+				Tier:    tier,
+				Used:    fs.NewUsageValue(0),
+				Objects: fs.NewUsageValue(0),
+			}
+			byTier[tier] = usage
+		}
+		*usage.Used += objectSize
+		*usage.Objects++
+	})
+	if err != nil {
+		return nil, err
+	}
+	for _, usage := range byTier {
+		tiers = append(tiers, *usage)
+	}
+	sort.Slice(tiers, func(i, j int) bool {
+		return tiers[i].Tier < tiers[j].Tier
+	})
+	return tiers, nil
+}
+
 // ConfigMaxDepth returns the depth to use for a recursive or non recursive listing.
 func ConfigMaxDepth(ctx context.Context, recursive bool) int {
 	ci := fs.GetConfig(ctx)
@@ -1285,10 +1651,179 @@ func Cat(ctx context.Context, f fs.Fs, w io.Writer, offset, count int64) error {
 	})
 }
 
+// catTailChunk is the size of the first block read back from the end
+// of a file to find its last lines. Each further read doubles it up
+// to catTailMaxChunk.
+const (
+	catTailChunk    = 64 * 1024
+	catTailMaxChunk = 1024 * 1024
+)
+
+// CatLines lists the files in f and outputs either the first head
+// lines or the last tail lines of each one to w. Only one of head and
+// tail should be set.
+//
+// If the size of a file is known its last lines are found by reading
+// blocks backwards from the end of the file so only the end of it is
+// downloaded.
+func CatLines(ctx context.Context, f fs.Fs, w io.Writer, head, tail int64) error {
+	var mu sync.Mutex
+	ci := fs.GetConfig(ctx)
+	var options []fs.OpenOption
+	for _, option := range ci.DownloadHeaders {
+		options = append(options, option)
+	}
+	return ListFn(ctx, f, func(o fs.Object) {
+		var err error
+		tr := accounting.Stats(ctx).NewTransfer(o)
+		defer func() {
+			tr.Done(ctx, err)
+		}()
+		if tail > 0 && o.Size() >= 0 {
+			var out []byte
+			out, err = tailLines(ctx, o, tail, tr, options)
+			if err != nil {
+				err = fs.CountError(err)
+				fs.Errorf(o, "Failed to read: %v", err)
+				return
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			_, err = w.Write(out)
+		} else {
+			var in io.ReadCloser
+			in, err = o.Open(ctx, options...)
+			if err != nil {
+				err = fs.CountError(err)
+				fs.Errorf(o, "Failed to open: %v", err)
+				return
+			}
+			in = tr.Account(ctx, in).WithBuffer() // account and buffer the transfer
+			defer fs.CheckClose(in, &err)
+			// take the lock just before we output stuff, so at the last possible moment
+			mu.Lock()
+			defer mu.Unlock()
+			if head > 0 {
+				err = headLinesStream(in, w, head)
+			} else {
+				err = tailLinesStream(in, w, tail)
+			}
+		}
+		if err != nil {
+			err = fs.CountError(err)
+			fs.Errorf(o, "Failed to send to output: %v", err)
+		}
+	})
+}
+
+// lastLines returns the offset in data of the start of its last n
+// lines. A newline at the very end of data doesn't start a new line.
+//
+// It returns found as false if data doesn't have n lines.
+func lastLines(data []byte, n int64) (start int, found bool) {
+	end := len(data)
+	if end > 0 && data[end-1] == '\n' {
+		end--
+	}
+	for i := end - 1; i >= 0; i-- {
+		if data[i] == '\n' {
+			n--
+			if n == 0 {
+				return i + 1, true
+			}
+		}
+	}
+	return 0, false
+}
+
+// tailLines returns the last n lines of o by reading blocks back from
+// the end of it until enough lines have been found.
+func tailLines(ctx context.Context, o fs.Object, n int64, tr *accounting.Transfer, options []fs.OpenOption) (data []byte, err error) {
+	chunk := int64(catTailChunk)
+	for end := o.Size(); end > 0; {
+		start := end - chunk
+		if start < 0 {
+			start = 0
+		}
+		in, err := o.Open(ctx, append([]fs.OpenOption{&fs.RangeOption{Start: start, End: end - 1}}, options...)...)
+		if err != nil {
+			return nil, err
+		}
+		acc := tr.Account(ctx, in)
+		block, err := ioutil.ReadAll(acc)
+		closeErr := acc.Close()
+		if err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			return nil, err
+		}
+		data = append(block, data...)
+		if i, found := lastLines(data, n); found {
+			return data[i:], nil
+		}
+		end = start
+		if chunk < catTailMaxChunk {
+			chunk *= 2
+		}
+	}
+	return data, nil
+}
+
+// headLinesStream copies the first n lines of in to w
+func headLinesStream(in io.Reader, w io.Writer, n int64) error {
+	br := bufio.NewReader(in)
+	for ; n > 0; n-- {
+		line, err := br.ReadBytes('\n')
+		if len(line) > 0 {
+			if _, writeErr := w.Write(line); writeErr != nil {
+				return writeErr
+			}
+		}
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// tailLinesStream copies the last n lines of in to w by reading all of
+// it and keeping only the last n lines. This is used when the size of
+// the file isn't known.
+func tailLinesStream(in io.Reader, w io.Writer, n int64) error {
+	br := bufio.NewReader(in)
+	var lines [][]byte
+	for {
+		line, err := br.ReadBytes('\n')
+		if len(line) > 0 {
+			lines = append(lines, line)
+			if int64(len(lines)) > n {
+				lines = lines[1:]
+			}
+		}
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return err
+		}
+	}
+	for _, line := range lines {
+		if _, err := w.Write(line); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // Rcat reads data from the Reader until EOF and uploads it to a file on remote
 func Rcat(ctx context.Context, fdst fs.Fs, dstFileName string, in io.ReadCloser, modTime time.Time) (dst fs.Object, err error) {
 	ci := fs.GetConfig(ctx)
-	tr := accounting.Stats(ctx).NewTransferRemoteSize(dstFileName, -1)
+	if err := accounting.TransferPause.Wait(ctx); err != nil {
+		return nil, err
+	}
+	tr := accounting.Stats(ctx).NewTransferRemoteSizeDst(dstFileName, -1, fdst)
 	defer func() {
 		tr.Done(ctx, err)
 	}()
@@ -1358,6 +1893,13 @@ func Rcat(ctx context.Context, fdst fs.Fs, dstFileName string, in io.ReadCloser,
 			}
 		}()
 		fStreamTo = tmpLocalFs
+	} else {
+		// The final Copy sets the metadata when spooling
+		metadata, err := metadataOptions(ctx, fdst)
+		if err != nil {
+			return nil, err
+		}
+		options = append(options, metadata...)
 	}
 
 	if SkipDestructive(ctx, dstFileName, "upload from pipe") {
@@ -1493,7 +2035,7 @@ func compareDest(ctx context.Context, dst, src fs.Object, CompareDest fs.Fs) (No
 	opt := defaultEqualOpt(ctx)
 	opt.updateModTime = false
 	if equal(ctx, src, CompareDestFile, opt) {
-		fs.Debugf(src, "Destination found in --compare-dest, skipping")
+		fs.LogCategoryPrintf(ctx, fs.LogCategorySkip, fs.LogLevelDebug, src, "Destination found in --compare-dest, skipping")
 		return true, nil
 	}
 	return false, nil
@@ -1558,7 +2100,7 @@ func copyDest(ctx context.Context, fdst fs.Fs, dst, src fs.Object, CopyDest, bac
 			fs.Debugf(src, "Destination found in --copy-dest, using server-side copy")
 			return true, nil
 		}
-		fs.Debugf(src, "Unchanged skipping")
+		fs.LogCategoryPrintf(ctx, fs.LogCategorySkip, fs.LogLevelDebug, src, "Unchanged skipping")
 		return true, nil
 	}
 	fs.Debugf(src, "Destination not found in --copy-dest")
@@ -1602,7 +2144,7 @@ func NeedTransfer(ctx context.Context, dst, src fs.Object) bool {
 	}
 	// If we should ignore existing files, don't transfer
 	if ci.IgnoreExisting {
-		fs.Debugf(src, "Destination exists, skipping")
+		fs.LogCategoryPrintf(ctx, fs.LogCategorySkip, fs.LogLevelDebug, src, "Destination exists, skipping")
 		return false
 	}
 	// If we should upload unconditionally
@@ -1625,22 +2167,24 @@ func NeedTransfer(ctx context.Context, dst, src fs.Object) bool {
 		}
 		switch {
 		case dt >= modifyWindow:
-			fs.Debugf(src, "Destination is newer than source, skipping")
+			fs.LogCategoryPrintf(ctx, fs.LogCategorySkip, fs.LogLevelDebug, src, "Destination is newer than source, skipping")
 			return false
 		case dt <= -modifyWindow:
 			// force --checksum on for the check and do update modtimes by default
 			opt := defaultEqualOpt(ctx)
 			opt.forceModTimeMatch = true
+			opt.compare = nil // the modification times have been compared already
 			if equal(ctx, src, dst, opt) {
-				fs.Debugf(src, "Unchanged skipping")
+				fs.LogCategoryPrintf(ctx, fs.LogCategorySkip, fs.LogLevelDebug, src, "Unchanged skipping")
 				return false
 			}
 		default:
 			// Do a size only compare unless --checksum is set
 			opt := defaultEqualOpt(ctx)
 			opt.sizeOnly = !ci.CheckSum
+			opt.compare = nil // the modification times have been compared already
 			if equal(ctx, src, dst, opt) {
-				fs.Debugf(src, "Destination mod time is within %v of source and files identical, skipping", modifyWindow)
+				fs.LogCategoryPrintf(ctx, fs.LogCategorySkip, fs.LogLevelDebug, src, "Destination mod time is within %v of source and files identical, skipping", modifyWindow)
 				return false
 			}
 			fs.Debugf(src, "Destination mod time is within %v of source but files differ, transferring", modifyWindow)
@@ -1648,7 +2192,7 @@ func NeedTransfer(ctx context.Context, dst, src fs.Object) bool {
 	} else {
 		// Check to see if changed or not
 		if Equal(ctx, src, dst) {
-			fs.Debugf(src, "Unchanged skipping")
+			fs.LogCategoryPrintf(ctx, fs.LogCategorySkip, fs.LogLevelDebug, src, "Unchanged skipping")
 			return false
 		}
 	}
@@ -1659,16 +2203,20 @@ func NeedTransfer(ctx context.Context, dst, src fs.Object) bool {
 // Pass in size >=0 if known, <0 if not known
 func RcatSize(ctx context.Context, fdst fs.Fs, dstFileName string, in io.ReadCloser, size int64, modTime time.Time) (dst fs.Object, err error) {
 	var obj fs.Object
+	if err := accounting.TransferPause.Wait(ctx); err != nil {
+		return nil, err
+	}
 
 	if size >= 0 {
 		var err error
 		// Size known use Put
-		tr := accounting.Stats(ctx).NewTransferRemoteSize(dstFileName, size)
+		tr := accounting.Stats(ctx).NewTransferRemoteSizeDst(dstFileName, size, fdst)
 		defer func() {
 			tr.Done(ctx, err)
 		}()
-		body := ioutil.NopCloser(in) // we let the server close the body
-		in := tr.Account(ctx, body)  // account the transfer (no buffering)
+		sizeIn := &sizeCheckReader{in: in, size: size} // fail if in isn't size bytes long
+		body := ioutil.NopCloser(sizeIn)               // we let the server close the body
+		in := tr.Account(ctx, body)                    // account the transfer (no buffering)
 
 		if SkipDestructive(ctx, dstFileName, "upload from pipe") {
 			// prevents "broken pipe" errors
@@ -1683,6 +2231,16 @@ func RcatSize(ctx context.Context, fdst fs.Fs, dstFileName string, in io.ReadClo
 
 			return nil, err
 		}
+		// The backend may have stopped reading at size so check
+		// there wasn't any more input
+		if err = sizeIn.checkEnd(); err != io.EOF {
+			fs.Errorf(dstFileName, "Removing uploaded file: %v", err)
+			if removeErr := obj.Remove(ctx); removeErr != nil {
+				fs.Errorf(dstFileName, "Failed to remove uploaded file: %v", removeErr)
+			}
+			return nil, err
+		}
+		err = nil
 	} else {
 		// Size unknown use Rcat
 		obj, err = Rcat(ctx, fdst, dstFileName, in, modTime)
@@ -1696,6 +2254,42 @@ func RcatSize(ctx context.Context, fdst fs.Fs, dstFileName string, in io.ReadClo
 	return obj, nil
 }
 
+// sizeCheckReader is an io.Reader which returns an error if the input
+// isn't exactly size bytes long
+type sizeCheckReader struct {
+	in   io.Reader
+	size int64
+	read int64
+}
+
+// Read bytes from the input, returning an error if it ends early or
+// continues after size bytes
+func (r *sizeCheckReader) Read(p []byte) (n int, err error) {
+	if r.read >= r.size {
+		return 0, r.checkEnd()
+	}
+	if remaining := r.size - r.read; int64(len(p)) > remaining {
+		p = p[:remaining]
+	}
+	n, err = r.in.Read(p)
+	r.read += int64(n)
+	if err == io.EOF && r.read < r.size {
+		err = fmt.Errorf("input ended after %d bytes but expected %d bytes: %w", r.read, r.size, io.ErrUnexpectedEOF)
+	}
+	return n, err
+}
+
+// checkEnd returns io.EOF if the input has ended or an error if it
+// hasn't
+func (r *sizeCheckReader) checkEnd() error {
+	var buf [1]byte
+	n, err := io.ReadFull(r.in, buf[:])
+	if n > 0 {
+		return fmt.Errorf("input is longer than the expected %d bytes", r.size)
+	}
+	return err
+}
+
 // copyURLFunc is called from CopyURLFn
 type copyURLFunc func(ctx context.Context, dstFileName string, in io.ReadCloser, size int64, modTime time.Time) (err error)
 
@@ -1740,12 +2334,202 @@ func CopyURL(ctx context.Context, fdst fs.Fs, dstFileName string, url string, ds
 	return dst, err
 }
 
+// copyURLRetryCodes are the HTTP status codes which are retried when
+// downloading a url
+var copyURLRetryCodes = []int{
+	429, // Too Many Requests
+	500, // Internal Server Error
+	502, // Bad Gateway
+	503, // Service Unavailable
+	504, // Gateway Timeout
+}
+
+// urlReader reads the body of a url, resuming from where it got to
+// with a Range request if the download fails part way through
+type urlReader struct {
+	ctx     context.Context
+	client  *http.Client
+	url     string
+	body    io.ReadCloser // body of the response or nil if not open
+	size    int64         // size of the body or -1 if not known
+	offset  int64         // number of bytes read so far
+	retries int           // number of retries left
+	// ETag or Last-Modified of the body sent with If-Range when
+	// resuming so the rest isn't read from a different file, or ""
+	// if the server didn't send one
+	validator string
+}
+
+// newURLReader opens url for reading, retrying up to
+// --low-level-retries times on errors which can be retried
+func newURLReader(ctx context.Context, url string) (*urlReader, error) {
+	r := &urlReader{
+		ctx:     ctx,
+		client:  fshttp.NewClient(ctx),
+		url:     url,
+		size:    -1,
+		retries: fs.GetConfig(ctx).LowLevelRetries,
+	}
+	err := r.open()
+	if err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// retry returns true if err should be retried, using up a retry if so
+func (r *urlReader) retry(err error) bool {
+	if r.retries <= 0 || r.ctx.Err() != nil || !(fserrors.ShouldRetry(err) || fserrors.IsRetryError(err)) {
+		return false
+	}
+	r.retries--
+	fs.Debugf(r.url, "Retrying download from offset %d: %v", r.offset, err)
+	return true
+}
+
+// open requests the url from the offset, retrying if necessary
+func (r *urlReader) open() error {
+	for {
+		err := r.get()
+		if err == nil || !r.retry(err) {
+			return err
+		}
+	}
+}
+
+// get requests the url from the offset once
+func (r *urlReader) get() error {
+	req, err := http.NewRequestWithContext(r.ctx, "GET", r.url, nil)
+	if err != nil {
+		return err
+	}
+	if r.offset > 0 {
+		if r.validator == "" {
+			return errors.New("CopyURL failed: can't resume download as the server didn't send an ETag or Last-Modified to check it is the same file")
+		}
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", r.offset))
+		req.Header.Set("If-Range", r.validator)
+	}
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return err
+	}
+	switch {
+	case r.offset == 0 && resp.StatusCode >= 200 && resp.StatusCode < 300:
+		r.size = resp.ContentLength
+		r.validator = resumeValidator(resp.Header)
+	case r.offset > 0 && resp.StatusCode == http.StatusPartialContent:
+		err = checkContentRange(resp.Header.Get("Content-Range"), r.offset, r.size)
+		if err != nil {
+			_ = resp.Body.Close()
+			return fmt.Errorf("CopyURL failed: can't resume download: %w", err)
+		}
+	default:
+		_ = resp.Body.Close()
+		err = fmt.Errorf("CopyURL failed: %s", resp.Status)
+		if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+			return fmt.Errorf("CopyURL failed: can't resume download as the file has changed or the server doesn't support Range requests: %s", resp.Status)
+		}
+		if fserrors.ShouldRetryHTTP(resp, copyURLRetryCodes) {
+			return fserrors.RetryError(err)
+		}
+		return err
+	}
+	r.body = resp.Body
+	return nil
+}
+
+// resumeValidator returns the value for the If-Range header when
+// resuming the download of the response with header h, the ETag if it
+// is a strong one, otherwise the Last-Modified, or "" if neither can
+// be used
+func resumeValidator(h http.Header) string {
+	etag := h.Get("ETag")
+	if etag != "" && !strings.HasPrefix(etag, "W/") {
+		return etag
+	}
+	return h.Get("Last-Modified")
+}
+
+// checkContentRange checks the Content-Range of a resumed download
+// starts at offset and, if size isn't -1, that the total size is size
+func checkContentRange(contentRange string, offset, size int64) error {
+	var start, end, total int64
+	n, _ := fmt.Sscanf(contentRange, "bytes %d-%d/%d", &start, &end, &total)
+	if n < 2 {
+		return fmt.Errorf("invalid Content-Range %q", contentRange)
+	}
+	if start != offset {
+		return fmt.Errorf("Content-Range %q doesn't start at offset %d", contentRange, offset)
+	}
+	if n == 3 && size >= 0 && total != size {
+		return fmt.Errorf("Content-Range %q doesn't have size %d", contentRange, size)
+	}
+	return nil
+}
+
+// Read reads from the body, resuming the download if it fails
+func (r *urlReader) Read(p []byte) (n int, err error) {
+	for {
+		if r.body == nil {
+			err = r.open()
+			if err != nil {
+				return 0, err
+			}
+		}
+		n, err = r.body.Read(p)
+		r.offset += int64(n)
+		if err == nil || err == io.EOF {
+			return n, err
+		}
+		_ = r.body.Close()
+		r.body = nil
+		if !r.retry(err) {
+			return n, err
+		}
+		if n > 0 {
+			return n, nil
+		}
+	}
+}
+
+// Close closes the body if it is open
+func (r *urlReader) Close() error {
+	if r.body == nil {
+		return nil
+	}
+	err := r.body.Close()
+	r.body = nil
+	return err
+}
+
 // CopyURLToWriter copies the data from the url to the io.Writer supplied
+//
+// The transfer is accounted for in the stats and limited by --bwlimit.
+// If the download fails part way through it is resumed from where it
+// got to with a Range request, up to --low-level-retries times, if the
+// server supports them. The download fails instead if the server
+// didn't send an ETag or Last-Modified to send with If-Range, or if
+// the rest doesn't come from the same file at the right offset.
+//
+// Errors after some data has been written to out are marked as not to
+// be retried as retrying would write the data to out again.
 func CopyURLToWriter(ctx context.Context, url string, out io.Writer) (err error) {
-	return copyURLFn(ctx, "", url, false, func(ctx context.Context, dstFileName string, in io.ReadCloser, size int64, modTime time.Time) (err error) {
-		_, err = io.Copy(out, in)
+	r, err := newURLReader(ctx, url)
+	if err != nil {
 		return err
-	})
+	}
+	tr := accounting.Stats(ctx).NewTransferRemoteSize(url, r.size)
+	defer func() {
+		tr.Done(ctx, err)
+	}()
+	in := tr.Account(ctx, r)
+	defer fs.CheckClose(in, &err)
+	_, err = io.Copy(out, in)
+	if err != nil && r.offset > 0 {
+		err = fserrors.NoRetryError(err)
+	}
+	return err
 }
 
 // BackupDir returns the correctly configured --backup-dir
@@ -1843,7 +2627,7 @@ func moveOrCopyFile(ctx context.Context, fdst fs.Fs, fsrc fs.Fs, dstFileName str
 			}
 			return fmt.Errorf("error while attempting to move file to a temporary location: %w", err)
 		}
-		tr := accounting.Stats(ctx).NewTransfer(srcObj)
+		tr := accounting.Stats(ctx).NewTransferDst(srcObj, fdst)
 		defer func() {
 			tr.Done(ctx, err)
 		}()
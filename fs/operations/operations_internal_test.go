@@ -5,11 +5,16 @@ package operations
 import (
 	"context"
 	"fmt"
+	"io"
 	"testing"
 	"time"
 
 	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fs/config/obscure"
+	"github.com/rclone/rclone/fs/hash"
 	"github.com/rclone/rclone/fs/object"
+	"github.com/rclone/rclone/fstest/mockfs"
+	"github.com/rclone/rclone/fstest/mockobject"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -41,3 +46,218 @@ func TestSizeDiffers(t *testing.T) {
 		assert.Equal(t, test.want, got, fmt.Sprintf("ignoreSize=%v, srcSize=%v, dstSize=%v", test.ignoreSize, test.srcSize, test.dstSize))
 	}
 }
+
+func TestCanServerSide(t *testing.T) {
+	ctx := context.Background()
+	config := map[string]map[string]string{
+		"eu1":      {"type": "onedrive", "region": "global", "endpoint": "eu"},
+		"eu2":      {"type": "onedrive", "region": "global", "endpoint": "eu2"},
+		"us":       {"type": "onedrive", "region": "us"},
+		"business": {"type": "onedrive", "region": "global", "drive_type": "business"},
+		"drive1":   {"type": "drive", "region": "eu"},
+		"drive2":   {"type": "drive", "region": "us"},
+		"s3eu1":    {"type": "s3", "provider": "AWS", "region": "eu-west-1", "acl": "private"},
+		"s3eu2":    {"type": "s3", "provider": "AWS", "region": "eu-west-1"},
+		"s3us":     {"type": "s3", "provider": "AWS", "region": "us-east-1"},
+		"crypt1":   {"type": "mockcrypt", "password": obscure.MustObscure("potato")},
+		"crypt2":   {"type": "mockcrypt", "password": obscure.MustObscure("potato")},
+		"crypt3":   {"type": "mockcrypt", "password": obscure.MustObscure("carrot")},
+	}
+	fs.Register(&fs.RegInfo{
+		Name:    "mockcrypt",
+		Options: []fs.Option{{Name: "password", IsPassword: true}},
+	})
+	serverSideDomainKeys["mockcrypt"] = []string{"password"}
+	defer delete(serverSideDomainKeys, "mockcrypt")
+	oldConfigFileGet := fs.ConfigFileGet
+	fs.ConfigFileGet = func(section, key string) (string, bool) {
+		value, ok := config[section][key]
+		return value, ok
+	}
+	defer func() {
+		fs.ConfigFileGet = oldConfigFileGet
+	}()
+
+	newFs := func(name string, acrossConfigs bool) fs.Fs {
+		f := mockfs.NewFs(ctx, name, "")
+		f.Features().ServerSideAcrossConfigs = acrossConfigs
+		return f
+	}
+
+	for _, test := range []struct {
+		dst           string
+		src           string
+		acrossConfigs bool
+		want          bool
+	}{
+		{"eu1", "eu1", false, true},
+		{"eu1", "eu2", false, false},
+		{"eu1", "eu2", true, true},
+		{"eu1", "us", true, false},
+		{"us", "eu1", true, false},
+		{"eu1", "business", true, false},
+		{"drive1", "drive2", true, true},
+		{"s3eu1", "s3eu2", true, true},
+		{"s3eu1", "s3us", true, false},
+		{"crypt1", "crypt2", true, true},
+		{"crypt1", "crypt3", true, false},
+	} {
+		what := fmt.Sprintf("dst=%q, src=%q, acrossConfigs=%v", test.dst, test.src, test.acrossConfigs)
+		got := canServerSide(newFs(test.dst, test.acrossConfigs), newFs(test.src, false))
+		assert.Equal(t, test.want, got, what)
+		// check the value is the same when already warned about
+		got = canServerSide(newFs(test.dst, test.acrossConfigs), newFs(test.src, false))
+		assert.Equal(t, test.want, got, what+" (again)")
+	}
+
+	// changes to the config are noticed
+	config["us"]["region"] = "global"
+	assert.True(t, canServerSide(newFs("eu1", true), newFs("us", false)))
+}
+
+func TestEqualCheckSumPartialSize(t *testing.T) {
+	ctx := context.Background()
+	ctx, ci := fs.AddConfig(ctx)
+	srcFs := mockfs.NewFs(ctx, "src", "")
+	dstFs := mockfs.NewFs(ctx, "dst", "")
+	src := mockobject.New("file").WithContent([]byte("same start, src end"), mockobject.SeekModeNone)
+	src.SetFs(srcFs)
+	dst := mockobject.New("file").WithContent([]byte("same start, dst end"), mockobject.SeekModeNone)
+	dst.SetFs(dstFs)
+	opt := equalOpt{checkSum: true}
+	for _, test := range []struct {
+		partialSize fs.SizeSuffix
+		want        bool
+	}{
+		{0, true},    // no hashes so size only
+		{10, true},   // only the identical start is compared
+		{100, false}, // the whole file is compared
+	} {
+		ci.CheckSumPartialSize = test.partialSize
+		assert.Equal(t, test.want, equal(ctx, src, dst, opt), fmt.Sprintf("partialSize=%v", test.partialSize))
+	}
+}
+
+// storedHashObject is a mock object with stored hashes which fails
+// the test if it is opened
+type storedHashObject struct {
+	*mockobject.ContentMockObject
+	t      *testing.T
+	hashes map[hash.Type]string
+}
+
+// Hash returns the stored hash or "" if there isn't one
+func (o *storedHashObject) Hash(ctx context.Context, ht hash.Type) (string, error) {
+	return o.hashes[ht], nil
+}
+
+// Open fails the test
+func (o *storedHashObject) Open(ctx context.Context, options ...fs.OpenOption) (io.ReadCloser, error) {
+	o.t.Errorf("unexpected Open of %v", o)
+	return o.ContentMockObject.Open(ctx, options...)
+}
+
+func TestEqualCheckSumStoredHashes(t *testing.T) {
+	ctx := context.Background()
+	ctx, ci := fs.AddConfig(ctx)
+	ci.CheckSumPartialSize = 1024
+	srcFs := mockfs.NewFs(ctx, "src", "")
+	srcFs.SetHashes(hash.NewHashSet(hash.MD5, hash.SHA1))
+	dstFs := mockfs.NewFs(ctx, "dst", "")
+	dstFs.SetHashes(hash.NewHashSet(hash.MD5, hash.SHA1))
+	newObject := func(f fs.Fs, content string, hashes map[hash.Type]string) fs.Object {
+		o := mockobject.New("file").WithContent([]byte(content), mockobject.SeekModeNone)
+		o.SetFs(f)
+		return &storedHashObject{ContentMockObject: o, t: t, hashes: hashes}
+	}
+	opt := equalOpt{checkSum: true}
+	for _, test := range []struct {
+		name      string
+		srcHashes map[hash.Type]string
+		dstHashes map[hash.Type]string
+		want      bool
+	}{
+		{"same MD5", map[hash.Type]string{hash.MD5: "a"}, map[hash.Type]string{hash.MD5: "a"}, true},
+		{"different MD5", map[hash.Type]string{hash.MD5: "a"}, map[hash.Type]string{hash.MD5: "b"}, false},
+		// the first common hash is missing from dst so SHA1 must be used
+		{"same SHA1", map[hash.Type]string{hash.MD5: "a", hash.SHA1: "c"}, map[hash.Type]string{hash.SHA1: "c"}, true},
+		{"different SHA1", map[hash.Type]string{hash.MD5: "a", hash.SHA1: "c"}, map[hash.Type]string{hash.SHA1: "d"}, false},
+	} {
+		// the contents differ so reading them would give the wrong answer
+		src := newObject(srcFs, "src contents", test.srcHashes)
+		dst := newObject(dstFs, "dst contents", test.dstHashes)
+		assert.Equal(t, test.want, equal(ctx, src, dst, opt), test.name)
+	}
+}
+
+// modTimeObject is a storedHashObject with a modification time which
+// can be set
+type modTimeObject struct {
+	*storedHashObject
+	modTime time.Time
+}
+
+// ModTime returns the modification time
+func (o *modTimeObject) ModTime(ctx context.Context) time.Time {
+	return o.modTime
+}
+
+// SetModTime sets the modification time
+func (o *modTimeObject) SetModTime(ctx context.Context, modTime time.Time) error {
+	o.modTime = modTime
+	return nil
+}
+
+func TestEqualCompare(t *testing.T) {
+	ctx := context.Background()
+	ctx, ci := fs.AddConfig(ctx)
+	srcFs := mockfs.NewFs(ctx, "src", "")
+	srcFs.SetHashes(hash.NewHashSet(hash.MD5))
+	dstFs := mockfs.NewFs(ctx, "dst", "")
+	dstFs.SetHashes(hash.NewHashSet(hash.MD5))
+	newObject := func(f fs.Fs, content string, modTime time.Time, md5 string) *modTimeObject {
+		o := mockobject.New("file").WithContent([]byte(content), mockobject.SeekModeNone)
+		o.SetFs(f)
+		return &modTimeObject{
+			storedHashObject: &storedHashObject{ContentMockObject: o, t: t, hashes: map[hash.Type]string{hash.MD5: md5}},
+			modTime:          modTime,
+		}
+	}
+	t0 := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	t1 := t0.Add(10 * time.Second)
+	for _, test := range []struct {
+		name           string
+		compare        string
+		checkSum       bool
+		noUpdate       bool
+		dstContent     string
+		dstModTime     time.Time
+		dstMD5         string
+		want           bool
+		wantDstModTime time.Time
+	}{
+		{"size only", "size", false, false, "CONTENTS", t1, "b", true, t1},
+		{"size differs", "size,modtime", false, false, "content", t0, "a", false, t0},
+		{"modtime same so no hash", "size,modtime,hash", false, false, "CONTENTS", t0, "b", true, t0},
+		{"modtime differs hash same", "size,modtime,hash", false, false, "CONTENTS", t1, "a", true, t0},
+		{"modtime differs hash differs", "size,modtime,hash", false, false, "CONTENTS", t1, "b", false, t1},
+		{"modtime differs last", "size,modtime", false, false, "CONTENTS", t1, "a", false, t1},
+		{"modtime tolerance", "size,modtime:20s", false, false, "CONTENTS", t1, "b", true, t1},
+		{"hash first", "hash,modtime", false, false, "CONTENTS", t0, "b", false, t0},
+		{"no common hash", "size,modtime,hash", false, false, "CONTENTS", t1, "", false, t1},
+		{"no common hash last", "size,hash", false, false, "CONTENTS", t1, "", true, t1},
+		{"overrides checksum", "size,modtime", true, false, "CONTENTS", t0, "b", true, t0},
+		{"no update modtime", "size,modtime,hash", false, true, "CONTENTS", t1, "a", true, t1},
+		{"ignore size", "modtime", false, false, "content", t0, "b", true, t0},
+	} {
+		if err := ci.Compare.Set(test.compare); err != nil {
+			t.Fatal(err)
+		}
+		ci.CheckSum = test.checkSum
+		ci.NoUpdateModTime = test.noUpdate
+		src := newObject(srcFs, "contents", t0, "a")
+		dst := newObject(dstFs, test.dstContent, test.dstModTime, test.dstMD5)
+		assert.Equal(t, test.want, Equal(ctx, src, dst), test.name)
+		assert.Equal(t, test.wantDstModTime, dst.modTime, test.name)
+	}
+}
@@ -45,6 +45,8 @@ import (
 	"github.com/rclone/rclone/fs/operations"
 	"github.com/rclone/rclone/fstest"
 	"github.com/rclone/rclone/fstest/fstests"
+	"github.com/rclone/rclone/fstest/mockfs"
+	"github.com/rclone/rclone/fstest/mockobject"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -407,6 +409,41 @@ func TestCount(t *testing.T) {
 	assert.Equal(t, int64(61), size)
 }
 
+// tierObject is an object in a storage tier
+type tierObject struct {
+	*mockobject.ContentMockObject
+	tier string
+}
+
+func (o tierObject) GetTier() string {
+	return o.tier
+}
+
+func TestCountTiers(t *testing.T) {
+	ctx := context.Background()
+	f := mockfs.NewFs(ctx, "potato", "")
+	add := func(remote, content, tier string) {
+		o := mockobject.New(remote).WithContent([]byte(content), mockobject.SeekModeNone)
+		if tier == "" {
+			f.AddObject(o)
+		} else {
+			f.AddObject(tierObject{ContentMockObject: o, tier: tier})
+		}
+	}
+	add("a", "12345", "STANDARD")
+	add("b", "123", "GLACIER")
+	add("c", "1234567", "STANDARD")
+	add("d", "1", "")
+
+	tiers, err := operations.CountTiers(ctx, f)
+	require.NoError(t, err)
+	assert.Equal(t, []fs.TierUsage{
+		{Tier: "", Used: fs.NewUsageValue(1), Objects: fs.NewUsageValue(1)},
+		{Tier: "GLACIER", Used: fs.NewUsageValue(3), Objects: fs.NewUsageValue(1)},
+		{Tier: "STANDARD", Used: fs.NewUsageValue(12), Objects: fs.NewUsageValue(2)},
+	}, tiers)
+}
+
 func TestDelete(t *testing.T) {
 	ctx := context.Background()
 	fi, err := filter.NewFilter(nil)
@@ -483,6 +520,39 @@ func TestCat(t *testing.T) {
 	}
 }
 
+func TestCatLines(t *testing.T) {
+	ctx := context.Background()
+	r := fstest.NewRun(t)
+	defer r.Finalise()
+	file1 := r.WriteBoth(ctx, "file1", "one\ntwo\nthree\n", t1)
+	file2 := r.WriteBoth(ctx, "file2", "four\nfive\nsix", t2)
+
+	r.CheckRemoteItems(t, file1, file2)
+
+	for _, test := range []struct {
+		head int64
+		tail int64
+		a    string
+		b    string
+	}{
+		{1, 0, "one\n", "four\n"},
+		{2, 0, "one\ntwo\n", "four\nfive\n"},
+		{10, 0, "one\ntwo\nthree\n", "four\nfive\nsix"},
+		{0, 1, "three\n", "six"},
+		{0, 2, "two\nthree\n", "five\nsix"},
+		{0, 10, "one\ntwo\nthree\n", "four\nfive\nsix"},
+	} {
+		var buf bytes.Buffer
+		err := operations.CatLines(ctx, r.Fremote, &buf, test.head, test.tail)
+		require.NoError(t, err)
+		res := buf.String()
+
+		if res != test.a+test.b && res != test.b+test.a {
+			t.Errorf("Incorrect output from CatLines(%d,%d): %q", test.head, test.tail, res)
+		}
+	}
+}
+
 func TestPurge(t *testing.T) {
 	ctx := context.Background()
 	r := fstest.NewRunIndividual(t) // make new container (azureblob has delayed mkdir after rmdir)
@@ -813,6 +883,95 @@ func TestCopyURLToWriter(t *testing.T) {
 	assert.Equal(t, 0, len(buf.String()))
 }
 
+func TestCopyURLToWriterResume(t *testing.T) {
+	ctx := context.Background()
+	contents := strings.Repeat("0123456789", 100)
+
+	// the first request fails part way through, then an error is
+	// returned, then the rest is sent if ranges are supported
+	var requests, ifRanges []string
+	ranges := true
+	etag := `"v1"`
+	changed := false
+	contentRange := ""
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.Header.Get("Range"))
+		ifRanges = append(ifRanges, r.Header.Get("If-Range"))
+		if changed && len(requests) > 1 {
+			w.Header().Set("ETag", `"v2"`)
+		} else if etag != "" {
+			w.Header().Set("ETag", etag)
+		}
+		switch {
+		case len(requests) == 1:
+			w.Header().Set("Content-Length", fmt.Sprint(len(contents)))
+			_, _ = w.Write([]byte(contents[:300]))
+		case len(requests) == 2:
+			http.Error(w, "try again", http.StatusServiceUnavailable)
+		case contentRange != "":
+			w.Header().Set("Content-Range", contentRange)
+			w.WriteHeader(http.StatusPartialContent)
+			_, _ = w.Write([]byte(contents[200:]))
+		case ranges:
+			http.ServeContent(w, r, "", time.Time{}, strings.NewReader(contents))
+		default:
+			_, _ = w.Write([]byte(contents))
+		}
+	})
+	ts := httptest.NewServer(handler)
+	defer ts.Close()
+
+	var buf bytes.Buffer
+	err := operations.CopyURLToWriter(ctx, ts.URL, &buf)
+	require.NoError(t, err)
+	assert.Equal(t, contents, buf.String())
+	assert.Equal(t, []string{"", "bytes=300-", "bytes=300-"}, requests)
+	assert.Equal(t, []string{"", `"v1"`, `"v1"`}, ifRanges)
+
+	// resuming fails if the server doesn't support ranges
+	requests = nil
+	ranges = false
+	buf.Reset()
+	err = operations.CopyURLToWriter(ctx, ts.URL, &buf)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "doesn't support Range requests")
+	assert.True(t, fserrors.IsNoRetryError(err))
+	assert.Equal(t, contents[:300], buf.String())
+
+	// resuming fails if the file has changed as the server sends all
+	// of it when the If-Range doesn't match
+	requests = nil
+	ranges = true
+	buf.Reset()
+	changed = true
+	err = operations.CopyURLToWriter(ctx, ts.URL, &buf)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "the file has changed")
+	assert.Equal(t, contents[:300], buf.String())
+
+	// resuming fails if the rest doesn't start at the offset
+	requests = nil
+	buf.Reset()
+	changed = false
+	contentRange = fmt.Sprintf("bytes 200-%d/%d", len(contents)-1, len(contents))
+	err = operations.CopyURLToWriter(ctx, ts.URL, &buf)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "doesn't start at offset 300")
+	assert.True(t, fserrors.IsNoRetryError(err))
+	assert.Equal(t, contents[:300], buf.String())
+
+	// resuming fails without an ETag or Last-Modified to check
+	requests = nil
+	buf.Reset()
+	etag = ""
+	contentRange = ""
+	err = operations.CopyURLToWriter(ctx, ts.URL, &buf)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "didn't send an ETag or Last-Modified")
+	assert.Equal(t, []string{""}, requests)
+	assert.Equal(t, contents[:300], buf.String())
+}
+
 func TestMoveFile(t *testing.T) {
 	ctx := context.Background()
 	r := fstest.NewRun(t)
@@ -843,6 +1002,33 @@ func TestMoveFile(t *testing.T) {
 	r.CheckRemoteItems(t, file2)
 }
 
+// Test that MoveFile within a remote uses the native Move rather
+// than copying the data
+func TestMoveFileServerSide(t *testing.T) {
+	ctx := context.Background()
+	r := fstest.NewRun(t)
+	defer r.Finalise()
+	if r.Fremote.Features().Move == nil {
+		t.Skip("Can't test without server-side move")
+	}
+
+	file1 := r.WriteObject(ctx, "file1", "file1 contents", t1)
+	r.CheckRemoteItems(t, file1)
+
+	accounting.Stats(ctx).ResetCounters()
+	defer accounting.Stats(ctx).ResetCounters()
+
+	file2 := file1
+	file2.Path = "sub/file2"
+	err := operations.MoveFile(ctx, r.Fremote, r.Fremote, file2.Path, file1.Path)
+	require.NoError(t, err)
+	r.CheckRemoteItems(t, file2)
+
+	// A copy would have counted a transfer
+	assert.Equal(t, int64(0), accounting.Stats(ctx).GetTransfers())
+	assert.Equal(t, int64(1), accounting.Stats(ctx).Renames(0))
+}
+
 func TestMoveFileWithIgnoreExisting(t *testing.T) {
 	ctx := context.Background()
 	ctx, ci := fs.AddConfig(ctx)
@@ -956,6 +1142,111 @@ func TestCopyFile(t *testing.T) {
 	r.CheckRemoteItems(t, file2)
 }
 
+func TestCopyFilePaused(t *testing.T) {
+	ctx := context.Background()
+	r := fstest.NewRun(t)
+	defer r.Finalise()
+
+	file1 := r.WriteFile("file1", "file1 contents", t1)
+	r.CheckLocalItems(t, file1)
+
+	// The copy shouldn't start while transfers are paused
+	accounting.TransferPause.Pause(false)
+	defer accounting.TransferPause.Resume()
+	timeoutCtx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	err := operations.CopyFile(timeoutCtx, r.Fremote, r.Flocal, file1.Path, file1.Path)
+	assert.Equal(t, context.DeadlineExceeded, err)
+	r.CheckRemoteItems(t)
+
+	accounting.TransferPause.Resume()
+	err = operations.CopyFile(ctx, r.Fremote, r.Flocal, file1.Path, file1.Path)
+	require.NoError(t, err)
+	r.CheckRemoteItems(t, file1)
+}
+
+func TestCopyFileMetadataSetUnsupported(t *testing.T) {
+	ctx := context.Background()
+	ctx, ci := fs.AddConfig(ctx)
+	r := fstest.NewRun(t)
+	defer r.Finalise()
+	if r.Fremote.Features().WriteMetadata {
+		t.Skip("Skipping test as remote supports metadata")
+	}
+	ci.MetadataSet = []*fs.MetadataOption{{Key: "backup-run", Value: "1"}}
+
+	file1 := r.WriteFile("file1", "file1 contents", t1)
+	r.CheckLocalItems(t, file1)
+
+	err := operations.CopyFile(ctx, r.Fremote, r.Flocal, file1.Path, file1.Path)
+	require.Error(t, err)
+	assert.True(t, fserrors.IsFatalError(err))
+	assert.Contains(t, err.Error(), "doesn't support setting metadata")
+	r.CheckRemoteItems(t)
+
+	ci.MetadataSetWarn = true
+	err = operations.CopyFile(ctx, r.Fremote, r.Flocal, file1.Path, file1.Path)
+	require.NoError(t, err)
+	r.CheckRemoteItems(t, file1)
+}
+
+// openFailObject is an fs.Object which can't be opened
+type openFailObject struct {
+	fs.Object
+}
+
+func (o openFailObject) Open(ctx context.Context, options ...fs.OpenOption) (io.ReadCloser, error) {
+	return nil, errors.New("open not allowed")
+}
+
+func TestCopyDedupeOnUpload(t *testing.T) {
+	ctx := context.Background()
+	ctx, ci := fs.AddConfig(ctx)
+	r := fstest.NewRun(t)
+	defer r.Finalise()
+	ci.DedupeOnUpload = true
+
+	// The memory backend can server-side copy
+	fdst, err := fs.NewFs(ctx, ":memory:TestCopyDedupeOnUpload")
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, operations.Purge(ctx, fdst, ""))
+	}()
+
+	file1 := r.WriteFile("file1", "duplicate contents", t1)
+	file2 := r.WriteFile("file2", "duplicate contents", t2)
+	file3 := r.WriteFile("file3", "different contents", t3)
+	file4 := r.WriteFile("file4", "duplicate contents", t3)
+	r.CheckLocalItems(t, file1, file2, file3, file4)
+
+	src1, err := r.Flocal.NewObject(ctx, file1.Path)
+	require.NoError(t, err)
+	_, err = operations.Copy(ctx, fdst, nil, file1.Path, src1)
+	require.NoError(t, err)
+
+	// file2 must be server-side copied from file1 as it can't be read
+	// but keep its own modification time
+	src2, err := r.Flocal.NewObject(ctx, file2.Path)
+	require.NoError(t, err)
+	dst2, err := operations.Copy(ctx, fdst, nil, file2.Path, openFailObject{src2})
+	require.NoError(t, err)
+	fstest.AssertTimeEqualWithPrecision(t, file2.Path, t2, dst2.ModTime(ctx), fs.GetModifyWindow(ctx, fdst))
+
+	// file3 has different contents so must be uploaded
+	src3, err := r.Flocal.NewObject(ctx, file3.Path)
+	require.NoError(t, err)
+	_, err = operations.Copy(ctx, fdst, nil, file3.Path, openFailObject{src3})
+	require.Error(t, err)
+
+	// the uploads aren't remembered in other stats groups
+	src4, err := r.Flocal.NewObject(ctx, file4.Path)
+	require.NoError(t, err)
+	_, err = operations.Copy(accounting.WithStatsGroup(ctx, "TestCopyDedupeOnUpload"), fdst, nil, file4.Path, openFailObject{src4})
+	require.Error(t, err)
+
+	fstest.CheckListingWithPrecision(t, fdst, []fstest.Item{file1, file2}, nil, fs.GetModifyWindow(ctx, fdst))
+}
+
 func TestCopyFileBackupDir(t *testing.T) {
 	ctx := context.Background()
 	ctx, ci := fs.AddConfig(ctx)
@@ -1551,6 +1842,76 @@ func TestRcatSize(t *testing.T) {
 
 	// Check files exist
 	r.CheckRemoteItems(t, file1, file2)
+
+	// Test with a stream which is shorter or longer than the size given
+	for _, size := range []int64{int64(len(body)) + 1, int64(len(body)) - 1} {
+		bodyReader = ioutil.NopCloser(strings.NewReader(body))
+		_, err = operations.RcatSize(ctx, r.Fremote, "potato3", bodyReader, size, t1)
+		require.Error(t, err, size)
+		if size > int64(len(body)) {
+			assert.Contains(t, err.Error(), "input ended after 60 bytes but expected 61 bytes")
+		} else {
+			assert.Contains(t, err.Error(), "input is longer than the expected 59 bytes")
+		}
+		r.CheckRemoteItems(t, file1, file2)
+	}
+}
+
+// badHashObject is an fs.Object which has the wrong hashes
+type badHashObject struct {
+	fs.Object
+}
+
+func (o badHashObject) Hash(ctx context.Context, ht hash.Type) (string, error) {
+	return strings.Repeat("0", hash.Width(ht, false)), nil
+}
+
+func TestCopyOnHashMismatch(t *testing.T) {
+	ctx := context.Background()
+	ctx, ci := fs.AddConfig(ctx)
+	r := fstest.NewRun(t)
+	defer r.Finalise()
+	defer accounting.Stats(ctx).ResetCounters()
+
+	if r.Fremote.Hashes().Overlap(r.Flocal.Hashes()).GetOne() == hash.None {
+		t.Skip("Can't check hashes")
+	}
+	file1 := r.WriteFile("file1", "file1 contents", t1)
+	obj, err := r.Flocal.NewObject(ctx, file1.Path)
+	require.NoError(t, err)
+	src := badHashObject{obj}
+
+	ci.OnHashMismatch = fs.HashMismatchRetry
+	_, err = operations.Copy(ctx, r.Fremote, nil, file1.Path, src)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "corrupted on transfer")
+	assert.False(t, fserrors.IsNoRetryError(err))
+	assert.False(t, fserrors.IsFatalError(err))
+	r.CheckRemoteItems(t)
+
+	ci.OnHashMismatch = fs.HashMismatchFail
+	_, err = operations.Copy(ctx, r.Fremote, nil, file1.Path, src)
+	require.Error(t, err)
+	assert.True(t, fserrors.IsFatalError(err))
+	r.CheckRemoteItems(t)
+
+	if r.Fremote.Features().Move == nil {
+		t.Log("skipping quarantine test as remote can't move")
+		return
+	}
+	ci.OnHashMismatch = fs.HashMismatchQuarantine
+	accounting.Stats(ctx).ResetCounters()
+	for i := 0; i < 2; i++ {
+		_, err = operations.Copy(ctx, r.Fremote, nil, file1.Path, src)
+		require.Error(t, err)
+		assert.True(t, fserrors.IsNoRetryError(err))
+		quarantined := fstest.NewItem("file1.failed", "file1 contents", t1)
+		fstest.CheckListingWithPrecision(t, r.Fremote, []fstest.Item{quarantined}, nil, fs.ModTimeNotSupported)
+	}
+	stats, err := accounting.Stats(ctx).RemoteStats()
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), stats["quarantined"])
+	assert.Contains(t, accounting.Stats(ctx).String(), "Quarantined:")
 }
 
 func TestCopyFileMaxTransfer(t *testing.T) {
@@ -1572,6 +1933,7 @@ func TestCopyFileMaxTransfer(t *testing.T) {
 	file2 := r.WriteFile("TestCopyFileMaxTransfer/file2", "file2 contents"+randomString, t2)
 	file3 := r.WriteFile("TestCopyFileMaxTransfer/file3", "file3 contents"+randomString, t2)
 	file4 := r.WriteFile("TestCopyFileMaxTransfer/file4", "file4 contents"+randomString, t2)
+	file5 := r.WriteFile("TestCopyFileMaxTransfer/file5", "file5 contents"+randomString[:sizeCutoff/2], t2)
 
 	// Cutoff mode: Hard
 	ci.MaxTransfer = sizeCutoff
@@ -1581,7 +1943,7 @@ func TestCopyFileMaxTransfer(t *testing.T) {
 	accounting.Stats(ctx).ResetCounters()
 	err = operations.CopyFile(ctx, r.Fremote, r.Flocal, file1.Path, file1.Path)
 	require.NoError(t, err)
-	r.CheckLocalItems(t, file1, file2, file3, file4)
+	r.CheckLocalItems(t, file1, file2, file3, file4, file5)
 	r.CheckRemoteItems(t, file1)
 
 	// file2: show a large file does not get transferred
@@ -1590,7 +1952,7 @@ func TestCopyFileMaxTransfer(t *testing.T) {
 	require.NotNil(t, err, "Did not get expected max transfer limit error")
 	assert.Contains(t, err.Error(), "Max transfer limit reached")
 	assert.True(t, fserrors.IsFatalError(err), fmt.Sprintf("Not fatal error: %v: %#v:", err, err))
-	r.CheckLocalItems(t, file1, file2, file3, file4)
+	r.CheckLocalItems(t, file1, file2, file3, file4, file5)
 	r.CheckRemoteItems(t, file1)
 
 	// Cutoff mode: Cautious
@@ -1602,7 +1964,7 @@ func TestCopyFileMaxTransfer(t *testing.T) {
 	require.NotNil(t, err)
 	assert.Contains(t, err.Error(), "Max transfer limit reached")
 	assert.True(t, fserrors.IsNoRetryError(err))
-	r.CheckLocalItems(t, file1, file2, file3, file4)
+	r.CheckLocalItems(t, file1, file2, file3, file4, file5)
 	r.CheckRemoteItems(t, file1)
 
 	if strings.HasPrefix(r.Fremote.Name(), "TestChunker") {
@@ -1613,12 +1975,22 @@ func TestCopyFileMaxTransfer(t *testing.T) {
 	// Cutoff mode: Soft
 	ci.CutoffMode = fs.CutoffModeSoft
 
-	// file4: show a large file does get transferred this time
+	// file4: show a file which can't complete under the limit does not get transferred
 	accounting.Stats(ctx).ResetCounters()
 	err = operations.CopyFile(ctx, r.Fremote, r.Flocal, file4.Path, file4.Path)
+	require.NotNil(t, err)
+	assert.Contains(t, err.Error(), "Max transfer limit reached")
+	assert.True(t, fserrors.IsNoRetryError(err))
+	assert.Equal(t, accounting.ErrorMaxTransferLimitSkipped, err)
+	r.CheckLocalItems(t, file1, file2, file3, file4, file5)
+	r.CheckRemoteItems(t, file1)
+
+	// file5: show a file which fits under the limit does get transferred
+	accounting.Stats(ctx).ResetCounters()
+	err = operations.CopyFile(ctx, r.Fremote, r.Flocal, file5.Path, file5.Path)
 	require.NoError(t, err)
-	r.CheckLocalItems(t, file1, file2, file3, file4)
-	r.CheckRemoteItems(t, file1, file4)
+	r.CheckLocalItems(t, file1, file2, file3, file4, file5)
+	r.CheckRemoteItems(t, file1, file5)
 }
 
 func TestTouchDir(t *testing.T) {
@@ -1648,3 +2020,125 @@ func TestTouchDir(t *testing.T) {
 		r.CheckRemoteItems(t, file1, file2, file3)
 	}
 }
+
+func TestBufferSizeOverride(t *testing.T) {
+	ctx := context.Background()
+	ctx, ci := fs.AddConfig(ctx)
+	ci.BufferSize = 16 * fs.Mebi
+
+	f, err := fs.NewFs(ctx, ":memory,buffer_size=3M:")
+	require.NoError(t, err)
+	assert.Equal(t, 3*fs.Mebi, fs.BufferSize(ctx, f))
+
+	// It can be set to 0 to turn the buffering off
+	f, err = fs.NewFs(ctx, ":memory,buffer_size=0:")
+	require.NoError(t, err)
+	assert.Equal(t, fs.SizeSuffix(0), fs.BufferSize(ctx, f))
+
+	// Other remotes aren't affected
+	f, err = fs.NewFs(ctx, ":memory:")
+	require.NoError(t, err)
+	assert.Equal(t, 16*fs.Mebi, fs.BufferSize(ctx, f))
+
+	// Wrapping backends use the buffer size of the remote they wrap
+	// unless they set their own
+	f, err = fs.NewFs(ctx, `:chunker,remote=":memory,buffer_size=3M:":`)
+	require.NoError(t, err)
+	assert.Equal(t, 3*fs.Mebi, fs.BufferSize(ctx, f))
+	f, err = fs.NewFs(ctx, `:chunker,remote=":memory,buffer_size=3M:",buffer_size=5M:`)
+	require.NoError(t, err)
+	assert.Equal(t, 5*fs.Mebi, fs.BufferSize(ctx, f))
+
+	assert.Equal(t, 16*fs.Mebi, fs.BufferSize(ctx, nil))
+
+	_, err = fs.NewFs(ctx, ":memory,buffer_size=potato:")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "bad buffer_size")
+}
+
+func TestCopyContentAddressed(t *testing.T) {
+	ctx := context.Background()
+	r := fstest.NewRun(t)
+	defer r.Finalise()
+	file1 := r.WriteFile("file1", "hello", t1)
+	file2 := r.WriteFile("dir/file2", "hello", t2)
+	file3 := r.WriteFile("file3", "world", t1)
+	r.CheckLocalItems(t, file1, file2, file3)
+
+	const (
+		helloMD5 = "5d41402abc4b2a76b9719d911017c592"
+		worldMD5 = "7d793037a0760186574b0282f2f435e7"
+	)
+	assert.Equal(t, "5d/41/"+helloMD5, operations.ContentAddressedPath(helloMD5))
+	assert.Equal(t, "index.md5", operations.ContentAddressedIndex(hash.MD5))
+
+	err := operations.CopyContentAddressed(ctx, r.Fremote, r.Flocal, hash.MD5)
+	require.NoError(t, err)
+
+	index := []byte(helloMD5 + "  dir/file2\n" + helloMD5 + "  file1\n" + worldMD5 + "  file3\n")
+	stored1 := fstest.NewItem("5d/41/"+helloMD5, "hello", t1)
+	stored3 := fstest.NewItem("7d/79/"+worldMD5, "world", t1)
+	indexItem := fstest.NewItem("index.md5", string(index), t1)
+	fstest.CheckListingWithPrecision(t, r.Fremote, []fstest.Item{stored1, stored3, indexItem}, []string{"5d", "5d/41", "7d", "7d/79"}, fs.ModTimeNotSupported)
+
+	// Check nothing but the index is uploaded if nothing changed
+	accounting.GlobalStats().ResetCounters()
+	err = operations.CopyContentAddressed(ctx, r.Fremote, r.Flocal, hash.MD5)
+	require.NoError(t, err)
+	indexTransfers := accounting.GlobalStats().GetTransfers()
+
+	// Add a new file and check only it is uploaded and the index is updated
+	file4 := r.WriteFile("file4", "potato", t1)
+	r.CheckLocalItems(t, file1, file2, file3, file4)
+	const potatoMD5 = "8ee2027983915ec78acc45027d874316"
+	accounting.GlobalStats().ResetCounters()
+	err = operations.CopyContentAddressed(ctx, r.Fremote, r.Flocal, hash.MD5)
+	require.NoError(t, err)
+	assert.Equal(t, indexTransfers+1, accounting.GlobalStats().GetTransfers())
+
+	o, err := r.Fremote.NewObject(ctx, "index.md5")
+	require.NoError(t, err)
+	sums, err := operations.ParseSumFile(ctx, o)
+	require.NoError(t, err)
+	assert.Equal(t, operations.HashSums{
+		"dir/file2": helloMD5,
+		"file1":     helloMD5,
+		"file3":     worldMD5,
+		"file4":     potatoMD5,
+	}, sums)
+}
+
+// openFailFs is an fs.Fs whose objects can't be opened
+type openFailFs struct {
+	fs.Fs
+}
+
+func (f openFailFs) List(ctx context.Context, dir string) (entries fs.DirEntries, err error) {
+	entries, err = f.Fs.List(ctx, dir)
+	for i, entry := range entries {
+		if o, ok := entry.(fs.Object); ok {
+			entries[i] = openFailObject{o}
+		}
+	}
+	return entries, err
+}
+
+func TestCopyContentAddressedFailed(t *testing.T) {
+	ctx := context.Background()
+	r := fstest.NewRun(t)
+	defer r.Finalise()
+	file1 := r.WriteFile("file1", "hello", t1)
+	file2 := r.WriteFile("file2", "hello", t2)
+	r.CheckLocalItems(t, file1, file2)
+
+	// Neither file is stored so neither should be in the index
+	err := operations.CopyContentAddressed(ctx, r.Fremote, openFailFs{r.Flocal}, hash.MD5)
+	require.Error(t, err)
+	accounting.GlobalStats().ResetErrors()
+
+	o, err := r.Fremote.NewObject(ctx, "index.md5")
+	require.NoError(t, err)
+	sums, err := operations.ParseSumFile(ctx, o)
+	require.NoError(t, err)
+	assert.Equal(t, operations.HashSums{}, sums)
+}
@@ -1,9 +1,12 @@
 package operations
 
 import (
+	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"mime"
 	"mime/multipart"
 	"net/http"
@@ -12,7 +15,9 @@ import (
 	"time"
 
 	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fs/object"
 	"github.com/rclone/rclone/fs/rc"
+	"github.com/rclone/rclone/lib/random"
 )
 
 func init() {
@@ -560,3 +565,200 @@ func rcBackend(ctx context.Context, in rc.Params) (out rc.Params, err error) {
 	out["result"] = result
 	return out, nil
 }
+
+func init() {
+	rc.Add(rc.Call{
+		Path:         "operations/test",
+		AuthRequired: true,
+		Fn:           rcTest,
+		Title:        "Check a remote is usable by doing a write, read and delete round trip",
+		Help: `This takes the following parameters:
+
+- fs - a remote name string e.g. "drive:"
+
+This writes a small object with random contents to the remote, reads
+it back, checks the contents are the same and then deletes it.
+
+The object is written inside a newly created directory called
+"` + testDirPrefix + `XXXXXXXX" (where XXXXXXXX is random) in the
+root of the remote, and nothing outside that directory is touched.
+The object and the directory are removed afterwards, even if the test
+fails.
+
+Returns:
+
+- success - boolean, true if the round trip worked
+- error - string, the error which caused the test to fail (if any)
+- step - string, which step failed (if any)
+- timings - a dictionary of how long each step took in seconds
+    - put - uploading the object
+    - stat - finding the object again
+    - read - downloading the object
+    - delete - deleting the object
+    - total - the whole round trip
+
+Note that failures of the round trip are reported in the result
+rather than as an error, so the timings are always available.
+
+This command does not have a command line equivalent so use this instead:
+
+    rclone rc --loopback operations/test fs=remote:
+
+`,
+	})
+}
+
+// testDirPrefix is the prefix of the temporary directory operations/test writes into
+const testDirPrefix = "rclone-operations-test-"
+
+// Check the remote is usable by doing a write, read, delete round trip
+func rcTest(ctx context.Context, in rc.Params) (out rc.Params, err error) {
+	f, err := rc.GetFs(ctx, in)
+	if err != nil {
+		return nil, err
+	}
+	timings := rc.Params{}
+	out = rc.Params{
+		"success": false,
+		"timings": timings,
+	}
+	start := time.Now()
+	defer func() {
+		timings["total"] = time.Since(start).Seconds()
+	}()
+
+	// step runs fn recording how long it took under name
+	step := func(name string, fn func() error) error {
+		stepStart := time.Now()
+		err := fn()
+		timings[name] = time.Since(stepStart).Seconds()
+		if err != nil {
+			out["step"] = name
+			out["error"] = err.Error()
+		}
+		return err
+	}
+
+	dir := testDirPrefix + random.String(8)
+	remote := path.Join(dir, "probe.txt")
+	contents := []byte("rclone operations/test " + random.String(32) + "\n")
+
+	if err := f.Mkdir(ctx, dir); err != nil {
+		out["step"] = "mkdir"
+		out["error"] = err.Error()
+		return out, nil
+	}
+	var o fs.Object
+	defer func() {
+		// Tidy up whatever happened - o is set to nil once removed
+		if o != nil {
+			if err := o.Remove(ctx); err != nil {
+				fs.Errorf(o, "operations/test: failed to remove test object: %v", err)
+			}
+		}
+		if err := f.Rmdir(ctx, dir); err != nil {
+			fs.Debugf(f, "operations/test: failed to remove test directory %q: %v", dir, err)
+		}
+	}()
+
+	err = step("put", func() error {
+		src := object.NewStaticObjectInfo(remote, time.Now(), int64(len(contents)), true, nil, f)
+		o, err = f.Put(ctx, bytes.NewReader(contents), src)
+		return err
+	})
+	if err != nil {
+		return out, nil
+	}
+	err = step("stat", func() error {
+		// Don't overwrite o on error so it is still removed
+		obj, err := f.NewObject(ctx, remote)
+		if err != nil {
+			return err
+		}
+		o = obj
+		if o.Size() != int64(len(contents)) {
+			return fmt.Errorf("size differs: wrote %d bytes but object has %d", len(contents), o.Size())
+		}
+		return nil
+	})
+	if err != nil {
+		return out, nil
+	}
+	err = step("read", func() error {
+		in, err := o.Open(ctx)
+		if err != nil {
+			return err
+		}
+		got, err := ioutil.ReadAll(in)
+		closeErr := in.Close()
+		if err != nil {
+			return err
+		}
+		if closeErr != nil {
+			return closeErr
+		}
+		if !bytes.Equal(got, contents) {
+			return errors.New("contents read back differ from those written")
+		}
+		return nil
+	})
+	if err != nil {
+		return out, nil
+	}
+	err = step("delete", func() error {
+		err := o.Remove(ctx)
+		if err == nil {
+			o = nil
+		}
+		return err
+	})
+	if err != nil {
+		return out, nil
+	}
+	out["success"] = true
+	return out, nil
+}
+
+func init() {
+	rc.Add(rc.Call{
+		Path:         "operations/forget",
+		AuthRequired: true,
+		Fn:           rcForget,
+		Title:        "Forget cached directory information for a remote",
+		Help: `This takes the following parameters
+
+- fs - a remote name string e.g. "drive:"
+
+Backends which look up directory IDs (e.g. drive, onedrive, box)
+keep a cache of the directories they have seen. If the remote is
+changed by something other than this rclone then this cache can go
+stale. Calling this causes the next operation to look the directories
+up on the backend again.
+
+The backends don't support forgetting part of the cache so the whole
+directory cache for the remote is always forgotten.
+
+The result is
+
+- flushed - true if the backend had a directory cache to forget
+
+See also vfs/forget which forgets the VFS directory cache used by
+mount and serve as well as this cache.
+`,
+	})
+}
+
+// Forget the directory cache of the remote
+func rcForget(ctx context.Context, in rc.Params) (out rc.Params, err error) {
+	f, err := rc.GetFs(ctx, in)
+	if err != nil {
+		return nil, err
+	}
+	flushed := false
+	if doDirCacheFlush := f.Features().DirCacheFlush; doDirCacheFlush != nil {
+		fs.Debugf(f, "Forgetting directory cache")
+		doDirCacheFlush()
+		flushed = true
+	}
+	return rc.Params{"flushed": flushed}, nil
+}
@@ -2,6 +2,7 @@ package operations_test
 
 import (
 	"context"
+	"errors"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
@@ -15,6 +16,7 @@ import (
 	"github.com/rclone/rclone/fs/operations"
 	"github.com/rclone/rclone/fs/rc"
 	"github.com/rclone/rclone/fstest"
+	"github.com/rclone/rclone/fstest/mockfs"
 	"github.com/rclone/rclone/lib/rest"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -595,3 +597,85 @@ func TestRcCommand(t *testing.T) {
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), errTxt)
 }
+
+// operations/test: Check a remote is usable by doing a round trip
+func TestRcTest(t *testing.T) {
+	r, call := rcNewRun(t, "operations/test")
+	defer r.Finalise()
+	r.Mkdir(context.Background(), r.Fremote)
+
+	in := rc.Params{
+		"fs": r.FremoteName,
+	}
+	out, err := call.Fn(context.Background(), in)
+	require.NoError(t, err)
+	assert.Equal(t, true, out["success"], "error: %v", out["error"])
+	assert.Nil(t, out["error"])
+	timings, ok := out["timings"].(rc.Params)
+	require.True(t, ok)
+	for _, name := range []string{"put", "stat", "read", "delete", "total"} {
+		assert.Contains(t, timings, name)
+	}
+
+	// Check the test tidied up after itself
+	r.CheckRemoteListing(t, nil, nil)
+}
+
+// statFailFs is an fs.Fs whose NewObject always fails
+type statFailFs struct {
+	fs.Fs
+}
+
+func (f statFailFs) NewObject(ctx context.Context, remote string) (fs.Object, error) {
+	return nil, errors.New("stat failed")
+}
+
+// operations/test: Check the probe is removed if a step fails
+func TestRcTestStatFail(t *testing.T) {
+	r, call := rcNewRun(t, "operations/test")
+	defer r.Finalise()
+	r.Mkdir(context.Background(), r.Fremote)
+	cache.Put("statfail:", statFailFs{r.Fremote})
+	defer cache.Clear()
+
+	in := rc.Params{
+		"fs": "statfail:",
+	}
+	out, err := call.Fn(context.Background(), in)
+	require.NoError(t, err)
+	assert.Equal(t, false, out["success"])
+	assert.Equal(t, "stat", out["step"])
+	assert.Equal(t, "stat failed", out["error"])
+
+	// Check the probe was removed
+	r.CheckRemoteListing(t, nil, nil)
+}
+
+// operations/forget: forget the directory cache
+func TestRcForget(t *testing.T) {
+	r, call := rcNewRun(t, "operations/forget")
+	defer r.Finalise()
+	ctx := context.Background()
+
+	// Remote without a directory cache
+	in := rc.Params{
+		"fs": r.LocalName,
+	}
+	out, err := call.Fn(ctx, in)
+	require.NoError(t, err)
+	assert.Equal(t, rc.Params{"flushed": false}, out)
+
+	// Remote with a directory cache
+	f := mockfs.NewFs(ctx, "mock", "/")
+	flushes := 0
+	f.Features().DirCacheFlush = func() { flushes++ }
+	cache.Put("mock:/", f)
+	defer cache.Clear()
+	in = rc.Params{
+		"fs": "mock:/",
+	}
+	out, err = call.Fn(ctx, in)
+	require.NoError(t, err)
+	assert.Equal(t, rc.Params{"flushed": true}, out)
+	assert.Equal(t, 1, flushes)
+}
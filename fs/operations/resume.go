@@ -0,0 +1,269 @@
+package operations
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fs/accounting"
+	"github.com/rclone/rclone/fs/config"
+	"github.com/rclone/rclone/lib/file"
+)
+
+const (
+	resumeDir            = "partial-resume" // directory in the cache dir the state is kept in
+	resumeCheckpointSize = 16 * 1024 * 1024 // save the state after this many bytes are written
+	resumeBufferSize     = 32 * 1024
+)
+
+// Return a boolean as to whether we should use a resumable copy for
+// this transfer
+func doResumableCopy(ctx context.Context, f fs.Fs, src fs.Object) bool {
+	ci := fs.GetConfig(ctx)
+	return ci.PartialResume && src.Size() > 0 && f.Features().OpenWriterAtResume != nil
+}
+
+// resumeState is the state of a partly done upload saved so it can be
+// resumed by a later run
+type resumeState struct {
+	Source  string `json:"source"`  // the source object the upload is of
+	Size    int64  `json:"size"`    // size of the source object
+	ModTime int64  `json:"modTime"` // modification time of the source object in ns
+	Offset  int64  `json:"offset"`  // number of bytes of the upload confirmed written
+	MD5     string `json:"md5"`     // MD5 of the first Offset bytes
+}
+
+// resumableCopyState is the state of a resumable copy
+type resumableCopyState struct {
+	f         fs.Fs
+	remote    string
+	src       fs.Object
+	stateName string      // path of the file the state is saved in
+	want      resumeState // the state with the details of src
+}
+
+// newResumableCopyState makes the state for a resumable copy of src
+// to remote on f
+func newResumableCopyState(ctx context.Context, f fs.Fs, remote string, src fs.Object) *resumableCopyState {
+	key := md5.Sum([]byte(path.Join(fs.ConfigString(f), remote)))
+	return &resumableCopyState{
+		f:         f,
+		remote:    remote,
+		src:       src,
+		stateName: filepath.Join(config.GetCacheDir(), resumeDir, hex.EncodeToString(key[:])+".json"),
+		want: resumeState{
+			Source:  src.Fs().Name() + ":" + path.Join(src.Fs().Root(), src.Remote()),
+			Size:    src.Size(),
+			ModTime: src.ModTime(ctx).UnixNano(),
+		},
+	}
+}
+
+// load the saved state returning the offset to carry on from with
+// the MD5 of the data up to there, having checked the data already
+// written still has that MD5.
+//
+// If there is no saved state or it isn't for the same source or the
+// data doesn't match then it returns 0 to start again.
+func (rs *resumableCopyState) load(ctx context.Context) (offset int64, hasher hash.Hash) {
+	hasher = md5.New()
+	data, err := ioutil.ReadFile(rs.stateName)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			fs.Debugf(rs.src, "Resumable copy: failed to read state: %v", err)
+		}
+		return 0, hasher
+	}
+	var state resumeState
+	err = json.Unmarshal(data, &state)
+	if err != nil {
+		fs.Debugf(rs.src, "Resumable copy: failed to parse state: %v", err)
+		return 0, hasher
+	}
+	if state.Source != rs.want.Source || state.Size != rs.want.Size || state.ModTime != rs.want.ModTime {
+		fs.Debugf(rs.src, "Resumable copy: not resuming as the source has changed")
+		return 0, hasher
+	}
+	if state.Offset <= 0 || state.Offset > state.Size {
+		return 0, hasher
+	}
+	err = rs.checkPartial(ctx, state, hasher)
+	if err != nil {
+		fs.Logf(rs.src, "Resumable copy: not resuming as the partial upload can't be used: %v", err)
+		return 0, md5.New()
+	}
+	fs.Infof(rs.src, "Resumable copy: resuming from offset %d", state.Offset)
+	return state.Offset, hasher
+}
+
+// checkPartial checks the first state.Offset bytes of the destination
+// have the MD5 in state, writing them to hasher
+func (rs *resumableCopyState) checkPartial(ctx context.Context, state resumeState, hasher hash.Hash) error {
+	dst, err := rs.f.NewObject(ctx, rs.remote)
+	if err != nil {
+		return err
+	}
+	if dst.Size() < state.Offset {
+		return fmt.Errorf("it is %d bytes but should be at least %d", dst.Size(), state.Offset)
+	}
+	in, err := dst.Open(ctx, &fs.RangeOption{Start: 0, End: state.Offset - 1})
+	if err != nil {
+		return err
+	}
+	n, err := io.Copy(hasher, io.LimitReader(in, state.Offset))
+	closeErr := in.Close()
+	if err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+	if n != state.Offset {
+		return fmt.Errorf("read %d bytes but expected %d", n, state.Offset)
+	}
+	if sum := hex.EncodeToString(hasher.Sum(nil)); sum != state.MD5 {
+		return fmt.Errorf("MD5 is %s but should be %s", sum, state.MD5)
+	}
+	return nil
+}
+
+// save the state with offset bytes confirmed written with the MD5 in
+// hasher
+func (rs *resumableCopyState) save(offset int64, hasher hash.Hash) error {
+	state := rs.want
+	state.Offset = offset
+	state.MD5 = hex.EncodeToString(hasher.Sum(nil))
+	data, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+	err = file.MkdirAll(filepath.Dir(rs.stateName), 0700)
+	if err != nil {
+		return err
+	}
+	tmpName := rs.stateName + ".tmp"
+	err = ioutil.WriteFile(tmpName, data, 0600)
+	if err != nil {
+		return err
+	}
+	return os.Rename(tmpName, rs.stateName)
+}
+
+// remove the saved state
+func (rs *resumableCopyState) remove() {
+	err := os.Remove(rs.stateName)
+	if err != nil && !os.IsNotExist(err) {
+		fs.Debugf(rs.src, "Resumable copy: failed to remove state: %v", err)
+	}
+}
+
+// Copy src to (f, remote) in a single stream using the
+// OpenWriterAtResume feature, saving how much has been written so a
+// later run can carry on from there if this one doesn't finish
+func resumableCopy(ctx context.Context, f fs.Fs, remote string, src fs.Object, tr *accounting.Transfer) (newDst fs.Object, err error) {
+	ci := fs.GetConfig(ctx)
+	openWriterAt := f.Features().OpenWriterAtResume
+	if openWriterAt == nil {
+		return nil, errors.New("resumable copy: OpenWriterAtResume not supported")
+	}
+	rs := newResumableCopyState(ctx, f, remote, src)
+	offset, hasher := rs.load(ctx)
+	saved := offset
+
+	wc, err := openWriterAt(ctx, remote, src.Size())
+	if err != nil {
+		return nil, fmt.Errorf("resumable copy: failed to open destination: %w", err)
+	}
+	closed := false
+	defer func() {
+		if !closed {
+			_ = wc.Close()
+		}
+		if err != nil && offset > saved {
+			// record what was written for the next run
+			saveErr := rs.save(offset, hasher)
+			if saveErr != nil {
+				fs.Errorf(src, "Resumable copy: failed to save state: %v", saveErr)
+			}
+		}
+	}()
+
+	var options []fs.OpenOption
+	if offset > 0 {
+		options = append(options, &fs.RangeOption{Start: offset, End: -1})
+	}
+	in, err := NewReOpen(ctx, src, ci.LowLevelRetries, options...)
+	if err != nil {
+		return nil, fmt.Errorf("resumable copy: failed to open source: %w", err)
+	}
+	defer fs.CheckClose(in, &err)
+	acc := tr.Account(ctx, nil)
+
+	buf := make([]byte, resumeBufferSize)
+	for offset < src.Size() {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		nr, er := in.Read(buf)
+		if nr > 0 {
+			err = acc.AccountRead(nr)
+			if err != nil {
+				return nil, fmt.Errorf("resumable copy: accounting failed: %w", err)
+			}
+			nw, ew := wc.WriteAt(buf[:nr], offset)
+			_, _ = hasher.Write(buf[:nw])
+			offset += int64(nw)
+			if ew != nil {
+				return nil, fmt.Errorf("resumable copy: write failed: %w", ew)
+			}
+			if nw != nr {
+				return nil, fmt.Errorf("resumable copy: %w", io.ErrShortWrite)
+			}
+			if offset-saved >= resumeCheckpointSize {
+				err = rs.save(offset, hasher)
+				if err != nil {
+					return nil, fmt.Errorf("resumable copy: failed to save state: %w", err)
+				}
+				saved = offset
+			}
+		}
+		if er == io.EOF {
+			break
+		}
+		if er != nil {
+			return nil, fmt.Errorf("resumable copy: read failed: %w", er)
+		}
+	}
+	if offset != src.Size() {
+		return nil, fmt.Errorf("resumable copy: wrote %d bytes but expected to write %d", offset, src.Size())
+	}
+	closed = true
+	err = wc.Close()
+	if err != nil {
+		return nil, fmt.Errorf("resumable copy: failed to close object after copy: %w", err)
+	}
+	rs.remove()
+
+	obj, err := f.NewObject(ctx, remote)
+	if err != nil {
+		return nil, fmt.Errorf("resumable copy: failed to find object after copy: %w", err)
+	}
+
+	err = obj.SetModTime(ctx, src.ModTime(ctx))
+	switch err {
+	case nil, fs.ErrorCantSetModTime, fs.ErrorCantSetModTimeWithoutDelete:
+	default:
+		return nil, fmt.Errorf("resumable copy: failed to set modification time: %w", err)
+	}
+	return obj, nil
+}
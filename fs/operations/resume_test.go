@@ -0,0 +1,95 @@
+package operations
+
+import (
+	"context"
+	"crypto/md5"
+	"os"
+	"testing"
+
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fs/accounting"
+	"github.com/rclone/rclone/fs/config"
+	"github.com/rclone/rclone/fstest"
+	"github.com/rclone/rclone/fstest/mockfs"
+	"github.com/rclone/rclone/fstest/mockobject"
+	"github.com/rclone/rclone/lib/random"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDoResumableCopy(t *testing.T) {
+	ctx, ci := fs.AddConfig(context.Background())
+	f := mockfs.NewFs(ctx, "potato", "")
+	src := mockobject.New("file.txt").WithContent([]byte(random.String(100)), mockobject.SeekModeNone)
+
+	f.Features().OpenWriterAtResume = func(ctx context.Context, remote string, size int64) (fs.WriterAtCloser, error) {
+		panic("don't call me")
+	}
+	assert.False(t, doResumableCopy(ctx, f, src))
+	ci.PartialResume = true
+	assert.True(t, doResumableCopy(ctx, f, src))
+	assert.False(t, doResumableCopy(ctx, f, mockobject.New("empty").WithContent(nil, mockobject.SeekModeNone)))
+	f.Features().OpenWriterAtResume = nil
+	assert.False(t, doResumableCopy(ctx, f, src))
+}
+
+func TestResumableCopy(t *testing.T) {
+	r := fstest.NewRun(t)
+	defer r.Finalise()
+	if r.Flocal.Features().OpenWriterAtResume == nil {
+		t.Skip("local has no OpenWriterAtResume")
+	}
+	ctx := context.Background()
+	oldCacheDir := config.GetCacheDir()
+	require.NoError(t, config.SetCacheDir(t.TempDir()))
+	defer func() { _ = config.SetCacheDir(oldCacheDir) }()
+
+	contents := random.String(3 * resumeBufferSize)
+	t1 := fstest.Time("2001-02-03T04:05:06.499999999Z")
+	file1 := r.WriteObject(ctx, "file1", contents, t1)
+	src, err := r.Fremote.NewObject(ctx, "file1")
+	require.NoError(t, err)
+
+	// copy with a partial upload from an earlier run which has
+	// written offset bytes, returning the number of bytes read
+	copyFrom := func(offset int, partial string) int64 {
+		rs := newResumableCopyState(ctx, r.Flocal, "file1", src)
+		hasher := md5.New()
+		_, _ = hasher.Write([]byte(contents[:offset]))
+		require.NoError(t, rs.save(int64(offset), hasher))
+		r.WriteFile("file1", partial, t1)
+
+		stats := accounting.NewStats(ctx)
+		tr := stats.NewTransfer(src)
+		dst, err := resumableCopy(ctx, r.Flocal, "file1", src, tr)
+		tr.Done(ctx, err)
+		require.NoError(t, err)
+		assert.Equal(t, src.Size(), dst.Size())
+		r.CheckLocalItems(t, file1)
+		_, err = os.Stat(rs.stateName)
+		assert.True(t, os.IsNotExist(err))
+		return stats.GetBytes()
+	}
+
+	// the partial is used and only the rest is read
+	offset := resumeBufferSize + 10
+	assert.Equal(t, int64(len(contents)-offset), copyFrom(offset, contents[:offset]))
+
+	// the partial doesn't match the state so it starts again
+	assert.Equal(t, int64(len(contents)), copyFrom(offset, "X"+contents[1:offset]))
+
+	// the partial is too short so it starts again
+	assert.Equal(t, int64(len(contents)), copyFrom(offset, contents[:offset-1]))
+
+	// the state is for a different source so it starts again
+	rs := newResumableCopyState(ctx, r.Flocal, "file1", src)
+	rs.want.Size++
+	require.NoError(t, rs.save(int64(offset), md5.New()))
+	stats := accounting.NewStats(ctx)
+	tr := stats.NewTransfer(src)
+	_, err = resumableCopy(ctx, r.Flocal, "file1", src, tr)
+	tr.Done(ctx, err)
+	require.NoError(t, err)
+	assert.Equal(t, int64(len(contents)), stats.GetBytes())
+	r.CheckLocalItems(t, file1)
+}
@@ -0,0 +1,143 @@
+package operations
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fs/accounting"
+	"github.com/rclone/rclone/fs/fserrors"
+)
+
+// stallWatchdog cancels a transfer if no data moves for
+// --transfer-stall-timeout
+//
+// Only the data read by the transfer is counted, so once all of it has
+// been read the watchdog is paused while the backend finishes off the
+// transfer, e.g. uploading the last chunks and completing a multipart
+// upload, which can take a while.
+type stallWatchdog struct {
+	timeout time.Duration
+	name    string
+	tr      *accounting.Transfer
+	cancel  context.CancelFunc
+	done    chan struct{}
+	wg      sync.WaitGroup
+	read    int64 // bytes read through readers made with wrap - used atomically
+	stalled int32 // set to 1 if the transfer stalled - used atomically
+	eof     int32 // set to 1 if a reader made with wrap hit EOF - used atomically
+}
+
+// newStallWatchdog starts watching the transfer tr of src if
+// --transfer-stall-timeout is set.
+//
+// It returns a context to run the transfer with which is cancelled
+// if the transfer stalls. stop must be called when the transfer has
+// finished. If --transfer-stall-timeout isn't set it returns ctx and
+// nil - all the methods can be used on a nil *stallWatchdog.
+func newStallWatchdog(ctx context.Context, src fs.Object, tr *accounting.Transfer) (context.Context, *stallWatchdog) {
+	timeout := fs.GetConfig(ctx).TransferStallTimeout
+	if timeout <= 0 {
+		return ctx, nil
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	w := &stallWatchdog{
+		timeout: timeout,
+		name:    src.Remote(),
+		tr:      tr,
+		cancel:  cancel,
+		done:    make(chan struct{}),
+	}
+	w.wg.Add(1)
+	go w.watch()
+	return ctx, w
+}
+
+// progress returns a number which changes when data moves
+func (w *stallWatchdog) progress() int64 {
+	return w.tr.Snapshot().Bytes + atomic.LoadInt64(&w.read)
+}
+
+// finishing returns true once all the data of the transfer has been
+// read so it is waiting for the backend to finish it off.
+func (w *stallWatchdog) finishing() bool {
+	if atomic.LoadInt32(&w.eof) != 0 {
+		return true
+	}
+	s := w.tr.Snapshot()
+	return s.Size > 0 && s.Bytes >= s.Size
+}
+
+// watch cancels the transfer if progress doesn't change for
+// w.timeout unless it is finishing
+func (w *stallWatchdog) watch() {
+	defer w.wg.Done()
+	ticker := time.NewTicker(w.timeout / 4)
+	defer ticker.Stop()
+	last, lastMoved := w.progress(), time.Now()
+	for {
+		select {
+		case <-w.done:
+			return
+		case now := <-ticker.C:
+			if current := w.progress(); current != last || w.finishing() {
+				last, lastMoved = current, now
+			} else if now.Sub(lastMoved) >= w.timeout {
+				fs.Errorf(w.name, "Transfer stalled: no data transferred for %v - aborting", w.timeout)
+				atomic.StoreInt32(&w.stalled, 1)
+				w.cancel()
+				return
+			}
+		}
+	}
+}
+
+// wrap in so the data read from it counts as progress.
+//
+// This is for transfers which aren't accounted in the Transfer being
+// watched.
+func (w *stallWatchdog) wrap(in io.ReadCloser) io.ReadCloser {
+	if w == nil {
+		return in
+	}
+	return &stallReader{ReadCloser: in, w: w}
+}
+
+// stop watching the transfer, returning the error the transfer
+// finished with.
+//
+// If the transfer stalled and failed this is replaced with a retry
+// error and the stall is counted in the stats.
+func (w *stallWatchdog) stop(ctx context.Context, err error) error {
+	if w == nil {
+		return err
+	}
+	close(w.done)
+	w.wg.Wait()
+	w.cancel()
+	if err == nil || atomic.LoadInt32(&w.stalled) == 0 {
+		return err
+	}
+	accounting.Stats(ctx).Stalls(1)
+	return fserrors.RetryError(fmt.Errorf("transfer stalled: no data transferred for %v", w.timeout))
+}
+
+// stallReader counts the bytes read for a stallWatchdog
+type stallReader struct {
+	io.ReadCloser
+	w *stallWatchdog
+}
+
+// Read bytes counting them as progress
+func (r *stallReader) Read(p []byte) (n int, err error) {
+	n, err = r.ReadCloser.Read(p)
+	atomic.AddInt64(&r.w.read, int64(n))
+	if err == io.EOF {
+		atomic.StoreInt32(&r.w.eof, 1)
+	}
+	return n, err
+}
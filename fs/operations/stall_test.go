@@ -0,0 +1,113 @@
+package operations
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"io/ioutil"
+	"testing"
+	"time"
+
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fs/accounting"
+	"github.com/rclone/rclone/fs/fserrors"
+	"github.com/rclone/rclone/fstest/mockobject"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStallWatchdogDisabled(t *testing.T) {
+	ctx := context.Background()
+	src := mockobject.New("potato")
+	tr := accounting.Stats(ctx).NewTransfer(src)
+	defer tr.Done(ctx, nil)
+
+	newCtx, w := newStallWatchdog(ctx, src, tr)
+	assert.Nil(t, w)
+	assert.Equal(t, ctx, newCtx)
+	in := ioutil.NopCloser(bytes.NewBufferString("hello"))
+	assert.Equal(t, in, w.wrap(in))
+	errTest := errors.New("potato")
+	assert.Equal(t, errTest, w.stop(ctx, errTest))
+}
+
+func TestStallWatchdogStalled(t *testing.T) {
+	ctx, ci := fs.AddConfig(accounting.WithStatsGroup(context.Background(), "TestStallWatchdogStalled"))
+	ci.TransferStallTimeout = 100 * time.Millisecond
+	stats := accounting.Stats(ctx)
+	src := mockobject.New("potato")
+	tr := stats.NewTransfer(src)
+	defer tr.Done(ctx, nil)
+
+	transferCtx, w := newStallWatchdog(ctx, src, tr)
+	require.NotNil(t, w)
+	select {
+	case <-transferCtx.Done():
+	case <-time.After(10 * time.Second):
+		t.Fatal("stalled transfer wasn't cancelled")
+	}
+	err := w.stop(ctx, transferCtx.Err())
+	require.Error(t, err)
+	assert.True(t, fserrors.IsRetryError(err))
+	assert.Contains(t, err.Error(), "transfer stalled")
+	assert.Equal(t, int64(1), stats.Stalls(0))
+	assert.Contains(t, stats.String(), "Stalled:")
+	out, err := stats.RemoteStats()
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), out["stalls"])
+}
+
+func TestStallWatchdogMoving(t *testing.T) {
+	ctx, ci := fs.AddConfig(accounting.WithStatsGroup(context.Background(), "TestStallWatchdogMoving"))
+	ci.TransferStallTimeout = 200 * time.Millisecond
+	stats := accounting.Stats(ctx)
+	src := mockobject.New("potato")
+	tr := stats.NewTransfer(src)
+	defer tr.Done(ctx, nil)
+
+	transferCtx, w := newStallWatchdog(ctx, src, tr)
+	require.NotNil(t, w)
+	in := w.wrap(ioutil.NopCloser(bytes.NewBufferString("0123456789")))
+	buf := make([]byte, 1)
+	for {
+		_, err := in.Read(buf)
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+		time.Sleep(50 * time.Millisecond)
+	}
+	assert.NoError(t, transferCtx.Err())
+	assert.NoError(t, w.stop(ctx, nil))
+	assert.Error(t, transferCtx.Err())
+	assert.Equal(t, int64(0), stats.Stalls(0))
+}
+
+func TestStallWatchdogFinishing(t *testing.T) {
+	ctx, ci := fs.AddConfig(accounting.WithStatsGroup(context.Background(), "TestStallWatchdogFinishing"))
+	ci.TransferStallTimeout = 100 * time.Millisecond
+	stats := accounting.Stats(ctx)
+
+	// Once all the data has been read the backend may take a while
+	// to finish the transfer without it counting as a stall
+	for _, accounted := range []bool{true, false} {
+		src := mockobject.New("potato")
+		tr := stats.NewTransferRemoteSize("potato", 10)
+		transferCtx, w := newStallWatchdog(ctx, src, tr)
+		require.NotNil(t, w)
+		in := ioutil.NopCloser(bytes.NewBufferString("0123456789"))
+		if accounted {
+			in = tr.Account(ctx, in)
+		} else {
+			in = w.wrap(in)
+		}
+		_, err := ioutil.ReadAll(in)
+		require.NoError(t, err)
+		time.Sleep(400 * time.Millisecond)
+		assert.NoError(t, transferCtx.Err(), "accounted=%v", accounted)
+		assert.NoError(t, w.stop(ctx, nil))
+		tr.Done(ctx, nil)
+	}
+	assert.Equal(t, int64(0), stats.Stalls(0))
+}
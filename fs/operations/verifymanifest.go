@@ -0,0 +1,227 @@
+package operations
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync/atomic"
+
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fs/accounting"
+	"github.com/rclone/rclone/fs/filter"
+	"github.com/rclone/rclone/fs/hash"
+)
+
+// ManifestEntry is a file in a manifest read by ParseManifest
+//
+// It has the fields of ListJSONItem which are needed to verify a file
+// so the output of lsjson can be used as a manifest.
+type ManifestEntry struct {
+	Path   string
+	Size   int64
+	IsDir  bool
+	Hashes map[string]string
+}
+
+// Manifest represents a parsed manifest indexed by path
+type Manifest map[string]ManifestEntry
+
+// ParseManifest reads a manifest in the format written by
+// "rclone lsjson -R --hash" from in.
+//
+// Directories are ignored, as are hashes of unknown types. The hashes
+// are lower cased so they are compared case insensitively.
+func ParseManifest(in io.Reader) (Manifest, error) {
+	var entries []ManifestEntry
+	err := json.NewDecoder(in).Decode(&entries)
+	if err != nil {
+		return nil, err
+	}
+	manifest := make(Manifest, len(entries))
+	for _, entry := range entries {
+		if entry.IsDir {
+			continue
+		}
+		if entry.Path == "" {
+			return nil, errors.New("entry with no Path")
+		}
+		if _, found := manifest[entry.Path]; found {
+			return nil, fmt.Errorf("duplicate entry for %q", entry.Path)
+		}
+		hashes := make(map[string]string, len(entry.Hashes))
+		for name, sum := range entry.Hashes {
+			var ht hash.Type
+			if ht.Set(name) != nil || ht == hash.None || sum == "" {
+				fs.Debugf(entry.Path, "Ignoring %q hash in manifest", name)
+				continue
+			}
+			hashes[ht.String()] = strings.ToLower(sum)
+		}
+		entry.Hashes = hashes
+		manifest[entry.Path] = entry
+	}
+	return manifest, nil
+}
+
+// hashType returns the hash type and hash to check the entry with on
+// f or hash.None if there isn't one. If download is set then any hash
+// type rclone can calculate may be used.
+func (entry *ManifestEntry) hashType(f fs.Fs, download bool) (hash.Type, string) {
+	var types hash.Set
+	for name := range entry.Hashes {
+		var ht hash.Type
+		_ = ht.Set(name)
+		types.Add(ht)
+	}
+	if !download {
+		types = types.Overlap(f.Hashes())
+	}
+	ht := types.GetOne()
+	if ht == hash.None {
+		return hash.None, ""
+	}
+	return ht, entry.Hashes[ht.String()]
+}
+
+// VerifyManifest checks the files in fdst against the manifest
+// manifestFile in fmanifest, as read by ParseManifest.
+//
+// The size of each file is checked and its hash if the manifest has a
+// hash the remote supports. If download is set then the files are
+// downloaded and hashed instead so any hash in the manifest can be
+// used.
+//
+// Files which differ or are missing from either side are reported as
+// for Check and an error is returned if there are any.
+func VerifyManifest(ctx context.Context, fdst, fmanifest fs.Fs, manifestFile string, opt *CheckOpt, download bool) error {
+	var options CheckOpt
+	if opt != nil {
+		options = *opt
+	} else {
+		options.Combined = os.Stdout
+	}
+	// Fsrc corresponds to the manifest and Fdst is checked against it
+	options.Fsrc = nil
+	options.Fdst = fdst
+	opt = &options
+
+	if manifestFile == "" {
+		return fmt.Errorf("not a manifest file: %s", fmanifest)
+	}
+	manifestObj, err := fmanifest.NewObject(ctx, manifestFile)
+	if err != nil {
+		return fmt.Errorf("cannot open manifest: %w", err)
+	}
+	in, err := manifestObj.Open(ctx)
+	if err != nil {
+		return fmt.Errorf("cannot open manifest: %w", err)
+	}
+	manifest, err := ParseManifest(in)
+	_ = in.Close()
+	if err != nil {
+		return fmt.Errorf("failed to parse manifest: %w", err)
+	}
+
+	ci := fs.GetConfig(ctx)
+	c := &checkMarch{
+		tokens: make(chan struct{}, ci.Checkers),
+		opt:    *opt,
+	}
+	lastErr := ListFn(ctx, opt.Fdst, func(obj fs.Object) {
+		c.verifyManifestEntry(ctx, obj, download, manifest)
+	})
+	c.wg.Wait() // wait for background go-routines
+
+	// what is left in the manifest is missing from fdst
+	fi := filter.GetConfig(ctx)
+	for remote := range manifest {
+		if !fi.IncludeRemote(remote) {
+			continue
+		}
+		err := fmt.Errorf("File not in %v", opt.Fdst)
+		fs.Errorf(remote, "%v", err)
+		_ = fs.CountError(err)
+		if lastErr == nil {
+			lastErr = err
+		}
+		atomic.AddInt32(&c.dstFilesMissing, 1)
+		c.reportFilename(remote, opt.MissingOnDst, '+')
+	}
+
+	return c.reportResults(ctx, lastErr)
+}
+
+// verifyManifestEntry checks a single object against its entry in the
+// manifest, removing the entry from the manifest
+func (c *checkMarch) verifyManifestEntry(ctx context.Context, obj fs.Object, download bool, manifest Manifest) {
+	remote := obj.Remote()
+	c.ioMu.Lock()
+	entry, found := manifest[remote]
+	delete(manifest, remote)
+	c.ioMu.Unlock()
+
+	if !found {
+		if c.opt.OneWay {
+			return
+		}
+		err := errors.New("file not in manifest")
+		_ = fs.CountError(err)
+		fs.Errorf(obj, "%v", err)
+		atomic.AddInt32(&c.differences, 1)
+		atomic.AddInt32(&c.srcFilesMissing, 1)
+		c.report(obj, c.opt.MissingOnSrc, '-')
+		return
+	}
+
+	c.wg.Add(1)
+	c.tokens <- struct{}{} // put a token to limit concurrency
+	go func() {
+		defer func() {
+			<-c.tokens // get the token back to free up a slot
+			c.wg.Done()
+		}()
+		ci := fs.GetConfig(ctx)
+		tr := accounting.Stats(ctx).NewCheckingTransfer(obj)
+		defer tr.Done(ctx, nil)
+
+		if !ci.IgnoreSize && entry.Size >= 0 && obj.Size() >= 0 && entry.Size != obj.Size() {
+			err := errors.New("sizes differ")
+			_ = fs.CountError(err)
+			fs.Debugf(nil, "size = %d (manifest)", entry.Size)
+			fs.Debugf(obj, "size = %d (%v)", obj.Size(), c.opt.Fdst)
+			fs.Errorf(obj, "%v", err)
+			atomic.AddInt32(&c.differences, 1)
+			c.report(obj, c.opt.Differ, '*')
+			return
+		}
+		if ci.SizeOnly {
+			fs.Debugf(obj, "OK")
+			atomic.AddInt32(&c.matches, 1)
+			c.report(obj, c.opt.Match, '=')
+			return
+		}
+		ht, sum := entry.hashType(c.opt.Fdst, download)
+		if ht == hash.None {
+			fs.Debugf(obj, "OK - could not check hash")
+			atomic.AddInt32(&c.noHashes, 1)
+			atomic.AddInt32(&c.matches, 1)
+			c.report(obj, c.opt.Match, '=')
+			return
+		}
+		var objHash string
+		var err error
+		if download {
+			objHash, err = hashSum(ctx, ht, false, true, obj)
+		} else {
+			objHash, err = obj.Hash(ctx, ht)
+		}
+		if err != nil {
+			objHash = ""
+		}
+		c.matchSum(ctx, sum, strings.ToLower(objHash), obj, err, ht)
+	}()
+}
@@ -0,0 +1,111 @@
+package operations_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fs/accounting"
+	"github.com/rclone/rclone/fs/hash"
+	"github.com/rclone/rclone/fs/operations"
+	"github.com/rclone/rclone/fstest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseManifest(t *testing.T) {
+	manifest, err := operations.ParseManifest(strings.NewReader(`[
+{"Path":"dir","Name":"dir","Size":-1,"ModTime":"2022-03-18T12:00:00Z","IsDir":true},
+{"Path":"dir/file","Name":"file","Size":5,"ModTime":"2022-03-18T12:00:00Z","IsDir":false,"Hashes":{"md5":"ABCDEF","potato":"123","sha1":""}}
+]`))
+	require.NoError(t, err)
+	assert.Equal(t, operations.Manifest{
+		"dir/file": {Path: "dir/file", Size: 5, Hashes: map[string]string{"md5": "abcdef"}},
+	}, manifest)
+
+	_, err = operations.ParseManifest(strings.NewReader(`[{"Path":"a","Size":1},{"Path":"a","Size":2}]`))
+	assert.EqualError(t, err, `duplicate entry for "a"`)
+
+	_, err = operations.ParseManifest(strings.NewReader(`[{"Size":1}]`))
+	assert.EqualError(t, err, "entry with no Path")
+
+	_, err = operations.ParseManifest(strings.NewReader(`potato`))
+	assert.Error(t, err)
+}
+
+func testVerifyManifest(t *testing.T, download bool) {
+	const (
+		dataDir      = "data"
+		manifestFile = "manifest.json"
+	)
+	ctx := context.Background()
+	r := fstest.NewRun(t)
+	defer r.Finalise()
+
+	subRemote := r.FremoteName
+	if !strings.HasSuffix(subRemote, ":") {
+		subRemote += "/"
+	}
+	subRemote += dataDir
+	dataFs, err := fs.NewFs(ctx, subRemote)
+	require.NoError(t, err)
+
+	if !download && !dataFs.Hashes().Contains(hash.MD5) {
+		t.Skipf("%s lacks %s, skipping", dataFs, hash.MD5)
+	}
+
+	r.WriteObject(ctx, dataDir+"/same", "Hello, World!", t1)
+	r.WriteObject(ctx, dataDir+"/changed", "I am the walrus", t1)
+	r.WriteObject(ctx, dataDir+"/resized", "potato", t1)
+	r.WriteObject(ctx, dataDir+"/extra", "extra", t1)
+
+	verify := func(entries []operations.ManifestEntry, oneWay bool) (combined []string, err error) {
+		b, err := json.Marshal(entries)
+		require.NoError(t, err)
+		r.WriteObject(ctx, manifestFile, string(b), t1)
+		accounting.GlobalStats().ResetCounters()
+		var buf bytes.Buffer
+		opt := operations.CheckOpt{
+			Combined: &buf,
+			OneWay:   oneWay,
+		}
+		err = operations.VerifyManifest(ctx, dataFs, r.Fremote, manifestFile, &opt, download)
+		combined = strings.Split(strings.TrimSpace(buf.String()), "\n")
+		sort.Strings(combined)
+		return combined, err
+	}
+
+	same := operations.ManifestEntry{Path: "same", Size: 13, Hashes: map[string]string{"md5": "65A8E27D8879283831B664BD8B7F0AD4"}}
+	changed := operations.ManifestEntry{Path: "changed", Size: 15, Hashes: map[string]string{"md5": "87396e030ef3f5b35bbf85c0a09a4fb3"}}
+	resized := operations.ManifestEntry{Path: "resized", Size: 6}
+	extra := operations.ManifestEntry{Path: "extra", Size: 5}
+
+	combined, err := verify([]operations.ManifestEntry{same, changed, resized, extra}, false)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"= changed", "= extra", "= resized", "= same"}, combined)
+
+	changed.Hashes["md5"] = "00000000000000000000000000000000"
+	resized.Size = 7
+	missing := operations.ManifestEntry{Path: "missing", Size: 3}
+	entries := []operations.ManifestEntry{same, changed, resized, missing}
+
+	combined, err = verify(entries, false)
+	assert.Error(t, err)
+	assert.Equal(t, []string{"* changed", "* resized", "+ missing", "- extra", "= same"}, combined)
+
+	combined, err = verify(entries, true)
+	assert.Error(t, err)
+	assert.Equal(t, []string{"* changed", "* resized", "+ missing", "= same"}, combined)
+}
+
+func TestVerifyManifest(t *testing.T) {
+	testVerifyManifest(t, false)
+}
+
+func TestVerifyManifestDownload(t *testing.T) {
+	testVerifyManifest(t, true)
+}
@@ -26,10 +26,14 @@ func NewPacer(ctx context.Context, c pacer.Calculator) *Pacer {
 	if retries <= 0 {
 		retries = 1
 	}
+	maxConnections := ci.Checkers + ci.Transfers
+	if ci.MaxConnections > 0 && maxConnections > ci.MaxConnections {
+		maxConnections = ci.MaxConnections
+	}
 	p := &Pacer{
 		Pacer: pacer.New(
 			pacer.InvokerOption(pacerInvoker),
-			pacer.MaxConnectionsOption(ci.Checkers+ci.Transfers),
+			pacer.MaxConnectionsOption(maxConnections),
 			pacer.RetriesOption(retries),
 			pacer.CalculatorOption(c),
 		),
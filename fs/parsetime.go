@@ -0,0 +1,107 @@
+package fs
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Time is a time.Time with some more parsing options
+type Time time.Time
+
+// Turn Time into a string
+func (t Time) String() string {
+	if !t.IsSet() {
+		return "off"
+	}
+	return time.Time(t).Format(time.RFC3339Nano)
+}
+
+// IsSet returns if the time is not zero
+func (t Time) IsSet() bool {
+	return !time.Time(t).IsZero()
+}
+
+// parse the date as a time in various date formats
+func parseTimeDates(date string) (t time.Time, err error) {
+	for _, timeFormat := range timeFormats {
+		t, err = time.Parse(timeFormat, date)
+		if err == nil {
+			return t, nil
+		}
+	}
+	return t, err
+}
+
+// parseTimeFromNow parses a time or duration string. Allows ParseTime
+// to use the current time and easier testing within the fs package.
+func parseTimeFromNow(date string, getNow func() time.Time) (t time.Time, err error) {
+	if date == "off" {
+		return time.Time{}, nil
+	}
+
+	// Attempt to parse as a date first
+	t, err = parseTimeDates(date)
+	if err == nil {
+		return t, nil
+	}
+
+	// Otherwise parse as a duration before now
+	d, err := time.ParseDuration(date)
+	if err == nil {
+		return getNow().Add(-d), nil
+	}
+
+	d, err = parseDurationSuffixes(date)
+	if err == nil {
+		return getNow().Add(-d), nil
+	}
+
+	return t, fmt.Errorf("can't parse %q as a date or a duration before now", date)
+}
+
+// ParseTime parses a time or duration string as a Time. Durations, eg
+// "1h" or "100d", are taken as that long before now.
+func ParseTime(date string) (time.Time, error) {
+	return parseTimeFromNow(date, time.Now)
+}
+
+// Set a Time
+func (t *Time) Set(s string) error {
+	parsedTime, err := ParseTime(s)
+	if err != nil {
+		return err
+	}
+	*t = Time(parsedTime)
+	return nil
+}
+
+// Type of the value
+func (t Time) Type() string {
+	return "Time"
+}
+
+// UnmarshalJSON makes sure the value can be parsed as a string in JSON
+func (t *Time) UnmarshalJSON(in []byte) error {
+	var s string
+	err := json.Unmarshal(in, &s)
+	if err != nil {
+		return err
+	}
+	return t.Set(s)
+}
+
+// MarshalJSON marshals as a time.Time value
+func (t Time) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Time(t))
+}
+
+// Scan implements the fmt.Scanner interface
+func (t *Time) Scan(s fmt.ScanState, ch rune) error {
+	token, err := s.Token(true, func(r rune) bool { return r != '\n' })
+	if err != nil {
+		return err
+	}
+	return t.Set(strings.TrimSpace(string(token)))
+}
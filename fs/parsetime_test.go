@@ -0,0 +1,107 @@
+package fs
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Check it satisfies the interface
+var _ flagger = (*Time)(nil)
+
+func TestParseTime(t *testing.T) {
+	now := time.Date(2020, 9, 5, 8, 15, 5, 250, time.UTC)
+	getNow := func() time.Time {
+		return now
+	}
+
+	for _, test := range []struct {
+		in   string
+		want time.Time
+		err  bool
+	}{
+		{"", time.Time{}, true},
+		{"off", time.Time{}, false},
+		{"0", now, false},
+		{"1ms", now.Add(-time.Millisecond), false},
+		{"1h2m3s", now.Add(-(time.Hour + 2*time.Minute + 3*time.Second)), false},
+		{"1d", now.Add(-24 * time.Hour), false},
+		{"1.5y", now.Add(-24 * 365 * 3 / 2 * time.Hour), false},
+		{"-1s", now.Add(time.Second), false},
+		{"1x", time.Time{}, true},
+		{"2001-02-03", time.Date(2001, 2, 3, 0, 0, 0, 0, time.UTC), false},
+		{"2001-02-03 10:11:12", time.Date(2001, 2, 3, 10, 11, 12, 0, time.UTC), false},
+		{"2001-02-03T10:11:12", time.Date(2001, 2, 3, 10, 11, 12, 0, time.UTC), false},
+		{"2001-02-03T10:11:12.123Z", time.Date(2001, 2, 3, 10, 11, 12, 123000000, time.UTC), false},
+		{"2001-02-03T10:11:12+01:00", time.Date(2001, 2, 3, 9, 11, 12, 0, time.UTC), false},
+	} {
+		parsedTime, err := parseTimeFromNow(test.in, getNow)
+		if test.err {
+			require.Error(t, err, test.in)
+		} else {
+			require.NoError(t, err, test.in)
+		}
+		assert.True(t, test.want.Equal(parsedTime), "%v: want %v got %v", test.in, test.want, parsedTime)
+	}
+}
+
+func TestTimeString(t *testing.T) {
+	for _, test := range []struct {
+		in   time.Time
+		want string
+	}{
+		{time.Time{}, "off"},
+		{time.Date(2001, 2, 3, 10, 11, 12, 0, time.UTC), "2001-02-03T10:11:12Z"},
+		{time.Date(2001, 2, 3, 10, 11, 12, 123000000, time.UTC), "2001-02-03T10:11:12.123Z"},
+	} {
+		got := Time(test.in).String()
+		assert.Equal(t, test.want, got)
+		// Test the reverse
+		var reverse Time
+		require.NoError(t, reverse.Set(got))
+		assert.True(t, test.in.Equal(time.Time(reverse)), test.want)
+	}
+}
+
+func TestTimeScan(t *testing.T) {
+	var v Time
+	n, err := fmt.Sscan(" 2001-02-03 10:11:12 ", &v)
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+	assert.True(t, time.Date(2001, 2, 3, 10, 11, 12, 0, time.UTC).Equal(time.Time(v)))
+}
+
+func TestTimeUnmarshalJSON(t *testing.T) {
+	for _, test := range []struct {
+		in   string
+		want time.Time
+		err  bool
+	}{
+		{`"off"`, time.Time{}, false},
+		{`"2001-02-03"`, time.Date(2001, 2, 3, 0, 0, 0, 0, time.UTC), false},
+		{`"2001-02-03T10:11:12Z"`, time.Date(2001, 2, 3, 10, 11, 12, 0, time.UTC), false},
+		{`"1x"`, time.Time{}, true},
+		{`1`, time.Time{}, true},
+	} {
+		var parsedTime Time
+		err := json.Unmarshal([]byte(test.in), &parsedTime)
+		if test.err {
+			require.Error(t, err, test.in)
+		} else {
+			require.NoError(t, err, test.in)
+		}
+		assert.True(t, test.want.Equal(time.Time(parsedTime)), test.in)
+	}
+
+	// Check it round trips through MarshalJSON
+	in := Time(time.Date(2001, 2, 3, 10, 11, 12, 0, time.UTC))
+	b, err := json.Marshal(in)
+	require.NoError(t, err)
+	var out Time
+	require.NoError(t, json.Unmarshal(b, &out))
+	assert.True(t, time.Time(in).Equal(time.Time(out)))
+}
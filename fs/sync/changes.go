@@ -0,0 +1,126 @@
+package sync
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fs/filter"
+)
+
+var (
+	// changesBatchSize is the maximum number of changed paths synced
+	// in one go
+	changesBatchSize = 1000
+	// changesBatchDelay is how long to wait for more changed paths
+	// before syncing the ones already read
+	changesBatchDelay = time.Second
+)
+
+// readChanges reads changed paths from in, one per line, and sends
+// them to out, closing it when in is exhausted. Any read error is
+// sent to errs.
+func readChanges(ctx context.Context, in io.Reader, out chan<- string, errs chan<- error) {
+	defer close(out)
+	scanner := bufio.NewScanner(in)
+	for scanner.Scan() {
+		remote := strings.Trim(strings.TrimRight(scanner.Text(), "\r"), "/")
+		if remote == "" {
+			continue
+		}
+		select {
+		case out <- remote:
+		case <-ctx.Done():
+			return
+		}
+	}
+	errs <- scanner.Err()
+}
+
+// nextChanges returns the next batch of changed paths from changes
+// with duplicates removed. It waits for the first path then collects
+// any more which arrive within changesBatchDelay of the previous one,
+// up to changesBatchSize paths. It returns false if there are no more
+// changes.
+func nextChanges(ctx context.Context, changes <-chan string) (batch []string, more bool) {
+	seen := make(map[string]struct{})
+	add := func(remote string) {
+		if _, found := seen[remote]; !found {
+			seen[remote] = struct{}{}
+			batch = append(batch, remote)
+		}
+	}
+	select {
+	case remote, ok := <-changes:
+		if !ok {
+			return nil, false
+		}
+		add(remote)
+	case <-ctx.Done():
+		return nil, false
+	}
+	timer := time.NewTimer(changesBatchDelay)
+	defer timer.Stop()
+	for len(batch) < changesBatchSize {
+		select {
+		case remote, ok := <-changes:
+			if !ok {
+				return batch, false
+			}
+			add(remote)
+			if !timer.Stop() {
+				<-timer.C
+			}
+			timer.Reset(changesBatchDelay)
+		case <-timer.C:
+			return batch, true
+		case <-ctx.Done():
+			return batch, false
+		}
+	}
+	return batch, true
+}
+
+// SyncChanges syncs fsrc into fdst like Sync but only the paths read
+// from in, which should be a stream of changed paths relative to the
+// root of fsrc, one per line.
+//
+// Paths are synced in batches as they arrive so in may be a stream
+// which doesn't end, eg from a change notification feed. A changed
+// path which no longer exists in fsrc is deleted from fdst.
+//
+// If syncing a batch fails the error is logged and the next batch is
+// synced. The last error is returned when in is exhausted.
+func SyncChanges(ctx context.Context, fdst, fsrc fs.Fs, in io.Reader, copyEmptySrcDirs bool) (err error) {
+	changes := make(chan string, changesBatchSize)
+	readErr := make(chan error, 1)
+	go readChanges(ctx, in, changes, readErr)
+	fi := filter.GetConfig(ctx)
+	for {
+		batch, more := nextChanges(ctx, changes)
+		if len(batch) > 0 {
+			fs.Infof(fdst, "Syncing %d changed paths", len(batch))
+			// OnlyFiles turns off --delete-excluded so the paths
+			// which aren't in the batch aren't deleted
+			batchCtx := filter.ReplaceConfig(ctx, fi.OnlyFiles(batch))
+			syncErr := Sync(batchCtx, fdst, fsrc, copyEmptySrcDirs)
+			if syncErr != nil {
+				fs.Errorf(fdst, "Failed to sync changed paths: %v", syncErr)
+				err = syncErr
+			}
+		}
+		if !more {
+			break
+		}
+	}
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	if scanErr := <-readErr; scanErr != nil {
+		return scanErr
+	}
+	return err
+}
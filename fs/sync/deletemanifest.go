@@ -0,0 +1,98 @@
+package sync
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fs/accounting"
+	"github.com/rclone/rclone/fs/fserrors"
+	"github.com/rclone/rclone/fs/operations"
+)
+
+// deleteFilesWithManifest deletes the files in the dstFiles map like
+// deleteFiles but writes the files it is about to delete to the
+// --delete-manifest file first.
+//
+// If the manifest can't be written then nothing is deleted.
+func (s *syncCopyMove) deleteFilesWithManifest(checkSrcMap bool) error {
+	toDelete := make([]fs.Object, 0, len(s.dstFiles))
+	for remote, o := range s.dstFiles {
+		if checkSrcMap {
+			_, exists := s.srcFiles[remote]
+			if exists {
+				continue
+			}
+		}
+		toDelete = append(toDelete, o)
+	}
+	sort.Slice(toDelete, func(i, j int) bool {
+		return toDelete[i].Remote() < toDelete[j].Remote()
+	})
+
+	// Only record the deletions --max-delete will allow
+	var maxDeleteErr error
+	if s.ci.MaxDelete >= 0 {
+		allowed := s.ci.MaxDelete - accounting.Stats(s.ctx).Deletes(0)
+		if allowed < 0 {
+			allowed = 0
+		}
+		if int64(len(toDelete)) > allowed {
+			fs.Errorf(s.fdst, "Only deleting %d of %d files as --max-delete threshold reached", allowed, len(toDelete))
+			toDelete = toDelete[:allowed]
+			maxDeleteErr = fserrors.FatalError(errors.New("--max-delete threshold reached"))
+		}
+	}
+
+	err := writeDeleteManifest(s.ctx, s.ci.DeleteManifest, s.fdst, s.fsrc, toDelete)
+	if err != nil {
+		fs.Errorf(s.fdst, "Not deleting files as failed to write --delete-manifest: %v", err)
+		return fmt.Errorf("failed to write --delete-manifest: %w", err)
+	}
+
+	in := make(fs.ObjectsChan, s.ci.Transfers)
+	go func() {
+		defer close(in)
+		for _, o := range toDelete {
+			if s.aborting() {
+				return
+			}
+			select {
+			case <-s.ctx.Done():
+				return
+			case in <- o:
+			}
+		}
+	}()
+	err = operations.DeleteFilesWithBackupDir(s.ctx, in, s.backupDir)
+	if err != nil {
+		return err
+	}
+	return maxDeleteErr
+}
+
+// writeDeleteManifest writes the objects in toDelete to the file
+// called name as a Plan of deletions.
+func writeDeleteManifest(ctx context.Context, name string, fdst, fsrc fs.Fs, toDelete []fs.Object) (err error) {
+	p := NewPlan(fdst, fsrc)
+	for _, o := range toDelete {
+		err = p.Add(ctx, PlanDelete, o)
+		if err != nil {
+			return fmt.Errorf("%s: %w", o.Remote(), err)
+		}
+	}
+	out, err := os.Create(name)
+	if err != nil {
+		return err
+	}
+	defer fs.CheckClose(out, &err)
+	err = p.Write(out)
+	if err != nil {
+		return err
+	}
+	fs.Infof(fdst, "Wrote %d files to be deleted to --delete-manifest %q", len(toDelete), name)
+	return nil
+}
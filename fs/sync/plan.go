@@ -0,0 +1,275 @@
+package sync
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fs/hash"
+	"github.com/rclone/rclone/fs/operations"
+)
+
+// PlanVersion is the version of the plan format written by Plan.Write
+const PlanVersion = 1
+
+// PlanAction is what a PlanItem does to the destination
+type PlanAction string
+
+// Actions which can be in a Plan
+const (
+	PlanCopy   PlanAction = "copy"   // copy a file which is missing on the destination
+	PlanUpdate PlanAction = "update" // overwrite a file which differs on the destination
+	PlanDelete PlanAction = "delete" // delete a file which is missing on the source
+)
+
+// PlanObject records the state of an object when the plan was made
+type PlanObject struct {
+	Size    int64     `json:"size"`
+	ModTime time.Time `json:"modTime"`
+	Hash    string    `json:"hash,omitempty"`
+}
+
+// PlanItem is a single change in a Plan.
+//
+// The PlanObject describes the source object for copy and update and
+// the destination object for delete. Dst describes the destination
+// object for update. They are checked again before the change is
+// applied.
+type PlanItem struct {
+	Action PlanAction `json:"action"`
+	Path   string     `json:"path"`
+	PlanObject
+	Dst *PlanObject `json:"dst,omitempty"`
+}
+
+// Plan is a machine readable list of changes to make the destination
+// match the source.
+//
+// A Plan is made by rclone check --plan and can be reviewed and then
+// applied with ApplyPlan.
+type Plan struct {
+	Version  int        `json:"version"`
+	Src      string     `json:"src"`
+	Dst      string     `json:"dst"`
+	HashType string     `json:"hashType"`
+	Items    []PlanItem `json:"items"`
+
+	mu       sync.Mutex
+	hashType hash.Type
+	fdst     fs.Fs // destination to read the objects to update from or nil
+}
+
+// NewPlan makes an empty plan for making fdst match fsrc.
+//
+// The plan will record the common hash of fsrc and fdst, if any.
+func NewPlan(fdst, fsrc fs.Fs) *Plan {
+	hashType := fsrc.Hashes().Overlap(fdst.Hashes()).GetOne()
+	return &Plan{
+		Version:  PlanVersion,
+		Src:      fs.ConfigString(fsrc),
+		Dst:      fs.ConfigString(fdst),
+		HashType: hashType.String(),
+		hashType: hashType,
+		fdst:     fdst,
+	}
+}
+
+// newPlanObject records the state of o
+func (p *Plan) newPlanObject(ctx context.Context, o fs.Object) (obj PlanObject, err error) {
+	obj = PlanObject{
+		Size:    o.Size(),
+		ModTime: o.ModTime(ctx),
+	}
+	if p.hashType != hash.None {
+		obj.Hash, err = o.Hash(ctx, p.hashType)
+		if err != nil {
+			return obj, fmt.Errorf("failed to read hash for plan: %w", err)
+		}
+	}
+	return obj, nil
+}
+
+// Add records action on o in the plan.
+//
+// o should be the source object for PlanCopy and PlanUpdate and the
+// destination object for PlanDelete. For PlanUpdate the state of the
+// object to be updated is read from the destination and recorded too.
+//
+// It is safe to call Add from multiple goroutines.
+func (p *Plan) Add(ctx context.Context, action PlanAction, o fs.Object) (err error) {
+	item := PlanItem{
+		Action: action,
+		Path:   o.Remote(),
+	}
+	item.PlanObject, err = p.newPlanObject(ctx, o)
+	if err != nil {
+		return err
+	}
+	if action == PlanUpdate {
+		if p.fdst == nil {
+			return errors.New("can't add an update to a plan which wasn't made with NewPlan")
+		}
+		dst, err := p.fdst.NewObject(ctx, o.Remote())
+		if err != nil {
+			return fmt.Errorf("failed to read destination for plan: %w", err)
+		}
+		dstObj, err := p.newPlanObject(ctx, dst)
+		if err != nil {
+			return err
+		}
+		item.Dst = &dstObj
+	}
+	p.mu.Lock()
+	p.Items = append(p.Items, item)
+	p.mu.Unlock()
+	return nil
+}
+
+// Write writes the plan as JSON to out with the items sorted by path
+func (p *Plan) Write(out io.Writer) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	sort.SliceStable(p.Items, func(i, j int) bool {
+		return p.Items[i].Path < p.Items[j].Path
+	})
+	if p.Items == nil {
+		p.Items = []PlanItem{}
+	}
+	enc := json.NewEncoder(out)
+	enc.SetIndent("", "\t")
+	return enc.Encode(p)
+}
+
+// ReadPlan reads a plan written by Plan.Write
+func ReadPlan(in io.Reader) (*Plan, error) {
+	p := new(Plan)
+	err := json.NewDecoder(in).Decode(p)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read plan: %w", err)
+	}
+	if p.Version != PlanVersion {
+		return nil, fmt.Errorf("unsupported plan version %d - expecting %d", p.Version, PlanVersion)
+	}
+	err = p.hashType.Set(p.HashType)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read plan: %w", err)
+	}
+	for _, item := range p.Items {
+		switch item.Action {
+		case PlanCopy, PlanDelete:
+		case PlanUpdate:
+			if item.Dst == nil {
+				return nil, fmt.Errorf("missing destination for update of %q in plan", item.Path)
+			}
+		default:
+			return nil, fmt.Errorf("unknown action %q for %q in plan", item.Action, item.Path)
+		}
+	}
+	return p, nil
+}
+
+// errPlanChanged is returned when an object no longer matches the plan
+var errPlanChanged = errors.New("changed since the plan was made")
+
+// checkPlanObject checks o still matches what was recorded in item
+func checkPlanObject(ctx context.Context, p *Plan, item *PlanObject, o fs.Object, modifyWindow time.Duration) error {
+	if o.Size() != item.Size {
+		return fmt.Errorf("size %d differs from %d: %w", o.Size(), item.Size, errPlanChanged)
+	}
+	if modifyWindow != fs.ModTimeNotSupported {
+		dt := o.ModTime(ctx).Sub(item.ModTime)
+		if dt >= modifyWindow || dt <= -modifyWindow {
+			return fmt.Errorf("modification time %v differs from %v: %w", o.ModTime(ctx), item.ModTime, errPlanChanged)
+		}
+	}
+	if p.hashType != hash.None && item.Hash != "" {
+		sum, err := o.Hash(ctx, p.hashType)
+		if err != nil {
+			return fmt.Errorf("failed to read hash: %w", err)
+		}
+		if !hash.Equals(sum, item.Hash) {
+			return fmt.Errorf("%v hash %q differs from %q: %w", p.hashType, sum, item.Hash, errPlanChanged)
+		}
+	}
+	return nil
+}
+
+// applyPlanItem applies a single item of the plan
+func applyPlanItem(ctx context.Context, fdst, fsrc fs.Fs, p *Plan, item *PlanItem, modifyWindow time.Duration) error {
+	if item.Action == PlanDelete {
+		dst, err := fdst.NewObject(ctx, item.Path)
+		if err != nil {
+			return err
+		}
+		err = checkPlanObject(ctx, p, &item.PlanObject, dst, modifyWindow)
+		if err != nil {
+			return fmt.Errorf("destination %w", err)
+		}
+		return operations.DeleteFile(ctx, dst)
+	}
+	src, err := fsrc.NewObject(ctx, item.Path)
+	if err != nil {
+		return err
+	}
+	err = checkPlanObject(ctx, p, &item.PlanObject, src, modifyWindow)
+	if err != nil {
+		return fmt.Errorf("source %w", err)
+	}
+	dst, err := fdst.NewObject(ctx, item.Path)
+	switch {
+	case err == fs.ErrorObjectNotFound && item.Action == PlanUpdate:
+		return fmt.Errorf("destination to update no longer exists: %w", errPlanChanged)
+	case err == fs.ErrorObjectNotFound:
+		dst = nil
+	case err != nil:
+		return err
+	case item.Action == PlanCopy:
+		return fmt.Errorf("destination exists but plan expected it to be missing: %w", errPlanChanged)
+	default:
+		err = checkPlanObject(ctx, p, item.Dst, dst, modifyWindow)
+		if err != nil {
+			return fmt.Errorf("destination %w", err)
+		}
+	}
+	_, err = operations.Copy(ctx, fdst, dst, item.Path, src)
+	return err
+}
+
+// ApplyPlan makes exactly the changes recorded in p to fdst, copying
+// from fsrc.
+//
+// Before each change the objects it is based on are checked to see if
+// they still have the size, modification time and hash recorded in
+// the plan. If they don't then that change is skipped with an error.
+//
+// All the items are attempted. If any fail an error is returned at the
+// end.
+func ApplyPlan(ctx context.Context, fdst, fsrc fs.Fs, p *Plan) error {
+	if src := fs.ConfigString(fsrc); src != p.Src {
+		fs.Logf(nil, "Plan was made for source %q but applying it from %q", p.Src, src)
+	}
+	if dst := fs.ConfigString(fdst); dst != p.Dst {
+		fs.Logf(nil, "Plan was made for destination %q but applying it to %q", p.Dst, dst)
+	}
+	modifyWindow := fs.GetModifyWindow(ctx, fsrc, fdst)
+	var failed int
+	for i := range p.Items {
+		item := &p.Items[i]
+		err := applyPlanItem(ctx, fdst, fsrc, p, item, modifyWindow)
+		if err != nil {
+			err = fs.CountError(err)
+			fs.Errorf(item.Path, "Failed to %s: %v", item.Action, err)
+			failed++
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("failed to apply %d of %d plan items", failed, len(p.Items))
+	}
+	return nil
+}
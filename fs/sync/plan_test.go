@@ -0,0 +1,150 @@
+// Test plan making and applying
+
+package sync
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fs/accounting"
+	"github.com/rclone/rclone/fs/operations"
+	"github.com/rclone/rclone/fstest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// makePlan checks r.Fremote against r.Flocal and returns the plan
+// after a round trip through its serialization
+func makePlan(ctx context.Context, t *testing.T, r *fstest.Run) *Plan {
+	plan := NewPlan(r.Fremote, r.Flocal)
+	opt := &operations.CheckOpt{
+		Fsrc: r.Flocal,
+		Fdst: r.Fremote,
+		ReportFn: func(sigil rune, entry fs.DirEntry) {
+			o, ok := entry.(fs.Object)
+			require.True(t, ok)
+			switch sigil {
+			case '+':
+				require.NoError(t, plan.Add(ctx, PlanCopy, o))
+			case '*':
+				require.NoError(t, plan.Add(ctx, PlanUpdate, o))
+			case '-':
+				require.NoError(t, plan.Add(ctx, PlanDelete, o))
+			}
+		},
+	}
+	_ = operations.Check(ctx, opt) // returns an error as there are differences
+	accounting.GlobalStats().ResetErrors()
+
+	var buf bytes.Buffer
+	require.NoError(t, plan.Write(&buf))
+	plan, err := ReadPlan(&buf)
+	require.NoError(t, err)
+	return plan
+}
+
+func TestApplyPlan(t *testing.T) {
+	ctx := context.Background()
+	r := fstest.NewRun(t)
+	defer r.Finalise()
+	file1 := r.WriteFile("copy", "only in source", t1)
+	file2 := r.WriteFile("update", "newer contents", t2)
+	r.WriteObject(ctx, "update", "old", t1)
+	file3 := r.WriteObject(ctx, "delete", "only in destination", t1)
+	file4 := r.WriteBoth(ctx, "same", "same", t1)
+
+	plan := makePlan(ctx, t, r)
+	var actions []PlanAction
+	var paths []string
+	for _, item := range plan.Items {
+		actions = append(actions, item.Action)
+		paths = append(paths, item.Path)
+	}
+	assert.Equal(t, []PlanAction{PlanCopy, PlanDelete, PlanUpdate}, actions)
+	assert.Equal(t, []string{"copy", "delete", "update"}, paths)
+
+	// Nothing should be changed until the plan is applied
+	r.CheckRemoteItems(t, file3, file4, fstest.NewItem("update", "old", t1))
+
+	require.NoError(t, ApplyPlan(ctx, r.Fremote, r.Flocal, plan))
+	r.CheckLocalItems(t, file1, file2, file4)
+	r.CheckRemoteItems(t, file1, file2, file4)
+}
+
+func TestApplyPlanSourceChanged(t *testing.T) {
+	ctx := context.Background()
+	r := fstest.NewRun(t)
+	defer r.Finalise()
+	r.WriteFile("changed", "original", t1)
+	file2 := r.WriteFile("unchanged", "unchanged", t1)
+	r.Mkdir(ctx, r.Fremote)
+
+	plan := makePlan(ctx, t, r)
+	require.Len(t, plan.Items, 2)
+
+	// Change the source after the plan was made
+	file1 := r.WriteFile("changed", "changed after plan", t2)
+
+	err := ApplyPlan(ctx, r.Fremote, r.Flocal, plan)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to apply 1 of 2 plan items")
+	accounting.GlobalStats().ResetErrors()
+
+	// Only the unchanged file should have been copied
+	r.CheckLocalItems(t, file1, file2)
+	r.CheckRemoteItems(t, file2)
+}
+
+func TestApplyPlanDestinationChanged(t *testing.T) {
+	ctx := context.Background()
+	r := fstest.NewRun(t)
+	defer r.Finalise()
+	file1 := r.WriteFile("changed", "newer contents", t2)
+	file2 := r.WriteFile("deleted", "newer contents", t2)
+	file3 := r.WriteFile("unchanged", "newer contents", t2)
+	r.WriteObject(ctx, "changed", "old", t1)
+	file4 := r.WriteObject(ctx, "deleted", "old", t1)
+	r.WriteObject(ctx, "unchanged", "old", t1)
+
+	plan := makePlan(ctx, t, r)
+	require.Len(t, plan.Items, 3)
+	for _, item := range plan.Items {
+		assert.Equal(t, PlanUpdate, item.Action)
+		require.NotNil(t, item.Dst, item.Path)
+		assert.Equal(t, int64(3), item.Dst.Size, item.Path)
+	}
+
+	// Change the destination after the plan was made
+	file5 := r.WriteObject(ctx, "changed", "changed after plan", t3)
+	o, err := r.Fremote.NewObject(ctx, file4.Path)
+	require.NoError(t, err)
+	require.NoError(t, o.Remove(ctx))
+
+	err = ApplyPlan(ctx, r.Fremote, r.Flocal, plan)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to apply 2 of 3 plan items")
+	accounting.GlobalStats().ResetErrors()
+
+	// Only the unchanged file should have been updated
+	r.CheckLocalItems(t, file1, file2, file3)
+	r.CheckRemoteItems(t, file3, file5)
+}
+
+func TestReadPlanErrors(t *testing.T) {
+	for _, test := range []struct {
+		in   string
+		want string
+	}{
+		{`not json`, "failed to read plan"},
+		{`{"version":99}`, "unsupported plan version 99"},
+		{`{"version":1,"hashType":"potato"}`, "Unknown hash type"},
+		{`{"version":1,"hashType":"none","items":[{"action":"eat","path":"a"}]}`, `unknown action "eat"`},
+		{`{"version":1,"hashType":"none","items":[{"action":"update","path":"a"}]}`, `missing destination for update of "a"`},
+	} {
+		_, err := ReadPlan(bytes.NewBufferString(test.in))
+		require.Error(t, err, test.in)
+		assert.Contains(t, err.Error(), test.want, test.in)
+	}
+}
@@ -41,6 +41,7 @@ type syncCopyMove struct {
 	noUnicodeNormalization bool                   // don't normalize unicode characters in filenames
 	deletersWg             sync.WaitGroup         // for delete before go routine
 	deleteFilesCh          chan fs.Object         // channel to receive deletes if delete before
+	collectDeletes         bool                   // set if deletes are collected in dstFiles and done after the march
 	trackRenames           bool                   // set if we should do server-side renames
 	trackRenamesStrategy   trackRenamesStrategy   // strategies used for tracking renames
 	dstFilesMu             sync.Mutex             // protect dstFiles
@@ -53,9 +54,9 @@ type syncCopyMove struct {
 	dstEmptyDirs           map[string]fs.DirEntry // potentially empty directories
 	srcEmptyDirsMu         sync.Mutex             // protect srcEmptyDirs
 	srcEmptyDirs           map[string]fs.DirEntry // potentially empty directories
-	checkerWg              sync.WaitGroup         // wait for checkers
+	checkers               *workers               // the checkers
 	toBeChecked            *pipe                  // checkers channel
-	transfersWg            sync.WaitGroup         // wait for transfers
+	transfers              *workers               // the transfers
 	toBeUploaded           *pipe                  // copiers channel
 	errorMu                sync.Mutex             // Mutex covering the errors variables
 	err                    error                  // normal error from copy process
@@ -65,7 +66,7 @@ type syncCopyMove struct {
 	modifyWindow           time.Duration          // modify window between fsrc, fdst
 	renameMapMu            sync.Mutex             // mutex to protect the below
 	renameMap              map[string][]fs.Object // dst files by hash - only used by trackRenames
-	renamerWg              sync.WaitGroup         // wait for renamers
+	renamers               *workers               // the renamers
 	toBeRenamed            *pipe                  // renamers channel
 	trackRenamesWg         sync.WaitGroup         // wg for background track renames
 	trackRenamesCh         chan fs.Object         // objects are pumped in here
@@ -73,6 +74,10 @@ type syncCopyMove struct {
 	compareCopyDest        []fs.Fs                // place to check for files to server side copy
 	backupDir              fs.Fs                  // place to store overwrites/deletes
 	checkFirst             bool                   // if set run all the checkers before starting transfers
+	failFastDone           chan struct{}          // closed to stop watching for errors with --fail-fast
+	failFastWg             sync.WaitGroup         // wg for the --fail-fast watcher
+	failFastErrors         int64                  // number of errors when the checks started
+	protectDest            *filter.Filter         // dst paths matching this must not be changed - may be nil
 }
 
 type trackRenamesStrategy byte
@@ -101,6 +106,14 @@ func newSyncCopyMove(ctx context.Context, fdst, fsrc fs.Fs, deleteMode fs.Delete
 	}
 	ci := fs.GetConfig(ctx)
 	fi := filter.GetConfig(ctx)
+	if ci.FailFast && !ci.CheckFirst {
+		return nil, fserrors.FatalError(errors.New("--fail-fast needs --check-first"))
+	}
+	if deleteMode != fs.DeleteModeOff && fi.HaveMetadataRules() && !fi.Opt.DeleteExcluded {
+		// the metadata rules only apply to the source so the files
+		// they exclude would be deleted from the destination
+		return nil, fserrors.FatalError(errors.New("can't use --metadata-include or --metadata-exclude with sync unless --delete-excluded is set"))
+	}
 	s := &syncCopyMove{
 		ci:                     ci,
 		fi:                     fi,
@@ -164,6 +177,16 @@ func newSyncCopyMove(ctx context.Context, fdst, fsrc fs.Fs, deleteMode fs.Delete
 	if err != nil {
 		return nil, err
 	}
+	if len(ci.ProtectDestFrom) > 0 {
+		// The protect files use the same syntax as --exclude-from
+		// so anything they exclude is protected
+		opt := filter.DefaultOpt
+		opt.ExcludeFrom = ci.ProtectDestFrom
+		s.protectDest, err = filter.NewFilter(&opt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read --protect-dest-from: %w", err)
+		}
+	}
 	if s.noCheckDest {
 		if s.deleteMode != fs.DeleteModeOff {
 			return nil, errors.New("can't use --no-check-dest with sync: use copy instead")
@@ -206,6 +229,11 @@ func newSyncCopyMove(ctx context.Context, fdst, fsrc fs.Fs, deleteMode fs.Delete
 			s.noTraverse = false
 		}
 	}
+	if ci.DeleteManifest != "" && s.deleteMode == fs.DeleteModeDuring {
+		// --delete-manifest needs all the deletions before any are done
+		s.deleteMode = fs.DeleteModeAfter
+	}
+	s.collectDeletes = s.deleteMode == fs.DeleteModeAfter || (ci.DeleteManifest != "" && s.deleteMode == fs.DeleteModeOnly)
 	// Make Fs for --backup-dir if required
 	if ci.BackupDir != "" || ci.Suffix != "" {
 		var err error
@@ -253,6 +281,69 @@ outer:
 	s.srcFilesResult <- nil
 }
 
+// failFastInterval is how often the errors are checked while checking
+// with --fail-fast
+var failFastInterval = 100 * time.Millisecond
+
+// errFailFast is returned when the sync is aborted with --fail-fast
+var errFailFast = errors.New("not transferring anything as --fail-fast is set")
+
+// startFailFast starts watching for errors while checking with
+// --check-first and --fail-fast so the sync can be aborted on the
+// first one before anything is transferred.
+//
+// Almost all the errors found while checking are only counted in the
+// stats so it watches the count of errors.
+func (s *syncCopyMove) startFailFast() {
+	if !s.checkFirst || !s.ci.FailFast {
+		return
+	}
+	s.failFastErrors = accounting.Stats(s.ctx).GetErrors()
+	s.failFastDone = make(chan struct{})
+	s.failFastWg.Add(1)
+	go func() {
+		defer s.failFastWg.Done()
+		ticker := time.NewTicker(failFastInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-s.failFastDone:
+				return
+			case <-s.ctx.Done():
+				return
+			case <-ticker.C:
+				if s.checkFailFast() {
+					return
+				}
+			}
+		}
+	}()
+}
+
+// checkFailFast aborts the sync if errors have been counted since
+// startFailFast, returning true if it did
+func (s *syncCopyMove) checkFailFast() bool {
+	errs := accounting.Stats(s.ctx).GetErrors() - s.failFastErrors
+	if errs <= 0 {
+		return false
+	}
+	s.processError(fserrors.FatalError(fmt.Errorf("found %d error(s) while checking: %w", errs, errFailFast)))
+	return true
+}
+
+// stopFailFast stops watching for errors, checking them one last time
+// now the checks have finished
+func (s *syncCopyMove) stopFailFast() {
+	if s.failFastDone == nil {
+		return
+	}
+	close(s.failFastDone)
+	s.failFastWg.Wait()
+	if !s.aborting() {
+		s.checkFailFast()
+	}
+}
+
 // This checks the types of errors returned while copying files
 func (s *syncCopyMove) processError(err error) {
 	if err == nil {
@@ -304,11 +395,12 @@ func (s *syncCopyMove) currentError() error {
 
 // pairChecker reads Objects~s on in send to out if they need transferring.
 //
+// It stops when ctx is cancelled or in is closed.
+//
 // FIXME potentially doing lots of hashes at once
-func (s *syncCopyMove) pairChecker(in *pipe, out *pipe, fraction int, wg *sync.WaitGroup) {
-	defer wg.Done()
+func (s *syncCopyMove) pairChecker(ctx context.Context, in *pipe, out *pipe, fraction int) {
 	for {
-		pair, ok := in.GetMax(s.inCtx, fraction)
+		pair, ok := in.GetMax(ctx, fraction)
 		if !ok {
 			return
 		}
@@ -323,7 +415,8 @@ func (s *syncCopyMove) pairChecker(in *pipe, out *pipe, fraction int, wg *sync.W
 			}
 			if !NoNeedTransfer && operations.NeedTransfer(s.ctx, pair.Dst, pair.Src) {
 				// If files are treated as immutable, fail if destination exists and does not match
-				if s.ci.Immutable && pair.Dst != nil {
+				// unless the existing file can be preserved in --backup-dir
+				if s.ci.Immutable && pair.Dst != nil && s.backupDir == nil {
 					err := fs.CountError(fserrors.NoRetryError(fs.ErrorImmutableModified))
 					fs.Errorf(pair.Dst, "Source and destination exist but do not match: %v", err)
 					s.processError(err)
@@ -368,10 +461,11 @@ func (s *syncCopyMove) pairChecker(in *pipe, out *pipe, fraction int, wg *sync.W
 
 // pairRenamer reads Objects~s on in and attempts to rename them,
 // otherwise it sends them out if they need transferring.
-func (s *syncCopyMove) pairRenamer(in *pipe, out *pipe, fraction int, wg *sync.WaitGroup) {
-	defer wg.Done()
+//
+// It stops when ctx is cancelled or in is closed.
+func (s *syncCopyMove) pairRenamer(ctx context.Context, in *pipe, out *pipe, fraction int) {
 	for {
-		pair, ok := in.GetMax(s.inCtx, fraction)
+		pair, ok := in.GetMax(ctx, fraction)
 		if !ok {
 			return
 		}
@@ -387,19 +481,25 @@ func (s *syncCopyMove) pairRenamer(in *pipe, out *pipe, fraction int, wg *sync.W
 }
 
 // pairCopyOrMove reads Objects on in and moves or copies them.
-func (s *syncCopyMove) pairCopyOrMove(ctx context.Context, in *pipe, fdst fs.Fs, fraction int, wg *sync.WaitGroup) {
-	defer wg.Done()
+//
+// It stops when ctx is cancelled or in is closed.
+func (s *syncCopyMove) pairCopyOrMove(ctx context.Context, in *pipe, fdst fs.Fs, fraction int) {
 	var err error
 	for {
-		pair, ok := in.GetMax(s.inCtx, fraction)
+		pair, ok := in.GetMax(ctx, fraction)
 		if !ok {
 			return
 		}
+		err = accounting.TransferPause.Wait(s.inCtx)
+		if err != nil {
+			s.processError(err)
+			continue
+		}
 		src := pair.Src
 		if s.DoMove {
-			_, err = operations.Move(ctx, fdst, pair.Dst, src.Remote(), src)
+			_, err = operations.Move(s.ctx, fdst, pair.Dst, src.Remote(), src)
 		} else {
-			_, err = operations.Copy(ctx, fdst, pair.Dst, src.Remote(), src)
+			_, err = operations.Copy(s.ctx, fdst, pair.Dst, src.Remote(), src)
 		}
 		s.processError(err)
 	}
@@ -407,34 +507,30 @@ func (s *syncCopyMove) pairCopyOrMove(ctx context.Context, in *pipe, fdst fs.Fs,
 
 // This starts the background checkers.
 func (s *syncCopyMove) startCheckers() {
-	s.checkerWg.Add(s.ci.Checkers)
-	for i := 0; i < s.ci.Checkers; i++ {
-		fraction := (100 * i) / s.ci.Checkers
-		go s.pairChecker(s.toBeChecked, s.toBeUploaded, fraction, &s.checkerWg)
-	}
+	s.checkers = startWorkers(s.inCtx, workersCheckers, s.ci.Checkers, func(ctx context.Context, fraction int) {
+		s.pairChecker(ctx, s.toBeChecked, s.toBeUploaded, fraction)
+	})
 }
 
 // This stops the background checkers
 func (s *syncCopyMove) stopCheckers() {
 	s.toBeChecked.Close()
 	fs.Debugf(s.fdst, "Waiting for checks to finish")
-	s.checkerWg.Wait()
+	s.checkers.wait()
 }
 
 // This starts the background transfers
 func (s *syncCopyMove) startTransfers() {
-	s.transfersWg.Add(s.ci.Transfers)
-	for i := 0; i < s.ci.Transfers; i++ {
-		fraction := (100 * i) / s.ci.Transfers
-		go s.pairCopyOrMove(s.ctx, s.toBeUploaded, s.fdst, fraction, &s.transfersWg)
-	}
+	s.transfers = startWorkers(s.inCtx, workersTransfers, s.ci.Transfers, func(ctx context.Context, fraction int) {
+		s.pairCopyOrMove(ctx, s.toBeUploaded, s.fdst, fraction)
+	})
 }
 
 // This stops the background transfers
 func (s *syncCopyMove) stopTransfers() {
 	s.toBeUploaded.Close()
 	fs.Debugf(s.fdst, "Waiting for transfers to finish")
-	s.transfersWg.Wait()
+	s.transfers.wait()
 }
 
 // This starts the background renamers.
@@ -442,11 +538,9 @@ func (s *syncCopyMove) startRenamers() {
 	if !s.trackRenames {
 		return
 	}
-	s.renamerWg.Add(s.ci.Checkers)
-	for i := 0; i < s.ci.Checkers; i++ {
-		fraction := (100 * i) / s.ci.Checkers
-		go s.pairRenamer(s.toBeRenamed, s.toBeUploaded, fraction, &s.renamerWg)
-	}
+	s.renamers = startWorkers(s.inCtx, workersCheckers, s.ci.Checkers, func(ctx context.Context, fraction int) {
+		s.pairRenamer(ctx, s.toBeRenamed, s.toBeUploaded, fraction)
+	})
 }
 
 // This stops the background renamers
@@ -456,7 +550,7 @@ func (s *syncCopyMove) stopRenamers() {
 	}
 	s.toBeRenamed.Close()
 	fs.Debugf(s.fdst, "Waiting for renames to finish")
-	s.renamerWg.Wait()
+	s.renamers.wait()
 }
 
 // This starts the collection of possible renames
@@ -484,7 +578,7 @@ func (s *syncCopyMove) stopTrackRenames() {
 
 // This starts the background deletion of files for --delete-during
 func (s *syncCopyMove) startDeleters() {
-	if s.deleteMode != fs.DeleteModeDuring && s.deleteMode != fs.DeleteModeOnly {
+	if (s.deleteMode != fs.DeleteModeDuring && s.deleteMode != fs.DeleteModeOnly) || s.collectDeletes {
 		return
 	}
 	s.deletersWg.Add(1)
@@ -497,7 +591,7 @@ func (s *syncCopyMove) startDeleters() {
 
 // This stops the background deleters
 func (s *syncCopyMove) stopDeleters() {
-	if s.deleteMode != fs.DeleteModeDuring && s.deleteMode != fs.DeleteModeOnly {
+	if (s.deleteMode != fs.DeleteModeDuring && s.deleteMode != fs.DeleteModeOnly) || s.collectDeletes {
 		return
 	}
 	close(s.deleteFilesCh)
@@ -512,8 +606,12 @@ func (s *syncCopyMove) stopDeleters() {
 func (s *syncCopyMove) deleteFiles(checkSrcMap bool) error {
 	if accounting.Stats(s.ctx).Errored() && !s.ci.IgnoreErrors {
 		fs.Errorf(s.fdst, "%v", fs.ErrorNotDeleting)
+		accounting.Stats(s.ctx).SkippedDeletes()
 		return fs.ErrorNotDeleting
 	}
+	if s.ci.DeleteManifest != "" {
+		return s.deleteFilesWithManifest(checkSrcMap)
+	}
 
 	// Delete the spare files
 	toDelete := make(fs.ObjectsChan, s.ci.Transfers)
@@ -548,6 +646,7 @@ func (s *syncCopyMove) deleteEmptyDirectories(ctx context.Context, f fs.Fs, entr
 	}
 	if accounting.Stats(ctx).Errored() && !s.ci.IgnoreErrors {
 		fs.Errorf(f, "%v", fs.ErrorNotDeletingDirs)
+		accounting.Stats(ctx).SkippedDeletes()
 		return fs.ErrorNotDeletingDirs
 	}
 
@@ -584,6 +683,22 @@ func (s *syncCopyMove) deleteEmptyDirectories(ctx context.Context, f fs.Fs, entr
 	return nil
 }
 
+// dirsBelow returns the entries whose depth is more than depth, where
+// a directory in the root has a depth of 1. If depth is 0 then all the
+// entries are returned.
+func dirsBelow(entries map[string]fs.DirEntry, depth int) map[string]fs.DirEntry {
+	if depth <= 0 {
+		return entries
+	}
+	below := make(map[string]fs.DirEntry, len(entries))
+	for remote, entry := range entries {
+		if strings.Count(remote, "/")+1 > depth {
+			below[remote] = entry
+		}
+	}
+	return below
+}
+
 // This copies the empty directories in the slice passed in and logs
 // any errors copying the directories
 func copyEmptyDirectories(ctx context.Context, f fs.Fs, entries map[string]fs.DirEntry) error {
@@ -829,6 +944,7 @@ func (s *syncCopyMove) run() error {
 		s.startTransfers()
 	}
 	s.startDeleters()
+	s.startFailFast()
 	s.dstFiles = make(map[string]fs.Object)
 
 	s.startTrackRenames()
@@ -863,7 +979,13 @@ func (s *syncCopyMove) run() error {
 	// Stop background checking and transferring pipeline
 	s.stopCheckers()
 	if s.checkFirst {
-		fs.Infof(s.fdst, "Checks finished, now starting transfers")
+		s.stopFailFast()
+		if s.aborting() {
+			// the transfers stop straight away as the context is cancelled
+			fs.Infof(s.fdst, "Checks finished, aborting so not transferring anything")
+		} else {
+			fs.Infof(s.fdst, "Checks finished, now starting transfers")
+		}
 		s.startTransfers()
 	}
 	s.stopRenamers()
@@ -875,9 +997,10 @@ func (s *syncCopyMove) run() error {
 	}
 
 	// Delete files after
-	if s.deleteMode == fs.DeleteModeAfter {
+	if s.collectDeletes {
 		if s.currentError() != nil && !s.ci.IgnoreErrors {
 			fs.Errorf(s.fdst, "%v", fs.ErrorNotDeleting)
+			accounting.Stats(s.ctx).SkippedDeletes()
 		} else {
 			s.processError(s.deleteFiles(false))
 		}
@@ -887,6 +1010,7 @@ func (s *syncCopyMove) run() error {
 	if s.deleteMode != fs.DeleteModeOff {
 		if s.currentError() != nil && !s.ci.IgnoreErrors {
 			fs.Errorf(s.fdst, "%v", fs.ErrorNotDeletingDirs)
+			accounting.Stats(s.ctx).SkippedDeletes()
 		} else {
 			s.processError(s.deleteEmptyDirectories(s.ctx, s.fdst, s.dstEmptyDirs))
 		}
@@ -896,7 +1020,7 @@ func (s *syncCopyMove) run() error {
 	// if DoMove and --delete-empty-src-dirs flag is set
 	if s.DoMove && s.deleteEmptySrcDirs {
 		// delete empty subdirectories that were part of the move
-		s.processError(s.deleteEmptyDirectories(s.ctx, s.fsrc, s.srcEmptyDirs))
+		s.processError(s.deleteEmptyDirectories(s.ctx, s.fsrc, dirsBelow(s.srcEmptyDirs, s.ci.DeleteSrcDirsBelow)))
 	}
 
 	// Read the error out of the context if there is one
@@ -912,6 +1036,30 @@ func (s *syncCopyMove) run() error {
 	return s.currentError()
 }
 
+// isProtected returns true if the destination path remote matches
+// --protect-dest-from, logging that action is being skipped.
+func (s *syncCopyMove) isProtected(remote string, isDir bool, action string) bool {
+	if s.protectDest == nil {
+		return false
+	}
+	protected := !isDir && !s.protectDest.IncludeRemote(remote)
+	// Anything inside a protected directory is protected too
+	includeDir := s.protectDest.IncludeDirectory(s.ctx, nil)
+	dir := remote
+	if !isDir {
+		dir = path.Dir(remote)
+	}
+	for !protected && dir != "." && dir != "" {
+		include, err := includeDir(dir)
+		protected = err == nil && !include
+		dir = path.Dir(dir)
+	}
+	if protected {
+		fs.Infof(remote, "Not %s as destination is protected by --protect-dest-from", action)
+	}
+	return protected
+}
+
 // DstOnly have an object which is in the destination only
 func (s *syncCopyMove) DstOnly(dst fs.DirEntry) (recurse bool) {
 	if s.deleteMode == fs.DeleteModeOff {
@@ -919,13 +1067,16 @@ func (s *syncCopyMove) DstOnly(dst fs.DirEntry) (recurse bool) {
 	}
 	switch x := dst.(type) {
 	case fs.Object:
-		switch s.deleteMode {
-		case fs.DeleteModeAfter:
+		if s.isProtected(x.Remote(), false, "deleting") {
+			return false
+		}
+		switch {
+		case s.collectDeletes:
 			// record object as needs deleting
 			s.dstFilesMu.Lock()
 			s.dstFiles[x.Remote()] = x
 			s.dstFilesMu.Unlock()
-		case fs.DeleteModeDuring, fs.DeleteModeOnly:
+		case s.deleteMode == fs.DeleteModeDuring, s.deleteMode == fs.DeleteModeOnly:
 			select {
 			case <-s.ctx.Done():
 				return
@@ -937,7 +1088,7 @@ func (s *syncCopyMove) DstOnly(dst fs.DirEntry) (recurse bool) {
 	case fs.Directory:
 		// Do the same thing to the entire contents of the directory
 		// Record directory as it is potentially empty and needs deleting
-		if s.fdst.Features().CanHaveEmptyDirectories {
+		if s.fdst.Features().CanHaveEmptyDirectories && !s.isProtected(dst.Remote(), true, "removing directory") {
 			s.dstEmptyDirsMu.Lock()
 			s.dstEmptyDirs[dst.Remote()] = dst
 			s.dstEmptyDirsMu.Unlock()
@@ -964,6 +1115,9 @@ func (s *syncCopyMove) SrcOnly(src fs.DirEntry) (recurse bool) {
 		s.srcParentDirCheck(src)
 		s.srcEmptyDirsMu.Unlock()
 
+		if s.isProtected(x.Remote(), false, "copying") {
+			return false
+		}
 		if s.trackRenames {
 			// Save object to check for a rename later
 			select {
@@ -1012,6 +1166,9 @@ func (s *syncCopyMove) Match(ctx context.Context, dst, src fs.DirEntry) (recurse
 		}
 		dstX, ok := dst.(fs.Object)
 		if ok {
+			if s.isProtected(dstX.Remote(), false, "updating") {
+				return false
+			}
 			ok = s.toBeChecked.Put(s.ctx, fs.ObjectPair{Src: srcX, Dst: dstX})
 			if !ok {
 				return false
@@ -1107,8 +1264,10 @@ func MoveDir(ctx context.Context, fdst, fsrc fs.Fs, deleteEmptySrcDirs bool, cop
 		return nil
 	}
 
-	// First attempt to use DirMover if exists, same Fs and no filters are active
-	if fdstDirMove := fdst.Features().DirMove; fdstDirMove != nil && operations.SameConfig(fsrc, fdst) && fi.InActive() {
+	// First attempt to use DirMover if exists, same Fs and no filters are active.
+	// This removes all the source directories so can't be used if some must be kept.
+	keepSrcDirs := deleteEmptySrcDirs && fs.GetConfig(ctx).DeleteSrcDirsBelow > 0
+	if fdstDirMove := fdst.Features().DirMove; fdstDirMove != nil && operations.SameConfig(fsrc, fdst) && fi.InActive() && !keepSrcDirs {
 		if operations.SkipDestructive(ctx, fdst, "server-side directory move") {
 			return nil
 		}
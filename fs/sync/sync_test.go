@@ -6,6 +6,8 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"os"
+	"path/filepath"
 	"runtime"
 	"strings"
 	"testing"
@@ -19,6 +21,8 @@ import (
 	"github.com/rclone/rclone/fs/hash"
 	"github.com/rclone/rclone/fs/operations"
 	"github.com/rclone/rclone/fstest"
+	"github.com/rclone/rclone/fstest/mockfs"
+	"github.com/rclone/rclone/fstest/mockobject"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"golang.org/x/text/unicode/norm"
@@ -119,6 +123,61 @@ func TestCopyCheckFirst(t *testing.T) {
 	r.CheckRemoteItems(t, file1)
 }
 
+// errHashObject is an object whose hash can't be read
+type errHashObject struct {
+	*mockobject.ContentMockObject
+}
+
+// Hash returns an error
+func (o errHashObject) Hash(ctx context.Context, t hash.Type) (string, error) {
+	return "", errors.New("can't read hash")
+}
+
+// Now with --check-first and --fail-fast
+func TestCopyCheckFirstFailFast(t *testing.T) {
+	ctx := context.Background()
+	ctx, ci := fs.AddConfig(ctx)
+	r := fstest.NewRun(t)
+	defer r.Finalise()
+
+	// the hash of a can't be read so checking it is an error
+	fsrc := mockfs.NewFs(ctx, "mock", "")
+	fsrc.SetHashes(hash.NewHashSet(hash.MD5))
+	a := mockobject.New("a").WithContent(nil, mockobject.SeekModeNone)
+	a.SetFs(fsrc)
+	fsrc.AddObject(errHashObject{a})
+	b := mockobject.New("b").WithContent([]byte("hello"), mockobject.SeekModeNone)
+	b.SetFs(fsrc)
+	fsrc.AddObject(b)
+	fileA := r.WriteObject(ctx, "a", "", t1)
+	ci.CheckSum = true
+	ci.CheckFirst = true
+
+	ci.FailFast = true
+	accounting.GlobalStats().ResetCounters()
+	err := CopyDir(ctx, r.Fremote, fsrc, false)
+	require.Error(t, err)
+	assert.True(t, errors.Is(err, errFailFast))
+	assert.True(t, fserrors.IsFatalError(err))
+	assert.Equal(t, int64(0), accounting.GlobalStats().GetTransfers())
+	r.CheckRemoteItems(t, fileA)
+
+	// without --fail-fast b is transferred
+	ci.FailFast = false
+	accounting.GlobalStats().ResetCounters()
+	err = CopyDir(ctx, r.Fremote, fsrc, false)
+	require.Error(t, err)
+	assert.False(t, fserrors.IsFatalError(err))
+	_, err = r.Fremote.NewObject(ctx, "b")
+	assert.NoError(t, err)
+
+	// --fail-fast needs --check-first
+	ci.FailFast, ci.CheckFirst = true, false
+	err = CopyDir(ctx, r.Fremote, fsrc, false)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "--check-first")
+}
+
 // Now with --no-traverse
 func TestSyncNoTraverse(t *testing.T) {
 	ctx := context.Background()
@@ -822,6 +881,7 @@ func TestSyncAfterRemovingAFileAndAddingAFileSubDirWithErrors(t *testing.T) {
 	_ = fs.CountError(errors.New("boom"))
 	err := Sync(ctx, r.Fremote, r.Flocal, false)
 	assert.Equal(t, fs.ErrorNotDeleting, err)
+	assert.True(t, accounting.GlobalStats().HadSkippedDeletes())
 
 	r.CheckLocalListing(
 		t,
@@ -899,6 +959,88 @@ func TestCopyDeleteBefore(t *testing.T) {
 	r.CheckLocalItems(t, file2)
 }
 
+// Test --delete-manifest records the files to be deleted
+func testSyncDeleteManifest(t *testing.T, deleteMode fs.DeleteMode, dryRun bool) {
+	ctx := context.Background()
+	ctx, ci := fs.AddConfig(ctx)
+	r := fstest.NewRun(t)
+	defer r.Finalise()
+
+	manifest := filepath.Join(t.TempDir(), "manifest.json")
+	ci.DeleteMode = deleteMode
+	ci.DeleteManifest = manifest
+	ci.DryRun = dryRun
+
+	file1 := r.WriteBoth(ctx, "empty space", "-", t2)
+	file2 := r.WriteObject(ctx, "potato", "to be deleted", t1)
+	file3 := r.WriteObject(ctx, "sub dir/potato2", "also to be deleted", t1)
+	r.CheckLocalItems(t, file1)
+	r.CheckRemoteItems(t, file1, file2, file3)
+
+	accounting.GlobalStats().ResetCounters()
+	err := Sync(ctx, r.Fremote, r.Flocal, false)
+	require.NoError(t, err)
+
+	if dryRun {
+		r.CheckRemoteItems(t, file1, file2, file3)
+	} else {
+		r.CheckRemoteItems(t, file1)
+	}
+
+	in, err := os.Open(manifest)
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, in.Close())
+	}()
+	p, err := ReadPlan(in)
+	require.NoError(t, err)
+	require.Equal(t, 2, len(p.Items))
+	for i, file := range []fstest.Item{file2, file3} {
+		item := p.Items[i]
+		assert.Equal(t, PlanDelete, item.Action)
+		assert.Equal(t, file.Path, item.Path)
+		assert.Equal(t, file.Size, item.Size)
+	}
+}
+
+func TestSyncDeleteManifest(t *testing.T) {
+	testSyncDeleteManifest(t, fs.DeleteModeDefault, false)
+}
+
+func TestSyncDeleteManifestDryRun(t *testing.T) {
+	testSyncDeleteManifest(t, fs.DeleteModeDefault, true)
+}
+
+func TestSyncDeleteManifestDeleteBefore(t *testing.T) {
+	testSyncDeleteManifest(t, fs.DeleteModeBefore, false)
+}
+
+func TestSyncDeleteManifestDeleteDuring(t *testing.T) {
+	testSyncDeleteManifest(t, fs.DeleteModeDuring, false)
+}
+
+// Test --delete-manifest doesn't delete anything if the manifest
+// can't be written
+func TestSyncDeleteManifestFailed(t *testing.T) {
+	ctx := context.Background()
+	ctx, ci := fs.AddConfig(ctx)
+	r := fstest.NewRun(t)
+	defer r.Finalise()
+
+	ci.DeleteManifest = filepath.Join(t.TempDir(), "missing", "manifest.json")
+
+	file1 := r.WriteBoth(ctx, "empty space", "-", t2)
+	file2 := r.WriteObject(ctx, "potato", "not to be deleted", t1)
+	r.CheckRemoteItems(t, file1, file2)
+
+	accounting.GlobalStats().ResetCounters()
+	err := Sync(ctx, r.Fremote, r.Flocal, false)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "delete-manifest")
+	r.CheckRemoteItems(t, file1, file2)
+	accounting.GlobalStats().ResetErrors()
+}
+
 // Test with exclude
 func TestSyncWithExclude(t *testing.T) {
 	ctx := context.Background()
@@ -958,6 +1100,93 @@ func TestSyncWithExcludeAndDeleteExcluded(t *testing.T) {
 	r.CheckLocalItems(t, file2)
 }
 
+// Test a sync of only some files, as done by --retry-failed-only,
+// doesn't delete the other files with --delete-excluded
+func TestSyncOnlyFilesWithDeleteExcluded(t *testing.T) {
+	ctx := context.Background()
+	r := fstest.NewRun(t)
+	defer r.Finalise()
+	file1 := r.WriteFile("failed", "new contents", t2)
+	file2 := r.WriteBoth(ctx, "done", "contents", t1)
+	file3 := r.WriteFile("excluded.bak", "excluded", t1)
+	r.WriteObject(ctx, "failed", "old contents", t1)
+	file4 := r.WriteObject(ctx, "excluded.bak", "excluded", t1)
+	file5 := r.WriteObject(ctx, "dst only", "dst only", t1)
+
+	fi, err := filter.NewFilter(nil)
+	require.NoError(t, err)
+	require.NoError(t, fi.AddRule("- *.bak"))
+	fi.Opt.DeleteExcluded = true
+	ctx = filter.ReplaceConfig(ctx, fi.OnlyFiles([]string{"failed"}))
+
+	accounting.GlobalStats().ResetCounters()
+	err = Sync(ctx, r.Fremote, r.Flocal, false)
+	require.NoError(t, err)
+	r.CheckLocalItems(t, file1, file2, file3)
+	r.CheckRemoteItems(t, file1, file2, file4, file5)
+}
+
+// Test the metadata filters leave the destination alone
+func TestSyncWithMetadataFilter(t *testing.T) {
+	ctx := context.Background()
+	r := fstest.NewRun(t)
+	defer r.Finalise()
+	file1 := r.WriteFile("file1", "new contents", t2)
+	file2 := r.WriteObject(ctx, "file2", "dst only", t1)
+
+	// the files have no metadata so are excluded by this
+	opt := filter.DefaultOpt
+	opt.MetadataInclude = []string{"archive=true"}
+	fi, err := filter.NewFilter(&opt)
+	require.NoError(t, err)
+	ctx = filter.ReplaceConfig(ctx, fi)
+
+	// copying doesn't touch the destination
+	accounting.GlobalStats().ResetCounters()
+	err = CopyDir(ctx, r.Fremote, r.Flocal, false)
+	require.NoError(t, err)
+	r.CheckRemoteItems(t, file2)
+
+	// sync would delete the destination files so needs --delete-excluded
+	err = Sync(ctx, r.Fremote, r.Flocal, false)
+	require.Error(t, err)
+	assert.True(t, fserrors.IsFatalError(err))
+	assert.Contains(t, err.Error(), "--delete-excluded")
+	r.CheckRemoteItems(t, file2)
+
+	fi.Opt.DeleteExcluded = true
+	accounting.GlobalStats().ResetCounters()
+	err = Sync(ctx, r.Fremote, r.Flocal, false)
+	require.NoError(t, err)
+	r.CheckLocalItems(t, file1)
+	r.CheckRemoteItems(t)
+}
+
+// Test with --protect-dest-from set
+func TestSyncWithProtectDestFrom(t *testing.T) {
+	ctx := context.Background()
+	ctx, ci := fs.AddConfig(ctx)
+	r := fstest.NewRun(t)
+	defer r.Finalise()
+	file1 := r.WriteFile("keep/file1", "new contents", t2)
+	file2 := r.WriteFile("keep.txt", "new contents", t2)
+	file3 := r.WriteFile("new", "new", t2)
+	r.CheckLocalItems(t, file1, file2, file3)
+	file1dst := r.WriteObject(ctx, "keep/file1", "old contents", t1)
+	file4 := r.WriteObject(ctx, "keep/file4", "dst only", t1)
+	file5 := r.WriteObject(ctx, "delete-me", "dst only", t1)
+	r.CheckRemoteItems(t, file1dst, file4, file5)
+
+	protectFile := filepath.Join(t.TempDir(), "protect.txt")
+	require.NoError(t, os.WriteFile(protectFile, []byte("keep/**\nkeep.txt\n"), 0600))
+	ci.ProtectDestFrom = []string{protectFile}
+
+	accounting.GlobalStats().ResetCounters()
+	err := Sync(ctx, r.Fremote, r.Flocal, false)
+	require.NoError(t, err)
+	r.CheckRemoteItems(t, file1dst, file3, file4)
+}
+
 // Test with UpdateOlder set
 func TestSyncWithUpdateOlder(t *testing.T) {
 	ctx := context.Background()
@@ -1285,6 +1514,31 @@ func TestMoveWithDeleteEmptySrcDirs(t *testing.T) {
 	r.CheckRemoteItems(t, file1, file2)
 }
 
+func TestMoveWithDeleteEmptySrcDirsBelow(t *testing.T) {
+	ctx := context.Background()
+	ctx, ci := fs.AddConfig(ctx)
+	r := fstest.NewRun(t)
+	defer r.Finalise()
+	file1 := r.WriteFile("sub dir/hello world", "hello world", t1)
+	file2 := r.WriteFile("nested/sub dir/deeper/file", "nested", t1)
+	r.Mkdir(ctx, r.Fremote)
+
+	// run move with --delete-empty-src-dirs keeping the top level
+	ci.DeleteSrcDirsBelow = 1
+	err := MoveDir(ctx, r.Fremote, r.Flocal, true, false)
+	require.NoError(t, err)
+
+	r.CheckLocalListing(
+		t,
+		nil,
+		[]string{
+			"sub dir",
+			"nested",
+		},
+	)
+	r.CheckRemoteItems(t, file1, file2)
+}
+
 func TestMoveWithoutDeleteEmptySrcDirs(t *testing.T) {
 	ctx := context.Background()
 	r := fstest.NewRun(t)
@@ -1943,6 +2197,45 @@ func TestSyncImmutable(t *testing.T) {
 	r.CheckRemoteItems(t, file1)
 }
 
+// Test with --immutable and --backup-dir the modified file is moved
+// into the backup dir instead of being an error
+func TestCopyImmutableBackupDir(t *testing.T) {
+	ctx := context.Background()
+	ctx, ci := fs.AddConfig(ctx)
+	r := fstest.NewRun(t)
+	defer r.Finalise()
+
+	if !operations.CanServerSideMove(r.Fremote) {
+		t.Skip("Skipping test as remote does not support server-side move")
+	}
+	r.Mkdir(ctx, r.Fremote)
+
+	ci.Immutable = true
+	ci.BackupDir = r.FremoteName + "/backup"
+
+	// Make the setup so we have one, two in the dest
+	// and one (different), two (same) in the source
+	file1 := r.WriteObject(ctx, "dst/one", "one", t1)
+	file2 := r.WriteObject(ctx, "dst/two", "two", t1)
+	file1a := r.WriteFile("one", "oneA", t2)
+	file2a := r.WriteFile("two", "two", t1)
+	r.CheckRemoteItems(t, file1, file2)
+	r.CheckLocalItems(t, file1a, file2a)
+
+	fdst, err := fs.NewFs(ctx, r.FremoteName+"/dst")
+	require.NoError(t, err)
+
+	accounting.GlobalStats().ResetCounters()
+	err = CopyDir(ctx, fdst, r.Flocal, false)
+	require.NoError(t, err)
+
+	// one should be moved to the backup dir and the new one
+	// copied in, two should be left alone
+	file1.Path = "backup/one"
+	file1a.Path = "dst/one"
+	r.CheckRemoteItems(t, file1, file1a, file2)
+}
+
 // Test --ignore-case-sync
 func TestSyncIgnoreCase(t *testing.T) {
 	ctx := context.Background()
@@ -2000,7 +2293,12 @@ func TestMaxTransfer(t *testing.T) {
 
 		err := Sync(ctx, r.Fremote, r.Flocal, false)
 		expectedErr := fserrors.FsError(accounting.ErrorMaxTransferLimitReachedFatal)
-		if cutoff != fs.CutoffModeHard {
+		switch cutoff {
+		case fs.CutoffModeSoft:
+			// Only the files which don't fit are skipped
+			expectedErr = accounting.ErrorMaxTransferLimitSkipped
+			r.CheckRemoteItems(t, file2)
+		case fs.CutoffModeCautious:
 			expectedErr = accounting.ErrorMaxTransferLimitReachedGraceful
 		}
 		fserrors.Count(expectedErr)
@@ -2065,3 +2363,55 @@ func TestSyncConcurrentDelete(t *testing.T) {
 func TestSyncConcurrentTruncate(t *testing.T) {
 	testSyncConcurrent(t, "truncate")
 }
+
+// Test syncing only the paths from a stream of changes
+func TestSyncChanges(t *testing.T) {
+	ctx := context.Background()
+	r := fstest.NewRun(t)
+	defer r.Finalise()
+	file1 := r.WriteFile("changed", "new contents", t2)
+	file2 := r.WriteFile("unchanged", "local only", t1)
+	file3 := r.WriteFile("dir/new", "new file", t1)
+	r.CheckLocalItems(t, file1, file2, file3)
+
+	file1old := r.WriteObject(ctx, "changed", "old contents", t1)
+	file4 := r.WriteObject(ctx, "deleted", "deleted from source", t1)
+	file5 := r.WriteObject(ctx, "other", "not in the changes", t1)
+	r.CheckRemoteItems(t, file1old, file4, file5)
+
+	changes := strings.NewReader("changed\n/dir/new\r\n\ndeleted\nchanged\n")
+	err := SyncChanges(ctx, r.Fremote, r.Flocal, changes, false)
+	require.NoError(t, err)
+
+	r.CheckLocalItems(t, file1, file2, file3)
+	r.CheckRemoteItems(t, file1, file3, file5)
+}
+
+// Test syncing a stream of changes with --delete-excluded only
+// deletes the changed paths which are gone from the source
+func TestSyncChangesWithDeleteExcluded(t *testing.T) {
+	ctx := context.Background()
+	r := fstest.NewRun(t)
+	defer r.Finalise()
+	file1 := r.WriteFile("changed", "new contents", t2)
+	file2 := r.WriteFile("excluded.bak", "excluded", t1)
+	r.CheckLocalItems(t, file1, file2)
+
+	r.WriteObject(ctx, "changed", "old contents", t1)
+	file3 := r.WriteObject(ctx, "excluded.bak", "excluded", t1)
+	r.WriteObject(ctx, "deleted", "deleted from source", t1)
+	file5 := r.WriteObject(ctx, "other", "not in the changes", t1)
+
+	fi, err := filter.NewFilter(nil)
+	require.NoError(t, err)
+	require.NoError(t, fi.AddRule("- *.bak"))
+	fi.Opt.DeleteExcluded = true
+	ctx = filter.ReplaceConfig(ctx, fi)
+
+	changes := strings.NewReader("changed\ndeleted\n")
+	err = SyncChanges(ctx, r.Fremote, r.Flocal, changes, false)
+	require.NoError(t, err)
+
+	r.CheckLocalItems(t, file1, file2)
+	r.CheckRemoteItems(t, file1, file3, file5)
+}
@@ -0,0 +1,196 @@
+package sync
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fs/rc"
+)
+
+// Kinds of workers, which are resized by the --transfers or
+// --checkers they are named after
+const (
+	workersTransfers = "transfers"
+	workersCheckers  = "checkers"
+)
+
+// workers runs a number of copies of a worker which can be changed
+// while they are running
+type workers struct {
+	kind     string                                  // workersTransfers or workersCheckers
+	ctx      context.Context                         // the contexts of the workers are made from this
+	fn       func(ctx context.Context, fraction int) // the worker - it should stop when ctx is cancelled
+	wg       sync.WaitGroup                          // wait for the workers
+	mu       sync.Mutex                              // protect the below
+	cancels  []context.CancelFunc                    // to stop each running worker, newest last
+	stopping bool                                    // set once wait has been called
+}
+
+// all the workers which are running
+var (
+	activeWorkersMu sync.Mutex
+	activeWorkers   = map[*workers]struct{}{}
+)
+
+// startWorkers starts n copies of fn until ctx is cancelled or wait
+// is called.
+//
+// Each worker is given its own context which is cancelled if there
+// are too many workers so it should only use that to wait for new
+// work, so the work in progress is finished.
+func startWorkers(ctx context.Context, kind string, n int, fn func(ctx context.Context, fraction int)) *workers {
+	w := &workers{
+		kind: kind,
+		ctx:  ctx,
+		fn:   fn,
+	}
+	w.resize(n)
+	activeWorkersMu.Lock()
+	activeWorkers[w] = struct{}{}
+	activeWorkersMu.Unlock()
+	return w
+}
+
+// resize starts or stops workers until there are n running
+//
+// Once wait has been called workers are only stopped.
+func (w *workers) resize(n int) {
+	if n < 1 {
+		n = 1
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	for i := len(w.cancels); i < n && !w.stopping; i++ {
+		ctx, cancel := context.WithCancel(w.ctx)
+		w.cancels = append(w.cancels, cancel)
+		fraction := (100 * i) / n
+		w.wg.Add(1)
+		go func() {
+			defer w.wg.Done()
+			w.fn(ctx, fraction)
+		}()
+	}
+	for len(w.cancels) > n {
+		last := len(w.cancels) - 1
+		w.cancels[last]()
+		w.cancels = w.cancels[:last]
+	}
+}
+
+// size returns the number of workers running
+func (w *workers) size() int {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return len(w.cancels)
+}
+
+// wait for the workers to finish
+func (w *workers) wait() {
+	w.mu.Lock()
+	w.stopping = true
+	w.mu.Unlock()
+	w.wg.Wait()
+	activeWorkersMu.Lock()
+	delete(activeWorkers, w)
+	activeWorkersMu.Unlock()
+	// free the contexts of the workers
+	w.mu.Lock()
+	for _, cancel := range w.cancels {
+		cancel()
+	}
+	w.cancels = nil
+	w.mu.Unlock()
+}
+
+// SetConcurrency changes --transfers and --checkers to the values
+// given, or leaves them alone if they are 0.
+//
+// The workers of the syncs, copies and moves which are running are
+// started or stopped to match. Workers which are stopped finish what
+// they are doing first so no transfers or checks are cancelled.
+func SetConcurrency(transfers, checkers int) error {
+	if transfers < 0 || checkers < 0 {
+		return errors.New("transfers and checkers must be at least 1")
+	}
+	ci := fs.GetConfig(context.Background())
+	if transfers > 0 {
+		ci.Transfers = transfers
+		fs.Logf(nil, "Set --transfers to %d", transfers)
+	}
+	if checkers > 0 {
+		ci.Checkers = checkers
+		fs.Logf(nil, "Set --checkers to %d", checkers)
+	}
+	activeWorkersMu.Lock()
+	defer activeWorkersMu.Unlock()
+	for w := range activeWorkers {
+		switch {
+		case w.kind == workersTransfers && transfers > 0:
+			w.resize(transfers)
+		case w.kind == workersCheckers && checkers > 0:
+			w.resize(checkers)
+		}
+	}
+	return nil
+}
+
+// Remote control for changing the concurrency
+func init() {
+	rc.Add(rc.Call{
+		Path:         "core/concurrency",
+		Fn:           rcConcurrency,
+		Title:        "Change --transfers and --checkers while running.",
+		AuthRequired: true,
+		Help: `
+This sets the number of transfers and checkers to use, as if
+rclone had been started with --transfers and --checkers, and
+applies them to the syncs, copies and moves which are running too.
+
+This takes the following parameters:
+
+- transfers - number of file transfers to run in parallel (optional)
+- checkers - number of checkers to run in parallel (optional)
+
+When a number is lowered no new transfers or checks are started
+beyond the new limit but the ones in progress are allowed to finish.
+When it is raised more are started straight away.
+
+It returns the values in use, e.g.
+
+    rclone rc core/concurrency transfers=16
+    {
+        "checkers": 8,
+        "transfers": 16
+    }
+
+Call it with no parameters to read the values.
+`,
+	})
+}
+
+// Change the concurrency
+func rcConcurrency(ctx context.Context, in rc.Params) (out rc.Params, err error) {
+	var transfers, checkers int64
+	transfers, err = in.GetInt64("transfers")
+	if rc.NotErrParamNotFound(err) {
+		return nil, err
+	}
+	checkers, err = in.GetInt64("checkers")
+	if rc.NotErrParamNotFound(err) {
+		return nil, err
+	}
+	if in["transfers"] != nil && transfers < 1 || in["checkers"] != nil && checkers < 1 {
+		return nil, rc.NewErrParamInvalid(errors.New("transfers and checkers must be at least 1"))
+	}
+	err = SetConcurrency(int(transfers), int(checkers))
+	if err != nil {
+		return nil, err
+	}
+	ci := fs.GetConfig(context.Background())
+	return rc.Params{
+		"transfers": ci.Transfers,
+		"checkers":  ci.Checkers,
+	}, nil
+}
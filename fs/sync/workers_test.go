@@ -0,0 +1,126 @@
+package sync
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fs/rc"
+	"github.com/rclone/rclone/fstest/mockobject"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWorkersResize(t *testing.T) {
+	ctx := context.Background()
+	ci := fs.GetConfig(ctx)
+	oldTransfers, oldCheckers := ci.Transfers, ci.Checkers
+	defer func() {
+		ci.Transfers, ci.Checkers = oldTransfers, oldCheckers
+	}()
+
+	p, err := newPipe("", func(int, int64) {}, -1)
+	require.NoError(t, err)
+	const items = 10
+	for i := 0; i < items; i++ {
+		require.True(t, p.Put(ctx, fs.ObjectPair{Src: mockobject.New("potato")}))
+	}
+
+	var done int32
+	started := make(chan struct{}, items)
+	release := make(chan struct{})
+	w := startWorkers(ctx, workersTransfers, 2, func(ctx context.Context, fraction int) {
+		for {
+			_, ok := p.GetMax(ctx, fraction)
+			if !ok {
+				return
+			}
+			started <- struct{}{}
+			<-release
+			atomic.AddInt32(&done, 1)
+		}
+	})
+
+	// wait for n workers to start an item and check no more do
+	waitStarted := func(n int) {
+		for i := 0; i < n; i++ {
+			select {
+			case <-started:
+			case <-time.After(10 * time.Second):
+				t.Fatalf("only %d of %d items started", i, n)
+			}
+		}
+		select {
+		case <-started:
+			t.Fatal("too many items started")
+		case <-time.After(50 * time.Millisecond):
+		}
+	}
+	waitStarted(2)
+
+	// growing starts more workers
+	require.NoError(t, SetConcurrency(4, 0))
+	assert.Equal(t, 4, ci.Transfers)
+	assert.Equal(t, oldCheckers, ci.Checkers)
+	assert.Equal(t, 4, w.size())
+	waitStarted(2)
+
+	// shrinking lets the items in progress finish
+	require.NoError(t, SetConcurrency(1, 0))
+	assert.Equal(t, 1, w.size())
+	for i := 0; i < 4; i++ {
+		release <- struct{}{}
+	}
+	waitStarted(1)
+	assert.Equal(t, int32(4), atomic.LoadInt32(&done))
+
+	// checkers aren't changed by transfers
+	require.NoError(t, SetConcurrency(0, 3))
+	assert.Equal(t, 1, w.size())
+	assert.Error(t, SetConcurrency(-1, 0))
+
+	close(release)
+	p.Close()
+	w.wait()
+	assert.Equal(t, int32(items), atomic.LoadInt32(&done))
+	activeWorkersMu.Lock()
+	_, found := activeWorkers[w]
+	activeWorkersMu.Unlock()
+	assert.False(t, found)
+}
+
+func TestRcConcurrency(t *testing.T) {
+	ctx := context.Background()
+	ci := fs.GetConfig(ctx)
+	oldTransfers, oldCheckers := ci.Transfers, ci.Checkers
+	defer func() {
+		ci.Transfers, ci.Checkers = oldTransfers, oldCheckers
+	}()
+	call := rc.Calls.Get("core/concurrency")
+	require.NotNil(t, call)
+
+	out, err := call.Fn(ctx, rc.Params{})
+	require.NoError(t, err)
+	assert.Equal(t, rc.Params{"transfers": oldTransfers, "checkers": oldCheckers}, out)
+
+	out, err = call.Fn(ctx, rc.Params{"transfers": 7})
+	require.NoError(t, err)
+	assert.Equal(t, rc.Params{"transfers": 7, "checkers": oldCheckers}, out)
+
+	out, err = call.Fn(ctx, rc.Params{"checkers": "9"})
+	require.NoError(t, err)
+	assert.Equal(t, rc.Params{"transfers": 7, "checkers": 9}, out)
+
+	for _, in := range []rc.Params{
+		{"transfers": 0},
+		{"checkers": -1},
+		{"transfers": "potato"},
+	} {
+		_, err = call.Fn(ctx, in)
+		assert.Error(t, err, in)
+	}
+	assert.Equal(t, 7, ci.Transfers)
+	assert.Equal(t, 9, ci.Checkers)
+}
@@ -183,6 +183,13 @@ type GetTierer interface {
 	GetTier() string
 }
 
+// Metadataer is an optional interface for Object
+type Metadataer interface {
+	// Metadata returns the user metadata of the Object with the
+	// keys in lower case. It may be empty if the Object has none.
+	Metadata(ctx context.Context) (map[string]string, error)
+}
+
 // FullObjectInfo contains all the read-only optional interfaces
 //
 // Use for checking making wrapping ObjectInfos implement everything
@@ -255,12 +262,22 @@ func NewUsageValue(value int64) *int64 {
 //
 // If a value is nil then it isn't supported by that backend
 type Usage struct {
-	Total   *int64 `json:"total,omitempty"`   // quota of bytes that can be used
-	Used    *int64 `json:"used,omitempty"`    // bytes in use
-	Trashed *int64 `json:"trashed,omitempty"` // bytes in trash
-	Other   *int64 `json:"other,omitempty"`   // other usage e.g. gmail in drive
-	Free    *int64 `json:"free,omitempty"`    // bytes which can be uploaded before reaching the quota
-	Objects *int64 `json:"objects,omitempty"` // objects in the storage system
+	Total   *int64      `json:"total,omitempty"`   // quota of bytes that can be used
+	Used    *int64      `json:"used,omitempty"`    // bytes in use
+	Trashed *int64      `json:"trashed,omitempty"` // bytes in trash
+	Other   *int64      `json:"other,omitempty"`   // other usage e.g. gmail in drive
+	Free    *int64      `json:"free,omitempty"`    // bytes which can be uploaded before reaching the quota
+	Objects *int64      `json:"objects,omitempty"` // objects in the storage system
+	Tiers   []TierUsage `json:"tiers,omitempty"`   // usage of each storage tier or class if known
+}
+
+// TierUsage is the usage of one storage tier or class in Usage
+//
+// If a value is nil then it isn't known
+type TierUsage struct {
+	Tier    string `json:"tier"`              // name of the tier, e.g. "STANDARD", or "" for objects without one
+	Used    *int64 `json:"used,omitempty"`    // bytes in the tier
+	Objects *int64 `json:"objects,omitempty"` // objects in the tier
 }
 
 // WriterAtCloser wraps io.WriterAt and io.Closer
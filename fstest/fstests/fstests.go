@@ -295,6 +295,7 @@ type Opt struct {
 	SkipFsCheckWrap              bool     // if set skip FsCheckWrap
 	SkipObjectCheckWrap          bool     // if set skip ObjectCheckWrap
 	SkipInvalidUTF8              bool     // if set skip invalid UTF-8 checks
+	DirMarkers                   bool     // if set the Fs makes directory markers with --create-dir-markers
 }
 
 // returns true if x is found in ss
@@ -469,8 +470,8 @@ func Run(t *testing.T, opt *Opt) {
 				continue
 			}
 			field := v.Field(i)
-			// skip the bools
-			if field.Type().Kind() == reflect.Bool {
+			// skip the bools and other settings
+			if field.Type().Kind() != reflect.Func {
 				continue
 			}
 			if field.IsNil() {
@@ -578,6 +579,43 @@ func Run(t *testing.T, opt *Opt) {
 			fstest.CheckListingWithPrecision(t, f, []fstest.Item{}, []string{}, fs.GetModifyWindow(ctx, f))
 		})
 
+		// TestFsMkdirDirMarkers tests that with --create-dir-markers
+		// empty directories are made and removed with markers
+		t.Run("FsMkdirDirMarkers", func(t *testing.T) {
+			skipIfNotOk(t)
+			if !opt.DirMarkers {
+				t.Skip("FS doesn't make directory markers")
+			}
+			ctx, ci := fs.AddConfig(ctx)
+			ci.CreateDirMarkers = true
+			listDirs := func(dir string) []string {
+				entries, err := f.List(ctx, dir)
+				require.NoError(t, err)
+				var dirs []string
+				for _, entry := range entries {
+					_, isDir := entry.(fs.Directory)
+					assert.True(t, isDir, "directory marker listed as %v", entry)
+					dirs = append(dirs, entry.Remote())
+				}
+				return dirs
+			}
+
+			err := operations.Mkdir(ctx, f, "dir/subdir")
+			require.NoError(t, err)
+			assert.Equal(t, []string{"dir"}, listDirs(""))
+			assert.Equal(t, []string{"dir/subdir"}, listDirs("dir"))
+			assert.Nil(t, listDirs("dir/subdir"))
+
+			// the marker of a directory which isn't empty isn't removed
+			err = operations.Rmdir(ctx, f, "dir")
+			assert.Error(t, err)
+			assert.Equal(t, []string{"dir/subdir"}, listDirs("dir"))
+
+			err = operations.Rmdir(ctx, f, "dir/subdir")
+			require.NoError(t, err)
+			assert.Nil(t, listDirs(""))
+		})
+
 		// TestFsListEmpty tests listing an empty directory
 		t.Run("FsListEmpty", func(t *testing.T) {
 			skipIfNotOk(t)
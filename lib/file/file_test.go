@@ -179,3 +179,38 @@ func TestIsReserved(t *testing.T) {
 	require.Error(t, IsReserved("test."))
 	require.Error(t, IsReserved("test "))
 }
+
+func TestParseFallocateMode(t *testing.T) {
+	for _, test := range []struct {
+		in      string
+		want    FallocateMode
+		wantErr bool
+	}{
+		{"", FallocateAuto, false},
+		{"auto", FallocateAuto, false},
+		{"keep-size", FallocateKeepSize, false},
+		{"punch-hole", FallocatePunchHole, false},
+		{"potato", FallocateAuto, true},
+	} {
+		got, err := ParseFallocateMode(test.in)
+		assert.Equal(t, test.wantErr, err != nil, test.in)
+		assert.Equal(t, test.want, got, test.in)
+	}
+}
+
+func TestPreAllocateWithMode(t *testing.T) {
+	dir, tidy := testDir(t)
+	defer tidy()
+
+	for _, mode := range []FallocateMode{FallocateAuto, FallocateKeepSize, FallocatePunchHole} {
+		f, err := os.Create(path.Join(dir, fmt.Sprintf("file%d", mode)))
+		require.NoError(t, err)
+		// Pre-allocation must not fail even if the file system
+		// doesn't support it or change the size of the file
+		require.NoError(t, PreAllocateWithMode(1024*1024, f, mode))
+		fi, err := f.Stat()
+		require.NoError(t, err)
+		assert.Equal(t, int64(0), fi.Size())
+		require.NoError(t, f.Close())
+	}
+}
@@ -1,6 +1,34 @@
 package file
 
-import "errors"
+import (
+	"errors"
+	"fmt"
+)
 
 // ErrDiskFull is returned from PreAllocate when it detects disk full
 var ErrDiskFull = errors.New("preallocate: file too big for remaining disk space")
+
+// FallocateMode chooses how PreAllocateWithMode asks Linux to
+// allocate space. It is ignored on other platforms.
+type FallocateMode int
+
+// Fallocate modes
+const (
+	FallocateAuto      FallocateMode = iota // try each of the modes below in turn until one works
+	FallocateKeepSize                       // allocate space without changing the file size
+	FallocatePunchHole                      // as FallocateKeepSize but also punch a hole which works on ZFS
+)
+
+// ParseFallocateMode parses a FallocateMode from the names used in
+// the config, eg "auto", "keep-size" or "punch-hole"
+func ParseFallocateMode(s string) (FallocateMode, error) {
+	switch s {
+	case "", "auto":
+		return FallocateAuto, nil
+	case "keep-size":
+		return FallocateKeepSize, nil
+	case "punch-hole":
+		return FallocatePunchHole, nil
+	}
+	return FallocateAuto, fmt.Errorf("unknown fallocate mode %q - expecting auto, keep-size or punch-hole", s)
+}
@@ -14,6 +14,12 @@ func PreAllocate(size int64, out *os.File) error {
 	return nil
 }
 
+// PreAllocateWithMode is the same as PreAllocate as mode is only used
+// on Linux
+func PreAllocateWithMode(size int64, out *os.File, mode FallocateMode) error {
+	return PreAllocate(size, out)
+}
+
 // SetSparseImplemented is a constant indicating whether the
 // implementation of SetSparse actually does anything.
 const SetSparseImplemented = false
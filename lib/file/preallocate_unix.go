@@ -4,9 +4,9 @@
 package file
 
 import (
+	"fmt"
 	"os"
 	"sync"
-	"sync/atomic"
 	"syscall"
 
 	"github.com/rclone/rclone/fs"
@@ -14,12 +14,18 @@ import (
 )
 
 var (
-	fallocFlags = [...]uint32{
-		unix.FALLOC_FL_KEEP_SIZE,                             // Default
-		unix.FALLOC_FL_KEEP_SIZE | unix.FALLOC_FL_PUNCH_HOLE, // for ZFS #3066
+	// The fallocate flags to try in order for each mode. The index
+	// of the first one not known to fail is kept in fallocIndex.
+	fallocFlags = map[FallocateMode][]uint32{
+		FallocateAuto: {
+			unix.FALLOC_FL_KEEP_SIZE,                             // Default
+			unix.FALLOC_FL_KEEP_SIZE | unix.FALLOC_FL_PUNCH_HOLE, // for ZFS #3066
+		},
+		FallocateKeepSize:  {unix.FALLOC_FL_KEEP_SIZE},
+		FallocatePunchHole: {unix.FALLOC_FL_KEEP_SIZE | unix.FALLOC_FL_PUNCH_HOLE},
 	}
-	fallocFlagsIndex int32
-	preAllocateMu    sync.Mutex
+	fallocIndex   = map[FallocateMode]int{}
+	preAllocateMu sync.Mutex
 )
 
 // PreallocateImplemented is a constant indicating whether the
@@ -28,29 +34,49 @@ const PreallocateImplemented = true
 
 // PreAllocate the file for performance reasons
 func PreAllocate(size int64, out *os.File) (err error) {
+	return PreAllocateWithMode(size, out, FallocateAuto)
+}
+
+// fallocateUnsupported returns true if err from fallocate means the
+// flags aren't supported by the file system
+func fallocateUnsupported(err error) bool {
+	return err == unix.ENOTSUP || err == unix.EOPNOTSUPP || err == unix.ENOSYS || err == unix.EINVAL
+}
+
+// PreAllocateWithMode pre-allocates the file using the fallocate
+// flags for mode.
+//
+// If the file system doesn't support them then it tries the next
+// ones for mode, and if none work pre-allocation is disabled for that
+// mode without returning an error.
+func PreAllocateWithMode(size int64, out *os.File, mode FallocateMode) (err error) {
 	if size <= 0 {
 		return nil
 	}
+	flagsList, ok := fallocFlags[mode]
+	if !ok {
+		return fmt.Errorf("preAllocate: unknown fallocate mode %d", mode)
+	}
 
 	preAllocateMu.Lock()
 	defer preAllocateMu.Unlock()
 
 	for {
-
-		index := atomic.LoadInt32(&fallocFlagsIndex)
-	again:
-		if index >= int32(len(fallocFlags)) {
+		index := fallocIndex[mode]
+		if index >= len(flagsList) {
 			return nil // Fallocate is disabled
 		}
-		flags := fallocFlags[index]
-		err = unix.Fallocate(int(out.Fd()), flags, 0, size)
-		if err == unix.ENOTSUP {
+		err = unix.Fallocate(int(out.Fd()), flagsList[index], 0, size)
+		if fallocateUnsupported(err) {
 			// Try the next flags combination
 			index++
-			atomic.StoreInt32(&fallocFlagsIndex, index)
-			fs.Debugf(nil, "preAllocate: got error on fallocate, trying combination %d/%d: %v", index, len(fallocFlags), err)
-			goto again
-
+			fallocIndex[mode] = index
+			if index >= len(flagsList) {
+				fs.Infof(nil, "preAllocate: fallocate isn't supported here so disabling pre-allocation: %v", err)
+				return nil
+			}
+			fs.Debugf(nil, "preAllocate: got error on fallocate, trying combination %d/%d: %v", index, len(flagsList), err)
+			continue
 		}
 		// Wrap important errors
 		if err == unix.ENOSPC {
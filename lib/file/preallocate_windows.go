@@ -91,6 +91,12 @@ func PreAllocate(size int64, out *os.File) error {
 	return nil
 }
 
+// PreAllocateWithMode is the same as PreAllocate as mode is only used
+// on Linux
+func PreAllocateWithMode(size int64, out *os.File, mode FallocateMode) error {
+	return PreAllocate(size, out)
+}
+
 const (
 	FSCTL_SET_SPARSE = 0x000900c4
 )
@@ -605,6 +605,28 @@ func fixRedirect(oauthConfig *oauth2.Config) *oauth2.Config {
 	return oauthConfig
 }
 
+type bindAddressKey struct{}
+
+// WithBindAddress returns a copy of ctx which makes the internal
+// webserver which receives the OAuth callback listen on addr, e.g.
+// "0.0.0.0:53682", instead of the default 127.0.0.1:53682.
+//
+// The redirect URL given to the provider isn't changed so the browser
+// must still be able to reach the webserver at it, e.g. through an SSH
+// tunnel or a port forward.
+func WithBindAddress(ctx context.Context, addr string) context.Context {
+	return context.WithValue(ctx, bindAddressKey{}, addr)
+}
+
+// getBindAddress returns the address the internal webserver should
+// listen on from ctx
+func getBindAddress(ctx context.Context) string {
+	if addr, ok := ctx.Value(bindAddressKey{}).(string); ok && addr != "" {
+		return addr
+	}
+	return bindAddress
+}
+
 // configSetup does the initial creation of the token
 //
 // If opt is nil it will use the default Options
@@ -623,14 +645,23 @@ func configSetup(ctx context.Context, id, name string, m configmap.Mapper, oauth
 	}
 
 	// Prepare webserver
-	server := newAuthServer(opt, bindAddress, state, authURL)
+	listenAddress := getBindAddress(ctx)
+	server := newAuthServer(opt, listenAddress, state, authURL)
 	err = server.Init()
 	if err != nil {
 		return "", fmt.Errorf("failed to start auth webserver: %w", err)
 	}
 	go server.Serve()
 	defer server.Stop()
-	authURL = "http://" + bindAddress + "/auth?state=" + state
+	authHost := bindAddress
+	if listenAddress != bindAddress {
+		// The browser reaches the webserver through the redirect URL
+		if u, err := url.Parse(oauthConfig.RedirectURL); err == nil && u.Host != "" {
+			authHost = u.Host
+		}
+		fs.Logf(nil, "Listening for the auth callback on %s - the browser must be able to reach it at %s\n", listenAddress, oauthConfig.RedirectURL)
+	}
+	authURL = "http://" + authHost + "/auth?state=" + state
 
 	if !authorizeNoAutoBrowser {
 		// Open the URL for the user to visit
@@ -0,0 +1,210 @@
+package oauthutil
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fs/cache"
+	"github.com/rclone/rclone/fs/config"
+	"github.com/rclone/rclone/fs/config/configmap"
+	"github.com/rclone/rclone/fs/rc"
+	"golang.org/x/oauth2"
+)
+
+// pendingAuthExpiry is how long an OAuth started with
+// config/oauthStart can be finished for
+const pendingAuthExpiry = time.Hour
+
+// pendingAuth is an OAuth started with config/oauthStart waiting for
+// its code
+type pendingAuth struct {
+	name        string
+	m           configmap.Mapper
+	oauthConfig *oauth2.Config
+	opt         *Options
+	expires     time.Time
+}
+
+var (
+	pendingMu sync.Mutex
+	pending   = map[string]*pendingAuth{} // indexed by state
+)
+
+func init() {
+	rc.Add(rc.Call{
+		Path:         "config/oauthStart",
+		Fn:           rcOAuthStart,
+		Title:        "Start the OAuth flow for a remote.",
+		AuthRequired: true,
+		Help: `
+This starts authorizing an existing remote which uses OAuth without
+running a webserver for the callback, so it can be used to set up
+remotes on headless machines.
+
+Parameters:
+
+- name - name of remote to authorize
+
+Returns:
+
+- url - the URL to visit in a browser to authorize rclone
+- state - pass this to config/oauthFinish
+
+Once rclone is authorized the browser is redirected to the redirect
+URL, normally http://127.0.0.1:53682/, which will fail to load unless
+rclone is listening there. Pass the address from the browser, or just
+the code from it, to config/oauthFinish to finish the config.
+
+The OAuth must be finished within an hour.
+`,
+	})
+}
+
+// Start the OAuth for a remote
+func rcOAuthStart(ctx context.Context, in rc.Params) (out rc.Params, err error) {
+	name, err := in.GetString("name")
+	if err != nil {
+		return nil, err
+	}
+	fsType := config.FileGet(name, "type")
+	if fsType == "" {
+		return nil, fmt.Errorf("couldn't find type of remote %q", name)
+	}
+	ri, err := fs.Find(fsType)
+	if err != nil {
+		return nil, err
+	}
+	if ri.Config == nil {
+		return nil, fmt.Errorf("remote %q of type %q doesn't use OAuth", name, fsType)
+	}
+	m := fs.ConfigMap(ri, name, nil)
+	configOut, err := ri.Config(ctx, name, m, fs.ConfigIn{})
+	if err != nil {
+		return nil, err
+	}
+	opt, ok := configOut.OAuth.(*Options)
+	if !ok || opt == nil || opt.OAuth2Config == nil {
+		return nil, fmt.Errorf("remote %q of type %q doesn't use OAuth", name, fsType)
+	}
+	oauthConfig, _ := overrideCredentials(name, m, opt.OAuth2Config)
+	oauthConfig = fixRedirect(oauthConfig)
+	authURL, state, err := getAuthURL(name, m, oauthConfig, opt)
+	if err != nil {
+		return nil, err
+	}
+
+	pendingMu.Lock()
+	now := time.Now()
+	for oldState, auth := range pending {
+		if now.After(auth.expires) {
+			delete(pending, oldState)
+		}
+	}
+	pending[state] = &pendingAuth{
+		name:        name,
+		m:           m,
+		oauthConfig: oauthConfig,
+		opt:         opt,
+		expires:     now.Add(pendingAuthExpiry),
+	}
+	pendingMu.Unlock()
+
+	return rc.Params{
+		"url":   authURL,
+		"state": state,
+	}, nil
+}
+
+func init() {
+	rc.Add(rc.Call{
+		Path:         "config/oauthFinish",
+		Fn:           rcOAuthFinish,
+		Title:        "Finish the OAuth flow for a remote.",
+		AuthRequired: true,
+		Help: `
+This finishes the OAuth started with config/oauthStart by exchanging
+the code for a token and saving it in the config of the remote.
+
+Parameters:
+
+- state - the state returned by config/oauthStart
+- code - the code from the redirect URL
+- url - the whole redirect URL the browser was sent to, instead of code
+
+One of code or url must be supplied. If url is supplied its state must
+match. Each OAuth can only be finished once, so start again with
+config/oauthStart if this fails.
+`,
+	})
+}
+
+// Finish the OAuth for a remote
+func rcOAuthFinish(ctx context.Context, in rc.Params) (out rc.Params, err error) {
+	state, err := in.GetString("state")
+	if err != nil {
+		return nil, err
+	}
+	code, err := in.GetString("code")
+	if err != nil && !rc.IsErrParamNotFound(err) {
+		return nil, err
+	}
+	redirectURL, err := in.GetString("url")
+	if err != nil && !rc.IsErrParamNotFound(err) {
+		return nil, err
+	}
+	if code == "" && redirectURL == "" {
+		return nil, errors.New("need code or url parameter")
+	}
+
+	// Each OAuth can only be finished once
+	pendingMu.Lock()
+	auth, ok := pending[state]
+	delete(pending, state)
+	pendingMu.Unlock()
+	if !ok || time.Now().After(auth.expires) {
+		return nil, errors.New("unknown or expired state - start again with config/oauthStart")
+	}
+
+	form := url.Values{}
+	if code == "" {
+		u, err := url.Parse(strings.TrimSpace(redirectURL))
+		if err != nil {
+			return nil, fmt.Errorf("couldn't parse url: %w", err)
+		}
+		form = u.Query()
+		code = form.Get("code")
+		if code == "" {
+			return nil, fmt.Errorf("no code in url: %s", form.Get("error"))
+		}
+		gotState := form.Get("state")
+		if gotState != state && !(gotState == "" && auth.opt.StateBlankOK) {
+			return nil, errors.New("state in url doesn't match")
+		}
+	} else {
+		form.Set("code", code)
+	}
+
+	if auth.opt.CheckAuth != nil {
+		err = auth.opt.CheckAuth(auth.oauthConfig, &AuthResult{
+			OK:   true,
+			Code: code,
+			Form: form,
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	err = configExchange(ctx, auth.name, auth.m, auth.oauthConfig, code)
+	if err != nil {
+		return nil, err
+	}
+	config.SaveConfig()
+	cache.ClearConfig(auth.name) // remove any remotes based on this config from the cache
+	return nil, nil
+}
@@ -0,0 +1,131 @@
+package oauthutil
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"testing"
+
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fs/config"
+	"github.com/rclone/rclone/fs/config/configfile"
+	"github.com/rclone/rclone/fs/config/configmap"
+	"github.com/rclone/rclone/fs/rc"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/oauth2"
+)
+
+func TestRcOAuth(t *testing.T) {
+	ctx := context.Background()
+
+	// Fake token endpoint which only accepts the right code
+	tokenServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.FormValue("code") != "good-code" {
+			http.Error(w, `{"error":"invalid_grant"}`, http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"access_token":"ACCESS","token_type":"bearer","refresh_token":"REFRESH","expires_in":3600}`))
+	}))
+	defer tokenServer.Close()
+
+	// Fake backend which uses OAuth
+	const backendName = "oauthutil_test_remote"
+	if regInfo, _ := fs.Find(backendName); regInfo == nil {
+		fs.Register(&fs.RegInfo{
+			Name: backendName,
+			Config: func(ctx context.Context, name string, m configmap.Mapper, in fs.ConfigIn) (*fs.ConfigOut, error) {
+				return ConfigOut("", &Options{
+					OAuth2Config: &oauth2.Config{
+						ClientID: "id",
+						Endpoint: oauth2.Endpoint{
+							AuthURL:  "https://example.com/auth",
+							TokenURL: tokenServer.URL,
+						},
+						RedirectURL: RedirectURL,
+					},
+				})
+			},
+		})
+	}
+
+	// Use a config file of our own
+	oldConfigPath := config.GetConfigPath()
+	oldConfigFile := config.Data()
+	require.NoError(t, config.SetConfigPath(filepath.Join(t.TempDir(), "rclone.conf")))
+	configfile.Install()
+	defer func() {
+		assert.NoError(t, config.SetConfigPath(oldConfigPath))
+		config.SetData(oldConfigFile)
+	}()
+	config.FileSet("test", "type", backendName)
+	config.FileSet("other", "type", "oauthutil_test_missing")
+
+	start := func(t *testing.T) (authURL *url.URL, state string) {
+		out, err := rc.Calls.Get("config/oauthStart").Fn(ctx, rc.Params{"name": "test"})
+		require.NoError(t, err)
+		state, ok := out["state"].(string)
+		require.True(t, ok)
+		require.NotEqual(t, "", state)
+		rawURL, ok := out["url"].(string)
+		require.True(t, ok)
+		authURL, err = url.Parse(rawURL)
+		require.NoError(t, err)
+		return authURL, state
+	}
+	finish := rc.Calls.Get("config/oauthFinish").Fn
+
+	t.Run("StartErrors", func(t *testing.T) {
+		_, err := rc.Calls.Get("config/oauthStart").Fn(ctx, rc.Params{"name": "missing"})
+		assert.Error(t, err)
+		_, err = rc.Calls.Get("config/oauthStart").Fn(ctx, rc.Params{"name": "other"})
+		assert.Error(t, err)
+	})
+
+	t.Run("URL", func(t *testing.T) {
+		authURL, state := start(t)
+		assert.Equal(t, "example.com", authURL.Host)
+		assert.Equal(t, state, authURL.Query().Get("state"))
+		assert.Equal(t, RedirectURL, authURL.Query().Get("redirect_uri"))
+
+		_, err := finish(ctx, rc.Params{"state": state, "url": RedirectURL + "?state=wrong&code=good-code"})
+		assert.EqualError(t, err, "state in url doesn't match")
+
+		// Can only be used once
+		_, err = finish(ctx, rc.Params{"state": state, "code": "good-code"})
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "unknown or expired state")
+
+		authURL, state = start(t)
+		_, err = finish(ctx, rc.Params{"state": state, "url": RedirectURL + "?state=" + url.QueryEscape(state) + "&code=good-code"})
+		require.NoError(t, err)
+		assert.Contains(t, config.FileGet("test", "token"), `"access_token":"ACCESS"`)
+	})
+
+	t.Run("Code", func(t *testing.T) {
+		config.FileDeleteKey("test", "token")
+		_, state := start(t)
+		_, err := finish(ctx, rc.Params{"state": state})
+		assert.EqualError(t, err, "need code or url parameter")
+		_, err = finish(ctx, rc.Params{"state": state, "code": "good-code"})
+		require.NoError(t, err)
+		assert.Contains(t, config.FileGet("test", "token"), `"refresh_token":"REFRESH"`)
+	})
+
+	t.Run("BadCode", func(t *testing.T) {
+		_, state := start(t)
+		_, err := finish(ctx, rc.Params{"state": state, "code": "bad-code"})
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "failed to get token")
+	})
+}
+
+func TestBindAddress(t *testing.T) {
+	ctx := context.Background()
+	assert.Equal(t, bindAddress, getBindAddress(ctx))
+	assert.Equal(t, bindAddress, getBindAddress(WithBindAddress(ctx, "")))
+	assert.Equal(t, "0.0.0.0:53682", getBindAddress(WithBindAddress(ctx, "0.0.0.0:53682")))
+}
@@ -54,6 +54,33 @@ Or individual files or directories:
 
     rclone rc vfs/forget file=path/to/file dir=path/to/dir
 
+### VFS Directory Cache Refresh
+
+The directory cache is empty when rclone starts, so the first time
+each directory is looked at it has to be read from the backend which
+can be slow. Use !--vfs-refresh! to read the directory tree in the
+background as soon as rclone starts instead.
+
+    --vfs-refresh                          Read the directory tree in the background on start to fill the directory cache
+    --vfs-refresh-depth int                Number of levels of directories to read with --vfs-refresh (-1 for all) (default -1)
+    --vfs-refresh-interval duration        Read the directory tree again this often with --vfs-refresh (0 for only on start)
+    --vfs-refresh-checkers int             Number of directories to read at once with --vfs-refresh (default 4)
+
+The directories are read a level at a time, starting at the root, with
+!--vfs-refresh-checkers! directories read at once so the backend isn't
+overwhelmed. Use !--vfs-refresh-depth! to only read that many levels,
+e.g. !--vfs-refresh-depth 2! to read the root and the directories in it.
+
+The directories read expire from the cache after !--dir-cache-time!
+as usual. Set !--vfs-refresh-interval! to read the directory tree again
+that often, e.g. to a bit less than !--dir-cache-time!, to keep the
+cache full.
+
+The directories can also be refreshed with the remote control, e.g.
+
+    rclone rc vfs/refresh recursive=true
+    rclone rc vfs/refresh dir=path/to/dir depth=2
+
 ### VFS File Buffering
 
 The !--buffer-size! flag determines the amount of memory,
@@ -117,6 +144,12 @@ around this by giving each rclone its own cache hierarchy with
 !--cache-dir!. You don't need to worry about this if the remotes in
 use don't overlap.
 
+Within one rclone process, for example several mounts made with the
+remote control, all the VFSes of the same remote share one VFS cache
+so each file is only cached once. If their cache options, such as
+!--vfs-cache-max-size!, differ then the options of the first one to
+start the cache are used.
+
 #### --vfs-cache-mode off
 
 In this mode (the default) the cache will read directly from the remote and write
@@ -287,4 +320,19 @@ and compute the total used space itself.
 _WARNING._ Contrary to !rclone size!, this flag ignores filters so that the
 result is accurate. However, this is very inefficient and may cost lots of API
 calls resulting in extra charges. Use it as a last resort and only with caching.
+
+### Reported disk space
+
+Where the backend supports it (see the !About! column in the [optional
+features table](https://rclone.org/overview/#optional-features)) the
+total, used and free space reported to the operating system, e.g. by
+!df!, comes from the backend. It is read again at most every
+!--dir-cache-time!.
+
+If the backend doesn't report the total size of the disk then rclone
+reports a very large disk (1 PiB plus any used space) so applications
+which check the free space before writing don't refuse to. Pass
+!--vfs-disk-space-total-size! to report a different total size
+instead, e.g. !--vfs-disk-space-total-size 2T!. The free space is then
+worked out as the total less the used space.
 `, "!", "`")
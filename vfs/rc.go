@@ -72,6 +72,12 @@ starting with dir will refresh that directory, e.g.
 
 If the parameter recursive=true is given the whole directory tree
 will get refreshed. This refresh will use --fast-list if enabled.
+
+If the parameter depth=N is given then N levels of directories are
+refreshed, so depth=1 refreshes the directories given only, or use
+depth=-1 to refresh all of them. This reads the directories a level at
+a time, --vfs-refresh-checkers at once, in the same way as
+--vfs-refresh does, instead of using --fast-list.
 ` + getVFSHelp,
 	})
 }
@@ -121,13 +127,35 @@ func rcRefresh(ctx context.Context, in rc.Params) (out rc.Params, err error) {
 		}
 	}
 
+	depth, haveDepth := -1, false
+	{
+		const k = "depth"
+
+		if _, ok := in[k]; ok {
+			var n int64
+			n, err = in.GetInt64(k)
+			if err != nil {
+				return out, err
+			}
+			depth, haveDepth = int(n), true
+			delete(in, k)
+		}
+	}
+
+	refresh := func(dir *Dir) error {
+		switch {
+		case haveDepth:
+			return dir.refreshTree(ctx, depth, vfs.Opt.RefreshCheckers)
+		case recursive:
+			return dir.readDirTree()
+		default:
+			return dir.readDir()
+		}
+	}
+
 	result := map[string]string{}
 	if len(in) == 0 {
-		if recursive {
-			err = root.readDirTree()
-		} else {
-			err = root.readDir()
-		}
+		err = refresh(root)
 		if err != nil {
 			result[""] = err.Error()
 		} else {
@@ -144,11 +172,7 @@ func rcRefresh(ctx context.Context, in rc.Params) (out rc.Params, err error) {
 				if err != nil {
 					result[path] = err.Error()
 				} else {
-					if recursive {
-						err = dir.readDirTree()
-					} else {
-						err = dir.readDir()
-					}
+					err = refresh(dir)
 					if err != nil {
 						result[path] = err.Error()
 					} else {
@@ -186,6 +210,11 @@ parameter key starting with file will forget that file and any
 starting with dir will forget that dir, e.g.
 
     rclone rc vfs/forget file=hello file2=goodbye dir=home/junk
+
+If the backend keeps a cache of directory IDs (e.g. drive, onedrive,
+box) then the whole of that cache is forgotten too, whichever paths
+are passed in, so the next listings are read from the backend. This
+is the same as calling operations/forget on the remote.
 ` + getVFSHelp,
 	})
 }
@@ -221,6 +250,9 @@ func rcForget(ctx context.Context, in rc.Params) (out rc.Params, err error) {
 			forgotten = append(forgotten, path)
 		}
 	}
+	if doDirCacheFlush := vfs.Fs().Features().DirCacheFlush; doDirCacheFlush != nil {
+		doDirCacheFlush()
+	}
 	out = rc.Params{
 		"forgotten": forgotten,
 	}
@@ -0,0 +1,114 @@
+package vfs
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/rclone/rclone/fs"
+)
+
+// refreshTree reads d and the directories below it again to fill the
+// directory cache, reading up to checkers directories at once.
+//
+// depth is the number of levels of directories to read, so 1 reads d
+// only, or -1 to read all of them. The directories are read a level at
+// a time so the ones nearest d are in the cache first.
+//
+// Errors reading a directory are logged and the directories below it
+// aren't read but the others are. The last error is returned.
+func (d *Dir) refreshTree(ctx context.Context, depth, checkers int) (err error) {
+	if checkers < 1 {
+		checkers = 1
+	}
+	level := []*Dir{d}
+	for n := 1; len(level) > 0 && (depth < 0 || n <= depth); n++ {
+		var (
+			mu   sync.Mutex
+			next []*Dir
+			wg   sync.WaitGroup
+			in   = make(chan *Dir)
+		)
+		for i := 0; i < checkers; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for dir := range in {
+					readErr := dir.readDir()
+					if readErr != nil {
+						fs.Errorf(dir, "Failed to refresh directory: %v", readErr)
+					}
+					subdirs := dir.subdirs()
+					mu.Lock()
+					if readErr != nil {
+						err = readErr
+					} else {
+						next = append(next, subdirs...)
+					}
+					mu.Unlock()
+				}
+			}()
+		}
+		for _, dir := range level {
+			if ctx.Err() != nil {
+				break
+			}
+			in <- dir
+		}
+		close(in)
+		wg.Wait()
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		level = next
+	}
+	return err
+}
+
+// subdirs returns the directories in d's cache
+func (d *Dir) subdirs() (dirs []*Dir) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	for _, node := range d.items {
+		if dir, ok := node.(*Dir); ok {
+			dirs = append(dirs, dir)
+		}
+	}
+	return dirs
+}
+
+// startRefresh reads the directory tree in the background to fill
+// the directory cache, and again every --vfs-refresh-interval if set,
+// until the VFS is shut down.
+func (vfs *VFS) startRefresh() {
+	ctx, cancel := context.WithCancel(context.Background())
+	vfs.stopRefresh = cancel
+	go func() {
+		var ticker *time.Ticker
+		if vfs.Opt.RefreshInterval > 0 {
+			ticker = time.NewTicker(vfs.Opt.RefreshInterval)
+			defer ticker.Stop()
+		}
+		for {
+			start := time.Now()
+			fs.Debugf(vfs.f, "Refreshing the directory cache")
+			err := vfs.root.refreshTree(ctx, vfs.Opt.RefreshDepth, vfs.Opt.RefreshCheckers)
+			if ctx.Err() != nil {
+				return
+			}
+			if err != nil {
+				fs.Errorf(vfs.f, "Refreshing the directory cache failed: %v", err)
+			} else {
+				fs.Infof(vfs.f, "Refreshed the directory cache in %v", time.Since(start))
+			}
+			if ticker == nil {
+				return
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
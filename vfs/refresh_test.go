@@ -0,0 +1,108 @@
+package vfs
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fs/rc"
+	"github.com/rclone/rclone/fstest"
+	"github.com/rclone/rclone/vfs/vfscommon"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// isRead returns whether the directory at path is in the cache and
+// has been read
+func isRead(vfs *VFS, path string) bool {
+	var node Node = vfs.root
+	if path != "" {
+		for _, leaf := range strings.Split(path, "/") {
+			dir, ok := node.(*Dir)
+			if !ok {
+				return false
+			}
+			dir.mu.RLock()
+			node = dir.items[leaf]
+			dir.mu.RUnlock()
+		}
+	}
+	dir, ok := node.(*Dir)
+	if !ok {
+		return false
+	}
+	dir.mu.RLock()
+	defer dir.mu.RUnlock()
+	return !dir.read.IsZero()
+}
+
+func writeRefreshTree(t *testing.T, r *fstest.Run) {
+	r.WriteObject(context.Background(), "a/b/c/file1", "file1 contents", t1)
+	r.WriteObject(context.Background(), "a/file2", "file2 contents", t1)
+	r.WriteObject(context.Background(), "d/file3", "file3 contents", t1)
+}
+
+func TestDirRefreshTree(t *testing.T) {
+	r, vfs, cleanup := newTestVFS(t)
+	defer cleanup()
+	writeRefreshTree(t, r)
+	ctx := context.Background()
+
+	require.NoError(t, vfs.root.refreshTree(ctx, 2, 2))
+	assert.True(t, isRead(vfs, ""))
+	assert.True(t, isRead(vfs, "a"))
+	assert.True(t, isRead(vfs, "d"))
+	assert.False(t, isRead(vfs, "a/b"))
+
+	require.NoError(t, vfs.root.refreshTree(ctx, -1, 2))
+	assert.True(t, isRead(vfs, "a/b"))
+	assert.True(t, isRead(vfs, "a/b/c"))
+
+	// stops if the context is cancelled
+	ctx, cancel := context.WithCancel(ctx)
+	cancel()
+	assert.Equal(t, context.Canceled, vfs.root.refreshTree(ctx, -1, 2))
+}
+
+func TestVFSRefresh(t *testing.T) {
+	opt := vfscommon.DefaultOpt
+	opt.Refresh = true
+	opt.RefreshCheckers = 1
+	r := fstest.NewRun(t)
+	defer r.Finalise()
+	writeRefreshTree(t, r)
+	vfs := New(r.Fremote, &opt)
+	defer cleanupVFS(t, vfs)
+
+	deadline := time.Now().Add(10 * time.Second)
+	for !isRead(vfs, "a/b/c") {
+		if time.Now().After(deadline) {
+			t.Fatal("directory tree wasn't read in the background")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	assert.True(t, isRead(vfs, "d"))
+}
+
+func TestRcRefreshDepth(t *testing.T) {
+	r, vfs, cleanup, call := rcNewRun(t, "vfs/refresh")
+	defer cleanup()
+	writeRefreshTree(t, r)
+
+	in := rc.Params{"fs": fs.ConfigString(r.Fremote), "dir": "a", "depth": 2}
+	out, err := call.Fn(context.Background(), in)
+	require.NoError(t, err)
+	assert.Equal(t, rc.Params{
+		"result": map[string]string{
+			"a": "OK",
+		},
+	}, out)
+	assert.True(t, isRead(vfs, "a/b"))
+	assert.False(t, isRead(vfs, "a/b/c"))
+	assert.False(t, isRead(vfs, "d"))
+
+	_, err = call.Fn(context.Background(), rc.Params{"fs": fs.ConfigString(r.Fremote), "depth": "potato"})
+	assert.Error(t, err)
+}
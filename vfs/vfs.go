@@ -164,6 +164,7 @@ type VFS struct {
 	Opt         vfscommon.Options
 	cache       *vfscache.Cache
 	cancelCache context.CancelFunc
+	stopRefresh context.CancelFunc // stop the --vfs-refresh loop if running
 	usageMu     sync.Mutex
 	usageTime   time.Time
 	usage       *fs.Usage
@@ -234,6 +235,11 @@ func New(f fs.Fs, opt *vfscommon.Options) *VFS {
 
 	vfs.SetCacheMode(vfs.Opt.CacheMode)
 
+	// Fill the directory cache in the background if required
+	if vfs.Opt.Refresh {
+		vfs.startRefresh()
+	}
+
 	// Pin the Fs into the cache so that when we use cache.NewFs
 	// with the same remote string we get this one. The Pin is
 	// removed when the vfs is finalized
@@ -287,21 +293,121 @@ func (vfs *VFS) Fs() fs.Fs {
 	return vfs.f
 }
 
+// sharedCache is a vfscache.Cache shared by all the VFSes of an Fs
+// as they use the same cache directory
+type sharedCache struct {
+	cache  *vfscache.Cache
+	cancel context.CancelFunc
+	opt    vfscommon.Options // options the cache was created with
+
+	mu    sync.Mutex
+	users []*VFS // VFSes using the cache
+}
+
+// Keep track of the caches in use keyed on fs.ConfigString(f)
+var (
+	sharedCachesMu sync.Mutex
+	sharedCaches   = map[string]*sharedCache{}
+)
+
+// addVirtual adds the virtual entry to all the VFSes using the cache
+func (sc *sharedCache) addVirtual(remote string, size int64, isDir bool) (err error) {
+	sc.mu.Lock()
+	users := append([]*VFS(nil), sc.users...)
+	sc.mu.Unlock()
+	for _, vfs := range users {
+		if addErr := vfs.AddVirtual(remote, size, isDir); addErr != nil {
+			err = addErr
+		}
+	}
+	return err
+}
+
+// cacheOptsEqual returns true if a and b have the same cache options
+func cacheOptsEqual(a, b *vfscommon.Options) bool {
+	return a.CacheMaxAge == b.CacheMaxAge &&
+		a.CacheMaxSize == b.CacheMaxSize &&
+		a.CachePollInterval == b.CachePollInterval &&
+		a.WriteBack == b.WriteBack &&
+		a.ChunkSize == b.ChunkSize &&
+		a.ChunkSizeLimit == b.ChunkSizeLimit &&
+		a.ReadAhead == b.ReadAhead
+}
+
+// getCache returns the cache for vfs, creating it if it isn't already
+// in use by another VFS of the same Fs.
+//
+// The cache directory is derived from the Fs so VFSes with different
+// options must share the cache otherwise they would corrupt it.
+func (vfs *VFS) getCache() (*vfscache.Cache, error) {
+	sharedCachesMu.Lock()
+	defer sharedCachesMu.Unlock()
+	configName := fs.ConfigString(vfs.f)
+	sc := sharedCaches[configName]
+	if sc != nil {
+		if !cacheOptsEqual(&sc.opt, &vfs.Opt) {
+			fs.Logf(vfs.f, "Sharing the VFS cache with the other VFS of this remote so using its cache options")
+		}
+		fs.Debugf(vfs.f, "Re-using VFS cache")
+		sc.mu.Lock()
+		sc.users = append(sc.users, vfs)
+		sc.mu.Unlock()
+		return sc.cache, nil
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	sc = &sharedCache{
+		cancel: cancel,
+		opt:    vfs.Opt,
+		users:  []*VFS{vfs},
+	}
+	cache, err := vfscache.New(ctx, vfs.f, &sc.opt, sc.addVirtual) // FIXME pass on context or get from Opt?
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	sc.cache = cache
+	sharedCaches[configName] = sc
+	return cache, nil
+}
+
+// releaseCache stops vfs using its cache, shutting the cache down if
+// no other VFS is using it
+func (vfs *VFS) releaseCache() {
+	sharedCachesMu.Lock()
+	defer sharedCachesMu.Unlock()
+	configName := fs.ConfigString(vfs.f)
+	sc := sharedCaches[configName]
+	if sc == nil {
+		return
+	}
+	sc.mu.Lock()
+	for i, user := range sc.users {
+		if user == vfs {
+			sc.users = append(sc.users[:i], sc.users[i+1:]...)
+			break
+		}
+	}
+	inUse := len(sc.users) > 0
+	sc.mu.Unlock()
+	if !inUse {
+		sc.cancel()
+		delete(sharedCaches, configName)
+	}
+}
+
 // SetCacheMode change the cache mode
 func (vfs *VFS) SetCacheMode(cacheMode vfscommon.CacheMode) {
 	vfs.shutdownCache()
 	vfs.cache = nil
 	if cacheMode > vfscommon.CacheModeOff {
-		ctx, cancel := context.WithCancel(context.Background())
-		cache, err := vfscache.New(ctx, vfs.f, &vfs.Opt, vfs.AddVirtual) // FIXME pass on context or get from Opt?
+		cache, err := vfs.getCache()
 		if err != nil {
 			fs.Errorf(nil, "Failed to create vfs cache - disabling: %v", err)
 			vfs.Opt.CacheMode = vfscommon.CacheModeOff
-			cancel()
 			return
 		}
 		vfs.Opt.CacheMode = cacheMode
-		vfs.cancelCache = cancel
+		vfs.cancelCache = vfs.releaseCache
 		vfs.cache = cache
 	}
 }
@@ -334,6 +440,9 @@ func (vfs *VFS) Shutdown() {
 	}
 	activeMu.Unlock()
 
+	if vfs.stopRefresh != nil {
+		vfs.stopRefresh()
+	}
 	vfs.shutdownCache()
 }
 
@@ -620,6 +729,9 @@ func (vfs *VFS) Statfs() (total, used, free int64) {
 			used = *u.Used
 		}
 	}
+	if total < 0 && vfs.Opt.DiskSpaceTotal >= 0 {
+		total = int64(vfs.Opt.DiskSpaceTotal)
+	}
 	total, used, free = fillInMissingSizes(total, used, free, unknownFreeBytes)
 	return
 }
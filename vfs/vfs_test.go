@@ -14,6 +14,7 @@ import (
 	_ "github.com/rclone/rclone/backend/all" // import all the backends
 	"github.com/rclone/rclone/fs"
 	"github.com/rclone/rclone/fstest"
+	"github.com/rclone/rclone/fstest/mockfs"
 	"github.com/rclone/rclone/vfs/vfscommon"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -163,6 +164,49 @@ func TestVFSNew(t *testing.T) {
 	checkActiveCacheEntries(0)
 }
 
+// Check VFSes of the same remote with different options share the cache
+func TestVFSSharedCache(t *testing.T) {
+	opt := vfscommon.DefaultOpt
+	opt.CacheMode = vfscommon.CacheModeFull
+	opt.WriteBack = writeBackDelay
+	r, vfs1, cleanup := newTestVFSOpt(t, &opt)
+
+	opt2 := opt
+	opt2.FilePerms = 0600
+	vfs2 := New(r.Fremote, &opt2)
+	assert.NotEqual(t, fmt.Sprintf("%p", vfs1), fmt.Sprintf("%p", vfs2))
+	assert.Equal(t, fmt.Sprintf("%p", vfs1.cache), fmt.Sprintf("%p", vfs2.cache))
+
+	writeFile := func(vfs *VFS, name, contents string) {
+		fd, err := vfs.OpenFile(name, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+		require.NoError(t, err)
+		_, err = fd.Write([]byte(contents))
+		require.NoError(t, err)
+		require.NoError(t, fd.Close())
+	}
+
+	// Write a file with vfs2 then check vfs1 can still use the
+	// cache after vfs2 is shut down
+	writeFile(vfs2, "file1", "hello")
+	vfs2.WaitForWriters(waitForWritersDelay)
+	vfs2.Shutdown()
+
+	sharedCachesMu.Lock()
+	assert.Equal(t, 1, len(sharedCaches))
+	sharedCachesMu.Unlock()
+
+	writeFile(vfs1, "file2", "world")
+	data, err := vfs1.ReadFile("file1")
+	require.NoError(t, err)
+	assert.Equal(t, "hello", string(data))
+
+	cleanup()
+
+	sharedCachesMu.Lock()
+	assert.Equal(t, 0, len(sharedCaches))
+	sharedCachesMu.Unlock()
+}
+
 // TestNew sees if the New command works properly
 func TestVFSNewWithOpts(t *testing.T) {
 	var opt = vfscommon.DefaultOpt
@@ -372,6 +416,34 @@ func TestVFSStatfs(t *testing.T) {
 	assert.Equal(t, oldTime, vfs.usageTime)
 }
 
+func TestVFSStatfsDiskSpaceTotal(t *testing.T) {
+	ctx := context.Background()
+	f := mockfs.NewFs(ctx, "mock", "mock")
+	used := int64(3 * fs.Gibi)
+	f.Features().About = func(ctx context.Context) (*fs.Usage, error) {
+		return &fs.Usage{Used: &used}, nil
+	}
+	opt := vfscommon.DefaultOpt
+	opt.DiskSpaceTotal = 10 * fs.Gibi
+	vfs := New(f, &opt)
+	defer vfs.Shutdown()
+
+	total, gotUsed, free := vfs.Statfs()
+	assert.Equal(t, int64(10*fs.Gibi), total)
+	assert.Equal(t, used, gotUsed)
+	assert.Equal(t, int64(7*fs.Gibi), free)
+
+	// A total from the backend takes precedence
+	backendTotal := int64(20 * fs.Gibi)
+	f.Features().About = func(ctx context.Context) (*fs.Usage, error) {
+		return &fs.Usage{Total: &backendTotal, Used: &used}, nil
+	}
+	vfs.usageTime = time.Time{}
+	total, _, free = vfs.Statfs()
+	assert.Equal(t, backendTotal, total)
+	assert.Equal(t, int64(17*fs.Gibi), free)
+}
+
 func TestFillInMissingSizes(t *testing.T) {
 	const unknownFree = 10
 	for _, test := range []struct {
@@ -33,6 +33,11 @@ type Options struct {
 	WriteBack         time.Duration // time to wait before writing back dirty files
 	ReadAhead         fs.SizeSuffix // bytes to read ahead in cache mode "full"
 	UsedIsSize        bool          // if true, use the `rclone size` algorithm for Used size
+	DiskSpaceTotal    fs.SizeSuffix // total size of the disk to report if the backend doesn't, -1 for the default
+	Refresh           bool          // if set read the directory tree in the background on start
+	RefreshDepth      int           // number of levels of directories to read for Refresh, -1 for all
+	RefreshInterval   time.Duration // if set read the directory tree again this often for Refresh
+	RefreshCheckers   int           // number of directories to read at once for Refresh
 }
 
 // DefaultOpt is the default values uses for Opt
@@ -60,4 +65,9 @@ var DefaultOpt = Options{
 	WriteBack:         5 * time.Second,
 	ReadAhead:         0 * fs.Mebi,
 	UsedIsSize:        false,
+	DiskSpaceTotal:    -1,
+	Refresh:           false,
+	RefreshDepth:      -1,
+	RefreshInterval:   0,
+	RefreshCheckers:   4,
 }
@@ -38,5 +38,10 @@ func AddFlags(flagSet *pflag.FlagSet) {
 	flags.DurationVarP(flagSet, &Opt.WriteBack, "vfs-write-back", "", Opt.WriteBack, "Time to writeback files after last use when using cache")
 	flags.FVarP(flagSet, &Opt.ReadAhead, "vfs-read-ahead", "", "Extra read ahead over --buffer-size when using cache-mode full")
 	flags.BoolVarP(flagSet, &Opt.UsedIsSize, "vfs-used-is-size", "", Opt.UsedIsSize, "Use the `rclone size` algorithm for Used size")
+	flags.FVarP(flagSet, &Opt.DiskSpaceTotal, "vfs-disk-space-total-size", "", "Total size of the disk to report if the backend doesn't ('off' is 1 PiB)")
+	flags.BoolVarP(flagSet, &Opt.Refresh, "vfs-refresh", "", Opt.Refresh, "Read the directory tree in the background on start to fill the directory cache")
+	flags.IntVarP(flagSet, &Opt.RefreshDepth, "vfs-refresh-depth", "", Opt.RefreshDepth, "Number of levels of directories to read with --vfs-refresh (-1 for all)")
+	flags.DurationVarP(flagSet, &Opt.RefreshInterval, "vfs-refresh-interval", "", Opt.RefreshInterval, "Read the directory tree again this often with --vfs-refresh (0 for only on start)")
+	flags.IntVarP(flagSet, &Opt.RefreshCheckers, "vfs-refresh-checkers", "", Opt.RefreshCheckers, "Number of directories to read at once with --vfs-refresh")
 	platformFlags(flagSet)
 }